@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+var allocationCmd = &cobra.Command{
+	Use:   "allocation",
+	Short: "Export a usage-weighted cost allocation of Copilot premium requests",
+	Long: `Export the Copilot premium requests of every cost center member for one
+month, with each user's share of the enterprise total, for chargeback
+models based on consumption rather than flat seat counts.
+
+Every user assigned to an active cost center is looked up in the premium
+request usage report of the enhanced billing platform.  Each row holds the
+user, the cost center (name and ID), the user's requests before the
+included allowance is deducted, and the user's share of all requests in
+percent.  Users outside any cost center are not listed.
+
+The format follows the --out file extension (.csv, otherwise JSON); "-"
+writes JSON to stdout.
+
+Examples:
+  gh cost-center allocation --month 2026-09 --out allocation.csv
+  gh cost-center allocation | jq 'group_by(.cost_center) | map({cc: .[0].cost_center, share: (map(.share_pct) | add)})'`,
+	RunE: runAllocation,
+}
+
+var (
+	allocationMonth string
+	allocationOut   string
+)
+
+func init() {
+	allocationCmd.Flags().StringVar(&allocationMonth, "month", "", "month to allocate as YYYY-MM (default: the current month)")
+	allocationCmd.Flags().StringVar(&allocationOut, "out", "-", "write the allocation to a .json/.csv file, or - for stdout")
+	allocationCmd.Flags().BoolVar(&skipPermissionCheck, "skip-permission-check", false, "do not check the token's scopes before the export")
+
+	rootCmd.AddCommand(allocationCmd)
+}
+
+// allocationRow is one user's share of the premium requests.
+type allocationRow struct {
+	User         string  `json:"user"`
+	CostCenter   string  `json:"cost_center"`
+	CostCenterID string  `json:"cost_center_id"`
+	Requests     float64 `json:"requests"`
+	SharePct     float64 `json:"share_pct"`
+}
+
+// parseAllocationMonth parses --month, defaulting to the month of now.
+func parseAllocationMonth(s string, now time.Time) (year, month int, err error) {
+	if s == "" {
+		return now.Year(), int(now.Month()), nil
+	}
+	t, err := time.Parse("2006-01", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid --month %q: want YYYY-MM", s)
+	}
+	return t.Year(), int(t.Month()), nil
+}
+
+// computeAllocation builds one row per cost center member, sorted by cost
+// center and user.  members maps cost center name → logins, active name →
+// ID, and usage login → requests.  Shares are of the total across all rows
+// and are 0 when nobody made a request.
+func computeAllocation(members map[string][]string, active map[string]string, usage map[string]float64) []allocationRow {
+	var rows []allocationRow
+	var total float64
+	for name, logins := range members {
+		for _, login := range logins {
+			rows = append(rows, allocationRow{
+				User:         login,
+				CostCenter:   name,
+				CostCenterID: active[name],
+				Requests:     usage[login],
+			})
+			total += usage[login]
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].CostCenter != rows[j].CostCenter {
+			return rows[i].CostCenter < rows[j].CostCenter
+		}
+		return rows[i].User < rows[j].User
+	})
+	if total > 0 {
+		for i := range rows {
+			rows[i].SharePct = rows[i].Requests / total * 100
+		}
+	}
+	return rows
+}
+
+// writeAllocation writes rows as "json" (an array) or "csv" (with a header).
+func writeAllocation(w io.Writer, rows []allocationRow, format string) error {
+	switch format {
+	case "json":
+		if rows == nil {
+			rows = []allocationRow{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	case "csv":
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"user", "cost_center", "cost_center_id", "requests", "share_pct"})
+		for _, r := range rows {
+			_ = cw.Write([]string{
+				r.User, r.CostCenter, r.CostCenterID,
+				strconv.FormatFloat(r.Requests, 'f', -1, 64),
+				strconv.FormatFloat(r.SharePct, 'f', 4, 64),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unsupported allocation format %q: must be 'json' or 'csv'", format)
+	}
+}
+
+func runAllocation(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	year, month, err := parseAllocationMonth(allocationMonth, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	logger := slog.Default()
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	if err := checkPermissions(ctx, client, "allocation", []string{cfgManager.CostCenterMode}, false); err != nil {
+		return err
+	}
+
+	active, err := client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching active cost centers: %w", err)
+	}
+	members := make(map[string][]string, len(active))
+	var logins []string
+	for name, id := range active {
+		users, err := client.GetCostCenterMembers(ctx, id)
+		if err != nil {
+			return fmt.Errorf("fetching members of cost center %q: %w", name, err)
+		}
+		members[name] = users
+		logins = append(logins, users...)
+	}
+
+	logger.Info("Fetching premium request usage", "users", len(logins), "year", year, "month", month)
+	usage, err := client.GetPremiumRequestsByUser(ctx, logins, year, month)
+	if err != nil {
+		return err
+	}
+	rows := computeAllocation(members, active, usage)
+
+	format := "json"
+	if filepath.Ext(allocationOut) == ".csv" {
+		format = "csv"
+	}
+	var w io.Writer = os.Stdout
+	if allocationOut != "-" {
+		f, err := os.Create(allocationOut)
+		if err != nil {
+			return fmt.Errorf("creating %s: %w", allocationOut, err)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+	if err := writeAllocation(w, rows, format); err != nil {
+		return fmt.Errorf("writing allocation: %w", err)
+	}
+	if allocationOut != "-" {
+		logger.Info("Allocation exported", "path", allocationOut, "rows", len(rows))
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAllocationMonth(t *testing.T) {
+	now := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	if y, m, err := parseAllocationMonth("", now); err != nil || y != 2026 || m != 10 {
+		t.Errorf("default = %d-%d, %v; want 2026-10", y, m, err)
+	}
+	if y, m, err := parseAllocationMonth("2025-03", now); err != nil || y != 2025 || m != 3 {
+		t.Errorf("2025-03 = %d-%d, %v", y, m, err)
+	}
+	if _, _, err := parseAllocationMonth("March", now); err == nil {
+		t.Error("expected an error for a malformed month")
+	}
+}
+
+func TestComputeAllocation(t *testing.T) {
+	members := map[string][]string{
+		"Platform": {"bob", "alice"},
+		"Data":     {"carol"},
+	}
+	active := map[string]string{"Platform": "id-p", "Data": "id-d"}
+	usage := map[string]float64{"alice": 30, "bob": 10, "carol": 60}
+
+	rows := computeAllocation(members, active, usage)
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(rows))
+	}
+	want := []allocationRow{
+		{User: "carol", CostCenter: "Data", CostCenterID: "id-d", Requests: 60, SharePct: 60},
+		{User: "alice", CostCenter: "Platform", CostCenterID: "id-p", Requests: 30, SharePct: 30},
+		{User: "bob", CostCenter: "Platform", CostCenterID: "id-p", Requests: 10, SharePct: 10},
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, rows[i], want[i])
+		}
+	}
+
+	// Without any usage every share is 0 rather than NaN.
+	for _, r := range computeAllocation(members, active, nil) {
+		if r.SharePct != 0 {
+			t.Errorf("share of %s = %v, want 0", r.User, r.SharePct)
+		}
+	}
+}
+
+func TestWriteAllocation_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	rows := []allocationRow{{User: "alice", CostCenter: "Platform", CostCenterID: "id-p", Requests: 12.5, SharePct: 100}}
+	if err := writeAllocation(&buf, rows, "csv"); err != nil {
+		t.Fatalf("writeAllocation: %v", err)
+	}
+	want := "user,cost_center,cost_center_id,requests,share_pct\nalice,Platform,id-p,12.5,100.0000\n"
+	if buf.String() != want {
+		t.Errorf("csv =\n%s\nwant\n%s", buf.String(), want)
+	}
+	if err := writeAllocation(&buf, nil, "xml"); err == nil || !strings.Contains(err.Error(), "xml") {
+		t.Errorf("err = %v, want unsupported format", err)
+	}
+}
@@ -2,18 +2,29 @@ package cmd
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/renan-alm/gh-cost-center/internal/audit"
 	"github.com/renan-alm/gh-cost-center/internal/cache"
+	"github.com/renan-alm/gh-cost-center/internal/checkpoint"
+	"github.com/renan-alm/gh-cost-center/internal/config"
 	"github.com/renan-alm/gh-cost-center/internal/customprop"
 	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
+	"github.com/renan-alm/gh-cost-center/internal/progress"
 	"github.com/renan-alm/gh-cost-center/internal/pru"
 	"github.com/renan-alm/gh-cost-center/internal/repository"
+	"github.com/renan-alm/gh-cost-center/internal/state"
 	"github.com/renan-alm/gh-cost-center/internal/teams"
 )
 
@@ -26,6 +37,20 @@ var (
 	assignCreateCC       bool
 	assignCreateBudgets  bool
 	assignCheckCurrentCC bool
+	assignModes          string
+	assignResultsFile    string
+	assignPermReport     bool
+	assignFormat         string
+	assignOut            string
+	assignTerraformOut   string
+	assignBudgetImpact   bool
+	assignPlanFile       string
+	assignNoSnapshot     bool
+	assignProgress       string
+	assignResume         bool
+	assignChangedTeams   bool
+	assignOrgs           bool
+	skipPermissionCheck  bool
 )
 
 var assignCmd = &cobra.Command{
@@ -38,6 +63,22 @@ The mode is determined by cost_center.mode in config.yaml:
   teams:           Assigns users based on GitHub team membership.
   repos:           Assigns repos based on custom property values (explicit mappings).
   custom-prop:     Assigns repos using custom property filters (AND logic).
+  idp-groups:      Assigns users based on identity provider group membership (EMU).
+  orgs:            Assigns every organization member to a per-organization cost center.
+  seat-org:        Assigns Copilot users by the organization or team that granted their seat.
+  rules:           Assigns Copilot users by ordered rules; the first match decides.
+  email-domain:    Assigns Copilot users by the domain of their verified IdP email (EMU).
+
+With cost_center.sources set, the users of the listed sources (overrides
+file, teams, users) are merged instead, in priority order: the first source
+that places a user wins.
+
+Use --modes to run several of these in one invocation with a shared client;
+cost center lists, members and memberships are read once per run.  Each
+mode's settings must be present in config.yaml.  A combined summary is
+printed at the end.  --results-file writes the outcome of each mode (or of
+the plan file or sources run) as JSON, with the users assigned and failed
+in each cost center.
 
 The --mode flag controls execution:
   plan  - Preview changes without applying (default)
@@ -54,45 +95,504 @@ Examples:
   gh cost-center assign --mode apply --yes --create-cost-centers
 
   # Process only new users since last run (users mode)
-  gh cost-center assign --mode apply --yes --incremental`,
+  gh cost-center assign --mode apply --yes --incremental
+
+  # Show which token permissions the run needed (to tighten the token)
+  gh cost-center assign --mode plan --permission-report
+
+  # Every organization member to their organization's cost center
+  gh cost-center assign --mode plan --orgs
+
+  # Teams then repos in one run, with a combined results file
+  gh cost-center assign --mode apply --yes --modes teams,repos --results-file results.json
+
+  # Plan as a markdown table for a pull request comment (other output goes to stderr)
+  gh cost-center assign --mode plan --format markdown > plan.md
+
+  # Save a plan for review, then apply exactly that plan later
+  gh cost-center assign --mode plan --out plan.json
+  gh cost-center assign --mode apply --plan-file plan.json
+
+  # Continue an apply that was interrupted, skipping writes that went through
+  gh cost-center assign --mode apply --yes --resume
+
+  # NDJSON progress events on file descriptor 3, for a wrapping UI
+  gh cost-center assign --mode apply --yes --progress-json fd:3 3>progress.ndjson`,
 	RunE: runAssign,
 }
 
 func init() {
 	assignCmd.Flags().StringVar(&assignMode, "mode", "plan", "execution mode: plan (preview) or apply (push changes)")
-	assignCmd.Flags().BoolVarP(&assignYes, "yes", "y", false, "skip confirmation prompt in apply mode")
-	assignCmd.Flags().StringVar(&assignUsers, "users", "", "comma-separated list of specific users to process")
-	assignCmd.Flags().BoolVar(&assignIncremental, "incremental", false, "only process users added since last run (users mode)")
-	assignCmd.Flags().BoolVar(&assignCreateCC, "create-cost-centers", false, "create cost centers if they don't exist")
-	assignCmd.Flags().BoolVar(&assignCreateBudgets, "create-budgets", false, "create budgets for new cost centers")
-	assignCmd.Flags().BoolVar(&assignCheckCurrentCC, "check-current", false, "check current cost center membership before assigning")
-
+	addAssignFlags(assignCmd, "")
 	rootCmd.AddCommand(assignCmd)
 }
 
-// runAssign dispatches to the appropriate assignment mode based on config.
-func runAssign(cmd *cobra.Command, _ []string) error {
+// addAssignFlags registers the flags of assign on cmd.  With only set to
+// "plan" or "apply", the flags that have no meaning in the other execution
+// mode are left out; --mode itself is registered on assign alone.
+func addAssignFlags(cmd *cobra.Command, only string) {
+	f := cmd.Flags()
+	f.StringVar(&assignUsers, "users", "", "comma-separated list of specific users to process")
+	f.BoolVar(&assignIncremental, "incremental", false, "only process users added since last run (users mode)")
+	f.BoolVar(&assignCreateCC, "create-cost-centers", false, "create cost centers if they don't exist")
+	f.BoolVar(&assignCreateBudgets, "create-budgets", false, "create budgets for new cost centers")
+	f.BoolVar(&assignCheckCurrentCC, "check-current", false, "check current cost center membership of users (or repositories in repos mode) before assigning")
+	f.BoolVar(&assignOrgs, "orgs", false, "assign every member of github.organizations to a per-organization cost center (same as --modes orgs)")
+	f.StringVar(&assignModes, "modes", "", "comma-separated cost center modes to run in sequence (overrides cost_center.mode), e.g. teams,repos")
+	f.StringVar(&assignResultsFile, "results-file", "", "write the run's results, per mode, cost center and user, as JSON to this file")
+	f.BoolVar(&assignPermReport, "permission-report", false, "after the run, report token permissions exercised versus granted")
+	f.BoolVar(&skipPermissionCheck, "skip-permission-check", false, "do not check the token's scopes before the run")
+	f.StringVar(&assignProgress, "progress-json", "", "write NDJSON progress events to a file, or to an inherited file descriptor as fd:N")
+	f.BoolVar(&assignChangedTeams, "changed-teams-only", false, "revalidate team member lists with their recorded ETags and reuse the recorded members of unchanged teams (teams mode)")
+	if only != "apply" {
+		f.StringVar(&assignFormat, "format", "text", "plan output format: text or markdown (markdown goes to stdout, everything else to stderr)")
+		f.StringVar(&assignOut, "out", "", "save the computed plan to this JSON file (plan mode)")
+		f.StringVar(&assignTerraformOut, "terraform-out", "", "export the desired cost centers and members as Terraform locals to this file: HCL, or Terraform JSON when it ends in .json (plan mode)")
+		f.BoolVar(&assignBudgetImpact, "budget-impact", false, "estimate what the plan does to each cost center's Copilot budget at budgets.seat_cost per seat (plan mode)")
+	}
+	if only != "plan" {
+		f.BoolVarP(&assignYes, "yes", "y", false, "skip confirmation prompt in apply mode")
+		f.BoolVar(&assignNoSnapshot, "no-snapshot", false, "do not capture a membership snapshot before applying")
+		f.BoolVar(&assignResume, "resume", false, "resume an interrupted apply from its checkpoint, skipping resources it already added")
+		f.StringVar(&assignPlanFile, "plan-file", "", "apply exactly the changes in a plan file written by --out (apply mode)")
+	}
+}
+
+// runAssign dispatches to the appropriate assignment mode based on config, or
+// runs each of --modes in turn with a shared client.
+func runAssign(cmd *cobra.Command, _ []string) (err error) {
+	ctx := cmd.Context()
 	if assignMode != "plan" && assignMode != "apply" {
 		return fmt.Errorf("invalid --mode %q: must be 'plan' or 'apply'", assignMode)
 	}
+	if assignFormat != "text" && assignFormat != "markdown" {
+		return fmt.Errorf("invalid --format %q: must be 'text' or 'markdown'", assignFormat)
+	}
+	if assignOut != "" && assignMode != "plan" {
+		return fmt.Errorf("--out requires --mode plan")
+	}
+	if assignTerraformOut != "" && assignMode != "plan" {
+		return fmt.Errorf("--terraform-out requires --mode plan")
+	}
+	if assignBudgetImpact {
+		if assignMode != "plan" {
+			return fmt.Errorf("--budget-impact requires --mode plan")
+		}
+		if cfgManager.BudgetSeatCost == 0 {
+			return fmt.Errorf("--budget-impact requires budgets.seat_cost, the monthly cost of one Copilot seat")
+		}
+	}
+	if assignResume && assignMode != "apply" {
+		return fmt.Errorf("--resume requires --mode apply")
+	}
+	var plan *planFile
+	if assignPlanFile != "" {
+		if assignMode != "apply" {
+			return fmt.Errorf("--plan-file requires --mode apply")
+		}
+		if assignModes != "" {
+			return fmt.Errorf("--plan-file cannot be combined with --modes; the plan records its modes")
+		}
+		if plan, err = readPlanFile(assignPlanFile, cfgManager.Enterprise); err != nil {
+			return err
+		}
+	}
+	var markdown io.Writer
+	if assignFormat == "markdown" {
+		if assignMode != "plan" {
+			return fmt.Errorf("--format markdown requires --mode plan")
+		}
+		// Keep stdout for the markdown alone so it can be piped into a PR
+		// comment; the usual human-readable output moves to stderr.
+		stdout := os.Stdout
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = stdout }()
+		markdown = stdout
+	}
+
+	if assignOrgs {
+		if assignModes != "" {
+			return fmt.Errorf("--orgs cannot be combined with --modes; list orgs in --modes instead")
+		}
+		assignModes = "orgs"
+	}
+	modes := []string{cfgManager.CostCenterMode}
+	if assignModes != "" {
+		modes = parseModes(assignModes)
+		if len(modes) == 0 {
+			return fmt.Errorf("--modes requires at least one mode")
+		}
+		if err := cfgManager.ResolveModes(modes); err != nil {
+			return fmt.Errorf("invalid --modes: %w", err)
+		}
+	}
+	if plan != nil {
+		modes = plan.Modes
+	} else if len(cfgManager.Sources) > 0 {
+		if assignModes != "" {
+			return fmt.Errorf("--modes cannot be combined with cost_center.sources; the sources decide which modes run")
+		}
+		if assignIncremental || assignUsers != "" {
+			return fmt.Errorf("--incremental and --users are not supported with cost_center.sources")
+		}
+		modes = sourceModes()
+	}
+
+	logger := slog.Default()
 
-	switch cfgManager.CostCenterMode {
+	prog, err := progress.Open(assignProgress)
+	if err != nil {
+		return err
+	}
+	prog.Emit(progress.PhaseStart, 0, len(modes), assignMode)
+	defer func() {
+		status := "ok"
+		if err != nil {
+			status = err.Error()
+		}
+		prog.Emit(progress.PhaseDone, len(modes), len(modes), status)
+		if cerr := prog.Close(); cerr != nil {
+			logger.Warn("Progress events", "error", cerr)
+		}
+	}()
+
+	// Create GitHub API client.
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	attachCache(client, logger)
+	defer func() {
+		client.FlushBucketCache()
+		if hits, misses := client.HTTPCacheStats(); hits+misses > 0 {
+			logger.Info("HTTP cache", "not_modified", hits, "fetched", misses)
+		}
+		logSchemaDrift(client, logger)
+	}()
+	client.SetProgress(prog)
+
+	if err := checkPermissions(ctx, client, "assign", modes, assignMode == "apply"); err != nil {
+		return err
+	}
+
+	sink, err := attachAuditSink(client, logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := sink.Close(); cerr != nil {
+			logger.Warn("Audit sink", "error", cerr)
+		}
+	}()
+
+	// Share cost center and membership reads across every manager in the run.
+	client.SetRunCache(github.NewRunCache())
+	client.SetCreationRecorder(cfgManager)
+
+	if assignMode == "plan" {
+		defer func() {
+			if err == nil {
+				err = writePlanOutputs(ctx, client, modes, markdown, logger)
+			}
+		}()
+	}
+
+	if assignMode == "apply" && len(cfgManager.ValidatorCommand) > 0 {
+		if err := validatePlan(ctx, client, plan, modes, logger); err != nil {
+			return err
+		}
+	}
+
+	resuming := false
+	if assignMode == "apply" {
+		cp, resumed, cerr := openCheckpoint(logger)
+		if cerr != nil {
+			return cerr
+		}
+		resuming = resumed
+		client.SetCheckpoint(cp)
+		defer func() {
+			if err == nil {
+				if rerr := cp.Remove(); rerr != nil {
+					logger.Warn("Apply checkpoint", "error", rerr)
+				}
+			}
+		}()
+	}
+
+	if resuming {
+		logger.Info("Resuming: keeping the pre-apply snapshot of the interrupted run")
+	} else if assignMode == "apply" && !assignNoSnapshot {
+		prog.Emit(progress.PhaseSnapshot, 0, 1, "")
+		if _, err := takeSnapshot(ctx, client, state.ReasonPreApply, logger); err != nil {
+			return fmt.Errorf("%w (use --no-snapshot to apply without one)", err)
+		}
+		prog.Emit(progress.PhaseSnapshot, 1, 1, "")
+	}
+
+	assignAborted = false
+	j := openJournal(client, logger)
+	// Journaled writes are re-sent after the run's own, confirmed changes,
+	// and only those the current plan does not decide.
+	replay := func() error {
+		prog.Emit(progress.PhaseReplay, 0, 1, "")
+		if err := replayJournal(ctx, client, j, modes, logger); err != nil {
+			return err
+		}
+		prog.Emit(progress.PhaseReplay, 1, 1, "")
+		return nil
+	}
+
+	if assignPermReport {
+		defer func() { client.PermissionReport().Print() }()
+	}
+
+	if plan != nil {
+		// A plan file applies exactly the reviewed changes: journaled
+		// writes are kept for a later run.
+		if n := len(j.Transient()); n > 0 && assignMode == "apply" {
+			logger.Info("Not re-attempting journaled writes with --plan-file", "entries", n, "journal", j.FilePath())
+		}
+		return runRecorded(client, strings.Join(plan.Modes, "+"), func() error {
+			return runPlanFileApply(ctx, client, plan, assignPlanFile)
+		})
+	}
+	if len(cfgManager.Sources) > 0 {
+		if err := runRecorded(client, "sources", func() error {
+			return runSourcesAssign(ctx, client, prog)
+		}); err != nil {
+			return err
+		}
+		return replay()
+	}
+
+	if len(modes) == 1 && assignResultsFile == "" {
+		prog.Emit(progress.PhaseMode, 0, 1, modes[0])
+		if err := runAssignMode(ctx, modes[0], client); err != nil {
+			return err
+		}
+		prog.Emit(progress.PhaseMode, 1, 1, modes[0])
+		return replay()
+	}
+
+	started := time.Now().UTC()
+	outcomes := make([]modeOutcome, 0, len(modes))
+	failed := 0
+	for i, mode := range modes {
+		logger.Info("Running assignment mode", "mode", mode)
+		prog.Emit(progress.PhaseMode, i, len(modes), mode)
+		cfgManager.CostCenterMode = mode
+
+		o := runOutcome(client, mode, func() error { return runAssignMode(ctx, mode, client) })
+		if o.Error != "" {
+			failed++
+			logger.Error("Assignment mode failed", "mode", mode, "error", o.Error)
+		}
+		outcomes = append(outcomes, o)
+		prog.Emit(progress.PhaseMode, i+1, len(modes), mode)
+	}
+
+	printCombinedSummary(os.Stdout, outcomes)
+
+	if assignResultsFile != "" {
+		if err := writeAssignResults(assignResultsFile, started, outcomes); err != nil {
+			return err
+		}
+		logger.Info("Combined results written", "path", assignResultsFile)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d assignment modes failed", failed, len(modes))
+	}
+	return replay()
+}
+
+// runOutcome runs one mode of an assign run with run and records how it
+// ended, with the apply results it added.
+func runOutcome(client *github.Client, mode string, run func() error) modeOutcome {
+	start := time.Now()
+	applied := len(client.ApplyResults())
+	err := run()
+	o := modeOutcome{
+		Mode:            mode,
+		Status:          "ok",
+		DurationSeconds: time.Since(start).Seconds(),
+		CostCenters:     client.ApplyResults()[applied:],
+	}
+	if err != nil {
+		o.Status = "failed"
+		o.Error = err.Error()
+	}
+	return o
+}
+
+// runRecorded runs an assign run that is not split by mode, such as a plan
+// file or cost_center.sources, and writes its outcome to --results-file
+// when set.  Its error is returned unchanged.
+func runRecorded(client *github.Client, mode string, run func() error) error {
+	if assignResultsFile == "" {
+		return run()
+	}
+	started := time.Now().UTC()
+	var err error
+	o := runOutcome(client, mode, func() error {
+		err = run()
+		return err
+	})
+	if werr := writeAssignResults(assignResultsFile, started, []modeOutcome{o}); werr != nil {
+		return errors.Join(err, werr)
+	}
+	slog.Default().Info("Results written", "path", assignResultsFile)
+	return err
+}
+
+// runAssignMode runs a single assignment mode.
+func runAssignMode(ctx context.Context, mode string, client *github.Client) error {
+	switch mode {
 	case "teams":
-		return runTeamsAssign(cmd)
+		return runTeamsAssign(ctx, client)
 	case "repos":
-		return runRepoAssign(cmd)
+		return runRepoAssign(ctx, client)
 	case "custom-prop":
-		return runCustomPropAssign(cmd)
+		return runCustomPropAssign(ctx, client)
+	case "idp-groups":
+		return runIdPGroupsAssign(ctx, client)
+	case "orgs":
+		return runOrgsAssign(ctx, client)
+	case "seat-org":
+		return runSeatOrgAssign(ctx, client)
+	case "rules":
+		return runRulesAssign(ctx, client)
+	case "email-domain":
+		return runEmailDomainAssign(ctx, client)
 	default:
 		// "users" (PRU) is the default
-		return runPRUAssign(cmd)
+		return runPRUAssign(ctx, client)
+	}
+}
+
+// openCheckpoint starts the apply checkpoint in the export dir or, with
+// --resume, loads the one an interrupted apply left behind.  resumed is
+// false when there was nothing to resume.
+func openCheckpoint(logger *slog.Logger) (cp *checkpoint.Checkpoint, resumed bool, err error) {
+	dir := cfgManager.ExportDir
+	if assignResume {
+		cp, err := checkpoint.Resume(dir, cfgManager.Enterprise, logger)
+		switch {
+		case err == nil:
+			logger.Info("Resuming interrupted apply",
+				"started_at", cp.StartedAt().Format(time.RFC3339),
+				"users_done", cp.Count(checkpoint.KindUsers),
+				"repositories_done", cp.Count(checkpoint.KindRepositories))
+			return cp, true, nil
+		case errors.Is(err, os.ErrNotExist):
+			logger.Warn("No interrupted apply to resume, applying in full", "path", checkpoint.Path(dir))
+		default:
+			return nil, false, fmt.Errorf("%w (delete it to apply in full)", err)
+		}
+	} else if checkpoint.Exists(dir) {
+		logger.Warn("An earlier apply was interrupted; starting over (use --resume to continue it)",
+			"path", checkpoint.Path(dir))
+	}
+	cp, err = checkpoint.Start(dir, cfgManager.Enterprise, logger)
+	if err != nil {
+		return nil, false, fmt.Errorf("starting apply checkpoint: %w", err)
+	}
+	return cp, false, nil
+}
+
+// parseModes splits a comma-separated --modes value, dropping blanks.
+func parseModes(commaSep string) []string {
+	var modes []string
+	for _, m := range strings.Split(commaSep, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			modes = append(modes, m)
+		}
+	}
+	return modes
+}
+
+// modeOutcome records how one mode of a multi-mode assign run ended.
+type modeOutcome struct {
+	Mode            string  `json:"mode"`
+	Status          string  `json:"status"` // "ok" or "failed"
+	Error           string  `json:"error,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	// CostCenters holds the apply results of the mode, by name and ID.
+	CostCenters []github.CostCenterResult `json:"cost_centers,omitempty"`
+}
+
+// assignResults is the --results-file document.
+type assignResults struct {
+	Enterprise string        `json:"enterprise"`
+	Mode       string        `json:"mode"` // plan or apply
+	StartedAt  time.Time     `json:"started_at"`
+	Modes      []modeOutcome `json:"modes"`
+}
+
+// printCombinedSummary prints one line per mode after a multi-mode run,
+// followed by the mode's results per cost center in apply mode.
+func printCombinedSummary(w io.Writer, outcomes []modeOutcome) {
+	_, _ = fmt.Fprintf(w, "\n%s\n", i18n.T("summary.combined.title"))
+	for _, o := range outcomes {
+		line := fmt.Sprintf("%-12s %-6s %6.1fs", o.Mode, o.Status, o.DurationSeconds)
+		if o.Error != "" {
+			line += "  " + o.Error
+		}
+		_, _ = fmt.Fprintln(w, line)
+		for _, r := range o.CostCenters {
+			_, _ = fmt.Fprintln(w, "  "+i18n.T("summary.success.cc_result", r.Label(), r.Succeeded, r.Failed))
+		}
+	}
+}
+
+// writeAssignResults writes the combined results of an assign run as JSON.
+func writeAssignResults(path string, started time.Time, outcomes []modeOutcome) error {
+	data, err := json.MarshalIndent(assignResults{
+		Enterprise: cfgManager.Enterprise,
+		Mode:       assignMode,
+		StartedAt:  started,
+		Modes:      outcomes,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding results: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing results file %s: %w", path, err)
+	}
+	return nil
+}
+
+// logSchemaDrift summarises, with counts, how the billing API responses of
+// the run differed from the fields the client expects.
+func logSchemaDrift(client *github.Client, logger *slog.Logger) {
+	for _, f := range client.SchemaDrift() {
+		logger.Warn("API schema drift",
+			"response", f.Response, "field", f.Field, "kind", f.Kind, "count", f.Count)
 	}
 }
 
-// attachCache creates a file-based cost center cache and attaches it to the
-// GitHub client.  Errors during cache creation are logged but do not abort
-// the run — the client will simply skip caching.
+// attachCache creates a file-based cost center cache, the team member and
+// seat buckets, and the HTTP response cache for conditional GETs, and
+// attaches them to the GitHub client.  Errors during cache creation are
+// logged but do not abort the run — the client will simply skip caching.
 func attachCache(client *github.Client, logger *slog.Logger) {
+	store := cache.NewHTTPStore("", logger)
+	if _, err := store.Prune(cache.DefaultHTTPMaxAge); err != nil {
+		logger.Debug("Could not prune HTTP response cache", "error", err)
+	}
+	client.SetHTTPCache(store)
+	logger.Debug("HTTP response cache attached", "path", store.Dir())
+
+	members, seats := cacheBuckets(logger)
+	if members.TTL() == 0 {
+		members = nil
+	}
+	if seats.TTL() == 0 {
+		seats = nil
+	}
+	client.SetBucketCache(members, seats)
+
 	cc, err := cache.New("", logger)
 	if err != nil {
 		logger.Warn("Could not initialise cost center cache, continuing without cache", "error", err)
@@ -102,8 +602,136 @@ func attachCache(client *github.Client, logger *slog.Logger) {
 	logger.Debug("Cost center cache attached", "path", cc.FilePath())
 }
 
+// attachAuditSink starts the audit sink configured under audit.sink, if
+// any, and attaches it to client.  The returned sink (nil when disabled)
+// must be closed to flush the last events.
+func attachAuditSink(client *github.Client, logger *slog.Logger) (*audit.Sink, error) {
+	if cfgManager.AuditSinkType == "" {
+		return nil, nil
+	}
+	sink, err := audit.NewSink(audit.Options{
+		Format:        cfgManager.AuditSinkType,
+		URL:           cfgManager.AuditSinkURL,
+		Token:         cfgManager.AuditSinkToken,
+		Index:         cfgManager.AuditSinkIndex,
+		SourceType:    cfgManager.AuditSinkSourceType,
+		BatchSize:     cfgManager.AuditSinkBatchSize,
+		FlushInterval: cfgManager.AuditSinkFlushInterval,
+	}, logger)
+	if err != nil {
+		return nil, fmt.Errorf("starting audit sink: %w", err)
+	}
+	client.SetAuditSink(sink)
+	logger.Info("Streaming audit events", "type", cfgManager.AuditSinkType, "url", cfgManager.AuditSinkURL)
+	return sink, nil
+}
+
+// checkPermissions fails fast when the token's scopes do not cover the API
+// areas the command will call in the given modes.  Commands that use cost
+// centers also fail fast when the enterprise cannot use the cost centers
+// API.  --skip-permission-check skips both checks.
+func checkPermissions(ctx context.Context, client *github.Client, command string, modes []string, apply bool) error {
+	if skipPermissionCheck {
+		return nil
+	}
+	var reqs []github.PermissionRequirement
+	settings := permissionSettings()
+	for _, mode := range modes {
+		reqs = append(reqs, github.RequiredPermissions(command, mode, settings, apply)...)
+	}
+	if err := client.CheckPermissions(ctx, reqs); err != nil {
+		return fmt.Errorf("permission pre-check failed (use --skip-permission-check to bypass): %w", err)
+	}
+	if github.RequiresBilling(reqs) {
+		return probeCostCenters(ctx, client, modes)
+	}
+	return nil
+}
+
+// pruPlannedChanges returns the first-run preview for users mode.
+func pruPlannedChanges(mgr *pru.Manager, users []github.CopilotUser) []plannedChange {
+	exceptions, server, windDown, skipped := 0, 0, 0, 0
+	for _, u := range users {
+		switch {
+		case mgr.Skips(u):
+			skipped++
+		case mgr.WindsDownPendingCancellations() && pru.IsPendingCancellation(u):
+			windDown++
+		case mgr.SegregatesServerUsers() && mgr.IsServerConnected(u.Login):
+			server++
+		case mgr.IsException(u.Login):
+			exceptions++
+		}
+	}
+	changes := []plannedChange{
+		{CostCenter: cfgManager.NoPRUsCostCenterName, Count: len(users) - exceptions - server - windDown - skipped, Unit: "users"},
+		{CostCenter: cfgManager.PRUsAllowedCostCenterName, Count: exceptions, Unit: "users"},
+	}
+	if mgr.SegregatesServerUsers() {
+		changes = append(changes, plannedChange{CostCenter: cfgManager.ServerCostCenterName, Count: server, Unit: "users"})
+	}
+	if mgr.WindsDownPendingCancellations() {
+		changes = append(changes, plannedChange{CostCenter: cfgManager.WindDownCostCenterName, Count: windDown, Unit: "users"})
+	}
+	return changes
+}
+
+// resolveServerCostCenter returns the ID of the cost center for segregated
+// server-connected users.
+func resolveServerCostCenter(ctx context.Context, client *github.Client, autoCreate bool) (string, error) {
+	return resolveExtraCostCenter(ctx, client, cfgManager.ServerCostCenterID, cfgManager.ServerCostCenterName,
+		"server-connected users", "cost_center.users.server_connected.cost_center_id", autoCreate)
+}
+
+// resolveWindDownCostCenter returns the ID of the cost center for users
+// with a pending seat cancellation.
+func resolveWindDownCostCenter(ctx context.Context, client *github.Client, autoCreate bool) (string, error) {
+	return resolveExtraCostCenter(ctx, client, cfgManager.WindDownCostCenterID, cfgManager.WindDownCostCenterName,
+		"wind-down", "cost_center.users.pending_cancellation.cost_center_id", autoCreate)
+}
+
+// resolveExtraCostCenter returns the ID of a users-mode cost center beyond
+// the two PRU ones: the configured UUID id, or the cost center found (or,
+// with autoCreate, created) by name.  what and idSetting name the cost
+// center and its ID setting in errors.
+func resolveExtraCostCenter(ctx context.Context, client *github.Client, id, name, what, idSetting string, autoCreate bool) (string, error) {
+	if github.IsValidCostCenterUUID(id) {
+		return id, nil
+	}
+	if autoCreate {
+		id, err := client.CreateCostCenter(ctx, name)
+		if err != nil {
+			return "", fmt.Errorf("creating %s cost center: %w", what, err)
+		}
+		return id, nil
+	}
+	active, err := client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		return "", fmt.Errorf("fetching active cost centers: %w", err)
+	}
+	id, ok := active[name]
+	if !ok {
+		return "", fmt.Errorf("%s cost center %q not found — set %s or use --create-cost-centers", what, name, idSetting)
+	}
+	return id, nil
+}
+
+// logPendingCancellations surfaces the users whose Copilot seat is pending
+// cancellation and how the run handles them.
+func logPendingCancellations(users []github.CopilotUser, logger *slog.Logger) {
+	pending := pru.PendingCancellations(users)
+	if len(pending) == 0 {
+		return
+	}
+	logger.Info("Copilot seats pending cancellation",
+		"count", len(pending), "handling", cfgManager.PendingCancellationHandling)
+	for _, u := range pending {
+		logger.Info("Seat pending cancellation", "user", u.Login, "date", u.PendingCancellationDate)
+	}
+}
+
 // runPRUAssign implements the default PRU-based assignment flow.
-func runPRUAssign(cmd *cobra.Command) error {
+func runPRUAssign(ctx context.Context, client *github.Client) error {
 	logger := slog.Default()
 
 	// Enable auto-creation if flag was passed.
@@ -118,46 +746,79 @@ func runPRUAssign(cmd *cobra.Command) error {
 	// Show configuration.
 	mgr.PrintConfigSummary(cfgManager, autoCreate)
 
-	// Create GitHub API client.
-	client, err := github.NewClient(cfgManager, logger)
+	keep, since, err := seatFilter()
 	if err != nil {
-		return fmt.Errorf("creating GitHub client: %w", err)
+		return err
+	}
+	if assignIncremental && since == nil {
+		logger.Info("Incremental mode: no previous timestamp found, processing all users")
 	}
-	attachCache(client, logger)
 
-	// Fetch Copilot users.
+	// Stream Copilot users, keeping only those this run processes.
 	logger.Info("Fetching Copilot license holders...")
-	users, err := client.GetCopilotUsers()
+	var users []github.CopilotUser
+	originalCount := 0
+	err = client.EachUniqueCopilotUser(ctx, func(u github.CopilotUser) error {
+		originalCount++
+		if keep(u) {
+			users = append(users, u)
+		}
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("fetching copilot users: %w", err)
 	}
-	logger.Info("Found Copilot license holders", "count", len(users))
+	logger.Info("Found Copilot license holders", "count", originalCount)
 
-	// Incremental processing: filter to new users since last run.
-	originalCount := len(users)
-	if assignIncremental {
-		ts, err := cfgManager.LoadLastRunTimestamp()
-		if err != nil {
-			return fmt.Errorf("loading last run timestamp: %w", err)
-		}
-		if ts != nil {
-			users = github.FilterUsersByTimestamp(users, *ts)
-			logger.Info("Incremental mode",
-				"new_users", len(users),
-				"total_users", originalCount,
-				"since", ts.Format("2006-01-02T15:04:05Z"),
-			)
-			if len(users) == 0 {
-				logger.Info("No new users found since last run — nothing to process")
-				if assignMode == "apply" {
-					if err := cfgManager.SaveLastRunTimestamp(nil); err != nil {
-						return fmt.Errorf("saving run timestamp: %w", err)
-					}
+	if since != nil {
+		logger.Info("Incremental mode",
+			"new_users", len(users),
+			"total_users", originalCount,
+			"since", since.Format("2006-01-02T15:04:05Z"),
+		)
+		if len(users) == 0 {
+			logger.Info("No new users found since last run — nothing to process")
+			if assignMode == "apply" {
+				if err := cfgManager.SaveLastRunTimestamp(nil); err != nil {
+					return fmt.Errorf("saving run timestamp: %w", err)
 				}
-				return nil
 			}
-		} else {
-			logger.Info("Incremental mode: no previous timestamp found, processing all users")
+			return nil
+		}
+	}
+	if assignUsers != "" {
+		logger.Info("Filtered to specified users", "count", len(users))
+	}
+	logPendingCancellations(users, logger)
+
+	// A first interactive apply gets an expanded preview before anything,
+	// including cost center creation, is changed.
+	firstRun, err := needsFirstRunConsent()
+	if err != nil {
+		return err
+	}
+	if firstRun {
+		var toCreate []string
+		if autoCreate {
+			names := []string{cfgManager.NoPRUsCostCenterName, cfgManager.PRUsAllowedCostCenterName}
+			if mgr.SegregatesServerUsers() && cfgManager.ServerCostCenterID == "" {
+				names = append(names, cfgManager.ServerCostCenterName)
+			}
+			if mgr.WindsDownPendingCancellations() && cfgManager.WindDownCostCenterID == "" {
+				names = append(names, cfgManager.WindDownCostCenterName)
+			}
+			toCreate, err = missingCostCenters(ctx, client, names)
+			if err != nil {
+				return err
+			}
+		}
+		proceed, err := confirmFirstRun(pruPlannedChanges(mgr, users), toCreate, nil)
+		if err != nil {
+			return fmt.Errorf("confirmation failed: %w", err)
+		}
+		if !proceed {
+			abortApply(logger, "Aborted by user before applying assignments")
+			return nil
 		}
 	}
 
@@ -170,6 +831,7 @@ func runPRUAssign(cmd *cobra.Command) error {
 		} else {
 			logger.Info("Creating cost centers if they don't exist...")
 			noPRUID, pruAllowedID, err := client.EnsureCostCentersExist(
+				ctx,
 				cfgManager.NoPRUsCostCenterName,
 				cfgManager.PRUsAllowedCostCenterName,
 			)
@@ -190,6 +852,7 @@ func runPRUAssign(cmd *cobra.Command) error {
 		// Without auto-create, resolve names to UUIDs.
 		logger.Info("Resolving cost center names to IDs...")
 		noPRUID, pruAllowedID, err := client.ResolveCostCenters(
+			ctx,
 			cfgManager.NoPRUsCostCenterName,
 			cfgManager.PRUsAllowedCostCenterName,
 		)
@@ -206,10 +869,21 @@ func runPRUAssign(cmd *cobra.Command) error {
 		)
 	}
 
-	// Filter to specific users if --users flag was provided.
-	if assignUsers != "" {
-		users = filterUsersByLogin(users, assignUsers)
-		logger.Info("Filtered to specified users", "count", len(users))
+	if mgr.SegregatesServerUsers() && assignMode != "plan" {
+		serverID, err := resolveServerCostCenter(ctx, client, autoCreate)
+		if err != nil {
+			return err
+		}
+		mgr.SetServerCostCenterID(serverID)
+		logger.Info("Server-connected users cost center", "name", cfgManager.ServerCostCenterName, "id", serverID)
+	}
+	if mgr.WindsDownPendingCancellations() && assignMode != "plan" {
+		windDownID, err := resolveWindDownCostCenter(ctx, client, autoCreate)
+		if err != nil {
+			return err
+		}
+		mgr.SetWindDownCostCenterID(windDownID)
+		logger.Info("Wind-down cost center", "name", cfgManager.WindDownCostCenterName, "id", windDownID)
 	}
 
 	// Build assignment groups.
@@ -222,19 +896,30 @@ func runPRUAssign(cmd *cobra.Command) error {
 	if assignMode == "plan" {
 		logger.Info("mode=plan: no changes will be made")
 		for _, u := range users {
+			if mgr.Skips(u) {
+				continue
+			}
 			cc := mgr.AssignCostCenter(u)
 			logger.Debug("Would assign", "user", u.Login, "cc", cc)
 		}
 	}
 
 	// Print assignment summary.
-	fmt.Printf("\n=== Assignment Summary ===\n")
-	fmt.Printf("PRUs Allowed (%s): %d users\n", mgr.PRUAllowedCCID(), pruCount)
-	fmt.Printf("No PRUs (%s): %d users\n", mgr.NoPRUCCID(), noPRUCount)
-	fmt.Printf("Total: %d users\n", len(users))
+	fmt.Printf("\n%s\n", i18n.T("summary.assign.title"))
+	fmt.Println(i18n.T("summary.assign.pru", mgr.PRUAllowedCCID(), pruCount))
+	fmt.Println(i18n.T("summary.assign.no_pru", mgr.NoPRUCCID(), noPRUCount))
+	if mgr.SegregatesServerUsers() {
+		fmt.Println(i18n.T("summary.assign.server", mgr.ServerCCID(), len(groups[mgr.ServerCCID()])))
+	}
+	if mgr.WindsDownPendingCancellations() {
+		fmt.Println(i18n.T("summary.assign.wind_down", mgr.WindDownCCID(), len(groups[mgr.WindDownCCID()])))
+	} else if mgr.SkipsPendingCancellations() {
+		fmt.Println(i18n.T("summary.assign.skipped", len(pru.PendingCancellations(users))))
+	}
+	fmt.Println(i18n.T("summary.assign.total", len(users)))
 
 	// Execute assignments.
-	var assignmentResults map[string]map[string]bool
+	var assignmentResults []github.CostCenterResult
 
 	if assignMode == "plan" {
 		logger.Info("Would sync full assignment state (plan mode)")
@@ -242,14 +927,15 @@ func runPRUAssign(cmd *cobra.Command) error {
 			logger.Info("Would add users to cost center", "cc", ccID, "count", len(usernames))
 		}
 	} else {
-		// Apply mode — safety confirmation unless --yes.
-		if !assignYes {
+		// Apply mode — safety confirmation unless --yes or already
+		// confirmed as a first run.
+		if !assignYes && !firstRun {
 			proceed, err := confirmApply(groups, assignCheckCurrentCC)
 			if err != nil {
 				return fmt.Errorf("confirmation failed: %w", err)
 			}
 			if !proceed {
-				logger.Warn("Aborted by user before applying assignments")
+				abortApply(logger, "Aborted by user before applying assignments")
 				return nil
 			}
 		}
@@ -268,14 +954,15 @@ func runPRUAssign(cmd *cobra.Command) error {
 			logger.Info("Applying full assignment state to GitHub Enterprise...")
 			// ignore_current_cost_center is the inverse of --check-current
 			ignoreCurrentCC := !assignCheckCurrentCC
-			results, err := client.BulkUpdateCostCenterAssignments(toSync, ignoreCurrentCC)
+			var err error
+			assignmentResults, err = client.BulkUpdateCostCenterAssignments(ctx, toSync, ignoreCurrentCC)
 			if err != nil {
 				return fmt.Errorf("applying assignments: %w", err)
 			}
-			assignmentResults = results
+			recordApply(logger)
 
 			// Process and log results.
-			if err := logAssignmentResults(results, logger); err != nil {
+			if err := logAssignmentResults(assignmentResults, logger); err != nil {
 				return err
 			}
 		}
@@ -303,24 +990,24 @@ func runPRUAssign(cmd *cobra.Command) error {
 // confirmApply shows a confirmation prompt and returns true if the user types "yes".
 // It returns an error if reading from stdin fails.
 func confirmApply(groups map[string][]string, checkCurrent bool) (bool, error) {
-	fmt.Println("\nYou are about to APPLY cost center assignments to GitHub Enterprise.")
-	fmt.Println("This will push assignments for ALL processed users (no diff).")
+	fmt.Println("\n" + i18n.T("confirm.apply.intro"))
+	fmt.Println(i18n.T("confirm.apply.no_diff"))
 
 	if checkCurrent {
-		fmt.Println("Current cost center membership will be checked — users in other cost centers will be SKIPPED.")
+		fmt.Println(i18n.T("confirm.apply.check"))
 	} else {
-		fmt.Println("Fast mode: Users will be assigned WITHOUT checking current cost center membership.")
+		fmt.Println(i18n.T("confirm.apply.fast"))
 	}
 
-	fmt.Println("Summary:")
+	fmt.Println(i18n.T("confirm.apply.summary"))
 	for ccID, usernames := range groups {
-		fmt.Printf("  - %s: %d users\n", ccID, len(usernames))
+		fmt.Println(i18n.T("confirm.apply.group", ccID, len(usernames)))
 	}
 
-	fmt.Print("\nProceed? (yes/no): ")
+	fmt.Print("\n" + i18n.T("confirm.proceed"))
 	scanner := bufio.NewScanner(os.Stdin)
 	if scanner.Scan() {
-		return strings.TrimSpace(strings.ToLower(scanner.Text())) == "yes", nil
+		return i18n.IsYes(scanner.Text()), nil
 	}
 	if err := scanner.Err(); err != nil {
 		return false, fmt.Errorf("reading user input: %w", err)
@@ -328,47 +1015,36 @@ func confirmApply(groups map[string][]string, checkCurrent bool) (bool, error) {
 	return false, nil
 }
 
-// logAssignmentResults logs per-cost-center and overall success/failure counts.
-// It returns an error when one or more user assignments failed so the caller
-// can propagate a non-zero exit code.
-func logAssignmentResults(results map[string]map[string]bool, logger *slog.Logger) error {
-	totalAttempted := 0
+// logAssignmentResults logs per-cost-center and overall success/failure
+// counts, naming each cost center alongside its ID.  It returns an error
+// when one or more user assignments failed so the caller can propagate a
+// non-zero exit code.
+func logAssignmentResults(results []github.CostCenterResult, logger *slog.Logger) error {
 	totalSuccessful := 0
 	totalFailed := 0
 
-	for ccID, userResults := range results {
-		ccSuccessful := 0
-		for _, ok := range userResults {
-			if ok {
-				ccSuccessful++
-			}
-		}
-		ccFailed := len(userResults) - ccSuccessful
-		totalAttempted += len(userResults)
-		totalSuccessful += ccSuccessful
-		totalFailed += ccFailed
+	for _, r := range results {
+		totalSuccessful += r.Succeeded
+		totalFailed += r.Failed
 
-		if ccFailed > 0 {
-			var failedUsers []string
-			for username, ok := range userResults {
-				if !ok {
-					failedUsers = append(failedUsers, username)
-				}
-			}
+		if r.Failed > 0 {
 			logger.Error("Cost center assignment failures",
-				"cost_center_id", ccID,
-				"successful", ccSuccessful,
-				"failed", ccFailed,
-				"failed_users", strings.Join(failedUsers, ", "),
+				"cost_center", r.Name,
+				"cost_center_id", r.ID,
+				"successful", r.Succeeded,
+				"failed", r.Failed,
+				"failed_users", strings.Join(r.FailedUsers, ", "),
 			)
 		} else {
 			logger.Info("Cost center all successful",
-				"cost_center_id", ccID,
-				"count", ccSuccessful,
+				"cost_center", r.Name,
+				"cost_center_id", r.ID,
+				"count", r.Succeeded,
 			)
 		}
 	}
 
+	totalAttempted := totalSuccessful + totalFailed
 	if totalFailed > 0 {
 		logger.Error("Assignment incomplete",
 			"successful", totalSuccessful,
@@ -385,23 +1061,24 @@ func logAssignmentResults(results map[string]map[string]bool, logger *slog.Logge
 }
 
 // runTeamsAssign implements the teams-based assignment flow.
-func runTeamsAssign(_ *cobra.Command) error {
+func runTeamsAssign(ctx context.Context, client *github.Client) error {
 	logger := slog.Default()
 
-	// Create GitHub API client.
-	client, err := github.NewClient(cfgManager, logger)
-	if err != nil {
-		return fmt.Errorf("creating GitHub client: %w", err)
-	}
-	attachCache(client, logger)
-
 	// Enable auto-creation if flag was passed.
 	if assignCreateCC {
 		cfgManager.EnableAutoCreation()
 	}
 
+	if err := teams.ResolveScope(ctx, cfgManager, client, logger); err != nil {
+		return err
+	}
 	// Initialize teams manager.
 	mgr := teams.NewManager(cfgManager, client, logger)
+	mgr.SetChangedTeamsOnly(assignChangedTeams)
+	if !assignYes && stdinIsTerminal() {
+		// Prompts go to stderr so they never mix into plan output on stdout.
+		mgr.SetConflictResolver(conflictPrompt(os.Stdin, os.Stderr, cfgManager.OverridesFile))
+	}
 
 	// Wire budget creation if requested.
 	if assignCreateBudgets && cfgManager.BudgetsEnabled {
@@ -411,12 +1088,71 @@ func runTeamsAssign(_ *cobra.Command) error {
 	// Show configuration.
 	mgr.PrintConfigSummary(assignCheckCurrentCC, assignCreateBudgets)
 
+	firstRun, err := needsFirstRunConsent()
+	if err != nil {
+		return err
+	}
+	// Emptying or deleting the cost centers of deleted teams is confirmed
+	// like a first run, listing them, unless --yes is set.
+	confirmRemovals := assignMode == "apply" && !assignYes && cfgManager.TeamsDeletedTeams != config.DeletedTeamsWarn
+	if !firstRun && confirmRemovals {
+		if _, err := mgr.BuildTeamAssignments(ctx); err != nil {
+			return fmt.Errorf("building team assignments: %w", err)
+		}
+		if toRemove := mgr.PreviewDeletedTeams(ctx); len(toRemove) > 0 {
+			proceed, err := confirmDeletedTeams(cfgManager.TeamsDeletedTeams, toRemove)
+			if err != nil {
+				return fmt.Errorf("confirmation failed: %w", err)
+			}
+			if !proceed {
+				abortApply(logger, "Aborted by user")
+				return nil
+			}
+		}
+	}
+	if firstRun {
+		assignments, err := mgr.BuildTeamAssignments(ctx)
+		if err != nil {
+			return fmt.Errorf("building team assignments: %w", err)
+		}
+		var toRemove []string
+		if confirmRemovals {
+			toRemove = mgr.PreviewDeletedTeams(ctx)
+		}
+		changes := make([]plannedChange, 0, len(assignments))
+		names := make([]string, 0, len(assignments))
+		for name, uas := range assignments {
+			users := make(map[string]bool, len(uas))
+			for _, ua := range uas {
+				users[ua.Username] = true
+			}
+			changes = append(changes, plannedChange{CostCenter: name, Count: len(users), Unit: "users"})
+			names = append(names, name)
+		}
+		var toCreate []string
+		if cfgManager.TeamsAutoCreate {
+			if toCreate, err = missingCostCenters(ctx, client, names); err != nil {
+				return err
+			}
+		}
+		proceed, err := confirmFirstRun(changes, toCreate, toRemove)
+		if err != nil {
+			return fmt.Errorf("confirmation failed: %w", err)
+		}
+		if !proceed {
+			abortApply(logger, "Aborted by user")
+			return nil
+		}
+	}
 	// Sync assignments (plan or apply).
 	ignoreCurrentCC := !assignCheckCurrentCC
-	results, err := mgr.SyncTeamAssignments(assignMode, ignoreCurrentCC)
+	results, err := mgr.SyncTeamAssignments(ctx, assignMode, ignoreCurrentCC)
 	if err != nil {
 		return fmt.Errorf("syncing team assignments: %w", err)
 	}
+	if assignMode == "apply" {
+		recordApply(logger)
+	}
 
 	if assignMode == "apply" {
 		if !assignYes && results == nil {
@@ -435,8 +1171,27 @@ func runTeamsAssign(_ *cobra.Command) error {
 	return nil
 }
 
+// confirmDeletedTeams lists the cost centers of deleted teams that action
+// (cost_center.teams.deleted_teams) would empty or delete and asks before
+// the teams apply.
+func confirmDeletedTeams(action string, names []string) (bool, error) {
+	fmt.Println("\n" + i18n.T("confirm.deleted."+action, len(names)))
+	for _, name := range names {
+		fmt.Printf("  - %s\n", name)
+	}
+	fmt.Print(i18n.T("confirm.proceed"))
+	scanner := bufio.NewScanner(os.Stdin)
+	if scanner.Scan() {
+		return i18n.IsYes(scanner.Text()), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("reading user input: %w", err)
+	}
+	return false, nil
+}
+
 // runRepoAssign implements the repository explicit-mapping assignment flow.
-func runRepoAssign(_ *cobra.Command) error {
+func runRepoAssign(ctx context.Context, client *github.Client) error {
 	logger := slog.Default()
 
 	if len(cfgManager.Organizations) == 0 {
@@ -444,12 +1199,6 @@ func runRepoAssign(_ *cobra.Command) error {
 	}
 	org := cfgManager.Organizations[0]
 
-	client, err := github.NewClient(cfgManager, logger)
-	if err != nil {
-		return fmt.Errorf("creating GitHub client: %w", err)
-	}
-	attachCache(client, logger)
-
 	mgr, err := repository.NewManager(cfgManager, client, logger)
 	if err != nil {
 		return fmt.Errorf("initializing repository manager: %w", err)
@@ -462,30 +1211,65 @@ func runRepoAssign(_ *cobra.Command) error {
 		return fmt.Errorf("invalid repository configuration: %d issues found", len(issues))
 	}
 
+	mgr.SetCheckCurrent(assignCheckCurrentCC)
 	mgr.PrintConfigSummary(org)
 
+	firstRun, err := needsFirstRunConsent()
+	if err != nil {
+		return err
+	}
+	if firstRun {
+		preview, err := mgr.Run(ctx, org, "plan", false)
+		if err != nil {
+			return fmt.Errorf("previewing repository assignment: %w", err)
+		}
+		var changes []plannedChange
+		var names []string
+		for _, r := range preview.MappingResults {
+			if r.ReposMatched > 0 {
+				changes = append(changes, plannedChange{CostCenter: r.CostCenter, Count: r.ReposMatched, Unit: "repositories"})
+				names = append(names, r.CostCenter)
+			}
+		}
+		toCreate, err := missingCostCenters(ctx, client, names)
+		if err != nil {
+			return err
+		}
+		proceed, err := confirmFirstRun(changes, toCreate, nil)
+		if err != nil {
+			return fmt.Errorf("confirmation failed: %w", err)
+		}
+		if !proceed {
+			abortApply(logger, "Aborted by user")
+			return nil
+		}
+	}
+
 	// Confirmation in apply mode.
-	if assignMode == "apply" && !assignYes {
-		fmt.Print("\nProceed with APPLY? (yes/no): ")
+	if assignMode == "apply" && !assignYes && !firstRun {
+		fmt.Print("\n" + i18n.T("confirm.proceed_apply"))
 		scanner := bufio.NewScanner(os.Stdin)
 		if !scanner.Scan() {
 			if err := scanner.Err(); err != nil {
 				return fmt.Errorf("reading user confirmation: %w", err)
 			}
-			logger.Warn("Aborted by user")
+			abortApply(logger, "Aborted by user")
 			return nil
 		}
-		if strings.TrimSpace(strings.ToLower(scanner.Text())) != "yes" {
-			logger.Warn("Aborted by user")
+		if !i18n.IsYes(scanner.Text()) {
+			abortApply(logger, "Aborted by user")
 			return nil
 		}
 	}
 
 	createBudgets := assignCreateBudgets && cfgManager.BudgetsEnabled
-	summary, err := mgr.Run(org, assignMode, createBudgets)
+	summary, err := mgr.Run(ctx, org, assignMode, createBudgets)
 	if err != nil {
 		return fmt.Errorf("repository assignment failed: %w", err)
 	}
+	if assignMode == "apply" {
+		recordApply(logger)
+	}
 	if summary != nil {
 		summary.Print()
 	}
@@ -495,7 +1279,7 @@ func runRepoAssign(_ *cobra.Command) error {
 }
 
 // runCustomPropAssign implements the custom-property assignment flow.
-func runCustomPropAssign(_ *cobra.Command) error {
+func runCustomPropAssign(ctx context.Context, client *github.Client) error {
 	logger := slog.Default()
 
 	if len(cfgManager.Organizations) == 0 {
@@ -503,12 +1287,6 @@ func runCustomPropAssign(_ *cobra.Command) error {
 	}
 	org := cfgManager.Organizations[0]
 
-	client, err := github.NewClient(cfgManager, logger)
-	if err != nil {
-		return fmt.Errorf("creating GitHub client: %w", err)
-	}
-	attachCache(client, logger)
-
 	cpMgr, err := customprop.NewManager(cfgManager, client, logger)
 	if err != nil {
 		return fmt.Errorf("initializing custom-property manager: %w", err)
@@ -523,28 +1301,62 @@ func runCustomPropAssign(_ *cobra.Command) error {
 
 	cpMgr.PrintConfigSummary(org)
 
+	firstRun, err := needsFirstRunConsent()
+	if err != nil {
+		return err
+	}
+	if firstRun {
+		preview, err := cpMgr.Run(ctx, org, "plan", false)
+		if err != nil {
+			return fmt.Errorf("previewing custom-property assignment: %w", err)
+		}
+		var changes []plannedChange
+		var names []string
+		for _, r := range preview.Results {
+			if r.ReposMatched > 0 {
+				changes = append(changes, plannedChange{CostCenter: r.CostCenter, Count: r.ReposMatched, Unit: "repositories"})
+				names = append(names, r.CostCenter)
+			}
+		}
+		toCreate, err := missingCostCenters(ctx, client, names)
+		if err != nil {
+			return err
+		}
+		proceed, err := confirmFirstRun(changes, toCreate, nil)
+		if err != nil {
+			return fmt.Errorf("confirmation failed: %w", err)
+		}
+		if !proceed {
+			abortApply(logger, "Aborted by user")
+			return nil
+		}
+	}
+
 	// Confirmation in apply mode.
-	if assignMode == "apply" && !assignYes {
-		fmt.Print("\nProceed with APPLY? (yes/no): ")
+	if assignMode == "apply" && !assignYes && !firstRun {
+		fmt.Print("\n" + i18n.T("confirm.proceed_apply"))
 		scanner := bufio.NewScanner(os.Stdin)
 		if !scanner.Scan() {
 			if err := scanner.Err(); err != nil {
 				return fmt.Errorf("reading user confirmation: %w", err)
 			}
-			logger.Warn("Aborted by user")
+			abortApply(logger, "Aborted by user")
 			return nil
 		}
-		if strings.TrimSpace(strings.ToLower(scanner.Text())) != "yes" {
-			logger.Warn("Aborted by user")
+		if !i18n.IsYes(scanner.Text()) {
+			abortApply(logger, "Aborted by user")
 			return nil
 		}
 	}
 
 	createBudgets := assignCreateBudgets && cfgManager.BudgetsEnabled
-	cpSummary, err := cpMgr.Run(org, assignMode, createBudgets)
+	cpSummary, err := cpMgr.Run(ctx, org, assignMode, createBudgets)
 	if err != nil {
 		return fmt.Errorf("custom-property assignment failed: %w", err)
 	}
+	if assignMode == "apply" {
+		recordApply(logger)
+	}
 	if cpSummary != nil {
 		cpSummary.Print()
 	}
@@ -553,22 +1365,37 @@ func runCustomPropAssign(_ *cobra.Command) error {
 	return nil
 }
 
-// filterUsersByLogin filters a user slice to only those whose login appears in
-// the comma-separated list.
-func filterUsersByLogin(users []github.CopilotUser, commaSep string) []github.CopilotUser {
-	wanted := make(map[string]bool)
-	for _, u := range strings.Split(commaSep, ",") {
-		u = strings.TrimSpace(u)
-		if u != "" {
-			wanted[strings.ToLower(u)] = true
+// seatFilter returns which Copilot seats users mode processes: with
+// --incremental those created since the last run, and with --users only
+// the listed logins.  since is the last run's time, nil without
+// --incremental or a recorded run.
+func seatFilter() (keep func(github.CopilotUser) bool, since *time.Time, err error) {
+	if assignIncremental {
+		if since, err = cfgManager.LoadLastRunTimestamp(); err != nil {
+			return nil, nil, fmt.Errorf("loading last run timestamp: %w", err)
+		}
+	}
+	wanted := loginSet(assignUsers)
+	keep = func(u github.CopilotUser) bool {
+		if since != nil && !u.CreatedAfter(*since) {
+			return false
 		}
+		return wanted == nil || wanted[strings.ToLower(u.Login)]
 	}
+	return keep, since, nil
+}
 
-	var filtered []github.CopilotUser
-	for _, u := range users {
-		if wanted[strings.ToLower(u.Login)] {
-			filtered = append(filtered, u)
+// loginSet returns the lower-cased logins of a comma-separated list, or nil
+// when the list is empty.
+func loginSet(commaSep string) map[string]bool {
+	var wanted map[string]bool
+	for _, u := range strings.Split(commaSep, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			if wanted == nil {
+				wanted = make(map[string]bool)
+			}
+			wanted[strings.ToLower(u)] = true
 		}
 	}
-	return filtered
+	return wanted
 }
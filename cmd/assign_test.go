@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/github/githubtest"
+)
+
+func TestPrintCombinedSummary_NamesCostCenters(t *testing.T) {
+	var buf bytes.Buffer
+	printCombinedSummary(&buf, []modeOutcome{
+		{Mode: "teams", Status: "ok", DurationSeconds: 1.25, CostCenters: []github.CostCenterResult{
+			{ID: "11111111-1111-1111-1111-111111111111", Name: "Platform", Succeeded: 3, Failed: 1},
+			{ID: "22222222-2222-2222-2222-222222222222", Succeeded: 2},
+		}},
+		{Mode: "repos", Status: "failed", DurationSeconds: 0.5, Error: "boom"},
+	})
+	want := `
+=== Combined Assignment Summary ===
+teams        ok        1.2s
+    Platform (11111111-1111-1111-1111-111111111111): 3 assigned, 1 failed
+    22222222-2222-2222-2222-222222222222: 2 assigned, 0 failed
+repos        failed    0.5s  boom
+`
+	if got := buf.String(); got != want {
+		t.Errorf("summary =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRunRecorded_WritesUserResults(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	eng := srv.AddCostCenter("Eng")
+	client, err := github.NewClient(&config.Manager{Enterprise: srv.Enterprise, APIBaseURL: srv.URL, Token: "test-token"},
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	origCfg, origMode, origFile := cfgManager, assignMode, assignResultsFile
+	t.Cleanup(func() { cfgManager, assignMode, assignResultsFile = origCfg, origMode, origFile })
+	cfgManager = &config.Manager{Enterprise: srv.Enterprise}
+	assignMode = "apply"
+	assignResultsFile = filepath.Join(t.TempDir(), "results.json")
+
+	boom := errors.New("boom")
+	err = runRecorded(client, "sources", func() error {
+		if _, err := client.BulkUpdateCostCenterAssignments(t.Context(), map[string][]string{eng: {"bob", "alice"}}, true); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("runRecorded = %v, want the run's error", err)
+	}
+
+	data, err := os.ReadFile(assignResultsFile)
+	if err != nil {
+		t.Fatalf("reading results: %v", err)
+	}
+	var got assignResults
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("decoding results: %v", err)
+	}
+	if len(got.Modes) != 1 || got.Modes[0].Mode != "sources" || got.Modes[0].Status != "failed" || got.Modes[0].Error != "boom" {
+		t.Fatalf("modes = %+v, want one failed sources run", got.Modes)
+	}
+	ccs := got.Modes[0].CostCenters
+	if len(ccs) != 1 || ccs[0].Name != "Eng" || !reflect.DeepEqual(ccs[0].SucceededUsers, []string{"alice", "bob"}) {
+		t.Errorf("cost centers = %+v, want Eng with alice and bob", ccs)
+	}
+}
+
+func TestAddAssignFlags_PlanAndApply(t *testing.T) {
+	for _, tc := range []struct {
+		cmd       string
+		has, lack []string
+	}{
+		{"assign", []string{"mode", "out", "plan-file", "yes", "modes"}, nil},
+		{"plan", []string{"out", "format", "terraform-out", "modes"}, []string{"mode", "plan-file", "yes", "resume", "no-snapshot"}},
+		{"apply", []string{"plan-file", "yes", "resume", "no-snapshot", "modes"}, []string{"mode", "out", "format", "terraform-out"}},
+	} {
+		cmd, _, err := rootCmd.Find([]string{tc.cmd})
+		if err != nil || cmd.Name() != tc.cmd {
+			t.Fatalf("Find(%q) = %v, %v", tc.cmd, cmd, err)
+		}
+		for _, name := range tc.has {
+			if cmd.Flags().Lookup(name) == nil {
+				t.Errorf("%s: missing --%s", tc.cmd, name)
+			}
+		}
+		for _, name := range tc.lack {
+			if cmd.Flags().Lookup(name) != nil {
+				t.Errorf("%s: unexpected --%s", tc.cmd, name)
+			}
+		}
+	}
+}
+
+func TestCheckPermissions_SkipFlagSkipsProbe(t *testing.T) {
+	origCfg, origSkip := cfgManager, skipPermissionCheck
+	t.Cleanup(func() { cfgManager, skipPermissionCheck = origCfg, origSkip })
+	cfgManager = &config.Manager{}
+	skipPermissionCheck = true
+
+	// Neither the scope check nor the cost centers probe may call the API:
+	// a nil client is never used.
+	if err := checkPermissions(t.Context(), nil, "assign", []string{"users"}, true); err != nil {
+		t.Errorf("checkPermissions = %v, want nil", err)
+	}
+}
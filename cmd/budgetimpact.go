@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
+)
+
+// budgetProduct is the product whose budgets seats are charged to.
+const budgetProduct = "copilot"
+
+// budgetLimit is the monthly Copilot budget of a cost center.
+type budgetLimit struct {
+	Amount int
+	// Configured is set when the cost center has no budget yet and Amount
+	// is budgets.products.copilot, the one --create-budgets would create.
+	Configured bool
+}
+
+// budgetImpact is what a plan does to the Copilot budget of one cost
+// center, estimated from its seats at a flat cost per seat.
+type budgetImpact struct {
+	CostCenter string
+	Budget     budgetLimit
+	Current    int // members now
+	Incoming   int // users the plan adds
+	Outgoing   int // members the plan moves to another cost center
+	Before     float64
+	After      float64
+}
+
+// Headroom is the budget left after the plan; negative when exceeded.
+func (b budgetImpact) Headroom() float64 {
+	return float64(b.Budget.Amount) - b.After
+}
+
+// Exceeds reports whether the plan takes the cost center over its budget.
+func (b budgetImpact) Exceeds() bool {
+	return b.Headroom() < 0
+}
+
+// computeBudgetImpact estimates the seat cost of every budgeted cost center
+// the user sections of a plan touch, before and after the plan.  members
+// are the current members of those cost centers; a planned user who is a
+// member of one cost center and added to another counts as moving out of
+// the first.  Cost centers without a budget are left out.  The result is
+// sorted by cost center.
+func computeBudgetImpact(sections []planSection, members map[string][]string, budgets map[string]budgetLimit, seatCost float64) []budgetImpact {
+	memberOf := make(map[string]string)
+	for cc, users := range members {
+		for _, u := range users {
+			memberOf[u] = cc
+		}
+	}
+
+	incoming := make(map[string]map[string]bool)
+	outgoing := make(map[string]map[string]bool)
+	for _, s := range sections {
+		if s.Unit != "users" {
+			continue
+		}
+		for _, u := range s.Items {
+			from := memberOf[u]
+			if from == s.CostCenter {
+				continue
+			}
+			if incoming[s.CostCenter] == nil {
+				incoming[s.CostCenter] = make(map[string]bool)
+			}
+			incoming[s.CostCenter][u] = true
+			if from != "" {
+				if outgoing[from] == nil {
+					outgoing[from] = make(map[string]bool)
+				}
+				outgoing[from][u] = true
+			}
+		}
+	}
+
+	var impacts []budgetImpact
+	for cc, limit := range budgets {
+		in, out := len(incoming[cc]), len(outgoing[cc])
+		if in == 0 && out == 0 {
+			continue
+		}
+		current := len(members[cc])
+		impacts = append(impacts, budgetImpact{
+			CostCenter: cc,
+			Budget:     limit,
+			Current:    current,
+			Incoming:   in,
+			Outgoing:   out,
+			Before:     float64(current) * seatCost,
+			After:      float64(current+in-out) * seatCost,
+		})
+	}
+	sort.Slice(impacts, func(i, j int) bool { return impacts[i].CostCenter < impacts[j].CostCenter })
+	return impacts
+}
+
+// writeBudgetImpact prints the budget impact of a plan, flagging the cost
+// centers it would take over budget.
+func writeBudgetImpact(w io.Writer, impacts []budgetImpact, seatCost float64) {
+	if len(impacts) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "\n%s\n", i18n.T("summary.budget.title", usd(seatCost)))
+	for _, b := range impacts {
+		line := i18n.T("summary.budget.row", b.CostCenter,
+			b.Current, b.Current+b.Incoming-b.Outgoing,
+			usd(b.Before), usd(b.After), usd(float64(b.Budget.Amount)), usd(b.Headroom()))
+		if b.Budget.Configured {
+			line += " " + i18n.T("summary.budget.configured")
+		}
+		if b.Exceeds() {
+			line += "  " + i18n.T("summary.budget.exceeds")
+		}
+		_, _ = fmt.Fprintln(w, line)
+	}
+}
+
+// usd formats an amount of dollars with cents, e.g. "$1234.50".
+func usd(amount float64) string {
+	if amount < 0 {
+		return fmt.Sprintf("-$%.2f", -amount)
+	}
+	return fmt.Sprintf("$%.2f", amount)
+}
+
+// reportBudgetImpact estimates what the user sections of a plan do to the
+// Copilot budget of each destination cost center and prints it to w,
+// warning about every cost center the plan would take over budget.  The
+// budgets are those set on the enterprise, or budgets.products.copilot for
+// cost centers without one when budgets are enabled.  The estimate is
+// advisory: lookups that fail are logged and leave it out.
+func reportBudgetImpact(ctx context.Context, client *github.Client, sections []planSection, w io.Writer, logger *slog.Logger) {
+	var targets []planSection
+	for _, s := range sections {
+		if s.Unit == "users" {
+			targets = append(targets, s)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	existing, err := client.ListBudgets(ctx)
+	var unavailable *github.BudgetsAPIUnavailableError
+	switch {
+	case errors.As(err, &unavailable):
+		logger.Debug("Budgets API unavailable; only configured budgets are estimated", "error", err)
+	case err != nil:
+		logger.Warn("Could not list budgets; skipping the budget impact estimate", "error", err)
+		return
+	}
+	active, err := client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		logger.Warn("Could not list cost centers; skipping the budget impact estimate", "error", err)
+		return
+	}
+
+	budgets := make(map[string]budgetLimit)
+	members := make(map[string][]string)
+	for _, s := range targets {
+		if _, done := budgets[s.CostCenter]; done {
+			continue
+		}
+		id := s.CostCenterID
+		if id == "" {
+			id = active[s.CostCenter]
+		}
+		configured, hasConfigured := cfgManager.BudgetProductsFor(s.CostCenter)[budgetProduct]
+		hasConfigured = hasConfigured && configured.Enabled && cfgManager.BudgetsEnabled
+		if b := github.FindProductBudget(existing, id, s.CostCenter, budgetProduct); b != nil {
+			budgets[s.CostCenter] = budgetLimit{Amount: b.BudgetAmount}
+		} else if hasConfigured {
+			budgets[s.CostCenter] = budgetLimit{Amount: configured.Amount, Configured: true}
+		} else {
+			continue
+		}
+		if id == "" {
+			continue // created on apply, so empty today
+		}
+		users, err := client.GetCostCenterMembers(ctx, id)
+		if err != nil {
+			logger.Warn("Could not read cost center members; skipping the budget impact estimate",
+				"cost_center", s.CostCenter, "error", err)
+			return
+		}
+		members[s.CostCenter] = users
+	}
+
+	seatCost := cfgManager.BudgetSeatCost
+	impacts := computeBudgetImpact(targets, members, budgets, seatCost)
+	writeBudgetImpact(w, impacts, seatCost)
+	for _, b := range impacts {
+		if b.Exceeds() {
+			logger.Warn("Plan would take the cost center over its Copilot budget",
+				"cost_center", b.CostCenter, "budget", b.Budget.Amount,
+				"estimated_cost", b.After, "incoming_users", b.Incoming)
+		}
+	}
+}
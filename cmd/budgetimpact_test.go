@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestComputeBudgetImpact(t *testing.T) {
+	sections := []planSection{
+		// alice is already in Platform; bob moves from Data; carol is new.
+		{Mode: "teams", CostCenter: "Platform", Unit: "users", Items: []string{"alice", "bob", "carol"}},
+		{Mode: "teams", CostCenter: "Unbudgeted", Unit: "users", Items: []string{"dave"}},
+		{Mode: "repos", CostCenter: "Platform", Unit: "repositories", Items: []string{"org/repo"}},
+	}
+	members := map[string][]string{
+		"Platform": {"alice"},
+		"Data":     {"bob", "erin"},
+	}
+	budgets := map[string]budgetLimit{
+		"Platform": {Amount: 50},
+		"Data":     {Amount: 100},
+		"Idle":     {Amount: 10},
+	}
+
+	got := computeBudgetImpact(sections, members, budgets, 19)
+	want := []budgetImpact{
+		{CostCenter: "Data", Budget: budgetLimit{Amount: 100}, Current: 2, Outgoing: 1, Before: 38, After: 19},
+		{CostCenter: "Platform", Budget: budgetLimit{Amount: 50}, Current: 1, Incoming: 2, Before: 19, After: 57},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("impact = %+v\nwant %+v", got, want)
+	}
+	if got[0].Exceeds() || !got[1].Exceeds() || got[1].Headroom() != -7 {
+		t.Errorf("Data exceeds = %v, Platform exceeds = %v, headroom %v", got[0].Exceeds(), got[1].Exceeds(), got[1].Headroom())
+	}
+}
+
+func TestWriteBudgetImpact(t *testing.T) {
+	var buf bytes.Buffer
+	writeBudgetImpact(&buf, []budgetImpact{
+		{CostCenter: "New", Budget: budgetLimit{Amount: 100, Configured: true}, Incoming: 3, After: 57},
+		{CostCenter: "Platform", Budget: budgetLimit{Amount: 50}, Current: 1, Incoming: 2, Before: 19, After: 57},
+	}, 19)
+	out := buf.String()
+	for _, want := range []string{
+		"=== Budget Impact (Copilot, est. $19.00 per seat/month) ===",
+		"  New: 0 -> 3 seats, est. $0.00 -> $57.00 of $100.00 budget (headroom $43.00) [configured budget, not created yet]\n",
+		"  Platform: 1 -> 3 seats, est. $19.00 -> $57.00 of $50.00 budget (headroom -$7.00)  EXCEEDS BUDGET\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	writeBudgetImpact(&buf, nil, 19)
+	if buf.Len() != 0 {
+		t.Errorf("no impact printed %q", buf.String())
+	}
+}
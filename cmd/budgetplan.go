@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
+)
+
+// budgetWritingModes are the modes whose apply writes budgets with
+// --create-budgets.
+var budgetWritingModes = map[string]bool{"teams": true, "repos": true, "custom-prop": true}
+
+// plannedBudget is a budget an apply with --create-budgets would create or
+// update.
+type plannedBudget struct {
+	CostCenter string
+	github.BudgetChange
+}
+
+// planBudgetChanges returns the budgets an apply of sections would write,
+// sorted by cost center and product.  A cost center the apply creates gets
+// every enabled product; an existing one only has drifted budgets updated,
+// as assign does not create budgets on cost centers it did not create.
+// active maps the names of active cost centers to their IDs.
+func planBudgetChanges(sections []planSection, active map[string]string, budgets []github.Budget) []plannedBudget {
+	var out []plannedBudget
+	seen := make(map[string]bool)
+	for _, s := range sections {
+		if !budgetWritingModes[s.Mode] || seen[s.CostCenter] {
+			continue
+		}
+		seen[s.CostCenter] = true
+		id := s.CostCenterID
+		if id == "" {
+			id = active[s.CostCenter]
+		}
+		if id == "" && !s.Create {
+			continue // no cost center to budget
+		}
+		products := s.Budgets
+		if products == nil {
+			products = cfgManager.BudgetProductsFor(s.CostCenter)
+		}
+		for _, ch := range github.PlanProductBudgets(budgets, id, s.CostCenter, products) {
+			if id != "" && ch.Current == nil {
+				continue
+			}
+			out = append(out, plannedBudget{CostCenter: s.CostCenter, BudgetChange: ch})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CostCenter < out[j].CostCenter })
+	return out
+}
+
+// writeBudgetChanges prints the budgets a plan would create (+) or update
+// (~).
+func writeBudgetChanges(w io.Writer, changes []plannedBudget) {
+	_, _ = fmt.Fprintf(w, "\n%s\n", i18n.T("summary.budgets.title"))
+	if len(changes) == 0 {
+		_, _ = fmt.Fprintln(w, i18n.T("summary.budgets.none"))
+		return
+	}
+	for _, c := range changes {
+		want := usd(float64(c.Want.Amount))
+		var line string
+		if c.Current == nil {
+			line = i18n.T("summary.budgets.create", c.CostCenter, c.Product, want)
+		} else {
+			line = i18n.T("summary.budgets.update", c.CostCenter, c.Product, usd(float64(c.Current.BudgetAmount)), want)
+		}
+		switch {
+		case !c.Want.StopsUsage():
+			line += " " + i18n.T("summary.budgets.allows")
+		case c.Current != nil && c.Current.PreventFurtherUsage != nil && !*c.Current.PreventFurtherUsage:
+			line += " " + i18n.T("summary.budgets.stops")
+		}
+		if c.Want.AlertThreshold != 0 {
+			line += " " + i18n.T("summary.budgets.alerts", c.Want.AlertThreshold)
+		}
+		_, _ = fmt.Fprintln(w, line)
+	}
+}
+
+// reportBudgetChanges prints the budgets an apply of sections with
+// --create-budgets would write.  Like the budget impact it is advisory:
+// failed lookups are logged and leave it out.
+func reportBudgetChanges(ctx context.Context, client *github.Client, sections []planSection, w io.Writer, logger *slog.Logger) {
+	budgets, err := client.ListBudgets(ctx)
+	var unavailable *github.BudgetsAPIUnavailableError
+	switch {
+	case errors.As(err, &unavailable):
+		logger.Warn("Budgets API unavailable; apply will skip budgets", "error", err)
+		return
+	case err != nil:
+		logger.Warn("Could not list budgets; skipping the budget preview", "error", err)
+		return
+	}
+	active, err := client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		logger.Warn("Could not list cost centers; skipping the budget preview", "error", err)
+		return
+	}
+	writeBudgetChanges(w, planBudgetChanges(sections, active, budgets))
+}
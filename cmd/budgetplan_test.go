@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+func TestPlanBudgetChanges(t *testing.T) {
+	orig := cfgManager
+	t.Cleanup(func() { cfgManager = orig })
+	cfgManager = &config.Manager{
+		BudgetProducts: map[string]config.ProductBudget{
+			"copilot": {Amount: 200, Enabled: true},
+			"actions": {Amount: 125, Enabled: true},
+		},
+		BudgetOverrides: map[string]map[string]config.BudgetOverride{
+			"infra": {"actions": {Amount: 500}},
+		},
+	}
+	active := map[string]string{"Platform": "id-1", "Infra": "id-2"}
+	budgets := []github.Budget{
+		{ID: "b1", BudgetScope: "cost_center", BudgetEntityName: "id-1", BudgetProductSKU: "copilot", BudgetAmount: 100},
+		{ID: "b2", BudgetScope: "cost_center", BudgetEntityName: "id-2", BudgetProductSKU: "actions", BudgetAmount: 125},
+	}
+	sections := []planSection{
+		// Existing: copilot drifted, actions missing but not created by assign.
+		{Mode: "teams", CostCenter: "Platform", Unit: "users", Items: []string{"alice"}},
+		{Mode: "repos", CostCenter: "Platform", Unit: "repositories", Items: []string{"acme/api"}},
+		// Existing with an override by name.
+		{Mode: "repos", CostCenter: "Infra", Unit: "repositories", Items: []string{"acme/ops"}},
+		// Created on apply, with products from the teams manager.
+		{Mode: "teams", CostCenter: "Data", Create: true, Unit: "users", Items: []string{"bob"},
+			Budgets: map[string]config.ProductBudget{"copilot": {Amount: 5000, Enabled: true}}},
+		// Not created, and users mode writes no budgets.
+		{Mode: "teams", CostCenter: "Ghost", Unit: "users", Items: []string{"carol"}},
+		{Mode: "users", CostCenter: "Legal", Create: true, Unit: "users", Items: []string{"dave"}},
+	}
+
+	changes := planBudgetChanges(sections, active, budgets)
+	var buf bytes.Buffer
+	writeBudgetChanges(&buf, changes)
+	want := []string{
+		"=== Budget Changes (--create-budgets) ===",
+		"  + Data: create copilot budget of $5000.00",
+		"  ~ Infra: update actions budget $125.00 -> $500.00",
+		"  ~ Platform: update copilot budget $100.00 -> $200.00",
+	}
+	if got := strings.Split(strings.TrimSpace(buf.String()), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	buf.Reset()
+	writeBudgetChanges(&buf, nil)
+	if !strings.Contains(buf.String(), "No budget changes.") {
+		t.Errorf("empty plan output = %q", buf.String())
+	}
+}
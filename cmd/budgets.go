@@ -0,0 +1,359 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
+)
+
+var budgetsCmd = &cobra.Command{
+	Use:   "budgets",
+	Short: "List, sync and audit cost center budgets",
+	Long: `Manage the budgets of every active cost center, independently of assign
+runs.
+
+  list   show the budgets of each cost center
+  sync   create missing budgets and update drifted ones to match
+         budgets.products and budgets.overrides (plan by default)
+  audit  report cost centers missing a budget of an enabled product;
+         exits with status 1 when there is any
+
+sync and audit need budgets.enabled.  Overrides are matched by cost center
+name only; team keys need the teams of an assign run.  --cost-center limits
+every subcommand to the named cost centers (name or UUID).
+
+Examples:
+  gh cost-center budgets list
+  gh cost-center budgets sync --mode apply
+  gh cost-center budgets audit --cost-center Platform`,
+}
+
+var budgetsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the budgets of each cost center",
+	Args:  cobra.NoArgs,
+	RunE:  runBudgetsList,
+}
+
+var budgetsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Create and update budgets to match the configuration",
+	Args:  cobra.NoArgs,
+	RunE:  runBudgetsSync,
+}
+
+var budgetsAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Report cost centers missing configured budgets",
+	Args:  cobra.NoArgs,
+	RunE:  runBudgetsAudit,
+}
+
+var (
+	budgetsCostCenters []string
+	budgetsSyncMode    string
+	budgetsSyncYes     bool
+)
+
+func init() {
+	budgetsCmd.PersistentFlags().StringSliceVar(&budgetsCostCenters, "cost-center", nil, "only these cost centers (name or UUID; repeatable or comma-separated)")
+	budgetsCmd.PersistentFlags().BoolVar(&skipPermissionCheck, "skip-permission-check", false, "do not check the token's scopes first")
+	budgetsSyncCmd.Flags().StringVar(&budgetsSyncMode, "mode", "plan", "execution mode: plan (preview) or apply (push changes)")
+	budgetsSyncCmd.Flags().BoolVarP(&budgetsSyncYes, "yes", "y", false, "skip confirmation prompt in apply mode")
+
+	budgetsCmd.AddCommand(budgetsListCmd, budgetsSyncCmd, budgetsAuditCmd)
+	rootCmd.AddCommand(budgetsCmd)
+}
+
+// budgetsState is what the budgets subcommands read: the selected active
+// cost centers (name → ID) and every budget of the enterprise.
+type budgetsState struct {
+	client  *github.Client
+	active  map[string]string
+	budgets []github.Budget
+}
+
+// loadBudgets reads the cost centers and budgets, keeping the cost centers
+// named by --cost-center when it is set.
+func loadBudgets(ctx context.Context, apply bool, logger *slog.Logger) (*budgetsState, error) {
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return nil, fmt.Errorf("creating GitHub client: %w", err)
+	}
+	if err := checkPermissions(ctx, client, "budgets", []string{cfgManager.CostCenterMode}, apply); err != nil {
+		return nil, err
+	}
+	// List the budgets once; the writes of a sync keep the list current.
+	client.SetRunCache(github.NewRunCache())
+	active, err := client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(budgetsCostCenters) > 0 {
+		selected := make(map[string]string, len(budgetsCostCenters))
+		for _, arg := range budgetsCostCenters {
+			name, id, err := resolveCostCenterArg(active, strings.TrimSpace(arg))
+			if err != nil {
+				return nil, err
+			}
+			selected[name] = id
+		}
+		active = selected
+	}
+	budgets, err := client.ListBudgets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &budgetsState{client: client, active: active, budgets: budgets}, nil
+}
+
+// changes returns the budget changes of every selected cost center with
+// any, by cost center name.
+func (s *budgetsState) changes() map[string][]github.BudgetChange {
+	out := make(map[string][]github.BudgetChange)
+	for name, id := range s.active {
+		if ch := github.PlanProductBudgets(s.budgets, id, name, cfgManager.BudgetProductsFor(name)); len(ch) > 0 {
+			out[name] = ch
+		}
+	}
+	return out
+}
+
+func runBudgetsList(cmd *cobra.Command, _ []string) error {
+	s, err := loadBudgets(cmd.Context(), false, slog.Default())
+	if err != nil {
+		return err
+	}
+	printBudgetList(os.Stdout, s.active, s.budgets, len(budgetsCostCenters) == 0)
+	return nil
+}
+
+func runBudgetsSync(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	if budgetsSyncMode != "plan" && budgetsSyncMode != "apply" {
+		return fmt.Errorf("invalid --mode %q: must be 'plan' or 'apply'", budgetsSyncMode)
+	}
+	if !cfgManager.BudgetsEnabled {
+		return errors.New("budgets.enabled is false in the configuration; nothing to sync")
+	}
+	logger := slog.Default()
+	s, err := loadBudgets(ctx, budgetsSyncMode == "apply", logger)
+	if err != nil {
+		return err
+	}
+	changes := s.changes()
+	printBudgetSync(os.Stdout, changes)
+	if budgetsSyncMode != "apply" || len(changes) == 0 {
+		return nil
+	}
+	if !budgetsSyncYes {
+		ok, err := confirmBudgetSync(changes)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			logger.Warn("Budget sync aborted by user")
+			return nil
+		}
+	}
+
+	sink, err := attachAuditSink(s.client, logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := sink.Close(); cerr != nil {
+			logger.Warn("Audit sink", "error", cerr)
+		}
+	}()
+
+	var failures []string
+	for _, name := range sortedNames(changes) {
+		products := cfgManager.BudgetProductsFor(name)
+		created, err := s.client.EnsureProductBudgets(ctx, s.active[name], name, products)
+		if len(created) > 0 {
+			logger.Info("Budgets created", "cost_center", name, "products", strings.Join(created, ", "))
+		}
+		if err == nil {
+			var updated []string
+			updated, err = s.client.ReconcileProductBudgets(ctx, s.active[name], name, products)
+			if len(updated) > 0 {
+				logger.Info("Budgets updated", "cost_center", name, "products", strings.Join(updated, ", "))
+			}
+		}
+		if err != nil {
+			var unavailable *github.BudgetsAPIUnavailableError
+			if errors.As(err, &unavailable) {
+				return err
+			}
+			logger.Error("Failed to write budgets", "cost_center", name, "error", err)
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("budget sync failures: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+func runBudgetsAudit(cmd *cobra.Command, _ []string) error {
+	if !cfgManager.BudgetsEnabled {
+		return errors.New("budgets.enabled is false in the configuration; no budgets are required")
+	}
+	s, err := loadBudgets(cmd.Context(), false, slog.Default())
+	if err != nil {
+		return err
+	}
+	missing := missingBudgets(s.changes())
+	printBudgetAudit(os.Stdout, len(s.active), missing)
+	if len(missing) > 0 {
+		return fmt.Errorf("%d cost center(s) missing budgets", len(missing))
+	}
+	return nil
+}
+
+// budgetsOf returns the cost center budgets of the cost center, by product.
+func budgetsOf(budgets []github.Budget, id, name string) []github.Budget {
+	var out []github.Budget
+	for _, b := range budgets {
+		if b.BudgetScope == "cost_center" && (b.BudgetEntityName == id || b.BudgetEntityName == name) {
+			out = append(out, b)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].BudgetProductSKU < out[j].BudgetProductSKU })
+	return out
+}
+
+// printBudgetList writes the budgets of each cost center and, with orphans,
+// the cost center budgets whose cost center is not active.
+func printBudgetList(w io.Writer, active map[string]string, budgets []github.Budget, orphans bool) {
+	_, _ = fmt.Fprintf(w, "%-40s %-28s %10s  %s\n", "COST CENTER", "PRODUCT", "AMOUNT", "STOPS USAGE")
+	known := make(map[string]bool, 2*len(active))
+	for _, name := range sortedNames(active) {
+		id := active[name]
+		known[id], known[name] = true, true
+		own := budgetsOf(budgets, id, name)
+		if len(own) == 0 {
+			_, _ = fmt.Fprintf(w, "%-40s %-28s\n", name, "(none)")
+		}
+		for _, b := range own {
+			_, _ = fmt.Fprintf(w, "%-40s %-28s %10s  %s\n", name, b.BudgetProductSKU, fmt.Sprintf("$%d", b.BudgetAmount), stopsUsage(b))
+		}
+	}
+	if !orphans {
+		return
+	}
+	for _, b := range budgets {
+		if b.BudgetScope == "cost_center" && !known[b.BudgetEntityName] {
+			_, _ = fmt.Fprintf(w, "%-40s %-28s %10s  %s\n", b.BudgetEntityName+" (not active)",
+				b.BudgetProductSKU, fmt.Sprintf("$%d", b.BudgetAmount), stopsUsage(b))
+		}
+	}
+}
+
+func stopsUsage(b github.Budget) string {
+	switch {
+	case b.PreventFurtherUsage == nil:
+		return "?"
+	case *b.PreventFurtherUsage:
+		return "yes"
+	}
+	return "no"
+}
+
+// printBudgetSync writes the budgets sync would create (+) or update (~).
+func printBudgetSync(w io.Writer, changes map[string][]github.BudgetChange) {
+	if len(changes) == 0 {
+		_, _ = fmt.Fprintln(w, "Budgets match the configuration.")
+		return
+	}
+	for _, name := range sortedNames(changes) {
+		_, _ = fmt.Fprintf(w, "%s\n", name)
+		for _, ch := range changes[name] {
+			notes := allowsUsage(ch.Want.StopsUsage()) + alertsAt(ch.Want.AlertThreshold)
+			if ch.Current == nil {
+				_, _ = fmt.Fprintf(w, "  + %s: $%d%s\n", ch.Product, ch.Want.Amount, notes)
+				continue
+			}
+			_, _ = fmt.Fprintf(w, "  ~ %s: $%d -> $%d%s\n", ch.Product, ch.Current.BudgetAmount, ch.Want.Amount, notes)
+		}
+	}
+}
+
+func allowsUsage(stop bool) string {
+	if stop {
+		return ""
+	}
+	return " (usage continues past the amount)"
+}
+
+func alertsAt(threshold int) string {
+	if threshold == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (alerts at %d%%)", threshold)
+}
+
+// missingBudgets returns the products missing a budget, by cost center.
+func missingBudgets(changes map[string][]github.BudgetChange) map[string][]string {
+	out := make(map[string][]string)
+	for name, chs := range changes {
+		for _, ch := range chs {
+			if ch.Current == nil {
+				out[name] = append(out[name], ch.Product)
+			}
+		}
+	}
+	return out
+}
+
+// printBudgetAudit writes the cost centers missing budgets.
+func printBudgetAudit(w io.Writer, checked int, missing map[string][]string) {
+	if len(missing) == 0 {
+		_, _ = fmt.Fprintf(w, "All %d cost centers have their configured budgets.\n", checked)
+		return
+	}
+	_, _ = fmt.Fprintf(w, "%d of %d cost centers are missing budgets:\n", len(missing), checked)
+	for _, name := range sortedNames(missing) {
+		_, _ = fmt.Fprintf(w, "  %s: %s\n", name, strings.Join(missing[name], ", "))
+	}
+}
+
+// confirmBudgetSync asks before writing the budgets.
+func confirmBudgetSync(changes map[string][]github.BudgetChange) (bool, error) {
+	writes := 0
+	for _, chs := range changes {
+		writes += len(chs)
+	}
+	fmt.Println("\n" + i18n.T("confirm.budgets.intro", writes, len(changes)))
+	fmt.Print(i18n.T("confirm.proceed"))
+	scanner := bufio.NewScanner(os.Stdin)
+	if scanner.Scan() {
+		return i18n.IsYes(scanner.Text()), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("reading user input: %w", err)
+	}
+	return false, nil
+}
+
+// sortedNames returns the keys of a cost center map in order.
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+func TestPrintBudgetList(t *testing.T) {
+	stop := true
+	active := map[string]string{"Platform": "id-1", "Data": "id-2"}
+	budgets := []github.Budget{
+		{BudgetScope: "cost_center", BudgetEntityName: "id-1", BudgetProductSKU: "copilot", BudgetAmount: 200, PreventFurtherUsage: &stop},
+		{BudgetScope: "cost_center", BudgetEntityName: "Platform", BudgetProductSKU: "actions", BudgetAmount: 125},
+		{BudgetScope: "cost_center", BudgetEntityName: "id-gone", BudgetProductSKU: "actions", BudgetAmount: 50},
+		{BudgetScope: "enterprise", BudgetEntityName: "acme", BudgetProductSKU: "actions", BudgetAmount: 1000},
+	}
+
+	var buf bytes.Buffer
+	printBudgetList(&buf, active, budgets, true)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := [][]string{
+		{"COST CENTER"},
+		{"Data", "(none)"},
+		{"Platform", "actions", "$125", "?"},
+		{"Platform", "copilot", "$200", "yes"},
+		{"id-gone (not active)", "actions", "$50"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for i, fields := range want {
+		for _, f := range fields {
+			if !strings.Contains(lines[i], f) {
+				t.Errorf("line %d = %q, want it to contain %q", i, lines[i], f)
+			}
+		}
+	}
+
+	buf.Reset()
+	printBudgetList(&buf, map[string]string{"Data": "id-2"}, budgets, false)
+	if strings.Contains(buf.String(), "not active") {
+		t.Errorf("filtered list shows other budgets:\n%s", buf.String())
+	}
+}
+
+func TestPrintBudgetSyncAndAudit(t *testing.T) {
+	changes := map[string][]github.BudgetChange{
+		"Platform": {
+			{Product: "actions", Current: &github.Budget{BudgetAmount: 50}, Want: config.ProductBudget{Amount: 125, Enabled: true, AlertThreshold: 90}},
+			{Product: "copilot", Want: config.ProductBudget{Amount: 5000, Enabled: true, AllowFurtherUsage: true}},
+		},
+	}
+	var buf bytes.Buffer
+	printBudgetSync(&buf, changes)
+	for _, want := range []string{"Platform\n", "  ~ actions: $50 -> $125 (alerts at 90%)\n", "  + copilot: $5000 (usage continues past the amount)\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("sync output missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	missing := missingBudgets(changes)
+	if len(missing) != 1 || strings.Join(missing["Platform"], ",") != "copilot" {
+		t.Errorf("missing = %v, want Platform: copilot", missing)
+	}
+	buf.Reset()
+	printBudgetAudit(&buf, 3, missing)
+	if !strings.Contains(buf.String(), "1 of 3 cost centers") || !strings.Contains(buf.String(), "  Platform: copilot\n") {
+		t.Errorf("audit output = %q", buf.String())
+	}
+}
@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -14,6 +18,8 @@ var (
 	cacheStats   bool
 	cacheClear   bool
 	cacheCleanup bool
+	cacheList    bool
+	cacheFormat  string
 )
 
 var cacheCmd = &cobra.Command{
@@ -22,7 +28,18 @@ var cacheCmd = &cobra.Command{
 	Long: `View, clear, or clean up the cost center cache.
 
 The cache stores cost center lookups to reduce API calls on repeated runs.
-Cache entries expire after 24 hours.
+Cache entries expire after 24 hours.  When cache.team_members_ttl or
+cache.seats_ttl is set, team member lists and the Copilot seat snapshot
+are kept in their own buckets for that long, so a plan followed by an
+apply fetches them once; the buckets are off by default.  GET responses
+are also kept with their ETags in the user cache directory
+(gh-cost-center/http), so repeated runs send conditional requests and
+unchanged data costs no rate limit; responses not refreshed for 7 days
+are pruned.  --stats, --clear and --cleanup cover all of them.
+
+When the cache directory cannot be written (e.g. a read-only checkout on a
+locked-down runner), a run warns once and keeps the cache in memory until
+it exits.  --stats shows the storage as degraded.
 
 Examples:
   # Show cache statistics
@@ -32,27 +49,43 @@ Examples:
   gh cost-center cache --clear
 
   # Remove only expired entries
-  gh cost-center cache --cleanup`,
+  gh cost-center cache --cleanup
+
+  # List cost center entries with their age and expiry
+  gh cost-center cache --list
+  gh cost-center cache --list --format json | jq '.[] | select(.expired)'`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if !cacheStats && !cacheClear && !cacheCleanup {
+		if !cacheStats && !cacheClear && !cacheCleanup && !cacheList {
 			return cmd.Help()
 		}
+		if cacheFormat != "text" && cacheFormat != "json" {
+			return fmt.Errorf("invalid --format %q: must be 'text' or 'json'", cacheFormat)
+		}
 
 		cc, err := cache.New("", slog.Default())
 		if err != nil {
 			return fmt.Errorf("opening cache: %w", err)
 		}
 
+		store := cache.NewHTTPStore("", slog.Default())
+		members, seats := cacheBuckets(slog.Default())
+		buckets := []*cache.Bucket{members, seats}
+
+		if cacheList {
+			if err := writeCacheList(os.Stdout, cc.List(), cacheFormat, time.Now()); err != nil {
+				return fmt.Errorf("listing cache: %w", err)
+			}
+		}
 		if cacheStats {
-			runCacheStats(cc)
+			runCacheStats(cc, buckets, store)
 		}
 		if cacheClear {
-			if err := runCacheClear(cc); err != nil {
+			if err := runCacheClear(cc, buckets, store); err != nil {
 				return err
 			}
 		}
 		if cacheCleanup {
-			if err := runCacheCleanup(cc); err != nil {
+			if err := runCacheCleanup(cc, buckets, store); err != nil {
 				return err
 			}
 		}
@@ -60,33 +93,125 @@ Examples:
 	},
 }
 
-func runCacheStats(cc *cache.Cache) {
+// cacheBuckets opens the team member and seat buckets with the TTLs of
+// cache.team_members_ttl and cache.seats_ttl.
+func cacheBuckets(logger *slog.Logger) (members, seats *cache.Bucket) {
+	return cache.NewBucket("", cache.BucketTeamMembers, cfgManager.TeamMembersTTL, logger),
+		cache.NewBucket("", cache.BucketSeats, cfgManager.SeatsTTL, logger)
+}
+
+func runCacheStats(cc *cache.Cache, buckets []*cache.Bucket, store *cache.HTTPStore) {
 	stats := cc.GetStats()
 	fmt.Println()
 	fmt.Println(strings.Repeat("=", 60))
 	fmt.Println("COST CENTER CACHE STATISTICS")
 	fmt.Println(strings.Repeat("=", 60))
 	fmt.Printf("Cache file:      %s\n", stats.FilePath)
+	fmt.Printf("Storage:         %s\n", cacheStorage(cc.CheckWritable()))
 	fmt.Printf("File size:       %d bytes\n", stats.FileSizeBytes)
 	fmt.Printf("Total entries:   %d\n", stats.TotalEntries)
 	fmt.Printf("Valid entries:   %d\n", stats.ValidEntries)
 	fmt.Printf("Expired entries: %d\n", stats.ExpiredEntries)
+	for _, b := range buckets {
+		bs := b.GetStats()
+		fmt.Printf("%-16s %d valid, %d expired (TTL %s, %d bytes)\n",
+			b.Name()+":", bs.ValidEntries, bs.ExpiredEntries, b.TTL(), bs.FileSizeBytes)
+	}
+	entries, size := store.Stats()
+	fmt.Printf("HTTP responses:  %d (%d bytes in %s)\n", entries, size, store.Dir())
 	fmt.Println(strings.Repeat("=", 60))
 }
 
-func runCacheClear(cc *cache.Cache) error {
+// cacheStorage describes where runs keep the cache, given the result of
+// probing the cache directory for writes.
+func cacheStorage(probe error) string {
+	if probe == nil {
+		return "on disk"
+	}
+	return fmt.Sprintf("in memory only (DEGRADED: %v); runs cannot reuse lookups", probe)
+}
+
+// cacheListRow is one cost center cache entry in --list --format json.
+type cacheListRow struct {
+	Key        string    `json:"key"`
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	CachedAt   time.Time `json:"cached_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	AgeSeconds int64     `json:"age_seconds"`
+	Expired    bool      `json:"expired"`
+}
+
+// writeCacheList writes the cost center cache entries as a table ("text")
+// or a JSON array ("json"), with ages and expiry relative to now.
+func writeCacheList(w io.Writer, entries []cache.KeyedEntry, format string, now time.Time) error {
+	if format == "json" {
+		rows := make([]cacheListRow, 0, len(entries))
+		for _, e := range entries {
+			rows = append(rows, cacheListRow{
+				Key:        e.Key,
+				ID:         e.ID,
+				Name:       e.Name,
+				CachedAt:   e.CachedAt,
+				ExpiresAt:  e.ExpiresAt(),
+				AgeSeconds: int64(now.Sub(e.CachedAt).Seconds()),
+				Expired:    now.After(e.ExpiresAt()),
+			})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+
+	if len(entries) == 0 {
+		_, err := fmt.Fprintln(w, "No cached cost centers.")
+		return err
+	}
+	_, _ = fmt.Fprintf(w, "%-40s %-36s %10s  %s\n", "KEY", "ID", "AGE", "EXPIRES")
+	for _, e := range entries {
+		expires := e.ExpiresAt().Format(time.RFC3339)
+		if now.After(e.ExpiresAt()) {
+			expires += " (expired)"
+		}
+		_, _ = fmt.Fprintf(w, "%-40s %-36s %10s  %s\n",
+			e.Key, e.ID, now.Sub(e.CachedAt).Round(time.Second), expires)
+	}
+	return nil
+}
+
+func runCacheClear(cc *cache.Cache, buckets []*cache.Bucket, store *cache.HTTPStore) error {
 	if err := cc.Clear(); err != nil {
 		return fmt.Errorf("clearing cache: %w", err)
 	}
+	for _, b := range buckets {
+		if err := b.Clear(); err != nil {
+			return fmt.Errorf("clearing cache: %w", err)
+		}
+	}
+	if err := store.Clear(); err != nil {
+		return fmt.Errorf("clearing cache: %w", err)
+	}
 	fmt.Println("Cache cleared successfully.")
 	return nil
 }
 
-func runCacheCleanup(cc *cache.Cache) error {
+func runCacheCleanup(cc *cache.Cache, buckets []*cache.Bucket, store *cache.HTTPStore) error {
 	removed, err := cc.CleanupExpired()
 	if err != nil {
 		return fmt.Errorf("cleaning up cache: %w", err)
 	}
+	for _, b := range buckets {
+		n, err := b.CleanupExpired()
+		if err != nil {
+			return fmt.Errorf("cleaning up cache: %w", err)
+		}
+		removed += n
+	}
+	n, err := store.Prune(cache.DefaultHTTPMaxAge)
+	if err != nil {
+		return fmt.Errorf("cleaning up cache: %w", err)
+	}
+	removed += n
 	stats := cc.GetStats()
 	fmt.Printf("Removed %d expired entries. %d entries remaining.\n", removed, stats.TotalEntries)
 	return nil
@@ -96,6 +221,8 @@ func init() {
 	cacheCmd.Flags().BoolVar(&cacheStats, "stats", false, "show cache statistics")
 	cacheCmd.Flags().BoolVar(&cacheClear, "clear", false, "clear the entire cache")
 	cacheCmd.Flags().BoolVar(&cacheCleanup, "cleanup", false, "remove expired cache entries")
+	cacheCmd.Flags().BoolVar(&cacheList, "list", false, "list cost center cache entries with their ID, age and expiry")
+	cacheCmd.Flags().StringVar(&cacheFormat, "format", "text", "--list output format: text or json")
 
 	rootCmd.AddCommand(cacheCmd)
 }
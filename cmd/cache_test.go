@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/cache"
+)
+
+func TestWriteCacheList(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	entries := []cache.KeyedEntry{
+		{Key: "fresh", Entry: cache.Entry{ID: "id-1", Name: "Fresh", CachedAt: now.Add(-time.Hour), TTLHours: 24}},
+		{Key: "stale", Entry: cache.Entry{ID: "id-2", Name: "Stale", CachedAt: now.Add(-30 * time.Hour), TTLHours: 24}},
+	}
+
+	var text bytes.Buffer
+	if err := writeCacheList(&text, entries, "text", now); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(text.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "KEY") {
+		t.Fatalf("unexpected table:\n%s", text.String())
+	}
+	if !strings.Contains(lines[1], "1h0m0s") || strings.Contains(lines[1], "expired") {
+		t.Errorf("fresh row: %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "30h0m0s") || !strings.HasSuffix(lines[2], "(expired)") {
+		t.Errorf("stale row: %q", lines[2])
+	}
+
+	var js bytes.Buffer
+	if err := writeCacheList(&js, entries, "json", now); err != nil {
+		t.Fatal(err)
+	}
+	var rows []cacheListRow
+	if err := json.Unmarshal(js.Bytes(), &rows); err != nil {
+		t.Fatalf("decoding JSON: %v\n%s", err, js.String())
+	}
+	if len(rows) != 2 || rows[0].AgeSeconds != 3600 || rows[0].Expired || !rows[1].Expired {
+		t.Errorf("unexpected rows: %+v", rows)
+	}
+	if !rows[1].ExpiresAt.Equal(now.Add(-6 * time.Hour)) {
+		t.Errorf("expires_at: got %v", rows[1].ExpiresAt)
+	}
+
+	var empty bytes.Buffer
+	_ = writeCacheList(&empty, nil, "json", now)
+	if strings.TrimSpace(empty.String()) != "[]" {
+		t.Errorf("empty JSON list: got %q", empty.String())
+	}
+}
+
+func TestCacheStorage(t *testing.T) {
+	if got := cacheStorage(nil); got != "on disk" {
+		t.Errorf("writable = %q", got)
+	}
+	if got := cacheStorage(errors.New("read-only file system")); !strings.Contains(got, "DEGRADED: read-only file system") {
+		t.Errorf("read-only = %q", got)
+	}
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/teams"
+)
+
+// probeCostCenters stops a run before any work when the enterprise cannot
+// use the cost centers API, instead of failing later with one 404 per
+// call.  In that case it prints a read-only report of what the token can
+// still read for modes.
+func probeCostCenters(ctx context.Context, client *github.Client, modes []string) error {
+	err := client.ProbeCostCenters(ctx)
+	var unavailable *github.CostCentersUnavailableError
+	if errors.As(err, &unavailable) {
+		printReadOnlyReport(ctx, client, modes)
+	}
+	return err
+}
+
+// printReadOnlyReport lists, per mode, how many of the resources the run
+// would assign the token can read.  Failures are reported per mode.
+func printReadOnlyReport(ctx context.Context, client *github.Client, modes []string) {
+	fmt.Println("\n=== Cost centers API unavailable: read-only report ===")
+	fmt.Println("The data the run would assign is still readable:")
+	for _, mode := range modes {
+		n, unit, err := readableResources(ctx, client, mode)
+		if err != nil {
+			fmt.Printf("  %s: not readable: %v\n", mode, err)
+			continue
+		}
+		fmt.Printf("  %s: %d %s\n", mode, n, unit)
+	}
+	fmt.Println("No cost center can be listed, created or assigned until the API is available.")
+}
+
+// readableResources counts the resources mode would assign.
+func readableResources(ctx context.Context, client *github.Client, mode string) (int, string, error) {
+	switch mode {
+	case "teams":
+		if err := teams.ResolveScope(ctx, cfgManager, client, slog.Default()); err != nil {
+			return 0, "", err
+		}
+		if cfgManager.TeamsScope == "enterprise" {
+			ts, err := client.GetEnterpriseTeams(ctx)
+			return len(ts), "enterprise teams", err
+		}
+		n := 0
+		for _, org := range cfgManager.Organizations {
+			ts, err := client.GetOrgTeams(ctx, org)
+			if err != nil {
+				return 0, "", err
+			}
+			n += len(ts)
+		}
+		return n, "organization teams", nil
+	case "orgs":
+		n := 0
+		for _, org := range cfgManager.Organizations {
+			ms, err := client.GetOrgMembers(ctx, org)
+			if err != nil {
+				return 0, "", err
+			}
+			n += len(ms)
+		}
+		return n, "organization members", nil
+	case "idp-groups":
+		n := 0
+		for _, org := range cfgManager.Organizations {
+			gs, err := client.GetOrgExternalGroups(ctx, org)
+			if err != nil {
+				return 0, "", err
+			}
+			n += len(gs)
+		}
+		return n, "external groups", nil
+	case "repos", "custom-prop":
+		n := 0
+		for _, org := range cfgManager.Organizations {
+			err := client.EachOrgRepoWithProperties(ctx, org, "", func(github.RepoProperties) error {
+				n++
+				return nil
+			})
+			if err != nil {
+				return 0, "", err
+			}
+		}
+		return n, "repositories", nil
+	default: // users
+		n := 0
+		err := client.EachCopilotUser(ctx, func(github.CopilotUser) error {
+			n++
+			return nil
+		})
+		return n, "Copilot seats", err
+	}
+}
@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
+	"github.com/renan-alm/gh-cost-center/internal/journal"
+)
+
+var ccCmd = &cobra.Command{
+	Use:   "cc",
+	Short: "Manage individual cost centers",
+}
+
+var ccTransferCmd = &cobra.Command{
+	Use:   "transfer-alerts <cost-center>",
+	Short: "Move a cost center's budget alerts to new recipients",
+	Long: `Move the budget alerts of a cost center to new recipients without
+recreating it, e.g. when it moves between departments in a
+reorganization.  The cost center is named by its name or UUID.
+
+With --from, only those alert recipients of the cost center's budgets are
+replaced by --to and the others stay; without it, --to becomes the
+complete recipient list of every budget.  Membership is not changed.
+
+The handoff is recorded in the "handoffs" list of the journal
+(<export_dir>/retry_journal.json) and, when an audit sink is configured,
+as a cost_center.alerts_transferred event.
+
+Examples:
+  gh cost-center cc transfer-alerts "Platform" --to alice,bob
+  gh cost-center cc transfer-alerts "Platform" --from carol --to alice --mode apply`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCCTransfer,
+}
+
+var ccRenameCmd = &cobra.Command{
+	Use:   "rename <cost-center> <new-name>",
+	Short: "Rename a cost center",
+	Long: `Rename a cost center in place.  The cost center is named by its name or
+UUID; its ID, members, repositories and budgets are kept, so nothing is
+reassigned.  The new name must not be used by another cost center,
+including deleted ones.
+
+budgets.overrides keyed by the old name no longer match: update them along
+with the rename.
+
+Examples:
+  gh cost-center cc rename "Platform" "Platform Engineering"
+  gh cost-center cc rename "Platform" "Platform Engineering" --mode apply --yes`,
+	Args: cobra.ExactArgs(2),
+	RunE: runCCRename,
+}
+
+var (
+	ccTransferFrom string
+	ccTransferTo   string
+	ccTransferMode string
+	ccTransferYes  bool
+
+	ccRenameMode string
+	ccRenameYes  bool
+)
+
+func init() {
+	ccTransferCmd.Flags().StringVar(&ccTransferTo, "to", "", "comma-separated new budget alert recipients")
+	ccTransferCmd.Flags().StringVar(&ccTransferFrom, "from", "", "comma-separated alert recipients to replace (default: all current recipients)")
+	ccTransferCmd.Flags().StringVar(&ccTransferMode, "mode", "plan", "execution mode: plan (preview) or apply (push changes)")
+	ccTransferCmd.Flags().BoolVarP(&ccTransferYes, "yes", "y", false, "skip confirmation prompt in apply mode")
+	ccTransferCmd.Flags().BoolVar(&skipPermissionCheck, "skip-permission-check", false, "do not check the token's scopes before the transfer")
+	_ = ccTransferCmd.MarkFlagRequired("to")
+
+	ccRenameCmd.Flags().StringVar(&ccRenameMode, "mode", "plan", "execution mode: plan (preview) or apply (push changes)")
+	ccRenameCmd.Flags().BoolVarP(&ccRenameYes, "yes", "y", false, "skip confirmation prompt in apply mode")
+	ccRenameCmd.Flags().BoolVar(&skipPermissionCheck, "skip-permission-check", false, "do not check the token's scopes before the rename")
+
+	ccCmd.AddCommand(ccTransferCmd, ccRenameCmd)
+	rootCmd.AddCommand(ccCmd)
+}
+
+func runCCTransfer(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if ccTransferMode != "plan" && ccTransferMode != "apply" {
+		return fmt.Errorf("invalid --mode %q: must be 'plan' or 'apply'", ccTransferMode)
+	}
+	to := parseModes(ccTransferTo)
+	if len(to) == 0 {
+		return fmt.Errorf("--to requires at least one recipient")
+	}
+	from := parseModes(ccTransferFrom)
+	logger := slog.Default()
+
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	if err := checkPermissions(ctx, client, "transfer-alerts", []string{cfgManager.CostCenterMode}, ccTransferMode == "apply"); err != nil {
+		return err
+	}
+
+	active, err := client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		return err
+	}
+	name, id, err := resolveCostCenterArg(active, args[0])
+	if err != nil {
+		return err
+	}
+
+	plan, err := client.PlanAlertTransfer(ctx, id, name, from, to)
+	if err != nil {
+		return err
+	}
+	printAlertTransferPlan(os.Stdout, name, id, plan)
+
+	if ccTransferMode != "apply" {
+		return nil
+	}
+	if !ccTransferYes {
+		ok, err := confirmTransfer(name, to, plan)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			logger.Warn("Alert transfer aborted by user")
+			return nil
+		}
+	}
+
+	sink, err := attachAuditSink(client, logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := sink.Close(); cerr != nil {
+			logger.Warn("Audit sink", "error", cerr)
+		}
+	}()
+	client.SetJournal(journal.Open(cfgManager.ExportDir, logger))
+	return client.TransferAlerts(ctx, id, name, from, to, plan)
+}
+
+func runCCRename(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if ccRenameMode != "plan" && ccRenameMode != "apply" {
+		return fmt.Errorf("invalid --mode %q: must be 'plan' or 'apply'", ccRenameMode)
+	}
+	newName := strings.TrimSpace(args[1])
+	if newName == "" {
+		return fmt.Errorf("the new name must not be empty")
+	}
+	logger := slog.Default()
+
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	// The cost center cache must follow the new name.
+	attachCache(client, logger)
+	if err := checkPermissions(ctx, client, "rename", []string{cfgManager.CostCenterMode}, ccRenameMode == "apply"); err != nil {
+		return err
+	}
+
+	active, err := client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		return err
+	}
+	name, id, err := resolveCostCenterArg(active, args[0])
+	if err != nil {
+		return err
+	}
+	if err := checkRename(active, name, newName); err != nil {
+		return err
+	}
+	fmt.Printf("Rename cost center %q (%s) -> %q\n", name, id, newName)
+
+	if ccRenameMode != "apply" {
+		return nil
+	}
+	if !ccRenameYes {
+		ok, err := confirmRename(name, newName)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			logger.Warn("Rename aborted by user")
+			return nil
+		}
+	}
+
+	sink, err := attachAuditSink(client, logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := sink.Close(); cerr != nil {
+			logger.Warn("Audit sink", "error", cerr)
+		}
+	}()
+	return client.RenameCostCenter(ctx, id, name, newName)
+}
+
+// checkRename rejects renames the API would refuse or that change nothing:
+// the new name is the current one or that of another active cost center.
+// Deleted cost centers also hold their names; the API reports those.
+func checkRename(active map[string]string, name, newName string) error {
+	if newName == name {
+		return fmt.Errorf("cost center %q already has that name", name)
+	}
+	if id, ok := active[newName]; ok {
+		return fmt.Errorf("cost center %q (%s) already uses the name", newName, id)
+	}
+	return nil
+}
+
+// resolveCostCenterArg finds an active cost center by name or UUID in
+// active (name → ID).
+func resolveCostCenterArg(active map[string]string, arg string) (name, id string, err error) {
+	if id, ok := active[arg]; ok {
+		return arg, id, nil
+	}
+	for n, i := range active {
+		if i == arg {
+			return n, i, nil
+		}
+	}
+	return "", "", fmt.Errorf("no active cost center named %q or with that ID", arg)
+}
+
+// printAlertTransferPlan writes the alert recipient change of every budget.
+func printAlertTransferPlan(w io.Writer, name, id string, plan []github.BudgetHandoff) {
+	_, _ = fmt.Fprintf(w, "Budget alert transfer of cost center %q (%s)\n", name, id)
+	if len(plan) == 0 {
+		_, _ = fmt.Fprintln(w, "  No budgets target this cost center; only the handoff is recorded.")
+		return
+	}
+	for _, h := range plan {
+		status := ""
+		if !h.Changed() {
+			status = "  (unchanged)"
+		}
+		_, _ = fmt.Fprintf(w, "  %s budget: %s -> %s%s\n",
+			h.Product, recipientList(h.Before), recipientList(h.After), status)
+	}
+}
+
+func recipientList(r []string) string {
+	if len(r) == 0 {
+		return "(none)"
+	}
+	return strings.Join(r, ", ")
+}
+
+// confirmRename asks before renaming the cost center.
+func confirmRename(name, newName string) (bool, error) {
+	fmt.Println("\n" + i18n.T("confirm.rename.intro", name, newName))
+	fmt.Print(i18n.T("confirm.proceed"))
+	scanner := bufio.NewScanner(os.Stdin)
+	if scanner.Scan() {
+		return i18n.IsYes(scanner.Text()), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("reading user input: %w", err)
+	}
+	return false, nil
+}
+
+// confirmTransfer asks before pushing the budget updates.
+func confirmTransfer(name string, to []string, plan []github.BudgetHandoff) (bool, error) {
+	changed := 0
+	for _, h := range plan {
+		if h.Changed() {
+			changed++
+		}
+	}
+	fmt.Println("\n" + i18n.T("confirm.transfer.intro", name, strings.Join(to, ", "), changed))
+	fmt.Print(i18n.T("confirm.proceed"))
+	scanner := bufio.NewScanner(os.Stdin)
+	if scanner.Scan() {
+		return i18n.IsYes(scanner.Text()), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("reading user input: %w", err)
+	}
+	return false, nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+func TestResolveCostCenterArg(t *testing.T) {
+	active := map[string]string{"Platform": "id-1", "Data": "id-2"}
+	if name, id, err := resolveCostCenterArg(active, "Data"); err != nil || name != "Data" || id != "id-2" {
+		t.Errorf("by name = %q, %q, %v", name, id, err)
+	}
+	if name, id, err := resolveCostCenterArg(active, "id-1"); err != nil || name != "Platform" || id != "id-1" {
+		t.Errorf("by ID = %q, %q, %v", name, id, err)
+	}
+	if _, _, err := resolveCostCenterArg(active, "Missing"); err == nil {
+		t.Error("expected error for unknown cost center")
+	}
+}
+
+func TestPrintAlertTransferPlan(t *testing.T) {
+	var buf bytes.Buffer
+	printAlertTransferPlan(&buf, "Platform", "id-1", []github.BudgetHandoff{
+		{BudgetID: "b1", Product: "actions", Before: []string{"carol"}, After: []string{"alice"}},
+		{BudgetID: "b2", Product: "copilot", Before: []string{"alice"}, After: []string{"alice"}},
+	})
+	out := buf.String()
+	for _, want := range []string{`"Platform" (id-1)`, "actions budget: carol -> alice\n", "copilot budget: alice -> alice  (unchanged)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	printAlertTransferPlan(&buf, "Empty", "id-2", nil)
+	if !strings.Contains(buf.String(), "No budgets") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
+
+func TestCheckRename(t *testing.T) {
+	active := map[string]string{"Platform": "id-1", "Data": "id-2"}
+	if err := checkRename(active, "Platform", "Platform Engineering"); err != nil {
+		t.Errorf("free name: %v", err)
+	}
+	if err := checkRename(active, "Platform", "Data"); err == nil || !strings.Contains(err.Error(), "id-2") {
+		t.Errorf("taken name: %v", err)
+	}
+	if err := checkRename(active, "Platform", "Platform"); err == nil {
+		t.Error("expected error for unchanged name")
+	}
+}
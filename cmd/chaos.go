@@ -0,0 +1,43 @@
+//go:build chaos
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+// chaosFlag holds the raw --chaos value, e.g. "p=0.05".  The flag only exists
+// in binaries built with `-tags chaos` and is hidden from help output.
+var chaosFlag string
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&chaosFlag, "chaos", "", "inject random 429/500 responses (e.g. p=0.05)")
+	_ = rootCmd.PersistentFlags().MarkHidden("chaos")
+
+	cobra.OnInitialize(func() {
+		if chaosFlag == "" {
+			return
+		}
+		p, err := parseChaosProbability(chaosFlag)
+		if err != nil {
+			cobra.CheckErr(err)
+		}
+		github.SetChaosProbability(p)
+	})
+}
+
+// parseChaosProbability accepts "p=0.05" or a bare "0.05".
+func parseChaosProbability(raw string) (float64, error) {
+	v := strings.TrimPrefix(strings.TrimSpace(raw), "p=")
+	p, err := strconv.ParseFloat(v, 64)
+	if err != nil || p < 0 || p > 1 {
+		return 0, fmt.Errorf("invalid --chaos %q: expected p=<probability between 0 and 1>", raw)
+	}
+	return p, nil
+}
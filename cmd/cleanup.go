@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
+)
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Delete empty cost centers",
+	Long: `Find active cost centers with no users, repositories or other resources
+and delete them.  The billing API archives deleted cost centers: they move
+to the "deleted" state, keep their name and can be restored in enterprise
+billing settings.
+
+--empty is required.  --name-prefix and --created-by-tool narrow the
+candidates, e.g. to the cost centers the teams auto strategy creates.  The
+cost centers the configuration maps or configures, in any mode, are never
+deleted.
+
+Examples:
+  gh cost-center cleanup --empty
+  gh cost-center cleanup --empty --name-prefix "[org team] " --mode apply
+  gh cost-center cleanup --empty --created-by-tool --mode apply --yes`,
+	Args: cobra.NoArgs,
+	RunE: runCleanup,
+}
+
+var (
+	cleanupEmpty         bool
+	cleanupNamePrefixes  []string
+	cleanupCreatedByTool bool
+	cleanupMode          string
+	cleanupYes           bool
+)
+
+func init() {
+	f := cleanupCmd.Flags()
+	f.BoolVar(&cleanupEmpty, "empty", false, "delete cost centers with no resources")
+	f.StringSliceVar(&cleanupNamePrefixes, "name-prefix", nil, "only cost centers whose name starts with one of these prefixes (repeatable)")
+	f.BoolVar(&cleanupCreatedByTool, "created-by-tool", false, "only cost centers this tool recorded creating in the export directory")
+	f.StringVar(&cleanupMode, "mode", "plan", "execution mode: plan (preview) or apply (push changes)")
+	f.BoolVarP(&cleanupYes, "yes", "y", false, "skip confirmation prompt in apply mode")
+	f.BoolVar(&skipPermissionCheck, "skip-permission-check", false, "do not check the token's scopes before the cleanup")
+	rootCmd.AddCommand(cleanupCmd)
+}
+
+// cleanupFilter decides which active cost centers cleanup considers.
+type cleanupFilter struct {
+	prefixes  []string
+	created   map[string]time.Time // nil unless --created-by-tool
+	protected map[string]bool      // names and IDs never deleted
+}
+
+// skipReason returns why the cost center is not a candidate, or "".
+func (f cleanupFilter) skipReason(name, id string) string {
+	switch {
+	case f.protected[name] || f.protected[id]:
+		return "configured or mapped"
+	case len(f.prefixes) > 0 && !hasAnyPrefix(name, f.prefixes):
+		return "name prefix"
+	case f.created != nil && f.created[id].IsZero():
+		return "not created by this tool"
+	}
+	return ""
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func runCleanup(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	if !cleanupEmpty {
+		return fmt.Errorf("nothing to clean up: pass --empty")
+	}
+	if cleanupMode != "plan" && cleanupMode != "apply" {
+		return fmt.Errorf("invalid --mode %q: must be 'plan' or 'apply'", cleanupMode)
+	}
+	logger := slog.Default()
+
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	// The cost center cache must forget deleted cost centers.
+	attachCache(client, logger)
+	if err := checkPermissions(ctx, client, "cleanup", []string{cfgManager.CostCenterMode}, cleanupMode == "apply"); err != nil {
+		return err
+	}
+
+	// Configured cost centers may be empty between runs but are still in
+	// use.
+	configured, err := cfgManager.ConfiguredCostCenters()
+	if err != nil {
+		return err
+	}
+	filter := cleanupFilter{prefixes: cleanupNamePrefixes, protected: make(map[string]bool)}
+	for _, cc := range configured {
+		filter.protected[cc] = true
+	}
+	if cleanupCreatedByTool {
+		if filter.created, err = cfgManager.CostCenterCreationTimes(); err != nil {
+			return err
+		}
+	}
+
+	active, err := client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching active cost centers: %w", err)
+	}
+	var empty []string
+	for _, name := range sortedNames(active) {
+		id := active[name]
+		if reason := filter.skipReason(name, id); reason != "" {
+			logger.Debug("Skipping cost center", "name", name, "reason", reason)
+			continue
+		}
+		detail, err := client.GetCostCenter(ctx, id)
+		if err != nil {
+			return fmt.Errorf("fetching cost center %q: %w", name, err)
+		}
+		if len(detail.Resources) == 0 {
+			empty = append(empty, name)
+		}
+	}
+	printCleanupPlan(os.Stdout, empty)
+	if cleanupMode != "apply" || len(empty) == 0 {
+		return nil
+	}
+	if !cleanupYes {
+		ok, err := confirmCleanup(len(empty))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			logger.Warn("Cleanup aborted by user")
+			return nil
+		}
+	}
+
+	sink, err := attachAuditSink(client, logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := sink.Close(); cerr != nil {
+			logger.Warn("Audit sink", "error", cerr)
+		}
+	}()
+
+	var failures []string
+	for _, name := range empty {
+		if err := client.DeleteCostCenter(ctx, active[name], name); err != nil {
+			logger.Error("Failed to delete cost center", "name", name, "error", err)
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d cost centers not deleted: %s", len(failures), len(empty), strings.Join(failures, "; "))
+	}
+	logger.Info("Cleanup completed", "deleted", len(empty))
+	return nil
+}
+
+// printCleanupPlan writes the cost centers cleanup deletes.
+func printCleanupPlan(w io.Writer, empty []string) {
+	if len(empty) == 0 {
+		_, _ = fmt.Fprintln(w, "No empty cost centers to delete.")
+		return
+	}
+	_, _ = fmt.Fprintf(w, "Empty cost centers to delete (%d):\n", len(empty))
+	for _, name := range empty {
+		_, _ = fmt.Fprintf(w, "  - %s\n", name)
+	}
+}
+
+// confirmCleanup asks before deleting the cost centers.
+func confirmCleanup(n int) (bool, error) {
+	fmt.Println("\n" + i18n.T("confirm.cleanup.intro", n))
+	fmt.Print(i18n.T("confirm.proceed"))
+	scanner := bufio.NewScanner(os.Stdin)
+	if scanner.Scan() {
+		return i18n.IsYes(scanner.Text()), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("reading user input: %w", err)
+	}
+	return false, nil
+}
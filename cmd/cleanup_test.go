@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCleanupFilter(t *testing.T) {
+	f := cleanupFilter{
+		prefixes:  []string{"[org team] "},
+		created:   map[string]time.Time{"id-1": time.Now(), "id-3": time.Now()},
+		protected: map[string]bool{"id-3": true},
+	}
+	tests := []struct {
+		name, id, want string
+	}{
+		{"[org team] acme/old", "id-1", ""},
+		{"Finance", "id-1", "name prefix"},
+		{"[org team] acme/manual", "id-2", "not created by this tool"},
+		{"[org team] acme/no-prus", "id-3", "configured or mapped"},
+	}
+	for _, tt := range tests {
+		if got := f.skipReason(tt.name, tt.id); got != tt.want {
+			t.Errorf("skipReason(%q, %q) = %q, want %q", tt.name, tt.id, got, tt.want)
+		}
+	}
+	if got := (cleanupFilter{}).skipReason("Anything", "id-9"); got != "" {
+		t.Errorf("empty filter skipReason = %q, want none", got)
+	}
+}
+
+func TestPrintCleanupPlan(t *testing.T) {
+	var buf bytes.Buffer
+	printCleanupPlan(&buf, []string{"A", "B"})
+	if !strings.Contains(buf.String(), "(2):\n  - A\n  - B\n") {
+		t.Errorf("output = %q", buf.String())
+	}
+	buf.Reset()
+	printCleanupPlan(&buf, nil)
+	if !strings.Contains(buf.String(), "No empty cost centers") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
@@ -2,10 +2,20 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
 	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+)
+
+var (
+	configSchema bool
+	configEnv    bool
 )
 
 var configCmd = &cobra.Command{
@@ -16,10 +26,37 @@ var configCmd = &cobra.Command{
 Shows enterprise, cost center mode, organizations, and mode-specific
 configuration details.
 
+With --schema, prints the JSON Schema of the configuration file instead,
+without loading it.  Point an editor's YAML language server at the schema
+for completion and inline errors.
+
+With --env, lists the GH_CC_* environment variable that overrides each
+configuration key, so the tool can be configured from CI variables without
+a config file.
+
 Examples:
   gh cost-center config
-  gh cost-center config --config path/to/config.yaml`,
+  gh cost-center config --config path/to/config.yaml
+  gh cost-center config --profile staging
+  gh cost-center config --schema > config/config.schema.json
+  gh cost-center config --env`,
+	// --schema needs no configuration; a broken one must not hide it.
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if configSchema || configEnv {
+			slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+			return nil
+		}
+		return rootCmd.PersistentPreRunE(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if configSchema {
+			_, err := os.Stdout.Write(config.Schema())
+			return err
+		}
+		if configEnv {
+			return writeEnvOverrides(os.Stdout, config.EnvOverrides())
+		}
+
 		summary := cfgManager.Summary()
 
 		// Print in sorted key order for deterministic output.
@@ -36,11 +73,35 @@ Examples:
 		}
 		fmt.Println(strings.Repeat("-", 50))
 		fmt.Printf("  config file: %s\n", cfgFile)
+		if len(cfgManager.Profiles) > 0 {
+			fmt.Printf("  profiles:    %s (select with --profile)\n", strings.Join(cfgManager.Profiles, ", "))
+		}
 
 		return nil
 	},
 }
 
+// writeEnvOverrides prints each configuration key and its environment
+// variable, in key order.
+func writeEnvOverrides(w io.Writer, vars map[string]string) error {
+	keys := make([]string, 0, len(vars))
+	width := 0
+	for k, env := range vars {
+		keys = append(keys, k)
+		width = max(width, len(env))
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, err := fmt.Fprintf(w, "%-*s  %s\n", width, vars[k], k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func init() {
+	configCmd.Flags().BoolVar(&configEnv, "env", false, "list the GH_CC_* environment variable of every configuration key and exit")
+	configCmd.Flags().BoolVar(&configSchema, "schema", false, "print the JSON Schema of the configuration file and exit")
+
 	rootCmd.AddCommand(configCmd)
 }
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
+	"github.com/renan-alm/gh-cost-center/internal/teams"
+)
+
+// stdinIsTerminal reports whether stdin is an interactive terminal, so
+// prompts that are optional (unlike confirmations) are skipped in CI.
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// conflictPrompt returns a teams.ConflictResolver that asks the operator on
+// out which cost center a user in several teams belongs to, reading answers
+// from in.  overridesFile is where remembered choices go; when empty the
+// choices apply to this run only.  Answering "s" stops the prompts for the
+// rest of the run.
+func conflictPrompt(in io.Reader, out io.Writer, overridesFile string) teams.ConflictResolver {
+	scanner := bufio.NewScanner(in)
+	stopped, warned := false, false
+
+	readLine := func() (string, bool, error) {
+		if !scanner.Scan() {
+			return "", false, scanner.Err()
+		}
+		return strings.TrimSpace(scanner.Text()), true, nil
+	}
+
+	return func(c teams.Conflict) (string, bool, error) {
+		if stopped {
+			return "", false, nil
+		}
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, i18n.T("conflict.title", c.User, len(c.Teams)))
+		for i, team := range c.Teams {
+			key := "conflict.option"
+			if i == len(c.Teams)-1 {
+				key = "conflict.current"
+			}
+			fmt.Fprintln(out, i18n.T(key, i+1, c.CostCenters[i], team))
+		}
+
+		var choice string
+		for choice == "" {
+			fmt.Fprint(out, i18n.T("conflict.choose", len(c.Teams)))
+			answer, ok, err := readLine()
+			if err != nil {
+				return "", false, fmt.Errorf("reading user input: %w", err)
+			}
+			switch {
+			case !ok || strings.EqualFold(answer, "s"):
+				stopped = true
+				return "", false, nil
+			case answer == "":
+				return "", false, nil
+			}
+			if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(c.Teams) {
+				choice = c.CostCenters[n-1]
+			}
+		}
+
+		if overridesFile == "" {
+			if !warned {
+				fmt.Fprintln(out, i18n.T("conflict.no_file"))
+				warned = true
+			}
+			return choice, false, nil
+		}
+		fmt.Fprint(out, i18n.T("conflict.remember", overridesFile))
+		answer, _, err := readLine()
+		if err != nil {
+			return "", false, fmt.Errorf("reading user input: %w", err)
+		}
+		return choice, i18n.IsYes(answer), nil
+	}
+}
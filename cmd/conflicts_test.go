@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/teams"
+)
+
+func TestConflictPrompt(t *testing.T) {
+	c := teams.Conflict{
+		User:        "alice",
+		Teams:       []string{"org/backend", "org/frontend"},
+		CostCenters: []string{"Backend", "Frontend"},
+		Current:     "Frontend",
+	}
+
+	var out bytes.Buffer
+	resolve := conflictPrompt(strings.NewReader("7\n1\nyes\n\n"), &out, "overrides.yaml")
+	cc, remember, err := resolve(c)
+	if err != nil || cc != "Backend" || !remember {
+		t.Errorf("first answer = %q, %v, %v; want Backend remembered (an out-of-range choice is asked again)", cc, remember, err)
+	}
+	if !strings.Contains(out.String(), "[current: conflict strategy]") {
+		t.Errorf("prompt does not mark the current choice:\n%s", out.String())
+	}
+	if cc, _, err := resolve(c); err != nil || cc != "" {
+		t.Errorf("Enter = %q, %v; want the current choice kept", cc, err)
+	}
+
+	resolve = conflictPrompt(strings.NewReader("s\n2\n"), &out, "")
+	if cc, _, _ := resolve(c); cc != "" {
+		t.Errorf("s = %q, want no choice", cc)
+	}
+	if cc, _, _ := resolve(c); cc != "" {
+		t.Errorf("after s = %q, want no more prompts", cc)
+	}
+
+	out.Reset()
+	resolve = conflictPrompt(strings.NewReader("2\n"), &out, "")
+	if cc, remember, _ := resolve(c); cc != "Frontend" || remember {
+		t.Errorf("without overrides file = %q, %v; want Frontend for this run only", cc, remember)
+	}
+	if !strings.Contains(out.String(), "overrides_file") {
+		t.Errorf("expected a hint about cost_center.overrides_file:\n%s", out.String())
+	}
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
+)
+
+// firstRunPreviewLimit is how many of the largest changes the first-run
+// preview lists.
+const firstRunPreviewLimit = 20
+
+// plannedChange is one cost center in the first-run preview.
+type plannedChange struct {
+	CostCenter string
+	Count      int
+	Unit       string // "users" or "repositories"
+}
+
+// firstRunConfirmed is set once the operator has confirmed the first apply
+// against the enterprise, so later modes of an --modes run are not gated
+// again.
+var firstRunConfirmed bool
+
+// needsFirstRunConsent reports whether this is an interactive apply (no
+// --yes) against an enterprise with no recorded prior apply.  First runs are
+// the most destructive, so they get an expanded preview and must be
+// confirmed by typing the enterprise slug.
+func needsFirstRunConsent() (bool, error) {
+	if assignMode != "apply" || assignYes || firstRunConfirmed {
+		return false, nil
+	}
+	prior, err := cfgManager.HasAppliedBefore()
+	if err != nil {
+		return false, fmt.Errorf("checking apply history: %w", err)
+	}
+	return !prior, nil
+}
+
+// confirmFirstRun shows the first-run banner and preview and returns true
+// only if the operator types the enterprise slug.  toRemove lists the cost
+// centers of deleted teams that cost_center.teams.deleted_teams empties or
+// deletes.
+func confirmFirstRun(changes []plannedChange, toCreate, toRemove []string) (bool, error) {
+	printFirstRunPreview(cfgManager.Enterprise, changes, toCreate, cfgManager.TeamsDeletedTeams, toRemove)
+
+	fmt.Print("\n" + i18n.T("consent.type_slug", cfgManager.Enterprise))
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return false, fmt.Errorf("reading user input: %w", err)
+		}
+		return false, nil
+	}
+	if strings.TrimSpace(scanner.Text()) != cfgManager.Enterprise {
+		return false, nil
+	}
+	firstRunConfirmed = true
+	return true, nil
+}
+
+// printFirstRunPreview prints the first-run banner: totals, the cost
+// centers that would be created, those deleted_teams would empty or delete,
+// and the largest changes.
+func printFirstRunPreview(enterprise string, changes []plannedChange, toCreate []string, deletedTeams string, toRemove []string) {
+	totals := make(map[string]int)
+	for _, c := range changes {
+		totals[c.Unit] += c.Count
+	}
+	units := make([]string, 0, len(totals))
+	for u := range totals {
+		units = append(units, u)
+	}
+	sort.Strings(units)
+
+	fmt.Println()
+	fmt.Println(strings.Repeat("=", 80))
+	fmt.Println(i18n.T("consent.title", enterprise))
+	fmt.Println(strings.Repeat("=", 80))
+	fmt.Println(i18n.T("consent.no_history"))
+	fmt.Println(i18n.T("consent.review"))
+	fmt.Println()
+	fmt.Println(i18n.T("consent.affected", len(changes)))
+	for _, u := range units {
+		fmt.Println(i18n.T("consent.total", i18n.T("unit."+u), totals[u]))
+	}
+
+	fmt.Println(i18n.T("consent.to_create", len(toCreate)))
+	for _, name := range toCreate {
+		fmt.Printf("  + %s\n", name)
+	}
+	if len(toRemove) > 0 {
+		fmt.Println(i18n.T("consent.deleted."+deletedTeams, len(toRemove)))
+		for _, name := range toRemove {
+			fmt.Printf("  - %s\n", name)
+		}
+	}
+
+	if top := largestChanges(changes, firstRunPreviewLimit); len(top) > 0 {
+		fmt.Println("\n" + i18n.T("consent.largest", len(top), len(changes)))
+		for _, c := range top {
+			fmt.Printf("  %6d %-12s -> %s\n", c.Count, i18n.T("unit."+c.Unit), c.CostCenter)
+		}
+	}
+	fmt.Println(strings.Repeat("=", 80))
+}
+
+// largestChanges returns up to limit changes ordered by descending count,
+// then by cost center name.
+func largestChanges(changes []plannedChange, limit int) []plannedChange {
+	sorted := make([]plannedChange, len(changes))
+	copy(sorted, changes)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].CostCenter < sorted[j].CostCenter
+	})
+	return sorted[:min(len(sorted), limit)]
+}
+
+// missingCostCenters returns the names (sorted, UUIDs skipped) that are not
+// active cost centers, i.e. those an apply would create.
+func missingCostCenters(ctx context.Context, client *github.Client, names []string) ([]string, error) {
+	active, err := client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching active cost centers: %w", err)
+	}
+	seen := make(map[string]bool, len(names))
+	var missing []string
+	for _, n := range names {
+		if n == "" || seen[n] || github.IsValidCostCenterUUID(n) {
+			continue
+		}
+		seen[n] = true
+		if _, ok := active[n]; !ok {
+			missing = append(missing, n)
+		}
+	}
+	sort.Strings(missing)
+	return missing, nil
+}
+
+// recordApply notes the apply in the export dir so later runs against the
+// enterprise are not treated as first runs.  Call it only once the apply
+// has returned successfully: a failed or cancelled apply must leave the
+// next run gated.  Failures are logged only.
+func recordApply(logger *slog.Logger) {
+	if err := cfgManager.RecordApply(); err != nil {
+		logger.Warn("Could not record apply history", "error", err)
+	}
+}
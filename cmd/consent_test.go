@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLargestChanges(t *testing.T) {
+	var changes []plannedChange
+	for i := range 25 {
+		changes = append(changes, plannedChange{CostCenter: fmt.Sprintf("cc-%02d", i), Count: i % 5, Unit: "users"})
+	}
+
+	top := largestChanges(changes, firstRunPreviewLimit)
+	if len(top) != firstRunPreviewLimit {
+		t.Fatalf("got %d changes, want %d", len(top), firstRunPreviewLimit)
+	}
+	if top[0].CostCenter != "cc-04" || top[0].Count != 4 {
+		t.Errorf("first = %+v, want cc-04 with 4", top[0])
+	}
+	for i := 1; i < len(top); i++ {
+		if top[i].Count > top[i-1].Count {
+			t.Fatalf("not sorted by count: %+v", top)
+		}
+	}
+	if changes[0].CostCenter != "cc-00" {
+		t.Error("input slice was reordered")
+	}
+}
@@ -0,0 +1,559 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/customprop"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/pru"
+	"github.com/renan-alm/gh-cost-center/internal/repository"
+	"github.com/renan-alm/gh-cost-center/internal/state"
+	"github.com/renan-alm/gh-cost-center/internal/teams"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare current cost center membership with the desired state",
+	Long: `Compute the desired assignments for the configured mode and compare them
+with what is currently assigned, without changing anything.
+
+For each cost center the configuration produces, users (users and teams
+modes) or repositories (repos and custom-prop modes) are reported as:
+  correct    - already in the cost center
+  missing    - not in the cost center
+  misplaced  - currently in another cost center
+  extra      - in the cost center but not wanted there
+
+Misplaced resources are found among the cost centers being compared.  With
+--check-membership every missing resource is also looked up through the
+memberships API, which finds moves from any cost center but makes one call
+per resource.
+
+With --snapshot the desired state is a saved snapshot (see the snapshot
+command) instead of the configuration, showing both users and repositories
+that changed since it was taken.
+
+Unlike plan, diff never intends to apply and only needs read access, so it
+works with read-only tokens.
+
+Examples:
+  gh cost-center diff
+  gh cost-center diff --format json | jq '.cost_centers[] | select(.missing != [])'
+  gh cost-center diff --exit-code   # exit 1 when anything differs
+  gh cost-center diff --snapshot latest`,
+	RunE: runDiff,
+}
+
+var (
+	diffFormat          string
+	diffCheckMembership bool
+	diffExitCode        bool
+	diffSnapshot        string
+)
+
+func init() {
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "output format: text or json")
+	diffCmd.Flags().BoolVar(&diffCheckMembership, "check-membership", false, "look up the current cost center of every missing resource")
+	diffCmd.Flags().BoolVar(&diffExitCode, "exit-code", false, "exit with status 1 when current and desired state differ")
+	diffCmd.Flags().StringVar(&diffSnapshot, "snapshot", "", "compare current membership with a saved snapshot (ID or \"latest\") instead of the configuration")
+	diffCmd.Flags().BoolVar(&skipPermissionCheck, "skip-permission-check", false, "do not check the token's scopes before the diff")
+
+	rootCmd.AddCommand(diffCmd)
+}
+
+// diffMisplaced is a resource that is in a different cost center than the
+// desired one.
+type diffMisplaced struct {
+	Name    string `json:"name"`
+	Current string `json:"current_cost_center"`
+}
+
+// diffCostCenter is the comparison for one desired cost center.
+type diffCostCenter struct {
+	Name      string          `json:"name"`
+	ID        string          `json:"id,omitempty"` // empty when it does not exist yet
+	Unit      string          `json:"unit"`         // "users" or "repositories"
+	Correct   []string        `json:"correct"`
+	Missing   []string        `json:"missing"`
+	Misplaced []diffMisplaced `json:"misplaced"`
+	Extra     []string        `json:"extra"`
+}
+
+// inSync reports whether the cost center needs no change.
+func (d diffCostCenter) inSync() bool {
+	return len(d.Missing) == 0 && len(d.Misplaced) == 0 && len(d.Extra) == 0
+}
+
+// diffDocument is the --format json output of the diff command.
+type diffDocument struct {
+	Enterprise  string           `json:"enterprise"`
+	Mode        string           `json:"mode"`
+	CostCenters []diffCostCenter `json:"cost_centers"`
+}
+
+// computeDiff compares desired (cost center -> resources) with current
+// (cost center -> resources, for cost centers that exist).  ids maps cost
+// center names to IDs.  Results are sorted by cost center name.
+func computeDiff(desired, current map[string][]string, ids map[string]string, unit string) []diffCostCenter {
+	location := make(map[string]string) // resource -> cost center it is in
+	for cc, members := range current {
+		for _, r := range members {
+			location[r] = cc
+		}
+	}
+
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make([]diffCostCenter, 0, len(names))
+	for _, name := range names {
+		d := diffCostCenter{
+			Name:      name,
+			ID:        ids[name],
+			Unit:      unit,
+			Correct:   []string{},
+			Missing:   []string{},
+			Misplaced: []diffMisplaced{},
+			Extra:     []string{},
+		}
+		want := make(map[string]bool, len(desired[name]))
+		for _, r := range desired[name] {
+			if want[r] {
+				continue
+			}
+			want[r] = true
+			switch loc, ok := location[r]; {
+			case ok && loc == name:
+				d.Correct = append(d.Correct, r)
+			case ok:
+				d.Misplaced = append(d.Misplaced, diffMisplaced{Name: r, Current: loc})
+			default:
+				d.Missing = append(d.Missing, r)
+			}
+		}
+		for _, r := range current[name] {
+			if !want[r] {
+				d.Extra = append(d.Extra, r)
+			}
+		}
+		sort.Strings(d.Correct)
+		sort.Strings(d.Missing)
+		sort.Strings(d.Extra)
+		sort.Slice(d.Misplaced, func(i, j int) bool { return d.Misplaced[i].Name < d.Misplaced[j].Name })
+		out = append(out, d)
+	}
+	return out
+}
+
+func runDiff(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	if diffFormat != "text" && diffFormat != "json" {
+		return fmt.Errorf("invalid --format %q: must be 'text' or 'json'", diffFormat)
+	}
+
+	logger := slog.Default()
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	command := "diff"
+	if diffSnapshot != "" {
+		command = "snapshot" // billing reads only
+	}
+	modes := []string{cfgManager.CostCenterMode}
+	if len(cfgManager.Sources) > 0 && diffSnapshot == "" {
+		modes = sourceModes()
+	}
+	if err := checkPermissions(ctx, client, command, modes, false); err != nil {
+		return err
+	}
+	client.SetRunCache(github.NewRunCache())
+
+	var doc diffDocument
+	if diffSnapshot != "" {
+		doc, err = snapshotDiff(ctx, client, diffSnapshot)
+	} else {
+		doc, err = configDiff(ctx, client, logger)
+	}
+	if err != nil {
+		return err
+	}
+
+	if diffCheckMembership {
+		if err := resolveMissing(ctx, client, doc.CostCenters); err != nil {
+			return err
+		}
+	}
+
+	if diffFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+	} else {
+		printDiff(os.Stdout, doc)
+	}
+
+	if diffExitCode {
+		for _, cc := range doc.CostCenters {
+			if !cc.inSync() {
+				return fmt.Errorf("current state differs from desired state")
+			}
+		}
+	}
+	return nil
+}
+
+// configDiff compares the assignments the configured mode would make with
+// current membership.
+func configDiff(ctx context.Context, client *github.Client, logger *slog.Logger) (diffDocument, error) {
+	desired, unit, err := desiredState(ctx, client, logger)
+	if err != nil {
+		return diffDocument{}, err
+	}
+
+	active, err := client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		return diffDocument{}, fmt.Errorf("fetching active cost centers: %w", err)
+	}
+	byID := make(map[string]string, len(active))
+	for name, id := range active {
+		byID[id] = name
+	}
+
+	// Desired cost centers may be given by UUID (manual team mappings);
+	// compare them under their display name.
+	byName := make(map[string][]string, len(desired))
+	ids := make(map[string]string, len(desired))
+	for name, resources := range desired {
+		display, id := name, active[name]
+		if github.IsValidCostCenterUUID(name) {
+			id = name
+			if n := byID[name]; n != "" {
+				display = n
+			}
+		}
+		byName[display] = append(byName[display], resources...)
+		ids[display] = id
+	}
+	desired = byName
+
+	current := make(map[string][]string, len(ids))
+	for name, id := range ids {
+		if id == "" {
+			continue
+		}
+		var members []string
+		if unit == "users" {
+			members, err = client.GetCostCenterMembers(ctx, id)
+		} else {
+			members, err = client.GetCostCenterRepositories(ctx, id)
+		}
+		if err != nil {
+			return diffDocument{}, fmt.Errorf("fetching members of cost center %q: %w", name, err)
+		}
+		current[name] = members
+	}
+
+	mode := cfgManager.CostCenterMode
+	if len(cfgManager.Sources) > 0 {
+		mode = "sources " + strings.Join(cfgManager.Sources, ">")
+	}
+	return diffDocument{
+		Enterprise:  cfgManager.Enterprise,
+		Mode:        mode,
+		CostCenters: computeDiff(desired, current, ids, unit),
+	}, nil
+}
+
+// snapshotDiff compares a saved snapshot with current membership.
+func snapshotDiff(ctx context.Context, client *github.Client, id string) (diffDocument, error) {
+	then, err := state.NewStore(cfgManager.ExportDir).Load(cfgManager.Enterprise, id)
+	if err != nil {
+		return diffDocument{}, err
+	}
+	now, err := state.Capture(ctx, client, cfgManager.Enterprise, "")
+	if err != nil {
+		return diffDocument{}, fmt.Errorf("reading current membership: %w", err)
+	}
+	return diffDocument{
+		Enterprise:  cfgManager.Enterprise,
+		Mode:        "snapshot " + then.ID,
+		CostCenters: diffSnapshots(then, now),
+	}, nil
+}
+
+// diffSnapshots compares the membership recorded in then (as desired) with
+// now (as current), for users and repositories.  Cost centers are matched
+// by ID and shown under their name in then; a current cost center that
+// reuses the name of a different one in then is shown with its ID appended.
+func diffSnapshots(then, now *state.Snapshot) []diffCostCenter {
+	thenByID := make(map[string]string, len(then.CostCenters))
+	thenNames := make(map[string]bool, len(then.CostCenters))
+	for _, cc := range then.CostCenters {
+		thenByID[cc.ID] = cc.Name
+		thenNames[cc.Name] = true
+	}
+
+	ids := make(map[string]string)
+	currentUsers := make(map[string][]string)
+	currentRepos := make(map[string][]string)
+	for _, cc := range now.CostCenters {
+		name, ok := thenByID[cc.ID]
+		if ok {
+			ids[name] = cc.ID
+		} else if name = cc.Name; thenNames[name] {
+			name = fmt.Sprintf("%s (%s)", cc.Name, cc.ID)
+		}
+		currentUsers[name] = cc.Users
+		currentRepos[name] = cc.Repositories
+	}
+
+	desiredUsers := make(map[string][]string)
+	desiredRepos := make(map[string][]string)
+	for _, cc := range then.CostCenters {
+		if len(cc.Users) > 0 || len(currentUsers[cc.Name]) > 0 {
+			desiredUsers[cc.Name] = cc.Users
+		}
+		if len(cc.Repositories) > 0 || len(currentRepos[cc.Name]) > 0 {
+			desiredRepos[cc.Name] = cc.Repositories
+		}
+	}
+
+	out := computeDiff(desiredUsers, currentUsers, ids, "users")
+	out = append(out, computeDiff(desiredRepos, currentRepos, ids, "repositories")...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// desiredState computes cost center -> resources for the configured mode,
+// and whether the resources are "users" or "repositories".
+func desiredState(ctx context.Context, client *github.Client, logger *slog.Logger) (map[string][]string, string, error) {
+	if len(cfgManager.Sources) > 0 {
+		res, err := resolveAssignmentSources(ctx, client, logger)
+		if err != nil {
+			return nil, "", err
+		}
+		return res.Assignments, "users", nil
+	}
+	return modeDesiredState(ctx, client, cfgManager.CostCenterMode, logger)
+}
+
+// modeDesiredState computes cost center -> resources for mode, and whether
+// the resources are "users" or "repositories".
+func modeDesiredState(ctx context.Context, client *github.Client, mode string, logger *slog.Logger) (map[string][]string, string, error) {
+	desired := make(map[string][]string)
+
+	switch mode {
+	case "teams":
+		desired, _, err := teamsDesired(ctx, client, logger)
+		if err != nil {
+			return nil, "", err
+		}
+		return desired, "users", nil
+
+	case "repos":
+		if len(cfgManager.Organizations) == 0 {
+			return nil, "", fmt.Errorf("repos mode requires at least one organization in github.organizations config")
+		}
+		mgr, err := repository.NewManager(cfgManager, client, logger)
+		if err != nil {
+			return nil, "", fmt.Errorf("initializing repository manager: %w", err)
+		}
+		summary, err := mgr.Run(ctx, cfgManager.Organizations[0], "plan", false)
+		if err != nil {
+			return nil, "", fmt.Errorf("computing repository assignment: %w", err)
+		}
+		for _, r := range summary.MappingResults {
+			desired[r.CostCenter] = append(desired[r.CostCenter], r.Repos...)
+		}
+		return desired, "repositories", nil
+
+	case "custom-prop":
+		if len(cfgManager.Organizations) == 0 {
+			return nil, "", fmt.Errorf("custom-prop mode requires at least one organization in github.organizations config")
+		}
+		mgr, err := customprop.NewManager(cfgManager, client, logger)
+		if err != nil {
+			return nil, "", fmt.Errorf("initializing custom-property manager: %w", err)
+		}
+		summary, err := mgr.Run(ctx, cfgManager.Organizations[0], "plan", false)
+		if err != nil {
+			return nil, "", fmt.Errorf("computing custom-property assignment: %w", err)
+		}
+		for _, r := range summary.Results {
+			desired[r.CostCenter] = append(desired[r.CostCenter], r.Repos...)
+		}
+		return desired, "repositories", nil
+
+	case "email-domain":
+		desired, err := emailDomainDesired(ctx, client, logger)
+		if err != nil {
+			return nil, "", err
+		}
+		return desired, "users", nil
+
+	case "rules":
+		desired, err := rulesDesired(ctx, client, logger)
+		if err != nil {
+			return nil, "", err
+		}
+		return desired, "users", nil
+
+	case "seat-org":
+		desired, err := seatOrgDesired(ctx, client, logger)
+		if err != nil {
+			return nil, "", err
+		}
+		return desired, "users", nil
+
+	case "orgs":
+		desired, err := orgsDesired(ctx, client, logger)
+		if err != nil {
+			return nil, "", err
+		}
+		return desired, "users", nil
+
+	case "idp-groups":
+		desired, err := idpGroupsDesired(ctx, client, logger)
+		if err != nil {
+			return nil, "", err
+		}
+		return desired, "users", nil
+
+	default: // users
+		desired, err := pruDesired(ctx, client, logger)
+		if err != nil {
+			return nil, "", err
+		}
+		return desired, "users", nil
+	}
+}
+
+// teamsDesired computes cost center -> users from team membership.  The
+// manager that built it knows the budgets of each cost center.
+func teamsDesired(ctx context.Context, client *github.Client, logger *slog.Logger) (map[string][]string, *teams.Manager, error) {
+	if err := teams.ResolveScope(ctx, cfgManager, client, logger); err != nil {
+		return nil, nil, err
+	}
+	mgr := teams.NewManager(cfgManager, client, logger)
+	assignments, err := mgr.BuildTeamAssignments(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("building team assignments: %w", err)
+	}
+	desired := make(map[string][]string, len(assignments))
+	for name, uas := range assignments {
+		for _, ua := range uas {
+			desired[name] = append(desired[name], ua.Username)
+		}
+	}
+	return desired, mgr, nil
+}
+
+// pruDesired computes cost center name -> users from the PRU rules, for
+// the seats --incremental and --users select.
+func pruDesired(ctx context.Context, client *github.Client, logger *slog.Logger) (map[string][]string, error) {
+	mgr := pru.NewManager(cfgManager, logger)
+	keep, _, err := seatFilter()
+	if err != nil {
+		return nil, err
+	}
+	desired := make(map[string][]string)
+	err = client.EachUniqueCopilotUser(ctx, func(u github.CopilotUser) error {
+		if !keep(u) || mgr.Skips(u) {
+			return nil
+		}
+		name := pruCostCenterName(mgr, u)
+		desired[name] = append(desired[name], u.Login)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching copilot users: %w", err)
+	}
+	return desired, nil
+}
+
+// pruCostCenterName returns the name of the cost center the PRU rules put
+// u in.
+func pruCostCenterName(mgr *pru.Manager, u github.CopilotUser) string {
+	switch {
+	case mgr.WindsDownPendingCancellations() && pru.IsPendingCancellation(u):
+		return cfgManager.WindDownCostCenterName
+	case mgr.SegregatesServerUsers() && mgr.IsServerConnected(u.Login):
+		return cfgManager.ServerCostCenterName
+	case mgr.IsException(u.Login):
+		return cfgManager.PRUsAllowedCostCenterName
+	}
+	return cfgManager.NoPRUsCostCenterName
+}
+
+// resolveMissing looks up the current cost center of every missing
+// resource and moves those found elsewhere to Misplaced.
+func resolveMissing(ctx context.Context, client *github.Client, ccs []diffCostCenter) error {
+	for i := range ccs {
+		d := &ccs[i]
+		resourceType := github.ResourceTypeUser
+		if d.Unit == "repositories" {
+			resourceType = github.ResourceTypeRepo
+		}
+		var stillMissing []string
+		for _, r := range d.Missing {
+			ref, err := client.CheckCostCenterMembership(ctx, resourceType, r)
+			if err != nil {
+				return fmt.Errorf("checking membership of %s: %w", r, err)
+			}
+			if ref == nil {
+				stillMissing = append(stillMissing, r)
+				continue
+			}
+			d.Misplaced = append(d.Misplaced, diffMisplaced{Name: r, Current: ref.Name})
+		}
+		d.Missing = append([]string{}, stillMissing...)
+		sort.Slice(d.Misplaced, func(a, b int) bool { return d.Misplaced[a].Name < d.Misplaced[b].Name })
+	}
+	return nil
+}
+
+// printDiff writes the human-readable comparison.
+func printDiff(w io.Writer, doc diffDocument) {
+	_, _ = fmt.Fprintf(w, "\n=== Cost Center Diff (%s, mode %s) ===\n", doc.Enterprise, doc.Mode)
+	var correct, missing, misplaced, extra int
+	for _, d := range doc.CostCenters {
+		correct += len(d.Correct)
+		missing += len(d.Missing)
+		misplaced += len(d.Misplaced)
+		extra += len(d.Extra)
+
+		status := "in sync"
+		if d.ID == "" {
+			status = "does not exist"
+		} else if !d.inSync() {
+			status = "differs"
+		}
+		_, _ = fmt.Fprintf(w, "\n%s (%s): %d correct, %d missing, %d misplaced, %d extra %s\n",
+			d.Name, status, len(d.Correct), len(d.Missing), len(d.Misplaced), len(d.Extra), d.Unit)
+		for _, r := range d.Missing {
+			_, _ = fmt.Fprintf(w, "  + %s\n", r)
+		}
+		for _, m := range d.Misplaced {
+			_, _ = fmt.Fprintf(w, "  ~ %s (currently in %s)\n", m.Name, m.Current)
+		}
+		for _, r := range d.Extra {
+			_, _ = fmt.Fprintf(w, "  - %s\n", r)
+		}
+	}
+	_, _ = fmt.Fprintf(w, "\nTotal: %d correct, %d missing, %d misplaced, %d extra\n", correct, missing, misplaced, extra)
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/state"
+)
+
+func TestComputeDiff(t *testing.T) {
+	desired := map[string][]string{
+		"Engineering": {"alice", "bob", "carol", "alice"},
+		"Sales":       {"dave"},
+		"New":         {"erin"},
+	}
+	current := map[string][]string{
+		"Engineering": {"alice", "mallory"},
+		"Sales":       {"bob"},
+	}
+	ids := map[string]string{"Engineering": "id-eng", "Sales": "id-sales"}
+
+	got := computeDiff(desired, current, ids, "users")
+	if len(got) != 3 || got[0].Name != "Engineering" || got[1].Name != "New" || got[2].Name != "Sales" {
+		t.Fatalf("cost centers = %+v, want sorted Engineering, New, Sales", got)
+	}
+
+	eng := got[0]
+	if strings.Join(eng.Correct, ",") != "alice" {
+		t.Errorf("correct = %v", eng.Correct)
+	}
+	if strings.Join(eng.Missing, ",") != "carol" {
+		t.Errorf("missing = %v", eng.Missing)
+	}
+	if len(eng.Misplaced) != 1 || eng.Misplaced[0].Name != "bob" || eng.Misplaced[0].Current != "Sales" {
+		t.Errorf("misplaced = %+v", eng.Misplaced)
+	}
+	if strings.Join(eng.Extra, ",") != "mallory" {
+		t.Errorf("extra = %v", eng.Extra)
+	}
+
+	if got[1].ID != "" || strings.Join(got[1].Missing, ",") != "erin" {
+		t.Errorf("new cost center = %+v", got[1])
+	}
+	if sales := got[2]; sales.inSync() || strings.Join(sales.Extra, ",") != "bob" || strings.Join(sales.Missing, ",") != "dave" {
+		t.Errorf("sales = %+v", sales)
+	}
+}
+
+func TestPrintDiff(t *testing.T) {
+	var buf bytes.Buffer
+	printDiff(&buf, diffDocument{
+		Enterprise: "ent",
+		Mode:       "teams",
+		CostCenters: computeDiff(
+			map[string][]string{"Eng": {"alice", "bob"}},
+			map[string][]string{"Eng": {"alice", "zed"}},
+			map[string]string{"Eng": "id"}, "users"),
+	})
+	out := buf.String()
+	for _, want := range []string{"Eng (differs): 1 correct, 1 missing, 0 misplaced, 1 extra users", "  + bob", "  - zed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	then := &state.Snapshot{CostCenters: []state.CostCenter{
+		{ID: "id-eng", Name: "Engineering", Users: []string{"alice", "bob"}, Repositories: []string{"org/api"}},
+		{ID: "id-old", Name: "Old", Users: []string{"carol"}},
+	}}
+	now := &state.Snapshot{CostCenters: []state.CostCenter{
+		// Renamed since the snapshot; matched by ID.
+		{ID: "id-eng", Name: "Eng", Users: []string{"alice", "dave"}, Repositories: []string{"org/api"}},
+		// Same name as a deleted cost center, different ID.
+		{ID: "id-new", Name: "Old", Users: []string{"bob"}},
+	}}
+
+	got := diffSnapshots(then, now)
+	var lines []string
+	for _, d := range got {
+		var misplaced []string
+		for _, m := range d.Misplaced {
+			misplaced = append(misplaced, m.Name+"@"+m.Current)
+		}
+		lines = append(lines, fmt.Sprintf("%s/%s id=%s correct=%v missing=%v misplaced=%v extra=%v",
+			d.Name, d.Unit, d.ID, d.Correct, d.Missing, misplaced, d.Extra))
+	}
+	want := []string{
+		"Engineering/users id=id-eng correct=[alice] missing=[] misplaced=[bob@Old (id-new)] extra=[dave]",
+		"Engineering/repositories id=id-eng correct=[org/api] missing=[] misplaced=[] extra=[]",
+		"Old/users id= correct=[] missing=[carol] misplaced=[] extra=[]",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("diffSnapshots =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
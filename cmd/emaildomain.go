@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/sources"
+)
+
+// emailDomainSource labels email-domain mode placements in the summary and
+// plan.
+const emailDomainSource = "email-domain"
+
+// emailDomainDesired computes cost center -> users from the verified
+// identity provider email, or SAML NameID, of every Copilot seat holder.
+func emailDomainDesired(ctx context.Context, client *github.Client, logger *slog.Logger) (map[string][]string, error) {
+	users, err := client.GetCopilotUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching copilot users: %w", err)
+	}
+	ids, err := client.GetEnterpriseIdentities(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching external identities: %w", err)
+	}
+	desired, noIdentity, unmatched := placeByIdentity(users, ids, cfgManager.EmailDomainCostCenter)
+	if noIdentity > 0 {
+		logger.Warn("Copilot users without an external identity are left unassigned", "users", noIdentity)
+	}
+	if len(unmatched) > 0 {
+		logger.Warn("Copilot users whose identity matches no email_domain mapping are left unassigned",
+			"users", len(unmatched), "logins", strings.Join(unmatched, ", "))
+	}
+	return desired, nil
+}
+
+// placeByIdentity maps each Copilot user to the cost center costCenter
+// names for the values of their external identity.  noIdentity counts the
+// users without one; unmatched lists those costCenter places nowhere.
+func placeByIdentity(users []github.CopilotUser, ids []github.ExternalIdentity, costCenter func(values []string) (string, bool)) (desired map[string][]string, noIdentity int, unmatched []string) {
+	byLogin := make(map[string]github.ExternalIdentity, len(ids))
+	for _, id := range ids {
+		byLogin[strings.ToLower(id.Login)] = id
+	}
+	desired = make(map[string][]string)
+	placed := make(map[string]bool)
+	for _, u := range users {
+		key := strings.ToLower(u.Login)
+		if placed[key] {
+			continue
+		}
+		placed[key] = true
+		id, ok := byLogin[key]
+		if !ok {
+			noIdentity++
+			continue
+		}
+		cc, ok := costCenter(id.Values())
+		if !ok {
+			unmatched = append(unmatched, u.Login)
+			continue
+		}
+		desired[cc] = append(desired[cc], u.Login)
+	}
+	for _, logins := range desired {
+		sort.Strings(logins)
+	}
+	sort.Strings(unmatched)
+	return desired, noIdentity, unmatched
+}
+
+// runEmailDomainAssign implements assign for email-domain mode: each
+// Copilot user is planned or applied like a plan file, in the cost center
+// of their identity's email domain.
+func runEmailDomainAssign(ctx context.Context, client *github.Client) error {
+	logger := slog.Default()
+	desired, err := emailDomainDesired(ctx, client, logger)
+	if err != nil {
+		return err
+	}
+	order := []string{emailDomainSource}
+	res := sources.Resolve(order, map[string]sources.Proposal{emailDomainSource: desired})
+	_, err = assignResolved(ctx, client, []string{"email-domain"}, order, res, logger)
+	return err
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+func TestPlaceByIdentity(t *testing.T) {
+	users := []github.CopilotUser{{Login: "Alice_acme"}, {Login: "bob_acme"}, {Login: "carol_acme"}, {Login: "dave_acme"}, {Login: "alice_acme"}}
+	ids := []github.ExternalIdentity{
+		{Login: "alice_acme", Emails: []string{"alice@acme.com"}},
+		{Login: "bob_acme", NameID: "bob@vendor.com"},
+		{Login: "carol_acme", Emails: []string{"carol@example.org"}},
+	}
+	costCenter := func(values []string) (string, bool) {
+		for _, v := range values {
+			if _, domain, ok := strings.Cut(v, "@"); ok && domain != "example.org" {
+				return domain, true
+			}
+		}
+		return "", false
+	}
+	desired, noIdentity, unmatched := placeByIdentity(users, ids, costCenter)
+
+	want := map[string][]string{"acme.com": {"Alice_acme"}, "vendor.com": {"bob_acme"}}
+	if !reflect.DeepEqual(desired, want) {
+		t.Errorf("desired = %v, want %v", desired, want)
+	}
+	if noIdentity != 1 || !reflect.DeepEqual(unmatched, []string{"carol_acme"}) {
+		t.Errorf("noIdentity = %d, unmatched = %v", noIdentity, unmatched)
+	}
+}
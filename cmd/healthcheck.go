@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/cache"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+var (
+	healthcheckTimeout time.Duration
+	healthcheckFull    bool
+)
+
+var healthcheckCmd = &cobra.Command{
+	Use:     "healthcheck",
+	Aliases: []string{"doctor"},
+	Short:   "Check the environment, token and API access the configuration needs",
+	Long: `Run a read-only self-test and print pass/fail for each item.  Exits 0 when
+healthy, 1 otherwise.
+
+Checks performed:
+  - configuration loads and validates
+  - cost center cache directory is writable
+  - the token authenticates
+  - the enterprise billing (cost centers) API responds
+
+These are quick enough for a container readiness/liveness probe.  --full,
+implied when run as "doctor", goes on with a preflight checklist:
+  - a classic token's scopes cover an apply of every configured mode
+  - the budgets API is available
+  - enterprise and organization teams can be listed
+  - organization custom properties can be read
+
+A failed check for an API the configured modes do not use is shown as a
+warning and does not fail the run.
+
+Examples:
+  gh cost-center healthcheck --timeout 5s
+  gh cost-center healthcheck --full
+  gh cost-center doctor`,
+	RunE: runHealthcheck,
+}
+
+func init() {
+	healthcheckCmd.Flags().DurationVar(&healthcheckTimeout, "timeout", 10*time.Second, "per-request HTTP timeout for API checks")
+	healthcheckCmd.Flags().BoolVar(&healthcheckFull, "full", false, "also check scopes and the budgets, teams and custom properties APIs (implied by doctor)")
+
+	rootCmd.AddCommand(healthcheckCmd)
+}
+
+// Status of a healthCheck.
+const (
+	checkOK   = "ok"
+	checkFail = "fail"
+	checkWarn = "warn"
+	checkSkip = "skip"
+)
+
+// healthCheck is one item of the healthcheck checklist.
+type healthCheck struct {
+	Name   string
+	Status string // checkOK, checkFail, checkWarn or checkSkip
+	Detail string
+}
+
+// checkResult is the check name for err: ok when nil, otherwise a failure
+// when the configured modes need it and a warning when they do not.
+func checkResult(name string, err error, needed bool) healthCheck {
+	switch {
+	case err == nil:
+		return healthCheck{Name: name, Status: checkOK}
+	case needed:
+		return healthCheck{Name: name, Status: checkFail, Detail: err.Error()}
+	default:
+		return healthCheck{Name: name, Status: checkWarn, Detail: err.Error() + " (not needed by the configured modes)"}
+	}
+}
+
+// writeHealthChecks prints the checklist, one line per check, and returns
+// the number of failures.
+func writeHealthChecks(w io.Writer, checks []healthCheck) int {
+	failed := 0
+	for _, c := range checks {
+		label := map[string]string{checkOK: "[ OK ]", checkFail: "[FAIL]", checkWarn: "[WARN]", checkSkip: "[SKIP]"}[c.Status]
+		if c.Status == checkFail {
+			failed++
+		}
+		if c.Detail == "" {
+			_, _ = fmt.Fprintf(w, "%s %s\n", label, c.Name)
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "%s %-28s %s\n", label, c.Name, c.Detail)
+	}
+	return failed
+}
+
+// runHealthcheck runs the checklist, in full with --full or as doctor.
+// API checks are skipped once the token has failed.
+func runHealthcheck(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	logger := slog.Default()
+	modes := sourceModes()
+	usesTeams := slices.Contains(modes, "teams")
+	usesProps := slices.Contains(modes, "repos") || slices.Contains(modes, "custom-prop")
+
+	var checks []healthCheck
+	finish := func() error {
+		if failed := writeHealthChecks(os.Stdout, checks); failed > 0 {
+			return fmt.Errorf("healthcheck failed: %d check(s) failed", failed)
+		}
+		fmt.Println("healthy")
+		return nil
+	}
+	skipRest := func() error {
+		checks = append(checks, healthCheck{Name: "API access", Status: checkSkip, Detail: "token check failed"})
+		return finish()
+	}
+
+	// Configuration was loaded by PersistentPreRunE; reaching here means it
+	// parsed and validated.
+	checks = append(checks, healthCheck{Name: "config", Status: checkOK})
+
+	cc, err := cache.New("", logger)
+	if err == nil {
+		err = cc.CheckWritable()
+	}
+	checks = append(checks, checkResult("cache", err, true))
+
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		checks = append(checks, checkResult("token", err, true))
+		return skipRest()
+	}
+	client.SetTimeout(healthcheckTimeout)
+	if err := client.VerifyToken(ctx); err != nil {
+		checks = append(checks, checkResult("token", err, true))
+		return skipRest()
+	}
+	checks = append(checks, healthCheck{Name: "token", Status: checkOK})
+	checks = append(checks, checkResult("billing-api", client.ProbeCostCenters(ctx), true))
+	if !healthcheckFull && cmd.CalledAs() != "doctor" {
+		return finish()
+	}
+
+	// Scopes: what an apply of every configured mode needs.
+	var reqs []github.PermissionRequirement
+	settings := permissionSettings()
+	for _, mode := range modes {
+		reqs = append(reqs, github.RequiredPermissions("assign", mode, settings, true)...)
+	}
+	if granted := client.PermissionReport().GrantedScopes; granted == nil {
+		checks = append(checks, healthCheck{Name: "scopes", Status: checkSkip,
+			Detail: "the token does not report its scopes (fine-grained PAT or GitHub App); the checks below show what it can reach"})
+	} else if err := client.CheckPermissions(ctx, reqs); err != nil {
+		checks = append(checks, checkResult("scopes", err, true))
+	} else {
+		checks = append(checks, healthCheck{Name: "scopes", Status: checkOK, Detail: "granted: " + strings.Join(granted, ", ")})
+	}
+
+	_, err = client.ListBudgets(ctx)
+	checks = append(checks, checkResult("budgets-api", err, cfgManager.BudgetsEnabled))
+
+	// Teams: enterprise teams unless the scope is organization; with "auto"
+	// scope the organizations are the fallback, so enterprise teams are not
+	// required.
+	if cfgManager.TeamsScope != "organization" {
+		checks = append(checks, checkResult("teams-api (enterprise)", client.ProbeEnterpriseTeams(ctx),
+			usesTeams && cfgManager.TeamsScope == "enterprise"))
+	}
+	orgs := cfgManager.Organizations
+	if len(orgs) == 0 {
+		checks = append(checks, healthCheck{Name: "custom-properties", Status: checkSkip, Detail: "no github.organizations configured"})
+		return finish()
+	}
+	for _, org := range orgs {
+		checks = append(checks, checkResult("teams-api ("+org+")", client.ProbeOrgTeams(ctx, org),
+			usesTeams && cfgManager.TeamsScope != "enterprise"))
+	}
+	for _, org := range orgs {
+		checks = append(checks, checkResult("custom-properties ("+org+")", client.ProbeOrgCustomProperties(ctx, org), usesProps))
+	}
+	return finish()
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckResult(t *testing.T) {
+	if c := checkResult("x", nil, true); c.Status != checkOK {
+		t.Errorf("nil error: %+v", c)
+	}
+	if c := checkResult("x", errors.New("403"), true); c.Status != checkFail || c.Detail != "403" {
+		t.Errorf("needed: %+v", c)
+	}
+	if c := checkResult("x", errors.New("403"), false); c.Status != checkWarn || !strings.Contains(c.Detail, "not needed") {
+		t.Errorf("not needed: %+v", c)
+	}
+}
+
+func TestWriteHealthChecks(t *testing.T) {
+	var buf bytes.Buffer
+	failed := writeHealthChecks(&buf, []healthCheck{
+		{Name: "token", Status: checkOK},
+		{Name: "scopes", Status: checkFail, Detail: "missing read:org"},
+		{Name: "budgets-api", Status: checkWarn, Detail: "404"},
+		{Name: "custom-properties", Status: checkSkip, Detail: "no orgs"},
+	})
+	if failed != 1 {
+		t.Errorf("failed = %d, want 1", failed)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	for i, prefix := range []string{"[ OK ] token", "[FAIL] scopes", "[WARN] budgets-api", "[SKIP] custom-properties"} {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d = %q, want prefix %q", i, lines[i], prefix)
+		}
+	}
+}
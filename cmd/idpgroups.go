@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/sources"
+)
+
+// idpGroupsDesired computes cost center -> users from the identity
+// provider groups provisioned into github.organizations.  A group
+// available to several organizations is read once.
+func idpGroupsDesired(ctx context.Context, client *github.Client, logger *slog.Logger) (map[string][]string, error) {
+	members := make(map[string][]string)
+	read := make(map[int64]bool)
+	for _, org := range cfgManager.Organizations {
+		groups, err := client.GetOrgExternalGroups(ctx, org)
+		if err != nil {
+			return nil, fmt.Errorf("listing external groups: %w", err)
+		}
+		for _, g := range groups {
+			if read[g.ID] || !mapsIdPGroup(cfgManager.IdPGroupsMappings, g.Name) {
+				continue
+			}
+			read[g.ID] = true
+			ms, err := client.GetExternalGroupMembers(ctx, org, g.ID)
+			if err != nil {
+				return nil, fmt.Errorf("reading external group %q: %w", g.Name, err)
+			}
+			for _, m := range ms {
+				if m.Login != "" {
+					members[g.Name] = append(members[g.Name], m.Login)
+				}
+			}
+		}
+	}
+
+	desired, missing := mapIdPGroups(members, cfgManager.IdPGroupsMappings)
+	for _, group := range missing {
+		logger.Warn("Mapped IdP group not found in any organization", "group", group,
+			"organizations", strings.Join(cfgManager.Organizations, ","))
+	}
+	return desired, nil
+}
+
+// mapsIdPGroup reports whether mappings has an entry for group, matched
+// case-insensitively.
+func mapsIdPGroup(mappings map[string]string, group string) bool {
+	for name := range mappings {
+		if strings.EqualFold(name, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// mapIdPGroups turns group name -> member logins into cost center -> users
+// through mappings (group name -> cost center), matching group names
+// case-insensitively.  A user in several groups of one cost center is
+// listed once, with the login spelling of the first group by name.
+// missing lists the mapped groups absent from members, sorted.
+func mapIdPGroups(members map[string][]string, mappings map[string]string) (desired map[string][]string, missing []string) {
+	byName := make(map[string][]string, len(members))
+	for _, name := range slices.Sorted(maps.Keys(members)) {
+		key := strings.ToLower(name)
+		byName[key] = append(byName[key], members[name]...)
+	}
+
+	// Map iteration order would decide the spelling of users in several
+	// groups; walk the groups in a fixed order instead.  Keys that differ
+	// only in case are ordered by their raw spelling.
+	groups := slices.SortedFunc(maps.Keys(mappings), func(a, b string) int {
+		if c := strings.Compare(strings.ToLower(a), strings.ToLower(b)); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	desired = make(map[string][]string)
+	seen := make(map[string]map[string]bool)
+	for _, group := range groups {
+		cc := mappings[group]
+		logins, ok := byName[strings.ToLower(group)]
+		if !ok {
+			missing = append(missing, group)
+			continue
+		}
+		if seen[cc] == nil {
+			seen[cc] = make(map[string]bool)
+		}
+		for _, login := range logins {
+			key := strings.ToLower(login)
+			if seen[cc][key] {
+				continue
+			}
+			seen[cc][key] = true
+			desired[cc] = append(desired[cc], login)
+		}
+	}
+	for _, logins := range desired {
+		sort.Strings(logins)
+	}
+	sort.Strings(missing)
+	return desired, missing
+}
+
+// runIdPGroupsAssign implements assign for idp-groups mode: the members of
+// the mapped groups are planned or applied like a plan file.
+func runIdPGroupsAssign(ctx context.Context, client *github.Client) error {
+	logger := slog.Default()
+	desired, err := idpGroupsDesired(ctx, client, logger)
+	if err != nil {
+		return err
+	}
+	order := []string{sources.IdPGroups}
+	res := sources.Resolve(order, map[string]sources.Proposal{sources.IdPGroups: desired})
+	_, err = assignResolved(ctx, client, []string{"idp-groups"}, order, res, logger)
+	return err
+}
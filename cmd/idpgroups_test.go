@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMapIdPGroups(t *testing.T) {
+	members := map[string][]string{
+		"Engineering": {"carol", "alice"},
+		"Platform":    {"Alice", "dave"},
+		"Sales":       {"erin"},
+	}
+	mappings := map[string]string{
+		"engineering": "CC Eng",
+		"PLATFORM":    "CC Eng",
+		"Finance":     "CC Fin",
+	}
+	desired, missing := mapIdPGroups(members, mappings)
+
+	want := map[string][]string{"CC Eng": {"alice", "carol", "dave"}}
+	if !reflect.DeepEqual(desired, want) {
+		t.Errorf("desired = %v, want %v", desired, want)
+	}
+	if !reflect.DeepEqual(missing, []string{"Finance"}) {
+		t.Errorf("missing = %v, want [Finance]", missing)
+	}
+}
+
+func TestMapIdPGroups_Deterministic(t *testing.T) {
+	members := map[string][]string{
+		"Data":   {"BOB", "carol"},
+		"Eng":    {"Bob", "Carol"},
+		"Search": {"bob"},
+	}
+	mappings := map[string]string{
+		"eng":    "CC Eng",
+		"Eng":    "CC Eng",
+		"data":   "CC Eng",
+		"SEARCH": "CC Eng",
+	}
+	// The user in every group takes the spelling of the first group by
+	// name, whatever the map iteration order.
+	want := map[string][]string{"CC Eng": {"BOB", "carol"}}
+	for range 50 {
+		desired, missing := mapIdPGroups(members, mappings)
+		if !reflect.DeepEqual(desired, want) || missing != nil {
+			t.Fatalf("desired = %v, missing = %v; want %v and none missing", desired, missing, want)
+		}
+	}
+}
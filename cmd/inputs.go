@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// inputsFlag is the root flag that takes the whole invocation as one JSON
+// document.
+const inputsFlag = "inputs-json"
+
+// inputsConfig is the configuration given in the "config" key of the
+// --inputs-json document; when set it replaces the --config file.
+var inputsConfig []byte
+
+// inputsDefaultCommand runs when the document names no command.
+const inputsDefaultCommand = "assign"
+
+// expandInputsJSON rewrites args when they carry --inputs-json: the
+// document is read from the flag value (inline JSON, "-" for stdin, or a
+// file path), validated against the command tree, and turned into the
+// equivalent command line.  Other arguments are kept after the generated
+// ones.  Args without the flag are returned as they are.
+//
+// The document is a flat object as GitHub Actions produces it with
+// toJSON(inputs): "command" names the subcommand (default "assign"),
+// "config" holds the configuration (an object, or YAML/JSON text), and every
+// other key is a flag of the command or a global flag, with "_" accepted in
+// place of "-".  Empty strings and nulls are unset inputs and are skipped.
+// Commands with a --yes flag always get it: the run is non-interactive.
+func expandInputsJSON(root *cobra.Command, args []string, stdin io.Reader) ([]string, []byte, error) {
+	value, rest, found, err := extractInputsFlag(args)
+	if err != nil || !found {
+		return args, nil, err
+	}
+
+	var data []byte
+	switch {
+	case value == "-":
+		data, err = io.ReadAll(stdin)
+	case strings.HasPrefix(strings.TrimSpace(value), "{"):
+		data = []byte(value)
+	default:
+		data, err = os.ReadFile(value)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading --%s: %w", inputsFlag, err)
+	}
+
+	var doc map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("parsing --%s: %w", inputsFlag, err)
+	}
+	if doc == nil {
+		return nil, nil, fmt.Errorf("parsing --%s: want a JSON object", inputsFlag)
+	}
+
+	command := inputsDefaultCommand
+	if v, ok := doc["command"]; ok && v != nil && v != "" {
+		s, ok := v.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("--%s: \"command\" must be a string", inputsFlag)
+		}
+		command = s
+	}
+	path := strings.Fields(command)
+	target, extra, err := root.Find(path)
+	if err != nil || target == root || len(extra) > 0 {
+		return nil, nil, fmt.Errorf("--%s: unknown command %q", inputsFlag, command)
+	}
+
+	var cfg []byte
+	switch v := doc["config"].(type) {
+	case nil:
+	case string:
+		if v != "" {
+			cfg = []byte(v)
+		}
+	case map[string]any:
+		if cfg, err = json.Marshal(v); err != nil {
+			return nil, nil, fmt.Errorf("--%s: encoding \"config\": %w", inputsFlag, err)
+		}
+	default:
+		return nil, nil, fmt.Errorf("--%s: \"config\" must be an object or a string", inputsFlag)
+	}
+
+	keys := make([]string, 0, len(doc))
+	for k := range doc {
+		if k != "command" && k != "config" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	out := append([]string{}, path...)
+	var errs []error
+	yes := false
+	for _, k := range keys {
+		name := strings.ReplaceAll(k, "_", "-")
+		flag := lookupFlag(target, name)
+		if flag == nil || name == inputsFlag {
+			errs = append(errs, fmt.Errorf("unknown input %q for %q", k, target.CommandPath()))
+			continue
+		}
+		values, err := inputValues(doc[k])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("input %q: %w", k, err))
+			continue
+		}
+		for _, v := range values {
+			if err := validateInput(flag, v); err != nil {
+				errs = append(errs, fmt.Errorf("input %q: %w", k, err))
+				continue
+			}
+			out = append(out, "--"+name+"="+v)
+		}
+		if name == "yes" {
+			yes = true
+		}
+	}
+	if len(errs) > 0 {
+		return nil, nil, fmt.Errorf("invalid --%s: %w", inputsFlag, errors.Join(errs...))
+	}
+	if !yes && lookupFlag(target, "yes") != nil {
+		out = append(out, "--yes")
+	}
+	return append(out, rest...), cfg, nil
+}
+
+// extractInputsFlag removes --inputs-json and its value from args.
+func extractInputsFlag(args []string) (value string, rest []string, found bool, err error) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--":
+			return value, append(rest, args[i:]...), found, nil
+		case a == "--"+inputsFlag:
+			if i+1 >= len(args) {
+				return "", nil, false, fmt.Errorf("flag needs an argument: --%s", inputsFlag)
+			}
+			value, found = args[i+1], true
+			i++
+		case strings.HasPrefix(a, "--"+inputsFlag+"="):
+			value, found = strings.TrimPrefix(a, "--"+inputsFlag+"="), true
+		default:
+			rest = append(rest, a)
+		}
+	}
+	return value, rest, found, nil
+}
+
+// lookupFlag finds a local or inherited flag of cmd.
+func lookupFlag(cmd *cobra.Command, name string) *pflag.Flag {
+	if f := cmd.Flags().Lookup(name); f != nil {
+		return f
+	}
+	return cmd.InheritedFlags().Lookup(name)
+}
+
+// inputValues turns an input into flag values: one per array element,
+// nothing for an unset input.
+func inputValues(v any) ([]string, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		return []string{v}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case json.Number:
+		return []string{v.String()}, nil
+	case []any:
+		var out []string
+		for _, e := range v {
+			switch e.(type) {
+			case []any, map[string]any:
+				return nil, errors.New("arrays may only hold strings, numbers and booleans")
+			}
+			vals, err := inputValues(e)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, vals...)
+		}
+		return out, nil
+	default:
+		return nil, errors.New("must be a string, number, boolean or array")
+	}
+}
+
+// validateInput checks that v parses as a value of flag, so every bad
+// input is reported at once rather than by the first failing flag.
+func validateInput(flag *pflag.Flag, v string) error {
+	var err error
+	switch flag.Value.Type() {
+	case "bool":
+		_, err = strconv.ParseBool(v)
+	case "int":
+		_, err = strconv.Atoi(v)
+	case "float64":
+		_, err = strconv.ParseFloat(v, 64)
+	case "duration":
+		_, err = time.ParseDuration(v)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s value %q", flag.Value.Type(), v)
+	}
+	return nil
+}
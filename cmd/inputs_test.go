@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandInputsJSON(t *testing.T) {
+	doc := `{"command": "assign", "mode": "apply", "verbose": true, "skip_permission_check": "",
+		"config": {"github": {"enterprise": "acme"}}}`
+	args, cfg, err := expandInputsJSON(rootCmd, []string{"--inputs-json", doc, "-v"}, nil)
+	if err != nil {
+		t.Fatalf("expandInputsJSON: %v", err)
+	}
+	if got, want := strings.Join(args, " "), "assign --mode=apply --verbose=true --yes -v"; got != want {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+	if !strings.Contains(string(cfg), `"enterprise":"acme"`) {
+		t.Errorf("config = %s", cfg)
+	}
+}
+
+func TestExpandInputsJSON_Stdin(t *testing.T) {
+	args, cfg, err := expandInputsJSON(rootCmd, []string{"--inputs-json=-"}, strings.NewReader(`{"command": "report", "config": "github:\n  enterprise: acme\n"}`))
+	if err != nil {
+		t.Fatalf("expandInputsJSON: %v", err)
+	}
+	if strings.Join(args, " ") != "report" {
+		t.Errorf("args = %q, want [report]", args)
+	}
+	if string(cfg) != "github:\n  enterprise: acme\n" {
+		t.Errorf("config = %q", cfg)
+	}
+}
+
+func TestExpandInputsJSON_Invalid(t *testing.T) {
+	for name, doc := range map[string]string{
+		"unknown command": `{"command": "frobnicate"}`,
+		"unknown flag":    `{"mode": "plan", "colour": "blue"}`,
+		"bad bool":        `{"verbose": "maybe"}`,
+		"bad int":         `{"max_retries": "many"}`,
+		"nested value":    `{"mode": {"value": "plan"}}`,
+		"bad config":      `{"config": 42}`,
+		"not an object":   `["assign"]`,
+	} {
+		if _, _, err := expandInputsJSON(rootCmd, []string{"--inputs-json", doc}, nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestExpandInputsJSON_Absent(t *testing.T) {
+	in := []string{"assign", "--mode", "plan"}
+	args, cfg, err := expandInputsJSON(rootCmd, in, nil)
+	if err != nil || cfg != nil || strings.Join(args, " ") != "assign --mode plan" {
+		t.Errorf("got %q, %s, %v; want the args unchanged", args, cfg, err)
+	}
+}
+
+func TestExpandInputsJSON_ApplyCommand(t *testing.T) {
+	args, _, err := expandInputsJSON(rootCmd, []string{"--inputs-json", `{"command": "apply", "plan_file": "plan.json"}`}, nil)
+	if err != nil {
+		t.Fatalf("expandInputsJSON: %v", err)
+	}
+	if got, want := strings.Join(args, " "), "apply --plan-file=plan.json --yes"; got != want {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List cost centers",
+	Long: `List the cost centers of the enterprise with their ID, state and the
+number of users, repositories and other resources assigned to them.
+Nothing is changed.
+
+--state selects active (default), deleted or all cost centers; resources
+are only counted for active ones.  --name-contains keeps names containing
+the text, ignoring case, and --created-by-tool the cost centers this tool
+recorded creating in the export directory.
+
+Examples:
+  gh cost-center list
+  gh cost-center list --state all --name-contains "org team"
+  gh cost-center list --created-by-tool --format json | jq '.[] | select(.users == 0)'`,
+	Args: cobra.NoArgs,
+	RunE: runList,
+}
+
+var (
+	listState        string
+	listNameContains string
+	listCreatedByUs  bool
+	listFormat       string
+)
+
+func init() {
+	f := listCmd.Flags()
+	f.StringVar(&listState, "state", "active", "cost centers to list: active, deleted or all")
+	f.StringVar(&listNameContains, "name-contains", "", "only cost centers whose name contains this text (ignoring case)")
+	f.BoolVar(&listCreatedByUs, "created-by-tool", false, "only cost centers this tool recorded creating in the export directory")
+	f.StringVar(&listFormat, "format", "text", "output format: text or json")
+	f.BoolVar(&skipPermissionCheck, "skip-permission-check", false, "do not check the token's scopes before listing")
+	rootCmd.AddCommand(listCmd)
+}
+
+// costCenterListFilter selects the cost centers list shows.
+type costCenterListFilter struct {
+	state        string // "active", "deleted" or "all"
+	nameContains string
+	created      map[string]bool // nil unless --created-by-tool
+}
+
+// apply returns the cost centers that pass the filter, sorted by name.
+func (f costCenterListFilter) apply(ccs []github.CostCenter) []github.CostCenter {
+	needle := strings.ToLower(f.nameContains)
+	var out []github.CostCenter
+	for _, cc := range ccs {
+		if f.state != "all" && cc.State != f.state {
+			continue
+		}
+		if needle != "" && !strings.Contains(strings.ToLower(cc.Name), needle) {
+			continue
+		}
+		if f.created != nil && !f.created[cc.ID] {
+			continue
+		}
+		out = append(out, cc)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func runList(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	if listState != "active" && listState != "deleted" && listState != "all" {
+		return fmt.Errorf("invalid --state %q: must be 'active', 'deleted' or 'all'", listState)
+	}
+	if listFormat != "text" && listFormat != "json" {
+		return fmt.Errorf("invalid --format %q: must be 'text' or 'json'", listFormat)
+	}
+	logger := slog.Default()
+
+	filter := costCenterListFilter{state: listState, nameContains: listNameContains}
+	if listCreatedByUs {
+		times, err := cfgManager.CostCenterCreationTimes()
+		if err != nil {
+			return err
+		}
+		filter.created = make(map[string]bool, len(times))
+		for id := range times {
+			filter.created[id] = true
+		}
+	}
+
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	if err := checkPermissions(ctx, client, "list", []string{cfgManager.CostCenterMode}, false); err != nil {
+		return err
+	}
+	all, err := client.GetAllCostCenters(ctx)
+	if err != nil {
+		return err
+	}
+	counts, err := client.CountCostCenterResources(ctx, filter.apply(all))
+	if err != nil {
+		return err
+	}
+	return writeCostCenterList(os.Stdout, counts, listFormat)
+}
+
+// costCenterListRow is one cost center in list --format json.
+type costCenterListRow struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	State        string `json:"state"`
+	Users        int    `json:"users"`
+	Repositories int    `json:"repositories"`
+	Other        int    `json:"other_resources"`
+}
+
+// writeCostCenterList writes the cost centers as a table ("text") or a JSON
+// array ("json").
+func writeCostCenterList(w io.Writer, ccs []github.CostCenterCounts, format string) error {
+	if format == "json" {
+		rows := make([]costCenterListRow, 0, len(ccs))
+		for _, cc := range ccs {
+			rows = append(rows, costCenterListRow{
+				ID: cc.ID, Name: cc.Name, State: cc.State,
+				Users: cc.Users, Repositories: cc.Repositories, Other: cc.Other,
+			})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+
+	if len(ccs) == 0 {
+		_, err := fmt.Fprintln(w, "No cost centers match.")
+		return err
+	}
+	_, _ = fmt.Fprintf(w, "%-40s %-36s %-8s %6s %6s %6s\n", "NAME", "ID", "STATE", "USERS", "REPOS", "OTHER")
+	for _, cc := range ccs {
+		_, _ = fmt.Fprintf(w, "%-40s %-36s %-8s %6d %6d %6d\n",
+			cc.Name, cc.ID, cc.State, cc.Users, cc.Repositories, cc.Other)
+	}
+	_, err := fmt.Fprintf(w, "\n%d cost centers\n", len(ccs))
+	return err
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+func TestCostCenterListFilter(t *testing.T) {
+	ccs := []github.CostCenter{
+		{ID: "id-1", Name: "[org team] acme/web", State: "active"},
+		{ID: "id-2", Name: "Finance", State: "active"},
+		{ID: "id-3", Name: "[org team] acme/old", State: "deleted"},
+	}
+	names := func(f costCenterListFilter) string {
+		var out []string
+		for _, cc := range f.apply(ccs) {
+			out = append(out, cc.Name)
+		}
+		return strings.Join(out, ",")
+	}
+
+	if got := names(costCenterListFilter{state: "active"}); got != "Finance,[org team] acme/web" {
+		t.Errorf("active = %q", got)
+	}
+	if got := names(costCenterListFilter{state: "all", nameContains: "ORG TEAM"}); got != "[org team] acme/old,[org team] acme/web" {
+		t.Errorf("all, name contains = %q", got)
+	}
+	if got := names(costCenterListFilter{state: "all", created: map[string]bool{"id-2": true}}); got != "Finance" {
+		t.Errorf("created by tool = %q", got)
+	}
+}
+
+func TestWriteCostCenterList(t *testing.T) {
+	ccs := []github.CostCenterCounts{
+		{CostCenter: github.CostCenter{ID: "id-1", Name: "Platform", State: "active"}, Users: 3, Repositories: 2},
+	}
+	var buf bytes.Buffer
+	if err := writeCostCenterList(&buf, ccs, "text"); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.HasPrefix(lines[0], "NAME") || !strings.Contains(lines[1], "Platform") || !strings.Contains(buf.String(), "1 cost centers") {
+		t.Errorf("text output = %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := writeCostCenterList(&buf, ccs, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var rows []costCenterListRow
+	if err := json.Unmarshal(buf.Bytes(), &rows); err != nil {
+		t.Fatalf("decoding JSON: %v", err)
+	}
+	if len(rows) != 1 || rows[0].Users != 3 || rows[0].Repositories != 2 || rows[0].State != "active" {
+		t.Errorf("rows = %+v", rows)
+	}
+}
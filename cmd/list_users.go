@@ -15,7 +15,8 @@ var listUsersCmd = &cobra.Command{
 	Short: "List all Copilot license holders",
 	Long: `List all GitHub Copilot license holders in the enterprise.
 
-Shows each user with their PRU exception status.
+Shows each user with their PRU exception status and, for seats being
+offboarded, their pending cancellation date.
 
 Examples:
   gh cost-center list-users
@@ -27,7 +28,8 @@ func init() {
 	rootCmd.AddCommand(listUsersCmd)
 }
 
-func runListUsers(_ *cobra.Command, _ []string) error {
+func runListUsers(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
 	logger := slog.Default()
 
 	// Create GitHub API client.
@@ -40,7 +42,7 @@ func runListUsers(_ *cobra.Command, _ []string) error {
 	mgr := pru.NewManager(cfgManager, logger)
 
 	// Fetch Copilot users.
-	users, err := client.GetCopilotUsers()
+	users, err := client.GetCopilotUsers(ctx)
 	if err != nil {
 		return fmt.Errorf("fetching copilot users: %w", err)
 	}
@@ -53,6 +55,9 @@ func runListUsers(_ *cobra.Command, _ []string) error {
 		if mgr.IsException(u.Login) {
 			marker = " [PRUs Exception]"
 		}
+		if pru.IsPendingCancellation(u) {
+			marker += fmt.Sprintf(" [Pending cancellation %s]", u.PendingCancellationDate)
+		}
 		fmt.Printf("- %s%s\n", u.Login, marker)
 	}
 
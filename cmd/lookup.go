@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+var lookupCmd = &cobra.Command{
+	Use:   "lookup [username...]",
+	Short: "Show the cost center of users",
+	Long: `Show the cost center each user is currently assigned to, or "none", using
+the cost center memberships endpoint.  Nothing is changed.
+
+Usernames come from the arguments and from --file: one login per line,
+blank lines and lines starting with # are skipped, and "-" reads standard
+input.
+
+Examples:
+  gh cost-center lookup alice bob
+  gh cost-center lookup --file offboarding.txt --format json
+  cut -d, -f1 users.csv | gh cost-center lookup --file -`,
+	RunE: runLookup,
+}
+
+var (
+	lookupFile   string
+	lookupFormat string
+)
+
+func init() {
+	lookupCmd.Flags().StringVar(&lookupFile, "file", "", "file of logins to look up, one per line, or - for stdin")
+	lookupCmd.Flags().StringVar(&lookupFormat, "format", "text", "output format: text or json")
+	lookupCmd.Flags().BoolVar(&skipPermissionCheck, "skip-permission-check", false, "do not check the token's scopes before the lookup")
+	rootCmd.AddCommand(lookupCmd)
+}
+
+// lookupResult is the cost center of one user in lookup --format json.
+// CostCenter is nil when the user is in none.
+type lookupResult struct {
+	User       string                `json:"user"`
+	CostCenter *github.CostCenterRef `json:"cost_center"`
+	Error      string                `json:"error,omitempty"`
+}
+
+// readLogins returns the logins listed in r, one per line, skipping blank
+// lines and # comments.
+func readLogins(r io.Reader) ([]string, error) {
+	var logins []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		logins = append(logins, line)
+	}
+	return logins, scanner.Err()
+}
+
+// readLoginsFile reads logins with readLogins from path, or from standard
+// input when path is "-".
+func readLoginsFile(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("opening %s: %w", path, err)
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+	logins, err := readLogins(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return logins, nil
+}
+
+// lookupLogins cleans up the logins to look up: a leading @ is dropped and
+// repeats, ignoring case, are removed.
+func lookupLogins(logins []string) []string {
+	seen := make(map[string]bool, len(logins))
+	var out []string
+	for _, l := range logins {
+		l = strings.TrimPrefix(strings.TrimSpace(l), "@")
+		if l == "" || seen[strings.ToLower(l)] {
+			continue
+		}
+		seen[strings.ToLower(l)] = true
+		out = append(out, l)
+	}
+	return out
+}
+
+func runLookup(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if lookupFormat != "text" && lookupFormat != "json" {
+		return fmt.Errorf("invalid --format %q: must be 'text' or 'json'", lookupFormat)
+	}
+	logins := args
+	if lookupFile != "" {
+		fromFile, err := readLoginsFile(lookupFile)
+		if err != nil {
+			return err
+		}
+		logins = append(logins, fromFile...)
+	}
+	logins = lookupLogins(logins)
+	if len(logins) == 0 {
+		return fmt.Errorf("no usernames to look up: pass them as arguments or with --file")
+	}
+	logger := slog.Default()
+
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	if err := checkPermissions(ctx, client, "lookup", []string{cfgManager.CostCenterMode}, false); err != nil {
+		return err
+	}
+
+	results := make([]lookupResult, 0, len(logins))
+	failed := 0
+	for _, login := range logins {
+		ref, err := client.LookupCostCenterMembership(ctx, github.ResourceTypeUser, login)
+		res := lookupResult{User: login, CostCenter: ref}
+		if err != nil {
+			res.Error = err.Error()
+			failed++
+		}
+		results = append(results, res)
+	}
+	if err := writeLookup(os.Stdout, results, lookupFormat); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d lookups failed", failed, len(logins))
+	}
+	return nil
+}
+
+// writeLookup writes the cost center of each user as a table ("text") or
+// a JSON array ("json").
+func writeLookup(w io.Writer, results []lookupResult, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	_, _ = fmt.Fprintf(w, "%-30s %-40s %s\n", "USER", "COST CENTER", "ID")
+	for _, r := range results {
+		switch {
+		case r.Error != "":
+			_, _ = fmt.Fprintf(w, "%-30s %-40s %s\n", r.User, "(lookup failed)", r.Error)
+		case r.CostCenter == nil:
+			_, _ = fmt.Fprintf(w, "%-30s %-40s\n", r.User, "none")
+		default:
+			_, _ = fmt.Fprintf(w, "%-30s %-40s %s\n", r.User, r.CostCenter.Name, r.CostCenter.ID)
+		}
+	}
+	return nil
+}
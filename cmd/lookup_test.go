@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+func TestReadLoginsAndLookupLogins(t *testing.T) {
+	logins, err := readLogins(strings.NewReader("# offboarding\nalice\n\n  @Bob \nbob\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := lookupLogins(append([]string{"carol", "Alice"}, logins...))
+	if strings.Join(got, ",") != "carol,Alice,Bob" {
+		t.Errorf("logins = %v, want [carol Alice Bob]", got)
+	}
+}
+
+func TestWriteLookup(t *testing.T) {
+	results := []lookupResult{
+		{User: "alice", CostCenter: &github.CostCenterRef{ID: "id-1", Name: "Platform"}},
+		{User: "bob"},
+		{User: "carol", Error: "boom"},
+	}
+	var buf bytes.Buffer
+	if err := writeLookup(&buf, results, "text"); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.Contains(lines[1], "Platform") || !strings.Contains(lines[2], "none") || !strings.Contains(lines[3], "(lookup failed)") {
+		t.Errorf("text output = %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := writeLookup(&buf, results[1:2], "json"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"cost_center": null`) {
+		t.Errorf("json output = %q", buf.String())
+	}
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+var membersCmd = &cobra.Command{
+	Use:   "members <cost-center>",
+	Short: "List the users and repositories of a cost center",
+	Long: `List every resource assigned to a cost center: users, repositories and
+any other resource type.  The cost center is named by its name or UUID; a
+UUID also finds cost centers that are no longer active.  Resources are
+fetched across all pages.  Nothing is changed.
+
+Examples:
+  gh cost-center members "Platform"
+  gh cost-center members "Platform" --format csv > platform.csv
+  gh cost-center members 0f1e2d3c-4b5a-6978-8796-a5b4c3d2e1f0 --format json | jq '.users | length'`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMembers,
+}
+
+var membersFormat string
+
+func init() {
+	membersCmd.Flags().StringVar(&membersFormat, "format", "text", "output format: text, json or csv")
+	membersCmd.Flags().BoolVar(&skipPermissionCheck, "skip-permission-check", false, "do not check the token's scopes before listing")
+	rootCmd.AddCommand(membersCmd)
+}
+
+// costCenterMembers is the membership of one cost center, in members
+// --format json.
+type costCenterMembers struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	State        string            `json:"state"`
+	Users        []string          `json:"users"`
+	Repositories []string          `json:"repositories"`
+	Other        []github.Resource `json:"other,omitempty"`
+}
+
+// newCostCenterMembers sorts the resources of a cost center by type and
+// name.
+func newCostCenterMembers(id, name, state string, resources []github.Resource) costCenterMembers {
+	m := costCenterMembers{ID: id, Name: name, State: state, Users: []string{}, Repositories: []string{}}
+	for _, r := range resources {
+		switch r.Type {
+		case "User":
+			m.Users = append(m.Users, r.Name)
+		case "Repository":
+			m.Repositories = append(m.Repositories, r.Name)
+		default:
+			m.Other = append(m.Other, r)
+		}
+	}
+	sort.Strings(m.Users)
+	sort.Strings(m.Repositories)
+	sort.Slice(m.Other, func(i, j int) bool {
+		if m.Other[i].Type != m.Other[j].Type {
+			return m.Other[i].Type < m.Other[j].Type
+		}
+		return m.Other[i].Name < m.Other[j].Name
+	})
+	return m
+}
+
+func runMembers(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if membersFormat != "text" && membersFormat != "json" && membersFormat != "csv" {
+		return fmt.Errorf("invalid --format %q: must be 'text', 'json' or 'csv'", membersFormat)
+	}
+	logger := slog.Default()
+
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	if err := checkPermissions(ctx, client, "members", []string{cfgManager.CostCenterMode}, false); err != nil {
+		return err
+	}
+
+	arg := strings.TrimSpace(args[0])
+	active, err := client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		return err
+	}
+	_, id, err := resolveCostCenterArg(active, arg)
+	if err != nil {
+		if !github.IsValidCostCenterUUID(arg) {
+			return err
+		}
+		id = arg // possibly a deleted cost center
+	}
+	detail, err := client.GetCostCenter(ctx, id)
+	if err != nil {
+		return err
+	}
+	return writeMembers(os.Stdout, newCostCenterMembers(detail.ID, detail.Name, detail.State, detail.Resources), membersFormat)
+}
+
+// writeMembers writes the membership as a list ("text"), an object
+// ("json") or type,name rows with a header ("csv").
+func writeMembers(w io.Writer, m costCenterMembers, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(m)
+	case "csv":
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"type", "name"})
+		for _, u := range m.Users {
+			_ = cw.Write([]string{"User", u})
+		}
+		for _, r := range m.Repositories {
+			_ = cw.Write([]string{"Repository", r})
+		}
+		for _, r := range m.Other {
+			_ = cw.Write([]string{r.Type, r.Name})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	_, _ = fmt.Fprintf(w, "Cost center %q (%s, %s)\n", m.Name, m.ID, m.State)
+	_, _ = fmt.Fprintf(w, "\nUsers (%d):\n", len(m.Users))
+	for _, u := range m.Users {
+		_, _ = fmt.Fprintf(w, "  - %s\n", u)
+	}
+	_, _ = fmt.Fprintf(w, "\nRepositories (%d):\n", len(m.Repositories))
+	for _, r := range m.Repositories {
+		_, _ = fmt.Fprintf(w, "  - %s\n", r)
+	}
+	if len(m.Other) > 0 {
+		_, _ = fmt.Fprintf(w, "\nOther resources (%d):\n", len(m.Other))
+		for _, r := range m.Other {
+			_, _ = fmt.Fprintf(w, "  - %s %s\n", r.Type, r.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+func TestWriteMembers(t *testing.T) {
+	m := newCostCenterMembers("id-1", "Platform", "active", []github.Resource{
+		{Type: "User", Name: "bob"},
+		{Type: "Repository", Name: "acme/api"},
+		{Type: "User", Name: "alice"},
+		{Type: "Org", Name: "acme"},
+	})
+
+	var buf bytes.Buffer
+	if err := writeMembers(&buf, m, "csv"); err != nil {
+		t.Fatal(err)
+	}
+	want := "type,name\nUser,alice\nUser,bob\nRepository,acme/api\nOrg,acme\n"
+	if buf.String() != want {
+		t.Errorf("csv = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := writeMembers(&buf, m, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var got costCenterMembers
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("decoding JSON: %v", err)
+	}
+	if strings.Join(got.Users, ",") != "alice,bob" || len(got.Repositories) != 1 || len(got.Other) != 1 {
+		t.Errorf("json = %+v", got)
+	}
+
+	buf.Reset()
+	if err := writeMembers(&buf, newCostCenterMembers("id-2", "Empty", "deleted", nil), "text"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"Empty" (id-2, deleted)`, "Users (0):", "Repositories (0):"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("text output missing %q:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "Other") {
+		t.Errorf("text output lists other resources:\n%s", buf.String())
+	}
+}
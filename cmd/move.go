@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
+	"github.com/renan-alm/gh-cost-center/internal/state"
+)
+
+var moveCmd = &cobra.Command{
+	Use:   "move",
+	Short: "Move users from one cost center to another",
+	Long: `Move users from one cost center to another, for reorgs.  The cost centers
+are named by name or UUID.  --users lists the users to move; --all moves
+every user of the source cost center.  Listed users that are not in the
+source cost center are reported and left alone.
+
+Users are moved by adding them to the target cost center, which takes them
+out of the source in the same request: they are never left without a cost
+center.  Users that fail stay in the source.  Repositories are not moved.
+
+In apply mode a snapshot is taken first (skip with --no-snapshot), so the
+move can be undone with "rollback --run latest".
+
+Examples:
+  gh cost-center move --from "Platform" --to "Infrastructure" --users alice,bob
+  gh cost-center move --from "Platform" --to "Infrastructure" --all --mode apply`,
+	Args: cobra.NoArgs,
+	RunE: runMove,
+}
+
+var (
+	moveFrom       string
+	moveTo         string
+	moveUsers      []string
+	moveAll        bool
+	moveMode       string
+	moveYes        bool
+	moveNoSnapshot bool
+)
+
+func init() {
+	f := moveCmd.Flags()
+	f.StringVar(&moveFrom, "from", "", "source cost center (name or UUID)")
+	f.StringVar(&moveTo, "to", "", "target cost center (name or UUID)")
+	f.StringSliceVar(&moveUsers, "users", nil, "users to move (repeatable or comma-separated)")
+	f.BoolVar(&moveAll, "all", false, "move every user of the source cost center")
+	f.StringVar(&moveMode, "mode", "plan", "execution mode: plan (preview) or apply (push changes)")
+	f.BoolVarP(&moveYes, "yes", "y", false, "skip confirmation prompt in apply mode")
+	f.BoolVar(&moveNoSnapshot, "no-snapshot", false, "do not capture a membership snapshot before moving")
+	f.BoolVar(&skipPermissionCheck, "skip-permission-check", false, "do not check the token's scopes before the move")
+	_ = moveCmd.MarkFlagRequired("from")
+	_ = moveCmd.MarkFlagRequired("to")
+
+	rootCmd.AddCommand(moveCmd)
+}
+
+func runMove(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	if moveAll == (len(moveUsers) > 0) {
+		return fmt.Errorf("pass either --users or --all")
+	}
+	if moveMode != "plan" && moveMode != "apply" {
+		return fmt.Errorf("invalid --mode %q: must be 'plan' or 'apply'", moveMode)
+	}
+	logger := slog.Default()
+
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	if err := checkPermissions(ctx, client, "move", []string{cfgManager.CostCenterMode}, moveMode == "apply"); err != nil {
+		return err
+	}
+
+	active, err := client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		return err
+	}
+	fromName, fromID, err := resolveCostCenterArg(active, strings.TrimSpace(moveFrom))
+	if err != nil {
+		return fmt.Errorf("--from: %w", err)
+	}
+	toName, toID, err := resolveCostCenterArg(active, strings.TrimSpace(moveTo))
+	if err != nil {
+		return fmt.Errorf("--to: %w", err)
+	}
+	if fromID == toID {
+		return fmt.Errorf("--from and --to are the same cost center %q", fromName)
+	}
+
+	members, err := client.GetCostCenterMembers(ctx, fromID)
+	if err != nil {
+		return err
+	}
+	users, missing := planMove(members, lookupLogins(moveUsers), moveAll)
+	printMovePlan(os.Stdout, fromName, toName, users, missing)
+
+	if moveMode != "apply" || len(users) == 0 {
+		return nil
+	}
+	if !moveYes {
+		ok, err := confirmMove(fromName, toName, len(users))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			logger.Warn("Move aborted by user")
+			return nil
+		}
+	}
+
+	sink, err := attachAuditSink(client, logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := sink.Close(); cerr != nil {
+			logger.Warn("Audit sink", "error", cerr)
+		}
+	}()
+
+	if !moveNoSnapshot {
+		if _, err := takeSnapshot(ctx, client, state.ReasonPreApply, logger); err != nil {
+			return fmt.Errorf("%w (use --no-snapshot to move without one)", err)
+		}
+	}
+	results, err := client.AddUsersToCostCenter(ctx, toID, users, true)
+	if err != nil {
+		return fmt.Errorf("moving users to cost center %q: %w", toName, err)
+	}
+	if failed := countFailed(results); failed > 0 {
+		return fmt.Errorf("%d of %d users could not be moved to cost center %q; they remain in %q",
+			failed, len(users), toName, fromName)
+	}
+	logger.Info("Move complete", "from", fromName, "to", toName, "users", len(users))
+	return nil
+}
+
+// planMove picks the users to move out of a cost center with the given
+// members: all of them, or the requested ones (see pickMembers).
+func planMove(members, requested []string, all bool) (users, missing []string) {
+	if all {
+		users = append(users, members...)
+		sort.Strings(users)
+		return users, nil
+	}
+	return pickMembers(members, requested)
+}
+
+// pickMembers returns the requested users that are members, matched
+// ignoring case and spelled as in the cost center, and the ones that are
+// not.  Both lists are sorted.
+func pickMembers(members, requested []string) (users, missing []string) {
+	byLower := make(map[string]string, len(members))
+	for _, m := range members {
+		byLower[strings.ToLower(m)] = m
+	}
+	for _, r := range requested {
+		if m, ok := byLower[strings.ToLower(r)]; ok {
+			users = append(users, m)
+		} else {
+			missing = append(missing, r)
+		}
+	}
+	sort.Strings(users)
+	sort.Strings(missing)
+	return users, missing
+}
+
+// printMovePlan writes the users to move and those left alone.
+func printMovePlan(w io.Writer, from, to string, users, missing []string) {
+	_, _ = fmt.Fprintf(w, "Move from cost center %q to %q\n", from, to)
+	if len(users) == 0 {
+		_, _ = fmt.Fprintln(w, "  No users to move.")
+	}
+	for _, u := range users {
+		_, _ = fmt.Fprintf(w, "  %s: %s -> %s\n", u, from, to)
+	}
+	if len(missing) > 0 {
+		_, _ = fmt.Fprintf(w, "\nNot in %q, skipped (%d):\n", from, len(missing))
+		for _, u := range missing {
+			_, _ = fmt.Fprintf(w, "  - %s\n", u)
+		}
+	}
+	_, _ = fmt.Fprintf(w, "\nTotal: %d to move, %d skipped\n", len(users), len(missing))
+}
+
+// confirmMove prompts for confirmation in interactive apply mode.
+func confirmMove(from, to string, n int) (bool, error) {
+	fmt.Println("\n" + i18n.T("confirm.move.intro", n, from, to))
+	fmt.Print(i18n.T("confirm.proceed"))
+	scanner := bufio.NewScanner(os.Stdin)
+	if scanner.Scan() {
+		return i18n.IsYes(scanner.Text()), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("reading user input: %w", err)
+	}
+	return false, nil
+}
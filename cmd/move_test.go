@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPlanMove(t *testing.T) {
+	members := []string{"carol", "Alice", "bob"}
+
+	users, missing := planMove(members, nil, true)
+	if !slices.Equal(users, []string{"Alice", "bob", "carol"}) || missing != nil {
+		t.Errorf("all: users = %v, missing = %v", users, missing)
+	}
+
+	users, missing = planMove(members, []string{"alice", "dave", "carol"}, false)
+	if !slices.Equal(users, []string{"Alice", "carol"}) {
+		t.Errorf("users = %v, want [Alice carol]", users)
+	}
+	if !slices.Equal(missing, []string{"dave"}) {
+		t.Errorf("missing = %v, want [dave]", missing)
+	}
+}
+
+func TestPrintMovePlan(t *testing.T) {
+	var buf bytes.Buffer
+	printMovePlan(&buf, "Platform", "Infra", []string{"alice"}, []string{"dave"})
+	out := buf.String()
+	for _, want := range []string{
+		`Move from cost center "Platform" to "Infra"`,
+		"alice: Platform -> Infra",
+		`Not in "Platform", skipped (1):`,
+		"  - dave",
+		"Total: 1 to move, 1 skipped",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	printMovePlan(&buf, "Platform", "Infra", nil, nil)
+	if !strings.Contains(buf.String(), "No users to move.") {
+		t.Errorf("empty plan output:\n%s", buf.String())
+	}
+}
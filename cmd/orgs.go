@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/sources"
+)
+
+// orgsSource labels orgs mode placements in the summary and plan.
+const orgsSource = "orgs"
+
+// orgsDesired computes cost center -> users from organization membership.
+// A member of several organizations is placed by the first of them in
+// github.organizations.
+func orgsDesired(ctx context.Context, client *github.Client, logger *slog.Logger) (map[string][]string, error) {
+	members := make(map[string][]string, len(cfgManager.Organizations))
+	for _, org := range cfgManager.Organizations {
+		ms, err := client.GetOrgMembers(ctx, org)
+		if err != nil {
+			return nil, fmt.Errorf("listing organization members: %w", err)
+		}
+		for _, m := range ms {
+			members[org] = append(members[org], m.Login)
+		}
+	}
+	desired, shared := placeOrgMembers(cfgManager.Organizations, members, cfgManager.OrgCostCenter)
+	if shared > 0 {
+		logger.Info("Users in several organizations placed by the first of them", "users", shared)
+	}
+	return desired, nil
+}
+
+// placeOrgMembers maps the members of each organization in orgs to the
+// cost center costCenter names for it.  Logins are matched
+// case-insensitively, and a user in several organizations stays with the
+// first; shared counts those users.
+func placeOrgMembers(orgs []string, members map[string][]string, costCenter func(org string) string) (desired map[string][]string, shared int) {
+	desired = make(map[string][]string)
+	placed := make(map[string]bool)
+	for _, org := range orgs {
+		cc := costCenter(org)
+		for _, login := range members[org] {
+			key := strings.ToLower(login)
+			if placed[key] {
+				shared++
+				continue
+			}
+			placed[key] = true
+			desired[cc] = append(desired[cc], login)
+		}
+	}
+	for _, logins := range desired {
+		sort.Strings(logins)
+	}
+	return desired, shared
+}
+
+// orgLeavers returns the current members of a cost center that are not in
+// want, sorted.  Logins are matched case-insensitively.
+func orgLeavers(current, want []string) []string {
+	keep := make(map[string]bool, len(want))
+	for _, login := range want {
+		keep[strings.ToLower(login)] = true
+	}
+	var stale []string
+	for _, login := range current {
+		if !keep[strings.ToLower(login)] {
+			stale = append(stale, login)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// syncOrgLeavers finds the users in each existing organization cost
+// center who are no longer members of its organization, and removes them
+// in apply mode when cost_center.orgs.remove_unmatched_users is set.
+// Otherwise they are only reported.
+func syncOrgLeavers(ctx context.Context, client *github.Client, desired map[string][]string, logger *slog.Logger) error {
+	active, err := client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching active cost centers: %w", err)
+	}
+	remove := cfgManager.OrgsRemoveUnmatchedUsers && assignMode == "apply"
+
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var failed []string
+	for _, name := range names {
+		id := active[name]
+		if id == "" && github.IsValidCostCenterUUID(name) {
+			id = name
+		}
+		if id == "" {
+			continue // created by this run, so no stale members
+		}
+		current, err := client.GetCostCenterMembers(ctx, id)
+		if err != nil {
+			logger.Error("Failed to get cost center members", "cost_center", name, "error", err)
+			failed = append(failed, name)
+			continue
+		}
+		stale := orgLeavers(current, desired[name])
+		if len(stale) == 0 {
+			continue
+		}
+		if !remove {
+			msg := "Users no longer in the organization (NOT removed -- full sync disabled)"
+			if cfgManager.OrgsRemoveUnmatchedUsers {
+				msg = "Would remove users no longer in the organization"
+			}
+			logger.Warn(msg, "cost_center", name, "count", len(stale), "users", strings.Join(stale, ","))
+			continue
+		}
+		logger.Info("Removing users no longer in the organization", "cost_center", name, "count", len(stale))
+		if _, err := client.RemoveUsersFromCostCenter(ctx, id, stale); err != nil {
+			logger.Error("Failed to remove users", "cost_center", name, "error", err)
+			failed = append(failed, name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("full sync failed for cost centers: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// runOrgsAssign implements assign for orgs mode: the members of each
+// organization are planned or applied like a plan file, then users who
+// left an organization are reported or, with full sync, removed.
+func runOrgsAssign(ctx context.Context, client *github.Client) error {
+	logger := slog.Default()
+	desired, err := orgsDesired(ctx, client, logger)
+	if err != nil {
+		return err
+	}
+	order := []string{orgsSource}
+	res := sources.Resolve(order, map[string]sources.Proposal{orgsSource: desired})
+	proceeded, err := assignResolved(ctx, client, []string{"orgs"}, order, res, logger)
+	if err != nil || !proceeded {
+		return err
+	}
+	return syncOrgLeavers(ctx, client, desired, logger)
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlaceOrgMembers(t *testing.T) {
+	members := map[string][]string{
+		"acme":  {"carol", "alice"},
+		"infra": {"Alice", "dave"},
+	}
+	costCenter := func(org string) string { return "[org] " + org }
+	desired, shared := placeOrgMembers([]string{"acme", "infra", "empty"}, members, costCenter)
+
+	want := map[string][]string{
+		"[org] acme":  {"alice", "carol"},
+		"[org] infra": {"dave"},
+	}
+	if !reflect.DeepEqual(desired, want) {
+		t.Errorf("desired = %v, want %v", desired, want)
+	}
+	if shared != 1 {
+		t.Errorf("shared = %d, want 1", shared)
+	}
+}
+
+func TestOrgLeavers(t *testing.T) {
+	got := orgLeavers([]string{"zed", "Alice", "bob"}, []string{"alice", "carol"})
+	if !reflect.DeepEqual(got, []string{"bob", "zed"}) {
+		t.Errorf("orgLeavers = %v, want [bob zed]", got)
+	}
+	if got := orgLeavers([]string{"alice"}, []string{"alice"}); got != nil {
+		t.Errorf("orgLeavers = %v, want none", got)
+	}
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+// orphansDocument is the report --orphans --format json output.
+type orphansDocument struct {
+	Enterprise   string         `json:"enterprise"`
+	GeneratedAt  time.Time      `json:"generated_at"`
+	CopilotSeats int            `json:"copilot_seats"`
+	Orphans      []orphanedUser `json:"orphans"`
+}
+
+// orphanedUser is a Copilot seat holder in no cost center.
+type orphanedUser struct {
+	Login                   string `json:"login"`
+	Name                    string `json:"name,omitempty"`
+	Email                   string `json:"email,omitempty"`
+	LastActivityAt          string `json:"last_activity_at,omitempty"`
+	PendingCancellationDate string `json:"pending_cancellation_date,omitempty"`
+}
+
+// runOrphansReport lists the Copilot seat holders that no active cost center
+// has as a member.  With --fail-on-orphans it fails when there are any.
+func runOrphansReport(ctx context.Context) error {
+	logger := slog.Default()
+
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	if err := checkPermissions(ctx, client, "orphans", []string{cfgManager.CostCenterMode}, false); err != nil {
+		return err
+	}
+
+	seats, err := client.GetCopilotUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching copilot users: %w", err)
+	}
+	active, err := client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		return err
+	}
+	var assigned []string
+	for name, id := range active {
+		users, err := client.GetCostCenterMembers(ctx, id)
+		if err != nil {
+			return fmt.Errorf("fetching members of cost center %q: %w", name, err)
+		}
+		assigned = append(assigned, users...)
+	}
+
+	doc := orphansDocument{
+		Enterprise:   cfgManager.Enterprise,
+		GeneratedAt:  time.Now().UTC(),
+		CopilotSeats: len(seats),
+		Orphans:      findOrphans(seats, assigned),
+	}
+	logger.Info("Orphaned Copilot users", "seats", len(seats), "cost_centers", len(active), "orphans", len(doc.Orphans))
+	if err := writeOrphans(os.Stdout, doc, reportFormat); err != nil {
+		return err
+	}
+	if reportFailOnOrphans && len(doc.Orphans) > 0 {
+		return fmt.Errorf("%d Copilot users are not in any cost center", len(doc.Orphans))
+	}
+	return nil
+}
+
+// findOrphans returns the seat holders whose login, ignoring case, is not
+// among the assigned users, sorted by login.
+func findOrphans(seats []github.CopilotUser, assigned []string) []orphanedUser {
+	in := make(map[string]bool, len(assigned))
+	for _, u := range assigned {
+		in[strings.ToLower(u)] = true
+	}
+	orphans := []orphanedUser{}
+	for _, s := range seats {
+		if in[strings.ToLower(s.Login)] {
+			continue
+		}
+		orphans = append(orphans, orphanedUser{
+			Login:                   s.Login,
+			Name:                    s.Name,
+			Email:                   s.Email,
+			LastActivityAt:          s.LastActivityAt,
+			PendingCancellationDate: s.PendingCancellationDate,
+		})
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].Login < orphans[j].Login })
+	return orphans
+}
+
+// writeOrphans writes the orphaned users as a list ("text") or doc as JSON.
+func writeOrphans(w io.Writer, doc orphansDocument, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	}
+
+	_, _ = fmt.Fprintln(w, "\n=== Orphaned Copilot Users ===")
+	_, _ = fmt.Fprintf(w, "Enterprise: %s\n", doc.Enterprise)
+	_, _ = fmt.Fprintf(w, "Copilot seats: %d, in no cost center: %d\n", doc.CopilotSeats, len(doc.Orphans))
+	for _, o := range doc.Orphans {
+		line := "  - " + o.Login
+		if o.LastActivityAt != "" {
+			line += "  (last active " + o.LastActivityAt + ")"
+		}
+		if o.PendingCancellationDate != "" {
+			line += "  (pending cancellation " + o.PendingCancellationDate + ")"
+		}
+		_, _ = fmt.Fprintln(w, line)
+	}
+	return nil
+}
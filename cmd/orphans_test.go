@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+func TestFindOrphans(t *testing.T) {
+	seats := []github.CopilotUser{
+		{Login: "carol", LastActivityAt: "2026-10-01T00:00:00Z"},
+		{Login: "Alice"},
+		{Login: "bob", PendingCancellationDate: "2026-11-01"},
+	}
+	orphans := findOrphans(seats, []string{"alice", "dave"})
+	if len(orphans) != 2 || orphans[0].Login != "bob" || orphans[1].Login != "carol" {
+		t.Fatalf("orphans = %+v, want bob and carol", orphans)
+	}
+	if orphans[0].PendingCancellationDate != "2026-11-01" {
+		t.Errorf("pending cancellation date not kept: %+v", orphans[0])
+	}
+
+	if got := findOrphans(seats, []string{"alice", "bob", "carol"}); got == nil || len(got) != 0 {
+		t.Errorf("orphans = %#v, want an empty list", got)
+	}
+}
+
+func TestWriteOrphans(t *testing.T) {
+	doc := orphansDocument{
+		Enterprise:   "acme",
+		CopilotSeats: 3,
+		Orphans:      []orphanedUser{{Login: "bob", PendingCancellationDate: "2026-11-01"}},
+	}
+	var buf bytes.Buffer
+	if err := writeOrphans(&buf, doc, "text"); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"Copilot seats: 3, in no cost center: 1", "  - bob  (pending cancellation 2026-11-01)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("text output missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	if err := writeOrphans(&buf, doc, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var got orphansDocument
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Enterprise != "acme" || len(got.Orphans) != 1 || got.Orphans[0].Login != "bob" {
+		t.Errorf("json = %+v", got)
+	}
+}
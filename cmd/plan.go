@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Preview cost center assignments without changing anything",
+	Long: `Compute the cost center assignments for the configured mode and show
+what apply would change.  Nothing is written to GitHub Enterprise.
+
+This is "gh cost-center assign --mode plan"; it takes the same flags, apart
+from those that only make sense when applying.
+
+Examples:
+  # Preview assignments (mode from config)
+  gh cost-center plan
+
+  # Save a plan for review, to apply exactly that plan later
+  gh cost-center plan --out plan.json
+
+  # Plan as a markdown table for a pull request comment (other output goes to stderr)
+  gh cost-center plan --format markdown > plan.md`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		assignMode = "plan"
+		return runAssign(cmd, args)
+	},
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Push cost center assignments to GitHub Enterprise",
+	Long: `Compute the cost center assignments for the configured mode and push
+them to GitHub Enterprise, or apply exactly the changes in a plan file
+written by "gh cost-center plan --out".
+
+This is "gh cost-center assign --mode apply"; it takes the same flags, apart
+from those that only make sense when planning.
+
+Examples:
+  # Apply assignments (skip confirmation)
+  gh cost-center apply --yes
+
+  # Apply a reviewed plan
+  gh cost-center apply --plan-file plan.json
+
+  # Continue an apply that was interrupted, skipping writes that went through
+  gh cost-center apply --yes --resume`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		assignMode = "apply"
+		return runAssign(cmd, args)
+	},
+}
+
+func init() {
+	addAssignFlags(planCmd, "plan")
+	addAssignFlags(applyCmd, "apply")
+	rootCmd.AddCommand(planCmd, applyCmd)
+}
@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/sources"
+	"github.com/renan-alm/gh-cost-center/internal/teams"
+)
+
+// buildPlan computes the changes an assign run of modes would make,
+// without making any: the cost centers each mode places users or
+// repositories in, compared with their current members.  With
+// cost_center.sources the merged placement is planned instead.
+func buildPlan(ctx context.Context, client *github.Client, modes []string, logger *slog.Logger) (*planFile, error) {
+	sections, err := desiredPlanSections(ctx, client, modes, logger)
+	if err != nil {
+		return nil, err
+	}
+	fullSync := modeFullSync
+	if len(cfgManager.Sources) > 0 {
+		fullSync = sourcesFullSync
+	}
+	if _, err := diffWithCurrent(ctx, client, sections, fullSync); err != nil {
+		return nil, err
+	}
+	return newPlanFile(cfgManager.Enterprise, modes, sections), nil
+}
+
+// desiredPlanSections returns the plan sections of modes, or of
+// cost_center.sources when set, before they are compared with the current
+// members.
+func desiredPlanSections(ctx context.Context, client *github.Client, modes []string, logger *slog.Logger) ([]planSection, error) {
+	if len(cfgManager.Sources) > 0 {
+		res, err := resolveAssignmentSources(ctx, client, logger)
+		if err != nil {
+			return nil, err
+		}
+		return sourcesPlanSections(res, sourceCreates), nil
+	}
+	var sections []planSection
+	for _, mode := range modes {
+		ms, err := modePlanSections(ctx, client, mode, logger)
+		if err != nil {
+			return nil, fmt.Errorf("mode %s: %w", mode, err)
+		}
+		sections = append(sections, ms...)
+	}
+	return sections, nil
+}
+
+// diffWithCurrent fills in the changes of sections against the current
+// members of their cost centers (see diffPlanSections) and returns the
+// active cost centers, by name.
+func diffWithCurrent(ctx context.Context, client *github.Client, sections []planSection, fullSync func(mode string) bool) (map[string]string, error) {
+	active, err := client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching active cost centers: %w", err)
+	}
+	current, err := currentPlanMembers(ctx, client, sections, active)
+	if err != nil {
+		return nil, err
+	}
+	diffPlanSections(sections, current, fullSync)
+	return active, nil
+}
+
+// modePlanSections returns one plan section per cost center mode places
+// users or repositories in, sorted by name.
+func modePlanSections(ctx context.Context, client *github.Client, mode string, logger *slog.Logger) ([]planSection, error) {
+	var (
+		desired map[string][]string
+		unit    = "users"
+		budgets func(string) map[string]config.ProductBudget
+		err     error
+	)
+	if mode == "teams" {
+		var mgr *teams.Manager
+		desired, mgr, err = teamsDesired(ctx, client, logger)
+		if mgr != nil {
+			budgets = mgr.BudgetProductsFor
+		}
+	} else {
+		desired, unit, err = modeDesiredState(ctx, client, mode, logger)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ids := pruCostCenterIDs()
+	sections := make([]planSection, 0, len(names))
+	for _, name := range names {
+		s := planSection{
+			Mode:       mode,
+			CostCenter: name,
+			Create:     modeCreates(mode),
+			Unit:       unit,
+			Items:      desired[name],
+		}
+		switch {
+		case github.IsValidCostCenterUUID(name):
+			s.CostCenterID = name
+		case mode == "users":
+			s.CostCenterID = ids[name]
+		}
+		if budgets != nil {
+			s.Budgets = budgets(name)
+		}
+		sections = append(sections, s)
+	}
+	return sections, nil
+}
+
+// pruCostCenterIDs maps the names of the users mode cost centers to the
+// IDs configured for them.
+func pruCostCenterIDs() map[string]string {
+	ids := make(map[string]string)
+	for name, id := range map[string]string{
+		cfgManager.NoPRUsCostCenterName:      cfgManager.NoPRUsCostCenterID,
+		cfgManager.PRUsAllowedCostCenterName: cfgManager.PRUsAllowedCostCenterID,
+		cfgManager.ServerCostCenterName:      cfgManager.ServerCostCenterID,
+		cfgManager.WindDownCostCenterName:    cfgManager.WindDownCostCenterID,
+	} {
+		if github.IsValidCostCenterUUID(id) {
+			ids[name] = id
+		}
+	}
+	return ids
+}
+
+// modeCreates reports whether an apply of mode creates the cost centers it
+// places resources in when they are missing.
+func modeCreates(mode string) bool {
+	switch mode {
+	case "repos", "custom-prop":
+		return true // created for every mapping that matches
+	case "idp-groups":
+		return sourceCreates(sources.IdPGroups)
+	}
+	return sourceCreates(mode)
+}
+
+// modeFullSync reports whether an apply of mode removes the members its
+// cost centers should no longer have.
+func modeFullSync(mode string) bool {
+	switch mode {
+	case "teams":
+		return cfgManager.TeamsRemoveUnmatchedUsers
+	case "orgs":
+		return cfgManager.OrgsRemoveUnmatchedUsers
+	case "repos":
+		return cfgManager.ReposRemoveNoLongerMatching
+	}
+	return false
+}
+
+// sourcesFullSync reports whether an apply of a cost_center.sources
+// section removes the members no source places: mode joins the sources
+// that placed users in it, and any of them with full sync enables it.
+func sourcesFullSync(mode string) bool {
+	for _, src := range strings.Split(mode, "+") {
+		if modeFullSync(src) {
+			return true
+		}
+	}
+	return false
+}
+
+// planMemberKey identifies the members of a cost center by unit.
+type planMemberKey struct {
+	unit, costCenter string
+}
+
+// currentPlanMembers reads the current members of every existing cost
+// center of sections.  Cost centers the apply would create are left out.
+func currentPlanMembers(ctx context.Context, client *github.Client, sections []planSection, active map[string]string) (map[planMemberKey][]string, error) {
+	current := make(map[planMemberKey][]string)
+	for _, s := range sections {
+		key := planMemberKey{s.Unit, s.CostCenter}
+		if _, done := current[key]; done {
+			continue
+		}
+		id := s.CostCenterID
+		if id == "" {
+			id = active[s.CostCenter]
+		}
+		if id == "" {
+			continue
+		}
+		var (
+			members []string
+			err     error
+		)
+		if s.Unit == "users" {
+			members, err = client.GetCostCenterMembers(ctx, id)
+		} else {
+			members, err = client.GetCostCenterRepositories(ctx, id)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fetching members of cost center %q: %w", s.CostCenter, err)
+		}
+		current[key] = members
+	}
+	return current, nil
+}
+
+// diffPlanSections fills in the changes of sections against current: the
+// items each cost center does not hold yet, those of them now in another
+// cost center of the plan, and, for modes with full sync, the members the
+// plan places nowhere.
+func diffPlanSections(sections []planSection, current map[planMemberKey][]string, fullSync func(mode string) bool) {
+	location := make(map[planMemberKey]string) // (unit, resource) -> cost center
+	for key, members := range current {
+		for _, r := range members {
+			location[planMemberKey{key.unit, r}] = key.costCenter
+		}
+	}
+	wanted := make(map[planMemberKey]map[string]bool)
+	placed := make(map[planMemberKey]bool) // (unit, resource) the plan places anywhere
+	for _, s := range sections {
+		key := planMemberKey{s.Unit, s.CostCenter}
+		if wanted[key] == nil {
+			wanted[key] = make(map[string]bool)
+		}
+		for _, r := range s.Items {
+			wanted[key][r] = true
+			placed[planMemberKey{s.Unit, r}] = true
+		}
+	}
+
+	removed := make(map[planMemberKey]bool)
+	for i := range sections {
+		s := &sections[i]
+		key := planMemberKey{s.Unit, s.CostCenter}
+		s.Adds, s.Moves, s.Remove = []string{}, nil, nil
+		seen := make(map[string]bool, len(s.Items))
+		for _, r := range s.Items {
+			if seen[r] {
+				continue
+			}
+			seen[r] = true
+			switch loc, ok := location[planMemberKey{s.Unit, r}]; {
+			case ok && loc == s.CostCenter:
+			case ok:
+				s.Adds = append(s.Adds, r)
+				s.Moves = append(s.Moves, diffMisplaced{Name: r, Current: loc})
+			default:
+				s.Adds = append(s.Adds, r)
+			}
+		}
+		if fullSync(s.Mode) && !removed[key] {
+			removed[key] = true
+			for _, r := range current[key] {
+				// Members moving to another cost center leave with the move.
+				if !placed[planMemberKey{s.Unit, r}] {
+					s.Remove = append(s.Remove, r)
+				}
+			}
+		}
+		sort.Strings(s.Adds)
+		sort.Strings(s.Remove)
+		sort.Slice(s.Moves, func(a, b int) bool { return s.Moves[a].Name < s.Moves[b].Name })
+	}
+}
+
+// writePlanOutputs writes what a plan-mode run asked for: the budget
+// estimates of --budget-impact and --create-budgets, --terraform-out,
+// --out, and the markdown plan to markdown when it is not nil.  The plan is
+// only built when one of them is asked for.
+func writePlanOutputs(ctx context.Context, client *github.Client, modes []string, markdown io.Writer, logger *slog.Logger) error {
+	budgetChanges := assignCreateBudgets && cfgManager.BudgetsEnabled
+	if !assignBudgetImpact && !budgetChanges && assignTerraformOut == "" && assignOut == "" && markdown == nil {
+		return nil
+	}
+	p, err := buildPlan(ctx, client, modes, logger)
+	if err != nil {
+		return fmt.Errorf("computing plan: %w", err)
+	}
+	if assignBudgetImpact {
+		reportBudgetImpact(ctx, client, p.Changes, os.Stdout, logger)
+	}
+	if budgetChanges {
+		reportBudgetChanges(ctx, client, p.Changes, os.Stdout, logger)
+	}
+	if assignTerraformOut != "" {
+		if err := writeTerraformFile(assignTerraformOut, cfgManager.Enterprise, p.Changes); err != nil {
+			return err
+		}
+	}
+	if assignOut != "" {
+		if err := writePlanFile(assignOut, p); err != nil {
+			return err
+		}
+	}
+	if markdown != nil {
+		return writePlanMarkdown(markdown, cfgManager.Enterprise, p.Changes)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+)
+
+func TestDiffPlanSections(t *testing.T) {
+	sections := []planSection{
+		{Mode: "teams", CostCenter: "Eng", Unit: "users", Items: []string{"carol", "alice", "bob", "alice"}},
+		{Mode: "teams", CostCenter: "Data", Unit: "users", Items: []string{"dave"}},
+		{Mode: "users", CostCenter: "Other", Unit: "users", Items: []string{"erin"}},
+		{Mode: "repos", CostCenter: "Eng", Unit: "repositories", Items: []string{"org/api"}},
+	}
+	current := map[planMemberKey][]string{
+		{"users", "Eng"}:        {"alice", "zed"},
+		{"users", "Data"}:       {"bob", "yan"},
+		{"users", "Other"}:      {"xia"},
+		{"repositories", "Eng"}: {"org/old"},
+	}
+	fullSync := func(mode string) bool { return mode == "teams" }
+	diffPlanSections(sections, current, fullSync)
+
+	eng := sections[0]
+	if want := []string{"bob", "carol"}; !reflect.DeepEqual(eng.Adds, want) {
+		t.Errorf("Eng adds = %v, want %v", eng.Adds, want)
+	}
+	if want := []diffMisplaced{{Name: "bob", Current: "Data"}}; !reflect.DeepEqual(eng.Moves, want) {
+		t.Errorf("Eng moves = %v, want %v", eng.Moves, want)
+	}
+	if want := []string{"zed"}; !reflect.DeepEqual(eng.Remove, want) {
+		t.Errorf("Eng remove = %v, want %v", eng.Remove, want)
+	}
+	if data := sections[1]; !reflect.DeepEqual(data.Remove, []string{"yan"}) {
+		t.Errorf("Data remove = %v, want yan (bob moves to Eng)", data.Remove)
+	}
+	if other := sections[2]; other.Remove != nil || !reflect.DeepEqual(other.Adds, []string{"erin"}) {
+		t.Errorf("Other = adds %v, remove %v; want only erin added", other.Adds, other.Remove)
+	}
+	if repos := sections[3]; !reflect.DeepEqual(repos.Adds, []string{"org/api"}) || repos.Moves != nil || repos.Remove != nil {
+		t.Errorf("repos = %+v; want org/api added without moves or removals", repos)
+	}
+}
+
+func TestWritePlanOutputs_NothingAsked(t *testing.T) {
+	origCfg, origImpact, origBudgets := cfgManager, assignBudgetImpact, assignCreateBudgets
+	origOut, origTF := assignOut, assignTerraformOut
+	t.Cleanup(func() {
+		cfgManager, assignBudgetImpact, assignCreateBudgets = origCfg, origImpact, origBudgets
+		assignOut, assignTerraformOut = origOut, origTF
+	})
+	cfgManager = &config.Manager{BudgetsEnabled: true}
+	assignBudgetImpact, assignCreateBudgets, assignOut, assignTerraformOut = false, false, "", ""
+
+	// No output is asked for, so no plan is built: a nil client is never
+	// used.
+	if err := writePlanOutputs(t.Context(), nil, []string{"users"}, nil, nil); err != nil {
+		t.Errorf("writePlanOutputs = %v, want nil", err)
+	}
+}
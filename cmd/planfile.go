@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
+)
+
+// planFileVersion is the version of the plan file format written by
+// --out.  Files with a different version are rejected by --plan-file.
+// Version 2 added the adds, moves and removals of every change.
+const planFileVersion = 2
+
+// planFile is the document written by assign --mode plan --out and
+// executed by assign --mode apply --plan-file.
+type planFile struct {
+	Version    int           `json:"version"`
+	Enterprise string        `json:"enterprise"`
+	CreatedAt  time.Time     `json:"created_at"`
+	Modes      []string      `json:"modes"`
+	Changes    []planSection `json:"changes"`
+}
+
+// newPlanFile returns the plan of sections collected for modes.
+func newPlanFile(enterprise string, modes []string, sections []planSection) *planFile {
+	if sections == nil {
+		sections = []planSection{}
+	}
+	return &planFile{
+		Version:    planFileVersion,
+		Enterprise: enterprise,
+		CreatedAt:  time.Now().UTC(),
+		Modes:      modes,
+		Changes:    sections,
+	}
+}
+
+// writePlanFile saves p to path.
+func writePlanFile(path string, p *planFile) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding plan: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing plan file %s: %w", path, err)
+	}
+	return nil
+}
+
+// readPlanFile loads and validates a plan file for enterprise.
+func readPlanFile(path, enterprise string) (*planFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading plan file: %w", err)
+	}
+	var p planFile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parsing plan file %s: %w", path, err)
+	}
+	if p.Version != planFileVersion {
+		return nil, fmt.Errorf("plan file %s has version %d, expected %d", path, p.Version, planFileVersion)
+	}
+	if p.Enterprise != enterprise {
+		return nil, fmt.Errorf("plan file %s was made for enterprise %q, not %q", path, p.Enterprise, enterprise)
+	}
+	for i, s := range p.Changes {
+		if s.CostCenter == "" && s.CostCenterID == "" {
+			return nil, fmt.Errorf("plan file %s: change %d has no cost center", path, i+1)
+		}
+		if s.Unit != "users" && s.Unit != "repositories" {
+			return nil, fmt.Errorf("plan file %s: change %d has unknown unit %q", path, i+1, s.Unit)
+		}
+	}
+	return &p, nil
+}
+
+// plannedChanges summarises a plan file for the confirmation prompts.
+func (p *planFile) plannedChanges() []plannedChange {
+	changes := make([]plannedChange, 0, len(p.Changes))
+	for _, s := range p.Changes {
+		changes = append(changes, plannedChange{CostCenter: s.CostCenter, Count: len(s.toAdd()), Unit: s.Unit})
+	}
+	return changes
+}
+
+// toAdd returns the items an apply of s adds: its adds when the plan was
+// compared with current membership, otherwise every item.
+func (s planSection) toAdd() []string {
+	if s.Adds != nil {
+		return s.Adds
+	}
+	return s.Items
+}
+
+// resolvePlanCostCenters returns the cost center ID for every change of
+// the plan, in order, and the names of the cost centers that must be
+// created.  It fails when a cost center is missing and the plan does not
+// create it, so nothing is applied against a partially matching tree.
+func resolvePlanCostCenters(p *planFile, active map[string]string) ([]string, []string, error) {
+	ids := make([]string, len(p.Changes))
+	seen := make(map[string]bool)
+	var toCreate []string
+	for i, s := range p.Changes {
+		switch {
+		case s.CostCenterID != "":
+			ids[i] = s.CostCenterID
+		case github.IsValidCostCenterUUID(s.CostCenter):
+			ids[i] = s.CostCenter
+		case active[s.CostCenter] != "":
+			ids[i] = active[s.CostCenter]
+		case s.Create:
+			if !seen[s.CostCenter] {
+				seen[s.CostCenter] = true
+				toCreate = append(toCreate, s.CostCenter)
+			}
+		default:
+			return nil, nil, fmt.Errorf("cost center %q does not exist and the plan does not create it", s.CostCenter)
+		}
+	}
+	sort.Strings(toCreate)
+	return ids, toCreate, nil
+}
+
+// runPlanFileApply executes exactly the changes recorded in p: it creates
+// the cost centers the plan marks for creation, then adds the planned users
+// and repositories and removes those it lists for removal.  Nothing is
+// recomputed from configuration.
+func runPlanFileApply(ctx context.Context, client *github.Client, p *planFile, path string) error {
+	logger := slog.Default()
+	logger.Info("Applying plan file",
+		"path", path, "created_at", p.CreatedAt.Format(time.RFC3339), "changes", len(p.Changes))
+
+	active, err := client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching active cost centers: %w", err)
+	}
+	intro := i18n.T("confirm.planfile.intro", path, p.CreatedAt.Format(time.RFC3339))
+	applied, err := applyPlan(ctx, client, p, active, intro, logger)
+	if err != nil || !applied {
+		return err
+	}
+	logger.Info("Plan file applied successfully", "path", path)
+	return nil
+}
+
+// applyPlan confirms and applies p: a first run must be confirmed with
+// the enterprise slug, other runs show the changes after intro unless
+// --yes is set.  active maps the names of the active cost centers to
+// their IDs.  applied is false when the user declined.
+func applyPlan(ctx context.Context, client *github.Client, p *planFile, active map[string]string, intro string, logger *slog.Logger) (applied bool, err error) {
+	ids, toCreate, err := resolvePlanCostCenters(p, active)
+	if err != nil {
+		return false, err
+	}
+
+	firstRun, err := needsFirstRunConsent()
+	if err != nil {
+		return false, err
+	}
+	if firstRun {
+		proceed, err := confirmFirstRun(p.plannedChanges(), toCreate, nil)
+		if err != nil {
+			return false, fmt.Errorf("confirmation failed: %w", err)
+		}
+		if !proceed {
+			abortApply(logger, "Aborted by user")
+			return false, nil
+		}
+	} else if !assignYes {
+		proceed, err := confirmPlan(intro, p, toCreate)
+		if err != nil {
+			return false, fmt.Errorf("confirmation failed: %w", err)
+		}
+		if !proceed {
+			abortApply(logger, "Aborted by user before applying plan")
+			return false, nil
+		}
+	}
+	if err := applyPlanChanges(ctx, client, p, active, ids, toCreate, logger); err != nil {
+		return false, err
+	}
+	recordApply(logger)
+	return true, nil
+}
+
+// applyPlanChanges creates the cost centers in toCreate, adds the users
+// and repositories of every change of p, then removes the members the
+// changes list for removal.  ids are the resolved cost center IDs of the
+// changes (see resolvePlanCostCenters); active gains the created cost
+// centers.
+func applyPlanChanges(ctx context.Context, client *github.Client, p *planFile, active map[string]string, ids, toCreate []string, logger *slog.Logger) error {
+	for _, name := range toCreate {
+		id, err := client.CreateCostCenterWithPreload(ctx, name, active)
+		if err != nil {
+			return fmt.Errorf("creating cost center %q: %w", name, err)
+		}
+		active[name] = id
+		logger.Info("Created cost center", "name", name, "id", id)
+	}
+
+	changeIDs := make([]string, len(p.Changes))
+	users := make(map[string][]string)
+	var failed []string
+	for i, s := range p.Changes {
+		id := ids[i]
+		if id == "" {
+			id = active[s.CostCenter]
+		}
+		changeIDs[i] = id
+		add := s.toAdd()
+		if len(add) == 0 {
+			continue
+		}
+		if s.Unit == "users" {
+			users[id] = append(users[id], add...)
+			continue
+		}
+		if err := client.AddRepositoriesToCostCenter(ctx, id, add); err != nil {
+			logger.Error("Failed to add repositories", "cost_center", s.CostCenter, "error", err)
+			failed = append(failed, s.CostCenter)
+		}
+	}
+
+	if len(users) > 0 {
+		results, err := client.BulkUpdateCostCenterAssignments(ctx, users, !assignCheckCurrentCC)
+		if err != nil {
+			return fmt.Errorf("applying assignments: %w", err)
+		}
+		if err := logAssignmentResults(results, logger); err != nil {
+			return err
+		}
+	}
+
+	for i, s := range p.Changes {
+		if len(s.Remove) == 0 {
+			continue
+		}
+		logger.Info("Removing members no longer placed in the cost center",
+			"cost_center", s.CostCenter, "unit", s.Unit, "count", len(s.Remove))
+		var err error
+		if s.Unit == "users" {
+			_, err = client.RemoveUsersFromCostCenter(ctx, changeIDs[i], s.Remove)
+		} else {
+			err = client.RemoveRepositoriesFromCostCenter(ctx, changeIDs[i], s.Remove)
+		}
+		if err != nil {
+			logger.Error("Failed to remove members", "cost_center", s.CostCenter, "unit", s.Unit, "error", err)
+			failed = append(failed, s.CostCenter)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("applying the plan failed for cost centers: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// confirmPlan shows intro and what the plan will change and returns true
+// if the user types "yes".
+func confirmPlan(intro string, p *planFile, toCreate []string) (bool, error) {
+	fmt.Println("\n" + intro)
+	for _, name := range toCreate {
+		fmt.Println(i18n.T("confirm.planfile.create", name))
+	}
+	for _, s := range p.Changes {
+		fmt.Println(i18n.T("confirm.planfile.change", s.CostCenter, s.Mode, len(s.toAdd()), i18n.T("unit."+s.Unit)))
+		if len(s.Remove) > 0 {
+			fmt.Println(i18n.T("confirm.planfile.remove", s.CostCenter, s.Mode, len(s.Remove), i18n.T("unit."+s.Unit)))
+		}
+	}
+
+	fmt.Print("\n" + i18n.T("confirm.proceed"))
+	scanner := bufio.NewScanner(os.Stdin)
+	if scanner.Scan() {
+		return i18n.IsYes(scanner.Text()), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("reading user input: %w", err)
+	}
+	return false, nil
+}
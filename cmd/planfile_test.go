@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/github/githubtest"
+)
+
+func TestPlanFile_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	sections := []planSection{
+		{Mode: "teams", CostCenter: "Eng", Create: true, Unit: "users", Items: []string{"alice", "bob"},
+			Adds: []string{"bob"}, Moves: []diffMisplaced{{Name: "bob", Current: "Data"}}, Remove: []string{"carol"}},
+		{Mode: "repos", CostCenter: "Platform", CostCenterID: "cc-1", Unit: "repositories", Items: []string{"org/a"}},
+	}
+	if err := writePlanFile(path, newPlanFile("ent", []string{"teams", "repos"}, sections)); err != nil {
+		t.Fatalf("writePlanFile: %v", err)
+	}
+
+	p, err := readPlanFile(path, "ent")
+	if err != nil {
+		t.Fatalf("readPlanFile: %v", err)
+	}
+	if p.Version != planFileVersion || p.CreatedAt.IsZero() {
+		t.Errorf("version/created_at = %d/%v", p.Version, p.CreatedAt)
+	}
+	if !reflect.DeepEqual(p.Modes, []string{"teams", "repos"}) {
+		t.Errorf("modes = %v", p.Modes)
+	}
+	if !reflect.DeepEqual(p.Changes, sections) {
+		t.Errorf("changes = %+v, want %+v", p.Changes, sections)
+	}
+
+	if _, err := readPlanFile(path, "other"); err == nil || !strings.Contains(err.Error(), `"ent"`) {
+		t.Errorf("expected enterprise mismatch error, got %v", err)
+	}
+}
+
+func TestReadPlanFile_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"version": `{"version": 1, "enterprise": "ent"}`,
+		"unit":    `{"version": 2, "enterprise": "ent", "changes": [{"cost_center": "A", "unit": "teams"}]}`,
+		"name":    `{"version": 2, "enterprise": "ent", "changes": [{"unit": "users"}]}`,
+		"json":    `{`,
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "plan.json")
+			if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := readPlanFile(path, "ent"); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestResolvePlanCostCenters(t *testing.T) {
+	uuid := "11111111-2222-3333-4444-555555555555"
+	p := &planFile{Changes: []planSection{
+		{CostCenter: "Known", Unit: "users"},
+		{CostCenter: "Pinned", CostCenterID: "cc-pinned", Unit: "users"},
+		{CostCenter: uuid, Unit: "users"},
+		{CostCenter: "New", Create: true, Unit: "repositories"},
+		{CostCenter: "New", Create: true, Unit: "users"},
+	}}
+	ids, toCreate, err := resolvePlanCostCenters(p, map[string]string{"Known": "cc-known"})
+	if err != nil {
+		t.Fatalf("resolvePlanCostCenters: %v", err)
+	}
+	if want := []string{"cc-known", "cc-pinned", uuid, "", ""}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+	if !reflect.DeepEqual(toCreate, []string{"New"}) {
+		t.Errorf("toCreate = %v", toCreate)
+	}
+
+	p.Changes = append(p.Changes, planSection{CostCenter: "Gone", Unit: "users"})
+	if _, _, err := resolvePlanCostCenters(p, map[string]string{"Known": "cc-known"}); err == nil {
+		t.Error("expected an error for a missing cost center the plan does not create")
+	}
+}
+
+func TestApplyPlanChanges_AddsAndRemoves(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	eng := srv.AddCostCenter("Eng", "alice", "carol")
+	data := srv.AddCostCenter("Data", "bob")
+	client, err := github.NewClient(&config.Manager{Enterprise: srv.Enterprise, APIBaseURL: srv.URL, Token: "test-token"},
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	p := newPlanFile(srv.Enterprise, []string{"teams"}, []planSection{
+		{Mode: "teams", CostCenter: "Eng", Unit: "users", Items: []string{"alice", "bob"},
+			Adds: []string{"bob"}, Moves: []diffMisplaced{{Name: "bob", Current: "Data"}}, Remove: []string{"carol"}},
+		{Mode: "teams", CostCenter: "New", Create: true, Unit: "users", Items: []string{"dave"}, Adds: []string{"dave"}},
+	})
+	active := map[string]string{"Eng": eng, "Data": data}
+	ids, toCreate, err := resolvePlanCostCenters(p, active)
+	if err != nil {
+		t.Fatalf("resolvePlanCostCenters: %v", err)
+	}
+	if err := applyPlanChanges(t.Context(), client, p, active, ids, toCreate, slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil {
+		t.Fatalf("applyPlanChanges: %v", err)
+	}
+
+	for name, want := range map[string]string{"Eng": "alice,bob", "Data": "", "New": "dave"} {
+		cc, ok := srv.CostCenterByName(name)
+		if !ok {
+			t.Fatalf("cost center %s missing", name)
+		}
+		users := append([]string(nil), cc.Users...)
+		sort.Strings(users)
+		if got := strings.Join(users, ","); got != want {
+			t.Errorf("%s users = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+)
+
+// planInlineLimit is the longest member list the markdown plan shows
+// inline; longer lists are collapsed into a <details> block.
+const planInlineLimit = 10
+
+// planSection is one cost center in the plan: the users or repositories a
+// mode places in it and, compared with its current members, the changes
+// that takes.
+type planSection struct {
+	Mode         string   `json:"mode"`
+	CostCenter   string   `json:"cost_center"`
+	CostCenterID string   `json:"cost_center_id,omitempty"` // empty when resolved by name on apply
+	Create       bool     `json:"create,omitempty"`         // create the cost center on apply if missing
+	Unit         string   `json:"unit"`                     // "users" or "repositories"
+	Items        []string `json:"items"`
+	// Adds are the items not in the cost center yet, and Moves those of
+	// them now in another cost center of the plan.  Remove lists the
+	// members the mode's full sync takes out.
+	Adds   []string        `json:"adds"`
+	Moves  []diffMisplaced `json:"moves,omitempty"`
+	Remove []string        `json:"remove,omitempty"`
+	// Budgets are the product budgets --create-budgets would give the cost
+	// center, when the mode knows more than budgets.overrides by name.
+	Budgets map[string]config.ProductBudget `json:"-"`
+}
+
+// planChange is one kind of change of a plan section, for the markdown
+// plan: its verb and the resources it applies to, each with a note.
+type planChange struct {
+	Verb  string
+	Items []string
+	Notes map[string]string
+}
+
+// changes splits the changes of s into new resources, resources moved
+// from another cost center, and removals, leaving out empty ones.
+func (s planSection) changes() []planChange {
+	moved := make(map[string]string, len(s.Moves))
+	for _, m := range s.Moves {
+		moved[m.Name] = "from " + m.Current
+	}
+	var added, moves []string
+	for _, r := range s.toAdd() {
+		if _, ok := moved[r]; ok {
+			moves = append(moves, r)
+		} else {
+			added = append(added, r)
+		}
+	}
+	var out []planChange
+	for _, c := range []planChange{
+		{Verb: "add", Items: added},
+		{Verb: "move", Items: moves, Notes: moved},
+		{Verb: "remove", Items: s.Remove},
+	} {
+		if len(c.Items) > 0 {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// writePlanMarkdown renders sections as GitHub-flavored markdown for a pull
+// request comment: a summary table of the resources added, moved from
+// another cost center, and removed, then one heading per cost center with
+// those resources, each list collapsed when longer than planInlineLimit.
+// Cost centers that need no change are left out.
+func writePlanMarkdown(w io.Writer, enterprise string, sections []planSection) error {
+	var sorted []planSection
+	for _, s := range sections {
+		if len(s.changes()) > 0 {
+			sorted = append(sorted, s)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].CostCenter != sorted[j].CostCenter {
+			return sorted[i].CostCenter < sorted[j].CostCenter
+		}
+		return sorted[i].Mode < sorted[j].Mode
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Cost center plan for `%s`\n\n", enterprise)
+	if len(sorted) == 0 {
+		b.WriteString("No changes.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	totals := make(map[string]int)
+	b.WriteString("| Cost center | Mode | Change | Count |\n")
+	b.WriteString("|---|---|---|---:|\n")
+	for _, s := range sorted {
+		for _, c := range s.changes() {
+			change := c.Verb + " " + s.Unit
+			fmt.Fprintf(&b, "| %s | %s | %s | %d |\n", markdownCell(s.CostCenter), s.Mode, change, len(c.Items))
+			totals[change] += len(c.Items)
+		}
+	}
+	kinds := make([]string, 0, len(totals))
+	for k := range totals {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	for _, k := range kinds {
+		fmt.Fprintf(&b, "| **Total** | | %s | **%d** |\n", k, totals[k])
+	}
+
+	for _, s := range sorted {
+		fmt.Fprintf(&b, "\n### %s\n", markdownText(s.CostCenter))
+		for _, c := range s.changes() {
+			items := make([]string, len(c.Items))
+			copy(items, c.Items)
+			sort.Strings(items)
+
+			b.WriteString("\n")
+			if len(items) > planInlineLimit {
+				fmt.Fprintf(&b, "<details><summary>%s %d %s (%s)</summary>\n\n", c.Verb, len(items), s.Unit, s.Mode)
+			} else {
+				fmt.Fprintf(&b, "%s %d %s (%s):\n\n", c.Verb, len(items), s.Unit, s.Mode)
+			}
+			for _, item := range items {
+				if note := c.Notes[item]; note != "" {
+					fmt.Fprintf(&b, "- `%s` %s\n", item, markdownText(note))
+				} else {
+					fmt.Fprintf(&b, "- `%s`\n", item)
+				}
+			}
+			if len(items) > planInlineLimit {
+				b.WriteString("\n</details>\n")
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell escapes text for a markdown table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(markdownText(s), "|", `\|`)
+}
+
+// markdownText escapes characters that would otherwise be rendered as
+// markup or HTML.
+func markdownText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", "&lt;", ">", "&gt;")
+	return r.Replace(s)
+}
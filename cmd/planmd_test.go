@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWritePlanMarkdown(t *testing.T) {
+	var many []string
+	for i := 0; i < planInlineLimit+1; i++ {
+		many = append(many, fmt.Sprintf("user%02d", i))
+	}
+
+	var buf bytes.Buffer
+	err := writePlanMarkdown(&buf, "ent", []planSection{
+		{Mode: "teams", CostCenter: "[org team] org/Big", Unit: "users", Items: many},
+		{Mode: "repos", CostCenter: "A|B", Unit: "repositories", Items: []string{"org/b", "org/a"}},
+	})
+	if err != nil {
+		t.Fatalf("writePlanMarkdown: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"## Cost center plan for `ent`",
+		"| A\\|B | repos | add repositories | 2 |",
+		"| \\[org team\\] org/Big | teams | add users | 11 |",
+		"<details><summary>add 11 users (teams)</summary>",
+		"- `org/a`\n- `org/b`\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "<details>") != 1 {
+		t.Errorf("only lists longer than %d should be collapsed:\n%s", planInlineLimit, out)
+	}
+	if strings.Index(out, "### A|B") > strings.Index(out, "### \\[org team\\]") {
+		t.Errorf("sections not sorted by cost center:\n%s", out)
+	}
+}
+
+func TestWritePlanMarkdown_MovesAndRemovals(t *testing.T) {
+	var buf bytes.Buffer
+	err := writePlanMarkdown(&buf, "ent", []planSection{
+		{
+			Mode: "teams", CostCenter: "Eng", Unit: "users",
+			Items:  []string{"alice", "bob", "carol"},
+			Adds:   []string{"bob", "carol"},
+			Moves:  []diffMisplaced{{Name: "bob", Current: "Data_Team"}},
+			Remove: []string{"zed"},
+		},
+		{Mode: "teams", CostCenter: "Done", Unit: "users", Items: []string{"dave"}, Adds: []string{}},
+	})
+	if err != nil {
+		t.Fatalf("writePlanMarkdown: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"| Eng | teams | add users | 1 |",
+		"| Eng | teams | move users | 1 |",
+		"| Eng | teams | remove users | 1 |",
+		"| **Total** | | remove users | **1** |",
+		"add 1 users (teams):\n\n- `carol`\n",
+		"move 1 users (teams):\n\n- `bob` from Data\\_Team\n",
+		"remove 1 users (teams):\n\n- `zed`\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Done") {
+		t.Errorf("cost centers without changes should be left out:\n%s", out)
+	}
+}
+
+func TestWritePlanMarkdown_NoChanges(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writePlanMarkdown(&buf, "ent", nil); err != nil {
+		t.Fatalf("writePlanMarkdown: %v", err)
+	}
+	if !strings.Contains(buf.String(), "No changes.") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
+	"github.com/renan-alm/gh-cost-center/internal/state"
+)
+
+var removeCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove users from a cost center",
+	Long: `Remove users from a cost center, for off-boarding without enabling full
+sync.  The cost center is named by its name or UUID.  Users come from
+--users and from --users-file: one login per line, blank lines and lines
+starting with # are skipped, and "-" reads standard input.  Listed users
+that are not in the cost center are reported and left alone.
+
+Removed users are in no cost center afterwards.  A later assign run puts
+them back if the configuration still maps them.
+
+In apply mode a snapshot is taken first (skip with --no-snapshot), so the
+removal can be undone with "rollback --run latest".
+
+Examples:
+  gh cost-center remove --cost-center "Platform" --users alice,bob
+  gh cost-center remove --cost-center "Platform" --users-file offboarding.txt --mode apply`,
+	Args: cobra.NoArgs,
+	RunE: runRemove,
+}
+
+var (
+	removeCostCenter string
+	removeUsers      []string
+	removeUsersFile  string
+	removeMode       string
+	removeYes        bool
+	removeNoSnapshot bool
+)
+
+func init() {
+	f := removeCmd.Flags()
+	f.StringVar(&removeCostCenter, "cost-center", "", "cost center to remove the users from (name or UUID)")
+	f.StringSliceVar(&removeUsers, "users", nil, "users to remove (repeatable or comma-separated)")
+	f.StringVar(&removeUsersFile, "users-file", "", "file of logins to remove, one per line, or - for stdin")
+	f.StringVar(&removeMode, "mode", "plan", "execution mode: plan (preview) or apply (push changes)")
+	f.BoolVarP(&removeYes, "yes", "y", false, "skip confirmation prompt in apply mode")
+	f.BoolVar(&removeNoSnapshot, "no-snapshot", false, "do not capture a membership snapshot before removing")
+	f.BoolVar(&skipPermissionCheck, "skip-permission-check", false, "do not check the token's scopes before the removal")
+	_ = removeCmd.MarkFlagRequired("cost-center")
+
+	rootCmd.AddCommand(removeCmd)
+}
+
+func runRemove(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	if removeMode != "plan" && removeMode != "apply" {
+		return fmt.Errorf("invalid --mode %q: must be 'plan' or 'apply'", removeMode)
+	}
+	logins := removeUsers
+	if removeUsersFile != "" {
+		fromFile, err := readLoginsFile(removeUsersFile)
+		if err != nil {
+			return err
+		}
+		logins = append(logins, fromFile...)
+	}
+	logins = lookupLogins(logins)
+	if len(logins) == 0 {
+		return fmt.Errorf("no usernames to remove: pass them with --users or --users-file")
+	}
+	logger := slog.Default()
+
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	if err := checkPermissions(ctx, client, "remove", []string{cfgManager.CostCenterMode}, removeMode == "apply"); err != nil {
+		return err
+	}
+
+	active, err := client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		return err
+	}
+	name, id, err := resolveCostCenterArg(active, strings.TrimSpace(removeCostCenter))
+	if err != nil {
+		return err
+	}
+	members, err := client.GetCostCenterMembers(ctx, id)
+	if err != nil {
+		return err
+	}
+	users, missing := pickMembers(members, logins)
+	printRemovePlan(os.Stdout, name, users, missing)
+
+	if removeMode != "apply" || len(users) == 0 {
+		return nil
+	}
+	if !removeYes {
+		ok, err := confirmRemove(name, len(users))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			logger.Warn("Removal aborted by user")
+			return nil
+		}
+	}
+
+	sink, err := attachAuditSink(client, logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := sink.Close(); cerr != nil {
+			logger.Warn("Audit sink", "error", cerr)
+		}
+	}()
+
+	if !removeNoSnapshot {
+		if _, err := takeSnapshot(ctx, client, state.ReasonPreApply, logger); err != nil {
+			return fmt.Errorf("%w (use --no-snapshot to remove without one)", err)
+		}
+	}
+	if _, err := client.RemoveUsersFromCostCenter(ctx, id, users); err != nil {
+		return err
+	}
+	logger.Info("Removal complete", "cost_center", name, "users", len(users))
+	return nil
+}
+
+// printRemovePlan writes the users to remove and those left alone.
+func printRemovePlan(w io.Writer, name string, users, missing []string) {
+	_, _ = fmt.Fprintf(w, "Remove from cost center %q\n", name)
+	if len(users) == 0 {
+		_, _ = fmt.Fprintln(w, "  No users to remove.")
+	}
+	for _, u := range users {
+		_, _ = fmt.Fprintf(w, "  - %s\n", u)
+	}
+	if len(missing) > 0 {
+		_, _ = fmt.Fprintf(w, "\nNot in %q, skipped (%d):\n", name, len(missing))
+		for _, u := range missing {
+			_, _ = fmt.Fprintf(w, "  - %s\n", u)
+		}
+	}
+	_, _ = fmt.Fprintf(w, "\nTotal: %d to remove, %d skipped\n", len(users), len(missing))
+}
+
+// confirmRemove prompts for confirmation in interactive apply mode.
+func confirmRemove(name string, n int) (bool, error) {
+	fmt.Println("\n" + i18n.T("confirm.remove.intro", n, name))
+	fmt.Print(i18n.T("confirm.proceed"))
+	scanner := bufio.NewScanner(os.Stdin)
+	if scanner.Scan() {
+		return i18n.IsYes(scanner.Text()), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("reading user input: %w", err)
+	}
+	return false, nil
+}
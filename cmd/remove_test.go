@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadLoginsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "offboarding.txt")
+	if err := os.WriteFile(path, []byte("# leavers\nalice\n\nbob\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readLoginsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []string{"alice", "bob"}) {
+		t.Errorf("logins = %v, want [alice bob]", got)
+	}
+	if _, err := readLoginsFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestPrintRemovePlan(t *testing.T) {
+	users, missing := pickMembers([]string{"Alice", "bob"}, []string{"alice", "dave"})
+	var buf bytes.Buffer
+	printRemovePlan(&buf, "Platform", users, missing)
+	out := buf.String()
+	for _, want := range []string{
+		`Remove from cost center "Platform"`,
+		"  - Alice",
+		`Not in "Platform", skipped (1):`,
+		"  - dave",
+		"Total: 1 to remove, 1 skipped",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
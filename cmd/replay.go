@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
+	"github.com/renan-alm/gh-cost-center/internal/journal"
+)
+
+// assignAborted is set when the operator declines a confirmation of the
+// assign run; journaled writes are then not re-attempted.
+var assignAborted bool
+
+// abortApply logs that the operator declined to apply and records it for
+// replayJournal.
+func abortApply(logger *slog.Logger, msg string) {
+	assignAborted = true
+	logger.Warn(msg)
+}
+
+// openJournal attaches the retry journal from the export dir, so failed
+// writes of the run are recorded.  In apply mode the permanent failures of
+// earlier runs are reported, then cleared; in plan mode both kinds are
+// only counted.
+func openJournal(client *github.Client, logger *slog.Logger) *journal.Journal {
+	j := journal.Open(cfgManager.ExportDir, logger)
+	if assignMode != "apply" {
+		if n := len(j.Transient()); n > 0 {
+			logger.Info("Transient failures from a previous run would be re-attempted after a confirmed apply",
+				"entries", n, "journal", j.FilePath())
+		}
+		if n := len(j.Permanent()); n > 0 {
+			logger.Warn("Permanent failures from a previous run are in the retry journal",
+				"entries", n, "journal", j.FilePath())
+		}
+		return j
+	}
+
+	permanent := j.Permanent()
+	for _, e := range permanent {
+		logger.Warn("Write failed permanently in a previous run and was not retried",
+			"op", e.Op, "cost_center_id", e.CostCenterID, "resources", strings.Join(e.Resources, ","),
+			"attempts", e.Attempts, "failed_at", e.FailedAt.Format(time.RFC3339), "error", e.Error)
+	}
+	if err := j.ClearPermanent(permanent); err != nil {
+		logger.Warn("Could not clear permanent failures from retry journal", "error", err)
+	}
+	client.SetJournal(j)
+	return j
+}
+
+// replayJournal re-attempts the transient failures of earlier runs once an
+// apply run has applied its own changes.  It does nothing in plan mode or
+// when the operator declined the run.  Resources the current plan of modes
+// places are dropped from the journal first: the run has just decided
+// them, so an older write could only undo or repeat its changes.  The
+// remaining writes are listed and confirmed unless --yes is set.
+func replayJournal(ctx context.Context, client *github.Client, j *journal.Journal, modes []string, logger *slog.Logger) error {
+	if assignMode != "apply" || len(j.Transient()) == 0 {
+		return nil
+	}
+	if assignAborted {
+		logger.Info("Journaled writes are kept for the next confirmed apply",
+			"entries", len(j.Transient()), "journal", j.FilePath())
+		return nil
+	}
+
+	sections, err := desiredPlanSections(ctx, client, modes, logger)
+	if err != nil {
+		return fmt.Errorf("computing plan for journal replay: %w", err)
+	}
+	dropped := dropPlannedEntries(j, sections)
+	if dropped > 0 {
+		logger.Info("Dropped journaled writes the current plan decides", "resources", dropped)
+	}
+
+	entries := j.Transient()
+	if len(entries) == 0 {
+		return nil
+	}
+	if !assignYes {
+		proceed, err := confirmReplay(entries)
+		if err != nil {
+			return fmt.Errorf("confirmation failed: %w", err)
+		}
+		if !proceed {
+			logger.Warn("Journaled writes not re-attempted; they are kept for the next apply")
+			return nil
+		}
+	}
+
+	recovered, failed, err := client.ReplayJournal(ctx, entries)
+	if err != nil {
+		return err
+	}
+	logger.Info("Retry journal replayed", "recovered", recovered, "failed_again", failed)
+	return nil
+}
+
+// dropPlannedEntries drops from the transient entries of j every resource
+// that sections place, matched case-insensitively, and returns how many it
+// dropped.
+func dropPlannedEntries(j *journal.Journal, sections []planSection) int {
+	placed := make(map[planMemberKey]bool)
+	for _, s := range sections {
+		for _, r := range s.Items {
+			placed[planMemberKey{s.Unit, strings.ToLower(r)}] = true
+		}
+	}
+	dropped := 0
+	for _, e := range j.Transient() {
+		unit := "users"
+		if e.Op == journal.OpAddRepositories || e.Op == journal.OpRemoveRepositories {
+			unit = "repositories"
+		}
+		var planned []string
+		for _, r := range e.Resources {
+			if placed[planMemberKey{unit, strings.ToLower(r)}] {
+				planned = append(planned, r)
+			}
+		}
+		if len(planned) == 0 {
+			continue
+		}
+		if err := j.Drop(e, planned); err != nil {
+			slog.Default().Warn("Could not write retry journal", "error", err)
+			continue
+		}
+		dropped += len(planned)
+	}
+	return dropped
+}
+
+// confirmReplay lists the journaled writes and asks before re-sending them.
+func confirmReplay(entries []journal.Entry) (bool, error) {
+	fmt.Println("\n" + i18n.T("confirm.replay.intro", len(entries)))
+	for _, e := range entries {
+		fmt.Println(i18n.T("confirm.replay.entry", e.Op, e.CostCenterID, strings.Join(e.Resources, ", ")))
+	}
+	fmt.Print(i18n.T("confirm.proceed"))
+	scanner := bufio.NewScanner(os.Stdin)
+	if scanner.Scan() {
+		return i18n.IsYes(scanner.Text()), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("reading user input: %w", err)
+	}
+	return false, nil
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/journal"
+)
+
+func TestDropPlannedEntries(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	j := journal.Open(t.TempDir(), logger)
+	_ = j.Record(journal.Entry{Op: journal.OpAddUsers, CostCenterID: "cc-a", Resources: []string{"Alice", "bob"}}, true)
+	_ = j.Record(journal.Entry{Op: journal.OpRemoveRepositories, CostCenterID: "cc-a", Resources: []string{"org/api"}}, true)
+	_ = j.Record(journal.Entry{Op: journal.OpAddUsers, CostCenterID: "cc-c", Resources: []string{"org/api"}}, true)
+
+	// The current plan puts alice in CC-B: the stale add to cc-a must not
+	// be replayed.  Repositories and users are matched by unit.
+	sections := []planSection{
+		{Mode: "teams", CostCenter: "CC-B", Unit: "users", Items: []string{"alice"}},
+		{Mode: "repos", CostCenter: "CC-R", Unit: "repositories", Items: []string{"org/api"}},
+	}
+	if n := dropPlannedEntries(j, sections); n != 2 {
+		t.Errorf("dropped = %d, want 2", n)
+	}
+	left := j.Transient()
+	if len(left) != 2 || left[0].Resources[0] != "bob" || left[1].CostCenterID != "cc-c" {
+		t.Errorf("journal = %+v; want bob for cc-a and the user entry of cc-c", left)
+	}
+}
+
+func TestReplayJournal_SkippedWhenDeclined(t *testing.T) {
+	origMode, origAborted := assignMode, assignAborted
+	t.Cleanup(func() { assignMode, assignAborted = origMode, origAborted })
+	assignMode, assignAborted = "apply", true
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	j := journal.Open(t.TempDir(), logger)
+	_ = j.Record(journal.Entry{Op: journal.OpAddUsers, CostCenterID: "cc-a", Resources: []string{"alice"}}, true)
+
+	// A declined run sends nothing: a nil client is never used.
+	if err := replayJournal(t.Context(), nil, j, []string{"users"}, logger); err != nil {
+		t.Fatalf("replayJournal = %v", err)
+	}
+	if len(j.Transient()) != 1 {
+		t.Errorf("journal = %+v; want the entry kept", j.Transient())
+	}
+}
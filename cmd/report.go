@@ -1,8 +1,16 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -19,18 +27,187 @@ var reportCmd = &cobra.Command{
 Shows per-cost-center user counts and assignment breakdown.
 The report type is determined by cost_center.mode in config.yaml.
 
+In teams mode with the manual strategy, --unmapped-teams writes the teams
+missing from team_mappings (with maintainers and member counts) as JSON or
+CSV, chosen by file extension; "-" writes JSON to stdout.
+
+With --format json the summary is written to stdout as a single JSON
+document (enterprise, mode, scope, and per-cost-center user counts) for CI
+pipelines; logs always go to stderr.
+
+Every online report saves its summary to <export_dir>/report_snapshot.json.
+With --offline the latest snapshot is shown instead, without any API calls,
+e.g. during a GitHub incident or while the token is being rotated.
+
+--orphans lists the Copilot seat holders that are in no active cost
+center, whatever the mode: their usage is billed to no cost center.
+--fail-on-orphans does the same and exits with status 1 when there are
+any, for scheduled CI checks.
+
 Examples:
-  gh cost-center report`,
+  gh cost-center report
+  gh cost-center report --offline
+  gh cost-center report --format json | jq '.cost_centers'
+  gh cost-center report --orphans
+  gh cost-center report --fail-on-orphans --format json
+  gh cost-center report --unmapped-teams unmapped.csv
+  gh cost-center report --unmapped-teams - | jq '.[].maintainers'`,
 	RunE: runReport,
 }
 
+var (
+	reportUnmappedTeams string
+	reportFormat        string
+	reportOffline       bool
+	reportOrphans       bool
+	reportFailOnOrphans bool
+)
+
+// reportSnapshotFile is the file inside the export dir that holds the latest
+// online report.
+const reportSnapshotFile = "report_snapshot.json"
+
 func init() {
+	reportCmd.Flags().StringVar(&reportUnmappedTeams, "unmapped-teams", "", "write unmapped teams (manual teams strategy) to a .json/.csv file, or - for stdout")
+	reportCmd.Flags().StringVar(&reportFormat, "format", "text", "output format: text or json")
+	reportCmd.Flags().BoolVar(&skipPermissionCheck, "skip-permission-check", false, "do not check the token's scopes before the report")
+	reportCmd.Flags().BoolVar(&reportOffline, "offline", false, "show the latest saved report without calling the API")
+	reportCmd.Flags().BoolVar(&reportOrphans, "orphans", false, "list Copilot seat holders that are in no cost center")
+	reportCmd.Flags().BoolVar(&reportFailOnOrphans, "fail-on-orphans", false, "like --orphans, and exit with status 1 when there are any")
+
 	rootCmd.AddCommand(reportCmd)
 }
 
-func runReport(_ *cobra.Command, _ []string) error {
+// reportDocument is the --format json output of the report command.
+type reportDocument struct {
+	Enterprise    string    `json:"enterprise"`
+	GeneratedAt   time.Time `json:"generated_at"`
+	Mode          string    `json:"mode"`  // cost_center.mode
+	Scope         string    `json:"scope"` // "enterprise" or "organization"
+	Strategy      string    `json:"strategy,omitempty"`
+	Organizations []string  `json:"organizations,omitempty"`
+	TotalTeams    int       `json:"total_teams,omitempty"`
+	TotalUsers    int       `json:"total_users"`
+	// PendingCancellation counts users whose Copilot seat is due to be
+	// cancelled (users mode).
+	PendingCancellation int                `json:"pending_cancellation,omitempty"`
+	CostCenters         []reportCostCenter `json:"cost_centers"`
+}
+
+// reportCostCenter is one cost center in a reportDocument.
+type reportCostCenter struct {
+	Name  string `json:"name"`
+	ID    string `json:"id,omitempty"`
+	Users int    `json:"users"`
+}
+
+// writeReportJSON writes doc to w, with cost centers sorted by name.
+func writeReportJSON(w io.Writer, doc reportDocument) error {
+	if doc.CostCenters == nil {
+		doc.CostCenters = []reportCostCenter{}
+	}
+	sort.Slice(doc.CostCenters, func(i, j int) bool { return doc.CostCenters[i].Name < doc.CostCenters[j].Name })
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// saveReportSnapshot writes doc to the export dir for later --offline runs.
+// Failures are logged only: the report itself has already been produced.
+func saveReportSnapshot(doc reportDocument) {
+	path := filepath.Join(cfgManager.ExportDir, reportSnapshotFile)
+	if err := os.MkdirAll(cfgManager.ExportDir, 0o755); err != nil {
+		slog.Warn("Could not save report snapshot", "path", path, "error", err)
+		return
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		slog.Warn("Could not save report snapshot", "path", path, "error", err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	if err := writeReportJSON(f, doc); err != nil {
+		slog.Warn("Could not save report snapshot", "path", path, "error", err)
+		return
+	}
+	slog.Debug("Report snapshot saved", "path", path)
+}
+
+// loadReportSnapshot reads the latest report snapshot from the export dir.
+func loadReportSnapshot() (reportDocument, error) {
+	var doc reportDocument
+	path := filepath.Join(cfgManager.ExportDir, reportSnapshotFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return doc, fmt.Errorf("no report snapshot at %s: run report once without --offline first", path)
+	}
+	if err != nil {
+		return doc, fmt.Errorf("reading report snapshot: %w", err)
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return doc, fmt.Errorf("parsing report snapshot %s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// printReportSnapshot displays a snapshot as text.
+func printReportSnapshot(doc reportDocument) {
+	fmt.Printf("\n=== Cost Center Summary (offline, snapshot from %s) ===\n",
+		doc.GeneratedAt.Local().Format("2006-01-02 15:04:05 MST"))
+	fmt.Printf("Enterprise: %s\n", doc.Enterprise)
+	fmt.Printf("Mode: %s\n", doc.Mode)
+	if len(doc.Organizations) > 0 {
+		fmt.Printf("Organizations: %s\n", strings.Join(doc.Organizations, ", "))
+	}
+	if doc.TotalTeams > 0 {
+		fmt.Printf("Total teams: %d\n", doc.TotalTeams)
+	}
+	fmt.Printf("Total users: %d\n", doc.TotalUsers)
+	for _, cc := range doc.CostCenters {
+		fmt.Printf("%s: %d users\n", cc.Name, cc.Users)
+	}
+}
+
+// runOfflineReport shows the latest snapshot without creating an API client.
+func runOfflineReport() error {
+	if reportUnmappedTeams != "" {
+		return fmt.Errorf("--unmapped-teams is not available with --offline")
+	}
+	doc, err := loadReportSnapshot()
+	if err != nil {
+		return err
+	}
+	if doc.Enterprise != cfgManager.Enterprise {
+		return fmt.Errorf("report snapshot is for enterprise %q, not %q", doc.Enterprise, cfgManager.Enterprise)
+	}
+	slog.Info("Using report snapshot", "generated_at", doc.GeneratedAt.Format(time.RFC3339),
+		"age", time.Since(doc.GeneratedAt).Round(time.Minute).String())
+	if reportFormat == "json" {
+		return writeReportJSON(os.Stdout, doc)
+	}
+	printReportSnapshot(doc)
+	return nil
+}
+
+func runReport(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	if reportFormat != "text" && reportFormat != "json" {
+		return fmt.Errorf("invalid --format %q: must be 'text' or 'json'", reportFormat)
+	}
+	if reportOrphans || reportFailOnOrphans {
+		if reportOffline || reportUnmappedTeams != "" {
+			return fmt.Errorf("--orphans cannot be combined with --offline or --unmapped-teams")
+		}
+		return runOrphansReport(ctx)
+	}
+	if reportOffline {
+		return runOfflineReport()
+	}
 	if cfgManager.CostCenterMode == "teams" {
-		return runTeamsReport()
+		return runTeamsReport(ctx)
+	}
+	if reportUnmappedTeams != "" {
+		return fmt.Errorf("--unmapped-teams requires cost_center.mode: teams (current: %s)", cfgManager.CostCenterMode)
 	}
 
 	logger := slog.Default()
@@ -40,12 +217,15 @@ func runReport(_ *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("creating GitHub client: %w", err)
 	}
+	if err := checkPermissions(ctx, client, "report", []string{cfgManager.CostCenterMode}, false); err != nil {
+		return err
+	}
 
 	// Initialize PRU manager.
 	mgr := pru.NewManager(cfgManager, logger)
 
 	// Fetch Copilot users.
-	users, err := client.GetCopilotUsers()
+	users, err := client.GetCopilotUsers(ctx)
 	if err != nil {
 		return fmt.Errorf("fetching copilot users: %w", err)
 	}
@@ -53,33 +233,129 @@ func runReport(_ *cobra.Command, _ []string) error {
 	// Generate and display summary.
 	summary := mgr.GenerateSummary(users)
 
+	names := map[string]string{
+		mgr.NoPRUCCID():      cfgManager.NoPRUsCostCenterName,
+		mgr.PRUAllowedCCID(): cfgManager.PRUsAllowedCostCenterName,
+	}
+	if mgr.SegregatesServerUsers() {
+		names[mgr.ServerCCID()] = cfgManager.ServerCostCenterName
+	}
+	if mgr.WindsDownPendingCancellations() {
+		names[mgr.WindDownCCID()] = cfgManager.WindDownCostCenterName
+	}
+	doc := reportDocument{
+		Enterprise:          cfgManager.Enterprise,
+		GeneratedAt:         time.Now().UTC(),
+		Mode:                cfgManager.CostCenterMode,
+		Scope:               "enterprise",
+		TotalUsers:          len(users),
+		PendingCancellation: len(pru.PendingCancellations(users)),
+	}
+	for cc, count := range summary {
+		name := names[cc]
+		if name == "" {
+			name = cc
+		}
+		doc.CostCenters = append(doc.CostCenters, reportCostCenter{Name: name, ID: cc, Users: count})
+	}
+	saveReportSnapshot(doc)
+
+	if reportFormat == "json" {
+		return writeReportJSON(os.Stdout, doc)
+	}
+
 	fmt.Println("\n=== Cost Center Summary ===")
 	logger.Info("Cost Center Assignment Summary")
 	for cc, count := range summary {
 		fmt.Printf("%s: %d users\n", cc, count)
 		logger.Info("Cost center", "id", cc, "users", count)
 	}
+	if doc.PendingCancellation > 0 {
+		fmt.Printf("Seats pending cancellation: %d users (handling: %s)\n",
+			doc.PendingCancellation, cfgManager.PendingCancellationHandling)
+	}
 
 	return nil
 }
 
 // runTeamsReport generates a teams-aware cost center report.
-func runTeamsReport() error {
+func runTeamsReport(ctx context.Context) error {
 	logger := slog.Default()
 
 	client, err := github.NewClient(cfgManager, logger)
 	if err != nil {
 		return fmt.Errorf("creating GitHub client: %w", err)
 	}
+	if err := checkPermissions(ctx, client, "report", []string{cfgManager.CostCenterMode}, false); err != nil {
+		return err
+	}
 
+	if err := teams.ResolveScope(ctx, cfgManager, client, logger); err != nil {
+		return err
+	}
 	mgr := teams.NewManager(cfgManager, client, logger)
 
-	summary, err := mgr.GenerateSummary()
+	if reportUnmappedTeams != "" {
+		return writeUnmappedTeams(ctx, mgr, reportUnmappedTeams)
+	}
+
+	summary, err := mgr.GenerateSummary(ctx)
 	if err != nil {
 		return fmt.Errorf("generating teams summary: %w", err)
 	}
 
+	doc := reportDocument{
+		Enterprise:    cfgManager.Enterprise,
+		GeneratedAt:   time.Now().UTC(),
+		Mode:          cfgManager.CostCenterMode,
+		Scope:         summary.Scope,
+		Strategy:      summary.Mode,
+		Organizations: summary.Organizations,
+		TotalTeams:    summary.TotalTeams,
+		TotalUsers:    summary.UniqueUsers,
+	}
+	for name, count := range summary.CostCenters {
+		doc.CostCenters = append(doc.CostCenters, reportCostCenter{Name: name, Users: count})
+	}
+	saveReportSnapshot(doc)
+
+	if reportFormat == "json" {
+		return writeReportJSON(os.Stdout, doc)
+	}
+
 	summary.Print(cfgManager.Enterprise)
 
 	return nil
 }
+
+// writeUnmappedTeams exports the unmapped teams to path.  The format follows
+// the file extension (.csv, otherwise JSON); "-" writes JSON to stdout.
+func writeUnmappedTeams(ctx context.Context, mgr *teams.Manager, path string) error {
+	unmapped, err := mgr.UnmappedTeams(ctx)
+	if err != nil {
+		return fmt.Errorf("listing unmapped teams: %w", err)
+	}
+
+	format := "json"
+	if filepath.Ext(path) == ".csv" {
+		format = "csv"
+	}
+
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("creating %s: %w", path, err)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+
+	if err := teams.WriteUnmappedTeams(w, unmapped, format); err != nil {
+		return fmt.Errorf("writing unmapped teams: %w", err)
+	}
+	if path != "-" {
+		slog.Info("Unmapped teams exported", "path", path, "count", len(unmapped))
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+)
+
+func TestWriteReportJSON(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeReportJSON(&buf, reportDocument{
+		Enterprise: "ent",
+		Mode:       "teams",
+		Scope:      "organization",
+		TotalUsers: 3,
+		CostCenters: []reportCostCenter{
+			{Name: "Zeta", Users: 1},
+			{Name: "Alpha", Users: 2},
+		},
+	})
+	if err != nil {
+		t.Fatalf("writeReportJSON: %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+	}
+	if got["enterprise"] != "ent" || got["mode"] != "teams" || got["scope"] != "organization" {
+		t.Errorf("header fields = %v", got)
+	}
+	ccs := got["cost_centers"].([]any)
+	if len(ccs) != 2 || ccs[0].(map[string]any)["name"] != "Alpha" {
+		t.Errorf("cost_centers = %v, want sorted by name", ccs)
+	}
+}
+
+func TestWriteReportJSON_EmptyCostCenters(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeReportJSON(&buf, reportDocument{Enterprise: "ent"}); err != nil {
+		t.Fatalf("writeReportJSON: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"cost_centers": []`)) {
+		t.Errorf("want empty cost_centers array, got %s", buf.String())
+	}
+}
+
+func TestReportSnapshot_RoundTrip(t *testing.T) {
+	orig := cfgManager
+	t.Cleanup(func() { cfgManager = orig })
+	cfgManager = &config.Manager{Enterprise: "ent", ExportDir: t.TempDir()}
+
+	if _, err := loadReportSnapshot(); err == nil || !strings.Contains(err.Error(), "without --offline") {
+		t.Fatalf("loadReportSnapshot with no snapshot: err = %v", err)
+	}
+
+	generated := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	saveReportSnapshot(reportDocument{
+		Enterprise:  "ent",
+		GeneratedAt: generated,
+		Mode:        "users",
+		TotalUsers:  2,
+		CostCenters: []reportCostCenter{{Name: "No PRU", ID: "id-1", Users: 2}},
+	})
+
+	doc, err := loadReportSnapshot()
+	if err != nil {
+		t.Fatalf("loadReportSnapshot: %v", err)
+	}
+	if !doc.GeneratedAt.Equal(generated) || doc.TotalUsers != 2 || len(doc.CostCenters) != 1 || doc.CostCenters[0].ID != "id-1" {
+		t.Errorf("snapshot = %+v", doc)
+	}
+
+	cfgManager.Enterprise = "other"
+	if err := runOfflineReport(); err == nil {
+		t.Error("expected an error for a snapshot of another enterprise")
+	}
+}
@@ -0,0 +1,364 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
+	"github.com/renan-alm/gh-cost-center/internal/state"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Undo the membership changes of a previous apply",
+	Long: `Reverse the user and repository moves made by a previous apply.
+
+A run is identified by the ID of the snapshot taken just before it (see
+"snapshot list"), or "latest" for the most recent apply.  The changes the
+run made are the difference between that snapshot and the next one (or the
+current membership when there is none): users and repositories it added
+are removed again, and those it moved or removed go back to the cost
+center they were in before.
+
+Resources that have changed again since the run, or whose earlier cost
+center no longer exists, are reported and left alone.  Cost centers the
+run created are not deleted.
+
+In apply mode a snapshot is taken first (skip with --no-snapshot), so a
+rollback can itself be rolled back.
+
+Examples:
+  gh cost-center rollback --run latest
+  gh cost-center rollback --run 20260401T100000Z --mode apply`,
+	Args: cobra.NoArgs,
+	RunE: runRollback,
+}
+
+var (
+	rollbackRun        string
+	rollbackMode       string
+	rollbackYes        bool
+	rollbackNoSnapshot bool
+)
+
+func init() {
+	rollbackCmd.Flags().StringVar(&rollbackRun, "run", "", "ID of the snapshot taken before the apply to undo, or \"latest\"")
+	rollbackCmd.Flags().StringVar(&rollbackMode, "mode", "plan", "execution mode: plan (preview) or apply (push changes)")
+	rollbackCmd.Flags().BoolVarP(&rollbackYes, "yes", "y", false, "skip confirmation prompt in apply mode")
+	rollbackCmd.Flags().BoolVar(&rollbackNoSnapshot, "no-snapshot", false, "do not capture a membership snapshot before rolling back")
+	rollbackCmd.Flags().BoolVar(&skipPermissionCheck, "skip-permission-check", false, "do not check the token's scopes before the rollback")
+	_ = rollbackCmd.MarkFlagRequired("run")
+
+	rootCmd.AddCommand(rollbackCmd)
+}
+
+// rollbackMove puts one resource back where it was before the run.
+type rollbackMove struct {
+	Unit     string // "users" or "repositories"
+	Resource string
+	From     string // cost center ID it is in now
+	FromName string
+	To       string // cost center ID it was in before the run; empty for none
+	ToName   string
+}
+
+// rollbackConflict is a resource the run changed that cannot be put back.
+type rollbackConflict struct {
+	Unit     string
+	Resource string
+	Reason   string
+}
+
+func runRollback(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	if rollbackMode != "plan" && rollbackMode != "apply" {
+		return fmt.Errorf("invalid --mode %q: must be 'plan' or 'apply'", rollbackMode)
+	}
+	logger := slog.Default()
+
+	snaps, err := state.NewStore(cfgManager.ExportDir).List(cfgManager.Enterprise)
+	if err != nil {
+		return err
+	}
+	before, after, err := findRun(snaps, rollbackRun)
+	if err != nil {
+		return err
+	}
+
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	if err := checkPermissions(ctx, client, "rollback", []string{cfgManager.CostCenterMode}, rollbackMode == "apply"); err != nil {
+		return err
+	}
+
+	now, err := state.Capture(ctx, client, cfgManager.Enterprise, state.ReasonPreRollback)
+	if err != nil {
+		return fmt.Errorf("reading current membership: %w", err)
+	}
+	if after == nil {
+		after = now
+	}
+	moves, conflicts := planRollback(before, after, now)
+	printRollback(os.Stdout, before, after, moves, conflicts)
+
+	if rollbackMode != "apply" || len(moves) == 0 {
+		return nil
+	}
+	if !rollbackYes {
+		ok, err := confirmRollback(before.ID, len(moves))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			logger.Warn("Rollback aborted by user")
+			return nil
+		}
+	}
+
+	sink, err := attachAuditSink(client, logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := sink.Close(); cerr != nil {
+			logger.Warn("Audit sink", "error", cerr)
+		}
+	}()
+
+	if !rollbackNoSnapshot {
+		path, err := state.NewStore(cfgManager.ExportDir).Save(now)
+		if err != nil {
+			return fmt.Errorf("%w (use --no-snapshot to roll back without one)", err)
+		}
+		logger.Info("Snapshot saved", "id", now.ID, "reason", now.Reason, "path", path)
+	}
+	return applyRollback(ctx, client, moves, logger)
+}
+
+// findRun returns the snapshot taken before the run with the given ID (or
+// the latest apply) and the snapshot that follows it, nil when the run is
+// the most recent one.  snaps are the enterprise's snapshots, oldest first.
+func findRun(snaps []*state.Snapshot, id string) (before, after *state.Snapshot, err error) {
+	idx := -1
+	for i, s := range snaps {
+		if id == state.Latest && (s.Reason == state.ReasonPreApply || s.Reason == state.ReasonPreRollback) {
+			idx = i
+		} else if s.ID == id {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		if id == state.Latest {
+			return nil, nil, fmt.Errorf("%w: no apply has been snapshotted", state.ErrNotFound)
+		}
+		return nil, nil, fmt.Errorf("%w: %s", state.ErrNotFound, id)
+	}
+	before = snaps[idx]
+	if before.Reason != state.ReasonPreApply && before.Reason != state.ReasonPreRollback {
+		return nil, nil, fmt.Errorf("snapshot %s (%s) was not taken before an apply", before.ID, before.Reason)
+	}
+	if idx+1 < len(snaps) {
+		after = snaps[idx+1]
+	}
+	return before, after, nil
+}
+
+// planRollback works out the moves that undo the changes between before
+// and after, given the membership now.  A resource is only moved if it is
+// still where the run left it and its earlier cost center is still active.
+// Moves and conflicts are sorted by unit, then resource.
+func planRollback(before, after, now *state.Snapshot) ([]rollbackMove, []rollbackConflict) {
+	names := make(map[string]string)
+	for _, s := range []*state.Snapshot{before, after, now} {
+		for _, cc := range s.CostCenters {
+			names[cc.ID] = cc.Name
+		}
+	}
+	active := make(map[string]bool, len(now.CostCenters))
+	for _, cc := range now.CostCenters {
+		active[cc.ID] = true
+	}
+
+	var moves []rollbackMove
+	var conflicts []rollbackConflict
+	for _, unit := range []string{"users", "repositories"} {
+		was, left, is := locations(before, unit), locations(after, unit), locations(now, unit)
+		resources := make([]string, 0, len(was)+len(left))
+		for r := range was {
+			resources = append(resources, r)
+		}
+		for r := range left {
+			if _, ok := was[r]; !ok {
+				resources = append(resources, r)
+			}
+		}
+		sort.Strings(resources)
+
+		for _, r := range resources {
+			if was[r] == left[r] {
+				continue
+			}
+			switch {
+			case is[r] != left[r]:
+				conflicts = append(conflicts, rollbackConflict{Unit: unit, Resource: r,
+					Reason: fmt.Sprintf("moved to %s since the run", ccLabel(names, is[r]))})
+			case was[r] != "" && !active[was[r]]:
+				conflicts = append(conflicts, rollbackConflict{Unit: unit, Resource: r,
+					Reason: fmt.Sprintf("cost center %s no longer exists", ccLabel(names, was[r]))})
+			default:
+				moves = append(moves, rollbackMove{
+					Unit: unit, Resource: r,
+					From: left[r], FromName: names[left[r]],
+					To: was[r], ToName: names[was[r]],
+				})
+			}
+		}
+	}
+	return moves, conflicts
+}
+
+// locations maps each user or repository in snap to its cost center ID.
+func locations(snap *state.Snapshot, unit string) map[string]string {
+	loc := make(map[string]string)
+	for _, cc := range snap.CostCenters {
+		members := cc.Users
+		if unit == "repositories" {
+			members = cc.Repositories
+		}
+		for _, m := range members {
+			loc[m] = cc.ID
+		}
+	}
+	return loc
+}
+
+// ccLabel names a cost center ID for output; empty means none.
+func ccLabel(names map[string]string, id string) string {
+	switch {
+	case id == "":
+		return "(none)"
+	case names[id] != "":
+		return names[id]
+	default:
+		return id
+	}
+}
+
+// printRollback writes the moves and conflicts of a rollback.
+func printRollback(w io.Writer, before, after *state.Snapshot, moves []rollbackMove, conflicts []rollbackConflict) {
+	_, _ = fmt.Fprintln(w, strings.Repeat("=", 80))
+	if after.ID != "" {
+		_, _ = fmt.Fprintln(w, i18n.T("rollback.title_snapshot", before.ID, after.ID))
+	} else {
+		_, _ = fmt.Fprintln(w, i18n.T("rollback.title_now", before.ID))
+	}
+	_, _ = fmt.Fprintln(w, strings.Repeat("=", 80))
+	if len(moves) == 0 {
+		_, _ = fmt.Fprintln(w, i18n.T("rollback.nothing"))
+	}
+	for _, m := range moves {
+		_, _ = fmt.Fprintf(w, "  %-12s %s: %s -> %s\n", m.Unit, m.Resource,
+			ccLabel(map[string]string{m.From: m.FromName}, m.From),
+			ccLabel(map[string]string{m.To: m.ToName}, m.To))
+	}
+	if len(conflicts) > 0 {
+		_, _ = fmt.Fprintln(w, "\n"+i18n.T("rollback.skipped", len(conflicts)))
+		for _, c := range conflicts {
+			_, _ = fmt.Fprintf(w, "  %-12s %s: %s\n", c.Unit, c.Resource, c.Reason)
+		}
+	}
+	_, _ = fmt.Fprintln(w, "\n"+i18n.T("rollback.total", len(moves), len(conflicts)))
+}
+
+// confirmRollback prompts for confirmation in interactive apply mode.
+func confirmRollback(id string, n int) (bool, error) {
+	fmt.Println("\n" + i18n.T("confirm.rollback.intro", id, n))
+	fmt.Print(i18n.T("confirm.proceed"))
+	scanner := bufio.NewScanner(os.Stdin)
+	if scanner.Scan() {
+		return i18n.IsYes(scanner.Text()), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("reading user input: %w", err)
+	}
+	return false, nil
+}
+
+// applyRollback pushes the moves, one request per cost center and unit:
+// resources with an earlier cost center are added back to it (which moves
+// them), the rest are removed from where the run put them.
+func applyRollback(ctx context.Context, client *github.Client, moves []rollbackMove, logger *slog.Logger) error {
+	type key struct{ unit, id string }
+	adds := make(map[key][]string)
+	removes := make(map[key][]string)
+	for _, m := range moves {
+		if m.To != "" {
+			adds[key{m.Unit, m.To}] = append(adds[key{m.Unit, m.To}], m.Resource)
+		} else {
+			removes[key{m.Unit, m.From}] = append(removes[key{m.Unit, m.From}], m.Resource)
+		}
+	}
+	sorted := func(m map[key][]string) []key {
+		keys := make([]key, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].unit != keys[j].unit {
+				return keys[i].unit < keys[j].unit
+			}
+			return keys[i].id < keys[j].id
+		})
+		return keys
+	}
+
+	var errs []error
+	for _, k := range sorted(adds) {
+		if k.unit == "users" {
+			results, err := client.AddUsersToCostCenter(ctx, k.id, adds[k], true)
+			if failed := countFailed(results); err == nil && failed > 0 {
+				err = fmt.Errorf("%d users could not be added back to cost center %s", failed, k.id)
+			}
+			errs = append(errs, err)
+		} else {
+			errs = append(errs, client.AddRepositoriesToCostCenter(ctx, k.id, adds[k]))
+		}
+	}
+	for _, k := range sorted(removes) {
+		if k.unit == "users" {
+			_, err := client.RemoveUsersFromCostCenter(ctx, k.id, removes[k])
+			errs = append(errs, err)
+		} else {
+			errs = append(errs, client.RemoveRepositoriesFromCostCenter(ctx, k.id, removes[k]))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("rollback incomplete: %w", err)
+	}
+	logger.Info("Rollback complete", "moved", len(moves))
+	return nil
+}
+
+// countFailed returns how many entries of a per-user result map failed.
+func countFailed(results map[string]bool) int {
+	n := 0
+	for _, ok := range results {
+		if !ok {
+			n++
+		}
+	}
+	return n
+}
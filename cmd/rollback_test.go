@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/state"
+)
+
+func TestFindRun(t *testing.T) {
+	at := time.Date(2026, 4, 1, 10, 0, 0, 0, time.UTC)
+	snaps := []*state.Snapshot{
+		{ID: "a", TakenAt: at, Reason: state.ReasonPreApply},
+		{ID: "b", TakenAt: at.Add(time.Hour), Reason: state.ReasonManual},
+		{ID: "c", TakenAt: at.Add(2 * time.Hour), Reason: state.ReasonPreApply},
+	}
+
+	before, after, err := findRun(snaps, "a")
+	if err != nil || before.ID != "a" || after.ID != "b" {
+		t.Errorf("findRun(a) = %v, %v, %v", before, after, err)
+	}
+	before, after, err = findRun(snaps, state.Latest)
+	if err != nil || before.ID != "c" || after != nil {
+		t.Errorf("findRun(latest) = %v, %v, %v", before, after, err)
+	}
+	if _, _, err := findRun(snaps, "b"); err == nil {
+		t.Error("expected an error for a manual snapshot")
+	}
+	if _, _, err := findRun(snaps, "zzz"); !errors.Is(err, state.ErrNotFound) {
+		t.Errorf("findRun(missing) error = %v, want ErrNotFound", err)
+	}
+	if _, _, err := findRun(nil, state.Latest); !errors.Is(err, state.ErrNotFound) {
+		t.Errorf("findRun(latest, none) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPlanRollback(t *testing.T) {
+	before := &state.Snapshot{CostCenters: []state.CostCenter{
+		{ID: "cc-eng", Name: "Eng", Users: []string{"alice", "bob"}, Repositories: []string{"org/a"}},
+		{ID: "cc-old", Name: "Old", Users: []string{"dave"}},
+		{ID: "cc-ops", Name: "Ops", Users: []string{"erin"}},
+	}}
+	// The run moved bob, dave and erin, added carol and org/b, and removed
+	// org/a.
+	after := &state.Snapshot{ID: "next", CostCenters: []state.CostCenter{
+		{ID: "cc-eng", Name: "Eng", Users: []string{"alice", "carol", "dave"}},
+		{ID: "cc-old", Name: "Old"},
+		{ID: "cc-ops", Name: "Ops", Users: []string{"bob", "erin-2"}, Repositories: []string{"org/b"}},
+		{ID: "cc-new", Name: "New", Users: []string{"erin"}},
+	}}
+	// Since then erin moved again and Old was deleted.
+	now := &state.Snapshot{CostCenters: []state.CostCenter{
+		{ID: "cc-eng", Name: "Eng", Users: []string{"alice", "carol", "dave", "erin"}},
+		{ID: "cc-ops", Name: "Ops", Users: []string{"bob", "erin-2"}, Repositories: []string{"org/b"}},
+		{ID: "cc-new", Name: "New"},
+	}}
+
+	moves, conflicts := planRollback(before, after, now)
+	wantMoves := []rollbackMove{
+		{Unit: "users", Resource: "bob", From: "cc-ops", FromName: "Ops", To: "cc-eng", ToName: "Eng"},
+		{Unit: "users", Resource: "carol", From: "cc-eng", FromName: "Eng"},
+		{Unit: "users", Resource: "erin-2", From: "cc-ops", FromName: "Ops"},
+		{Unit: "repositories", Resource: "org/a", To: "cc-eng", ToName: "Eng"},
+		{Unit: "repositories", Resource: "org/b", From: "cc-ops", FromName: "Ops"},
+	}
+	if !reflect.DeepEqual(moves, wantMoves) {
+		t.Errorf("moves = %+v\nwant %+v", moves, wantMoves)
+	}
+	wantConflicts := []rollbackConflict{
+		{Unit: "users", Resource: "dave", Reason: "cost center Old no longer exists"},
+		{Unit: "users", Resource: "erin", Reason: "moved to Eng since the run"},
+	}
+	if !reflect.DeepEqual(conflicts, wantConflicts) {
+		t.Errorf("conflicts = %+v\nwant %+v", conflicts, wantConflicts)
+	}
+
+	var buf bytes.Buffer
+	printRollback(&buf, &state.Snapshot{ID: "run"}, after, moves, conflicts)
+	out := buf.String()
+	for _, want := range []string{"Rollback of run run (changes up to snapshot next)", "bob: Ops -> Eng", "carol: Eng -> (none)", "Total: 5 to move back, 2 skipped"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -2,20 +2,29 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
 )
 
 var (
 	// Global flags
-	cfgFile   string
-	verbose   bool
-	tokenFlag string
+	cfgFile        string
+	verbose        bool
+	tokenFlag      string
+	langFlag       string
+	maxRetriesFlag int
+	sharedRateFile string
+	lenient        bool
+	profileFlag    string
 
 	// cfgManager is the loaded configuration, available to all subcommands.
 	cfgManager *config.Manager
@@ -68,21 +77,62 @@ Examples:
 		slog.SetDefault(logger)
 
 		// Load configuration.
-		mgr, err := config.Load(cfgFile, logger)
+		var mgr *config.Manager
+		var err error
+		if inputsConfig != nil {
+			mgr, err = config.LoadDataWith(cfgFile, inputsConfig, profileFlag, logger)
+		} else {
+			mgr, err = config.LoadWith(cfgFile, config.Options{Lenient: lenient, Profile: profileFlag}, logger)
+		}
+		var schemaErr *config.SchemaError
+		if errors.As(err, &schemaErr) && inputsConfig == nil {
+			return fmt.Errorf("loading configuration: %w\n(fix these, or run with --lenient to ignore them)", err)
+		}
 		if err != nil {
 			return fmt.Errorf("loading configuration: %w", err)
 		}
 		cfgManager = mgr
 		cfgManager.Token = tokenFlag
+		if cmd.Flags().Changed("max-retries") {
+			if err := config.ValidateMaxRetries(maxRetriesFlag); err != nil {
+				return fmt.Errorf("invalid --max-retries: %w", err)
+			}
+			cfgManager.MaxRetries = maxRetriesFlag
+		}
+		if sharedRateFile != "" {
+			cfgManager.SharedRateLimitFile = sharedRateFile
+		}
 		cfgManager.CheckConfigWarnings()
-		return nil
+
+		// Prompts and summaries: --lang, then config/env, then the locale.
+		lang := langFlag
+		if lang == "" {
+			lang = cfgManager.Language
+		}
+		if lang == "" {
+			lang = i18n.FromLocale()
+		}
+		return i18n.SetLanguage(strings.ToLower(lang))
 	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
-// This is called by main.main(). It only needs to happen once.
-func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+// This is called by main.main(). It only needs to happen once.  A run
+// cancelled through ctx (Ctrl-C) exits with status 130.
+func Execute(ctx context.Context) {
+	args, cfg, err := expandInputsJSON(rootCmd, os.Args[1:], os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	inputsConfig = cfg
+	rootCmd.SetArgs(args)
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		if errors.Is(err, context.Canceled) {
+			fmt.Fprintf(os.Stderr, "Interrupted: %v\n", err)
+			os.Exit(130) // 128 + SIGINT(2) — standard Unix convention
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -91,5 +141,13 @@ func Execute() {
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "config/config.yaml", "configuration file path")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "enable verbose (debug) logging")
-	rootCmd.PersistentFlags().StringVar(&tokenFlag, "token", "", "GitHub personal access token (overrides GITHUB_TOKEN, GH_TOKEN, and gh auth)")
+	rootCmd.PersistentFlags().StringVar(&tokenFlag, "token", "", "GitHub personal access token (overrides GH_TOKEN, GITHUB_TOKEN, and gh auth)")
+	rootCmd.PersistentFlags().IntVar(&maxRetriesFlag, "max-retries", 0, "attempts per API request on network errors and 5xx, including the first (overrides github.retry.max_retries)")
+	rootCmd.PersistentFlags().StringVar(&sharedRateFile, "shared-rate-limit-file", "", "usage ledger shared with other automations; requests are paced to github.shared_rate_limit.points_per_minute across all of them (overrides github.shared_rate_limit.file)")
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "configuration profile to merge over the top-level settings, from the config file's profiles section (overrides "+config.ProfileEnv+")")
+	rootCmd.PersistentFlags().BoolVar(&lenient, "lenient", false, "warn about unknown keys and mistyped values in the config file and ignore them, instead of failing")
+	// Consumed by expandInputsJSON before cobra parses the command line;
+	// registered so it shows in --help.
+	rootCmd.PersistentFlags().String(inputsFlag, "", "run non-interactively from one JSON document of flags and config: inline JSON, a file, or - for stdin (see README)")
+	rootCmd.PersistentFlags().StringVar(&langFlag, "lang", "", "language of prompts and summaries: en, es, or pt (default from config or locale)")
 }
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/pru"
+	"github.com/renan-alm/gh-cost-center/internal/rules"
+	"github.com/renan-alm/gh-cost-center/internal/sources"
+	"github.com/renan-alm/gh-cost-center/internal/teams"
+)
+
+// rulesSource labels rules mode placements in the summary and plan.
+const rulesSource = "rules"
+
+// rulesDesired computes cost center -> users by evaluating the assignment
+// rules for every Copilot seat holder.  Organization and team memberships
+// and identity provider attributes are only fetched when a rule looks at
+// them; teams are those of the teams mode scope, listed by teams.Manager.
+func rulesDesired(ctx context.Context, client *github.Client, logger *slog.Logger) (map[string][]string, error) {
+	engine, err := rules.New(cfgManager.Rules, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	users, err := client.GetCopilotUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching copilot users: %w", err)
+	}
+
+	orgsOf := make(map[string][]string)
+	teamsOf := make(map[string][]string)
+	if engine.UsesOrgs() {
+		for _, org := range cfgManager.Organizations {
+			members, err := client.GetOrgMembers(ctx, org)
+			if err != nil {
+				return nil, fmt.Errorf("listing organization members: %w", err)
+			}
+			for _, m := range members {
+				key := strings.ToLower(m.Login)
+				orgsOf[key] = append(orgsOf[key], org)
+			}
+		}
+	}
+	if engine.UsesTeams() {
+		if err := teams.ResolveScope(ctx, cfgManager, client, logger); err != nil {
+			return nil, err
+		}
+		if teamsOf, err = teams.NewManager(cfgManager, client, logger).MemberTeams(ctx); err != nil {
+			return nil, fmt.Errorf("listing team members: %w", err)
+		}
+	}
+
+	attrsOf := make(map[string]map[string][]string)
+	if engine.UsesAttributes() {
+		ids, err := client.GetEnterpriseIdentities(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("fetching external identities: %w", err)
+		}
+		for _, id := range ids {
+			attrsOf[strings.ToLower(id.Login)] = id.AttributeMap()
+		}
+	}
+
+	var pruMgr *pru.Manager
+	if cfgManager.RulesDefault.PRU {
+		pruMgr = pru.NewManager(cfgManager, logger)
+	}
+	desired, counts := evaluateRules(engine, users, memberships{orgsOf, teamsOf, attrsOf}, func(u github.CopilotUser) (string, bool) {
+		switch {
+		case pruMgr != nil:
+			if pruMgr.Skips(u) {
+				return "", false
+			}
+			return pruCostCenterName(pruMgr, u), true
+		case cfgManager.RulesDefault.CostCenter != "":
+			return cfgManager.RulesDefault.CostCenter, true
+		}
+		return "", false
+	})
+	printRuleCounts(logger, counts)
+	return desired, nil
+}
+
+// permissionSettings returns the settings that decide which API areas the
+// configured modes read.  Rules that do not compile require every
+// membership their evaluation could read; the run reports the error.
+func permissionSettings() github.ModeSettings {
+	settings := github.ModeSettings{TeamsScope: cfgManager.TeamsScope}
+	if len(cfgManager.Rules) == 0 {
+		return settings
+	}
+	engine, err := rules.New(cfgManager.Rules, time.Now())
+	if err != nil {
+		settings.RulesOrgs, settings.RulesTeams, settings.RulesAttributes = true, true, true
+		return settings
+	}
+	settings.RulesOrgs = engine.UsesOrgs()
+	settings.RulesTeams = engine.UsesTeams()
+	settings.RulesAttributes = engine.UsesAttributes()
+	return settings
+}
+
+// Labels of users placed by no rule in the counts of evaluateRules.
+const (
+	ruleDefault    = "(default)"
+	ruleUnassigned = "(unassigned)"
+)
+
+// memberships holds what rules may look at beyond the seat, by lower-cased
+// login.
+type memberships struct {
+	orgs       map[string][]string
+	teams      map[string][]string // team keys
+	attributes map[string]map[string][]string
+}
+
+// evaluateRules places every user by the first rule they match, or by
+// fallback.  counts tallies users per rule name, ruleDefault and
+// ruleUnassigned.
+func evaluateRules(engine *rules.Engine, users []github.CopilotUser, of memberships, fallback func(github.CopilotUser) (string, bool)) (desired map[string][]string, counts map[string]int) {
+	desired = make(map[string][]string)
+	counts = make(map[string]int)
+	for _, u := range users {
+		key := strings.ToLower(u.Login)
+		s := rules.Subject{
+			Login:      u.Login,
+			Orgs:       of.orgs[key],
+			Teams:      of.teams[key],
+			Plan:       u.Plan,
+			Attributes: of.attributes[key],
+		}
+		if t, err := time.Parse(time.RFC3339, u.LastActivityAt); err == nil {
+			s.LastActivity = t
+		}
+		cc, rule, ok := engine.Evaluate(s)
+		if !ok {
+			if cc, ok = fallback(u); !ok {
+				counts[ruleUnassigned]++
+				continue
+			}
+			rule = ruleDefault
+		}
+		counts[rule]++
+		desired[cc] = append(desired[cc], u.Login)
+	}
+	for _, logins := range desired {
+		sort.Strings(logins)
+	}
+	return desired, counts
+}
+
+// printRuleCounts logs how many users each rule placed.
+func printRuleCounts(logger *slog.Logger, counts map[string]int) {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		logger.Info("Rule matched users", "rule", name, "users", counts[name])
+	}
+}
+
+// runRulesAssign implements assign for rules mode: the placement the rules
+// decide is planned or applied like a plan file.
+func runRulesAssign(ctx context.Context, client *github.Client) error {
+	logger := slog.Default()
+	desired, err := rulesDesired(ctx, client, logger)
+	if err != nil {
+		return err
+	}
+	order := []string{rulesSource}
+	res := sources.Resolve(order, map[string]sources.Proposal{rulesSource: desired})
+	_, err = assignResolved(ctx, client, []string{"rules"}, order, res, logger)
+	return err
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/rules"
+)
+
+func TestEvaluateRules(t *testing.T) {
+	engine, err := rules.New([]config.AssignmentRule{
+		{Name: "engineering", Teams: []string{"acme/eng-*"}, CostCenter: "Eng"},
+		{Name: "sales", Orgs: []string{"acme-sales"}, CostCenter: "[org] {org}"},
+		{Name: "finance", Attributes: map[string][]string{"department": {"fin*"}}, CostCenter: "Dept {attr.Department}"},
+	}, time.Now())
+	if err != nil {
+		t.Fatalf("rules.New: %v", err)
+	}
+	users := []github.CopilotUser{{Login: "Alice"}, {Login: "bob"}, {Login: "carol"}, {Login: "dave"}, {Login: "erin"}}
+	of := memberships{
+		orgs:       map[string][]string{"bob": {"acme-sales"}},
+		teams:      map[string][]string{"alice": {"acme/eng-web"}},
+		attributes: map[string]map[string][]string{"erin": {"department": {"Finance"}}, "dave": {"department": {"Sales"}}},
+	}
+	fallback := func(u github.CopilotUser) (string, bool) {
+		return "Default", u.Login == "carol"
+	}
+
+	desired, counts := evaluateRules(engine, users, of, fallback)
+	want := map[string][]string{
+		"Eng":              {"Alice"},
+		"[org] acme-sales": {"bob"},
+		"Default":          {"carol"},
+		"Dept Finance":     {"erin"},
+	}
+	if !reflect.DeepEqual(desired, want) {
+		t.Errorf("desired = %v, want %v", desired, want)
+	}
+	wantCounts := map[string]int{"engineering": 1, "sales": 1, "finance": 1, ruleDefault: 1, ruleUnassigned: 1}
+	if !reflect.DeepEqual(counts, wantCounts) {
+		t.Errorf("counts = %v, want %v", counts, wantCounts)
+	}
+}
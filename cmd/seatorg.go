@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/sources"
+)
+
+// seatOrgSource labels seat-org mode placements in the summary and plan.
+const seatOrgSource = "seat-org"
+
+// seatOrgDesired computes cost center -> users from the organization, or
+// team, that granted each Copilot seat.
+func seatOrgDesired(ctx context.Context, client *github.Client, logger *slog.Logger) (map[string][]string, error) {
+	users, err := client.GetCopilotUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching copilot users: %w", err)
+	}
+	desired, unowned := placeSeats(users, cfgManager.SeatCostCenter)
+	if unowned > 0 {
+		logger.Warn("Copilot users with no seat granted by an organization are left unassigned", "users", unowned)
+	}
+	return desired, nil
+}
+
+// placeSeats maps each seat holder to the cost center costCenter names for
+// the organization and team that granted the seat.  Seats without an
+// organization (assigned by the enterprise) are skipped, and a user with
+// several seats is placed by the first one an organization granted.
+// unowned counts the users left unplaced.
+func placeSeats(users []github.CopilotUser, costCenter func(org, team string) string) (desired map[string][]string, unowned int) {
+	desired = make(map[string][]string)
+	placed := make(map[string]bool)
+	for _, u := range users {
+		if _, seen := placed[u.Login]; !seen {
+			placed[u.Login] = false
+		}
+		if placed[u.Login] {
+			continue
+		}
+		org := u.AssigningOrg()
+		if org == "" {
+			continue
+		}
+		placed[u.Login] = true
+		cc := costCenter(org, u.AssigningTeamSlug())
+		desired[cc] = append(desired[cc], u.Login)
+	}
+	for _, ok := range placed {
+		if !ok {
+			unowned++
+		}
+	}
+	for _, logins := range desired {
+		sort.Strings(logins)
+	}
+	return desired, unowned
+}
+
+// runSeatOrgAssign implements assign for seat-org mode: each seat holder is
+// planned or applied like a plan file, in the cost center of the
+// organization that granted the seat.
+func runSeatOrgAssign(ctx context.Context, client *github.Client) error {
+	logger := slog.Default()
+	desired, err := seatOrgDesired(ctx, client, logger)
+	if err != nil {
+		return err
+	}
+	order := []string{seatOrgSource}
+	res := sources.Resolve(order, map[string]sources.Proposal{seatOrgSource: desired})
+	_, err = assignResolved(ctx, client, []string{"seat-org"}, order, res, logger)
+	return err
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+func TestPlaceSeats(t *testing.T) {
+	users := []github.CopilotUser{
+		{Login: "alice", Organization: &github.SeatOrganization{Login: "acme"}},
+		{Login: "bob", Organization: &github.SeatOrganization{Login: "acme"}, AssigningTeam: &github.SeatTeam{Slug: "data"}},
+		{Login: "carol"},
+		{Login: "alice", Organization: &github.SeatOrganization{Login: "labs"}},
+		{Login: "dave", Organization: &github.SeatOrganization{Login: "labs"}},
+	}
+	costCenter := func(org, team string) string {
+		if team != "" {
+			return org + "/" + team
+		}
+		return org
+	}
+	desired, unowned := placeSeats(users, costCenter)
+
+	want := map[string][]string{
+		"acme":      {"alice"},
+		"acme/data": {"bob"},
+		"labs":      {"dave"},
+	}
+	if !reflect.DeepEqual(desired, want) {
+		t.Errorf("desired = %v, want %v", desired, want)
+	}
+	if unowned != 1 {
+		t.Errorf("unowned = %d, want 1", unowned)
+	}
+}
+
+func TestPlaceSeats_OrgSeatAfterEnterpriseSeat(t *testing.T) {
+	users := []github.CopilotUser{
+		{Login: "erin"},
+		{Login: "erin", Organization: &github.SeatOrganization{Login: "acme"}},
+		{Login: "frank"},
+	}
+	desired, unowned := placeSeats(users, func(org, _ string) string { return org })
+
+	if want := map[string][]string{"acme": {"erin"}}; !reflect.DeepEqual(desired, want) {
+		t.Errorf("desired = %v, want %v", desired, want)
+	}
+	if unowned != 1 {
+		t.Errorf("unowned = %d, want 1 (only frank has no organization seat)", unowned)
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/state"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Capture and inspect cost center membership snapshots",
+	Long: `Capture the full cost center membership graph of the enterprise (every
+active cost center with its users and repositories) into a versioned JSON
+snapshot in <export_dir>/snapshots.
+
+A snapshot is also taken automatically before every assign --mode apply
+(skip with --no-snapshot).  Snapshots are referenced by ID, or "latest"
+for the most recent one, e.g. by diff --snapshot.
+
+Examples:
+  gh cost-center snapshot
+  gh cost-center snapshot list
+  gh cost-center snapshot show latest | jq '.cost_centers[].name'`,
+	Args: cobra.NoArgs,
+	RunE: runSnapshot,
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the saved snapshots of the enterprise",
+	Args:  cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		snaps, err := state.NewStore(cfgManager.ExportDir).List(cfgManager.Enterprise)
+		if err != nil {
+			return err
+		}
+		printSnapshotList(os.Stdout, snaps)
+		return nil
+	},
+}
+
+var snapshotShowCmd = &cobra.Command{
+	Use:   "show <id|latest>",
+	Short: "Write a saved snapshot to stdout as JSON",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		snap, err := state.NewStore(cfgManager.ExportDir).Load(cfgManager.Enterprise, args[0])
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snap)
+	},
+}
+
+func init() {
+	snapshotCmd.Flags().BoolVar(&skipPermissionCheck, "skip-permission-check", false, "do not check the token's scopes before the snapshot")
+	snapshotCmd.AddCommand(snapshotListCmd, snapshotShowCmd)
+	rootCmd.AddCommand(snapshotCmd)
+}
+
+func runSnapshot(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	logger := slog.Default()
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	if err := checkPermissions(ctx, client, "snapshot", []string{cfgManager.CostCenterMode}, false); err != nil {
+		return err
+	}
+	snap, err := takeSnapshot(ctx, client, state.ReasonManual, logger)
+	if err != nil {
+		return err
+	}
+	fmt.Println(snap.ID)
+	return nil
+}
+
+// takeSnapshot captures the membership graph and saves it to the export
+// dir.
+func takeSnapshot(ctx context.Context, client *github.Client, reason string, logger *slog.Logger) (*state.Snapshot, error) {
+	snap, err := state.Capture(ctx, client, cfgManager.Enterprise, reason)
+	if err != nil {
+		return nil, fmt.Errorf("capturing snapshot: %w", err)
+	}
+	path, err := state.NewStore(cfgManager.ExportDir).Save(snap)
+	if err != nil {
+		return nil, err
+	}
+	users, repos := snap.Counts()
+	logger.Info("Snapshot saved", "id", snap.ID, "reason", reason, "path", path,
+		"cost_centers", len(snap.CostCenters), "users", users, "repositories", repos)
+	return snap, nil
+}
+
+// printSnapshotList writes one line per snapshot, oldest first.
+func printSnapshotList(w io.Writer, snaps []*state.Snapshot) {
+	if len(snaps) == 0 {
+		_, _ = fmt.Fprintln(w, "No snapshots.")
+		return
+	}
+	_, _ = fmt.Fprintf(w, "%-22s %-20s %-10s %12s %6s %6s\n", "ID", "TAKEN AT", "REASON", "COST CENTERS", "USERS", "REPOS")
+	for _, s := range snaps {
+		users, repos := s.Counts()
+		_, _ = fmt.Fprintf(w, "%-22s %-20s %-10s %12d %6d %6d\n",
+			s.ID, s.TakenAt.Format(time.RFC3339), s.Reason, len(s.CostCenters), users, repos)
+	}
+}
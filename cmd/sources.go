@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
+	"github.com/renan-alm/gh-cost-center/internal/progress"
+	"github.com/renan-alm/gh-cost-center/internal/sources"
+)
+
+// sourceModes returns the modes the configured sources draw on, for
+// permission checks and plan files.  A run with only the overrides source
+// falls back to cost_center.mode.
+func sourceModes() []string {
+	var modes []string
+	for _, src := range cfgManager.Sources {
+		if mode := config.SourceMode(src); mode != "" {
+			modes = append(modes, mode)
+		}
+	}
+	if len(modes) == 0 {
+		modes = []string{cfgManager.CostCenterMode}
+	}
+	return modes
+}
+
+// resolveAssignmentSources asks every configured source for its placement
+// and merges them in priority order.
+func resolveAssignmentSources(ctx context.Context, client *github.Client, logger *slog.Logger) (sources.Result, error) {
+	proposals := make(map[string]sources.Proposal, len(cfgManager.Sources))
+	for _, src := range cfgManager.Sources {
+		var (
+			desired map[string][]string
+			err     error
+		)
+		switch src {
+		case sources.Overrides:
+			desired = overridesDesired(cfgManager.Overrides)
+		case sources.Teams:
+			desired, _, err = teamsDesired(ctx, client, logger)
+		case sources.IdPGroups:
+			desired, err = idpGroupsDesired(ctx, client, logger)
+		case sources.Users:
+			desired, err = pruDesired(ctx, client, logger)
+		}
+		if err != nil {
+			return sources.Result{}, fmt.Errorf("%s source: %w", src, err)
+		}
+		proposals[src] = desired
+	}
+	return sources.Resolve(cfgManager.Sources, proposals), nil
+}
+
+// overridesDesired turns the login -> cost center overrides into cost
+// center -> users.
+func overridesDesired(overrides map[string]string) map[string][]string {
+	desired := make(map[string][]string)
+	for login, cc := range overrides {
+		desired[cc] = append(desired[cc], login)
+	}
+	return desired
+}
+
+// sourceCreates reports whether src may create the cost centers it places
+// users in.
+func sourceCreates(src string) bool {
+	switch src {
+	case sources.Teams:
+		return assignCreateCC || cfgManager.TeamsAutoCreate
+	case sources.IdPGroups:
+		return assignCreateCC || cfgManager.IdPGroupsAutoCreate
+	case orgsSource:
+		return assignCreateCC || cfgManager.OrgsAutoCreate
+	case seatOrgSource:
+		return assignCreateCC || cfgManager.SeatOrgAutoCreate
+	case rulesSource:
+		return assignCreateCC || cfgManager.RulesAutoCreate
+	case emailDomainSource:
+		return assignCreateCC || cfgManager.EmailDomainAutoCreate
+	case sources.Users:
+		return assignCreateCC || cfgManager.AutoCreate
+	}
+	return assignCreateCC
+}
+
+// sourcesPlanSections returns one plan section per cost center of res,
+// sorted by name.  A cost center is created on apply if any source that
+// placed users in it may create it.
+func sourcesPlanSections(res sources.Result, creates func(src string) bool) []planSection {
+	names := make([]string, 0, len(res.Assignments))
+	for name := range res.Assignments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sections := make([]planSection, 0, len(names))
+	for _, name := range names {
+		s := planSection{
+			Mode:       strings.Join(res.Contributors[name], "+"),
+			CostCenter: name,
+			Unit:       "users",
+			Items:      res.Assignments[name],
+		}
+		if github.IsValidCostCenterUUID(name) {
+			s.CostCenterID = name
+		}
+		for _, src := range res.Contributors[name] {
+			s.Create = s.Create || creates(src)
+		}
+		sections = append(sections, s)
+	}
+	return sections
+}
+
+// printSourcesSummary writes how many users each source placed and how
+// many of its placements were dropped for an earlier source's.
+func printSourcesSummary(w io.Writer, order []string, res sources.Result) {
+	placed := res.Placed()
+	_, _ = fmt.Fprintf(w, "\nAssignment sources (%s):\n", strings.Join(order, " > "))
+	for _, src := range order {
+		line := fmt.Sprintf("  %-10s %d users placed", src, placed[src])
+		if n := res.Shadowed[src]; n > 0 {
+			line += fmt.Sprintf(", %d already placed by a higher-priority source", n)
+		}
+		_, _ = fmt.Fprintln(w, line)
+	}
+	_, _ = fmt.Fprintf(w, "  Total: %d users in %d cost centers\n", len(res.Winner), len(res.Assignments))
+}
+
+// runSourcesAssign implements assign with cost_center.sources: the sources
+// are merged into one placement per user, which is then planned or applied
+// like a plan file.  Sources with full sync remove the members no source
+// places.
+func runSourcesAssign(ctx context.Context, client *github.Client, prog *progress.Reporter) error {
+	logger := slog.Default()
+	prog.Emit(progress.PhaseMode, 0, 1, "sources")
+
+	res, err := resolveAssignmentSources(ctx, client, logger)
+	if err != nil {
+		return err
+	}
+	if _, err := applyResolved(ctx, client, sourceModes(), cfgManager.Sources, res, sourcesFullSync, logger); err != nil {
+		return err
+	}
+	prog.Emit(progress.PhaseMode, 1, 1, "sources")
+	return nil
+}
+
+// assignResolved plans or applies the merged placement res of the sources
+// in order, like a plan file made for modes.  It only adds users; removing
+// stale members is left to the caller.  proceeded is false when the user
+// declined the apply.
+func assignResolved(ctx context.Context, client *github.Client, modes, order []string, res sources.Result, logger *slog.Logger) (proceeded bool, err error) {
+	return applyResolved(ctx, client, modes, order, res, func(string) bool { return false }, logger)
+}
+
+// applyResolved is assignResolved, also removing the members of the cost
+// centers of the sections fullSync reports for.
+func applyResolved(ctx context.Context, client *github.Client, modes, order []string, res sources.Result, fullSync func(mode string) bool, logger *slog.Logger) (proceeded bool, err error) {
+	printSourcesSummary(os.Stdout, order, res)
+	if assignMode == "plan" {
+		logger.Info("mode=plan: no changes will be made")
+		return true, nil
+	}
+
+	sections := sourcesPlanSections(res, sourceCreates)
+	active, err := diffWithCurrent(ctx, client, sections, fullSync)
+	if err != nil {
+		return false, err
+	}
+	p := newPlanFile(cfgManager.Enterprise, modes, sections)
+	applied, err := applyPlan(ctx, client, p, active, i18n.T("confirm.apply.intro"), logger)
+	if err != nil || !applied {
+		return false, err
+	}
+	logger.Info("Assign command completed successfully", "sources", strings.Join(order, " > "))
+	return true, nil
+}
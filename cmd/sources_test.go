@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/github/githubtest"
+	"github.com/renan-alm/gh-cost-center/internal/sources"
+)
+
+func TestSourcesPlanSections(t *testing.T) {
+	uuid := "11111111-2222-3333-4444-555555555555"
+	res := sources.Resolve([]string{sources.Overrides, sources.Teams}, map[string]sources.Proposal{
+		sources.Overrides: overridesDesired(map[string]string{"alice": "CC Platform", "bob": uuid}),
+		sources.Teams:     {"CC Platform": {"carol"}, "CC Data": {"alice"}},
+	})
+	creates := func(src string) bool { return src == sources.Teams }
+
+	got := sourcesPlanSections(res, creates)
+	if len(got) != 2 {
+		t.Fatalf("sections = %+v, want 2", got)
+	}
+	if got[0].CostCenterID != uuid || got[0].Create || got[0].Mode != "overrides" {
+		t.Errorf("section 0 = %+v", got[0])
+	}
+	if got[1].CostCenter != "CC Platform" || got[1].Mode != "overrides+teams" || !got[1].Create ||
+		strings.Join(got[1].Items, ",") != "alice,carol" {
+		t.Errorf("section 1 = %+v", got[1])
+	}
+}
+
+func TestPrintSourcesSummary(t *testing.T) {
+	order := []string{sources.Overrides, sources.Users}
+	res := sources.Resolve(order, map[string]sources.Proposal{
+		sources.Overrides: {"CC Platform": {"alice"}},
+		sources.Users:     {"00 - No PRU overages": {"alice", "bob"}},
+	})
+	var buf bytes.Buffer
+	printSourcesSummary(&buf, order, res)
+	out := buf.String()
+	for _, want := range []string{"overrides > users", "1 already placed", "Total: 2 users in 2 cost centers"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestApplyResolved_FullSync(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.AddCostCenter("Eng", "alice", "zed")
+	srv.AddCostCenter("Data", "bob", "yan")
+	cfg := &config.Manager{Enterprise: srv.Enterprise, APIBaseURL: srv.URL, Token: "test-token", TeamsRemoveUnmatchedUsers: true}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client, err := github.NewClient(cfg, logger)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	origCfg, origMode, origYes := cfgManager, assignMode, assignYes
+	t.Cleanup(func() { cfgManager, assignMode, assignYes = origCfg, origMode, origYes })
+	cfgManager, assignMode, assignYes = cfg, "apply", true
+
+	order := []string{sources.Teams, sources.Users}
+	res := sources.Resolve(order, map[string]sources.Proposal{
+		sources.Teams: {"Eng": {"alice", "bob"}},
+		sources.Users: {"Data": {"carol"}},
+	})
+	if _, err := applyResolved(t.Context(), client, []string{"teams", "users"}, order, res, sourcesFullSync, logger); err != nil {
+		t.Fatalf("applyResolved: %v", err)
+	}
+
+	// zed is placed by no source and Eng is fed by teams, which has full
+	// sync; Data is fed only by users, so yan stays.
+	for name, want := range map[string]string{"Eng": "alice,bob", "Data": "carol,yan"} {
+		cc, ok := srv.CostCenterByName(name)
+		if !ok {
+			t.Fatalf("cost center %s missing", name)
+		}
+		users := append([]string(nil), cc.Users...)
+		sort.Strings(users)
+		if got := strings.Join(users, ","); got != want {
+			t.Errorf("%s users = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show distribution statistics across cost centers",
+	Long: `Show how users and repositories are distributed across all active cost
+centers in the enterprise, to spot structural problems in the billing
+layout.
+
+For users and repositories separately it reports the min, median, and max
+per cost center, the Gini concentration (0 = evenly spread, close to 1 =
+nearly everything in one cost center), and the share held by the largest
+cost center.  It also counts cost centers with no resources at all and
+those this tool created within --recent-days, as recorded in
+<export_dir>/.created_cost_centers by assign.
+
+Examples:
+  gh cost-center stats
+  gh cost-center stats --recent-days 7
+  gh cost-center stats --format json | jq '.users.gini'`,
+	RunE: runStats,
+}
+
+var (
+	statsFormat     string
+	statsRecentDays int
+)
+
+func init() {
+	statsCmd.Flags().StringVar(&statsFormat, "format", "text", "output format: text or json")
+	statsCmd.Flags().IntVar(&statsRecentDays, "recent-days", 30, "count cost centers created within this many days")
+	statsCmd.Flags().BoolVar(&skipPermissionCheck, "skip-permission-check", false, "do not check the token's scopes before collecting statistics")
+
+	rootCmd.AddCommand(statsCmd)
+}
+
+// ccCounts is the number of resources in one cost center.
+type ccCounts struct {
+	Name         string
+	ID           string
+	Users        int
+	Repositories int
+}
+
+// distribution summarises resource counts across cost centers.
+type distribution struct {
+	Total    int     `json:"total"`
+	Min      int     `json:"min"`
+	Median   float64 `json:"median"`
+	Max      int     `json:"max"`
+	Gini     float64 `json:"gini"`
+	TopShare float64 `json:"top_share"` // fraction held by the largest cost center
+}
+
+// statsDocument is the --format json output of the stats command.
+type statsDocument struct {
+	Enterprise      string       `json:"enterprise"`
+	GeneratedAt     time.Time    `json:"generated_at"`
+	CostCenters     int          `json:"cost_centers"`
+	Empty           []string     `json:"empty"`
+	RecentDays      int          `json:"recent_days"`
+	CreatedRecently []string     `json:"created_recently"`
+	Users           distribution `json:"users"`
+	Repositories    distribution `json:"repositories"`
+}
+
+// distributionOf computes the distribution of values.  Gini is 0 for an
+// empty or all-zero set.
+func distributionOf(values []int) distribution {
+	var d distribution
+	if len(values) == 0 {
+		return d
+	}
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	n := len(sorted)
+
+	d.Min, d.Max = sorted[0], sorted[n-1]
+	if n%2 == 1 {
+		d.Median = float64(sorted[n/2])
+	} else {
+		d.Median = float64(sorted[n/2-1]+sorted[n/2]) / 2
+	}
+
+	var weighted int
+	for i, v := range sorted {
+		d.Total += v
+		weighted += (i + 1) * v
+	}
+	if d.Total > 0 {
+		d.Gini = 2*float64(weighted)/(float64(n)*float64(d.Total)) - float64(n+1)/float64(n)
+		d.TopShare = float64(d.Max) / float64(d.Total)
+	}
+	return d
+}
+
+// computeStats builds the statistics document from per-cost-center counts
+// and recorded creation times (cost center ID -> time).
+func computeStats(counts []ccCounts, created map[string]time.Time, recentDays int, now time.Time) statsDocument {
+	doc := statsDocument{
+		CostCenters:     len(counts),
+		Empty:           []string{},
+		RecentDays:      recentDays,
+		CreatedRecently: []string{},
+	}
+	cutoff := now.AddDate(0, 0, -recentDays)
+	users := make([]int, 0, len(counts))
+	repos := make([]int, 0, len(counts))
+	for _, c := range counts {
+		users = append(users, c.Users)
+		repos = append(repos, c.Repositories)
+		if c.Users == 0 && c.Repositories == 0 {
+			doc.Empty = append(doc.Empty, c.Name)
+		}
+		if t, ok := created[c.ID]; ok && !t.Before(cutoff) {
+			doc.CreatedRecently = append(doc.CreatedRecently, c.Name)
+		}
+	}
+	sort.Strings(doc.Empty)
+	sort.Strings(doc.CreatedRecently)
+	doc.Users = distributionOf(users)
+	doc.Repositories = distributionOf(repos)
+	return doc
+}
+
+func runStats(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	if statsFormat != "text" && statsFormat != "json" {
+		return fmt.Errorf("invalid --format %q: must be 'text' or 'json'", statsFormat)
+	}
+	if statsRecentDays < 0 {
+		return fmt.Errorf("--recent-days must not be negative")
+	}
+
+	logger := slog.Default()
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	if err := checkPermissions(ctx, client, "stats", []string{cfgManager.CostCenterMode}, false); err != nil {
+		return err
+	}
+
+	active, err := client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching active cost centers: %w", err)
+	}
+	counts := make([]ccCounts, 0, len(active))
+	for name, id := range active {
+		detail, err := client.GetCostCenter(ctx, id)
+		if err != nil {
+			return fmt.Errorf("fetching cost center %q: %w", name, err)
+		}
+		c := ccCounts{Name: name, ID: id}
+		for _, r := range detail.Resources {
+			switch r.Type {
+			case "User":
+				c.Users++
+			case "Repository":
+				c.Repositories++
+			}
+		}
+		counts = append(counts, c)
+	}
+
+	created, err := cfgManager.CostCenterCreationTimes()
+	if err != nil {
+		logger.Warn("Could not read recorded cost center creations", "error", err)
+	}
+
+	now := time.Now().UTC()
+	doc := computeStats(counts, created, statsRecentDays, now)
+	doc.Enterprise = cfgManager.Enterprise
+	doc.GeneratedAt = now
+
+	if statsFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	}
+	printStats(os.Stdout, doc)
+	return nil
+}
+
+// printStats writes the human-readable statistics.
+func printStats(w io.Writer, doc statsDocument) {
+	_, _ = fmt.Fprintf(w, "\n=== Cost Center Statistics (%s) ===\n", doc.Enterprise)
+	_, _ = fmt.Fprintf(w, "Active cost centers:  %d\n", doc.CostCenters)
+	_, _ = fmt.Fprintf(w, "Empty:                %d\n", len(doc.Empty))
+	_, _ = fmt.Fprintf(w, "Created in last %d days: %d (recorded by assign)\n", doc.RecentDays, len(doc.CreatedRecently))
+
+	for _, row := range []struct {
+		label string
+		d     distribution
+	}{{"Users", doc.Users}, {"Repositories", doc.Repositories}} {
+		_, _ = fmt.Fprintf(w, "\n%s (%d total)\n", row.label, row.d.Total)
+		_, _ = fmt.Fprintf(w, "  min %d, median %g, max %d\n", row.d.Min, row.d.Median, row.d.Max)
+		_, _ = fmt.Fprintf(w, "  concentration (Gini) %.2f, largest cost center holds %.0f%%\n", row.d.Gini, row.d.TopShare*100)
+	}
+
+	if len(doc.Empty) > 0 {
+		_, _ = fmt.Fprintf(w, "\nEmpty cost centers:\n")
+		for _, name := range doc.Empty {
+			_, _ = fmt.Fprintf(w, "  - %s\n", name)
+		}
+	}
+	if len(doc.CreatedRecently) > 0 {
+		_, _ = fmt.Fprintf(w, "\nCreated in last %d days:\n", doc.RecentDays)
+		for _, name := range doc.CreatedRecently {
+			_, _ = fmt.Fprintf(w, "  + %s\n", name)
+		}
+	}
+}
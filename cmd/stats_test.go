@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDistributionOf(t *testing.T) {
+	tests := map[string]struct {
+		values []int
+		want   distribution
+	}{
+		"empty":  {nil, distribution{}},
+		"zeros":  {[]int{0, 0}, distribution{}},
+		"even":   {[]int{5, 5, 5, 5}, distribution{Total: 20, Min: 5, Median: 5, Max: 5, Gini: 0, TopShare: 0.25}},
+		"skewed": {[]int{0, 0, 0, 10}, distribution{Total: 10, Min: 0, Median: 0, Max: 10, Gini: 0.75, TopShare: 1}},
+		"odd":    {[]int{3, 1, 2}, distribution{Total: 6, Min: 1, Median: 2, Max: 3, Gini: 2.0 / 9, TopShare: 0.5}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := distributionOf(tt.values)
+			if math.Abs(got.Gini-tt.want.Gini) > 1e-9 {
+				t.Errorf("gini = %v, want %v", got.Gini, tt.want.Gini)
+			}
+			got.Gini = tt.want.Gini
+			if got != tt.want {
+				t.Errorf("distributionOf(%v) = %+v, want %+v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	counts := []ccCounts{
+		{Name: "B", ID: "b", Users: 4},
+		{Name: "A", ID: "a", Repositories: 2},
+		{Name: "Empty", ID: "e"},
+	}
+	created := map[string]time.Time{
+		"b": now.AddDate(0, 0, -3),
+		"e": now.AddDate(0, 0, -45),
+	}
+
+	doc := computeStats(counts, created, 30, now)
+	if doc.CostCenters != 3 {
+		t.Errorf("cost centers = %d", doc.CostCenters)
+	}
+	if !reflect.DeepEqual(doc.Empty, []string{"Empty"}) {
+		t.Errorf("empty = %v", doc.Empty)
+	}
+	if !reflect.DeepEqual(doc.CreatedRecently, []string{"B"}) {
+		t.Errorf("created recently = %v", doc.CreatedRecently)
+	}
+	if doc.Users.Total != 4 || doc.Repositories.Total != 2 || doc.Users.Median != 0 {
+		t.Errorf("users = %+v, repositories = %+v", doc.Users, doc.Repositories)
+	}
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// terraformCostCenter is the desired state of one cost center in the
+// Terraform export: its ID when known and every member the run assigns.
+type terraformCostCenter struct {
+	ID           *string  `json:"id"`
+	Users        []string `json:"users"`
+	Repositories []string `json:"repositories"`
+}
+
+// terraformState merges plan sections into the desired state per cost
+// center name, with members sorted and de-duplicated so the export diffs
+// cleanly in review.
+func terraformState(sections []planSection) map[string]*terraformCostCenter {
+	state := make(map[string]*terraformCostCenter)
+	for _, s := range sections {
+		cc := state[s.CostCenter]
+		if cc == nil {
+			cc = &terraformCostCenter{Users: []string{}, Repositories: []string{}}
+			state[s.CostCenter] = cc
+		}
+		if cc.ID == nil && s.CostCenterID != "" {
+			id := s.CostCenterID
+			cc.ID = &id
+		}
+		switch s.Unit {
+		case "users":
+			cc.Users = append(cc.Users, s.Items...)
+		case "repositories":
+			cc.Repositories = append(cc.Repositories, s.Items...)
+		}
+	}
+	for _, cc := range state {
+		cc.Users = sortedUnique(cc.Users)
+		cc.Repositories = sortedUnique(cc.Repositories)
+	}
+	return state
+}
+
+// sortedUnique sorts items and drops duplicates in place.
+func sortedUnique(items []string) []string {
+	sort.Strings(items)
+	out := items[:0]
+	for i, v := range items {
+		if i == 0 || v != items[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// writeTerraformFile saves the desired state of sections to path, as
+// Terraform JSON syntax when the path ends in .json and as HCL otherwise.
+func writeTerraformFile(path, enterprise string, sections []planSection) error {
+	var buf bytes.Buffer
+	var err error
+	if strings.HasSuffix(path, ".json") {
+		err = writeTerraformJSON(&buf, enterprise, sections)
+	} else {
+		err = writeTerraformHCL(&buf, enterprise, sections)
+	}
+	if err != nil {
+		return fmt.Errorf("encoding Terraform export: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("writing Terraform export %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeTerraformJSON writes the desired state as a Terraform JSON
+// configuration (.tf.json) holding two locals: cost_center_enterprise and
+// cost_centers, a map from cost center name to its id, users and
+// repositories.
+func writeTerraformJSON(w io.Writer, enterprise string, sections []planSection) error {
+	// Strings in Terraform JSON are templates too, so escape them all.
+	state := make(map[string]*terraformCostCenter)
+	for name, cc := range terraformState(sections) {
+		for i := range cc.Users {
+			cc.Users[i] = terraformEscape(cc.Users[i])
+		}
+		for i := range cc.Repositories {
+			cc.Repositories[i] = terraformEscape(cc.Repositories[i])
+		}
+		state[terraformEscape(name)] = cc
+	}
+	doc := map[string]any{
+		"//": "Generated by gh cost-center assign --terraform-out; do not edit.",
+		"locals": map[string]any{
+			"cost_center_enterprise": terraformEscape(enterprise),
+			"cost_centers":           state,
+		},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// writeTerraformHCL writes the same locals as writeTerraformJSON in HCL
+// native syntax (.tf).
+func writeTerraformHCL(w io.Writer, enterprise string, sections []planSection) error {
+	state := terraformState(sections)
+	names := make([]string, 0, len(state))
+	for name := range state {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("# Generated by gh cost-center assign --terraform-out; do not edit.\n\n")
+	b.WriteString("locals {\n")
+	fmt.Fprintf(&b, "  cost_center_enterprise = %s\n\n", hclString(enterprise))
+	if len(names) == 0 {
+		b.WriteString("  cost_centers = {}\n}\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	b.WriteString("  cost_centers = {\n")
+	for i, name := range names {
+		cc := state[name]
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "    %s = {\n", hclString(name))
+		if cc.ID != nil {
+			fmt.Fprintf(&b, "      id           = %s\n", hclString(*cc.ID))
+		} else {
+			b.WriteString("      id           = null\n")
+		}
+		fmt.Fprintf(&b, "      users        = %s\n", hclList(cc.Users, "      "))
+		fmt.Fprintf(&b, "      repositories = %s\n", hclList(cc.Repositories, "      "))
+		b.WriteString("    }\n")
+	}
+	b.WriteString("  }\n}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// terraformEscape escapes the template sequences of s so Terraform takes
+// it literally.
+func terraformEscape(s string) string {
+	s = strings.ReplaceAll(s, "${", "$${")
+	return strings.ReplaceAll(s, "%{", "%%{")
+}
+
+// hclString quotes s as an HCL string literal.
+func hclString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(terraformEscape(s))
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// hclList formats items as an HCL list, one item per line below indent.
+func hclList(items []string, indent string) string {
+	if len(items) == 0 {
+		return "[]"
+	}
+	var b strings.Builder
+	b.WriteString("[\n")
+	for _, item := range items {
+		fmt.Fprintf(&b, "%s  %s,\n", indent, hclString(item))
+	}
+	b.WriteString(indent + "]")
+	return b.String()
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var terraformSections = []planSection{
+	{Mode: "teams", CostCenter: "Eng ${x}", CostCenterID: "cc-1", Unit: "users", Items: []string{"bob", "alice"}},
+	{Mode: "users", CostCenter: "Eng ${x}", Unit: "users", Items: []string{"alice", "carol"}},
+	{Mode: "repos", CostCenter: "Eng ${x}", Unit: "repositories", Items: []string{"org/b", "org/a"}},
+	{Mode: "repos", CostCenter: "New", Create: true, Unit: "repositories", Items: []string{"org/c"}},
+}
+
+func TestWriteTerraformHCL(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeTerraformHCL(&buf, "ent", terraformSections); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`cost_center_enterprise = "ent"`,
+		`"Eng $${x}" = {`,
+		`id           = "cc-1"`,
+		"users        = [\n        \"alice\",\n        \"bob\",\n        \"carol\",\n      ]",
+		"repositories = [\n        \"org/a\",\n        \"org/b\",\n      ]",
+		"id           = null",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, `"Eng`) > strings.Index(out, `"New"`) {
+		t.Errorf("cost centers should be sorted by name:\n%s", out)
+	}
+
+	buf.Reset()
+	_ = writeTerraformHCL(&buf, "ent", nil)
+	if !strings.Contains(buf.String(), "cost_centers = {}") {
+		t.Errorf("empty export:\n%s", buf.String())
+	}
+}
+
+func TestWriteTerraformJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeTerraformJSON(&buf, "ent", terraformSections); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Locals struct {
+			Enterprise  string                         `json:"cost_center_enterprise"`
+			CostCenters map[string]terraformCostCenter `json:"cost_centers"`
+		} `json:"locals"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("decoding: %v\n%s", err, buf.String())
+	}
+	if doc.Locals.Enterprise != "ent" || len(doc.Locals.CostCenters) != 2 {
+		t.Fatalf("unexpected document:\n%s", buf.String())
+	}
+	eng := doc.Locals.CostCenters["Eng $${x}"]
+	if eng.ID == nil || *eng.ID != "cc-1" || strings.Join(eng.Users, ",") != "alice,bob,carol" {
+		t.Errorf("Eng: %+v", eng)
+	}
+	if n := doc.Locals.CostCenters["New"]; n.ID != nil || len(n.Users) != 0 || n.Repositories[0] != "org/c" {
+		t.Errorf("New: %+v", n)
+	}
+	if !strings.Contains(buf.String(), `"users": []`) {
+		t.Errorf("empty member lists should be [] rather than null:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the configuration without calling the API",
+	Long: `Check the configuration file offline and list every problem found.
+
+Unlike other commands, which stop at the first configuration error, validate
+reports them all: unknown keys, invalid settings, the settings of every mode
+the file configures (not only cost_center.mode), team mappings that can never
+match a team, and budgets.  A file with profiles is checked as each profile
+completes the top-level settings, unless --profile selects one.  Files
+referenced by the configuration, such as overrides_file and
+member_attributes_file, are read.  No API call is made and no token is
+needed, so it can run as a pre-merge check.
+
+Exits with status 1 when there is any problem.
+
+Examples:
+  gh cost-center validate
+  gh cost-center validate --config path/to/config.yaml
+  gh cost-center validate --profile prod`,
+	// The configuration is what is being checked: do not load it first.
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		level := slog.LevelWarn
+		if verbose {
+			level = slog.LevelDebug
+		}
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data := inputsConfig
+		if data == nil {
+			var err error
+			if data, err = os.ReadFile(cfgFile); err != nil {
+				return fmt.Errorf("reading config file: %w", err)
+			}
+		}
+		problems := config.ValidateProfile(cfgFile, data, profileFlag, slog.Default())
+		if err := writeValidation(os.Stdout, cfgFile, problems); err != nil {
+			return err
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("%s: %d configuration problem(s)", cfgFile, len(problems))
+		}
+		return nil
+	},
+}
+
+// writeValidation prints the problems found in the configuration at path,
+// or that it is valid.
+func writeValidation(w io.Writer, path string, problems []error) error {
+	if len(problems) == 0 {
+		_, err := fmt.Fprintf(w, "%s: configuration is valid.\n", path)
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%s: %d problem(s) found:\n", path, len(problems)); err != nil {
+		return err
+	}
+	for _, p := range problems {
+		if _, err := fmt.Fprintf(w, "  - %v\n", p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
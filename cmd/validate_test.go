@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestWriteValidation(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeValidation(&buf, "c.yaml", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "c.yaml: configuration is valid.\n" {
+		t.Errorf("valid output = %q", got)
+	}
+
+	buf.Reset()
+	_ = writeValidation(&buf, "c.yaml", []error{errors.New("first"), errors.New("second")})
+	want := "c.yaml: 2 problem(s) found:\n  - first\n  - second\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
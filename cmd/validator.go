@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+// validatePlan runs the configured pre-apply validator against the plan of
+// an apply run.  Without a plan file the plan is built first, as plan mode
+// would.  It returns an error, stopping the apply, unless the validator
+// exits 0.
+func validatePlan(ctx context.Context, client *github.Client, p *planFile, modes []string, logger *slog.Logger) error {
+	if p == nil {
+		logger.Info("Computing plan for the pre-apply validator", "modes", strings.Join(modes, ","))
+		var err error
+		if p, err = buildPlan(ctx, client, modes, logger); err != nil {
+			return fmt.Errorf("computing plan for validation: %w", err)
+		}
+	}
+	return runValidator(ctx, cfgManager.ValidatorCommand, cfgManager.ValidatorTimeout, p, logger)
+}
+
+// runValidator starts command with the plan JSON on stdin and
+// GH_COST_CENTER_ENTERPRISE set, passing its output through to stderr.  A
+// non-zero exit, a failure to start, or exceeding timeout rejects the plan.
+func runValidator(ctx context.Context, command []string, timeout time.Duration, p *planFile, logger *slog.Logger) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("encoding plan for validator: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "GH_COST_CENTER_ENTERPRISE="+p.Enterprise)
+
+	logger.Info("Running pre-apply validator", "command", strings.Join(command, " "), "changes", len(p.Changes))
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("pre-apply validator timed out after %s; apply aborted", timeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("pre-apply validator rejected the plan (exit status %d); apply aborted", exitErr.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("running pre-apply validator: %w; apply aborted", err)
+	}
+	logger.Info("Pre-apply validator accepted the plan")
+	return nil
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunValidator(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	p := newPlanFile("ent", []string{"users"}, []planSection{
+		{Mode: "users", CostCenter: "Eng", Unit: "users", Items: []string{"alice"}},
+	})
+	stdin := filepath.Join(t.TempDir(), "stdin.json")
+
+	accept := []string{"sh", "-c", `cat > "$1"; [ "$GH_COST_CENTER_ENTERPRISE" = ent ]`, "validator", stdin}
+	if err := runValidator(t.Context(), accept, time.Minute, p, logger); err != nil {
+		t.Fatalf("accepting validator: %v", err)
+	}
+	data, err := os.ReadFile(stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"items":["alice"]`) || !strings.Contains(string(data), `"enterprise":"ent"`) {
+		t.Errorf("validator stdin = %s", data)
+	}
+
+	err = runValidator(t.Context(), []string{"sh", "-c", "exit 3"}, time.Minute, p, logger)
+	if err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("rejecting validator error = %v", err)
+	}
+	err = runValidator(t.Context(), []string{"sh", "-c", "exec sleep 5"}, 50*time.Millisecond, p, logger)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("slow validator error = %v", err)
+	}
+	if err := runValidator(t.Context(), []string{filepath.Join(t.TempDir(), "missing")}, time.Minute, p, logger); err == nil {
+		t.Error("expected an error for a missing program")
+	}
+}
@@ -0,0 +1,258 @@
+// Package audit streams billing-assignment changes to an external collector
+// while a run is in progress, so security monitoring sees them in near real
+// time.  Two formats are supported: Splunk HTTP Event Collector and a
+// generic HTTP endpoint that accepts a JSON array of events.
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Sink formats.
+const (
+	FormatSplunkHEC = "splunk_hec"
+	FormatHTTP      = "http"
+)
+
+// Actions recorded in events.
+const (
+	ActionCostCenterCreated = "cost_center.created"
+	ActionCostCenterDeleted = "cost_center.deleted"
+	ActionCostCenterRenamed = "cost_center.renamed"
+	ActionUsersAdded        = "cost_center.users_added"
+	ActionUsersRemoved      = "cost_center.users_removed"
+	ActionReposAdded        = "cost_center.repositories_added"
+	ActionReposRemoved      = "cost_center.repositories_removed"
+	ActionBudgetCreated     = "budget.created"
+	ActionBudgetUpdated     = "budget.updated"
+	ActionAlertsTransferred = "cost_center.alerts_transferred"
+)
+
+const (
+	// DefaultBatchSize is the number of events sent per request.
+	DefaultBatchSize = 50
+	// DefaultFlushInterval bounds how long an event waits before it is sent.
+	DefaultFlushInterval = 5 * time.Second
+	// queueSize is how many unsent events are buffered before new ones are
+	// dropped rather than slowing the run down.
+	queueSize = 1000
+)
+
+// Event is one billing-assignment change, successful or not.
+type Event struct {
+	Time         time.Time `json:"time"`
+	Enterprise   string    `json:"enterprise"`
+	Action       string    `json:"action"`
+	CostCenterID string    `json:"cost_center_id,omitempty"`
+	CostCenter   string    `json:"cost_center,omitempty"`
+	Resources    []string  `json:"resources,omitempty"`
+	Product      string    `json:"product,omitempty"`
+	Amount       int       `json:"amount,omitempty"`
+	// PreviousName is the name of a renamed cost center before the rename.
+	PreviousName string `json:"previous_name,omitempty"`
+	// Recipients and PreviousRecipients describe a budget alert handoff.
+	Recipients         []string `json:"alert_recipients,omitempty"`
+	PreviousRecipients []string `json:"previous_alert_recipients,omitempty"`
+	Success            bool     `json:"success"`
+	Error              string   `json:"error,omitempty"`
+}
+
+// Options configures an HTTP sink.
+type Options struct {
+	Format        string // FormatSplunkHEC or FormatHTTP
+	URL           string
+	Token         string
+	Index         string // Splunk index, optional
+	SourceType    string // Splunk sourcetype, optional
+	BatchSize     int
+	FlushInterval time.Duration
+}
+
+// Sink sends events in batches from a background goroutine.  Emit never
+// blocks on the network; Close flushes what is left.
+type Sink struct {
+	opts   Options
+	http   *http.Client
+	log    *slog.Logger
+	events chan Event
+	done   chan struct{}
+
+	mu      sync.Mutex
+	sent    int
+	dropped int
+}
+
+// NewSink starts a sink for opts.
+func NewSink(opts Options, logger *slog.Logger) (*Sink, error) {
+	if opts.Format != FormatSplunkHEC && opts.Format != FormatHTTP {
+		return nil, fmt.Errorf("unknown audit sink format %q: must be %q or %q", opts.Format, FormatSplunkHEC, FormatHTTP)
+	}
+	if opts.URL == "" {
+		return nil, fmt.Errorf("audit sink URL is required")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultFlushInterval
+	}
+	s := &Sink{
+		opts:   opts,
+		http:   &http.Client{Timeout: 10 * time.Second},
+		log:    logger,
+		events: make(chan Event, queueSize),
+		done:   make(chan struct{}),
+	}
+	go s.loop()
+	return s, nil
+}
+
+// Emit queues an event.  When the queue is full the event is dropped and
+// counted, so a slow collector never stalls the run.  A nil sink is a no-op.
+func (s *Sink) Emit(e Event) {
+	if s == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	select {
+	case s.events <- e:
+	default:
+		s.mu.Lock()
+		s.dropped++
+		s.mu.Unlock()
+	}
+}
+
+// Close sends the remaining events and stops the sink.  It returns an error
+// if any event could not be delivered.
+func (s *Sink) Close() error {
+	if s == nil {
+		return nil
+	}
+	close(s.events)
+	<-s.done
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.log.Debug("Audit sink closed", "sent", s.sent, "dropped", s.dropped)
+	if s.dropped > 0 {
+		return fmt.Errorf("%d audit event(s) could not be delivered to %s", s.dropped, s.opts.URL)
+	}
+	return nil
+}
+
+// loop batches queued events and sends them when the batch is full or the
+// flush interval elapses.
+func (s *Sink) loop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+
+	var batch []Event
+	for {
+		select {
+		case e, ok := <-s.events:
+			if !ok {
+				s.flush(batch)
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) >= s.opts.BatchSize {
+				s.flush(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			s.flush(batch)
+			batch = nil
+		}
+	}
+}
+
+// flush sends one batch, counting it as dropped on failure.
+func (s *Sink) flush(batch []Event) {
+	if len(batch) == 0 {
+		return
+	}
+	err := s.send(batch)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.dropped += len(batch)
+		s.log.Warn("Could not deliver audit events", "url", s.opts.URL, "events", len(batch), "error", err)
+		return
+	}
+	s.sent += len(batch)
+}
+
+// hecEvent is the Splunk HEC envelope for one event.
+type hecEvent struct {
+	Time       float64 `json:"time"`
+	Source     string  `json:"source"`
+	SourceType string  `json:"sourcetype,omitempty"`
+	Index      string  `json:"index,omitempty"`
+	Event      Event   `json:"event"`
+}
+
+// encode renders a batch in the sink's format: concatenated HEC envelopes
+// for Splunk, a JSON array otherwise.
+func (s *Sink) encode(batch []Event) ([]byte, error) {
+	if s.opts.Format == FormatHTTP {
+		return json.Marshal(batch)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range batch {
+		env := hecEvent{
+			Time:       float64(e.Time.UnixMilli()) / 1000,
+			Source:     "gh-cost-center",
+			SourceType: s.opts.SourceType,
+			Index:      s.opts.Index,
+			Event:      e,
+		}
+		if err := enc.Encode(env); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// send posts one batch to the collector.
+func (s *Sink) send(batch []Event) error {
+	body, err := s.encode(batch)
+	if err != nil {
+		return fmt.Errorf("encoding audit events: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, s.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("building audit request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.opts.Token != "" {
+		if s.opts.Format == FormatSplunkHEC {
+			req.Header.Set("Authorization", "Splunk "+s.opts.Token)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+s.opts.Token)
+		}
+	}
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("collector returned %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
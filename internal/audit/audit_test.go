@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+}
+
+// collector records request bodies and Authorization headers.
+type collector struct {
+	mu     sync.Mutex
+	bodies [][]byte
+	auth   []string
+	status int
+}
+
+func (c *collector) server(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		c.mu.Lock()
+		c.bodies = append(c.bodies, body)
+		c.auth = append(c.auth, r.Header.Get("Authorization"))
+		status := c.status
+		c.mu.Unlock()
+		if status != 0 {
+			w.WriteHeader(status)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSink_SplunkHEC(t *testing.T) {
+	var col collector
+	srv := col.server(t)
+	s, err := NewSink(Options{Format: FormatSplunkHEC, URL: srv.URL, Token: "tok", Index: "billing", BatchSize: 2}, testLogger())
+	if err != nil {
+		t.Fatalf("NewSink: %v", err)
+	}
+	s.Emit(Event{Enterprise: "ent", Action: ActionUsersAdded, CostCenterID: "cc-1", Resources: []string{"alice"}, Success: true})
+	s.Emit(Event{Enterprise: "ent", Action: ActionUsersRemoved, CostCenterID: "cc-1", Resources: []string{"bob"}, Success: true})
+	s.Emit(Event{Enterprise: "ent", Action: ActionCostCenterCreated, CostCenter: "New", Success: true})
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if len(col.bodies) != 2 {
+		t.Fatalf("requests = %d, want 2 (batch of 2, then the rest on close)", len(col.bodies))
+	}
+	if col.auth[0] != "Splunk tok" {
+		t.Errorf("Authorization = %q", col.auth[0])
+	}
+	var envs []hecEvent
+	sc := bufio.NewScanner(bytes.NewReader(col.bodies[0]))
+	for sc.Scan() {
+		var e hecEvent
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("HEC line is not JSON: %v", err)
+		}
+		envs = append(envs, e)
+	}
+	if len(envs) != 2 || envs[0].Index != "billing" || envs[0].Event.Action != ActionUsersAdded || envs[0].Time == 0 {
+		t.Errorf("first batch = %+v", envs)
+	}
+}
+
+func TestSink_HTTPArrayOnInterval(t *testing.T) {
+	var col collector
+	srv := col.server(t)
+	s, err := NewSink(Options{Format: FormatHTTP, URL: srv.URL, Token: "tok", FlushInterval: 20 * time.Millisecond}, testLogger())
+	if err != nil {
+		t.Fatalf("NewSink: %v", err)
+	}
+	s.Emit(Event{Action: ActionReposAdded, Resources: []string{"org/repo"}})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		col.mu.Lock()
+		n := len(col.bodies)
+		col.mu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if len(col.bodies) != 1 {
+		t.Fatalf("requests = %d, want 1 flushed by the interval", len(col.bodies))
+	}
+	var events []Event
+	if err := json.Unmarshal(col.bodies[0], &events); err != nil || len(events) != 1 {
+		t.Fatalf("body = %s (%v)", col.bodies[0], err)
+	}
+	if col.auth[0] != "Bearer tok" {
+		t.Errorf("Authorization = %q", col.auth[0])
+	}
+}
+
+func TestSink_DeliveryFailureReportedOnClose(t *testing.T) {
+	col := collector{status: http.StatusServiceUnavailable}
+	srv := col.server(t)
+	s, err := NewSink(Options{Format: FormatHTTP, URL: srv.URL}, testLogger())
+	if err != nil {
+		t.Fatalf("NewSink: %v", err)
+	}
+	s.Emit(Event{Action: ActionBudgetCreated})
+	if err := s.Close(); err == nil {
+		t.Error("Close = nil, want an error for undelivered events")
+	}
+}
+
+func TestNewSink_Validation(t *testing.T) {
+	if _, err := NewSink(Options{Format: "kafka", URL: "http://x"}, testLogger()); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	if _, err := NewSink(Options{Format: FormatHTTP}, testLogger()); err == nil {
+		t.Error("expected error for missing URL")
+	}
+}
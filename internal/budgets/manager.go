@@ -1,9 +1,12 @@
 // Package budgets provides helper functions for creating product budgets for
-// newly-created cost centers.  It wraps the lower-level github.Client budget
-// operations and handles the case where the Budgets API is unavailable.
+// newly-created cost centers and reconciling those of existing ones.  It
+// wraps the lower-level github.Client budget operations and handles the
+// case where the Budgets API is unavailable.
 package budgets
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -37,41 +40,81 @@ func (m *Manager) IsAvailable() bool {
 // EnsureBudgetsForCostCenter creates all enabled product budgets for a cost center.
 // If the budgets API is unavailable, it sets a flag and returns nil (graceful degradation).
 // Individual product creation failures are accumulated and returned as a single error.
-func (m *Manager) EnsureBudgetsForCostCenter(ccID, ccName string) error {
+func (m *Manager) EnsureBudgetsForCostCenter(ctx context.Context, ccID, ccName string) error {
 	if m.unavailable {
 		return nil
 	}
 
 	m.log.Info("Creating budgets for cost center", "name", ccName)
 
-	var failures []string
-	for product, pc := range m.products {
-		if !pc.Enabled {
-			m.log.Debug("Skipping disabled product budget", "product", product)
-			continue
+	written, err := m.client.EnsureProductBudgets(ctx, ccID, ccName, m.products)
+	for _, product := range written {
+		m.log.Info("Budget created",
+			"product", product, "cost_center", ccName, "amount", m.products[product].Amount)
+	}
+	if err != nil {
+		var unavailable *github.BudgetsAPIUnavailableError
+		if errors.As(err, &unavailable) {
+			m.log.Warn("Budgets API unavailable, disabling budget creation",
+				"error", err)
+			m.unavailable = true
+			return nil
 		}
+		m.log.Error("Failed to create budgets", "cost_center", ccName, "error", err)
+		return fmt.Errorf("budget creation failed for cost center %s: %w", ccName, err)
+	}
+	return nil
+}
+
+// Reconciler updates the product budgets of existing cost centers whose
+// settings drifted from the configuration.  The enterprise's budgets are
+// listed on first use and reused for every cost center of the run.
+type Reconciler struct {
+	client      *github.Client
+	log         *slog.Logger
+	budgets     []github.Budget
+	listed      bool
+	unavailable bool
+}
 
-		ok, err := m.client.CreateProductBudget(ccID, ccName, product, pc.Amount)
+// NewReconciler creates a budget reconciler for one run.
+func NewReconciler(client *github.Client, logger *slog.Logger) *Reconciler {
+	return &Reconciler{client: client, log: logger}
+}
+
+// Reconcile updates the budgets of cost center ccID whose amount, alert
+// threshold or prevent_further_usage differs from products.  Missing
+// budgets are left alone.  If the budgets API is unavailable it logs once
+// and returns nil for the rest of the run.
+func (r *Reconciler) Reconcile(ctx context.Context, ccID, ccName string, products map[string]config.ProductBudget) error {
+	if r.unavailable {
+		return nil
+	}
+	if !r.listed {
+		budgets, err := r.client.ListBudgets(ctx)
 		if err != nil {
-			if _, uaErr := err.(*github.BudgetsAPIUnavailableError); uaErr {
-				m.log.Warn("Budgets API unavailable, disabling budget creation",
-					"error", err)
-				m.unavailable = true
-				return nil
-			}
-			m.log.Error("Failed to create budget",
-				"product", product, "cost_center", ccName, "error", err)
-			failures = append(failures, fmt.Sprintf("%s: %v", product, err))
-			continue
-		}
-		if ok {
-			m.log.Info("Budget created",
-				"product", product, "cost_center", ccName, "amount", pc.Amount)
+			return r.handle(err)
 		}
+		r.budgets, r.listed = budgets, true
 	}
 
-	if len(failures) > 0 {
-		return fmt.Errorf("budget creation failed for cost center %s: %s", ccName, strings.Join(failures, "; "))
+	updated, err := r.client.ReconcileListedBudgets(ctx, r.budgets, ccID, ccName, products)
+	if len(updated) > 0 {
+		r.log.Info("Budgets updated", "cost_center", ccName, "products", strings.Join(updated, ", "))
+	}
+	if err != nil {
+		return r.handle(err)
 	}
 	return nil
 }
+
+// handle turns an unavailable budgets API into graceful degradation.
+func (r *Reconciler) handle(err error) error {
+	var unavailable *github.BudgetsAPIUnavailableError
+	if errors.As(err, &unavailable) {
+		r.log.Warn("Budgets API unavailable, skipping budget reconciliation", "error", err)
+		r.unavailable = true
+		return nil
+	}
+	return err
+}
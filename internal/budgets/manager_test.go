@@ -73,7 +73,7 @@ func TestEnsureBudgets_SkipsWhenUnavailable(t *testing.T) {
 	mgr.unavailable = true
 
 	// Should return immediately without panic (no client set).
-	if err := mgr.EnsureBudgetsForCostCenter("cc-id-1", "Test CC"); err != nil {
+	if err := mgr.EnsureBudgetsForCostCenter(t.Context(), "cc-id-1", "Test CC"); err != nil {
 		t.Errorf("expected nil error when unavailable, got %v", err)
 	}
 }
@@ -97,7 +97,7 @@ func TestEnsureBudgets_AllSucceed(t *testing.T) {
 	}
 	mgr := NewManager(client, testLogger(), products)
 
-	err := mgr.EnsureBudgetsForCostCenter("cc-1", "Test CC")
+	err := mgr.EnsureBudgetsForCostCenter(t.Context(), "cc-1", "Test CC")
 	if err != nil {
 		t.Errorf("expected nil error, got %v", err)
 	}
@@ -126,7 +126,7 @@ func TestEnsureBudgets_SkipsDisabledProducts(t *testing.T) {
 	}
 	mgr := NewManager(client, testLogger(), products)
 
-	err := mgr.EnsureBudgetsForCostCenter("cc-1", "Test CC")
+	err := mgr.EnsureBudgetsForCostCenter(t.Context(), "cc-1", "Test CC")
 	if err != nil {
 		t.Errorf("expected nil error, got %v", err)
 	}
@@ -158,7 +158,7 @@ func TestEnsureBudgets_PartialFailureAccumulates(t *testing.T) {
 	}
 	mgr := NewManager(client, testLogger(), products)
 
-	err := mgr.EnsureBudgetsForCostCenter("cc-1", "Test CC")
+	err := mgr.EnsureBudgetsForCostCenter(t.Context(), "cc-1", "Test CC")
 	if err == nil {
 		t.Fatal("expected error for partial failures")
 	}
@@ -185,7 +185,7 @@ func TestEnsureBudgets_APIUnavailable_GracefulDegradation(t *testing.T) {
 	}
 	mgr := NewManager(client, testLogger(), products)
 
-	err := mgr.EnsureBudgetsForCostCenter("cc-1", "Test CC")
+	err := mgr.EnsureBudgetsForCostCenter(t.Context(), "cc-1", "Test CC")
 	if err != nil {
 		t.Errorf("expected nil error for API unavailable (graceful degradation), got %v", err)
 	}
@@ -214,7 +214,7 @@ func TestEnsureBudgets_MixedSuccessAndFailure(t *testing.T) {
 	}
 	mgr := NewManager(client, testLogger(), products)
 
-	err := mgr.EnsureBudgetsForCostCenter("cc-1", "Fail CC")
+	err := mgr.EnsureBudgetsForCostCenter(t.Context(), "cc-1", "Fail CC")
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -231,7 +231,7 @@ func TestEnsureBudgets_NoEnabledProducts(t *testing.T) {
 	mgr := NewManager(nil, testLogger(), products)
 
 	// No client needed since nothing should be called.
-	err := mgr.EnsureBudgetsForCostCenter("cc-1", "Test CC")
+	err := mgr.EnsureBudgetsForCostCenter(t.Context(), "cc-1", "Test CC")
 	if err != nil {
 		t.Errorf("expected nil error when all products disabled, got %v", err)
 	}
@@ -239,3 +239,57 @@ func TestEnsureBudgets_NoEnabledProducts(t *testing.T) {
 
 // Ensure the test client builder uses a short timeout so tests don't hang.
 var _ = time.Second
+
+func TestReconciler_ListsBudgetsOnce(t *testing.T) {
+	var lists, updates int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			lists++
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"budgets": []map[string]any{
+				{"id": "b1", "budget_scope": "cost_center", "budget_entity_name": "cc-1", "budget_product_sku": "actions", "budget_amount": 50},
+				{"id": "b2", "budget_scope": "cost_center", "budget_entity_name": "cc-2", "budget_product_sku": "actions", "budget_amount": 100},
+			}})
+		case http.MethodPatch:
+			updates++
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+
+	r := NewReconciler(newTestClient(t, srv.URL), testLogger())
+	products := map[string]config.ProductBudget{"actions": {Amount: 100, Enabled: true}}
+	for _, cc := range []string{"cc-1", "cc-2"} {
+		if err := r.Reconcile(t.Context(), cc, cc, products); err != nil {
+			t.Fatalf("Reconcile %s: %v", cc, err)
+		}
+	}
+	if lists != 1 {
+		t.Errorf("budgets listed %d times, want 1", lists)
+	}
+	if updates != 1 {
+		t.Errorf("budgets updated %d times, want 1 (only cc-1 drifted)", updates)
+	}
+}
+
+func TestReconciler_APIUnavailable(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	r := NewReconciler(newTestClient(t, srv.URL), testLogger())
+	products := map[string]config.ProductBudget{"actions": {Amount: 100, Enabled: true}}
+	for _, cc := range []string{"cc-1", "cc-2"} {
+		if err := r.Reconcile(t.Context(), cc, cc, products); err != nil {
+			t.Errorf("Reconcile %s: expected nil for API unavailable, got %v", cc, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("API called %d times, want 1", calls)
+	}
+}
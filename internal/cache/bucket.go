@@ -0,0 +1,237 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Names of the buckets kept next to the cost center cache.
+const (
+	// BucketTeamMembers holds team member lists, keyed by team.
+	BucketTeamMembers = "team_members"
+	// BucketSeats holds Copilot seat snapshots, keyed by enterprise.
+	BucketSeats = "seats"
+)
+
+// bucketEntry is one cached value with the time it was stored.
+type bucketEntry struct {
+	CachedAt time.Time       `json:"cached_at"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// bucketData is the on-disk JSON structure of a bucket.
+type bucketData struct {
+	Version int                    `json:"version"`
+	Entries map[string]bucketEntry `json:"entries"`
+}
+
+// Bucket is a namespaced file-backed cache of JSON values with its own TTL,
+// stored as <name>.json in the cache directory.  The TTL is applied when an
+// entry is read, so a changed TTL also covers entries stored earlier.
+// Entries set during a run are written together by Flush.
+type Bucket struct {
+	mu       sync.Mutex
+	name     string
+	filePath string
+	ttl      time.Duration
+	data     bucketData
+	dirty    bool // entries set since the last Flush
+	log      *slog.Logger
+	persist  fallback
+}
+
+// NewBucket creates or loads the bucket name in dir (DefaultCacheDir when
+// empty).  Entries older than ttl are treated as missing.
+func NewBucket(dir, name string, ttl time.Duration, logger *slog.Logger) *Bucket {
+	if dir == "" {
+		dir = DefaultCacheDir
+	}
+	b := &Bucket{
+		name:     name,
+		filePath: filepath.Join(dir, name+".json"),
+		ttl:      ttl,
+		log:      logger,
+		data: bucketData{
+			Version: currentVersion,
+			Entries: make(map[string]bucketEntry),
+		},
+	}
+	if err := b.load(); err != nil {
+		b.log.Debug("No existing cache bucket, starting fresh", "bucket", name, "error", err)
+	}
+	return b
+}
+
+// Name returns the bucket name.
+func (b *Bucket) Name() string {
+	return b.name
+}
+
+// FilePath returns the path to the bucket file.
+func (b *Bucket) FilePath() string {
+	return b.filePath
+}
+
+// TTL returns how long entries stay valid.
+func (b *Bucket) TTL() time.Duration {
+	return b.ttl
+}
+
+// Get decodes the entry for key into v and reports whether a valid
+// (non-expired) entry was found.
+func (b *Bucket) Get(key string, v any) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e, ok := b.data.Entries[key]
+	if !ok {
+		return false
+	}
+	if time.Since(e.CachedAt) > b.ttl {
+		b.log.Debug("Cache entry expired", "bucket", b.name, "key", key)
+		return false
+	}
+	if err := json.Unmarshal(e.Value, v); err != nil {
+		b.log.Debug("Cache entry unreadable", "bucket", b.name, "key", key, "error", err)
+		return false
+	}
+	b.log.Debug("Cache hit", "bucket", b.name, "key", key, "age", time.Since(e.CachedAt).Round(time.Second))
+	return true
+}
+
+// Set stores v under key in memory; Flush writes it to disk.
+func (b *Bucket) Set(key string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding %s cache entry: %w", b.name, err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.data.Entries[key] = bucketEntry{CachedAt: time.Now().UTC(), Value: raw}
+	b.dirty = true
+	b.log.Debug("Cache set", "bucket", b.name, "key", key)
+	return nil
+}
+
+// Flush writes the entries set since the last Flush to disk in one write.
+// When the cache directory cannot be written they are kept in memory for
+// the run.
+func (b *Bucket) Flush() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.dirty {
+		return
+	}
+	b.persist.write(filepath.Dir(b.filePath), b.log, b.save)
+	b.dirty = false
+}
+
+// GetStats returns statistics about the bucket.
+func (b *Bucket) GetStats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := Stats{
+		TotalEntries: len(b.data.Entries),
+		FilePath:     b.filePath,
+	}
+	for _, e := range b.data.Entries {
+		if time.Since(e.CachedAt) > b.ttl {
+			s.ExpiredEntries++
+		} else {
+			s.ValidEntries++
+		}
+	}
+	if info, err := os.Stat(b.filePath); err == nil {
+		s.FileSizeBytes = info.Size()
+	}
+	s.MemoryOnly = b.persist.memoryOnly()
+	return s
+}
+
+// Clear removes all entries and deletes the bucket file.
+func (b *Bucket) Clear() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.data.Entries = make(map[string]bucketEntry)
+	b.dirty = false
+	if err := os.Remove(b.filePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing %s cache file: %w", b.name, err)
+	}
+	return nil
+}
+
+// CleanupExpired removes expired entries and saves to disk.  Returns the
+// number of entries removed.
+func (b *Bucket) CleanupExpired() (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	removed := 0
+	for key, e := range b.data.Entries {
+		if time.Since(e.CachedAt) > b.ttl {
+			delete(b.data.Entries, key)
+			removed++
+		}
+	}
+	if removed > 0 {
+		b.persist.write(filepath.Dir(b.filePath), b.log, b.save)
+		b.dirty = false
+	}
+	return removed, nil
+}
+
+// load reads the bucket file from disk.
+func (b *Bucket) load() error {
+	f, err := os.Open(b.filePath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	var d bucketData
+	if err := json.NewDecoder(f).Decode(&d); err != nil {
+		return fmt.Errorf("decoding cache bucket: %w", err)
+	}
+	if d.Version != currentVersion {
+		b.log.Warn("Cache version mismatch, starting fresh",
+			"bucket", b.name, "expected", currentVersion, "found", d.Version)
+		return nil
+	}
+	if d.Entries == nil {
+		d.Entries = make(map[string]bucketEntry)
+	}
+	b.data = d
+	b.log.Debug("Cache bucket loaded", "bucket", b.name, "entries", len(b.data.Entries))
+	return nil
+}
+
+// save writes the bucket to disk atomically, creating the directory if
+// needed.
+func (b *Bucket) save() error {
+	dir := filepath.Dir(b.filePath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+	data, err := json.Marshal(b.data)
+	if err != nil {
+		return fmt.Errorf("encoding %s cache: %w", b.name, err)
+	}
+	tmp := b.filePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing %s cache: %w", b.name, err)
+	}
+	if err := os.Rename(tmp, b.filePath); err != nil {
+		return fmt.Errorf("writing %s cache: %w", b.name, err)
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 )
@@ -38,6 +39,17 @@ func (e Entry) IsExpired() bool {
 	return time.Since(e.CachedAt) > ttl
 }
 
+// ExpiresAt returns when the entry exceeds its TTL.
+func (e Entry) ExpiresAt() time.Time {
+	return e.CachedAt.Add(time.Duration(e.TTLHours) * time.Hour)
+}
+
+// KeyedEntry is an entry together with its cache key.
+type KeyedEntry struct {
+	Key string
+	Entry
+}
+
 // cacheData is the on-disk JSON structure.
 type cacheData struct {
 	Version int              `json:"version"`
@@ -51,6 +63,9 @@ type Stats struct {
 	ValidEntries   int
 	FilePath       string
 	FileSizeBytes  int64
+	// MemoryOnly is why the cache stopped writing to disk during this
+	// run, or nil while it persists.
+	MemoryOnly error
 }
 
 // Cache is a file-backed cost center cache.
@@ -60,6 +75,7 @@ type Cache struct {
 	ttlHours int
 	data     cacheData
 	log      *slog.Logger
+	persist  fallback
 }
 
 // New creates or loads a cache from the given directory.
@@ -105,7 +121,9 @@ func (c *Cache) Get(key string) (Entry, bool) {
 	return e, true
 }
 
-// Set stores or updates a cache entry and flushes to disk.
+// Set stores or updates a cache entry and flushes to disk.  When the cache
+// directory cannot be written the entry is kept in memory for the run (see
+// MemoryOnly) and no error is returned.
 func (c *Cache) Set(key, id, name string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -117,7 +135,46 @@ func (c *Cache) Set(key, id, name string) error {
 		TTLHours: c.ttlHours,
 	}
 	c.log.Debug("Cache set", "key", key, "id", id)
-	return c.save()
+	return c.flush()
+}
+
+// Retain removes the entries for which keep returns false and saves to disk
+// if any were removed.  Returns the number of entries removed.
+func (c *Cache) Retain(keep func(key string, e Entry) bool) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for key, e := range c.data.Entries {
+		if !keep(key, e) {
+			delete(c.data.Entries, key)
+			removed++
+			c.log.Debug("Cache entry invalidated", "key", key, "id", e.ID)
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, c.flush()
+}
+
+// DeleteID removes every entry that resolves to the given cost center ID,
+// e.g. after the cost center was deleted.  Returns the number removed.
+func (c *Cache) DeleteID(id string) (int, error) {
+	return c.Retain(func(_ string, e Entry) bool { return e.ID != id })
+}
+
+// List returns every entry, expired ones included, sorted by key.
+func (c *Cache) List() []KeyedEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make([]KeyedEntry, 0, len(c.data.Entries))
+	for key, e := range c.data.Entries {
+		out = append(out, KeyedEntry{Key: key, Entry: e})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
 }
 
 // GetStats returns statistics about the current cache.
@@ -141,10 +198,17 @@ func (c *Cache) GetStats() Stats {
 	if info, err := os.Stat(c.filePath); err == nil {
 		s.FileSizeBytes = info.Size()
 	}
+	s.MemoryOnly = c.persist.memoryOnly()
 
 	return s
 }
 
+// MemoryOnly returns why the cache stopped writing to disk during this
+// run, or nil while it persists.
+func (c *Cache) MemoryOnly() error {
+	return c.persist.memoryOnly()
+}
+
 // Clear removes all cache entries and deletes the cache file.
 func (c *Cache) Clear() error {
 	c.mu.Lock()
@@ -175,7 +239,7 @@ func (c *Cache) CleanupExpired() (int, error) {
 	}
 
 	if removed > 0 {
-		if err := c.save(); err != nil {
+		if err := c.flush(); err != nil {
 			return removed, err
 		}
 	}
@@ -189,6 +253,26 @@ func (c *Cache) FilePath() string {
 	return c.filePath
 }
 
+// CheckWritable verifies that the cache directory can be created and written
+// to by creating and removing a probe file.  The cache contents are untouched.
+func (c *Cache) CheckWritable() error {
+	dir := filepath.Dir(c.filePath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(dir, ".probe-*")
+	if err != nil {
+		return fmt.Errorf("writing to cache directory: %w", err)
+	}
+	name := f.Name()
+	_ = f.Close()
+	if err := os.Remove(name); err != nil {
+		return fmt.Errorf("removing cache probe file: %w", err)
+	}
+	return nil
+}
+
 // load reads the cache file from disk. Returns an error if the file
 // does not exist or cannot be parsed.
 func (c *Cache) load() error {
@@ -218,6 +302,13 @@ func (c *Cache) load() error {
 	return nil
 }
 
+// flush saves the cache, falling back to memory when the cache directory
+// cannot be written.
+func (c *Cache) flush() error {
+	c.persist.write(filepath.Dir(c.filePath), c.log, c.save)
+	return nil
+}
+
 // save writes the cache data to disk, creating the directory if needed.
 func (c *Cache) save() error {
 	dir := filepath.Dir(c.filePath)
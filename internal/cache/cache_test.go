@@ -1,6 +1,10 @@
 package cache
 
 import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -143,6 +147,69 @@ func TestCleanupExpired_NoneExpired(t *testing.T) {
 	}
 }
 
+func TestDeleteID(t *testing.T) {
+	dir := t.TempDir()
+	c, _ := New(dir, testLogger())
+	_ = c.Set("a", "uuid-1", "A")
+	_ = c.Set("a-alias", "uuid-1", "A")
+	_ = c.Set("b", "uuid-2", "B")
+
+	n, err := c.DeleteID("uuid-1")
+	if err != nil {
+		t.Fatalf("DeleteID: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("removed %d entries, want 2", n)
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Error("entry a should be gone")
+	}
+	if _, ok := c.Get("b"); !ok {
+		t.Error("entry b should survive")
+	}
+
+	// The removal is persisted.
+	c2, _ := New(dir, testLogger())
+	if _, ok := c2.Get("a-alias"); ok {
+		t.Error("entry a-alias should be gone after reload")
+	}
+}
+
+func TestRetain_NothingRemoved(t *testing.T) {
+	dir := t.TempDir()
+	c, _ := New(dir, testLogger())
+	_ = c.Set("a", "uuid-1", "A")
+
+	n, err := c.Retain(func(string, Entry) bool { return true })
+	if err != nil || n != 0 {
+		t.Errorf("Retain = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestList_SortedWithExpired(t *testing.T) {
+	dir := t.TempDir()
+	c, _ := New(dir, testLogger())
+	_ = c.Set("b-cc", "id-b", "B")
+	_ = c.Set("a-cc", "id-a", "A")
+	c.data.Entries["old-cc"] = Entry{ID: "id-old", CachedAt: time.Now().Add(-48 * time.Hour), TTLHours: DefaultTTLHours}
+
+	got := c.List()
+	if len(got) != 3 {
+		t.Fatalf("got %d entries, want 3", len(got))
+	}
+	for i, key := range []string{"a-cc", "b-cc", "old-cc"} {
+		if got[i].Key != key {
+			t.Errorf("entry %d: key %q, want %q", i, got[i].Key, key)
+		}
+	}
+	if !got[2].IsExpired() {
+		t.Error("expected old-cc to be listed as expired")
+	}
+	if want := got[0].CachedAt.Add(DefaultTTLHours * time.Hour); !got[0].ExpiresAt().Equal(want) {
+		t.Errorf("ExpiresAt: got %v, want %v", got[0].ExpiresAt(), want)
+	}
+}
+
 func TestGetStats(t *testing.T) {
 	dir := t.TempDir()
 	c, _ := New(dir, testLogger())
@@ -240,3 +307,286 @@ func TestNew_DefaultDir(t *testing.T) {
 		t.Errorf("expected default path, got %q", c.filePath)
 	}
 }
+
+func TestCheckWritable(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested")
+	c, _ := New(dir, testLogger())
+
+	if err := c.CheckWritable(); err != nil {
+		t.Fatalf("CheckWritable: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("reading cache dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected probe file to be removed, found %d entries", len(entries))
+	}
+}
+
+func TestCheckWritable_ReadOnlyDir(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("permission checks are bypassed when running as root")
+	}
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0o555); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(dir, 0o755) })
+
+	c, _ := New(dir, testLogger())
+	if err := c.CheckWritable(); err == nil {
+		t.Error("expected error for read-only cache directory")
+	}
+}
+
+func TestHTTPStore_CommitAndDiscard(t *testing.T) {
+	s := NewHTTPStore(t.TempDir(), testLogger())
+	url := "https://api.github.com/enterprises/acme/teams?page=1"
+
+	w, err := s.Put(HTTPEntry{URL: url, ETag: `"abc"`, Link: `<next>; rel="next"`})
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	_, _ = w.Write([]byte(`[{"slug":"a"}]`))
+	if _, ok := s.Get(url); ok {
+		t.Fatal("entry visible before Commit")
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	e, ok := s.Get(url)
+	if !ok || e.ETag != `"abc"` || e.Link != `<next>; rel="next"` || e.StoredAt.IsZero() {
+		t.Fatalf("Get = %+v, %v", e, ok)
+	}
+	body, err := s.Body(url)
+	if err != nil {
+		t.Fatalf("Body: %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	_ = body.Close()
+	if string(data) != `[{"slug":"a"}]` {
+		t.Errorf("body = %s", data)
+	}
+
+	// A discarded rewrite keeps the committed entry.
+	w, _ = s.Put(HTTPEntry{URL: url, ETag: `"def"`})
+	_, _ = w.Write([]byte(`[`))
+	w.Discard()
+	if e, _ := s.Get(url); e.ETag != `"abc"` {
+		t.Errorf("ETag after discard = %s, want \"abc\"", e.ETag)
+	}
+	if n, size := s.Stats(); n != 1 || size == 0 {
+		t.Errorf("Stats = %d entries, %d bytes", n, size)
+	}
+
+	if err := s.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if _, ok := s.Get(url); ok {
+		t.Error("entry survived Clear")
+	}
+}
+
+func TestHTTPStore_OwnerOnlyFiles(t *testing.T) {
+	s := NewHTTPStore(t.TempDir(), testLogger())
+	w, err := s.Put(HTTPEntry{URL: "https://api.github.com/x", ETag: `"a"`})
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	_, _ = w.Write([]byte(`{}`))
+	if err := w.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	files, _ := os.ReadDir(s.Dir())
+	if len(files) != 2 {
+		t.Fatalf("files = %d, want the metadata and the body", len(files))
+	}
+	for _, f := range files {
+		info, _ := f.Info()
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("%s mode = %o, want 600", f.Name(), perm)
+		}
+	}
+}
+
+func TestHTTPStore_Prune(t *testing.T) {
+	s := NewHTTPStore(t.TempDir(), testLogger())
+	for _, url := range []string{"https://api.github.com/old", "https://api.github.com/new"} {
+		w, err := s.Put(HTTPEntry{URL: url})
+		if err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+		if url == "https://api.github.com/old" {
+			w.entry.StoredAt = time.Now().Add(-2 * time.Hour)
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatalf("Commit: %v", err)
+		}
+	}
+	orphan := filepath.Join(s.Dir(), "orphan.body")
+	if err := os.WriteFile(orphan, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := s.Prune(time.Hour)
+	if err != nil || removed != 1 {
+		t.Fatalf("Prune = %d, %v; want 1 removed", removed, err)
+	}
+	if _, ok := s.Get("https://api.github.com/old"); ok {
+		t.Error("old entry survived Prune")
+	}
+	if _, ok := s.Get("https://api.github.com/new"); !ok {
+		t.Error("recent entry was pruned")
+	}
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Error("body without metadata survived Prune")
+	}
+	if n, _ := s.Stats(); n != 1 {
+		t.Errorf("Stats = %d entries, want 1", n)
+	}
+}
+
+func TestBucket_SetGetAndPersistence(t *testing.T) {
+	dir := t.TempDir()
+	b := NewBucket(dir, BucketTeamMembers, time.Hour, testLogger())
+	if err := b.Set("org/acme/platform", []string{"alice", "bob"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, err := os.Stat(b.FilePath()); !os.IsNotExist(err) {
+		t.Fatal("bucket written before Flush")
+	}
+	b.Flush()
+
+	reloaded := NewBucket(dir, BucketTeamMembers, time.Hour, testLogger())
+	var got []string
+	if !reloaded.Get("org/acme/platform", &got) || len(got) != 2 || got[1] != "bob" {
+		t.Fatalf("Get = %v, want [alice bob]", got)
+	}
+	if reloaded.Get("org/acme/other", &got) {
+		t.Error("expected a miss for an unknown key")
+	}
+	if filepath.Base(reloaded.FilePath()) != "team_members.json" {
+		t.Errorf("FilePath = %s", reloaded.FilePath())
+	}
+}
+
+func TestBucket_TTL(t *testing.T) {
+	dir := t.TempDir()
+	b := NewBucket(dir, BucketSeats, time.Hour, testLogger())
+	if err := b.Set("acme", []string{"alice"}); err != nil {
+		t.Fatal(err)
+	}
+	b.data.Entries["acme"] = bucketEntry{CachedAt: time.Now().Add(-2 * time.Hour), Value: b.data.Entries["acme"].Value}
+
+	var got []string
+	if b.Get("acme", &got) {
+		t.Error("expected the entry to have expired")
+	}
+	if s := b.GetStats(); s.ExpiredEntries != 1 || s.ValidEntries != 0 {
+		t.Errorf("stats = %+v", s)
+	}
+	if n, err := b.CleanupExpired(); err != nil || n != 1 {
+		t.Errorf("CleanupExpired = %d, %v; want 1", n, err)
+	}
+
+	if err := b.Set("acme", []string{"alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if _, err := os.Stat(b.FilePath()); !os.IsNotExist(err) {
+		t.Error("bucket file should be gone")
+	}
+}
+
+// countingHandler counts the warnings logged through it.
+type countingHandler struct {
+	slog.Handler
+	warnings *int
+}
+
+func (h countingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level == slog.LevelWarn {
+		*h.warnings++
+	}
+	return nil
+}
+
+// unwritableDir returns a cache directory that cannot be created, even as
+// root: its parent is a regular file.
+func unwritableDir(t *testing.T) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(file, "cache")
+}
+
+func TestUnwritableDir_FallsBackToMemory(t *testing.T) {
+	persistBackoff = 0
+	t.Cleanup(func() { persistBackoff = 20 * time.Millisecond })
+	warnings := 0
+	logger := slog.New(countingHandler{Handler: slog.NewTextHandler(io.Discard, nil), warnings: &warnings})
+	dir := unwritableDir(t)
+
+	c, _ := New(dir, logger)
+	for _, key := range []string{"a", "b"} {
+		if err := c.Set(key, "id-"+key, key); err != nil {
+			t.Fatalf("Set(%s) = %v, want the failure absorbed", key, err)
+		}
+	}
+	if e, ok := c.Get("b"); !ok || e.ID != "id-b" {
+		t.Errorf("Get = %+v, %v; want the entry kept in memory", e, ok)
+	}
+	if c.MemoryOnly() == nil || c.GetStats().MemoryOnly == nil {
+		t.Error("cache should report that it is memory-only")
+	}
+
+	b := NewBucket(dir, BucketSeats, time.Hour, logger)
+	if err := b.Set("acme", []string{"alice"}); err != nil {
+		t.Fatalf("bucket Set = %v", err)
+	}
+	b.Flush()
+	var seats []string
+	if !b.Get("acme", &seats) || len(seats) != 1 || b.GetStats().MemoryOnly == nil {
+		t.Errorf("bucket = %v, memory-only %v", seats, b.GetStats().MemoryOnly)
+	}
+	if warnings != 1 {
+		t.Errorf("logged %d warnings, want 1 for the directory", warnings)
+	}
+
+	store := NewHTTPStore(dir, logger)
+	if _, err := store.Put(HTTPEntry{URL: "https://example.com"}); err == nil || store.MemoryOnly() == nil {
+		t.Errorf("Put = %v, want the store disabled", err)
+	}
+}
+
+func TestFallback_RetriesTransientFailures(t *testing.T) {
+	persistBackoff = 0
+	t.Cleanup(func() { persistBackoff = 20 * time.Millisecond })
+
+	var f fallback
+	calls := 0
+	f.write(t.TempDir(), testLogger(), func() error {
+		if calls++; calls < persistAttempts {
+			return errors.New("device busy")
+		}
+		return nil
+	})
+	if calls != persistAttempts || f.memoryOnly() != nil {
+		t.Errorf("calls = %d, memory-only %v; want success on the last attempt", calls, f.memoryOnly())
+	}
+
+	calls = 0
+	f.write(t.TempDir(), testLogger(), func() error {
+		calls++
+		return fs.ErrPermission
+	})
+	if calls != 1 || f.memoryOnly() == nil {
+		t.Errorf("calls = %d, want a read-only directory given up on at once", calls)
+	}
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// persistAttempts is how often a write to the cache directory is tried
+// before the cache falls back to memory.
+const persistAttempts = 3
+
+// persistBackoff is the wait before the second attempt, doubled before
+// each later one.  Replaced in tests.
+var persistBackoff = 20 * time.Millisecond
+
+// warnedDirs holds the cache directories whose fallback has been warned
+// about, so the caches sharing a directory warn once between them.
+var (
+	warnedMu   sync.Mutex
+	warnedDirs = map[string]bool{}
+)
+
+// fallback keeps a cache in memory for the rest of the run once its
+// directory cannot be written, e.g. on a locked-down runner with a
+// read-only working directory.
+type fallback struct {
+	mu  sync.Mutex
+	err error // why the cache stopped persisting; nil while it persists
+}
+
+// write runs save, retrying failures that may be transient.  When every
+// attempt fails, or the directory is read-only, the cache falls back to
+// memory: one warning is logged per cache directory, and this and later
+// writes are skipped and report success.
+func (f *fallback) write(dir string, log *slog.Logger, save func() error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return
+	}
+
+	var err error
+	wait := persistBackoff
+	for attempt := 1; ; attempt++ {
+		if err = save(); err == nil {
+			return
+		}
+		if attempt == persistAttempts || readOnly(err) {
+			break
+		}
+		log.Debug("Cache write failed, retrying", "dir", dir, "attempt", attempt, "error", err)
+		time.Sleep(wait)
+		wait *= 2
+	}
+	f.err = err
+
+	warnedMu.Lock()
+	defer warnedMu.Unlock()
+	key := filepath.Clean(dir)
+	if warnedDirs[key] {
+		log.Debug("Cache kept in memory for this run", "dir", dir, "error", err)
+		return
+	}
+	warnedDirs[key] = true
+	log.Warn("Cache directory is not writable; caching in memory for this run only",
+		"dir", dir, "error", err)
+}
+
+// memoryOnly returns why the cache stopped persisting, or nil.
+func (f *fallback) memoryOnly() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
+
+// readOnly reports whether err means the directory cannot be written at
+// all, so retrying is pointless.
+func readOnly(err error) bool {
+	return errors.Is(err, fs.ErrPermission) || errors.Is(err, syscall.EROFS)
+}
@@ -0,0 +1,263 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultHTTPDir is the directory of the HTTP response cache inside the
+	// cache directory.
+	DefaultHTTPDir = "http"
+	// DefaultHTTPMaxAge is how long a stored response is kept without
+	// being refreshed before Prune removes it.
+	DefaultHTTPMaxAge = 7 * 24 * time.Hour
+	// userCacheSubdir is the directory of the tool in the user cache
+	// directory.
+	userCacheSubdir = "gh-cost-center"
+)
+
+// HTTPEntry is the validator and headers of a cached GET response.  Its
+// body is stored next to it and read with HTTPStore.Body.
+type HTTPEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Link         string    `json:"link,omitempty"`
+	ContentType  string    `json:"content_type,omitempty"`
+	StoredAt     time.Time `json:"stored_at"`
+}
+
+// HTTPStore keeps GET responses with their ETag or Last-Modified
+// validators on disk, one metadata and one body file per URL, so repeated
+// runs can send conditional requests and reuse the body on 304 Not
+// Modified.  Bodies are streamed to and from disk rather than held in
+// memory.  Responses hold enterprise data, so the files are readable by
+// the owner only.
+type HTTPStore struct {
+	dir     string
+	log     *slog.Logger
+	persist fallback
+}
+
+// NewHTTPStore returns a store in the DefaultHTTPDir of dir.  When dir is
+// empty the user cache directory is used (see os.UserCacheDir), so the
+// responses stay out of the checkout, falling back to DefaultCacheDir.
+// The directory is created on the first write.
+func NewHTTPStore(dir string, logger *slog.Logger) *HTTPStore {
+	if dir == "" {
+		dir = DefaultCacheDir
+		if base, err := os.UserCacheDir(); err == nil {
+			dir = filepath.Join(base, userCacheSubdir)
+		}
+	}
+	return &HTTPStore{dir: filepath.Join(dir, DefaultHTTPDir), log: logger}
+}
+
+// Dir returns the directory the store writes to.
+func (s *HTTPStore) Dir() string {
+	return s.dir
+}
+
+// key names the files of a URL.
+func (s *HTTPStore) key(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:16]))
+}
+
+// Get returns the entry stored for url.
+func (s *HTTPStore) Get(url string) (HTTPEntry, bool) {
+	data, err := os.ReadFile(s.key(url) + ".json")
+	if err != nil {
+		return HTTPEntry{}, false
+	}
+	var e HTTPEntry
+	if err := json.Unmarshal(data, &e); err != nil || e.URL != url {
+		return HTTPEntry{}, false
+	}
+	return e, true
+}
+
+// Body opens the stored body of url.
+func (s *HTTPStore) Body(url string) (io.ReadCloser, error) {
+	return os.Open(s.key(url) + ".body")
+}
+
+// Put returns a writer for the body of a new entry.  The entry replaces the
+// stored one only when the writer is committed; a discarded writer leaves
+// the store untouched.  Once the directory has proved not writable, Put
+// fails at once for the rest of the run (see MemoryOnly).
+func (s *HTTPStore) Put(e HTTPEntry) (*HTTPWriter, error) {
+	var f *os.File
+	s.persist.write(filepath.Dir(s.dir), s.log, func() error {
+		if err := os.MkdirAll(s.dir, 0o700); err != nil {
+			return fmt.Errorf("creating HTTP cache directory: %w", err)
+		}
+		var err error
+		if f, err = os.CreateTemp(s.dir, ".body-*"); err != nil {
+			return fmt.Errorf("creating HTTP cache body: %w", err)
+		}
+		return nil
+	})
+	if f == nil {
+		return nil, fmt.Errorf("HTTP cache not writable: %w", s.persist.memoryOnly())
+	}
+	e.StoredAt = time.Now().UTC()
+	return &HTTPWriter{store: s, entry: e, f: f}, nil
+}
+
+// HTTPWriter receives the body of an entry being stored.
+type HTTPWriter struct {
+	store *HTTPStore
+	entry HTTPEntry
+	f     *os.File
+}
+
+// Write implements io.Writer.
+func (w *HTTPWriter) Write(p []byte) (int, error) {
+	return w.f.Write(p)
+}
+
+// Commit stores the entry with the body written so far.
+func (w *HTTPWriter) Commit() error {
+	key := w.store.key(w.entry.URL)
+	if err := w.f.Close(); err != nil {
+		_ = os.Remove(w.f.Name())
+		return fmt.Errorf("writing HTTP cache body: %w", err)
+	}
+	if err := os.Rename(w.f.Name(), key+".body"); err != nil {
+		_ = os.Remove(w.f.Name())
+		return fmt.Errorf("writing HTTP cache body: %w", err)
+	}
+	data, err := json.Marshal(w.entry)
+	if err != nil {
+		return fmt.Errorf("encoding HTTP cache entry: %w", err)
+	}
+	if err := writeFileAtomic(key+".json", data); err != nil {
+		return fmt.Errorf("writing HTTP cache entry: %w", err)
+	}
+	w.store.log.Debug("HTTP response cached", "url", w.entry.URL)
+	return nil
+}
+
+// writeFileAtomic writes data to path through a temporary file in the
+// same directory, created with mode 0600, and renames it into place, so
+// readers never see a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// Discard drops the entry.
+func (w *HTTPWriter) Discard() {
+	_ = w.f.Close()
+	_ = os.Remove(w.f.Name())
+}
+
+// MemoryOnly returns why the store stopped caching responses during this
+// run, or nil while it writes to disk.
+func (s *HTTPStore) MemoryOnly() error {
+	return s.persist.memoryOnly()
+}
+
+// Stats returns the number of stored responses and their total size.
+func (s *HTTPStore) Stats() (entries int, bytes int64) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, 0
+	}
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		if strings.HasSuffix(f.Name(), ".json") {
+			entries++
+		}
+		if info, err := f.Info(); err == nil {
+			bytes += info.Size()
+		}
+	}
+	return entries, bytes
+}
+
+// Prune removes the responses stored more than maxAge ago, bodies whose
+// metadata is missing, and temporary files older than maxAge left by
+// interrupted writes.  It returns how many responses it removed.
+func (s *HTTPStore) Prune(maxAge time.Duration) (int, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("reading HTTP cache: %w", err)
+	}
+	cutoff := time.Now().Add(-maxAge)
+	present := make(map[string]bool, len(files))
+	for _, f := range files {
+		present[f.Name()] = true
+	}
+
+	removed := 0
+	for _, f := range files {
+		name := f.Name()
+		path := filepath.Join(s.dir, name)
+		switch {
+		case strings.HasPrefix(name, "."):
+			if info, err := f.Info(); err == nil && info.ModTime().Before(cutoff) {
+				_ = os.Remove(path)
+			}
+		case strings.HasSuffix(name, ".json"):
+			var e HTTPEntry
+			data, err := os.ReadFile(path)
+			if err == nil && json.Unmarshal(data, &e) == nil && e.StoredAt.After(cutoff) {
+				continue
+			}
+			base := strings.TrimSuffix(path, ".json")
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return removed, fmt.Errorf("pruning HTTP cache: %w", err)
+			}
+			_ = os.Remove(base + ".body")
+			removed++
+		case strings.HasSuffix(name, ".body"):
+			if !present[strings.TrimSuffix(name, ".body")+".json"] {
+				_ = os.Remove(path)
+			}
+		}
+	}
+	if removed > 0 {
+		s.log.Debug("HTTP cache pruned", "removed", removed, "max_age", maxAge)
+	}
+	return removed, nil
+}
+
+// Clear removes every stored response.
+func (s *HTTPStore) Clear() error {
+	if err := os.RemoveAll(s.dir); err != nil {
+		return fmt.Errorf("removing HTTP cache: %w", err)
+	}
+	return nil
+}
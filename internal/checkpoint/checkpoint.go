@@ -0,0 +1,248 @@
+// Package checkpoint records the writes of an apply as they succeed, batch
+// by batch, so a run that was interrupted (Ctrl-C, a crash, a rate limit
+// that outlasted the retries) can be resumed with assign --resume without
+// re-sending what already went through.  The checkpoint is removed once an
+// apply completes.
+package checkpoint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultFile is the checkpoint filename inside the export directory.
+	DefaultFile = "apply_checkpoint.jsonl"
+	// currentVersion is the checkpoint format version.
+	currentVersion = 2
+)
+
+// Kinds of resources recorded, matching the cost center resource keys.
+const (
+	KindUsers        = "users"
+	KindRepositories = "repositories"
+)
+
+// header is the first line of the checkpoint file.  Each recorded write is
+// appended after it as one entry line, so recording a batch never rewrites
+// what is already on disk.
+type header struct {
+	Version    int       `json:"version"`
+	Enterprise string    `json:"enterprise"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+// entry is one recorded write: resources added to a cost center.
+type entry struct {
+	Kind         string    `json:"kind"`
+	CostCenterID string    `json:"cost_center_id"`
+	Names        []string  `json:"names"`
+	At           time.Time `json:"at"`
+}
+
+// Checkpoint is a file-backed record of the writes of one apply.  A nil
+// Checkpoint records nothing and filters nothing, so callers need not check
+// whether checkpointing is enabled.
+type Checkpoint struct {
+	mu       sync.Mutex
+	filePath string
+	header   header
+	done     map[string]map[string]map[string]bool // kind → cost center ID → resources added
+	log      *slog.Logger
+}
+
+// Path returns the checkpoint path inside dir.
+func Path(dir string) string {
+	return filepath.Join(dir, DefaultFile)
+}
+
+// Exists reports whether dir holds the checkpoint of an unfinished apply.
+func Exists(dir string) bool {
+	_, err := os.Stat(Path(dir))
+	return err == nil
+}
+
+// Start begins a new checkpoint for enterprise in dir, replacing any
+// earlier one.
+func Start(dir, enterprise string, logger *slog.Logger) (*Checkpoint, error) {
+	c := &Checkpoint{
+		filePath: Path(dir),
+		log:      logger,
+		header: header{
+			Version:    currentVersion,
+			Enterprise: enterprise,
+			StartedAt:  time.Now().UTC(),
+		},
+		done: make(map[string]map[string]map[string]bool),
+	}
+	if err := c.writeHeader(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Resume loads the checkpoint of an unfinished apply from dir.  The error
+// wraps os.ErrNotExist when there is none.  A checkpoint written for
+// another enterprise is rejected.  A last entry cut short by the
+// interruption is ignored: its batch is sent again.
+func Resume(dir, enterprise string, logger *slog.Logger) (*Checkpoint, error) {
+	c := &Checkpoint{
+		filePath: Path(dir),
+		log:      logger,
+		done:     make(map[string]map[string]map[string]bool),
+	}
+	data, err := os.ReadFile(c.filePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading apply checkpoint: %w", err)
+	}
+	lines := bytes.Split(data, []byte{'\n'})
+	// Only lines ending in a newline were written in full.
+	lines = lines[:len(lines)-1]
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("apply checkpoint %s is empty", c.filePath)
+	}
+	if err := json.Unmarshal(lines[0], &c.header); err != nil {
+		return nil, fmt.Errorf("decoding apply checkpoint %s: %w", c.filePath, err)
+	}
+	if c.header.Version != currentVersion {
+		return nil, fmt.Errorf("apply checkpoint %s has version %d, expected %d", c.filePath, c.header.Version, currentVersion)
+	}
+	if c.header.Enterprise != enterprise {
+		return nil, fmt.Errorf("apply checkpoint %s is for enterprise %q, not %q", c.filePath, c.header.Enterprise, enterprise)
+	}
+	for i, line := range lines[1:] {
+		var e entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("decoding apply checkpoint %s line %d: %w", c.filePath, i+2, err)
+		}
+		c.add(e)
+	}
+	return c, nil
+}
+
+// StartedAt returns when the checkpointed apply began.
+func (c *Checkpoint) StartedAt() time.Time {
+	if c == nil {
+		return time.Time{}
+	}
+	return c.header.StartedAt
+}
+
+// Count returns how many resources of the given kind have been recorded.
+func (c *Checkpoint) Count(kind string) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, names := range c.done[kind] {
+		n += len(names)
+	}
+	return n
+}
+
+// Filter splits names into those already added to the cost center by the
+// checkpointed apply and those still to send.
+func (c *Checkpoint) Filter(kind, costCenterID string, names []string) (done, todo []string) {
+	if c == nil {
+		return nil, names
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	recorded := c.done[kind][costCenterID]
+	if len(recorded) == 0 {
+		return nil, names
+	}
+	for _, n := range names {
+		if recorded[n] {
+			done = append(done, n)
+		} else {
+			todo = append(todo, n)
+		}
+	}
+	return done, todo
+}
+
+// Record adds a successful write, appending it to the checkpoint file.
+func (c *Checkpoint) Record(kind, costCenterID string, names []string) error {
+	if c == nil || len(names) == 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := entry{Kind: kind, CostCenterID: costCenterID, Names: names, At: time.Now().UTC()}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding checkpoint entry: %w", err)
+	}
+	f, err := os.OpenFile(c.filePath, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return fmt.Errorf("opening checkpoint file: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("writing checkpoint file: %w", err)
+	}
+	c.add(e)
+	return nil
+}
+
+// add records the resources of e as added.
+func (c *Checkpoint) add(e entry) {
+	if c.done[e.Kind] == nil {
+		c.done[e.Kind] = make(map[string]map[string]bool)
+	}
+	set := c.done[e.Kind][e.CostCenterID]
+	if set == nil {
+		set = make(map[string]bool, len(e.Names))
+		c.done[e.Kind][e.CostCenterID] = set
+	}
+	for _, n := range e.Names {
+		set[n] = true
+	}
+}
+
+// Remove deletes the checkpoint file once the apply has completed.
+func (c *Checkpoint) Remove() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.Remove(c.filePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing apply checkpoint: %w", err)
+	}
+	return nil
+}
+
+// writeHeader starts the checkpoint file with its header, creating the
+// directory if needed.  The file is replaced atomically so an interruption
+// mid-write never leaves a truncated header behind.
+func (c *Checkpoint) writeHeader() error {
+	if err := os.MkdirAll(filepath.Dir(c.filePath), 0o755); err != nil {
+		return fmt.Errorf("creating checkpoint directory: %w", err)
+	}
+	data, err := json.Marshal(c.header)
+	if err != nil {
+		return fmt.Errorf("encoding checkpoint: %w", err)
+	}
+	tmp := c.filePath + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing checkpoint file: %w", err)
+	}
+	if err := os.Rename(tmp, c.filePath); err != nil {
+		return fmt.Errorf("writing checkpoint file: %w", err)
+	}
+	c.log.Debug("Apply checkpoint started", "path", c.filePath)
+	return nil
+}
@@ -0,0 +1,105 @@
+package checkpoint
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+}
+
+func TestCheckpoint_RecordAndResume(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Start(dir, "acme", testLogger())
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := c.Record(KindUsers, "cc-1", []string{"alice", "bob"}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := c.Record(KindRepositories, "cc-1", []string{"o/r"}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if !Exists(dir) {
+		t.Fatal("checkpoint file missing")
+	}
+
+	r, err := Resume(dir, "acme", testLogger())
+	if err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	done, todo := r.Filter(KindUsers, "cc-1", []string{"alice", "carol", "bob"})
+	if strings.Join(done, ",") != "alice,bob" || strings.Join(todo, ",") != "carol" {
+		t.Errorf("Filter = %v, %v; want [alice bob], [carol]", done, todo)
+	}
+	if _, todo := r.Filter(KindUsers, "cc-2", []string{"alice"}); len(todo) != 1 {
+		t.Errorf("other cost center todo = %v, want [alice]", todo)
+	}
+	if n := r.Count(KindUsers); n != 2 {
+		t.Errorf("Count(users) = %d, want 2", n)
+	}
+
+	if err := r.Remove(); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if Exists(dir) {
+		t.Error("checkpoint file should be gone")
+	}
+}
+
+func TestResume_IgnoresTruncatedEntry(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Start(dir, "acme", testLogger())
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := c.Record(KindUsers, "cc-1", []string{"alice"}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	// An interruption mid-append leaves a line without its newline.
+	f, err := os.OpenFile(Path(dir), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"kind":"users","cost_center_id":"cc-1","names":["bo`)
+	_ = f.Close()
+
+	r, err := Resume(dir, "acme", testLogger())
+	if err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	done, todo := r.Filter(KindUsers, "cc-1", []string{"alice", "bob"})
+	if strings.Join(done, ",") != "alice" || strings.Join(todo, ",") != "bob" {
+		t.Errorf("Filter = %v, %v; want [alice], [bob]", done, todo)
+	}
+}
+
+func TestResume_Missing(t *testing.T) {
+	if _, err := Resume(t.TempDir(), "acme", testLogger()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("err = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestResume_OtherEnterprise(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Start(dir, "acme", testLogger()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Resume(dir, "other", testLogger()); err == nil {
+		t.Error("expected an error for a checkpoint of another enterprise")
+	}
+}
+
+func TestNilCheckpoint(t *testing.T) {
+	var c *Checkpoint
+	if err := c.Record(KindUsers, "cc-1", []string{"alice"}); err != nil {
+		t.Errorf("Record: %v", err)
+	}
+	if done, todo := c.Filter(KindUsers, "cc-1", []string{"alice"}); len(done) != 0 || len(todo) != 1 {
+		t.Errorf("Filter = %v, %v", done, todo)
+	}
+}
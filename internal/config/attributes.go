@@ -0,0 +1,213 @@
+package config
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadMemberAttributes reads a login -> attribute file used by team splits.
+// The format is chosen by extension:
+//
+//	.csv          two columns (login,attribute); a "login" header row is skipped
+//	.json         {"alice": "employee", "bob": "contractor"}
+//	.yaml / .yml  alice: employee
+//
+// Logins are lower-cased so lookups are case-insensitive.
+func loadMemberAttributes(path string) (map[string]string, error) {
+	return loadLoginMap(path, "attribute")
+}
+
+// loadOverrides reads the login -> cost center file of the "overrides"
+// assignment source, in the same formats as loadMemberAttributes.
+func loadOverrides(path string) (map[string]string, error) {
+	return loadLoginMap(path, "cost_center")
+}
+
+// RecordOverride sets the override of login to costCenter, both in
+// m.Overrides and in cost_center.overrides_file, which is created if
+// missing.  CSV files, and YAML files without the login, get a line
+// appended so existing comments and ordering survive; other files are
+// rewritten.
+func (m *Manager) RecordOverride(login, costCenter string) error {
+	if m.OverridesFile == "" {
+		return fmt.Errorf("cost_center.overrides_file is not set")
+	}
+	path := m.OverridesFile
+	var line string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		var b strings.Builder
+		w := csv.NewWriter(&b)
+		_ = w.Write([]string{login, costCenter})
+		w.Flush()
+		line = b.String()
+	case ".yaml", ".yml":
+		// A repeated key is invalid YAML, so a login already in the file
+		// means rewriting it.
+		raw := make(map[string]string)
+		if data, err := os.ReadFile(path); err == nil {
+			if err := yaml.Unmarshal(data, &raw); err != nil {
+				return fmt.Errorf("parsing %s: %w", path, err)
+			}
+		}
+		existing := ""
+		for k := range raw {
+			if strings.EqualFold(strings.TrimSpace(k), login) {
+				existing = k
+			}
+		}
+		if existing == "" {
+			data, err := yaml.Marshal(map[string]string{login: costCenter})
+			if err != nil {
+				return fmt.Errorf("encoding override: %w", err)
+			}
+			line = string(data)
+			break
+		}
+		raw[existing] = costCenter
+		data, err := yaml.Marshal(raw)
+		if err != nil {
+			return fmt.Errorf("encoding overrides: %w", err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+	case ".json":
+		raw := make(map[string]string)
+		if data, err := os.ReadFile(path); err == nil {
+			if err := json.Unmarshal(data, &raw); err != nil {
+				return fmt.Errorf("parsing %s: %w", path, err)
+			}
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+		for k := range raw {
+			if strings.EqualFold(strings.TrimSpace(k), login) {
+				delete(raw, k)
+			}
+		}
+		raw[login] = costCenter
+		data, err := json.MarshalIndent(raw, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding overrides: %w", err)
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported file extension %q: use .csv, .json, .yaml, or .yml", filepath.Ext(path))
+	}
+
+	if line != "" {
+		if err := appendLine(path, line); err != nil {
+			return err
+		}
+	}
+	if m.Overrides == nil {
+		m.Overrides = make(map[string]string)
+	}
+	m.Overrides[strings.ToLower(login)] = costCenter
+	return nil
+}
+
+// appendLine appends line to path, first ending an unterminated last line.
+func appendLine(path, line string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	if len(existing) > 0 && existing[len(existing)-1] != '\n' {
+		line = "\n" + line
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", path, err)
+	}
+	if _, err := f.WriteString(line); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// loadTeamMappings reads a team -> cost center file of extra teams mode
+// mappings, in the same formats as loadMemberAttributes with "team" for
+// "login".  Team keys keep their case.
+func loadTeamMappings(path string) (map[string]string, error) {
+	return loadKeyMap(path, "team", "cost_center", false)
+}
+
+// loadLoginMap reads a two-column login -> value file; column names the
+// value in error messages.  Logins are lower-cased.
+func loadLoginMap(path, column string) (map[string]string, error) {
+	return loadKeyMap(path, "login", column, true)
+}
+
+// loadKeyMap reads a two-column key -> value file: a CSV, whose first row is
+// skipped when it starts with the key column's name, or a JSON or YAML map.
+// Keys and values are trimmed, and entries missing either are dropped.
+// With fold, keys are lower-cased, and of CSV rows differing only in case
+// the last wins.
+func loadKeyMap(path, key, column string, fold bool) (map[string]string, error) {
+	normalize := strings.TrimSpace
+	if fold {
+		normalize = func(k string) string { return strings.ToLower(strings.TrimSpace(k)) }
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	raw := make(map[string]string)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		r := csv.NewReader(f)
+		r.FieldsPerRecord = -1
+		r.TrimLeadingSpace = true
+		for line := 1; ; line++ {
+			rec, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("parsing CSV: %w", err)
+			}
+			if len(rec) < 2 {
+				return nil, fmt.Errorf("line %d: expected 2 columns (%s,%s), got %d", line, key, column, len(rec))
+			}
+			if line == 1 && strings.EqualFold(strings.TrimSpace(rec[0]), key) {
+				continue
+			}
+			raw[normalize(rec[0])] = rec[1]
+		}
+	case ".json":
+		if err := json.NewDecoder(f).Decode(&raw); err != nil {
+			return nil, fmt.Errorf("parsing JSON: %w", err)
+		}
+	case ".yaml", ".yml":
+		if err := yaml.NewDecoder(f).Decode(&raw); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("parsing YAML: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported file extension %q: use .csv, .json, .yaml, or .yml", filepath.Ext(path))
+	}
+
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		k, v = normalize(k), strings.TrimSpace(v)
+		if k == "" || v == "" {
+			continue
+		}
+		values[k] = v
+	}
+	return values, nil
+}
@@ -0,0 +1,54 @@
+package config
+
+import "strings"
+
+// BudgetOverrideFor returns the budgets.overrides entry of the first key
+// that has one; keys are cost center names or team keys, matched ignoring
+// case.
+func (m *Manager) BudgetOverrideFor(keys ...string) (map[string]BudgetOverride, bool) {
+	for _, key := range keys {
+		if o, ok := m.BudgetOverrides[normalizeOverrideKey(key)]; ok {
+			return o, true
+		}
+	}
+	return nil, false
+}
+
+// BudgetProductsFor returns the product budgets of the cost center known by
+// keys: BudgetProducts with the first matching override applied.
+func (m *Manager) BudgetProductsFor(keys ...string) map[string]ProductBudget {
+	o, ok := m.BudgetOverrideFor(keys...)
+	if !ok {
+		return m.BudgetProducts
+	}
+	return WithBudgetOverride(m.BudgetProducts, o)
+}
+
+// WithBudgetOverride returns a copy of products with the override's
+// products replaced.  Overridden products are enabled unless their amount
+// is 0, keep their alert_threshold, and keep their allow_further_usage
+// unless the override sets it; products only in the override are added.
+func WithBudgetOverride(products map[string]ProductBudget, override map[string]BudgetOverride) map[string]ProductBudget {
+	out := make(map[string]ProductBudget, len(products)+len(override))
+	for product, pb := range products {
+		out[product] = pb
+	}
+	for product, o := range override {
+		allow := products[product].AllowFurtherUsage
+		if o.AllowFurtherUsage != nil {
+			allow = *o.AllowFurtherUsage
+		}
+		out[product] = ProductBudget{
+			Amount:            o.Amount,
+			Enabled:           o.Amount > 0,
+			AllowFurtherUsage: allow,
+			AlertThreshold:    products[product].AlertThreshold,
+		}
+	}
+	return out
+}
+
+// normalizeOverrideKey folds a budgets.overrides key for lookup.
+func normalizeOverrideKey(key string) string {
+	return strings.ToLower(strings.TrimSpace(key))
+}
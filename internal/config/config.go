@@ -2,17 +2,26 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
-	"gopkg.in/yaml.v3"
+
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
+	"github.com/renan-alm/gh-cost-center/internal/quota"
+	"github.com/renan-alm/gh-cost-center/internal/sources"
+	"github.com/renan-alm/gh-cost-center/internal/state"
 )
 
 // Default values.
@@ -26,17 +35,72 @@ const (
 	DefaultPRUsAllowedCCID   = "CC-002-PRUS-ALLOWED"
 	DefaultNoPRUsCCName      = "00 - No PRU overages"
 	DefaultPRUsAllowedCCName = "01 - PRU overages allowed"
+	DefaultServerCCName      = "02 - GHES Connect users"
+	DefaultWindDownCCName    = "03 - Copilot wind-down"
 	DefaultAPIBaseURL        = "https://api.github.com"
-
-	timestampFileName = ".last_run_timestamp"
+	DefaultAPIVersion        = "2022-11-28"
+	DefaultValidatorTimeout  = 60 * time.Second
+	DefaultApplyParallelism  = 1
+	MaxApplyParallelism      = 16
+	DefaultRateLimitReserve  = 100
+	DefaultMaxRetries        = 3
+	DefaultBackoffBase       = 1 * time.Second
+	DefaultBackoffMax        = 60 * time.Second
+	MaxMaxRetries            = 20
+
+	// DeletedCollisionFail aborts when a cost center name matches a deleted
+	// cost center; DeletedCollisionSuffix creates "name (2)" instead.
+	DeletedCollisionFail   = "fail"
+	DeletedCollisionSuffix = "suffix"
+
+	// ServerUsersAssign treats server-connected Copilot users like any other
+	// user; ServerUsersSegregate puts them in their own cost center.
+	ServerUsersAssign    = "assign"
+	ServerUsersSegregate = "segregate"
+
+	// PendingCancellationAssign treats Copilot users with a pending seat
+	// cancellation like any other user; PendingCancellationSkip leaves them
+	// out of the run; PendingCancellationWindDown puts them in a wind-down
+	// cost center.
+	PendingCancellationAssign   = "assign"
+	PendingCancellationSkip     = "skip"
+	PendingCancellationWindDown = "wind_down"
+
+	// Team conflict strategies decide the cost center of a user in several
+	// mapped teams: the team listed first in team_priority, the first team
+	// key alphabetically (default), the team with the fewest or most
+	// members, or an error naming the users.
+	ConflictPriority     = "priority"
+	ConflictAlphabetical = "alphabetical"
+	ConflictSmallest     = "smallest"
+	ConflictLargest      = "largest"
+	ConflictFail         = "fail"
+
+	// Deleted team handling decides what apply does with the cost center of
+	// an auto strategy team that no longer exists: log it, remove its
+	// users, or delete it.
+	DeletedTeamsWarn   = "warn"
+	DeletedTeamsEmpty  = "empty"
+	DeletedTeamsDelete = "delete"
+
+	timestampFileName    = ".last_run_timestamp"
+	applyHistoryFileName = ".apply_history"
+	teamNamesFileName    = ".team_cost_center_names"
+	createdCCsFileName   = ".created_cost_centers"
+	teamMembershipsFile  = ".team_memberships"
 )
 
 // Valid mode values.
 var validModes = map[string]bool{
-	"users":       true,
-	"teams":       true,
-	"repos":       true,
-	"custom-prop": true,
+	"users":        true,
+	"teams":        true,
+	"repos":        true,
+	"custom-prop":  true,
+	"idp-groups":   true,
+	"orgs":         true,
+	"seat-org":     true,
+	"rules":        true,
+	"email-domain": true,
 }
 
 // Placeholder values that indicate the config has not been customised.
@@ -52,14 +116,55 @@ type Manager struct {
 	path string
 	log  *slog.Logger
 
+	// Profile is the profile merged over the top-level settings ("" for
+	// none), and Profiles every profile the file defines, sorted.
+	Profile  string
+	Profiles []string
+
 	// Resolved values after applying env overrides and defaults.
 	Enterprise    string
 	APIBaseURL    string
+	APIFlavor     string // FlavorGitHubCom, FlavorDataResidency, FlavorServer or FlavorCustom
+	APIVersion    string // sent as X-GitHub-Api-Version
 	Organizations []string
 
 	// Cost center mode.
 	CostCenterMode string
 
+	// Assignment sources in priority order (empty: single mode), and the
+	// lower-cased login -> cost center map of the "overrides" source, read
+	// from OverridesFile.  Without sources the overrides only settle users
+	// in several teams.
+	Sources       []string
+	Overrides     map[string]string
+	OverridesFile string
+
+	// DeletedCollisionPolicy is "fail" or "suffix" (see DeletedCollision*).
+	DeletedCollisionPolicy string
+
+	// Apply ordering (cost center names or UUIDs).
+	ApplyFirst            []string
+	ApplyLast             []string
+	ApplyFirstMustSucceed bool
+
+	// ApplyParallelism bounds concurrent cost center writes in apply mode;
+	// RateLimitReserve is the remaining-calls floor at which every request
+	// waits for the rate limit to reset.
+	ApplyParallelism int
+	RateLimitReserve int
+
+	// SharedRateLimitFile, when set, is a usage ledger shared with other
+	// automations; requests are paced so all of them together spend at
+	// most SharedRateLimitBudget points per minute.
+	SharedRateLimitFile   string
+	SharedRateLimitBudget int
+
+	// Retry policy for network errors and 5xx responses (github.retry).
+	MaxRetries    int // attempts per request, including the first
+	BackoffBase   time.Duration
+	BackoffMax    time.Duration
+	BackoffJitter float64
+
 	// Users (PRU) mode fields.
 	NoPRUsCostCenterID        string
 	PRUsAllowedCostCenterID   string
@@ -69,22 +174,92 @@ type Manager struct {
 	PRUsAllowedCostCenterName string
 	EnableIncremental         bool
 
+	// Server-connected (GHES via GitHub Connect) users in users mode.
+	ServerLoginSuffixes  []string
+	ServerUsersHandling  string // ServerUsersAssign or ServerUsersSegregate
+	ServerCostCenterName string
+	ServerCostCenterID   string
+
+	// Users with a pending Copilot seat cancellation in users mode.
+	PendingCancellationHandling string // PendingCancellationAssign, Skip or WindDown
+	WindDownCostCenterName      string
+	WindDownCostCenterID        string
+
 	// Teams mode fields.
 	TeamsScope                string
 	TeamsStrategy             string
 	TeamsAutoCreate           bool
 	TeamsRemoveUnmatchedUsers bool
-	TeamsMappings             map[string]string
+	TeamsMappings             map[string]string // cost_center.teams.mappings and team_mappings_file
+	TeamMappingsFile          string
+	TeamsSplits               map[string]map[string]string
+	TeamsMemberAttributes     map[string]string // lower-cased login -> attribute
+	TeamsConflictStrategy     string
+	TeamsNameTemplate         string
+	TeamsInclude              []string // globs over team slugs or keys
+	TeamsDescriptionKey       string
+	TeamsExclude              []string
+	TeamsPriority             []string // team keys, highest priority first
+	TeamsDeletedTeams         string
 
 	// Repos mode fields.
-	ReposMappings []ExplicitMapping
+	ReposMappings               []ExplicitMapping
+	ReposRemoveNoLongerMatching bool
 
 	// Custom-prop mode fields.
 	CustomPropCostCenters []CustomPropCostCenter
 
+	// IdP groups mode fields.
+	IdPGroupsAutoCreate bool
+	IdPGroupsMappings   map[string]string // IdP group name -> cost center
+
+	// Orgs mode fields.
+	OrgsAutoCreate           bool
+	OrgsRemoveUnmatchedUsers bool
+	OrgsMappings             map[string]string // organization -> cost center
+
+	// Seat-org mode fields.
+	SeatOrgAutoCreate bool
+	SeatOrgMappings   map[string]string // "org" or "org/team-slug" -> cost center
+
+	// Rules mode fields.
+	RulesAutoCreate bool
+	Rules           []AssignmentRule
+	RulesDefault    RuleDefault
+
+	// Email-domain mode fields.
+	EmailDomainAutoCreate bool
+	EmailDomainMappings   map[string]string // lower-cased domain or NameID suffix -> cost center
+	EmailDomainDefault    string
+
 	// Budgets.
 	BudgetsEnabled bool
 	BudgetProducts map[string]ProductBudget
+	BudgetSeatCost float64 // estimated monthly cost of a Copilot seat; 0 when unset
+	// BudgetOverrides is budgets.overrides keyed by lower-cased cost
+	// center name or team key.
+	BudgetOverrides map[string]map[string]BudgetOverride
+
+	// Audit sink (empty AuditSinkType disables it).
+	AuditSinkType          string
+	AuditSinkURL           string
+	AuditSinkToken         string
+	AuditSinkIndex         string
+	AuditSinkSourceType    string
+	AuditSinkBatchSize     int
+	AuditSinkFlushInterval time.Duration
+
+	// Pre-apply validator (empty ValidatorCommand disables it).
+	ValidatorCommand []string
+	ValidatorTimeout time.Duration
+
+	// Lifetimes of the team member and Copilot seat cache buckets; 0
+	// disables a bucket.
+	TeamMembersTTL time.Duration
+	SeatsTTL       time.Duration
+
+	// Language of prompts and summaries; empty follows the locale.
+	Language string
 
 	// Logging & export.
 	ExportDir string
@@ -94,33 +269,90 @@ type Manager struct {
 	// Token from --token flag.
 	Token string
 
-	timestampFile string
+	// GitHub App installation authentication (AppID 0 disables it).
+	AppID             int64
+	AppInstallationID int64
+	AppPrivateKey     []byte // PEM
+
+	// GHHost is the gh CLI host the API base URL belongs to (e.g.
+	// "github.com"), used to resolve the token the way gh does.
+	GHHost string
+
+	timestampFile    string
+	applyHistoryFile string
 }
 
 // Load reads the YAML config at path, applies env-var overrides, and validates.
+// The file must match Schema: unknown keys and type mismatches are all
+// reported in a *SchemaError, so a misspelt key fails the load instead of
+// leaving its setting at the default.
 func Load(path string, logger *slog.Logger) (*Manager, error) {
+	return LoadWith(path, Options{}, logger)
+}
+
+// LoadLenient is Load that logs the keys and values not matching Schema as
+// warnings and ignores them, as releases before the schema did.
+func LoadLenient(path string, logger *slog.Logger) (*Manager, error) {
+	return LoadWith(path, Options{Lenient: true}, logger)
+}
+
+// Options select how LoadWith reads the configuration.
+type Options struct {
+	// Lenient ignores the problems a strict load fails on (see LoadLenient).
+	Lenient bool
+	// Profile names the profile under profiles: to merge over the top-level
+	// settings; "" uses GH_CC_PROFILE, or none when that is unset.
+	Profile string
+}
+
+// LoadWith is Load with options.
+func LoadWith(path string, opts Options, logger *slog.Logger) (*Manager, error) {
 	if logger == nil {
 		logger = slog.Default()
 	}
 
 	loadDotEnv(path, logger)
 
-	m := &Manager{
-		path: path,
-		log:  logger,
-	}
+	m := newManager(path, opts, logger)
 
 	data, err := os.ReadFile(path)
 	if err != nil {
-		if os.IsNotExist(err) {
-			logger.Warn("Config file not found, using defaults", "path", path)
-		} else {
+		if !os.IsNotExist(err) {
 			return nil, fmt.Errorf("reading config file: %w", err)
 		}
-	} else {
-		if err := yaml.Unmarshal(data, &m.cfg); err != nil {
-			return nil, fmt.Errorf("parsing config YAML: %w", err)
-		}
+		logger.Info("Config file not found, using defaults and GH_CC_ environment variables", "path", path)
+	}
+	if err := m.decodeConfig(data, opts.Lenient); err != nil {
+		return nil, err
+	}
+
+	if err := m.resolve(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// LoadData is Load for a configuration given as YAML (or JSON) data rather
+// than read from path; path still locates the .env file and the state
+// files.  It is always strict, since the data usually comes from a
+// generated document where a typo would otherwise go unnoticed.
+func LoadData(path string, data []byte, logger *slog.Logger) (*Manager, error) {
+	return LoadDataWith(path, data, "", logger)
+}
+
+// LoadDataWith is LoadData with the given profile selected (see Options).
+func LoadDataWith(path string, data []byte, profile string, logger *slog.Logger) (*Manager, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	loadDotEnv(path, logger)
+
+	m := newManager(path, Options{Profile: profile}, logger)
+
+	if err := m.decodeConfig(data, false); err != nil {
+		return nil, err
 	}
 
 	if err := m.resolve(); err != nil {
@@ -130,6 +362,23 @@ func Load(path string, logger *slog.Logger) (*Manager, error) {
 	return m, nil
 }
 
+// newManager returns an empty Manager for path with its profile chosen;
+// call it after loadDotEnv so a .env file can set the profile.
+func newManager(path string, opts Options, logger *slog.Logger) *Manager {
+	profile := opts.Profile
+	if profile == "" {
+		profile = os.Getenv(ProfileEnv)
+	}
+	if profile != "" {
+		logger.Debug("Using configuration profile", "profile", profile)
+	}
+	return &Manager{
+		path:    path,
+		log:     logger,
+		Profile: profile,
+	}
+}
+
 // loadDotEnv loads .env files if present, without overriding already-exported
 // environment variables.
 func loadDotEnv(configPath string, logger *slog.Logger) {
@@ -165,26 +414,79 @@ func (m *Manager) Raw() *Config {
 
 // resolve applies env-var overrides, defaults, and validation.
 func (m *Manager) resolve() error {
-	// --- Enterprise ---
-	m.Enterprise = envOrFallback("GITHUB_ENTERPRISE", m.cfg.GitHub.Enterprise)
-	if placeholderEnterpriseValues[m.Enterprise] {
-		if v := os.Getenv("GITHUB_ENTERPRISE"); v != "" && !placeholderEnterpriseValues[v] {
-			m.Enterprise = v
-		} else {
-			return fmt.Errorf("github enterprise must be configured (set env GITHUB_ENTERPRISE or update config github.enterprise)")
-		}
-	}
+	// Settings not given explicitly are inherited from the gh CLI.
+	gh := loadGHCLIConfig()
 
 	// --- API base URL ---
 	rawURL := envOrFallback("GITHUB_API_BASE_URL", m.cfg.GitHub.APIBaseURL)
-	if rawURL == "" {
-		rawURL = DefaultAPIBaseURL
+	hostname := strings.ToLower(strings.TrimSpace(envOrFallback("GITHUB_HOSTNAME", m.cfg.GitHub.Hostname)))
+	if hostname != "" {
+		if err := validateHostname(hostname); err != nil {
+			return err
+		}
+	}
+	switch {
+	case rawURL == "" && hostname != "":
+		rawURL = APIURLForHost(hostname)
+	case rawURL == "":
+		rawURL = APIURLForHost(gh.defaultHost())
+		if rawURL != DefaultAPIBaseURL {
+			m.log.Info("Using API host from gh CLI", "host", gh.defaultHost())
+		}
 	}
 	apiURL, err := validateAPIURL(rawURL, m.log)
 	if err != nil {
 		return err
 	}
 	m.APIBaseURL = apiURL
+	m.APIFlavor = APIFlavor(apiURL)
+	m.GHHost = HostForAPIURL(apiURL)
+	if hostname != "" && hostname != m.GHHost {
+		return fmt.Errorf("github.hostname %q does not match github.api_base_url %s (host %q); set only one of them",
+			hostname, apiURL, m.GHHost)
+	}
+
+	m.APIVersion = defaultString(envOrFallback("GITHUB_API_VERSION", m.cfg.GitHub.APIVersion), DefaultAPIVersion)
+	if _, err := time.Parse("2006-01-02", m.APIVersion); err != nil {
+		return fmt.Errorf("github.api_version must be a date like %q, got %q", DefaultAPIVersion, m.APIVersion)
+	}
+
+	m.RateLimitReserve = m.cfg.GitHub.RateLimitReserve
+	if m.RateLimitReserve == 0 {
+		m.RateLimitReserve = DefaultRateLimitReserve
+	} else if m.RateLimitReserve < 0 {
+		return fmt.Errorf("github.rate_limit_reserve must be positive, got %d", m.RateLimitReserve)
+	}
+
+	if err := m.resolveRetry(); err != nil {
+		return err
+	}
+
+	m.SharedRateLimitFile = m.cfg.GitHub.SharedRateLimit.File
+	m.SharedRateLimitBudget = m.cfg.GitHub.SharedRateLimit.PointsPerMinute
+	if m.SharedRateLimitBudget == 0 {
+		m.SharedRateLimitBudget = quota.DefaultBudget
+	} else if m.SharedRateLimitBudget < 0 {
+		return fmt.Errorf("github.shared_rate_limit.points_per_minute must be positive, got %d", m.SharedRateLimitBudget)
+	}
+
+	// --- GitHub App ---
+	if err := m.resolveGitHubApp(); err != nil {
+		return err
+	}
+
+	// --- Enterprise ---
+	m.Enterprise = envOrFallback("GITHUB_ENTERPRISE", m.cfg.GitHub.Enterprise)
+	if placeholderEnterpriseValues[m.Enterprise] {
+		if v := os.Getenv("GITHUB_ENTERPRISE"); v != "" && !placeholderEnterpriseValues[v] {
+			m.Enterprise = v
+		} else if v := gh.enterprise(m.GHHost); v != "" {
+			m.log.Debug("Using enterprise from gh CLI config", "enterprise", v)
+			m.Enterprise = v
+		} else {
+			return fmt.Errorf("github enterprise must be configured (set env GITHUB_ENTERPRISE, update config github.enterprise, or run 'gh config set -h HOST enterprise SLUG')")
+		}
+	}
 
 	// --- Organizations ---
 	m.Organizations = m.cfg.GitHub.Organizations
@@ -195,27 +497,28 @@ func (m *Manager) resolve() error {
 	// --- Cost center mode ---
 	m.CostCenterMode = defaultString(m.cfg.CostCenter.Mode, DefaultCostCenterMode)
 	if !validModes[m.CostCenterMode] {
-		return fmt.Errorf("invalid cost_center.mode %q: must be one of: users, teams, repos, custom-prop", m.CostCenterMode)
+		return fmt.Errorf("invalid cost_center.mode %q: must be one of: users, teams, repos, custom-prop, idp-groups, orgs, seat-org, rules, email-domain", m.CostCenterMode)
+	}
+
+	// --- Deleted cost center name collisions ---
+	m.DeletedCollisionPolicy = defaultString(m.cfg.CostCenter.DeletedNameCollision, DeletedCollisionFail)
+	if m.DeletedCollisionPolicy != DeletedCollisionFail && m.DeletedCollisionPolicy != DeletedCollisionSuffix {
+		return fmt.Errorf("invalid cost_center.deleted_name_collision %q: must be 'fail' or 'suffix'", m.DeletedCollisionPolicy)
+	}
+
+	// --- Apply ordering ---
+	if err := m.resolveApplyOrder(); err != nil {
+		return err
 	}
 
 	// --- Validate and resolve per-mode settings ---
-	switch m.CostCenterMode {
-	case "users":
-		if err := m.resolveUsersMode(); err != nil {
-			return err
-		}
-	case "teams":
-		if err := m.resolveTeamsMode(); err != nil {
-			return err
-		}
-	case "repos":
-		if err := m.resolveReposMode(); err != nil {
-			return err
-		}
-	case "custom-prop":
-		if err := m.resolveCustomPropMode(); err != nil {
-			return err
-		}
+	if err := m.resolveMode(m.CostCenterMode); err != nil {
+		return err
+	}
+
+	// --- Assignment sources ---
+	if err := m.resolveSources(); err != nil {
+		return err
 	}
 
 	// --- Budgets ---
@@ -228,6 +531,38 @@ func (m *Manager) resolve() error {
 			"actions": {Amount: 125, Enabled: true},
 		}
 	}
+	m.BudgetOverrides = nil
+	for key, products := range b.Overrides {
+		if m.BudgetOverrides == nil {
+			m.BudgetOverrides = make(map[string]map[string]BudgetOverride, len(b.Overrides))
+		}
+		m.BudgetOverrides[normalizeOverrideKey(key)] = products
+	}
+	m.BudgetSeatCost = b.SeatCost
+	if m.BudgetSeatCost < 0 {
+		return fmt.Errorf("budgets.seat_cost must be positive, got %v", m.BudgetSeatCost)
+	}
+
+	// --- Audit sink ---
+	if err := m.resolveAuditSink(); err != nil {
+		return err
+	}
+
+	// --- Pre-apply validator ---
+	if err := m.resolveValidation(); err != nil {
+		return err
+	}
+
+	// --- Cache buckets ---
+	if err := m.resolveCache(); err != nil {
+		return err
+	}
+
+	// --- Language ---
+	m.Language = strings.ToLower(envOrFallback("GH_COST_CENTER_LANG", m.cfg.Language))
+	if m.Language != "" && !i18n.Supported(m.Language) {
+		return fmt.Errorf("invalid language %q: must be one of %s", m.Language, strings.Join(i18n.Languages(), ", "))
+	}
 
 	// --- Logging ---
 	m.LogLevel = defaultString(m.cfg.Logging.Level, DefaultLogLevel)
@@ -236,10 +571,314 @@ func (m *Manager) resolve() error {
 	// --- Export ---
 	m.ExportDir = defaultString(m.cfg.ExportDir, DefaultExportDir)
 	m.timestampFile = filepath.Join(m.ExportDir, timestampFileName)
+	m.applyHistoryFile = filepath.Join(m.ExportDir, applyHistoryFileName)
+
+	return nil
+}
+
+// resolveGitHubApp validates github.app.  GITHUB_APP_ID,
+// GITHUB_APP_INSTALLATION_ID, GITHUB_APP_PRIVATE_KEY and
+// GITHUB_APP_PRIVATE_KEY_PATH override the YAML values.  Nothing set leaves
+// token authentication in place.
+func (m *Manager) resolveGitHubApp() error {
+	a := m.cfg.GitHub.App
+	appID := envOrFallback("GITHUB_APP_ID", a.AppID)
+	installationID := envOrFallback("GITHUB_APP_INSTALLATION_ID", a.InstallationID)
+	key := envOrFallback("GITHUB_APP_PRIVATE_KEY", a.PrivateKey)
+	keyPath := envOrFallback("GITHUB_APP_PRIVATE_KEY_PATH", a.PrivateKeyPath)
+	if appID == "" && installationID == "" && key == "" && keyPath == "" {
+		return nil
+	}
 
+	var err error
+	if m.AppID, err = strconv.ParseInt(appID, 10, 64); err != nil || m.AppID <= 0 {
+		return fmt.Errorf("invalid github.app.app_id %q: must be a positive number", appID)
+	}
+	if m.AppInstallationID, err = strconv.ParseInt(installationID, 10, 64); err != nil || m.AppInstallationID <= 0 {
+		return fmt.Errorf("invalid github.app.installation_id %q: must be a positive number", installationID)
+	}
+	switch {
+	case key != "" && keyPath != "":
+		return fmt.Errorf("github.app: set private_key or private_key_path, not both")
+	case key != "":
+		m.AppPrivateKey = []byte(key)
+	case keyPath != "":
+		if m.AppPrivateKey, err = os.ReadFile(keyPath); err != nil {
+			return fmt.Errorf("reading github.app.private_key_path: %w", err)
+		}
+	default:
+		return fmt.Errorf("github.app: private_key or private_key_path is required")
+	}
+	return nil
+}
+
+// resolveAuditSink validates audit.sink.  AUDIT_SINK_URL and
+// AUDIT_SINK_TOKEN override the YAML values.
+func (m *Manager) resolveAuditSink() error {
+	a := m.cfg.Audit.Sink
+	m.AuditSinkType = a.Type
+	m.AuditSinkURL = envOrFallback("AUDIT_SINK_URL", a.URL)
+	m.AuditSinkToken = envOrFallback("AUDIT_SINK_TOKEN", a.Token)
+	m.AuditSinkIndex = a.Index
+	m.AuditSinkSourceType = a.SourceType
+	m.AuditSinkBatchSize = a.BatchSize
+	if m.AuditSinkType == "" {
+		return nil
+	}
+	if m.AuditSinkType != "splunk_hec" && m.AuditSinkType != "http" {
+		return fmt.Errorf("invalid audit.sink.type %q: must be 'splunk_hec' or 'http'", m.AuditSinkType)
+	}
+	if m.AuditSinkURL == "" {
+		return fmt.Errorf("audit.sink.url is required when audit.sink.type is set")
+	}
+	if a.FlushInterval != "" {
+		d, err := time.ParseDuration(a.FlushInterval)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid audit.sink.flush_interval %q: must be a positive duration such as \"5s\"", a.FlushInterval)
+		}
+		m.AuditSinkFlushInterval = d
+	}
+	return nil
+}
+
+// resolveValidation validates the pre-apply validator settings.
+func (m *Manager) resolveValidation() error {
+	v := m.cfg.Validation
+	m.ValidatorCommand = nil
+	m.ValidatorTimeout = DefaultValidatorTimeout
+	if len(v.Command) == 0 {
+		return nil
+	}
+	if strings.TrimSpace(v.Command[0]) == "" {
+		return fmt.Errorf("validation.command: the program must not be empty")
+	}
+	m.ValidatorCommand = v.Command
+	if v.Timeout != "" {
+		d, err := time.ParseDuration(v.Timeout)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid validation.timeout %q: must be a positive duration such as \"60s\"", v.Timeout)
+		}
+		m.ValidatorTimeout = d
+	}
+	return nil
+}
+
+// resolveCache validates the cache bucket TTLs.  The buckets are opt-in:
+// an unset TTL leaves a bucket off.
+func (m *Manager) resolveCache() error {
+	c := m.cfg.Cache
+	m.TeamMembersTTL, m.SeatsTTL = 0, 0
+	for _, t := range []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{{"team_members_ttl", c.TeamMembersTTL, &m.TeamMembersTTL}, {"seats_ttl", c.SeatsTTL, &m.SeatsTTL}} {
+		if t.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(t.value)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid cache.%s %q: must be a duration such as \"30m\", or \"0s\" to disable", t.name, t.value)
+		}
+		*t.dest = d
+	}
+	return nil
+}
+
+// resolveRetry validates github.retry and applies its defaults.
+func (m *Manager) resolveRetry() error {
+	r := m.cfg.GitHub.Retry
+	m.MaxRetries = r.MaxRetries
+	if m.MaxRetries == 0 {
+		m.MaxRetries = DefaultMaxRetries
+	}
+	if err := ValidateMaxRetries(m.MaxRetries); err != nil {
+		return fmt.Errorf("github.retry.max_retries: %w", err)
+	}
+
+	m.BackoffBase, m.BackoffMax = DefaultBackoffBase, DefaultBackoffMax
+	for _, d := range []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{{"backoff_base", r.BackoffBase, &m.BackoffBase}, {"backoff_max", r.BackoffMax, &m.BackoffMax}} {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil || v <= 0 {
+			return fmt.Errorf("invalid github.retry.%s %q: must be a positive duration such as \"2s\"", d.name, d.value)
+		}
+		*d.dest = v
+	}
+	if m.BackoffMax < m.BackoffBase {
+		return fmt.Errorf("github.retry.backoff_max (%s) must not be shorter than backoff_base (%s)", m.BackoffMax, m.BackoffBase)
+	}
+
+	if r.Jitter < 0 || r.Jitter > 1 {
+		return fmt.Errorf("github.retry.jitter must be between 0 and 1, got %v", r.Jitter)
+	}
+	m.BackoffJitter = r.Jitter
+	return nil
+}
+
+// ValidateMaxRetries checks a max_retries value from config or --max-retries.
+func ValidateMaxRetries(n int) error {
+	if n < 1 || n > MaxMaxRetries {
+		return fmt.Errorf("must be between 1 and %d, got %d", MaxMaxRetries, n)
+	}
+	return nil
+}
+
+// resolveApplyOrder validates cost_center.apply_order and
+// apply_parallelism.  A cost center may appear in first or last, but not
+// both or twice.
+func (m *Manager) resolveApplyOrder() error {
+	o := m.cfg.CostCenter.ApplyOrder
+	seen := make(map[string]string)
+	for _, l := range []struct {
+		name    string
+		entries []string
+	}{{"first", o.First}, {"last", o.Last}} {
+		for _, e := range l.entries {
+			if strings.TrimSpace(e) == "" {
+				return fmt.Errorf("cost_center.apply_order.%s contains an empty entry", l.name)
+			}
+			if prev, ok := seen[e]; ok {
+				if prev == l.name {
+					return fmt.Errorf("cost center %q listed twice in cost_center.apply_order.%s", e, l.name)
+				}
+				return fmt.Errorf("cost center %q listed in both apply_order.%s and apply_order.%s", e, prev, l.name)
+			}
+			seen[e] = l.name
+		}
+	}
+	m.ApplyFirst = o.First
+	m.ApplyLast = o.Last
+	m.ApplyFirstMustSucceed = o.FirstMustSucceed
+
+	m.ApplyParallelism = m.cfg.CostCenter.ApplyParallelism
+	if m.ApplyParallelism == 0 {
+		m.ApplyParallelism = DefaultApplyParallelism
+	} else if m.ApplyParallelism < 0 || m.ApplyParallelism > MaxApplyParallelism {
+		return fmt.Errorf("cost_center.apply_parallelism must be between 1 and %d, got %d",
+			MaxApplyParallelism, m.ApplyParallelism)
+	}
+	return nil
+}
+
+// resolveMode validates and resolves the settings for a single mode.
+func (m *Manager) resolveMode(mode string) error {
+	switch mode {
+	case "users":
+		return m.resolveUsersMode()
+	case "teams":
+		return m.resolveTeamsMode()
+	case "repos":
+		return m.resolveReposMode()
+	case "custom-prop":
+		return m.resolveCustomPropMode()
+	case "idp-groups":
+		return m.resolveIdPGroupsMode()
+	case "orgs":
+		return m.resolveOrgsMode()
+	case "seat-org":
+		return m.resolveSeatOrgMode()
+	case "rules":
+		return m.resolveRulesMode()
+	case "email-domain":
+		return m.resolveEmailDomainMode()
+	}
+	return fmt.Errorf("invalid cost center mode %q: must be one of: users, teams, repos, custom-prop, idp-groups, orgs, seat-org, rules, email-domain", mode)
+}
+
+// ResolveModes validates and resolves the settings for each of the given
+// modes, so several modes can run in one invocation (assign --modes).  The
+// mode from cost_center.mode is already resolved by Load and is skipped.
+func (m *Manager) ResolveModes(modes []string) error {
+	seen := make(map[string]bool)
+	for _, mode := range modes {
+		if seen[mode] {
+			return fmt.Errorf("mode %q listed more than once", mode)
+		}
+		seen[mode] = true
+		if mode == m.CostCenterMode {
+			continue
+		}
+		if err := m.resolveMode(mode); err != nil {
+			return fmt.Errorf("resolving %s mode: %w", mode, err)
+		}
+	}
+	return nil
+}
+
+// resolveSources validates cost_center.sources, loads the overrides file
+// and resolves the settings of the modes the sources draw on.
+func (m *Manager) resolveSources() error {
+	c := m.cfg.CostCenter
+	m.OverridesFile = c.OverridesFile
+	if len(c.Sources) == 0 {
+		if c.OverridesFile == "" {
+			return nil
+		}
+		// Without the overrides source the file only settles the cost
+		// center of users in several teams (teams mode), and may not
+		// exist until the first choice is recorded.
+		overrides, err := loadOverrides(c.OverridesFile)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("loading cost_center.overrides_file: %w", err)
+		}
+		m.Overrides = overrides
+		m.log.Debug("Loaded multi-team overrides", "path", c.OverridesFile, "users", len(overrides))
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var modes []string
+	for _, src := range c.Sources {
+		if seen[src] {
+			return fmt.Errorf("source %q listed more than once in cost_center.sources", src)
+		}
+		seen[src] = true
+		switch src {
+		case sources.Overrides:
+			if c.OverridesFile == "" {
+				return fmt.Errorf("cost_center.sources entry %q requires cost_center.overrides_file to be set", src)
+			}
+			overrides, err := loadOverrides(c.OverridesFile)
+			if err != nil {
+				return fmt.Errorf("loading cost_center.overrides_file: %w", err)
+			}
+			m.Overrides = overrides
+			m.log.Info("Loaded assignment overrides", "path", c.OverridesFile, "users", len(overrides))
+		case sources.Teams, sources.Users, sources.IdPGroups:
+			modes = append(modes, SourceMode(src))
+		default:
+			return fmt.Errorf("invalid cost_center.sources entry %q: must be one of: %s, %s, %s, %s",
+				src, sources.Overrides, sources.Teams, sources.IdPGroups, sources.Users)
+		}
+	}
+	if err := m.ResolveModes(modes); err != nil {
+		return fmt.Errorf("cost_center.sources: %w", err)
+	}
+
+	m.Sources = c.Sources
+	m.log.Info("Assignment sources enabled", "priority", strings.Join(m.Sources, " > "))
 	return nil
 }
 
+// SourceMode returns the cost center mode whose settings an assignment
+// source draws on, or "" for a source without one (overrides).
+func SourceMode(src string) string {
+	switch src {
+	case sources.Teams, sources.Users:
+		return src
+	case sources.IdPGroups:
+		return "idp-groups"
+	}
+	return ""
+}
+
 // resolveUsersMode resolves PRU-based (users) mode settings.
 func (m *Manager) resolveUsersMode() error {
 	u := m.cfg.CostCenter.Users
@@ -253,13 +892,49 @@ func (m *Manager) resolveUsersMode() error {
 	if m.PRUsExceptionUsers == nil {
 		m.PRUsExceptionUsers = []string{}
 	}
+	for _, entry := range m.PRUsExceptionUsers {
+		if _, err := ParseLoginPattern(entry); err != nil {
+			return fmt.Errorf("cost_center.users.exception_users: %w", err)
+		}
+	}
 
 	m.AutoCreate = u.AutoCreate
 	m.EnableIncremental = u.EnableIncremental
 
+	sc := u.ServerConnected
+	m.ServerLoginSuffixes = nil
+	for _, suffix := range sc.LoginSuffixes {
+		if suffix = strings.TrimSpace(suffix); suffix != "" {
+			m.ServerLoginSuffixes = append(m.ServerLoginSuffixes, suffix)
+		}
+	}
+	m.ServerUsersHandling = defaultString(sc.Handling, ServerUsersAssign)
+	if m.ServerUsersHandling != ServerUsersAssign && m.ServerUsersHandling != ServerUsersSegregate {
+		return fmt.Errorf("cost_center.users.server_connected.handling must be %q or %q, got %q",
+			ServerUsersAssign, ServerUsersSegregate, m.ServerUsersHandling)
+	}
+	if m.ServerUsersHandling == ServerUsersSegregate && len(m.ServerLoginSuffixes) == 0 {
+		return fmt.Errorf("cost_center.users.server_connected.handling %q requires login_suffixes", ServerUsersSegregate)
+	}
+	m.ServerCostCenterName = defaultString(sc.CostCenterName, DefaultServerCCName)
+	m.ServerCostCenterID = sc.CostCenterID
+
+	pc := u.PendingCancellation
+	m.PendingCancellationHandling = defaultString(pc.Handling, PendingCancellationAssign)
+	switch m.PendingCancellationHandling {
+	case PendingCancellationAssign, PendingCancellationSkip, PendingCancellationWindDown:
+	default:
+		return fmt.Errorf("cost_center.users.pending_cancellation.handling must be %q, %q or %q, got %q",
+			PendingCancellationAssign, PendingCancellationSkip, PendingCancellationWindDown, m.PendingCancellationHandling)
+	}
+	m.WindDownCostCenterName = defaultString(pc.CostCenterName, DefaultWindDownCCName)
+	m.WindDownCostCenterID = pc.CostCenterID
+
 	m.log.Info("Users (PRU) mode enabled",
 		"exception_users", len(m.PRUsExceptionUsers),
-		"auto_create", m.AutoCreate)
+		"auto_create", m.AutoCreate,
+		"server_connected_handling", m.ServerUsersHandling,
+		"pending_cancellation_handling", m.PendingCancellationHandling)
 	return nil
 }
 
@@ -272,20 +947,137 @@ func (m *Manager) resolveTeamsMode() error {
 	m.TeamsAutoCreate = t.AutoCreate
 	m.TeamsRemoveUnmatchedUsers = t.RemoveUnmatchedUsers
 
-	m.TeamsMappings = t.Mappings
-	if m.TeamsMappings == nil {
-		m.TeamsMappings = map[string]string{}
+	m.TeamsMappings = make(map[string]string, len(t.Mappings))
+	for team, cc := range t.Mappings {
+		ref, err := CostCenterRef(cc)
+		if err != nil {
+			return fmt.Errorf("cost_center.teams.mappings[%s]: %w", team, err)
+		}
+		m.TeamsMappings[team] = ref
+	}
+	m.TeamMappingsFile = t.TeamMappingsFile
+	if t.TeamMappingsFile != "" {
+		fromFile, err := loadTeamMappings(t.TeamMappingsFile)
+		if err != nil {
+			return fmt.Errorf("loading cost_center.teams.team_mappings_file: %w", err)
+		}
+		// Teams are compared by key ignoring case, as GitHub does, and
+		// cost centers once id: references are normalized.
+		inline := make(map[string]string, len(m.TeamsMappings)) // folded key -> key
+		for team := range m.TeamsMappings {
+			inline[strings.ToLower(team)] = team
+		}
+		var conflicts []string
+		for team, cc := range fromFile {
+			ref, err := CostCenterRef(cc)
+			if err != nil {
+				return fmt.Errorf("cost_center.teams.team_mappings_file[%s]: %w", team, err)
+			}
+			if key, ok := inline[strings.ToLower(team)]; ok {
+				if m.TeamsMappings[key] != ref {
+					conflicts = append(conflicts, fmt.Sprintf("%s (%q in the config, %q in the file)", key, t.Mappings[key], cc))
+				}
+				continue
+			}
+			m.TeamsMappings[team] = ref
+		}
+		if len(conflicts) > 0 {
+			sort.Strings(conflicts)
+			return fmt.Errorf("cost_center.teams.team_mappings_file %s maps teams differently from cost_center.teams.mappings: %s",
+				t.TeamMappingsFile, strings.Join(conflicts, "; "))
+		}
+		m.log.Info("Loaded team mappings", "path", t.TeamMappingsFile, "mappings", len(fromFile))
+	}
+
+	m.TeamsSplits = t.Splits
+	if m.TeamsSplits == nil {
+		m.TeamsSplits = map[string]map[string]string{}
+	}
+	for team, split := range m.TeamsSplits {
+		for attr, cc := range split {
+			ref, err := CostCenterRef(cc)
+			if err != nil {
+				return fmt.Errorf("cost_center.teams.splits[%s][%s]: %w", team, attr, err)
+			}
+			split[attr] = ref
+		}
+	}
+	if len(m.TeamsSplits) > 0 {
+		if t.MemberAttributesFile == "" {
+			return fmt.Errorf("cost_center.teams.splits requires cost_center.teams.member_attributes_file to be set")
+		}
+		attrs, err := loadMemberAttributes(t.MemberAttributesFile)
+		if err != nil {
+			return fmt.Errorf("loading cost_center.teams.member_attributes_file: %w", err)
+		}
+		m.TeamsMemberAttributes = attrs
+		m.log.Info("Loaded member attributes", "path", t.MemberAttributesFile, "members", len(attrs))
+	}
+
+	m.TeamsConflictStrategy = defaultString(t.ConflictStrategy, ConflictAlphabetical)
+	switch m.TeamsConflictStrategy {
+	case ConflictAlphabetical, ConflictSmallest, ConflictLargest, ConflictFail:
+	case ConflictPriority:
+		if len(t.TeamPriority) == 0 {
+			return fmt.Errorf("cost_center.teams.conflict_strategy %q requires cost_center.teams.team_priority", ConflictPriority)
+		}
+	default:
+		return fmt.Errorf("invalid cost_center.teams.conflict_strategy %q: must be %q, %q, %q, %q or %q",
+			m.TeamsConflictStrategy, ConflictPriority, ConflictAlphabetical, ConflictSmallest, ConflictLargest, ConflictFail)
+	}
+	m.TeamsPriority = nil
+	for i, team := range t.TeamPriority {
+		if team = strings.TrimSpace(team); team == "" {
+			return fmt.Errorf("cost_center.teams.team_priority[%d] is empty", i)
+		}
+		m.TeamsPriority = append(m.TeamsPriority, team)
+	}
+
+	m.TeamsDeletedTeams = defaultString(strings.TrimSpace(t.DeletedTeams), DeletedTeamsWarn)
+	switch m.TeamsDeletedTeams {
+	case DeletedTeamsWarn, DeletedTeamsEmpty, DeletedTeamsDelete:
+	default:
+		return fmt.Errorf("invalid cost_center.teams.deleted_teams %q: must be %q, %q or %q",
+			m.TeamsDeletedTeams, DeletedTeamsWarn, DeletedTeamsEmpty, DeletedTeamsDelete)
 	}
 
-	// Validate: organization scope requires organizations
-	if m.TeamsScope == "organization" && len(m.Organizations) == 0 {
-		return fmt.Errorf("teams mode with scope 'organization' requires github.organizations to be configured")
+	// Validate: organization scope, and the organization fallback of auto
+	// scope, require organizations
+	switch m.TeamsScope {
+	case "enterprise":
+	case "organization", "auto":
+		if len(m.Organizations) == 0 {
+			return fmt.Errorf("teams mode with scope '%s' requires github.organizations to be configured", m.TeamsScope)
+		}
+	default:
+		return fmt.Errorf("invalid cost_center.teams.scope %q: must be 'enterprise', 'organization' or 'auto'", m.TeamsScope)
 	}
 
 	if m.TeamsStrategy != "auto" && m.TeamsStrategy != "manual" {
 		return fmt.Errorf("invalid cost_center.teams.strategy %q: must be 'auto' or 'manual'", m.TeamsStrategy)
 	}
 
+	m.TeamsInclude = t.Include
+	m.TeamsExclude = t.Exclude
+	if _, err := m.TeamFilter(); err != nil {
+		return err
+	}
+
+	m.TeamsDescriptionKey = strings.TrimSpace(t.DescriptionKey)
+	if strings.ContainsAny(m.TeamsDescriptionKey, " \t:=\"'") {
+		return fmt.Errorf("invalid cost_center.teams.description_key %q: must not contain spaces, quotes, ':' or '='", m.TeamsDescriptionKey)
+	}
+
+	m.TeamsNameTemplate = strings.TrimSpace(t.NameTemplate)
+	if m.TeamsNameTemplate != "" {
+		if _, err := ParseTeamNameTemplate(m.TeamsNameTemplate); err != nil {
+			return fmt.Errorf("invalid cost_center.teams.name_template: %w", err)
+		}
+		if m.TeamsStrategy != "auto" {
+			m.log.Warn("cost_center.teams.name_template only applies to the auto strategy", "strategy", m.TeamsStrategy)
+		}
+	}
+
 	// Warn about mapping values that don't look like UUIDs when auto-create
 	// is disabled. These will be resolved by name at runtime, but a mismatch
 	// will cause a failure.
@@ -302,7 +1094,8 @@ func (m *Manager) resolveTeamsMode() error {
 	m.log.Info("Teams mode enabled",
 		"scope", m.TeamsScope,
 		"strategy", m.TeamsStrategy,
-		"auto_create", m.TeamsAutoCreate)
+		"auto_create", m.TeamsAutoCreate,
+		"conflict_strategy", m.TeamsConflictStrategy)
 	return nil
 }
 
@@ -322,7 +1115,10 @@ func (m *Manager) resolveReposMode() error {
 	}
 
 	m.ReposMappings = r.Mappings
-	m.log.Info("Repos mode enabled", "mappings", len(r.Mappings))
+	m.ReposRemoveNoLongerMatching = r.RemoveReposNoLongerMatching
+	m.log.Info("Repos mode enabled",
+		"mappings", len(r.Mappings),
+		"remove_repos_no_longer_matching", r.RemoveReposNoLongerMatching)
 	return nil
 }
 
@@ -346,6 +1142,328 @@ func (m *Manager) resolveCustomPropMode() error {
 	return nil
 }
 
+// resolveIdPGroupsMode resolves identity provider group (idp-groups) mode
+// settings.
+func (m *Manager) resolveIdPGroupsMode() error {
+	if len(m.Organizations) == 0 {
+		return fmt.Errorf("idp-groups mode requires github.organizations to be configured")
+	}
+
+	g := m.cfg.CostCenter.IdPGroups
+	if len(g.Mappings) == 0 {
+		return fmt.Errorf("idp-groups mode requires at least one entry in cost_center.idp_groups.mappings")
+	}
+	seen := make(map[string]string, len(g.Mappings))
+	for group, cc := range g.Mappings {
+		if strings.TrimSpace(group) == "" || strings.TrimSpace(cc) == "" {
+			return fmt.Errorf("cost_center.idp_groups.mappings: group names and cost centers must be non-empty")
+		}
+		key := strings.ToLower(group)
+		if prev, ok := seen[key]; ok {
+			return fmt.Errorf("cost_center.idp_groups.mappings: groups %q and %q differ only in case", prev, group)
+		}
+		seen[key] = group
+	}
+
+	m.IdPGroupsAutoCreate = g.AutoCreate
+	m.IdPGroupsMappings = g.Mappings
+	m.log.Info("IdP groups mode enabled", "mappings", len(g.Mappings), "organizations", len(m.Organizations))
+	return nil
+}
+
+// resolveOrgsMode resolves organization-membership (orgs) mode settings.
+func (m *Manager) resolveOrgsMode() error {
+	if len(m.Organizations) == 0 {
+		return fmt.Errorf("orgs mode requires github.organizations to be configured")
+	}
+
+	o := m.cfg.CostCenter.Orgs
+	for org, cc := range o.Mappings {
+		if !slices.Contains(m.Organizations, org) {
+			return fmt.Errorf("cost_center.orgs.mappings: organization %q is not in github.organizations", org)
+		}
+		if strings.TrimSpace(cc) == "" {
+			return fmt.Errorf("cost_center.orgs.mappings: organization %q maps to an empty cost center", org)
+		}
+	}
+
+	m.OrgsAutoCreate = o.AutoCreate
+	m.OrgsRemoveUnmatchedUsers = o.RemoveUnmatchedUsers
+	m.OrgsMappings = o.Mappings
+	m.log.Info("Orgs mode enabled", "organizations", len(m.Organizations), "mappings", len(o.Mappings))
+	return nil
+}
+
+// OrgCostCenter returns the cost center of an organization in orgs mode:
+// its cost_center.orgs.mappings entry, or "[org] <org>".
+func (m *Manager) OrgCostCenter(org string) string {
+	if cc, ok := m.OrgsMappings[org]; ok {
+		return cc
+	}
+	return "[org] " + org
+}
+
+// resolveSeatOrgMode resolves seat-org mode settings.
+func (m *Manager) resolveSeatOrgMode() error {
+	s := m.cfg.CostCenter.SeatOrg
+	for key, cc := range s.Mappings {
+		org, team, hasTeam := strings.Cut(key, "/")
+		if org == "" || (hasTeam && (team == "" || strings.Contains(team, "/"))) {
+			return fmt.Errorf("cost_center.seat_org.mappings: key %q must be \"org\" or \"org/team-slug\"", key)
+		}
+		if strings.TrimSpace(cc) == "" {
+			return fmt.Errorf("cost_center.seat_org.mappings: %q maps to an empty cost center", key)
+		}
+	}
+
+	m.SeatOrgAutoCreate = s.AutoCreate
+	m.SeatOrgMappings = s.Mappings
+	m.log.Info("Seat-org mode enabled", "mappings", len(s.Mappings))
+	return nil
+}
+
+// resolveEmailDomainMode resolves email-domain mode settings.  Keys are
+// lower-cased and a leading "@" is dropped.
+func (m *Manager) resolveEmailDomainMode() error {
+	e := m.cfg.CostCenter.EmailDomain
+	if len(e.Mappings) == 0 {
+		return fmt.Errorf("email-domain mode requires cost_center.email_domain.mappings")
+	}
+	mappings := make(map[string]string, len(e.Mappings))
+	for key, cc := range e.Mappings {
+		norm := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(key), "@"))
+		if norm == "" {
+			return fmt.Errorf("cost_center.email_domain.mappings: empty domain")
+		}
+		if strings.TrimSpace(cc) == "" {
+			return fmt.Errorf("cost_center.email_domain.mappings: %q maps to an empty cost center", key)
+		}
+		if _, dup := mappings[norm]; dup {
+			return fmt.Errorf("cost_center.email_domain.mappings: %q is listed more than once", norm)
+		}
+		mappings[norm] = cc
+	}
+
+	m.EmailDomainAutoCreate = e.AutoCreate
+	m.EmailDomainMappings = mappings
+	m.EmailDomainDefault = e.DefaultCostCenter
+	m.log.Info("Email-domain mode enabled", "mappings", len(mappings))
+	return nil
+}
+
+// resolveRulesMode resolves rules mode settings.  A default of the PRU
+// rules also resolves the users mode settings.
+func (m *Manager) resolveRulesMode() error {
+	r := m.cfg.CostCenter.Rules
+	if len(r.List) == 0 {
+		return fmt.Errorf("rules mode requires at least one entry in cost_center.rules.list")
+	}
+	readsTeams := false
+	for i, rule := range r.List {
+		if err := validateAssignmentRule(rule); err != nil {
+			return fmt.Errorf("cost_center.rules.list[%d] %s: %w", i, ruleLabel(rule, i), err)
+		}
+		if ruleReads(rule, RuleVarOrgs) && len(m.Organizations) == 0 {
+			return fmt.Errorf("cost_center.rules.list[%d] %s: orgs conditions require github.organizations", i, ruleLabel(rule, i))
+		}
+		readsTeams = readsTeams || ruleReads(rule, RuleVarTeams)
+	}
+	if readsTeams {
+		// Team conditions see the teams of the teams mode scope and
+		// filters.
+		t := m.cfg.CostCenter.Teams
+		m.TeamsScope = defaultString(t.Scope, DefaultTeamsScope)
+		m.TeamsInclude = t.Include
+		m.TeamsExclude = t.Exclude
+		if _, err := m.TeamFilter(); err != nil {
+			return err
+		}
+		switch m.TeamsScope {
+		case "enterprise":
+		case "organization", "auto":
+			if len(m.Organizations) == 0 {
+				return fmt.Errorf("rules teams conditions with cost_center.teams.scope '%s' require github.organizations", m.TeamsScope)
+			}
+		default:
+			return fmt.Errorf("invalid cost_center.teams.scope %q: must be 'enterprise', 'organization' or 'auto'", m.TeamsScope)
+		}
+	}
+	if r.Default.PRU && r.Default.CostCenter != "" {
+		return fmt.Errorf("cost_center.rules.default: set cost_center or pru, not both")
+	}
+	if r.Default.PRU {
+		if err := m.resolveUsersMode(); err != nil {
+			return fmt.Errorf("cost_center.rules.default.pru: %w", err)
+		}
+	}
+
+	m.RulesAutoCreate = r.AutoCreate
+	m.Rules = r.List
+	m.RulesDefault = r.Default
+	m.log.Info("Rules mode enabled", "rules", len(r.List), "default_pru", r.Default.PRU, "default_cost_center", r.Default.CostCenter)
+	return nil
+}
+
+// ruleLabel names a rule in error messages: its name, or its position.
+func ruleLabel(rule AssignmentRule, i int) string {
+	if rule.Name != "" {
+		return fmt.Sprintf("(%q)", rule.Name)
+	}
+	return fmt.Sprintf("(#%d)", i+1)
+}
+
+// rulePlaceholder matches the {name} placeholders of a rule's cost center.
+var rulePlaceholder = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// validateAssignmentRule checks the patterns, inactivity and cost center
+// placeholders of a rule.
+func validateAssignmentRule(rule AssignmentRule) error {
+	for _, l := range rule.Logins {
+		if _, err := ParseLoginPattern(l); err != nil {
+			return fmt.Errorf("logins: %w", err)
+		}
+	}
+	for _, g := range append(append([]string(nil), rule.Teams...), rule.Orgs...) {
+		if strings.TrimSpace(g) == "" {
+			return fmt.Errorf("teams and orgs entries must be non-empty")
+		}
+		if _, err := path.Match(strings.ToLower(g), ""); err != nil {
+			return fmt.Errorf("invalid glob %q: %w", g, err)
+		}
+	}
+	for name, globs := range rule.Attributes {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("attributes: empty attribute name")
+		}
+		if len(globs) == 0 {
+			return fmt.Errorf("attributes: %q lists no values", name)
+		}
+		for _, g := range globs {
+			if _, err := path.Match(strings.ToLower(g), ""); err != nil {
+				return fmt.Errorf("attributes: invalid glob %q: %w", g, err)
+			}
+		}
+	}
+	if rule.InactiveDays < 0 {
+		return fmt.Errorf("inactive_days must not be negative, got %d", rule.InactiveDays)
+	}
+	if rule.When != "" {
+		if _, err := ParseRuleCondition(rule.When); err != nil {
+			return fmt.Errorf("when: %w", err)
+		}
+	}
+	if strings.TrimSpace(rule.CostCenter) == "" {
+		return fmt.Errorf("cost_center is required")
+	}
+	for _, ph := range rulePlaceholder.FindAllStringSubmatch(rule.CostCenter, -1) {
+		switch ph[1] {
+		case "login":
+		case "org":
+			if len(rule.Orgs) == 0 && len(rule.Teams) == 0 {
+				return fmt.Errorf("cost_center placeholder {org} needs an orgs or teams condition")
+			}
+		case "team":
+			if len(rule.Teams) == 0 {
+				return fmt.Errorf("cost_center placeholder {team} needs a teams condition")
+			}
+		default:
+			if name, ok := strings.CutPrefix(ph[1], "attr."); ok {
+				if !hasAttributeCondition(rule, name) {
+					return fmt.Errorf("cost_center placeholder {%s} needs an attributes condition on %q", ph[1], name)
+				}
+				continue
+			}
+			return fmt.Errorf("unknown cost_center placeholder {%s}: use {login}, {org}, {team} or {attr.NAME}", ph[1])
+		}
+	}
+	return nil
+}
+
+// ruleReads reports whether rule looks at the rule variable name, through
+// its list conditions or its when expression.
+func ruleReads(rule AssignmentRule, name string) bool {
+	switch name {
+	case RuleVarOrgs:
+		if len(rule.Orgs) > 0 {
+			return true
+		}
+	case RuleVarTeams:
+		if len(rule.Teams) > 0 {
+			return true
+		}
+	}
+	if rule.When == "" {
+		return false
+	}
+	c, err := ParseRuleCondition(rule.When)
+	return err == nil && c.Reads(name)
+}
+
+// hasAttributeCondition reports whether rule has an attributes condition
+// on name, ignoring case.
+func hasAttributeCondition(rule AssignmentRule, name string) bool {
+	for n := range rule.Attributes {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// SeatCostCenter returns the cost center of a Copilot seat granted by org,
+// through team when not empty, in seat-org mode: the "org/team" mapping,
+// else the "org" mapping, else "[org] <org>".
+func (m *Manager) SeatCostCenter(org, team string) string {
+	if team != "" {
+		if cc, ok := m.SeatOrgMappings[org+"/"+team]; ok {
+			return cc
+		}
+	}
+	if cc, ok := m.SeatOrgMappings[org]; ok {
+		return cc
+	}
+	return "[org] " + org
+}
+
+// EmailDomainCostCenter returns the cost center of the first of an
+// identity's values (NameID, user name, emails) that a mapping key matches
+// in email-domain mode.  A key matches a value equal to it or ending in it
+// after "@", "." or ",", so "acme.com" covers "jo@eu.acme.com" and
+// "ou=x,dc=acme,dc=com" covers "cn=jo,ou=x,dc=acme,dc=com"; the longest
+// matching key wins.  Otherwise the default is returned; ok is false when
+// there is none.
+func (m *Manager) EmailDomainCostCenter(values []string) (costCenter string, ok bool) {
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		best := ""
+		for key := range m.EmailDomainMappings {
+			if len(key) > len(best) && identityHasSuffix(v, key) {
+				best = key
+			}
+		}
+		if best != "" {
+			return m.EmailDomainMappings[best], true
+		}
+	}
+	return m.EmailDomainDefault, m.EmailDomainDefault != ""
+}
+
+// identityHasSuffix reports whether v is key or ends in key after a
+// separator.
+func identityHasSuffix(v, key string) bool {
+	if v == key {
+		return true
+	}
+	if !strings.HasSuffix(v, key) || len(v) == len(key) {
+		return false
+	}
+	switch v[len(v)-len(key)-1] {
+	case '@', '.', ',':
+		return true
+	}
+	return false
+}
+
 // EnableAutoCreation turns on auto-creation mode at runtime (--create-cost-centers).
 func (m *Manager) EnableAutoCreation() {
 	m.AutoCreate = true
@@ -377,23 +1495,12 @@ func (m *Manager) SaveLastRunTimestamp(t *time.Time) error {
 		t = &now
 	}
 
-	dir := filepath.Dir(m.timestampFile)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return fmt.Errorf("creating export directory: %w", err)
-	}
-
 	td := timestampData{
 		LastRun: t.UTC().Format(time.RFC3339),
 		SavedAt: now.Format(time.RFC3339),
 	}
-
-	data, err := json.MarshalIndent(td, "", "  ")
-	if err != nil {
-		return fmt.Errorf("marshalling timestamp: %w", err)
-	}
-
-	if err := os.WriteFile(m.timestampFile, data, 0o644); err != nil {
-		return fmt.Errorf("writing timestamp file: %w", err)
+	if err := state.SaveFile(m.timestampFile, "timestamp file", td); err != nil {
+		return err
 	}
 
 	m.log.Info("Saved last run timestamp", "timestamp", td.LastRun)
@@ -431,16 +1538,293 @@ func (m *Manager) LoadLastRunTimestamp() (*time.Time, error) {
 	return &t, nil
 }
 
+// applyHistory represents the JSON stored in the apply history file: the
+// time of the first apply against each enterprise.
+type applyHistory struct {
+	FirstApply map[string]string `json:"first_apply"`
+}
+
+// HasAppliedBefore reports whether an apply against the configured
+// enterprise has been recorded in the export dir.
+func (m *Manager) HasAppliedBefore() (bool, error) {
+	h, err := m.loadApplyHistory()
+	if err != nil {
+		return false, err
+	}
+	_, ok := h.FirstApply[m.Enterprise]
+	return ok, nil
+}
+
+// RecordApply records that an apply ran against the configured enterprise.
+// The first recorded time is kept.
+func (m *Manager) RecordApply() error {
+	h, err := m.loadApplyHistory()
+	if err != nil {
+		return err
+	}
+	if _, ok := h.FirstApply[m.Enterprise]; ok {
+		return nil
+	}
+	h.FirstApply[m.Enterprise] = time.Now().UTC().Format(time.RFC3339)
+
+	if err := state.SaveFile(m.applyHistoryFile, "apply history file", h); err != nil {
+		return err
+	}
+	m.log.Debug("Recorded first apply", "enterprise", m.Enterprise)
+	return nil
+}
+
+// loadApplyHistory reads the apply history file.  A missing file yields an
+// empty history.
+func (m *Manager) loadApplyHistory() (*applyHistory, error) {
+	h := &applyHistory{}
+	if err := state.LoadFile(m.applyHistoryFile, "apply history file", h); err != nil {
+		return nil, err
+	}
+	if h.FirstApply == nil {
+		h.FirstApply = make(map[string]string)
+	}
+	return h, nil
+}
+
+// teamNames represents the JSON stored in the team names file: per
+// enterprise, the cost center name given to each team whose auto-generated
+// name collided with another team's, and the cost center name of each auto
+// strategy team by team ID, which survives team renames.
+type teamNames struct {
+	Enterprises map[string]map[string]string `json:"enterprises"`
+	TeamIDs     map[string]map[string]string `json:"team_ids,omitempty"`
+}
+
+// TeamCostCenterNames returns the recorded team key -> cost center name
+// assignments for the configured enterprise.  A missing file yields an empty
+// map.
+func (m *Manager) TeamCostCenterNames() (map[string]string, error) {
+	t, err := m.loadTeamNames()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(t.Enterprises[m.Enterprise]))
+	for k, v := range t.Enterprises[m.Enterprise] {
+		names[k] = v
+	}
+	return names, nil
+}
+
+// RecordTeamCostCenterNames replaces the recorded team cost center names for
+// the configured enterprise.
+func (m *Manager) RecordTeamCostCenterNames(names map[string]string) error {
+	t, err := m.loadTeamNames()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		if _, ok := t.Enterprises[m.Enterprise]; !ok {
+			return nil
+		}
+		delete(t.Enterprises, m.Enterprise)
+	} else {
+		t.Enterprises[m.Enterprise] = names
+	}
+
+	return m.saveTeamNames(t)
+}
+
+// TeamIDCostCenterNames returns the recorded team ID -> cost center name
+// assignments for the configured enterprise.  A missing file yields an
+// empty map.
+func (m *Manager) TeamIDCostCenterNames() (map[string]string, error) {
+	t, err := m.loadTeamNames()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(t.TeamIDs[m.Enterprise]))
+	for k, v := range t.TeamIDs[m.Enterprise] {
+		names[k] = v
+	}
+	return names, nil
+}
+
+// RecordTeamIDCostCenterNames replaces the recorded team ID cost center
+// names for the configured enterprise.
+func (m *Manager) RecordTeamIDCostCenterNames(names map[string]string) error {
+	t, err := m.loadTeamNames()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		if _, ok := t.TeamIDs[m.Enterprise]; !ok {
+			return nil
+		}
+		delete(t.TeamIDs, m.Enterprise)
+	} else {
+		if t.TeamIDs == nil {
+			t.TeamIDs = make(map[string]map[string]string)
+		}
+		t.TeamIDs[m.Enterprise] = names
+	}
+	return m.saveTeamNames(t)
+}
+
+// saveTeamNames writes the team names file.
+func (m *Manager) saveTeamNames(t *teamNames) error {
+	return state.SaveFile(filepath.Join(m.ExportDir, teamNamesFileName), "team names file", t)
+}
+
+// loadTeamNames reads the team names file.  A missing file yields an empty
+// record.
+func (m *Manager) loadTeamNames() (*teamNames, error) {
+	t := &teamNames{}
+	if err := state.LoadFile(filepath.Join(m.ExportDir, teamNamesFileName), "team names file", t); err != nil {
+		return nil, err
+	}
+	if t.Enterprises == nil {
+		t.Enterprises = make(map[string]map[string]string)
+	}
+	return t, nil
+}
+
+// TeamMembership is the recorded member list of one team: the ETag of
+// each page of its member listing when it was fetched (see teams.Manager),
+// a hash of its sorted member logins, and the logins themselves.
+type TeamMembership struct {
+	ETags     []string  `json:"etags,omitempty"`
+	Hash      string    `json:"hash"`
+	Members   []string  `json:"members"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// teamMemberships represents the JSON stored in the team memberships file:
+// enterprise -> team key -> membership.
+type teamMemberships struct {
+	Enterprises map[string]map[string]TeamMembership `json:"enterprises"`
+}
+
+// TeamMemberships returns the recorded team memberships of the configured
+// enterprise, keyed by team key.  A missing file yields an empty map.
+func (m *Manager) TeamMemberships() (map[string]TeamMembership, error) {
+	t, err := m.loadTeamMemberships()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]TeamMembership, len(t.Enterprises[m.Enterprise]))
+	for k, v := range t.Enterprises[m.Enterprise] {
+		out[k] = v
+	}
+	return out, nil
+}
+
+// RecordTeamMemberships replaces the recorded team memberships of the
+// configured enterprise.
+func (m *Manager) RecordTeamMemberships(memberships map[string]TeamMembership) error {
+	t, err := m.loadTeamMemberships()
+	if err != nil {
+		return err
+	}
+	t.Enterprises[m.Enterprise] = memberships
+	return state.SaveFile(filepath.Join(m.ExportDir, teamMembershipsFile), "team memberships file", t)
+}
+
+// loadTeamMemberships reads the team memberships file.  A missing file
+// yields an empty record.
+func (m *Manager) loadTeamMemberships() (*teamMemberships, error) {
+	t := &teamMemberships{}
+	if err := state.LoadFile(filepath.Join(m.ExportDir, teamMembershipsFile), "team memberships file", t); err != nil {
+		return nil, err
+	}
+	if t.Enterprises == nil {
+		t.Enterprises = make(map[string]map[string]TeamMembership)
+	}
+	return t, nil
+}
+
+// createdCostCenters represents the JSON stored in the created cost centers
+// file: enterprise -> cost center ID -> creation time (RFC 3339).
+type createdCostCenters struct {
+	Enterprises map[string]map[string]string `json:"enterprises"`
+}
+
+// CostCenterCreationTimes returns the recorded creation time of every cost
+// center this tool created in the configured enterprise, keyed by ID.  A
+// missing file yields an empty map.
+func (m *Manager) CostCenterCreationTimes() (map[string]time.Time, error) {
+	c, err := m.loadCreatedCostCenters()
+	if err != nil {
+		return nil, err
+	}
+	times := make(map[string]time.Time, len(c.Enterprises[m.Enterprise]))
+	for id, ts := range c.Enterprises[m.Enterprise] {
+		t, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			return nil, fmt.Errorf("parsing creation time of cost center %s: %w", id, err)
+		}
+		times[id] = t
+	}
+	return times, nil
+}
+
+// RecordCostCenterCreated records that the cost center with the given ID was
+// created now in the configured enterprise.
+func (m *Manager) RecordCostCenterCreated(id string) error {
+	c, err := m.loadCreatedCostCenters()
+	if err != nil {
+		return err
+	}
+	if c.Enterprises[m.Enterprise] == nil {
+		c.Enterprises[m.Enterprise] = make(map[string]string)
+	}
+	c.Enterprises[m.Enterprise][id] = time.Now().UTC().Format(time.RFC3339)
+	return state.SaveFile(filepath.Join(m.ExportDir, createdCCsFileName), "created cost centers file", c)
+}
+
+// loadCreatedCostCenters reads the created cost centers file.  A missing
+// file yields an empty record.
+func (m *Manager) loadCreatedCostCenters() (*createdCostCenters, error) {
+	c := &createdCostCenters{}
+	if err := state.LoadFile(filepath.Join(m.ExportDir, createdCCsFileName), "created cost centers file", c); err != nil {
+		return nil, err
+	}
+	if c.Enterprises == nil {
+		c.Enterprises = make(map[string]map[string]string)
+	}
+	return c, nil
+}
+
 // Summary returns a human-readable map of current configuration for display.
 func (m *Manager) Summary() map[string]any {
 	s := map[string]any{
-		"enterprise":       m.Enterprise,
-		"api_base_url":     m.APIBaseURL,
-		"organizations":    m.Organizations,
-		"cost_center_mode": m.CostCenterMode,
-		"budgets_enabled":  m.BudgetsEnabled,
-		"log_level":        m.LogLevel,
-		"export_dir":       m.ExportDir,
+		"enterprise":             m.Enterprise,
+		"api_base_url":           m.APIBaseURL,
+		"api_flavor":             m.APIFlavor,
+		"api_version":            m.APIVersion,
+		"organizations":          m.Organizations,
+		"cost_center_mode":       m.CostCenterMode,
+		"deleted_name_collision": m.DeletedCollisionPolicy,
+		"apply_parallelism":      m.ApplyParallelism,
+		"rate_limit_reserve":     m.RateLimitReserve,
+		"max_retries":            m.MaxRetries,
+		"backoff":                fmt.Sprintf("%s..%s, jitter %v", m.BackoffBase, m.BackoffMax, m.BackoffJitter),
+		"budgets_enabled":        m.BudgetsEnabled,
+		"budget_overrides":       len(m.BudgetOverrides),
+		"log_level":              m.LogLevel,
+		"export_dir":             m.ExportDir,
+		"audit_sink":             m.AuditSinkType,
+	}
+	if m.Profile != "" {
+		s["profile"] = m.Profile
+	}
+	if len(m.Sources) > 0 {
+		s["sources"] = strings.Join(m.Sources, " > ")
+	}
+	if m.SharedRateLimitFile != "" {
+		s["shared_rate_limit"] = fmt.Sprintf("%s (%d points/min)", m.SharedRateLimitFile, m.SharedRateLimitBudget)
+	}
+	if len(m.ValidatorCommand) > 0 {
+		s["validator_command"] = strings.Join(m.ValidatorCommand, " ")
+	}
+	if m.AppID != 0 {
+		s["github_app_id"] = m.AppID
+		s["github_app_installation_id"] = m.AppInstallationID
 	}
 
 	switch m.CostCenterMode {
@@ -450,15 +1834,14 @@ func (m *Manager) Summary() map[string]any {
 		s["prus_exception_users_count"] = len(m.PRUsExceptionUsers)
 		s["auto_create"] = m.AutoCreate
 		s["enable_incremental"] = m.EnableIncremental
+		if len(m.ServerLoginSuffixes) > 0 {
+			s["server_connected_login_suffixes"] = m.ServerLoginSuffixes
+			s["server_connected_handling"] = m.ServerUsersHandling
+		}
+		s["pending_cancellation_handling"] = m.PendingCancellationHandling
 		if m.Enterprise != "" {
-			s["no_prus_cost_center_url"] = fmt.Sprintf(
-				"https://github.com/enterprises/%s/billing/cost_centers/%s",
-				m.Enterprise, m.NoPRUsCostCenterID,
-			)
-			s["prus_allowed_cost_center_url"] = fmt.Sprintf(
-				"https://github.com/enterprises/%s/billing/cost_centers/%s",
-				m.Enterprise, m.PRUsAllowedCostCenterID,
-			)
+			s["no_prus_cost_center_url"] = m.CostCenterURL(m.NoPRUsCostCenterID)
+			s["prus_allowed_cost_center_url"] = m.CostCenterURL(m.PRUsAllowedCostCenterID)
 		}
 
 	case "teams":
@@ -466,13 +1849,58 @@ func (m *Manager) Summary() map[string]any {
 		s["teams_strategy"] = m.TeamsStrategy
 		s["teams_auto_create"] = m.TeamsAutoCreate
 		s["teams_remove_unmatched_users"] = m.TeamsRemoveUnmatchedUsers
+		s["teams_conflict_strategy"] = m.TeamsConflictStrategy
+		s["teams_deleted_teams"] = m.TeamsDeletedTeams
+		if m.TeamsNameTemplate != "" {
+			s["teams_name_template"] = m.TeamsNameTemplate
+		}
+		s["teams_include_count"] = len(m.TeamsInclude)
+		if m.TeamsDescriptionKey != "" {
+			s["teams_description_key"] = m.TeamsDescriptionKey
+		}
+		s["teams_exclude_count"] = len(m.TeamsExclude)
 		s["teams_mappings_count"] = len(m.TeamsMappings)
+		if m.TeamMappingsFile != "" {
+			s["team_mappings_file"] = m.TeamMappingsFile
+		}
+		s["teams_splits_count"] = len(m.TeamsSplits)
 
 	case "repos":
 		s["repos_mappings_count"] = len(m.ReposMappings)
+		s["repos_remove_no_longer_matching"] = m.ReposRemoveNoLongerMatching
 
 	case "custom-prop":
 		s["custom_prop_cost_centers_count"] = len(m.CustomPropCostCenters)
+
+	case "idp-groups":
+		s["idp_groups_auto_create"] = m.IdPGroupsAutoCreate
+		s["idp_groups_mappings_count"] = len(m.IdPGroupsMappings)
+
+	case "orgs":
+		s["orgs_auto_create"] = m.OrgsAutoCreate
+		s["orgs_remove_unmatched_users"] = m.OrgsRemoveUnmatchedUsers
+		s["orgs_mappings_count"] = len(m.OrgsMappings)
+
+	case "seat-org":
+		s["seat_org_auto_create"] = m.SeatOrgAutoCreate
+		s["seat_org_mappings_count"] = len(m.SeatOrgMappings)
+
+	case "email-domain":
+		s["email_domain_auto_create"] = m.EmailDomainAutoCreate
+		s["email_domain_mappings_count"] = len(m.EmailDomainMappings)
+		if m.EmailDomainDefault != "" {
+			s["email_domain_default"] = m.EmailDomainDefault
+		}
+
+	case "rules":
+		s["rules_auto_create"] = m.RulesAutoCreate
+		s["rules_count"] = len(m.Rules)
+		switch {
+		case m.RulesDefault.PRU:
+			s["rules_default"] = "pru"
+		case m.RulesDefault.CostCenter != "":
+			s["rules_default"] = m.RulesDefault.CostCenter
+		}
 	}
 
 	return s
@@ -515,6 +1943,14 @@ func validateAPIURL(raw string, log *slog.Logger) (string, error) {
 		log.Info("Using GitHub Enterprise Data Resident API", "subdomain", subdomain, "url", raw)
 
 	case strings.Contains(raw, "/api/v3"):
+		u, err := url.Parse(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid API URL: %w", err)
+		}
+		if u.Path != "/api/v3" {
+			return "", fmt.Errorf(
+				"GitHub Enterprise Server API URL should match 'https://{hostname}/api/v3', got: %s", raw)
+		}
 		log.Info("Using GitHub Enterprise Server API", "url", raw)
 
 	default:
@@ -532,12 +1968,26 @@ func validateExplicitMappings(mappings []ExplicitMapping) error {
 		if em.CostCenter == "" {
 			return fmt.Errorf("repos.mappings[%d]: missing 'cost_center'", i)
 		}
-		if em.PropertyName == "" {
+		// A topics or repositories mapping needs no property; otherwise
+		// property name and values go together.
+		if em.PropertyName == "" && ((len(em.Topics) == 0 && len(em.Repositories) == 0) || len(em.PropertyValues) > 0) {
 			return fmt.Errorf("repos.mappings[%d]: missing 'property_name'", i)
 		}
-		if len(em.PropertyValues) == 0 {
+		if em.PropertyName != "" && len(em.PropertyValues) == 0 {
 			return fmt.Errorf("repos.mappings[%d]: missing 'property_values'", i)
 		}
+		if _, err := em.ValueMatcher(); err != nil {
+			return fmt.Errorf("repos.mappings[%d]: %w", i, err)
+		}
+		if _, err := em.TopicMatcher(); err != nil {
+			return fmt.Errorf("repos.mappings[%d] topics: %w", i, err)
+		}
+		if _, err := em.RepositoryMatcher(); err != nil {
+			return fmt.Errorf("repos.mappings[%d] repositories: %w", i, err)
+		}
+		if em.Combine != "" && em.Combine != "any" && em.Combine != "all" {
+			return fmt.Errorf("repos.mappings[%d]: invalid combine %q: must be any or all", i, em.Combine)
+		}
 	}
 	return nil
 }
@@ -584,6 +2034,33 @@ func defaultString(val, def string) string {
 	return def
 }
 
+// CostCenterIDPrefix marks a mapping value as a cost center ID rather than
+// a name: "id:3b7c...".  Such values are never looked up or created.
+const CostCenterIDPrefix = "id:"
+
+// CostCenterRef returns a mapping value with an "id:" prefix replaced by
+// the bare, lower-cased ID, which must be a UUID.  Other values are
+// returned unchanged.
+func CostCenterRef(value string) (string, error) {
+	rest, ok := cutPrefixFold(value, CostCenterIDPrefix)
+	if !ok {
+		return value, nil
+	}
+	id := strings.ToLower(strings.TrimSpace(rest))
+	if !looksLikeUUID(id) {
+		return "", fmt.Errorf("%q is not a cost center ID: %q is not a UUID", value, rest)
+	}
+	return id, nil
+}
+
+// cutPrefixFold is strings.CutPrefix ignoring ASCII case.
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
+
 // uuidPattern matches a standard UUID format (lowercase hex).
 var uuidPattern = regexp.MustCompile(
 	`^[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}$`,
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ConfiguredCostCenters returns the names and IDs of every cost center the
+// configuration targets, in any mode rather than only the selected one:
+// the users mode cost centers, the targets of mappings, splits, rules and
+// overrides, the apply_order entries and the budgets.overrides keys.  id:
+// references are returned as bare IDs.  The team mappings file is read
+// when teams mode did not load it.
+func (m *Manager) ConfiguredCostCenters() ([]string, error) {
+	c := m.cfg.CostCenter
+	set := make(map[string]bool)
+	add := func(values ...string) {
+		for _, v := range values {
+			if ref, err := CostCenterRef(strings.TrimSpace(v)); err == nil && ref != "" {
+				set[ref] = true
+			}
+		}
+	}
+	addValues := func(mappings map[string]string) {
+		for _, v := range mappings {
+			add(v)
+		}
+	}
+
+	u := c.Users
+	add(m.NoPRUsCostCenterID, m.NoPRUsCostCenterName, m.PRUsAllowedCostCenterID, m.PRUsAllowedCostCenterName,
+		m.ServerCostCenterID, m.ServerCostCenterName, m.WindDownCostCenterID, m.WindDownCostCenterName,
+		u.NoPRUsCostCenterID, u.NoPRUsCostCenterName, u.PRUsAllowedCostCenterID, u.PRUsAllowedCostCenterName,
+		u.ServerConnected.CostCenterID, u.ServerConnected.CostCenterName,
+		u.PendingCancellation.CostCenterID, u.PendingCancellation.CostCenterName)
+
+	addValues(c.Teams.Mappings)
+	addValues(m.TeamsMappings)
+	if c.Teams.TeamMappingsFile != "" && m.TeamMappingsFile == "" {
+		fromFile, err := loadTeamMappings(c.Teams.TeamMappingsFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading cost_center.teams.team_mappings_file: %w", err)
+		}
+		addValues(fromFile)
+	}
+	for _, split := range c.Teams.Splits {
+		addValues(split)
+	}
+	for _, mapping := range c.Repos.Mappings {
+		add(mapping.CostCenter)
+	}
+	for _, cc := range c.CustomProp.CostCenters {
+		add(cc.Name)
+	}
+	addValues(c.IdPGroups.Mappings)
+	addValues(c.Orgs.Mappings)
+	addValues(c.SeatOrg.Mappings)
+	for _, rule := range c.Rules.List {
+		add(rule.CostCenter)
+	}
+	add(c.Rules.Default.CostCenter)
+	addValues(c.EmailDomain.Mappings)
+	add(c.EmailDomain.DefaultCostCenter)
+	addValues(m.Overrides)
+	add(c.ApplyOrder.First...)
+	add(c.ApplyOrder.Last...)
+	for key := range m.cfg.Budgets.Overrides {
+		add(key)
+	}
+
+	out := make([]string, 0, len(set))
+	for v := range set {
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out, nil
+}
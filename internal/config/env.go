@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the environment variables that override configuration
+// keys: the key's dotted path upper-cased, with dots as underscores, e.g.
+// GH_CC_COST_CENTER_TEAMS_SCOPE for cost_center.teams.scope.
+const EnvPrefix = "GH_CC_"
+
+// envField is a configuration key that an environment variable overrides.
+type envField struct {
+	Env   string // e.g. GH_CC_GITHUB_ENTERPRISE
+	Key   string // e.g. github.enterprise
+	index []int  // field path within Config
+}
+
+// envFields lists every configuration key, in key order: each field that
+// is not itself a section.  Lists and maps are whole keys.
+var envFields = func() []envField {
+	var fields []envField
+	var walk func(typ reflect.Type, key string, index []int)
+	walk = func(typ reflect.Type, key string, index []int) {
+		for i := range typ.NumField() {
+			f := typ.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			k := joinPath(key, name)
+			idx := append(append([]int(nil), index...), i)
+			if f.Type.Kind() == reflect.Struct {
+				walk(f.Type, k, idx)
+				continue
+			}
+			fields = append(fields, envField{
+				Env:   EnvPrefix + strings.ToUpper(strings.ReplaceAll(k, ".", "_")),
+				Key:   k,
+				index: idx,
+			})
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "", nil)
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+	return fields
+}()
+
+// EnvOverrides returns the environment variable of every configuration key,
+// keyed by the key's dotted path.
+func EnvOverrides() map[string]string {
+	out := make(map[string]string, len(envFields))
+	for _, f := range envFields {
+		out[f.Key] = f.Env
+	}
+	return out
+}
+
+// applyEnv sets the keys whose GH_CC_ variable is set, over the file.
+// Scalars are given as text ("true", "4", "1s"); lists as a comma-separated
+// value or a YAML/JSON list; maps and lists of mappings as YAML or JSON.
+// Empty variables are ignored.  A GH_CC_ variable that names no key, or
+// whose value does not fit its key, is returned as a problem.
+func (m *Manager) applyEnv() []error {
+	known := make(map[string]envField, len(envFields))
+	for _, f := range envFields {
+		known[f.Env] = f
+	}
+	var problems []error
+	cfg := reflect.ValueOf(&m.cfg).Elem()
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, EnvPrefix) || value == "" {
+			continue // unset CI variables often expand to ""
+		}
+		if name == ProfileEnv {
+			continue // selects the profile rather than setting a key
+		}
+		f, ok := known[name]
+		if !ok {
+			msg := fmt.Sprintf("%s: no configuration key has this variable", name)
+			if hint := closestEnv(name, known); hint != "" {
+				msg += fmt.Sprintf(" (did you mean %s?)", hint)
+			}
+			problems = append(problems, fmt.Errorf("%s", msg))
+			continue
+		}
+		if err := setFromEnv(cfg.FieldByIndex(f.index), value); err != nil {
+			problems = append(problems, fmt.Errorf("%s (%s): %w", name, f.Key, err))
+			continue
+		}
+		m.log.Debug("Configuration key set from the environment", "key", f.Key, "env", name)
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Error() < problems[j].Error() })
+	return problems
+}
+
+// setFromEnv parses value into v, a configuration key.
+func setFromEnv(v reflect.Value, value string) error {
+	trimmed := strings.TrimSpace(value)
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(trimmed)
+		if err != nil {
+			return fmt.Errorf("must be true or false, got %q", value)
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(trimmed)
+		if err != nil {
+			return fmt.Errorf("must be an integer, got %q", value)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(trimmed, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number, got %q", value)
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.String && !strings.HasPrefix(trimmed, "[") {
+			var items []string
+			for item := range strings.SplitSeq(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			v.Set(reflect.ValueOf(items))
+			return nil
+		}
+		fallthrough
+	default:
+		ptr := reflect.New(v.Type())
+		dec := yaml.NewDecoder(strings.NewReader(value))
+		dec.KnownFields(true)
+		if err := dec.Decode(ptr.Interface()); err != nil {
+			return fmt.Errorf("must be YAML or JSON: %w", err)
+		}
+		v.Set(ptr.Elem())
+	}
+	return nil
+}
+
+// closestEnv returns the GH_CC_ variable name most likely misspells, or "".
+func closestEnv(name string, known map[string]envField) string {
+	best, bestDist := "", len(name)/4+1
+	for k := range known {
+		if d := editDistance(name, k); d < bestDist || (d == bestDist && k < best) {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
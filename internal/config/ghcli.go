@@ -0,0 +1,189 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultGHHost is the gh CLI host for github.com.
+const DefaultGHHost = "github.com"
+
+// ghHostConfig is one host entry in the gh CLI's hosts.yml.  Enterprise is
+// not a gh setting; it is read so `gh config set -h HOST enterprise SLUG`
+// can provide a default enterprise.  Tokens are left to go-gh.
+type ghHostConfig struct {
+	User       string `yaml:"user"`
+	Enterprise string `yaml:"enterprise"`
+}
+
+// ghCLIConfig is the subset of the gh CLI configuration the extension
+// inherits.
+type ghCLIConfig struct {
+	Hosts      map[string]ghHostConfig
+	Enterprise string // top-level "enterprise" key in config.yml
+}
+
+// ghConfigDir returns the gh CLI configuration directory, following gh's own
+// lookup: GH_CONFIG_DIR, then XDG_CONFIG_HOME/gh, then ~/.config/gh.
+func ghConfigDir() string {
+	if d := os.Getenv("GH_CONFIG_DIR"); d != "" {
+		return d
+	}
+	if d := os.Getenv("XDG_CONFIG_HOME"); d != "" {
+		return filepath.Join(d, "gh")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gh")
+}
+
+// loadGHCLIConfig reads hosts.yml and config.yml from the gh configuration
+// directory.  Missing or unreadable files yield an empty configuration: gh
+// inheritance is best effort and never fails a run.
+func loadGHCLIConfig() ghCLIConfig {
+	var c ghCLIConfig
+	dir := ghConfigDir()
+	if dir == "" {
+		return c
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "hosts.yml")); err == nil {
+		var hosts map[string]ghHostConfig
+		if yaml.Unmarshal(data, &hosts) == nil {
+			c.Hosts = make(map[string]ghHostConfig, len(hosts))
+			for h, hc := range hosts {
+				c.Hosts[strings.ToLower(h)] = hc
+			}
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "config.yml")); err == nil {
+		var top struct {
+			Enterprise string `yaml:"enterprise"`
+		}
+		if yaml.Unmarshal(data, &top) == nil {
+			c.Enterprise = top.Enterprise
+		}
+	}
+	return c
+}
+
+// defaultHost returns the host gh would use: GH_HOST, else github.com when
+// it is authenticated or no host is, else the first authenticated host.
+func (c ghCLIConfig) defaultHost() string {
+	if h := os.Getenv("GH_HOST"); h != "" {
+		return strings.ToLower(h)
+	}
+	if _, ok := c.Hosts[DefaultGHHost]; ok || len(c.Hosts) == 0 {
+		return DefaultGHHost
+	}
+	hosts := make([]string, 0, len(c.Hosts))
+	for h := range c.Hosts {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	return hosts[0]
+}
+
+// enterprise returns the default enterprise for host from the gh config.
+func (c ghCLIConfig) enterprise(host string) string {
+	if e := c.Hosts[host].Enterprise; e != "" {
+		return e
+	}
+	return c.Enterprise
+}
+
+// API flavors, as returned by APIFlavor.
+const (
+	FlavorGitHubCom     = "github.com"
+	FlavorDataResidency = "ghe.com"
+	FlavorServer        = "ghes"
+	FlavorCustom        = "custom"
+)
+
+// APIFlavor classifies an API base URL: github.com, a ghe.com data
+// residency host, GitHub Enterprise Server (/api/v3), or a custom URL such
+// as a proxy.
+func APIFlavor(apiURL string) string {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return FlavorCustom
+	}
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case host == "api.github.com":
+		return FlavorGitHubCom
+	case strings.HasPrefix(host, "api.") && strings.HasSuffix(host, ".ghe.com"):
+		return FlavorDataResidency
+	case strings.TrimRight(u.Path, "/") == "/api/v3":
+		return FlavorServer
+	default:
+		return FlavorCustom
+	}
+}
+
+// validateHostname checks a github.hostname setting: a bare host name such
+// as "github.company.com", not a URL.
+func validateHostname(host string) error {
+	if strings.Contains(host, "/") || strings.Contains(host, ":") || strings.Contains(host, " ") {
+		return fmt.Errorf("github.hostname must be a host name such as \"github.company.com\", got %q", host)
+	}
+	if host == "api.github.com" || (strings.HasPrefix(host, "api.") && strings.HasSuffix(host, ".ghe.com")) {
+		return fmt.Errorf("github.hostname must be the web host, not the API host: use %q instead of %q",
+			strings.TrimPrefix(host, "api."), host)
+	}
+	return nil
+}
+
+// WebURLForHost returns the web UI base URL of a gh host.
+func WebURLForHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host == "" {
+		host = DefaultGHHost
+	}
+	return "https://" + host
+}
+
+// CostCenterURL returns the web UI link to a cost center of the enterprise.
+func (m *Manager) CostCenterURL(ccID string) string {
+	return fmt.Sprintf("%s/enterprises/%s/billing/cost_centers/%s", WebURLForHost(m.GHHost), m.Enterprise, ccID)
+}
+
+// APIURLForHost returns the REST API base URL for a gh host:
+// api.github.com for github.com, api.SUBDOMAIN.ghe.com for data residency,
+// and https://HOST/api/v3 for GitHub Enterprise Server.
+func APIURLForHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	switch {
+	case host == "" || host == DefaultGHHost:
+		return DefaultAPIBaseURL
+	case strings.HasSuffix(host, ".ghe.com"):
+		return "https://api." + strings.TrimPrefix(host, "api.")
+	default:
+		return "https://" + host + "/api/v3"
+	}
+}
+
+// HostForAPIURL is the inverse of APIURLForHost: it returns the gh host an
+// API base URL belongs to.
+func HostForAPIURL(apiURL string) string {
+	u, err := url.Parse(apiURL)
+	if err != nil || u.Hostname() == "" {
+		return DefaultGHHost
+	}
+	host := u.Hostname()
+	switch {
+	case host == "api.github.com":
+		return DefaultGHHost
+	case strings.HasSuffix(host, ".ghe.com"):
+		return strings.TrimPrefix(host, "api.")
+	default:
+		return host
+	}
+}
@@ -7,23 +7,88 @@ type Config struct {
 	CostCenter CostCenterConfig `yaml:"cost_center"`
 	Budgets    BudgetsConfig    `yaml:"budgets"`
 	Logging    LoggingConfig    `yaml:"logging"`
+	Audit      AuditConfig      `yaml:"audit"`
+	Validation ValidationConfig `yaml:"validation"`
+	Cache      CacheConfig      `yaml:"cache"`
 	ExportDir  string           `yaml:"export_dir"`
+	Language   string           `yaml:"language"` // "en", "es" or "pt"; prompts and summaries only
 }
 
 // GitHubConfig holds GitHub-related settings.
 type GitHubConfig struct {
-	Enterprise    string   `yaml:"enterprise"`
-	APIBaseURL    string   `yaml:"api_base_url"`
-	Organizations []string `yaml:"organizations"`
+	Enterprise    string          `yaml:"enterprise"`
+	APIBaseURL    string          `yaml:"api_base_url"`
+	Hostname      string          `yaml:"hostname"`    // alternative to api_base_url, e.g. "github.company.com"
+	APIVersion    string          `yaml:"api_version"` // X-GitHub-Api-Version, e.g. "2022-11-28"
+	Organizations []string        `yaml:"organizations"`
+	App           GitHubAppConfig `yaml:"app"`
+	// RateLimitReserve pauses all requests until the rate limit resets once
+	// X-RateLimit-Remaining drops to this many calls.
+	RateLimitReserve int                   `yaml:"rate_limit_reserve"`
+	Retry            RetryConfig           `yaml:"retry"`
+	SharedRateLimit  SharedRateLimitConfig `yaml:"shared_rate_limit"`
+}
+
+// SharedRateLimitConfig paces requests against a usage ledger shared with
+// other automations (see package quota).
+type SharedRateLimitConfig struct {
+	File            string `yaml:"file"`              // ledger path; empty disables
+	PointsPerMinute int    `yaml:"points_per_minute"` // shared budget; default 900
+}
+
+// RetryConfig controls how requests are retried after network errors and
+// 5xx responses (rate limits are always waited out).
+type RetryConfig struct {
+	MaxRetries  int     `yaml:"max_retries"`  // attempts per request, including the first
+	BackoffBase string  `yaml:"backoff_base"` // Go duration, e.g. "1s"
+	BackoffMax  string  `yaml:"backoff_max"`  // Go duration, e.g. "60s"
+	Jitter      float64 `yaml:"jitter"`       // 0..1, fraction each wait may vary by
+}
+
+// GitHubAppConfig authenticates as a GitHub App installation instead of
+// with a personal token.  Set PrivateKey or PrivateKeyPath, not both.
+type GitHubAppConfig struct {
+	AppID          string `yaml:"app_id"`
+	InstallationID string `yaml:"installation_id"`
+	PrivateKey     string `yaml:"private_key"`      // PEM; prefer env GITHUB_APP_PRIVATE_KEY
+	PrivateKeyPath string `yaml:"private_key_path"` // path to a PEM file
 }
 
 // CostCenterConfig holds the mode selector and per-mode settings.
 type CostCenterConfig struct {
-	Mode       string           `yaml:"mode"` // "users", "teams", "repos", or "custom-prop"
-	Users      UsersConfig      `yaml:"users"`
-	Teams      TeamsConfig      `yaml:"teams"`
-	Repos      ReposConfig      `yaml:"repos"`
-	CustomProp CustomPropConfig `yaml:"custom_prop"`
+	Mode string `yaml:"mode"` // "users", "teams", "repos", "custom-prop", "idp-groups", "orgs", "seat-org", "rules" or "email-domain"
+	// DeletedNameCollision is "fail" (default) or "suffix".
+	DeletedNameCollision string            `yaml:"deleted_name_collision"`
+	Users                UsersConfig       `yaml:"users"`
+	Teams                TeamsConfig       `yaml:"teams"`
+	Repos                ReposConfig       `yaml:"repos"`
+	CustomProp           CustomPropConfig  `yaml:"custom_prop"`
+	IdPGroups            IdPGroupsConfig   `yaml:"idp_groups"`
+	Orgs                 OrgsConfig        `yaml:"orgs"`
+	SeatOrg              SeatOrgConfig     `yaml:"seat_org"`
+	Rules                RulesConfig       `yaml:"rules"`
+	EmailDomain          EmailDomainConfig `yaml:"email_domain"`
+	ApplyOrder           ApplyOrderConfig  `yaml:"apply_order"`
+	// ApplyParallelism is how many cost centers (and batches) are written
+	// concurrently in apply mode; 1 (default) applies serially.
+	ApplyParallelism int `yaml:"apply_parallelism"`
+	// Sources lists the user assignment sources in priority order
+	// ("overrides", "teams", "idp_groups", "users"); the first source that
+	// places a user wins.  Empty runs the single mode from Mode.
+	Sources []string `yaml:"sources"`
+	// OverridesFile points to a YAML/JSON map or two-column CSV of
+	// login -> cost center for the "overrides" source.
+	OverridesFile string `yaml:"overrides_file"`
+}
+
+// ApplyOrderConfig controls the order in which cost centers are populated in
+// apply mode.  Entries are cost center names or UUIDs.
+type ApplyOrderConfig struct {
+	First []string `yaml:"first"` // populated before all others, in order
+	Last  []string `yaml:"last"`  // populated after all others, in order
+	// FirstMustSucceed skips the remaining cost centers when any user in a
+	// "first" cost center fails to assign.
+	FirstMustSucceed bool `yaml:"first_must_succeed"`
 }
 
 // UsersConfig holds PRU-based cost center settings.
@@ -35,27 +100,112 @@ type UsersConfig struct {
 	NoPRUsCostCenterName      string   `yaml:"no_prus_cost_center_name"`
 	PRUsAllowedCostCenterName string   `yaml:"prus_allowed_cost_center_name"`
 	EnableIncremental         bool     `yaml:"enable_incremental"`
+
+	ServerConnected     ServerConnectedConfig     `yaml:"server_connected"`
+	PendingCancellation PendingCancellationConfig `yaml:"pending_cancellation"`
+}
+
+// ServerConnectedConfig recognizes Copilot users whose identity comes from a
+// GitHub Enterprise Server instance connected through GitHub Connect.
+type ServerConnectedConfig struct {
+	// LoginSuffixes identify server-connected logins (e.g. "_ghes"); the
+	// suffix is stripped, case-insensitively, to normalize the login.
+	LoginSuffixes []string `yaml:"login_suffixes"`
+	// Handling is "assign" (default: treat like any other user, matching
+	// exception_users on the normalized login) or "segregate" (put them in
+	// their own cost center).
+	Handling       string `yaml:"handling"`
+	CostCenterName string `yaml:"cost_center_name"`
+	CostCenterID   string `yaml:"cost_center_id"`
+}
+
+// PendingCancellationConfig decides what happens to Copilot users whose seat
+// has a pending_cancellation_date, i.e. users being offboarded.
+type PendingCancellationConfig struct {
+	// Handling is "assign" (default: treat like any other user), "skip"
+	// (leave them where they are) or "wind_down" (move them to their own
+	// cost center).
+	Handling       string `yaml:"handling"`
+	CostCenterName string `yaml:"cost_center_name"`
+	CostCenterID   string `yaml:"cost_center_id"`
 }
 
 // TeamsConfig holds teams-based cost center settings.
 type TeamsConfig struct {
-	Scope                string            `yaml:"scope"`    // "organization" or "enterprise"
-	Strategy             string            `yaml:"strategy"` // "auto" or "manual"
+	Scope    string `yaml:"scope"`    // "organization", "enterprise" or "auto"
+	Strategy string `yaml:"strategy"` // "auto" or "manual"
+	// NameTemplate, a Go template over TeamNameData (e.g.
+	// "CC-{{.Org}}-{{.TeamSlug | upper}}"), names the cost centers of the
+	// auto strategy instead of "[org team] org/name".
+	NameTemplate         string            `yaml:"name_template"`
 	AutoCreate           bool              `yaml:"auto_create"`
 	RemoveUnmatchedUsers bool              `yaml:"remove_unmatched_users"`
 	Mappings             map[string]string `yaml:"mappings"` // "org/team-slug" -> "cost-center-name"
+	// TeamMappingsFile points to a YAML/JSON map or two-column CSV of more
+	// Mappings, kept outside this file (e.g. owned by finance) and read on
+	// every run.
+	TeamMappingsFile string `yaml:"team_mappings_file"`
+
+	// MemberAttributesFile points to a YAML/JSON map or two-column CSV of
+	// login -> attribute (e.g. "contractor", "employee") used by Splits.
+	MemberAttributesFile string `yaml:"member_attributes_file"`
+	// Include and Exclude are globs over team slugs, or "org/team-slug"
+	// when they contain a slash: only teams matching Include (when set)
+	// and not Exclude are processed.
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+
+	// DescriptionKey, when set, lets team owners name their cost center
+	// (or its ID) in the team description as "<key>: <value>", e.g.
+	// "cost-center: FIN-1234".  Mappings still win over the marker.
+	DescriptionKey string `yaml:"description_key"`
+
+	// ConflictStrategy decides the cost center of a user in several mapped
+	// teams: "alphabetical" (default), "priority", "smallest", "largest"
+	// or "fail".
+	ConflictStrategy string `yaml:"conflict_strategy"`
+	// TeamPriority lists team keys ("org/team-slug", or the slug for
+	// enterprise teams), highest priority first, for the "priority"
+	// strategy.  Teams not listed rank after those listed.
+	TeamPriority []string `yaml:"team_priority"`
+
+	// DeletedTeams decides what apply does with the cost center of an auto
+	// strategy team that no longer exists: "warn" (default), "empty"
+	// (remove its users) or "delete".
+	DeletedTeams string `yaml:"deleted_teams"`
+
+	// Splits routes members of a team to different cost centers based on
+	// their attribute: "org/team-slug" -> attribute value -> cost center.
+	// Members without a matching attribute fall back to the team's normal
+	// cost center.
+	Splits map[string]map[string]string `yaml:"splits"`
 }
 
 // ReposConfig holds repository-based (explicit OR-mapping) cost center settings.
 type ReposConfig struct {
 	Mappings []ExplicitMapping `yaml:"mappings"`
+	// RemoveReposNoLongerMatching removes, in apply mode, repositories a
+	// mapped cost center holds that no mapping to it matches any more.
+	RemoveReposNoLongerMatching bool `yaml:"remove_repos_no_longer_matching"`
 }
 
-// ExplicitMapping maps a custom-property value set to a cost center.
+// ExplicitMapping maps repositories to a cost center by up to three
+// selectors: a custom-property value set, topics and repository name
+// globs.  Combine decides whether a repository must meet any (default) or
+// all of the selectors the mapping sets.
 type ExplicitMapping struct {
 	CostCenter     string   `yaml:"cost_center"`
 	PropertyName   string   `yaml:"property_name"`
 	PropertyValues []string `yaml:"property_values"`
+	Topics         []string `yaml:"topics"`
+	// Repositories are name globs, case-insensitive: "payments-*" matches
+	// the repository name, "*/infra-*" the "org/name" full name.
+	Repositories []string `yaml:"repositories"`
+	// Combine is "any" (default) or "all".
+	Combine string `yaml:"combine"`
+	// Match is how PropertyValues and Topics are compared: "exact"
+	// (default), "glob" (* and ? wildcards over the whole value) or "regex".
+	Match string `yaml:"match"`
 }
 
 // CustomPropConfig holds AND-filter custom-property cost center definitions.
@@ -79,20 +229,163 @@ type CustomPropertyFilter struct {
 	Value    string `yaml:"value"`
 }
 
+// IdPGroupsConfig holds identity provider group (idp-groups mode) settings.
+// Groups are the external groups an Enterprise Managed Users enterprise
+// provisions from its IdP (Entra ID, Okta) into github.organizations.
+type IdPGroupsConfig struct {
+	AutoCreate bool              `yaml:"auto_create"`
+	Mappings   map[string]string `yaml:"mappings"` // IdP group name -> cost center
+}
+
+// OrgsConfig holds organization-membership (orgs mode) settings: every
+// member of each of github.organizations goes to that organization's cost
+// center.
+type OrgsConfig struct {
+	AutoCreate           bool              `yaml:"auto_create"`
+	RemoveUnmatchedUsers bool              `yaml:"remove_unmatched_users"`
+	Mappings             map[string]string `yaml:"mappings"` // organization -> cost center; others are auto-named
+}
+
+// SeatOrgConfig holds seat-org mode settings: every Copilot seat holder
+// goes to the cost center of the organization, or team, that granted the
+// seat.
+type SeatOrgConfig struct {
+	AutoCreate bool `yaml:"auto_create"`
+	// Mappings maps "org" or "org/team-slug" to a cost center; a team
+	// entry wins over its organization's.  Unmapped organizations get
+	// "[org] <org>".
+	Mappings map[string]string `yaml:"mappings"`
+}
+
+// RulesConfig holds rules mode settings: an ordered list of assignment
+// rules evaluated for every Copilot seat holder, the first match deciding
+// the cost center, and a default for users no rule matches.
+type RulesConfig struct {
+	AutoCreate bool             `yaml:"auto_create"`
+	List       []AssignmentRule `yaml:"list"`
+	Default    RuleDefault      `yaml:"default"`
+}
+
+// AssignmentRule matches users on every condition it sets; within a
+// condition any entry may match.  A rule without conditions matches every
+// user.  CostCenter may use {login}, {org} (with orgs or teams), {team}
+// (with teams) and {attr.NAME} (with an attributes condition on NAME),
+// filled from the matching entry.
+type AssignmentRule struct {
+	Name   string   `yaml:"name"`
+	Logins []string `yaml:"logins"` // logins, globs or /regex/, as exception_users
+	Teams  []string `yaml:"teams"`  // globs over the team keys ("org/team-slug", or slugs in enterprise scope)
+	Orgs   []string `yaml:"orgs"`   // globs over the organizations the user belongs to
+	Plans  []string `yaml:"plans"`  // Copilot seat plans, e.g. "business", "enterprise"
+	// When is a CEL expression over the RuleVar variables that must yield
+	// true, for what the lists above can't express: alternatives across
+	// conditions, negation, comparisons (see ParseRuleCondition).
+	When string `yaml:"when"`
+	// Attributes maps an identity attribute name (a SAML attribute such as
+	// "department" or "employeeid", or name_id, scim_username, emails) to
+	// globs over its values; every attribute listed must match.
+	Attributes map[string][]string `yaml:"attributes"`
+	// InactiveDays matches users without Copilot activity in that many
+	// days, or ever.
+	InactiveDays int    `yaml:"inactive_days"`
+	CostCenter   string `yaml:"cost_center"`
+}
+
+// RuleDefault places users no rule matches: in CostCenter, or by the PRU
+// rules of users mode when PRU is set.  Empty leaves them unassigned.
+type RuleDefault struct {
+	CostCenter string `yaml:"cost_center"`
+	PRU        bool   `yaml:"pru"`
+}
+
+// EmailDomainConfig holds email-domain mode settings: every Copilot seat
+// holder goes to the cost center mapped to the domain of their verified
+// identity provider email, or to a suffix of their SAML NameID.
+type EmailDomainConfig struct {
+	AutoCreate bool `yaml:"auto_create"`
+	// Mappings maps an email domain ("vendor.com", which also covers its
+	// subdomains) or a NameID suffix ("ou=contractors,dc=acme,dc=com") to a
+	// cost center.  The longest matching key wins.
+	Mappings map[string]string `yaml:"mappings"`
+	// DefaultCostCenter places users whose identity matches no key; empty
+	// leaves them unassigned.
+	DefaultCostCenter string `yaml:"default_cost_center"`
+}
+
 // LoggingConfig controls log level and output file.
 type LoggingConfig struct {
 	Level string `yaml:"level"`
 	File  string `yaml:"file"`
 }
 
+// AuditConfig controls streaming of billing-assignment changes to an
+// external collector during a run.
+type AuditConfig struct {
+	Sink AuditSinkConfig `yaml:"sink"`
+}
+
+// AuditSinkConfig describes the collector.  An empty Type disables it.
+type AuditSinkConfig struct {
+	Type          string `yaml:"type"` // "splunk_hec" or "http"
+	URL           string `yaml:"url"`
+	Token         string `yaml:"token"` // prefer env AUDIT_SINK_TOKEN
+	Index         string `yaml:"index"`
+	SourceType    string `yaml:"sourcetype"`
+	BatchSize     int    `yaml:"batch_size"`
+	FlushInterval string `yaml:"flush_interval"` // Go duration, e.g. "5s"
+}
+
+// ValidationConfig configures the operator's pre-apply validator.  An
+// empty Command disables it.
+type ValidationConfig struct {
+	Command []string `yaml:"command"` // program and arguments; receives the plan JSON on stdin
+	Timeout string   `yaml:"timeout"` // Go duration, e.g. "60s"
+}
+
+// CacheConfig sets the lifetimes of the cache buckets kept next to the cost
+// center cache.  A bucket is used only when its TTL is set.
+type CacheConfig struct {
+	TeamMembersTTL string `yaml:"team_members_ttl"` // Go duration, e.g. "1h"; unset or "0s" disables
+	SeatsTTL       string `yaml:"seats_ttl"`        // Go duration, e.g. "1h"; unset or "0s" disables
+}
+
 // BudgetsConfig holds budget auto-creation settings.
 type BudgetsConfig struct {
 	Enabled  bool                     `yaml:"enabled"`
 	Products map[string]ProductBudget `yaml:"products"`
+	// SeatCost is the monthly cost of one Copilot seat, used by assign
+	// --budget-impact to show what a plan does to each cost center's
+	// budget.  It has no default: the price depends on the plan and
+	// contract.
+	SeatCost float64 `yaml:"seat_cost"`
+	// Overrides sets product budgets for single cost centers, keyed by
+	// cost center name or team key, then by product.
+	Overrides map[string]map[string]BudgetOverride `yaml:"overrides"`
+}
+
+// BudgetOverride replaces a product's budget for one cost center.  An
+// amount of 0 creates no budget for the product there.
+type BudgetOverride struct {
+	Amount int `yaml:"amount"`
+	// AllowFurtherUsage, when set, replaces the product's setting; nil
+	// keeps the one of budgets.products.
+	AllowFurtherUsage *bool `yaml:"allow_further_usage"`
 }
 
 // ProductBudget is the budget configuration for a single product.
 type ProductBudget struct {
 	Amount  int  `yaml:"amount"`
 	Enabled bool `yaml:"enabled"`
+	// AllowFurtherUsage lets usage continue once the amount is spent;
+	// budgets stop usage by default.
+	AllowFurtherUsage bool `yaml:"allow_further_usage"`
+	// AlertThreshold is the percentage of the amount at which the budget's
+	// alert recipients are notified; 0 leaves the budget's own threshold.
+	AlertThreshold int `yaml:"alert_threshold"`
+}
+
+// StopsUsage reports whether the budget stops usage at its amount, the
+// API's prevent_further_usage.
+func (pb ProductBudget) StopsUsage() bool {
+	return !pb.AllowFurtherUsage
 }
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ValueMatcher returns the function reporting whether a custom property
+// value matches the mapping's property_values, compared as Match says.
+// Matching is case-sensitive, as property values are.  A regex matches
+// anywhere in the value unless anchored.
+func (em ExplicitMapping) ValueMatcher() (func(string) bool, error) {
+	return valueMatcher(em.Match, em.PropertyValues)
+}
+
+// TopicMatcher is ValueMatcher for the mapping's topics; nil when it has
+// none.
+func (em ExplicitMapping) TopicMatcher() (func(string) bool, error) {
+	if len(em.Topics) == 0 {
+		return nil, nil
+	}
+	return valueMatcher(em.Match, em.Topics)
+}
+
+// RepositoryMatcher returns the function reporting whether a repository
+// full name ("org/name") matches one of the mapping's repositories globs;
+// nil when it has none.
+func (em ExplicitMapping) RepositoryMatcher() (func(fullName string) bool, error) {
+	if len(em.Repositories) == 0 {
+		return nil, nil
+	}
+	return keyGlobMatcher(em.Repositories, "repository")
+}
+
+// TeamFilter returns the function reporting whether a team, by key
+// ("org/team-slug", or the slug for enterprise teams), is processed in
+// teams mode: it matches cost_center.teams.include (when set) and not
+// cost_center.teams.exclude.
+func (m *Manager) TeamFilter() (func(teamKey string) bool, error) {
+	include, err := keyGlobMatcher(m.TeamsInclude, "team")
+	if err != nil {
+		return nil, fmt.Errorf("cost_center.teams.include: %w", err)
+	}
+	exclude, err := keyGlobMatcher(m.TeamsExclude, "team")
+	if err != nil {
+		return nil, fmt.Errorf("cost_center.teams.exclude: %w", err)
+	}
+	return func(key string) bool {
+		if len(m.TeamsInclude) > 0 && !include(key) {
+			return false
+		}
+		return len(m.TeamsExclude) == 0 || !exclude(key)
+	}, nil
+}
+
+// keyGlobMatcher compiles case-insensitive globs over "owner/name" keys.
+// A glob without a slash matches the name (or a key without an owner), one
+// with a slash the whole key.
+func keyGlobMatcher(patterns []string, kind string) (func(key string) bool, error) {
+	globs := make([]string, 0, len(patterns))
+	for _, g := range patterns {
+		glob := strings.ToLower(strings.TrimSpace(g))
+		if glob == "" {
+			return nil, fmt.Errorf("empty %s pattern", kind)
+		}
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid %s glob %q: %w", kind, g, err)
+		}
+		globs = append(globs, glob)
+	}
+	return func(key string) bool {
+		full := strings.ToLower(key)
+		name := full
+		if _, n, ok := strings.Cut(full, "/"); ok {
+			name = n
+		}
+		for _, g := range globs {
+			target := name
+			if strings.Contains(g, "/") {
+				target = full
+			}
+			if ok, _ := path.Match(g, target); ok {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// valueMatcher compiles values for a mapping's match kind.
+func valueMatcher(match string, values []string) (func(string) bool, error) {
+	switch match {
+	case "", "exact":
+		set := make(map[string]bool, len(values))
+		for _, v := range values {
+			set[v] = true
+		}
+		return func(s string) bool { return set[s] }, nil
+	case "glob", "regex":
+		res := make([]*regexp.Regexp, 0, len(values))
+		for _, v := range values {
+			expr := v
+			if match == "glob" {
+				expr = "^" + strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(v)) + "$"
+			}
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression %q: %w", v, err)
+			}
+			res = append(res, re)
+		}
+		return func(s string) bool {
+			for _, re := range res {
+				if re.MatchString(s) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid match %q: must be exact, glob or regex", match)
+}
+
+// LoginPattern is a cost_center.users.exception_users entry: an exact login,
+// a glob such as "svc-*" (any entry with *, ? or [), or a regular
+// expression written between slashes, such as "/^bot-[0-9]+$/".  Matching
+// is case-insensitive.
+type LoginPattern struct {
+	raw   string
+	exact string
+	glob  string
+	re    *regexp.Regexp
+}
+
+// ParseLoginPattern parses an exception_users entry.
+func ParseLoginPattern(s string) (LoginPattern, error) {
+	p := LoginPattern{raw: s}
+	trimmed := strings.TrimSpace(s)
+	switch {
+	case trimmed == "":
+		return p, fmt.Errorf("empty login pattern")
+	case len(trimmed) > 2 && strings.HasPrefix(trimmed, "/") && strings.HasSuffix(trimmed, "/"):
+		re, err := regexp.Compile("(?i)" + trimmed[1:len(trimmed)-1])
+		if err != nil {
+			return p, fmt.Errorf("invalid regular expression %q: %w", s, err)
+		}
+		p.re = re
+	case strings.ContainsAny(trimmed, "*?["):
+		glob := strings.ToLower(trimmed)
+		if _, err := path.Match(glob, ""); err != nil {
+			return p, fmt.Errorf("invalid glob %q: %w", s, err)
+		}
+		p.glob = glob
+	default:
+		p.exact = strings.ToLower(trimmed)
+	}
+	return p, nil
+}
+
+// Exact returns the lower-cased login of an exact-match pattern.
+func (p LoginPattern) Exact() (string, bool) {
+	return p.exact, p.exact != ""
+}
+
+// Match reports whether login matches the pattern.
+func (p LoginPattern) Match(login string) bool {
+	switch {
+	case p.re != nil:
+		return p.re.MatchString(login)
+	case p.glob != "":
+		ok, _ := path.Match(p.glob, strings.ToLower(login))
+		return ok
+	}
+	return p.exact == strings.ToLower(login)
+}
+
+// String returns the entry as configured.
+func (p LoginPattern) String() string {
+	return p.raw
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileEnv names the profile to use when --profile is not given.
+const ProfileEnv = "GH_CC_PROFILE"
+
+// profilesKey is the top-level key holding the named profiles.  Each
+// profile has the shape of the whole file, profiles excepted.
+const profilesKey = "profiles"
+
+// applyProfile splits the profiles off the document root and returns the
+// settings to decode: the top-level settings with the named profile merged
+// over them, or the top-level settings alone when name is empty.  Mappings
+// merge key by key at every depth; a list or scalar in the profile replaces
+// the top-level value.  The names of the profiles defined are returned too.
+func applyProfile(root *yaml.Node, name string) (*yaml.Node, []string, error) {
+	base := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	var profiles *yaml.Node
+	if root != nil && root.Kind == yaml.MappingNode {
+		*base = *root
+		base.Content = nil
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value == profilesKey {
+				profiles = resolveAlias(root.Content[i+1])
+				continue
+			}
+			base.Content = append(base.Content, root.Content[i], root.Content[i+1])
+		}
+	}
+
+	var names []string
+	var selected *yaml.Node
+	if profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			names = append(names, profiles.Content[i].Value)
+			if profiles.Content[i].Value == name {
+				selected = resolveAlias(profiles.Content[i+1])
+			}
+		}
+	}
+	sort.Strings(names)
+
+	switch {
+	case name == "":
+		return base, names, nil
+	case len(names) == 0:
+		return nil, nil, fmt.Errorf("profile %q selected, but the configuration defines no profiles", name)
+	case selected == nil:
+		return nil, names, fmt.Errorf("unknown profile %q (defined: %s)", name, strings.Join(names, ", "))
+	}
+	if selected.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(selected.Content); i += 2 {
+			if selected.Content[i].Value == profilesKey {
+				return nil, names, fmt.Errorf("profile %q: profiles cannot be nested", name)
+			}
+		}
+	}
+	return mergeNodes(base, selected), names, nil
+}
+
+// mergeNodes returns over merged onto base without changing either: two
+// mappings merge key by key, anything else in over replaces base.  An empty
+// (null) value in over leaves base as it is.
+func mergeNodes(base, over *yaml.Node) *yaml.Node {
+	base, over = resolveAlias(base), resolveAlias(over)
+	if over == nil || (over.Kind == yaml.ScalarNode && over.Tag == "!!null") {
+		return base
+	}
+	if base == nil || base.Kind != yaml.MappingNode || over.Kind != yaml.MappingNode {
+		return over
+	}
+	merged := *base
+	merged.Content = append([]*yaml.Node(nil), base.Content...)
+	for i := 0; i+1 < len(over.Content); i += 2 {
+		key, value := over.Content[i], over.Content[i+1]
+		found := false
+		for j := 0; j+1 < len(merged.Content); j += 2 {
+			if merged.Content[j].Value == key.Value {
+				merged.Content[j+1] = mergeNodes(merged.Content[j+1], value)
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged.Content = append(merged.Content, key, value)
+		}
+	}
+	return &merged
+}
+
+// resolveAlias returns the node an alias points to, or n itself.
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	if n != nil && n.Kind == yaml.AliasNode {
+		return n.Alias
+	}
+	return n
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+)
+
+// Variables a rule's when expression can read.
+const (
+	RuleVarLogin        = "login"         // string
+	RuleVarOrgs         = "orgs"          // list(string): organizations
+	RuleVarTeams        = "teams"         // list(string): team keys, as teams mode
+	RuleVarPlan         = "plan"          // string: Copilot seat plan
+	RuleVarAttributes   = "attributes"    // map(string, list(string)), lower-cased names
+	RuleVarInactiveDays = "inactive_days" // int: whole days since the last Copilot activity
+	RuleVarNeverActive  = "never_active"  // bool: no Copilot activity on record
+)
+
+// ruleEnv is the CEL environment of when expressions, with the string and
+// list extensions and optional values (attributes[?"department"]).
+var ruleEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable(RuleVarLogin, cel.StringType),
+		cel.Variable(RuleVarOrgs, cel.ListType(cel.StringType)),
+		cel.Variable(RuleVarTeams, cel.ListType(cel.StringType)),
+		cel.Variable(RuleVarPlan, cel.StringType),
+		cel.Variable(RuleVarAttributes, cel.MapType(cel.StringType, cel.ListType(cel.StringType))),
+		cel.Variable(RuleVarInactiveDays, cel.IntType),
+		cel.Variable(RuleVarNeverActive, cel.BoolType),
+		cel.OptionalTypes(),
+		ext.Strings(),
+		ext.Lists(),
+	)
+})
+
+// RuleCondition is the compiled when expression of an assignment rule.
+type RuleCondition struct {
+	prg   cel.Program
+	reads map[string]bool // rule variables the expression refers to
+}
+
+// ParseRuleCondition compiles a when expression: a CEL expression over the
+// RuleVar variables that yields a bool.  Syntax and type errors, such as
+// an unknown variable, are reported here rather than on the first user.
+func ParseRuleCondition(expr string) (*RuleCondition, error) {
+	env, err := ruleEnv()
+	if err != nil {
+		return nil, fmt.Errorf("building rule environment: %w", err)
+	}
+	ast, iss := env.Compile(expr)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	if !ast.OutputType().IsExactType(cel.BoolType) {
+		return nil, fmt.Errorf("must yield a bool, not %s", ast.OutputType())
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	c := &RuleCondition{prg: prg, reads: make(map[string]bool)}
+	for _, ref := range ast.NativeRep().ReferenceMap() {
+		if len(ref.OverloadIDs) == 0 {
+			c.reads[ref.Name] = true
+		}
+	}
+	return c, nil
+}
+
+// Reads reports whether the expression refers to the variable name, so
+// what it holds is worth fetching.
+func (c *RuleCondition) Reads(name string) bool {
+	return c != nil && c.reads[name]
+}
+
+// Match evaluates the expression with vars, which maps every RuleVar to a
+// value of its type.  An evaluation error, such as a missing attribute
+// key, is returned with false.
+func (c *RuleCondition) Match(vars map[string]any) (bool, error) {
+	out, _, err := c.prg.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	ok, _ := out.Value().(bool)
+	return ok, nil
+}
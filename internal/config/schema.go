@@ -0,0 +1,305 @@
+package config
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schemaJSON is the JSON Schema of the configuration file.  Keep it in step
+// with the models: TestSchema_MatchesModels fails when a key is missing.
+//
+//go:embed schema.json
+var schemaJSON []byte
+
+// Schema returns the JSON Schema (draft 2020-12) of the configuration file,
+// for editors and CI linters.
+func Schema() []byte {
+	return schemaJSON
+}
+
+// schemaNode is the subset of JSON Schema the configuration schema uses.
+type schemaNode struct {
+	Type       string                 `json:"type"`
+	Properties map[string]*schemaNode `json:"properties"`
+	Items      *schemaNode            `json:"items"`
+	// Ref "#" is the whole schema again (a profile has the file's shape).
+	Ref string `json:"$ref"`
+	// AdditionalProperties is false (only Properties are allowed) or the
+	// schema of every value of a map.
+	AdditionalProperties json.RawMessage `json:"additionalProperties"`
+
+	values *schemaNode // parsed AdditionalProperties, nil when false
+}
+
+// parsedSchema is the parsed schemaJSON.
+var parsedSchema = func() *schemaNode {
+	var s schemaNode
+	if err := json.Unmarshal(schemaJSON, &s); err != nil {
+		panic(fmt.Sprintf("config: invalid embedded schema: %v", err))
+	}
+	s.link()
+	return &s
+}()
+
+// link parses AdditionalProperties throughout the schema.
+func (s *schemaNode) link() {
+	if len(s.AdditionalProperties) > 0 && string(s.AdditionalProperties) != "false" {
+		s.values = &schemaNode{}
+		if err := json.Unmarshal(s.AdditionalProperties, s.values); err != nil {
+			panic(fmt.Sprintf("config: invalid embedded schema: %v", err))
+		}
+	}
+	for _, p := range s.Properties {
+		p.link()
+	}
+	if s.Items != nil {
+		s.Items.link()
+	}
+	if s.values != nil {
+		s.values.link()
+	}
+}
+
+// SchemaError lists where a configuration file, or a GH_CC_ environment
+// override, does not match Schema.
+type SchemaError struct {
+	Path     string
+	Problems []error
+}
+
+func (e *SchemaError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d problem(s) in the configuration:", e.Path, len(e.Problems))
+	for _, p := range e.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(p.Error())
+	}
+	return b.String()
+}
+
+// CheckSchema checks YAML (or JSON) configuration data against Schema and
+// returns every unknown key and type mismatch, each with its line and
+// dotted path, e.g.
+//
+//	line 12: cost_center.teams.team_mapping: unknown key (did you mean "mappings"?)
+//
+// A syntax error is returned alone.
+func CheckSchema(data []byte) []error {
+	problems, err := checkSchema(data)
+	if err != nil {
+		return []error{err}
+	}
+	return problems
+}
+
+// checkSchema is CheckSchema with a syntax error returned apart.
+func checkSchema(data []byte) ([]error, error) {
+	doc, err := parseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	return checkSchemaNode(doc), nil
+}
+
+// parseConfig parses data into the root node of its document, nil when
+// the document is empty.
+func parseConfig(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing config YAML: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	return doc.Content[0], nil
+}
+
+// checkSchemaNode checks a document root against Schema.
+func checkSchemaNode(root *yaml.Node) []error {
+	if root == nil {
+		return nil
+	}
+	var problems []error
+	parsedSchema.check(root, "", &problems)
+	return problems
+}
+
+// check appends to problems where n does not match s; path is n's dotted
+// location.
+func (s *schemaNode) check(n *yaml.Node, path string, problems *[]error) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!null" {
+		return // an empty value leaves the default
+	}
+	if s.Ref == "#" {
+		s = parsedSchema
+	}
+	fail := func(format string, args ...any) {
+		where := path
+		if where == "" {
+			where = "top level"
+		}
+		*problems = append(*problems, fmt.Errorf("line %d: %s: %s", n.Line, where, fmt.Sprintf(format, args...)))
+	}
+
+	switch s.Type {
+	case "object":
+		if n.Kind != yaml.MappingNode {
+			fail("must be a mapping, got %s", describeNode(n))
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.Value == "<<" {
+				continue // merge key; the merged mapping is checked where defined
+			}
+			sub := s.Properties[key.Value]
+			if sub == nil {
+				sub = s.values
+			}
+			if sub == nil {
+				msg := "unknown key"
+				if hint := closestKey(key.Value, s.Properties); hint != "" {
+					msg += fmt.Sprintf(" (did you mean %q?)", hint)
+				}
+				*problems = append(*problems, fmt.Errorf("line %d: %s: %s", key.Line, joinPath(path, key.Value), msg))
+				continue
+			}
+			sub.check(value, joinPath(path, key.Value), problems)
+		}
+	case "array":
+		if n.Kind != yaml.SequenceNode {
+			fail("must be a list, got %s", describeNode(n))
+			return
+		}
+		for i, item := range n.Content {
+			s.Items.check(item, fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	case "string":
+		// Any scalar decodes into a string (e.g. app_id: 12345).
+		if n.Kind != yaml.ScalarNode {
+			fail("must be a string, got %s", describeNode(n))
+		}
+	case "integer":
+		if n.Kind != yaml.ScalarNode || n.Tag != "!!int" {
+			fail("must be an integer, got %s", describeNode(n))
+		}
+	case "number":
+		if n.Kind != yaml.ScalarNode || (n.Tag != "!!int" && n.Tag != "!!float") {
+			fail("must be a number, got %s", describeNode(n))
+		}
+	case "boolean":
+		if n.Kind != yaml.ScalarNode || n.Tag != "!!bool" {
+			fail("must be true or false, got %s", describeNode(n))
+		}
+	}
+}
+
+// describeNode names what n is, for a type mismatch message.
+func describeNode(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	}
+	return fmt.Sprintf("%q", n.Value)
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// closestKey returns the known key that key most likely misspells, or "".
+// A key within a third of its length in edits, or one that contains (or is
+// contained in) a known key once plurals are ignored, counts.
+func closestKey(key string, known map[string]*schemaNode) string {
+	names := make([]string, 0, len(known))
+	for k := range known {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	best, bestDist := "", len(key)/3+1
+	for _, k := range names {
+		if d := editDistance(strings.ToLower(key), k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	if best != "" {
+		return best
+	}
+	stem := strings.TrimSuffix(strings.ToLower(key), "s")
+	for _, k := range names {
+		ks := strings.TrimSuffix(k, "s")
+		if len(ks) >= 4 && len(stem) >= 4 && (strings.Contains(stem, ks) || strings.Contains(ks, stem)) {
+			return k
+		}
+	}
+	return ""
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// decodeConfig decodes data (nil without a config file) into cfg, with the
+// profile selected for the Manager merged over the top-level settings, and
+// applies the GH_CC_ environment overrides.  Strictly, data must match
+// Schema and the overrides their keys, and every problem is returned in a
+// *SchemaError; leniently, the problems are logged as warnings and the rest
+// of the configuration is used, as unknown keys are ignored.
+func (m *Manager) decodeConfig(data []byte, lenient bool) error {
+	root, err := parseConfig(data)
+	if err != nil {
+		return err
+	}
+	problems := checkSchemaNode(root)
+	settings, names, err := applyProfile(root, m.Profile)
+	if err != nil {
+		return err
+	}
+	m.Profiles = names
+	if err := settings.Decode(&m.cfg); err != nil {
+		var te *yaml.TypeError
+		if !errors.As(err, &te) {
+			return fmt.Errorf("parsing config YAML: %w", err)
+		}
+	}
+	problems = append(problems, m.applyEnv()...)
+	if len(problems) > 0 && !lenient {
+		return &SchemaError{Path: m.path, Problems: problems}
+	}
+	for _, p := range problems {
+		m.log.Warn("Ignoring configuration problem (lenient)", "path", m.path, "problem", p.Error())
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// TeamNameData is what cost_center.teams.name_template sees for a team.
+// Org is empty for enterprise teams.
+type TeamNameData struct {
+	Org        string
+	Enterprise string
+	TeamName   string
+	TeamSlug   string
+}
+
+// teamNameFuncs are the functions name templates may pipe values through.
+var teamNameFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	// replace is ordered for pipelines: {{.TeamName | replace " " "-"}}.
+	"replace": func(old, repl, s string) string { return strings.ReplaceAll(s, old, repl) },
+}
+
+// ParseTeamNameTemplate compiles an auto strategy naming template.  It is
+// tried against a sample team, so unknown fields and functions are
+// reported at load rather than on the first team.
+func ParseTeamNameTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("name_template").Funcs(teamNameFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	sample := TeamNameData{Org: "org", Enterprise: "enterprise", TeamName: "Team", TeamSlug: "team"}
+	if _, err := ExecuteTeamNameTemplate(tmpl, sample); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// ExecuteTeamNameTemplate renders a cost center name; surrounding space is
+// trimmed and an empty name is an error.
+func ExecuteTeamNameTemplate(tmpl *template.Template, data TeamNameData) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	name := strings.TrimSpace(b.String())
+	if name == "" {
+		return "", fmt.Errorf("name template gives an empty name")
+	}
+	return name, nil
+}
@@ -0,0 +1,248 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Validate checks the configuration in data as Load would, without any API
+// call, and returns every problem found where Load stops at the first.
+// Beyond Load it resolves every mode the file configures rather than only
+// cost_center.mode, and checks the team mappings and budgets.  path
+// locates the .env file and relative paths, as for Load.  An empty result
+// means the configuration is valid.
+func Validate(path string, data []byte, logger *slog.Logger) []error {
+	return ValidateProfile(path, data, "", logger)
+}
+
+// ValidateProfile is Validate for the named profile ("" uses
+// GH_CC_PROFILE).  Without a profile selected, a file that defines
+// profiles is validated as each of them completes the top-level settings,
+// and their problems are prefixed with the profile, e.g.
+//
+//	profile "staging": github.enterprise is required
+//
+// The schema is checked once, over the whole file.
+func ValidateProfile(path string, data []byte, profile string, logger *slog.Logger) []error {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	loadDotEnv(path, logger)
+
+	root, err := parseConfig(data)
+	if err != nil {
+		return []error{err}
+	}
+	problems := checkSchemaNode(root)
+
+	m := newManager(path, Options{Profile: profile}, logger)
+	settings, names, err := applyProfile(root, m.Profile)
+	if err != nil {
+		return append(problems, err)
+	}
+	if m.Profile != "" || len(names) == 0 {
+		return append(problems, m.validateSettings(settings)...)
+	}
+	for _, name := range names {
+		pm := newManager(path, Options{Profile: name}, logger)
+		settings, _, _ := applyProfile(root, name)
+		for _, p := range pm.validateSettings(settings) {
+			problems = append(problems, fmt.Errorf("profile %q: %w", name, p))
+		}
+	}
+	return problems
+}
+
+// validateSettings decodes settings leniently, the schema having been
+// checked, and returns the problems of the result.
+func (m *Manager) validateSettings(settings *yaml.Node) []error {
+	var problems []error
+	add := func(err error) {
+		// Errors of a mode resolved both by Load and here differ only by
+		// the context Load wraps them in; list each once.
+		for i, p := range problems {
+			switch {
+			case p.Error() == err.Error() || strings.HasSuffix(p.Error(), ": "+err.Error()):
+				return
+			case strings.HasSuffix(err.Error(), ": "+p.Error()):
+				problems[i] = err
+				return
+			}
+		}
+		problems = append(problems, err)
+	}
+
+	// Carry on with what the lenient decoder makes of the file.
+	_ = settings.Decode(&m.cfg)
+	for _, p := range m.applyEnv() {
+		add(p)
+	}
+
+	if err := m.resolve(); err != nil {
+		add(err)
+	}
+
+	// resolve may have stopped before github.organizations, which the mode
+	// checks below depend on.
+	m.Organizations = m.cfg.GitHub.Organizations
+	for _, mode := range m.configuredModes() {
+		if err := m.resolveMode(mode); err != nil {
+			add(err)
+		}
+	}
+	for _, err := range m.validateTeamsMappings() {
+		add(err)
+	}
+	for _, err := range validateBudgets(m.cfg.Budgets) {
+		add(err)
+	}
+	return problems
+}
+
+// configuredModes returns the modes the configuration sets up: the one in
+// cost_center.mode, those cost_center.sources draw on, and every other
+// mode whose section is filled in.
+func (m *Manager) configuredModes() []string {
+	c := m.cfg.CostCenter
+	mode := defaultString(c.Mode, DefaultCostCenterMode)
+	set := map[string]bool{
+		"users":        !reflect.ValueOf(c.Users).IsZero(),
+		"teams":        !reflect.ValueOf(c.Teams).IsZero(),
+		"repos":        len(c.Repos.Mappings) > 0,
+		"custom-prop":  len(c.CustomProp.CostCenters) > 0,
+		"idp-groups":   len(c.IdPGroups.Mappings) > 0,
+		"orgs":         !reflect.ValueOf(c.Orgs).IsZero(),
+		"seat-org":     !reflect.ValueOf(c.SeatOrg).IsZero(),
+		"rules":        len(c.Rules.List) > 0,
+		"email-domain": len(c.EmailDomain.Mappings) > 0,
+	}
+	if validModes[mode] {
+		set[mode] = true
+	}
+	for _, src := range c.Sources {
+		if mode := SourceMode(src); mode != "" {
+			set[mode] = true
+		}
+	}
+	var modes []string
+	for mode, ok := range set {
+		if ok {
+			modes = append(modes, mode)
+		}
+	}
+	sort.Strings(modes)
+	return modes
+}
+
+// validateTeamsMappings checks the keys and values of the team mappings
+// and splits against the teams scope, so a mapping that can never match a
+// team is found before a run.
+func (m *Manager) validateTeamsMappings() []error {
+	t := m.cfg.CostCenter.Teams
+	scope := defaultString(t.Scope, DefaultTeamsScope)
+	var problems []error
+	check := func(field, key string) {
+		org, slug, qualified := strings.Cut(key, "/")
+		switch {
+		case strings.TrimSpace(key) == "":
+			problems = append(problems, fmt.Errorf("%s: empty team key", field))
+		case scope == "enterprise" && qualified:
+			problems = append(problems, fmt.Errorf("%s: key %q is org/team-slug, but scope 'enterprise' matches enterprise team slugs", field, key))
+		case scope == "organization" && !qualified:
+			problems = append(problems, fmt.Errorf("%s: key %q must be org/team-slug with scope 'organization'", field, key))
+		case qualified && (org == "" || slug == ""):
+			problems = append(problems, fmt.Errorf("%s: key %q must be org/team-slug", field, key))
+		case qualified && len(m.Organizations) > 0 && !slices.ContainsFunc(m.Organizations, func(o string) bool { return strings.EqualFold(o, org) }):
+			problems = append(problems, fmt.Errorf("%s: key %q names organization %q, which is not in github.organizations", field, key, org))
+		}
+	}
+
+	// Once teams mode resolved, the mappings include team_mappings_file.
+	mappings := t.Mappings
+	if len(m.TeamsMappings) > 0 {
+		mappings = m.TeamsMappings
+	}
+	for _, key := range sortedKeys(mappings) {
+		check("cost_center.teams.mappings", key)
+		if strings.TrimSpace(mappings[key]) == "" {
+			problems = append(problems, fmt.Errorf("cost_center.teams.mappings: team %q has no cost center", key))
+		}
+	}
+	if defaultString(t.Strategy, DefaultTeamsStrategy) == "manual" && len(mappings) == 0 && t.TeamMappingsFile == "" && m.usesMode("teams") {
+		problems = append(problems, errors.New("cost_center.teams.strategy 'manual' requires at least one entry in cost_center.teams.mappings or cost_center.teams.team_mappings_file"))
+	}
+	for _, key := range sortedKeys(t.Splits) {
+		check("cost_center.teams.splits", key)
+		for _, attr := range sortedKeys(t.Splits[key]) {
+			if strings.TrimSpace(t.Splits[key][attr]) == "" {
+				problems = append(problems, fmt.Errorf("cost_center.teams.splits: team %q attribute %q has no cost center", key, attr))
+			}
+		}
+	}
+	return problems
+}
+
+// usesMode reports whether a run of the configuration runs mode.
+func (m *Manager) usesMode(mode string) bool {
+	return defaultString(m.cfg.CostCenter.Mode, DefaultCostCenterMode) == mode ||
+		slices.Contains(m.cfg.CostCenter.Sources, mode)
+}
+
+// validateBudgets checks the product budgets: every enabled product needs
+// a positive amount, and alert thresholds are percentages.  Overrides need
+// a key, product names and amounts that are not negative, and keys that
+// stay distinct ignoring case.
+func validateBudgets(b BudgetsConfig) []error {
+	var problems []error
+	for _, product := range sortedKeys(b.Products) {
+		p := b.Products[product]
+		switch {
+		case strings.TrimSpace(product) == "":
+			problems = append(problems, errors.New("budgets.products: empty product name"))
+		case p.Enabled && p.Amount <= 0:
+			problems = append(problems, fmt.Errorf("budgets.products.%s: amount must be positive, got %d", product, p.Amount))
+		case p.Amount < 0:
+			problems = append(problems, fmt.Errorf("budgets.products.%s: amount must not be negative, got %d", product, p.Amount))
+		case p.AlertThreshold < 0 || p.AlertThreshold > 100:
+			problems = append(problems, fmt.Errorf("budgets.products.%s: alert_threshold must be a percentage from 1 to 100, got %d", product, p.AlertThreshold))
+		}
+	}
+	seen := make(map[string]string, len(b.Overrides))
+	for _, key := range sortedKeys(b.Overrides) {
+		norm := normalizeOverrideKey(key)
+		if norm == "" {
+			problems = append(problems, errors.New("budgets.overrides: empty cost center or team key"))
+			continue
+		}
+		if prev, dup := seen[norm]; dup {
+			problems = append(problems, fmt.Errorf("budgets.overrides: %q and %q name the same cost center", prev, key))
+		}
+		seen[norm] = key
+		for _, product := range sortedKeys(b.Overrides[key]) {
+			switch amount := b.Overrides[key][product].Amount; {
+			case strings.TrimSpace(product) == "":
+				problems = append(problems, fmt.Errorf("budgets.overrides.%s: empty product name", key))
+			case amount < 0:
+				problems = append(problems, fmt.Errorf("budgets.overrides.%s.%s: amount must not be negative, got %d", key, product, amount))
+			}
+		}
+	}
+	return problems
+}
+
+// sortedKeys returns the keys of a string-keyed map in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -5,12 +5,16 @@
 package customprop
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
 
+	"github.com/renan-alm/gh-cost-center/internal/budgets"
 	"github.com/renan-alm/gh-cost-center/internal/config"
 	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
 )
 
 // Result records the outcome of processing a single custom-property cost center.
@@ -19,6 +23,7 @@ type Result struct {
 	CostCenterID  string
 	Filters       []config.CustomPropertyFilter
 	ReposMatched  int
+	Repos         []string // matched repository full names (plan mode)
 	ReposAssigned int
 	Success       bool
 	Message       string
@@ -36,24 +41,24 @@ type Summary struct {
 func (s *Summary) Print() {
 	fmt.Println()
 	fmt.Println(strings.Repeat("=", 80))
-	fmt.Println("CUSTOM-PROPERTY ASSIGNMENT SUMMARY")
+	fmt.Println(i18n.T("summary.customprop.title"))
 	fmt.Println(strings.Repeat("=", 80))
-	fmt.Printf("Total repositories in organization: %d\n", s.TotalRepos)
-	fmt.Printf("Cost centers processed: %d / %d\n", s.AppliedCCs, s.TotalCCs)
+	fmt.Println(i18n.T("summary.repos.total", s.TotalRepos))
+	fmt.Println(i18n.T("summary.repos.ccs", s.AppliedCCs, s.TotalCCs))
 
 	for _, r := range s.Results {
 		fmt.Println()
-		fmt.Printf("Cost Center: %s\n", r.CostCenter)
-		fmt.Println("  Filters (AND):")
+		fmt.Println(i18n.T("summary.repos.cc", r.CostCenter))
+		fmt.Println(i18n.T("summary.repos.filters"))
 		for _, f := range r.Filters {
 			fmt.Printf("    %s = %q\n", f.Property, f.Value)
 		}
-		fmt.Printf("  Matched:   %d repositories\n", r.ReposMatched)
-		fmt.Printf("  Assigned:  %d repositories\n", r.ReposAssigned)
+		fmt.Println(i18n.T("summary.repos.matched", r.ReposMatched))
+		fmt.Println(i18n.T("summary.repos.assigned", r.ReposAssigned))
 		if r.Success {
-			fmt.Println("  Status:    Success")
+			fmt.Println(i18n.T("summary.repos.ok"))
 		} else {
-			fmt.Printf("  Status:    Failed — %s\n", r.Message)
+			fmt.Println(i18n.T("summary.repos.failed", r.Message))
 		}
 	}
 	fmt.Println(strings.Repeat("=", 80))
@@ -66,6 +71,7 @@ type Manager struct {
 	client      *github.Client
 	log         *slog.Logger
 	costCenters []config.CustomPropCostCenter
+	reconciler  *budgets.Reconciler
 }
 
 // NewManager creates a Manager from configuration.
@@ -79,6 +85,7 @@ func NewManager(cfg *config.Manager, client *github.Client, logger *slog.Logger)
 		client:      client,
 		log:         logger,
 		costCenters: cfg.CustomPropCostCenters,
+		reconciler:  budgets.NewReconciler(client, logger),
 	}, nil
 }
 
@@ -130,31 +137,41 @@ func (m *Manager) PrintConfigSummary(org string) {
 
 // Run executes the full custom-property assignment flow.
 // mode is "plan" or "apply".  createBudgets enables budget creation for new CCs.
-func (m *Manager) Run(org, mode string, createBudgets bool) (*Summary, error) {
+func (m *Manager) Run(ctx context.Context, org, mode string, createBudgets bool) (*Summary, error) {
 	m.log.Info("Starting custom-property cost center assignment",
 		"org", org, "mode", mode, "cost_centers", len(m.costCenters))
 
 	// Fetch all repos with custom properties.
 	m.log.Info("Fetching repositories with custom properties...", "org", org)
-	allRepos, err := m.client.GetOrgReposWithProperties(org, "")
+	// Repos are streamed and only those matching a cost center's filters
+	// are kept, so memory does not grow with the size of the organization.
+	total := 0
+	var allRepos []github.RepoProperties
+	err := m.client.EachOrgRepoWithProperties(ctx, org, "", func(r github.RepoProperties) error {
+		total++
+		if m.matchesAnyCostCenter(r) {
+			allRepos = append(allRepos, r)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("fetching repos with properties: %w", err)
 	}
-	if len(allRepos) == 0 {
+	if total == 0 {
 		m.log.Warn("No repositories found", "org", org)
 		return &Summary{TotalRepos: 0, TotalCCs: len(m.costCenters)}, nil
 	}
-	m.log.Info("Repositories found", "org", org, "count", len(allRepos))
+	m.log.Info("Repositories found", "org", org, "count", total, "matching", len(allRepos))
 
 	// Preload existing cost centers for efficient lookups.
-	activeCCs, err := m.client.GetAllActiveCostCenters()
+	activeCCs, err := m.client.GetAllActiveCostCenters(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("fetching active cost centers: %w", err)
 	}
 	m.log.Info("Existing cost centers loaded", "count", len(activeCCs))
 
 	summary := &Summary{
-		TotalRepos: len(allRepos),
+		TotalRepos: total,
 		TotalCCs:   len(m.costCenters),
 	}
 
@@ -164,7 +181,7 @@ func (m *Manager) Run(org, mode string, createBudgets bool) (*Summary, error) {
 			"index", i+1, "total", len(m.costCenters),
 			"name", cc.Name, "filters", len(cc.Filters))
 
-		result := m.processCostCenter(cc, allRepos, activeCCs, mode, createBudgets)
+		result := m.processCostCenter(ctx, cc, allRepos, activeCCs, mode, createBudgets)
 		if result.Success {
 			summary.AppliedCCs++
 		}
@@ -177,6 +194,7 @@ func (m *Manager) Run(org, mode string, createBudgets bool) (*Summary, error) {
 // processCostCenter handles one custom-property cost center — finds matching
 // repos and (in apply mode) ensures the CC exists and assigns the repos.
 func (m *Manager) processCostCenter(
+	ctx context.Context,
 	cc config.CustomPropCostCenter,
 	allRepos []github.RepoProperties,
 	activeCCs map[string]string,
@@ -211,6 +229,7 @@ func (m *Manager) processCostCenter(
 			"cost_center", cc.Name, "count", len(matching))
 		for _, r := range matching {
 			m.log.Debug("Would assign", "repo", r.RepositoryFullName, "cost_center", cc.Name)
+			result.Repos = append(result.Repos, r.RepositoryFullName)
 		}
 		return result
 	}
@@ -220,7 +239,7 @@ func (m *Manager) processCostCenter(
 	if !ok {
 		m.log.Info("Cost center does not exist, creating...", "name", cc.Name)
 		var err error
-		ccID, err = m.client.CreateCostCenterWithPreload(cc.Name, activeCCs)
+		ccID, err = m.client.CreateCostCenterWithPreload(ctx, cc.Name, activeCCs)
 		if err != nil {
 			result.Message = fmt.Sprintf("failed to create cost center: %v", err)
 			m.log.Error("Failed to create cost center", "name", cc.Name, "error", err)
@@ -230,7 +249,7 @@ func (m *Manager) processCostCenter(
 		m.log.Info("Created cost center", "name", cc.Name, "id", ccID)
 
 		if createBudgets && m.cfg.BudgetsEnabled {
-			if err := m.createBudgets(ccID, cc.Name); err != nil {
+			if err := m.createBudgets(ctx, ccID, cc.Name); err != nil {
 				result.Message = fmt.Sprintf("budget creation failed: %v", err)
 				m.log.Error("Budget creation failed for cost center", "name", cc.Name, "error", err)
 				return result
@@ -238,6 +257,15 @@ func (m *Manager) processCostCenter(
 		}
 	} else {
 		m.log.Info("Cost center already exists", "name", cc.Name, "id", ccID)
+
+		// Update budgets whose amount drifted from configuration.
+		if createBudgets && m.cfg.BudgetsEnabled {
+			if err := m.reconciler.Reconcile(ctx, ccID, cc.Name, m.cfg.BudgetProductsFor(cc.Name)); err != nil {
+				result.Message = fmt.Sprintf("budget update failed: %v", err)
+				m.log.Error("Budget update failed for cost center", "name", cc.Name, "error", err)
+				return result
+			}
+		}
 	}
 
 	result.CostCenterID = ccID
@@ -267,7 +295,7 @@ func (m *Manager) processCostCenter(
 		m.log.Info("...and more", "remaining", len(repoNames)-10)
 	}
 
-	if err := m.client.AddRepositoriesToCostCenter(ccID, repoNames); err != nil {
+	if err := m.client.AddRepositoriesToCostCenter(ctx, ccID, repoNames); err != nil {
 		result.Message = fmt.Sprintf("failed to assign repos: %v", err)
 		m.log.Error("Failed to assign repos", "cost_center", cc.Name, "error", err)
 		return result
@@ -284,35 +312,24 @@ func (m *Manager) processCostCenter(
 }
 
 // createBudgets creates configured budgets for a newly-created cost center.
-func (m *Manager) createBudgets(ccID, ccName string) error {
+func (m *Manager) createBudgets(ctx context.Context, ccID, ccName string) error {
 	m.log.Info("Creating budgets for cost center", "name", ccName)
 
-	var failures []string
-	for product, pc := range m.cfg.BudgetProducts {
-		if !pc.Enabled {
-			m.log.Debug("Skipping disabled product budget", "product", product)
-			continue
-		}
-
-		ok, err := m.client.CreateProductBudget(ccID, ccName, product, pc.Amount)
-		if err != nil {
-			if _, unavailable := err.(*github.BudgetsAPIUnavailableError); unavailable {
-				m.log.Warn("Budgets API unavailable, skipping remaining budgets", "error", err)
-				return nil
-			}
-			m.log.Error("Failed to create budget",
-				"product", product, "cost_center", ccName, "error", err)
-			failures = append(failures, fmt.Sprintf("%s: %v", product, err))
-			continue
-		}
-		if ok {
-			m.log.Info("Budget created",
-				"product", product, "cost_center", ccName, "amount", pc.Amount)
-		}
+	products := m.cfg.BudgetProductsFor(ccName)
+	written, err := m.client.EnsureProductBudgets(ctx, ccID, ccName, products)
+	for _, product := range written {
+		m.log.Info("Budget created",
+			"product", product, "cost_center", ccName, "amount", products[product].Amount)
 	}
-
-	if len(failures) > 0 {
-		return fmt.Errorf("budget creation failed for cost center %s: %s", ccName, strings.Join(failures, "; "))
+	if err != nil {
+		// If budgets API is unavailable, log and stop trying.
+		var unavailable *github.BudgetsAPIUnavailableError
+		if errors.As(err, &unavailable) {
+			m.log.Warn("Budgets API unavailable, skipping remaining budgets", "error", err)
+			return nil
+		}
+		m.log.Error("Failed to create budgets", "cost_center", ccName, "error", err)
+		return fmt.Errorf("budget creation failed for cost center %s: %w", ccName, err)
 	}
 	return nil
 }
@@ -336,6 +353,17 @@ func findReposMatchingAllFilters(
 	return matched
 }
 
+// matchesAnyCostCenter reports whether repo satisfies the filters of at
+// least one cost center.
+func (m *Manager) matchesAnyCostCenter(repo github.RepoProperties) bool {
+	for _, cc := range m.costCenters {
+		if len(cc.Filters) > 0 && repoMatchesAllFilters(repo, cc.Filters) {
+			return true
+		}
+	}
+	return false
+}
+
 // repoMatchesAllFilters returns true when the repository satisfies every
 // filter (AND logic).
 func repoMatchesAllFilters(repo github.RepoProperties, filters []config.CustomPropertyFilter) bool {
@@ -536,7 +536,7 @@ func TestCreateBudgets_AllSucceed(t *testing.T) {
 	}
 	mgr := newTestManagerWithClient(t, client, products)
 
-	err := mgr.createBudgets("cc-id-1", "Test CC")
+	err := mgr.createBudgets(t.Context(), "cc-id-1", "Test CC")
 	if err != nil {
 		t.Errorf("expected nil error, got %v", err)
 	}
@@ -560,7 +560,7 @@ func TestCreateBudgets_PartialFailure(t *testing.T) {
 	}
 	mgr := newTestManagerWithClient(t, client, products)
 
-	err := mgr.createBudgets("cc-id-1", "Fail CC")
+	err := mgr.createBudgets(t.Context(), "cc-id-1", "Fail CC")
 	if err == nil {
 		t.Fatal("expected error for budget creation failure")
 	}
@@ -583,7 +583,7 @@ func TestCreateBudgets_APIUnavailable(t *testing.T) {
 	mgr := newTestManagerWithClient(t, client, products)
 
 	// 404 triggers BudgetsAPIUnavailableError — graceful degradation, returns nil.
-	err := mgr.createBudgets("cc-id-1", "Test CC")
+	err := mgr.createBudgets(t.Context(), "cc-id-1", "Test CC")
 	if err != nil {
 		t.Errorf("expected nil error for API unavailable, got %v", err)
 	}
@@ -598,7 +598,7 @@ func TestCreateBudgets_DisabledProducts(t *testing.T) {
 		log: testLogger(),
 	}
 
-	err := mgr.createBudgets("cc-id-1", "Test CC")
+	err := mgr.createBudgets(t.Context(), "cc-id-1", "Test CC")
 	if err != nil {
 		t.Errorf("expected nil error when all products disabled, got %v", err)
 	}
@@ -0,0 +1,145 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"slices"
+
+	"github.com/renan-alm/gh-cost-center/internal/audit"
+	"github.com/renan-alm/gh-cost-center/internal/journal"
+)
+
+// BudgetHandoff is the alert recipient change of one budget in an alert
+// transfer.
+type BudgetHandoff struct {
+	BudgetID string
+	Product  string // budget_product_sku
+	Before   []string
+	After    []string
+}
+
+// Changed reports whether the handoff alters the recipients.
+func (h BudgetHandoff) Changed() bool {
+	return !slices.Equal(h.Before, h.After)
+}
+
+// PlanAlertTransfer computes the alert recipient changes that move the
+// budget alerts of a cost center (matched by ID or name, see
+// CheckCostCenterHasBudget) from the recipients in from to those in to.
+// An empty from hands over every budget entirely; otherwise only the
+// listed recipients are replaced and the others stay.
+func (c *Client) PlanAlertTransfer(ctx context.Context, costCenterID, costCenterName string, from, to []string) ([]BudgetHandoff, error) {
+	budgets, err := c.ListBudgets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var plan []BudgetHandoff
+	for _, b := range budgets {
+		if b.BudgetScope != "cost_center" ||
+			(b.BudgetEntityName != costCenterID && b.BudgetEntityName != costCenterName) {
+			continue
+		}
+		plan = append(plan, BudgetHandoff{
+			BudgetID: b.ID,
+			Product:  b.BudgetProductSKU,
+			Before:   b.BudgetAlerting.AlertRecipients,
+			After:    handoffRecipients(b.BudgetAlerting.AlertRecipients, from, to),
+		})
+	}
+	return plan, nil
+}
+
+// handoffRecipients returns current with the from recipients (all of them
+// when from is empty) replaced by to, keeping the order of the survivors.
+func handoffRecipients(current, from, to []string) []string {
+	var out []string
+	if len(from) > 0 {
+		for _, r := range current {
+			if !slices.Contains(from, r) && !slices.Contains(out, r) {
+				out = append(out, r)
+			}
+		}
+	}
+	for _, r := range to {
+		if !slices.Contains(out, r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// TransferAlerts applies plan (see PlanAlertTransfer), updating the alert
+// recipients of every changed budget, and records the handoff in the
+// journal (see SetJournal) and the audit sink.  Budgets that fail to update
+// are reported together; the others keep their new recipients.
+func (c *Client) TransferAlerts(ctx context.Context, costCenterID, costCenterName string, from, to []string, plan []BudgetHandoff) error {
+	var errs []error
+	updated := 0
+	for _, h := range plan {
+		if !h.Changed() {
+			continue
+		}
+		if err := c.UpdateBudgetAlertRecipients(ctx, h.BudgetID, costCenterName, h.Product, h.After); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		updated++
+	}
+	err := errors.Join(errs...)
+
+	if c.journal != nil {
+		h := journal.Handoff{CostCenterID: costCenterID, CostCenter: costCenterName, From: from, To: to, Budgets: updated}
+		if err != nil {
+			h.Error = err.Error()
+		}
+		if jerr := c.journal.RecordHandoff(h); jerr != nil {
+			c.log.Warn("Could not record alert handoff in journal", "error", jerr)
+		}
+	}
+	c.emitAudit(audit.Event{
+		Action:             audit.ActionAlertsTransferred,
+		CostCenterID:       costCenterID,
+		CostCenter:         costCenterName,
+		Recipients:         to,
+		PreviousRecipients: from,
+	}, err)
+	if err != nil {
+		return fmt.Errorf("transferring budget alerts of cost center %q: %w", costCenterName, err)
+	}
+	c.log.Info("Transferred cost center budget alerts",
+		"cost_center", costCenterName, "cost_center_id", costCenterID, "to", to)
+	return nil
+}
+
+// UpdateBudgetAlertRecipients replaces the alert recipients of a budget.
+// Alerting is turned off when recipients is empty.
+func (c *Client) UpdateBudgetAlertRecipients(ctx context.Context, budgetID, costCenterName, product string, recipients []string) error {
+	url := c.enterpriseURL("/settings/billing/budgets/" + neturl.PathEscape(budgetID))
+	if recipients == nil {
+		recipients = []string{}
+	}
+	body := map[string]any{
+		"budget_alerting": map[string]any{
+			"will_alert":       len(recipients) > 0,
+			"alert_recipients": recipients,
+		},
+	}
+
+	_, err := c.doJSON(ctx, http.MethodPatch, url, body, nil)
+	c.emitAudit(audit.Event{Action: audit.ActionBudgetUpdated, CostCenter: costCenterName, Product: product, Resources: recipients}, err)
+	c.run.forgetBudgets()
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("budget %s for cost center %q not found: %w", budgetID, costCenterName, err)
+		}
+		return fmt.Errorf("updating alert recipients of %s budget for cost center %q: %w", product, costCenterName, err)
+	}
+
+	c.log.Info("Updated budget alert recipients",
+		"cost_center", costCenterName, "product", product, "budget_id", budgetID, "recipients", recipients)
+	return nil
+}
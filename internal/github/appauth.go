@@ -0,0 +1,157 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// appJWTLifetime is how long the app JWT used to request installation
+	// tokens is valid (GitHub allows at most 10 minutes).
+	appJWTLifetime = 9 * time.Minute
+	// appClockSkew backdates the JWT issue time against clock drift.
+	appClockSkew = 60 * time.Second
+	// appTokenRefreshMargin renews an installation token this long before
+	// it expires, so no request is sent with a token about to lapse.
+	appTokenRefreshMargin = 5 * time.Minute
+)
+
+// appTokenSource issues GitHub App installation tokens, caching each one
+// until shortly before it expires.  Installation tokens last one hour, so
+// long runs refresh transparently.
+type appTokenSource struct {
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+	baseURL        string
+	apiVersion     string // X-GitHub-Api-Version; apiVersion when empty
+	http           *http.Client
+	now            func() time.Time
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// newAppTokenSource parses the PEM-encoded private key (PKCS#1 or PKCS#8)
+// of the app.
+func newAppTokenSource(appID, installationID int64, privateKey []byte, baseURL string, httpClient *http.Client) (*appTokenSource, error) {
+	key, err := parseAppPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &appTokenSource{
+		appID:          appID,
+		installationID: installationID,
+		key:            key,
+		baseURL:        baseURL,
+		http:           httpClient,
+		now:            time.Now,
+	}, nil
+}
+
+// parseAppPrivateKey decodes an RSA private key in PEM form.
+func parseAppPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("GitHub App private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// Token returns a valid installation token, requesting a new one when
+// there is none or the current one is within the refresh margin.
+func (s *appTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && s.now().Add(appTokenRefreshMargin).Before(s.expires) {
+		return s.token, nil
+	}
+	token, expires, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.token, s.expires = token, expires
+	return token, nil
+}
+
+// fetch exchanges an app JWT for an installation token.
+func (s *appTokenSource) fetch(ctx context.Context) (string, time.Time, error) {
+	jwt, err := s.jwt()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", s.baseURL, s.installationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", acceptHeader)
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("X-GitHub-Api-Version", versionOrDefault(s.apiVersion))
+	req.Header.Set("Authorization", "Bearer "+jwt)
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("requesting installation token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", time.Time{}, fmt.Errorf("requesting installation token for app %d installation %d: %w",
+			s.appID, s.installationID, &APIError{StatusCode: resp.StatusCode, Body: string(body)})
+	}
+
+	var out struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", time.Time{}, fmt.Errorf("decoding installation token: %w", err)
+	}
+	if out.Token == "" {
+		return "", time.Time{}, fmt.Errorf("installation token response has no token")
+	}
+	return out.Token, out.ExpiresAt, nil
+}
+
+// jwt returns an RS256-signed JWT identifying the app.
+func (s *appTokenSource) jwt() (string, error) {
+	now := s.now()
+	header := `{"alg":"RS256","typ":"JWT"}`
+	claims := fmt.Sprintf(`{"iat":%d,"exp":%d,"iss":%s}`,
+		now.Add(-appClockSkew).Unix(), now.Add(appJWTLifetime).Unix(), strconv.Quote(strconv.FormatInt(s.appID, 10)))
+
+	enc := base64.RawURLEncoding
+	signing := enc.EncodeToString([]byte(header)) + "." + enc.EncodeToString([]byte(claims))
+	sum := sha256.Sum256([]byte(signing))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("signing GitHub App JWT: %w", err)
+	}
+	return signing + "." + enc.EncodeToString(sig), nil
+}
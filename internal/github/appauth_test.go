@@ -0,0 +1,113 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+)
+
+// verifyAppJWT checks the RS256 signature and issuer of an app JWT.
+func verifyAppJWT(t *testing.T, jwt string, pub *rsa.PublicKey, appID string) {
+	t.Helper()
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("JWT has %d parts", len(parts))
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatalf("decoding signature: %v", err)
+	}
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, sum[:], sig); err != nil {
+		t.Fatalf("JWT signature: %v", err)
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims struct {
+		Iss string `json:"iss"`
+		Iat int64  `json:"iat"`
+		Exp int64  `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatalf("JWT claims: %v", err)
+	}
+	if claims.Iss != appID || claims.Exp-claims.Iat > 600 {
+		t.Errorf("claims = %+v", claims)
+	}
+}
+
+func TestNewClient_GitHubApp(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	var issued atomic.Int32
+	var lastAuth atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/installations/42/access_tokens":
+			verifyAppJWT(t, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), &key.PublicKey, "7")
+			n := issued.Add(1)
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"token":      fmt.Sprintf("inst-%d", n),
+				"expires_at": time.Now().Add(time.Hour).UTC(),
+			})
+		case "/rate_limit":
+			lastAuth.Store(r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := &config.Manager{Enterprise: "ent", APIBaseURL: srv.URL, AppID: 7, AppInstallationID: 42, AppPrivateKey: pemKey}
+	c, err := NewClient(cfg, testLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := c.VerifyToken(t.Context()); err != nil {
+			t.Fatalf("VerifyToken: %v", err)
+		}
+	}
+	if issued.Load() != 1 || lastAuth.Load() != "Bearer inst-1" {
+		t.Errorf("issued %d tokens, last auth %v; want one cached token", issued.Load(), lastAuth.Load())
+	}
+
+	// Close to expiry the token is renewed.
+	c.app.now = func() time.Time { return time.Now().Add(56 * time.Minute) }
+	if err := c.VerifyToken(t.Context()); err != nil {
+		t.Fatalf("VerifyToken: %v", err)
+	}
+	if issued.Load() != 2 || lastAuth.Load() != "Bearer inst-2" {
+		t.Errorf("issued %d tokens, last auth %v; want a refreshed token", issued.Load(), lastAuth.Load())
+	}
+}
+
+func TestNewClient_GitHubAppBadKey(t *testing.T) {
+	cfg := &config.Manager{Enterprise: "ent", APIBaseURL: "https://api.github.com", AppID: 7, AppInstallationID: 42, AppPrivateKey: []byte("nope")}
+	if _, err := NewClient(cfg, testLogger()); err == nil || !strings.Contains(err.Error(), "PEM") {
+		t.Errorf("err = %v, want a PEM error", err)
+	}
+}
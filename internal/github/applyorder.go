@@ -0,0 +1,104 @@
+package github
+
+import (
+	"context"
+	"slices"
+	"sort"
+)
+
+// applyOrder holds cost_center.apply_order.  Entries are cost center names
+// or UUIDs; names are resolved to IDs on first use.
+type applyOrder struct {
+	first            []string
+	last             []string
+	firstMustSucceed bool
+}
+
+// configured reports whether any ordering was requested.
+func (o applyOrder) configured() bool {
+	return len(o.first) > 0 || len(o.last) > 0
+}
+
+// sortCostCenterIDs returns ids with the first entries (in configured order)
+// at the front, the last entries at the back, and everything else sorted in
+// between.  Entries not present in ids are ignored.
+func sortCostCenterIDs(ids, first, last []string) []string {
+	present := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		present[id] = true
+	}
+	placed := make(map[string]bool, len(ids))
+
+	out := make([]string, 0, len(ids))
+	for _, id := range first {
+		if present[id] && !placed[id] {
+			placed[id] = true
+			out = append(out, id)
+		}
+	}
+
+	var middle []string
+	for _, id := range ids {
+		if !placed[id] && !slices.Contains(last, id) {
+			middle = append(middle, id)
+		}
+	}
+	sort.Strings(middle)
+	for _, id := range middle {
+		placed[id] = true
+	}
+	out = append(out, middle...)
+
+	for _, id := range last {
+		if present[id] && !placed[id] {
+			placed[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// splitApplyPhases splits ids, as ordered by sortCostCenterIDs, into the
+// leading entries listed in first, the trailing entries listed in last, and
+// everything in between.
+func splitApplyPhases(ids, first, last []string) (head, middle, tail []string) {
+	i := 0
+	for i < len(ids) && slices.Contains(first, ids[i]) {
+		i++
+	}
+	j := len(ids)
+	for j > i && slices.Contains(last, ids[j-1]) {
+		j--
+	}
+	return ids[:i], ids[i:j], ids[j:]
+}
+
+// resolveApplyOrderIDs translates configured apply_order entries to cost
+// center IDs.  Names are looked up among the active cost centers; unknown
+// names are logged and dropped.
+func (c *Client) resolveApplyOrderIDs(ctx context.Context) (first, last []string) {
+	var active map[string]string
+	resolve := func(entries []string) []string {
+		ids := make([]string, 0, len(entries))
+		for _, e := range entries {
+			if IsValidCostCenterUUID(e) {
+				ids = append(ids, e)
+				continue
+			}
+			if active == nil {
+				var err error
+				if active, err = c.GetAllActiveCostCenters(ctx); err != nil {
+					c.log.Warn("Could not resolve apply_order names, ordering by UUID entries only", "error", err)
+					active = map[string]string{}
+				}
+			}
+			if id, ok := active[e]; ok {
+				ids = append(ids, id)
+			} else {
+				c.log.Warn("apply_order cost center not found, ignoring", "name", e)
+			}
+		}
+		return ids
+	}
+	return resolve(c.applyOrder.first), resolve(c.applyOrder.last)
+}
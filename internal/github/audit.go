@@ -0,0 +1,25 @@
+package github
+
+import (
+	"github.com/renan-alm/gh-cost-center/internal/audit"
+)
+
+// SetAuditSink attaches a sink that receives an event for every cost
+// center, membership, and budget write the client makes.
+func (c *Client) SetAuditSink(s *audit.Sink) {
+	c.auditSink = s
+}
+
+// emitAudit sends e to the audit sink, if one is attached, marking it as
+// failed when err is non-nil.
+func (c *Client) emitAudit(e audit.Event, err error) {
+	if c.auditSink == nil {
+		return
+	}
+	e.Enterprise = c.enterprise
+	e.Success = err == nil
+	if err != nil {
+		e.Error = err.Error()
+	}
+	c.auditSink.Emit(e)
+}
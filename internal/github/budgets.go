@@ -1,10 +1,16 @@
 package github
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	neturl "net/url"
+	"sort"
 	"strings"
+
+	"github.com/renan-alm/gh-cost-center/internal/audit"
+	"github.com/renan-alm/gh-cost-center/internal/config"
 )
 
 // BudgetsAPIUnavailableError indicates the GitHub Budgets API is not enabled
@@ -19,38 +25,68 @@ func (e *BudgetsAPIUnavailableError) Error() string {
 
 // Budget represents a single budget entry from the API.
 type Budget struct {
-	BudgetType       string `json:"budget_type"`
-	BudgetProductSKU string `json:"budget_product_sku"`
-	BudgetScope      string `json:"budget_scope"`
-	BudgetAmount     int    `json:"budget_amount"`
-	BudgetEntityName string `json:"budget_entity_name"`
+	ID               string         `json:"id"`
+	BudgetType       string         `json:"budget_type"`
+	BudgetProductSKU string         `json:"budget_product_sku"`
+	BudgetScope      string         `json:"budget_scope"`
+	BudgetAmount     int            `json:"budget_amount"`
+	BudgetEntityName string         `json:"budget_entity_name"`
+	BudgetAlerting   BudgetAlerting `json:"budget_alerting"`
+	// PreventFurtherUsage is whether usage stops once the amount is
+	// spent; nil when the API does not report it.
+	PreventFurtherUsage *bool `json:"prevent_further_usage"`
+}
+
+// BudgetAlerting is who is notified as a budget is consumed, and when.
+type BudgetAlerting struct {
+	WillAlert       bool     `json:"will_alert"`
+	AlertRecipients []string `json:"alert_recipients"`
+	// AlertThreshold is the percentage of the amount at which recipients
+	// are notified; nil when the API does not report it.
+	AlertThreshold *int `json:"alert_threshold"`
 }
 
 // budgetsListResponse is the JSON envelope for the budgets list endpoint.
 type budgetsListResponse struct {
-	Budgets []Budget `json:"budgets"`
+	Budgets     []Budget `json:"budgets"`
+	HasNextPage bool     `json:"has_next_page"`
 }
 
-// ListBudgets returns all budgets for the enterprise.
-func (c *Client) ListBudgets() ([]Budget, error) {
+// ListBudgets returns all budgets for the enterprise, following
+// has_next_page: a budget missed on a later page would be created again.
+// With a RunCache attached the budgets are listed once per run; budgets
+// created and updated through the client are kept in step.
+func (c *Client) ListBudgets(ctx context.Context) ([]Budget, error) {
+	if budgets, ok := c.run.budgetList(); ok {
+		return budgets, nil
+	}
 	url := c.enterpriseURL("/settings/billing/budgets")
-	var resp budgetsListResponse
-	_, err := c.doJSON(http.MethodGet, url, nil, &resp)
-	if err != nil {
-		var apiErr *APIError
-		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
-			return nil, &BudgetsAPIUnavailableError{Enterprise: c.enterprise}
+	const perPage = 100
+
+	var all []Budget
+	for page := 1; ; page++ {
+		var resp budgetsListResponse
+		_, err := c.doJSON(ctx, http.MethodGet, fmt.Sprintf("%s?page=%d&per_page=%d", url, page, perPage), nil, &resp)
+		if err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+				return nil, &BudgetsAPIUnavailableError{Enterprise: c.enterprise}
+			}
+			return nil, fmt.Errorf("listing budgets page %d: %w", page, err)
+		}
+		all = append(all, resp.Budgets...)
+		if !resp.HasNextPage || len(resp.Budgets) == 0 {
+			c.run.setBudgets(all)
+			return all, nil
 		}
-		return nil, fmt.Errorf("listing budgets: %w", err)
 	}
-	return resp.Budgets, nil
 }
 
 // CheckCostCenterHasBudget returns true if any budget targets the given cost
 // center name.  Due to a known API bug, the entity name may store the CC name
 // rather than the UUID, so we compare against both.
-func (c *Client) CheckCostCenterHasBudget(costCenterID, costCenterName string) (bool, error) {
-	budgets, err := c.ListBudgets()
+func (c *Client) CheckCostCenterHasBudget(ctx context.Context, costCenterID, costCenterName string) (bool, error) {
+	budgets, err := c.ListBudgets(ctx)
 	if err != nil {
 		return false, err
 	}
@@ -67,27 +103,22 @@ func (c *Client) CheckCostCenterHasBudget(costCenterID, costCenterName string) (
 
 // CheckCostCenterHasProductBudget returns true if a budget exists for the
 // given cost center and product combination.
-func (c *Client) CheckCostCenterHasProductBudget(costCenterID, costCenterName, product string) (bool, error) {
-	budgets, err := c.ListBudgets()
+func (c *Client) CheckCostCenterHasProductBudget(ctx context.Context, costCenterID, costCenterName, product string) (bool, error) {
+	budgets, err := c.ListBudgets(ctx)
 	if err != nil {
 		return false, err
 	}
-	_, sku := GetBudgetTypeAndSKU(product)
-	for _, b := range budgets {
-		if b.BudgetScope == "cost_center" &&
-			(b.BudgetEntityName == costCenterID || b.BudgetEntityName == costCenterName) &&
-			b.BudgetProductSKU == sku {
-			c.log.Info("Found existing budget", "product", product, "cost_center", costCenterName)
-			return true, nil
-		}
+	if FindProductBudget(budgets, costCenterID, costCenterName, product) != nil {
+		c.log.Info("Found existing budget", "product", product, "cost_center", costCenterName)
+		return true, nil
 	}
 	return false, nil
 }
 
 // CreateBudget creates a default Copilot Premium Request budget for a cost
-// center.  If a budget already exists it returns true without error.
-func (c *Client) CreateBudget(costCenterID, costCenterName string, amount int) (bool, error) {
-	exists, err := c.CheckCostCenterHasBudget(costCenterID, costCenterName)
+// center.  If that budget already exists it returns true without error.
+func (c *Client) CreateBudget(ctx context.Context, costCenterID, costCenterName string, amount int) (bool, error) {
+	exists, err := c.CheckCostCenterHasProductBudget(ctx, costCenterID, costCenterName, "copilot_premium_request")
 	if err != nil {
 		return false, err
 	}
@@ -96,56 +127,313 @@ func (c *Client) CreateBudget(costCenterID, costCenterName string, amount int) (
 		return true, nil
 	}
 
-	return c.createBudgetRequest(costCenterID, costCenterName, "SkuPricing", "copilot_premium_request", amount)
+	return c.createBudgetRequest(ctx, costCenterID, costCenterName, "SkuPricing", "copilot_premium_request", config.ProductBudget{Amount: amount})
 }
 
 // CreateProductBudget creates a product-specific budget for a cost center.
-func (c *Client) CreateProductBudget(costCenterID, costCenterName, product string, amount int) (bool, error) {
-	exists, err := c.CheckCostCenterHasProductBudget(costCenterID, costCenterName, product)
+// When the budget already exists with a different amount it is updated to
+// the configured one.
+func (c *Client) CreateProductBudget(ctx context.Context, costCenterID, costCenterName, product string, amount int) (bool, error) {
+	budgets, err := c.ListBudgets(ctx)
 	if err != nil {
 		return false, err
 	}
-	if exists {
+	if existing := FindProductBudget(budgets, costCenterID, costCenterName, product); existing != nil {
 		c.log.Info("Product budget already exists",
 			"product", product, "cost_center", costCenterName)
+		if _, err := c.syncBudget(ctx, existing, costCenterName, product, config.ProductBudget{Amount: amount, Enabled: true}); err != nil {
+			return false, err
+		}
 		return true, nil
 	}
 
 	budgetType, sku := GetBudgetTypeAndSKU(product)
-	return c.createBudgetRequest(costCenterID, costCenterName, budgetType, sku, amount)
+	return c.createBudgetRequest(ctx, costCenterID, costCenterName, budgetType, sku, config.ProductBudget{Amount: amount})
 }
 
-// createBudgetRequest sends the POST to create a budget.
-func (c *Client) createBudgetRequest(costCenterID, costCenterName, budgetType, productSKU string, amount int) (bool, error) {
-	url := c.enterpriseURL("/settings/billing/budgets")
+// ReconcileProductBudgets updates existing product budgets for a cost center
+// whose amount, alert threshold or prevent_further_usage has drifted from
+// the configured one.  Missing budgets are not created.  It returns the
+// products that were updated.
+func (c *Client) ReconcileProductBudgets(ctx context.Context, costCenterID, costCenterName string, products map[string]config.ProductBudget) ([]string, error) {
+	budgets, err := c.ListBudgets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.ReconcileListedBudgets(ctx, budgets, costCenterID, costCenterName, products)
+}
 
-	body := map[string]any{
-		"budget_type":           budgetType,
-		"budget_product_sku":    productSKU,
-		"budget_scope":          "cost_center",
-		"budget_amount":         amount,
-		"prevent_further_usage": true,
-		"budget_entity_name":    costCenterID,
-		"budget_alerting": map[string]any{
-			"will_alert":       false,
-			"alert_recipients": []string{},
-		},
+// ReconcileListedBudgets is ReconcileProductBudgets against budgets, the
+// enterprise's budgets as listed by ListBudgets, so callers reconciling
+// many cost centers list them once.
+func (c *Client) ReconcileListedBudgets(ctx context.Context, budgets []Budget, costCenterID, costCenterName string, products map[string]config.ProductBudget) ([]string, error) {
+	var updated, failures []string
+	for product, pc := range products {
+		if !pc.Enabled {
+			continue
+		}
+		existing := FindProductBudget(budgets, costCenterID, costCenterName, product)
+		if existing == nil {
+			continue
+		}
+		changed, err := c.syncBudget(ctx, existing, costCenterName, product, pc)
+		if err != nil {
+			var unavailable *BudgetsAPIUnavailableError
+			if errors.As(err, &unavailable) {
+				return updated, err
+			}
+			failures = append(failures, fmt.Sprintf("%s: %v", product, err))
+			continue
+		}
+		if changed {
+			updated = append(updated, product)
+		}
+	}
+	sort.Strings(updated)
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return updated, fmt.Errorf("updating budgets for cost center %q: %s", costCenterName, strings.Join(failures, "; "))
+	}
+	return updated, nil
+}
+
+// BudgetChange is a product budget of a cost center that differs from the
+// configuration: missing when Current is nil, drifted otherwise.
+type BudgetChange struct {
+	Product string
+	Current *Budget
+	Want    config.ProductBudget
+}
+
+// PlanProductBudgets returns the enabled products of products whose budget
+// for the cost center is missing from budgets or drifted, by product.
+func PlanProductBudgets(budgets []Budget, costCenterID, costCenterName string, products map[string]config.ProductBudget) []BudgetChange {
+	var changes []BudgetChange
+	for product, pc := range products {
+		if !pc.Enabled {
+			continue
+		}
+		existing := FindProductBudget(budgets, costCenterID, costCenterName, product)
+		if existing == nil || budgetDrifted(existing, pc) {
+			changes = append(changes, BudgetChange{Product: product, Current: existing, Want: pc})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Product < changes[j].Product })
+	return changes
+}
+
+// EnsureProductBudgets creates the enabled product budgets a cost center is
+// missing.  Existing budgets are left alone, whatever their amount;
+// ReconcileProductBudgets updates those.  It returns the products created,
+// sorted.  A *BudgetsAPIUnavailableError is returned as is.
+func (c *Client) EnsureProductBudgets(ctx context.Context, costCenterID, costCenterName string, products map[string]config.ProductBudget) ([]string, error) {
+	enabled := false
+	for _, pc := range products {
+		enabled = enabled || pc.Enabled
+	}
+	if !enabled {
+		return nil, nil
+	}
+	budgets, err := c.ListBudgets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var created, failures []string
+	for _, ch := range PlanProductBudgets(budgets, costCenterID, costCenterName, products) {
+		if ch.Current != nil {
+			c.log.Debug("Product budget already exists", "product", ch.Product, "cost_center", costCenterName)
+			continue
+		}
+		budgetType, sku := GetBudgetTypeAndSKU(ch.Product)
+		if _, err := c.createBudgetRequest(ctx, costCenterID, costCenterName, budgetType, sku, ch.Want); err != nil {
+			var unavailable *BudgetsAPIUnavailableError
+			if errors.As(err, &unavailable) {
+				return created, err
+			}
+			failures = append(failures, fmt.Sprintf("%s: %v", ch.Product, err))
+			continue
+		}
+		created = append(created, ch.Product)
+	}
+	if len(failures) > 0 {
+		return created, fmt.Errorf("creating budgets for cost center %q: %s", costCenterName, strings.Join(failures, "; "))
+	}
+	return created, nil
+}
+
+// UpdateProductBudget sets the amount, prevent_further_usage and, when pc
+// has one, the alert threshold of an existing budget.  alerting is the
+// budget's current alerting, kept apart from the threshold.
+func (c *Client) UpdateProductBudget(ctx context.Context, budgetID, costCenterName, product string, pc config.ProductBudget, alerting BudgetAlerting) error {
+	url := c.enterpriseURL("/settings/billing/budgets/" + neturl.PathEscape(budgetID))
+	body := map[string]any{"budget_amount": pc.Amount, "prevent_further_usage": pc.StopsUsage()}
+	if pc.AlertThreshold != 0 {
+		alerting.AlertThreshold = &pc.AlertThreshold
+		body["budget_alerting"] = alertingBody(alerting)
+	}
+
+	_, err := c.doJSON(ctx, http.MethodPatch, url, body, nil)
+	c.emitAudit(audit.Event{Action: audit.ActionBudgetUpdated, CostCenter: costCenterName, Product: product, Amount: pc.Amount}, err)
+	if err != nil {
+		c.run.forgetBudgets()
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("budget %s for cost center %q not found: %w", budgetID, costCenterName, err)
+		}
+		return fmt.Errorf("updating %s budget for cost center %q: %w", product, costCenterName, err)
+	}
+
+	c.run.budgetUpdated(budgetID, pc)
+	c.log.Info("Updated budget",
+		"cost_center", costCenterName, "product", product, "budget_id", budgetID, "amount", pc.Amount,
+		"prevent_further_usage", pc.StopsUsage(), "alert_threshold", pc.AlertThreshold)
+	return nil
+}
+
+// syncBudget updates b when its amount, alert threshold or
+// prevent_further_usage differs from pc.  It reports whether an update was
+// made.
+func (c *Client) syncBudget(ctx context.Context, b *Budget, costCenterName, product string, pc config.ProductBudget) (bool, error) {
+	if !budgetDrifted(b, pc) {
+		return false, nil
+	}
+	if b.ID == "" {
+		c.log.Warn("Budget drifted but the API returned no budget ID, cannot update",
+			"cost_center", costCenterName, "product", product, "current", b.BudgetAmount, "configured", pc.Amount)
+		return false, nil
+	}
+	c.logBudgetDrift(b, costCenterName, product, pc)
+	if err := c.UpdateProductBudget(ctx, b.ID, costCenterName, product, pc, b.BudgetAlerting); err != nil {
+		return false, err
+	}
+	applyProductBudget(b, pc)
+	return true, nil
+}
+
+// applyProductBudget records in b the settings an update from pc made.
+func applyProductBudget(b *Budget, pc config.ProductBudget) {
+	stop := pc.StopsUsage()
+	b.BudgetAmount = pc.Amount
+	b.PreventFurtherUsage = &stop
+	if pc.AlertThreshold != 0 {
+		threshold := pc.AlertThreshold
+		b.BudgetAlerting.AlertThreshold = &threshold
+	}
+}
+
+// budgetDrifted reports whether b differs from pc.  prevent_further_usage
+// is only compared when the API reports it, and the alert threshold when
+// pc sets one.
+func budgetDrifted(b *Budget, pc config.ProductBudget) bool {
+	return b.BudgetAmount != pc.Amount ||
+		(b.PreventFurtherUsage != nil && *b.PreventFurtherUsage != pc.StopsUsage()) ||
+		thresholdDrifted(b, pc)
+}
+
+// thresholdDrifted reports whether pc sets an alert threshold b does not
+// have.
+func thresholdDrifted(b *Budget, pc config.ProductBudget) bool {
+	t := b.BudgetAlerting.AlertThreshold
+	return pc.AlertThreshold != 0 && (t == nil || *t != pc.AlertThreshold)
+}
+
+// logBudgetDrift logs how b differs from pc.
+func (c *Client) logBudgetDrift(b *Budget, costCenterName, product string, pc config.ProductBudget) {
+	args := []any{"cost_center", costCenterName, "product", product, "current", b.BudgetAmount, "configured", pc.Amount}
+	if b.PreventFurtherUsage != nil && *b.PreventFurtherUsage != pc.StopsUsage() {
+		args = append(args, "current_prevent_further_usage", *b.PreventFurtherUsage, "configured_prevent_further_usage", pc.StopsUsage())
+	}
+	if thresholdDrifted(b, pc) {
+		if t := b.BudgetAlerting.AlertThreshold; t != nil {
+			args = append(args, "current_alert_threshold", *t)
+		}
+		args = append(args, "configured_alert_threshold", pc.AlertThreshold)
 	}
+	c.log.Info("Budget drifted from configuration", args...)
+}
+
+// FindProductBudget returns the budget for the cost center (by ID or name,
+// see CheckCostCenterHasBudget) and product, or nil.
+func FindProductBudget(budgets []Budget, costCenterID, costCenterName, product string) *Budget {
+	_, sku := GetBudgetTypeAndSKU(product)
+	for i := range budgets {
+		b := &budgets[i]
+		if b.BudgetScope == "cost_center" &&
+			(b.BudgetEntityName == costCenterID || b.BudgetEntityName == costCenterName) &&
+			b.BudgetProductSKU == sku {
+			return b
+		}
+	}
+	return nil
+}
+
+// createBudgetRequest sends the POST to create a budget with the settings
+// of pc.
+func (c *Client) createBudgetRequest(ctx context.Context, costCenterID, costCenterName, budgetType, productSKU string, pc config.ProductBudget) (bool, error) {
+	url := c.enterpriseURL("/settings/billing/budgets")
 
-	_, err := c.doJSON(http.MethodPost, url, body, nil)
+	body := budgetBody(costCenterID, budgetType, productSKU, pc)
+
+	_, err := c.doJSON(ctx, http.MethodPost, url, body, nil)
+	c.emitAudit(audit.Event{Action: audit.ActionBudgetCreated, CostCenterID: costCenterID, CostCenter: costCenterName, Product: productSKU, Amount: pc.Amount}, err)
 	if err != nil {
 		var apiErr *APIError
 		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
 			return false, &BudgetsAPIUnavailableError{Enterprise: c.enterprise}
 		}
+		c.run.forgetBudgets()
 		return false, fmt.Errorf("creating budget for cost center %q: %w", costCenterName, err)
 	}
+	created := Budget{
+		BudgetType:       budgetType,
+		BudgetProductSKU: productSKU,
+		BudgetScope:      "cost_center",
+		BudgetEntityName: costCenterID,
+	}
+	applyProductBudget(&created, pc)
+	c.run.budgetCreated(created)
 
 	c.log.Info("Successfully created budget",
-		"cost_center", costCenterName, "product_sku", productSKU, "amount", amount)
+		"cost_center", costCenterName, "product_sku", productSKU, "amount", pc.Amount)
 	return true, nil
 }
 
+// budgetBody is the request body creating a cost center budget.
+func budgetBody(costCenterID, budgetType, productSKU string, pc config.ProductBudget) map[string]any {
+	alerting := BudgetAlerting{}
+	if pc.AlertThreshold != 0 {
+		alerting.AlertThreshold = &pc.AlertThreshold
+	}
+	return map[string]any{
+		"budget_type":           budgetType,
+		"budget_product_sku":    productSKU,
+		"budget_scope":          "cost_center",
+		"budget_amount":         pc.Amount,
+		"prevent_further_usage": pc.StopsUsage(),
+		"budget_entity_name":    costCenterID,
+		"budget_alerting":       alertingBody(alerting),
+	}
+}
+
+// alertingBody is the budget_alerting object of a request.  The threshold
+// is only sent when set, so the API keeps its default otherwise.
+func alertingBody(a BudgetAlerting) map[string]any {
+	recipients := a.AlertRecipients
+	if recipients == nil {
+		recipients = []string{}
+	}
+	body := map[string]any{
+		"will_alert":       a.WillAlert,
+		"alert_recipients": recipients,
+	}
+	if a.AlertThreshold != nil {
+		body["alert_threshold"] = *a.AlertThreshold
+	}
+	return body
+}
+
 // GetBudgetTypeAndSKU maps a product name to the appropriate (budgetType,
 // productSKU) tuple.  Product-level identifiers use "ProductPricing", while
 // SKU-level identifiers use "SkuPricing".
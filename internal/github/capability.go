@@ -0,0 +1,68 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// CostCentersUnavailableError is returned by ProbeCostCenters when the
+// enterprise has no access to the cost centers API, as opposed to the token
+// lacking a permission.
+type CostCentersUnavailableError struct {
+	Enterprise string
+	StatusCode int
+	Body       string
+}
+
+func (e *CostCentersUnavailableError) Error() string {
+	return fmt.Sprintf("the cost centers API is not available for enterprise %q (HTTP %d). "+
+		"Cost centers require the enhanced billing platform on GitHub Enterprise Cloud; check that the "+
+		"enterprise slug is correct, that the enterprise is on the enhanced billing platform, and that "+
+		"cost centers are enabled in its billing settings", e.Enterprise, e.StatusCode)
+}
+
+// ProbeCostCenters checks that the enterprise can use the cost centers API
+// by listing a single cost center.  A 404 means the endpoint does not exist
+// for the enterprise (wrong plan, feature not enabled, or unknown slug) and
+// is reported as a *CostCentersUnavailableError; other failures are
+// returned as is.
+func (c *Client) ProbeCostCenters(ctx context.Context) error {
+	url := c.enterpriseURL("/settings/billing/cost-centers") + "?per_page=1"
+	_, err := c.doJSON(ctx, http.MethodGet, url, nil, nil)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return &CostCentersUnavailableError{Enterprise: c.enterprise, StatusCode: apiErr.StatusCode, Body: apiErr.Body}
+	}
+	if err != nil {
+		return fmt.Errorf("probing cost centers API: %w", err)
+	}
+	c.log.Debug("Cost centers API is available", "enterprise", c.enterprise)
+	return nil
+}
+
+// ProbeEnterpriseTeams checks that the token can list enterprise teams.
+func (c *Client) ProbeEnterpriseTeams(ctx context.Context) error {
+	return c.probe(ctx, "enterprise teams", c.enterpriseURL("/teams")+"?per_page=1")
+}
+
+// ProbeOrgTeams checks that the token can list the teams of org.
+func (c *Client) ProbeOrgTeams(ctx context.Context, org string) error {
+	return c.probe(ctx, "teams of "+org, fmt.Sprintf("%s/orgs/%s/teams?per_page=1", c.baseURL, org))
+}
+
+// ProbeOrgCustomProperties checks that the token can read the custom
+// property schema of org.
+func (c *Client) ProbeOrgCustomProperties(ctx context.Context, org string) error {
+	return c.probe(ctx, "custom properties of "+org, fmt.Sprintf("%s/orgs/%s/properties/schema", c.baseURL, org))
+}
+
+// probe makes one read request to url, describing a failure as reading
+// what.
+func (c *Client) probe(ctx context.Context, what, url string) error {
+	if _, err := c.doJSON(ctx, http.MethodGet, url, nil, nil); err != nil {
+		return fmt.Errorf("reading %s: %w", what, err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package github
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// chaosProbability is the fraction of requests that NewClient-created clients
+// fail with a synthetic 429 or 500.  Zero disables failure injection.  It is
+// only set from the hidden --chaos flag in builds tagged "chaos".
+var chaosProbability float64
+
+// SetChaosProbability enables failure injection for clients created after the
+// call.  p must be in [0, 1]; values outside the range are clamped.
+func SetChaosProbability(p float64) {
+	chaosProbability = min(max(p, 0), 1)
+}
+
+// chaosTransport wraps an http.RoundTripper and replaces a random fraction of
+// responses with retryable errors, so retry, back-off, and partial-failure
+// paths can be exercised against real endpoints.
+type chaosTransport struct {
+	base http.RoundTripper
+	p    float64
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// newChaosTransport returns a transport failing roughly p of requests.  The
+// seed makes a run reproducible.
+func newChaosTransport(base http.RoundTripper, p float64, seed uint64) *chaosTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &chaosTransport{
+		base: base,
+		p:    p,
+		rnd:  rand.New(rand.NewPCG(seed, seed)),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *chaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	inject := t.rnd.Float64() < t.p
+	rateLimit := t.rnd.IntN(2) == 0
+	t.mu.Unlock()
+
+	if !inject {
+		return t.base.RoundTrip(req)
+	}
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+
+	resp := &http.Response{
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Request:    req,
+	}
+	if rateLimit {
+		resp.StatusCode = http.StatusTooManyRequests
+		resp.Header.Set("X-RateLimit-Remaining", "0")
+		resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+		resp.Body = io.NopCloser(strings.NewReader(`{"message":"chaos: injected rate limit"}`))
+	} else {
+		resp.StatusCode = http.StatusInternalServerError
+		resp.Body = io.NopCloser(strings.NewReader(`{"message":"chaos: injected server error"}`))
+	}
+	resp.Status = strconv.Itoa(resp.StatusCode) + " " + http.StatusText(resp.StatusCode)
+	return resp, nil
+}
@@ -2,30 +2,42 @@ package github
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
-	"math"
 	"net/http"
 	"os"
-	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/cli/go-gh/v2/pkg/auth"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/renan-alm/gh-cost-center/internal/audit"
 	"github.com/renan-alm/gh-cost-center/internal/cache"
+	"github.com/renan-alm/gh-cost-center/internal/checkpoint"
 	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/journal"
+	"github.com/renan-alm/gh-cost-center/internal/progress"
+	"github.com/renan-alm/gh-cost-center/internal/quota"
 )
 
 const (
 	userAgent        = "gh-cost-center"
-	apiVersion       = "2022-11-28"
+	apiVersion       = config.DefaultAPIVersion
 	acceptHeader     = "application/vnd.github+json"
-	maxRetries       = 3
-	retryBackoffBase = 1 * time.Second
+	maxRetries       = config.DefaultMaxRetries
+	retryBackoffBase = config.DefaultBackoffBase
+	retryBackoffMax  = config.DefaultBackoffMax
 
-	// rateLimitFallback is used when the X-RateLimit-Reset header is missing.
+	// rateLimitFallback is used when neither Retry-After nor
+	// X-RateLimit-Reset says how long to wait; GitHub asks clients to wait
+	// at least a minute after a secondary rate limit.
 	rateLimitFallback = 60 * time.Second
 )
 
@@ -45,18 +57,98 @@ type Client struct {
 	http       *http.Client
 	baseURL    string
 	enterprise string
-	token      string // Bearer token for GitHub API
+	token      string          // Bearer token for GitHub API
+	app        *appTokenSource // when set, tokens come from here instead
+	apiVersion string          // X-GitHub-Api-Version; apiVersion when empty
 	log        *slog.Logger
 	ccCache    *cache.Cache // optional cost center cache
+
+	// memberCache and seatCache, when set, keep team member lists and the
+	// Copilot seat snapshot across runs (see SetBucketCache).
+	memberCache *cache.Bucket
+	seatCache   *cache.Bucket
+
+	// deletedCollisionPolicy controls how CreateCostCenter handles names
+	// that collide with deleted cost centers ("fail" or "suffix").
+	deletedCollisionPolicy string
+
+	// run, when set, shares cost center reads across the managers of one
+	// run (see RunCache).
+	run *RunCache
+
+	// perms records which API areas the client exercised (PermissionReport).
+	perms *permissionRecorder
+
+	// flights coalesces identical concurrent GET requests (see getJSON);
+	// writes move later GETs to new flights through flightGens.
+	flights    singleflight.Group
+	flightGens flightGenerations
+
+	// applyOrder controls the order of BulkUpdateCostCenterAssignments.
+	applyOrder applyOrder
+
+	// parallelism bounds the cost centers and batches written concurrently
+	// (see parallel); writeSlots enforces it across both.
+	parallelism int
+	writeSlots  chan struct{}
+
+	// rate holds every request while the rate limit is nearly exhausted.
+	rate *rateGate
+
+	// quota, when set, paces requests against a usage ledger shared with
+	// other processes (cfg.SharedRateLimitFile).
+	quota *quota.Ledger
+
+	// retry is the github.retry policy for transient errors and 5xx.
+	retry retryPolicy
+
+	// journal, when set, records failed cost center writes (see SetJournal).
+	journal *journal.Journal
+
+	// auditSink, when set, receives every billing-assignment change (see
+	// SetAuditSink).
+	auditSink *audit.Sink
+
+	// creations, when set, records the cost centers the client creates (see
+	// SetCreationRecorder).
+	creations CreationRecorder
+
+	// progress, when set, receives one event per cost center applied by
+	// BulkUpdateCostCenterAssignments (see SetProgress).
+	progress *progress.Reporter
+
+	// checkpoint, when set, records the resources added during an apply and
+	// skips those an interrupted apply already added (see SetCheckpoint).
+	checkpoint *checkpoint.Checkpoint
+
+	// httpCache, when set, counts the GET responses served from the HTTP
+	// cache (see SetHTTPCache).
+	httpCache *httpCacheStats
+
+	// schema collects how billing API responses differ from the fields the
+	// client decodes (see SchemaDrift).
+	schema schemaDrift
+
+	// applied collects the per-cost-center results of every apply (see
+	// ApplyResults).
+	applied applyResults
 }
 
 // NewClient creates a Client from a loaded config.Manager.
 //
-// Authentication is resolved in this order:
+// Authentication follows the gh CLI, resolved in this order:
 //  1. Explicit token passed via --token flag (stored in cfg.Token).
-//  2. GITHUB_TOKEN environment variable (set by gh CLI for extensions).
-//  3. GH_TOKEN environment variable.
-//  4. `gh auth token` shell-out (silent fallback if gh is installed).
+//  2. go-gh's auth.TokenForHost for the API host: the GH_TOKEN /
+//     GITHUB_TOKEN (github.com, *.ghe.com) or GH_ENTERPRISE_TOKEN /
+//     GITHUB_ENTERPRISE_TOKEN (GitHub Enterprise Server) env vars, gh's
+//     hosts.yml, then `gh auth token`, which reads gh's keyring.
+//  3. GITHUB_TOKEN for GitHub Enterprise Server hosts, which go-gh skips
+//     outside Codespaces but Actions runners on GHES provide.
+//
+// When a GitHub App is configured (cfg.AppID) and no --token is given, the
+// rest of the chain is skipped: the client authenticates as the app
+// installation, requesting an installation token on first use and renewing
+// it before it expires.
 //
 // Returns an error if no token can be obtained.
 func NewClient(cfg *config.Manager, logger *slog.Logger) (*Client, error) {
@@ -66,55 +158,97 @@ func NewClient(cfg *config.Manager, logger *slog.Logger) (*Client, error) {
 
 	baseURL := strings.TrimRight(cfg.APIBaseURL, "/")
 
-	token := resolveToken(cfg.Token, logger)
-	if token == "" {
-		return nil, fmt.Errorf("no GitHub token found: set GITHUB_TOKEN, GH_TOKEN, use --token flag, or run 'gh auth login'")
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	if chaosProbability > 0 {
+		logger.Warn("Chaos mode enabled: injecting random 429/500 responses",
+			"probability", chaosProbability)
+		httpClient.Transport = newChaosTransport(nil, chaosProbability, uint64(time.Now().UnixNano()))
+	}
+
+	var token string
+	var app *appTokenSource
+	if cfg.AppID != 0 && cfg.Token == "" {
+		var err error
+		app, err = newAppTokenSource(cfg.AppID, cfg.AppInstallationID, cfg.AppPrivateKey, baseURL, httpClient)
+		if err != nil {
+			return nil, err
+		}
+		app.apiVersion = cfg.APIVersion
+		logger.Debug("Authenticating as GitHub App", "app_id", cfg.AppID, "installation_id", cfg.AppInstallationID)
+	} else {
+		host := cfg.GHHost
+		if host == "" {
+			host = config.HostForAPIURL(baseURL)
+		}
+		var source string
+		token, source = resolveToken(cfg.Token, host)
+		if token == "" {
+			return nil, fmt.Errorf("no GitHub token found for %s: set GH_TOKEN or GITHUB_TOKEN (GH_ENTERPRISE_TOKEN for GitHub Enterprise Server), use --token flag, configure github.app, or run 'gh auth login --hostname %s'", host, host)
+		}
+		logger.Debug("GitHub token resolved", "source", source, "host", host)
 	}
 
-	logger.Debug("GitHub token resolved", "source", tokenSource(cfg.Token))
+	parallelism := max(cfg.ApplyParallelism, 1)
+	reserve := cfg.RateLimitReserve
+	if reserve == 0 {
+		reserve = config.DefaultRateLimitReserve
+	}
 
 	return &Client{
-		http:       &http.Client{Timeout: 30 * time.Second},
+		http:       httpClient,
 		baseURL:    baseURL,
 		enterprise: cfg.Enterprise,
 		token:      token,
+		app:        app,
+		apiVersion: cfg.APIVersion,
 		log:        logger,
+
+		deletedCollisionPolicy: cfg.DeletedCollisionPolicy,
+		perms:                  newPermissionRecorder(),
+		applyOrder: applyOrder{
+			first:            cfg.ApplyFirst,
+			last:             cfg.ApplyLast,
+			firstMustSucceed: cfg.ApplyFirstMustSucceed,
+		},
+		parallelism: parallelism,
+		writeSlots:  make(chan struct{}, parallelism),
+		rate:        newRateGate(reserve),
+		quota:       sharedQuota(cfg, logger),
+		retry: retryPolicy{
+			maxRetries: cfg.MaxRetries,
+			base:       cfg.BackoffBase,
+			max:        cfg.BackoffMax,
+			jitter:     cfg.BackoffJitter,
+		},
 	}, nil
 }
 
-// resolveToken returns the first non-empty token from the chain:
-// flag → GITHUB_TOKEN → GH_TOKEN → gh auth token.
-func resolveToken(flagToken string, logger *slog.Logger) string {
-	if flagToken != "" {
-		return flagToken
-	}
-	if v := os.Getenv("GITHUB_TOKEN"); v != "" {
-		return v
-	}
-	if v := os.Getenv("GH_TOKEN"); v != "" {
-		return v
-	}
-	// Fallback: try `gh auth token`.
-	out, err := exec.Command("gh", "auth", "token").Output()
-	if err != nil {
-		logger.Debug("gh auth token fallback failed", "error", err)
-		return ""
+// sharedQuota opens the shared rate limit ledger of cfg, or returns nil
+// when none is configured.
+func sharedQuota(cfg *config.Manager, logger *slog.Logger) *quota.Ledger {
+	if cfg.SharedRateLimitFile == "" {
+		return nil
 	}
-	return strings.TrimSpace(string(out))
+	l := quota.Open(cfg.SharedRateLimitFile, userAgent, cfg.SharedRateLimitBudget, logger)
+	logger.Debug("Pacing requests with a shared rate limit ledger", "path", l.Path(), "points_per_minute", l.Budget())
+	return l
 }
 
-// tokenSource returns a log-safe label describing where the token came from.
-func tokenSource(flagToken string) string {
+// resolveToken returns the first non-empty token from the chain described
+// on NewClient, with a log-safe label describing where it came from.
+func resolveToken(flagToken, host string) (token, source string) {
 	if flagToken != "" {
-		return "--token flag"
+		return flagToken, "--token flag"
 	}
-	if os.Getenv("GITHUB_TOKEN") != "" {
-		return "GITHUB_TOKEN env"
+	if token, source = auth.TokenForHost(host); token != "" {
+		return token, source
 	}
-	if os.Getenv("GH_TOKEN") != "" {
-		return "GH_TOKEN env"
+	if auth.IsEnterprise(host) {
+		if v := os.Getenv("GITHUB_TOKEN"); v != "" {
+			return v, "GITHUB_TOKEN"
+		}
 	}
-	return "gh auth token"
+	return "", ""
 }
 
 // SetCache attaches a cost center cache to the client.  When set, cost
@@ -124,6 +258,57 @@ func (c *Client) SetCache(cc *cache.Cache) {
 	c.ccCache = cc
 }
 
+// SetBucketCache attaches the team member and Copilot seat buckets.  Team
+// member lists and the seat snapshot are then served from them while their
+// entries are within the bucket TTL, so a plan followed shortly by an apply
+// fetches them once.  Either bucket may be nil.  FlushBucketCache writes
+// what the run stored in them.
+func (c *Client) SetBucketCache(members, seats *cache.Bucket) {
+	c.memberCache, c.seatCache = members, seats
+}
+
+// FlushBucketCache writes the entries stored in the attached buckets
+// during the run to disk.
+func (c *Client) FlushBucketCache() {
+	for _, b := range []*cache.Bucket{c.memberCache, c.seatCache} {
+		if b != nil {
+			b.Flush()
+		}
+	}
+}
+
+// SetProgress attaches a progress reporter for apply events.
+func (c *Client) SetProgress(r *progress.Reporter) {
+	c.progress = r
+}
+
+// SetCheckpoint attaches an apply checkpoint.  Every user and repository
+// batch added is recorded in it, and resources it already holds for a cost
+// center are reported as added without being sent again.
+func (c *Client) SetCheckpoint(cp *checkpoint.Checkpoint) {
+	c.checkpoint = cp
+}
+
+// SetTimeout overrides the per-request HTTP timeout (default 30s).
+func (c *Client) SetTimeout(d time.Duration) {
+	c.http.Timeout = d
+}
+
+// VerifyToken checks that the resolved token is accepted by the API.  It
+// calls the rate-limit endpoint, which works for every token type (PAT,
+// fine-grained, GitHub App) and does not count against the rate limit.
+func (c *Client) VerifyToken(ctx context.Context) error {
+	url := c.baseURL + "/rate_limit"
+	if _, err := c.doJSON(ctx, http.MethodGet, url, nil, nil); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			return fmt.Errorf("token rejected by GitHub API (401 Bad credentials): %w", err)
+		}
+		return fmt.Errorf("verifying token: %w", err)
+	}
+	return nil
+}
+
 // APIError is returned when the GitHub API responds with a non-2xx status
 // that is not retried (or all retries are exhausted).
 type APIError struct {
@@ -142,30 +327,160 @@ func (e *APIError) Error() string {
 // doJSON performs an HTTP request, retrying on transient errors and rate
 // limits. If dest is non-nil the response body is JSON-decoded into it.
 // The body parameter, when non-nil, is JSON-encoded as the request body.
-func (c *Client) doJSON(method, url string, body any, dest any) (*http.Response, error) {
+func (c *Client) doJSON(ctx context.Context, method, url string, body any, dest any) (*http.Response, error) {
+	if method == http.MethodGet && body == nil {
+		return c.getJSON(ctx, url, dest)
+	}
+	var decode func(*json.Decoder) error
+	if dest != nil {
+		decode = func(dec *json.Decoder) error { return dec.Decode(dest) }
+	}
+	return c.doStream(ctx, method, url, body, decode)
+}
+
+// getJSON is doJSON for GET requests.  Identical GETs issued concurrently
+// are coalesced into one API call (see singleflight): every caller decodes
+// its own copy of the shared response body into dest.  The shared call is
+// not cancelled with any one caller's ctx; each caller stops waiting when
+// its own ctx is done.  A GET issued after a write to the same cost center
+// (or, for other URLs, after any write) never joins a call started before
+// the write.
+func (c *Client) getJSON(ctx context.Context, url string, dest any) (*http.Response, error) {
+	flightCtx := context.WithoutCancel(ctx)
+	ch := c.flights.DoChan(c.flightGens.key(url), func() (any, error) {
+		var res flightResult
+		resp, err := c.doStream(flightCtx, http.MethodGet, url, nil, func(dec *json.Decoder) error {
+			if err := dec.Decode(&res.body); err != nil && err != io.EOF {
+				return err
+			}
+			return nil
+		})
+		res.resp = resp
+		return res, err
+	})
+	var r singleflight.Result
+	select {
+	case r = <-ch:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	res, err := r.Val.(flightResult), r.Err
+	resp, raw := res.resp, res.body
+	if r.Shared {
+		c.log.Debug("Coalesced identical in-flight GET", "url", url)
+	}
+	if err != nil || dest == nil {
+		return resp, err
+	}
+	if err := json.Unmarshal(raw, dest); err != nil {
+		return resp, fmt.Errorf("decoding response from %s %s: %w", http.MethodGet, url, err)
+	}
+	c.checkSchema(url, raw, dest)
+	return resp, nil
+}
+
+// flightGenerations counts the writes that invalidate in-flight GETs:
+// every write bumps all, and a write to a cost center also bumps its
+// entry in byCostCenter.  getJSON keys its flights by URL and generation.
+type flightGenerations struct {
+	mu           sync.Mutex
+	all          uint64
+	byCostCenter map[string]uint64
+}
+
+// flightCostCenter returns the cost center ID in a billing API URL, or "".
+func flightCostCenter(url string) string {
+	_, rest, ok := strings.Cut(url, "/cost-centers/")
+	if !ok {
+		return ""
+	}
+	id, _, _ := strings.Cut(rest, "/")
+	id, _, _ = strings.Cut(id, "?")
+	if id == "memberships" {
+		return ""
+	}
+	return id
+}
+
+// key returns the flight key of a GET of url.
+func (g *flightGenerations) key(url string) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	gen := g.all
+	if id := flightCostCenter(url); id != "" {
+		gen = g.byCostCenter[id]
+	}
+	return strconv.FormatUint(gen, 10) + " " + url
+}
+
+// bump records a write to url.
+func (g *flightGenerations) bump(url string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.all++
+	if id := flightCostCenter(url); id != "" {
+		if g.byCostCenter == nil {
+			g.byCostCenter = make(map[string]uint64)
+		}
+		g.byCostCenter[id]++
+	}
+}
+
+// flightResult is the outcome of a GET shared by getJSON's callers: the
+// response, its body already consumed and closed, and the raw body.
+type flightResult struct {
+	resp *http.Response
+	body json.RawMessage
+}
+
+// doStream is doJSON with the decoding left to decode, which reads the 2xx
+// response body from a decoder as it arrives (see streamArray) instead of
+// holding the whole body in memory.  Retries happen before decode is called,
+// so decode runs at most once.
+func (c *Client) doStream(ctx context.Context, method, url string, body any, decode func(*json.Decoder) error) (*http.Response, error) {
+	return c.doStreamHeader(ctx, method, url, body, nil, decode)
+}
+
+// doStreamHeader is doStream with extra request headers.  When header
+// carries If-None-Match, a 304 Not Modified is returned as a success
+// without calling decode.
+func (c *Client) doStreamHeader(ctx context.Context, method, url string, body any, header http.Header, decode func(*json.Decoder) error) (*http.Response, error) {
+	if method != http.MethodGet && method != http.MethodHead {
+		// GETs issued once the write is done must not join a flight
+		// that started before it.
+		defer c.flightGens.bump(url)
+	}
+	attempts := c.retry.attempts()
 	attempt := 0
-	for attempt < maxRetries {
-		resp, err := c.do(method, url, body)
+	for attempt < attempts {
+		resp, err := c.do(ctx, method, url, body, header)
 		if err != nil {
-			if isTransient(err) && attempt < maxRetries-1 {
+			if isTransient(err) && attempt < attempts-1 {
 				wait := c.backoff(attempt, nil)
 				c.log.Warn("transient error, retrying",
 					"attempt", attempt+1,
 					"wait", wait,
 					"err", err,
 				)
-				time.Sleep(wait)
+				if err := sleep(ctx, wait); err != nil {
+					return nil, err
+				}
 				attempt++
 				continue
 			}
 			return nil, err
 		}
 
+		if resp.StatusCode == http.StatusNotModified && header.Get("If-None-Match") != "" {
+			_ = resp.Body.Close()
+			return resp, nil
+		}
+
 		// Successful 2xx — decode response.
 		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			if dest != nil {
+			if decode != nil {
 				defer func() { _ = resp.Body.Close() }()
-				if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
+				if err := decode(json.NewDecoder(resp.Body)); err != nil {
 					return resp, fmt.Errorf("decoding response from %s %s: %w", method, url, err)
 				}
 			} else {
@@ -180,18 +495,22 @@ func (c *Client) doJSON(method, url string, body any, dest any) (*http.Response,
 
 		// Rate limit — sleep until reset and then retry (does not count
 		// against the retry budget).
-		if resp.StatusCode == http.StatusTooManyRequests {
+		if limit := rateLimitKind(resp, errBody); limit != "" {
 			wait := c.rateLimitWait(resp)
-			c.log.Warn("rate limit hit, waiting",
+			c.rate.exhaust(time.Now().Add(wait))
+			c.log.Warn(limit+" rate limit hit, waiting",
+				"status", resp.StatusCode,
 				"wait", wait,
 				"url", url,
 			)
-			time.Sleep(wait)
+			if err := sleep(ctx, wait); err != nil {
+				return nil, err
+			}
 			continue // do NOT increment attempt
 		}
 
 		// Retryable server error.
-		if retryableStatusCodes[resp.StatusCode] && attempt < maxRetries-1 {
+		if retryableStatusCodes[resp.StatusCode] && attempt < attempts-1 {
 			wait := c.backoff(attempt, resp)
 			c.log.Warn("retryable HTTP error, retrying",
 				"status", resp.StatusCode,
@@ -199,7 +518,9 @@ func (c *Client) doJSON(method, url string, body any, dest any) (*http.Response,
 				"wait", wait,
 				"url", url,
 			)
-			time.Sleep(wait)
+			if err := sleep(ctx, wait); err != nil {
+				return nil, err
+			}
 			attempt++
 			continue
 		}
@@ -212,11 +533,12 @@ func (c *Client) doJSON(method, url string, body any, dest any) (*http.Response,
 	}
 
 	// Should not typically be reached, but guard against it.
-	return nil, fmt.Errorf("request to %s %s failed after %d retries", method, url, maxRetries)
+	return nil, fmt.Errorf("request to %s %s failed after %d retries", method, url, attempts)
 }
 
-// do builds and executes a single HTTP request (no retry logic).
-func (c *Client) do(method, url string, body any) (*http.Response, error) {
+// do builds and executes a single HTTP request (no retry logic), adding
+// header to the standard ones.  The request is cancelled when ctx is.
+func (c *Client) do(ctx context.Context, method, url string, body any, header http.Header) (*http.Response, error) {
 	var bodyReader io.Reader
 	if body != nil {
 		b, err := json.Marshal(body)
@@ -226,21 +548,48 @@ func (c *Client) do(method, url string, body any) (*http.Response, error) {
 		bodyReader = bytes.NewReader(b)
 	}
 
-	req, err := http.NewRequest(method, url, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
 
+	for k, v := range header {
+		req.Header[k] = v
+	}
 	req.Header.Set("Accept", acceptHeader)
 	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("X-GitHub-Api-Version", apiVersion)
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("X-GitHub-Api-Version", versionOrDefault(c.apiVersion))
+	token := c.token
+	if c.app != nil {
+		if token, err = c.app.Token(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
 	}
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
+	if wait := c.rate.pause(); wait > 0 {
+		c.log.Warn("rate limit nearly exhausted, pausing until reset",
+			"wait", wait,
+			"reserve", c.rate.reserve,
+		)
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+
+	points := quota.ReadPoints
+	if method != http.MethodGet && method != http.MethodHead {
+		points = quota.WritePoints
+	}
+	if err := c.quota.Reserve(ctx, points); err != nil {
+		return nil, err
+	}
+
 	c.log.Debug("HTTP request",
 		"method", method,
 		"url", url,
@@ -250,6 +599,11 @@ func (c *Client) do(method, url string, body any) (*http.Response, error) {
 	if err != nil {
 		return nil, fmt.Errorf("%s %s: %w", method, url, err)
 	}
+	if resp.Header.Get(cacheStatusHeader) == "hit" {
+		c.log.Debug("Not modified, served from HTTP cache", "url", url)
+	}
+	c.rate.observe(resp)
+	c.perms.record(method, strings.TrimPrefix(req.URL.Path, c.apiPathPrefix()), resp)
 	return resp, nil
 }
 
@@ -265,19 +619,91 @@ func (c *Client) enterpriseURL(path string) string {
 	return fmt.Sprintf("%s/enterprises/%s%s", c.baseURL, c.enterprise, path)
 }
 
+// nextPageURL returns the rel="next" URL of the response's Link header, or
+// "" on the last page.
+//
+//	Link: <https://api.github.com/...?page=2>; rel="next", <...>; rel="last"
+func nextPageURL(resp *http.Response) string {
+	if resp == nil {
+		return ""
+	}
+	for _, link := range strings.Split(resp.Header.Get("Link"), ",") {
+		target, params, ok := strings.Cut(link, ";")
+		if !ok {
+			continue
+		}
+		for _, p := range strings.Split(params, ";") {
+			if strings.TrimSpace(p) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(target), "<>")
+			}
+		}
+	}
+	return ""
+}
+
+// versionOrDefault returns the configured REST API version, or the one the
+// client was written against.
+func versionOrDefault(v string) string {
+	if v == "" {
+		return apiVersion
+	}
+	return v
+}
+
+// apiPathPrefix returns the path component of baseURL (e.g. "/api/v3" on
+// GHES), which is stripped before classifying request paths.
+func (c *Client) apiPathPrefix() string {
+	if i := strings.Index(c.baseURL, "://"); i >= 0 {
+		if j := strings.Index(c.baseURL[i+3:], "/"); j >= 0 {
+			return c.baseURL[i+3+j:]
+		}
+	}
+	return ""
+}
+
 // --------------------------------------------------------------------
 // Retry / back-off helpers
 // --------------------------------------------------------------------
 
-// backoff returns the duration to wait before the next retry.
-// It uses exponential back-off: base * 2^attempt.
+// backoff returns the duration to wait before the next retry (see
+// retryPolicy.backoff).
 func (c *Client) backoff(attempt int, _ *http.Response) time.Duration {
-	return retryBackoffBase * time.Duration(math.Pow(2, float64(attempt)))
+	return c.retry.backoff(attempt)
+}
+
+// rateLimitKind classifies a rate-limited response as "primary" or
+// "secondary", or returns "" when resp is not rate limited.  GitHub answers
+// 429 or 403 for both: a 403 is a rate limit only when it carries
+// Retry-After, reports no remaining calls, or says so in its message, since
+// a plain 403 is a permission error.
+func rateLimitKind(resp *http.Response, body string) string {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusForbidden {
+		return ""
+	}
+	lower := strings.ToLower(body)
+	switch {
+	case resp.Header.Get("Retry-After") != "" || strings.Contains(lower, "secondary rate limit"):
+		return "secondary"
+	case resp.Header.Get("X-RateLimit-Remaining") == "0" || strings.Contains(lower, "rate limit exceeded"):
+		return "primary"
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return "primary"
+	}
+	return ""
 }
 
-// rateLimitWait computes how long to wait based on the X-RateLimit-Reset
-// header.  Falls back to rateLimitFallback when the header is absent.
+// rateLimitWait computes how long to wait from the Retry-After header
+// (seconds or an HTTP date), else the X-RateLimit-Reset header.  Falls back
+// to rateLimitFallback when neither is usable.
 func (c *Client) rateLimitWait(resp *http.Response) time.Duration {
+	if ra := resp.Header.Get("Retry-After"); ra != "" {
+		if secs, err := strconv.Atoi(ra); err == nil && secs >= 0 {
+			return max(time.Duration(secs)*time.Second, time.Second)
+		}
+		if at, err := http.ParseTime(ra); err == nil {
+			return max(time.Until(at), time.Second)
+		}
+	}
 	resetStr := resp.Header.Get("X-RateLimit-Reset")
 	if resetStr == "" {
 		return rateLimitFallback
@@ -314,6 +740,19 @@ func isTransient(err error) bool {
 	return false
 }
 
+// sleep waits for d, returning early with ctx's error when ctx is
+// cancelled first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // readBody reads and returns the response body as a string, capped at 4 KB.
 func readBody(resp *http.Response) string {
 	if resp.Body == nil {
@@ -1,6 +1,8 @@
 package github
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -21,7 +23,42 @@ type CopilotUser struct {
 	LastActivityAt          string `json:"last_activity_at"`
 	LastActivityEditor      string `json:"last_activity_editor"`
 	Plan                    string `json:"plan"`
-	AssigningTeam           any    `json:"assigning_team"` // may be object or null
+	// Organization is the organization that granted the seat, and
+	// AssigningTeam the team it was granted through; nil for seats
+	// assigned directly by the enterprise, or not through a team.
+	Organization  *SeatOrganization `json:"organization,omitempty"`
+	AssigningTeam *SeatTeam         `json:"assigning_team,omitempty"`
+}
+
+// SeatOrganization is the organization a Copilot seat was granted by.
+type SeatOrganization struct {
+	Login string `json:"login"`
+	ID    int64  `json:"id"`
+}
+
+// SeatTeam is the team a Copilot seat was granted through.
+type SeatTeam struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// AssigningOrg returns the login of the organization that granted the
+// seat, or "" for a seat assigned by the enterprise.
+func (u CopilotUser) AssigningOrg() string {
+	if u.Organization == nil {
+		return ""
+	}
+	return u.Organization.Login
+}
+
+// AssigningTeamSlug returns the slug of the team the seat was granted
+// through, or "".
+func (u CopilotUser) AssigningTeamSlug() string {
+	if u.AssigningTeam == nil {
+		return ""
+	}
+	return u.AssigningTeam.Slug
 }
 
 // seatsResponse is the JSON envelope returned by the Copilot billing seats API.
@@ -31,14 +68,15 @@ type seatsResponse struct {
 }
 
 type seatEntry struct {
-	Assignee                assignee `json:"assignee"`
-	CreatedAt               string   `json:"created_at"`
-	UpdatedAt               string   `json:"updated_at"`
-	PendingCancellationDate string   `json:"pending_cancellation_date"`
-	LastActivityAt          string   `json:"last_activity_at"`
-	LastActivityEditor      string   `json:"last_activity_editor"`
-	Plan                    string   `json:"plan"`
-	AssigningTeam           any      `json:"assigning_team"`
+	Assignee                assignee          `json:"assignee"`
+	CreatedAt               string            `json:"created_at"`
+	UpdatedAt               string            `json:"updated_at"`
+	PendingCancellationDate string            `json:"pending_cancellation_date"`
+	LastActivityAt          string            `json:"last_activity_at"`
+	LastActivityEditor      string            `json:"last_activity_editor"`
+	Plan                    string            `json:"plan"`
+	Organization            *SeatOrganization `json:"organization"`
+	AssigningTeam           *SeatTeam         `json:"assigning_team"`
 }
 
 type assignee struct {
@@ -50,55 +88,110 @@ type assignee struct {
 }
 
 // GetCopilotUsers returns all Copilot seat holders across the enterprise,
-// handling pagination and deduplicating by login.
-func (c *Client) GetCopilotUsers() ([]CopilotUser, error) {
+// handling pagination and deduplicating by login.  The seat snapshot is
+// served from the seat bucket when one is attached (see SetBucketCache).
+func (c *Client) GetCopilotUsers(ctx context.Context) ([]CopilotUser, error) {
+	var allUsers []CopilotUser
+	if c.seatCache != nil && c.seatCache.Get(c.enterprise, &allUsers) {
+		c.log.Info("Using cached Copilot seats", "enterprise", c.enterprise, "ttl", c.seatCache.TTL())
+	} else {
+		err := c.EachCopilotUser(ctx, func(u CopilotUser) error {
+			allUsers = append(allUsers, u)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if c.seatCache != nil {
+			if err := c.seatCache.Set(c.enterprise, allUsers); err != nil {
+				c.log.Warn("Could not cache Copilot seats", "error", err)
+			}
+		}
+	}
+
+	c.log.Info("Total Copilot users found", "count", len(allUsers))
+
+	// Deduplicate by login.
+	unique := deduplicateUsers(allUsers, c.log)
+	return unique, nil
+}
+
+// EachUniqueCopilotUser calls fn once per login holding a Copilot seat,
+// with the first seat of that login, like GetCopilotUsers but keeping only
+// the logins seen rather than every seat.  With a seat bucket attached the
+// seats come from GetCopilotUsers, which fills the bucket.
+func (c *Client) EachUniqueCopilotUser(ctx context.Context, fn func(CopilotUser) error) error {
+	if c.seatCache != nil {
+		users, err := c.GetCopilotUsers(ctx)
+		if err != nil {
+			return err
+		}
+		for _, u := range users {
+			if err := fn(u); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	seen := make(map[string]bool)
+	return c.EachCopilotUser(ctx, func(u CopilotUser) error {
+		if u.Login == "" || seen[u.Login] {
+			return nil
+		}
+		seen[u.Login] = true
+		return fn(u)
+	})
+}
+
+// EachCopilotUser calls fn for every Copilot seat across the enterprise as
+// the seats are decoded, so memory stays flat however many seats there are.
+// A login holding several seats is passed once per seat.  An error from fn
+// stops the iteration and is returned.
+func (c *Client) EachCopilotUser(ctx context.Context, fn func(CopilotUser) error) error {
 	c.log.Info("Fetching Copilot users", "enterprise", c.enterprise)
 
 	url := c.enterpriseURL("/copilot/billing/seats")
-	var allUsers []CopilotUser
 	page := 1
 	const perPage = 100
 
 	for {
 		pageURL := fmt.Sprintf("%s?page=%d&per_page=%d", url, page, perPage)
-		var resp seatsResponse
-		if _, err := c.doJSON(http.MethodGet, pageURL, nil, &resp); err != nil {
-			return nil, fmt.Errorf("fetching copilot seats page %d: %w", page, err)
-		}
-
-		if len(resp.Seats) == 0 {
-			break
-		}
-
-		for _, s := range resp.Seats {
-			allUsers = append(allUsers, CopilotUser{
-				Login:                   s.Assignee.Login,
-				ID:                      s.Assignee.ID,
-				Name:                    s.Assignee.Name,
-				Email:                   s.Assignee.Email,
-				Type:                    s.Assignee.Type,
-				CreatedAt:               s.CreatedAt,
-				UpdatedAt:               s.UpdatedAt,
-				PendingCancellationDate: s.PendingCancellationDate,
-				LastActivityAt:          s.LastActivityAt,
-				LastActivityEditor:      s.LastActivityEditor,
-				Plan:                    s.Plan,
-				AssigningTeam:           s.AssigningTeam,
+		var count int
+		_, err := c.doStream(ctx, http.MethodGet, pageURL, nil, func(dec *json.Decoder) error {
+			var err error
+			count, err = streamObjectArray(dec, "seats", func(dec *json.Decoder) error {
+				var s seatEntry
+				if err := dec.Decode(&s); err != nil {
+					return err
+				}
+				return fn(CopilotUser{
+					Login:                   s.Assignee.Login,
+					ID:                      s.Assignee.ID,
+					Name:                    s.Assignee.Name,
+					Email:                   s.Assignee.Email,
+					Type:                    s.Assignee.Type,
+					CreatedAt:               s.CreatedAt,
+					UpdatedAt:               s.UpdatedAt,
+					PendingCancellationDate: s.PendingCancellationDate,
+					LastActivityAt:          s.LastActivityAt,
+					LastActivityEditor:      s.LastActivityEditor,
+					Plan:                    s.Plan,
+					Organization:            s.Organization,
+					AssigningTeam:           s.AssigningTeam,
+				})
 			})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("fetching copilot seats page %d: %w", page, err)
 		}
 
-		c.log.Debug("Fetched copilot seats page", "page", page, "count", len(resp.Seats))
-		if len(resp.Seats) < perPage {
-			break
+		c.log.Debug("Fetched copilot seats page", "page", page, "count", count)
+		if count < perPage {
+			return nil
 		}
 		page++
 	}
-
-	c.log.Info("Total Copilot users found", "count", len(allUsers))
-
-	// Deduplicate by login.
-	unique := deduplicateUsers(allUsers, c.log)
-	return unique, nil
 }
 
 // deduplicateUsers removes duplicate entries, keeping the first occurrence of
@@ -139,20 +232,26 @@ func deduplicateUsers(users []CopilotUser, logger *slog.Logger) []CopilotUser {
 func FilterUsersByTimestamp(users []CopilotUser, after time.Time) []CopilotUser {
 	var filtered []CopilotUser
 	for _, u := range users {
-		if u.CreatedAt == "" {
-			continue
-		}
-		t, err := time.Parse(time.RFC3339, u.CreatedAt)
-		if err != nil {
-			// Try alternative format without timezone (some API responses).
-			t, err = time.Parse("2006-01-02T15:04:05Z", u.CreatedAt)
-			if err != nil {
-				continue
-			}
-		}
-		if t.After(after) {
+		if u.CreatedAfter(after) {
 			filtered = append(filtered, u)
 		}
 	}
 	return filtered
 }
+
+// CreatedAfter reports whether the seat's created_at is strictly after t.
+// A seat without a parseable created_at is not.
+func (u CopilotUser) CreatedAfter(t time.Time) bool {
+	if u.CreatedAt == "" {
+		return false
+	}
+	created, err := time.Parse(time.RFC3339, u.CreatedAt)
+	if err != nil {
+		// Try alternative format without timezone (some API responses).
+		created, err = time.Parse("2006-01-02T15:04:05Z", u.CreatedAt)
+		if err != nil {
+			return false
+		}
+	}
+	return created.After(t)
+}
@@ -1,11 +1,23 @@
 package github
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	neturl "net/url"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+
+	"github.com/renan-alm/gh-cost-center/internal/audit"
+	"github.com/renan-alm/gh-cost-center/internal/cache"
+	"github.com/renan-alm/gh-cost-center/internal/checkpoint"
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/journal"
+	"github.com/renan-alm/gh-cost-center/internal/progress"
 )
 
 // costCentersListResponse is the JSON envelope for the list endpoint.
@@ -103,16 +115,25 @@ func ValidateCostCenterID(id string) error {
 
 // GetAllActiveCostCenters returns a map of cost center name → ID for all
 // active cost centers in the enterprise.
-func (c *Client) GetAllActiveCostCenters() (map[string]string, error) {
-	url := c.enterpriseURL("/settings/billing/cost-centers")
+//
+// With a RunCache attached, the list is fetched once per run and a copy
+// (kept up to date with cost centers created or found gone since) is
+// returned afterwards.  Fetching the list also drops file cache entries
+// that no longer match it, i.e. cost centers deleted or renamed since they
+// were cached.
+func (c *Client) GetAllActiveCostCenters(ctx context.Context) (map[string]string, error) {
+	if active, ok := c.run.active(); ok {
+		c.log.Debug("Using cached active cost centers", "count", len(active))
+		return active, nil
+	}
 
-	var resp costCentersListResponse
-	if _, err := c.doJSON(http.MethodGet, url, nil, &resp); err != nil {
-		return nil, fmt.Errorf("fetching cost centers: %w", err)
+	all, err := c.GetAllCostCenters(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	active := make(map[string]string)
-	for _, cc := range resp.CostCenters {
+	for _, cc := range all {
 		if cc.State == "active" && cc.Name != "" && cc.ID != "" {
 			active[cc.Name] = cc.ID
 			// Populate cache with every active cost center.
@@ -121,28 +142,117 @@ func (c *Client) GetAllActiveCostCenters() (map[string]string, error) {
 			}
 		}
 	}
-	c.log.Debug("Found active cost centers", "active", len(active), "total", len(resp.CostCenters))
+	c.log.Debug("Found active cost centers", "active", len(active), "total", len(all))
+	if c.ccCache != nil {
+		n, err := c.ccCache.Retain(func(_ string, e cache.Entry) bool { return active[e.Name] == e.ID })
+		if err != nil {
+			c.log.Warn("Could not save cost center cache", "error", err)
+		} else if n > 0 {
+			c.log.Info("Dropped stale cost center cache entries", "count", n)
+		}
+	}
+	c.run.setActive(active)
 	return active, nil
 }
 
-// GetCostCenter returns the details of a single cost center including its
-// assigned resources.
-func (c *Client) GetCostCenter(id string) (*costCenterDetailResponse, error) {
+// PreloadActiveCostCenters fetches the active cost centers once and serves
+// later GetAllActiveCostCenters calls from memory, so several assignment
+// modes run with one client share a single lookup.  A RunCache is attached
+// if the client has none.
+func (c *Client) PreloadActiveCostCenters(ctx context.Context) error {
+	if c.run == nil {
+		c.run = NewRunCache()
+	}
+	c.run.resetActive()
+	_, err := c.GetAllActiveCostCenters(ctx)
+	return err
+}
+
+// CreationRecorder keeps track of the cost centers a client has created.
+// config.Manager implements it with a file in the export dir.
+type CreationRecorder interface {
+	RecordCostCenterCreated(id string) error
+}
+
+// SetCreationRecorder attaches a recorder that is told about every cost
+// center the client creates.
+func (c *Client) SetCreationRecorder(r CreationRecorder) {
+	c.creations = r
+}
+
+// recordCreated passes a newly created cost center to the recorder, if any.
+// Failures are logged only.
+func (c *Client) recordCreated(id string) {
+	if c.creations == nil {
+		return
+	}
+	if err := c.creations.RecordCostCenterCreated(id); err != nil {
+		c.log.Warn("Could not record cost center creation", "id", id, "error", err)
+	}
+}
+
+// rememberActive records a created or resolved cost center in the run
+// cache, if any.
+func (c *Client) rememberActive(name, id string) {
+	c.run.rememberActive(name, id)
+}
+
+// costCenterGone forgets a cost center the API reports as missing or
+// deleted, in the run cache and the file cache, so neither a later mode of
+// this run nor a later run reuses its ID.
+func (c *Client) costCenterGone(id string) {
+	c.run.forgetCostCenter(id)
+	if c.ccCache == nil {
+		return
+	}
+	if n, err := c.ccCache.DeleteID(id); err != nil {
+		c.log.Warn("Could not save cost center cache", "error", err)
+	} else if n > 0 {
+		c.log.Info("Dropped cache entries of missing cost center", "id", id, "count", n)
+	}
+}
+
+// GetCostCenter returns the details of a single cost center including all
+// of its assigned resources.  Resources are fetched 100 per page, following
+// the Link header, so cost centers with thousands of users or repositories
+// come back complete.
+func (c *Client) GetCostCenter(ctx context.Context, id string) (*costCenterDetailResponse, error) {
 	if err := ValidateCostCenterID(id); err != nil {
 		return nil, err
 	}
-	url := c.enterpriseURL(fmt.Sprintf("/settings/billing/cost-centers/%s", id))
-	var resp costCenterDetailResponse
-	if _, err := c.doJSON(http.MethodGet, url, nil, &resp); err != nil {
-		return nil, fmt.Errorf("fetching cost center %s: %w", id, err)
+	pageURL := c.enterpriseURL(fmt.Sprintf("/settings/billing/cost-centers/%s", id)) + "?per_page=100"
+
+	var detail *costCenterDetailResponse
+	for page := 1; pageURL != ""; page++ {
+		var resp costCenterDetailResponse
+		httpResp, err := c.doJSON(ctx, http.MethodGet, pageURL, nil, &resp)
+		if err != nil {
+			if IsCostCenterNotFound(err) {
+				c.costCenterGone(id)
+			}
+			return nil, fmt.Errorf("fetching cost center %s resources page %d: %w", id, page, err)
+		}
+		if detail == nil {
+			detail = &resp
+		} else {
+			detail.Resources = append(detail.Resources, resp.Resources...)
+		}
+		pageURL = nextPageURL(httpResp)
+		if pageURL != "" {
+			c.log.Debug("Fetched cost center resources page", "cost_center_id", id, "page", page, "count", len(resp.Resources))
+		}
 	}
-	return &resp, nil
+	return detail, nil
 }
 
 // GetCostCenterMembers returns the usernames of all users assigned to the
 // given cost center.
-func (c *Client) GetCostCenterMembers(id string) ([]string, error) {
-	detail, err := c.GetCostCenter(id)
+func (c *Client) GetCostCenterMembers(ctx context.Context, id string) ([]string, error) {
+	if users, ok := c.run.costCenterMembers(id); ok {
+		c.log.Debug("Using cached cost center members", "cost_center_id", id, "count", len(users))
+		return users, nil
+	}
+	detail, err := c.GetCostCenter(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -153,13 +263,58 @@ func (c *Client) GetCostCenterMembers(id string) ([]string, error) {
 		}
 	}
 	c.log.Debug("Cost center members", "cost_center_id", id, "count", len(users))
+	c.run.setCostCenterMembers(id, users)
 	return users, nil
 }
 
+// GetCostCenterRepositories returns the full names of all repositories
+// assigned to the given cost center.
+func (c *Client) GetCostCenterRepositories(ctx context.Context, id string) ([]string, error) {
+	detail, err := c.GetCostCenter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var repos []string
+	for _, r := range detail.Resources {
+		if r.Type == "Repository" && r.Name != "" {
+			repos = append(repos, r.Name)
+		}
+	}
+	return repos, nil
+}
+
+// DeletedCostCenterError is returned when a cost center name collides with a
+// cost center in the "deleted" state.  The API rejects the create with 409
+// and reports the deleted UUID, which must not be reused for assignments.
+type DeletedCostCenterError struct {
+	Name string
+	ID   string
+}
+
+func (e *DeletedCostCenterError) Error() string {
+	return fmt.Sprintf(
+		"cost center name %q matches a deleted cost center (%s) — restore it in enterprise billing settings, "+
+			"choose a different name, or set cost_center.deleted_name_collision: suffix to create %q instead",
+		e.Name, e.ID, suffixedName(e.Name, 2))
+}
+
+// maxNameSuffix bounds the numeric suffixes tried when recreating a cost
+// center whose name collides with deleted ones.
+const maxNameSuffix = 20
+
+// suffixedName returns "name (n)".
+func suffixedName(name string, n int) string {
+	return fmt.Sprintf("%s (%d)", name, n)
+}
+
 // CreateCostCenter creates a new cost center with the given name.  If the cost
 // center already exists (409 Conflict) it attempts to extract the existing UUID
 // from the error message.  If that fails it falls back to searching by name.
-func (c *Client) CreateCostCenter(name string) (string, error) {
+//
+// When the existing cost center is in the "deleted" state a
+// *DeletedCostCenterError is returned, unless the deleted-collision policy is
+// "suffix", in which case "name (2)", "name (3)", … are tried instead.
+func (c *Client) CreateCostCenter(ctx context.Context, name string) (string, error) {
 	// Check cache first.
 	if c.ccCache != nil {
 		if entry, ok := c.ccCache.Get(name); ok {
@@ -168,13 +323,46 @@ func (c *Client) CreateCostCenter(name string) (string, error) {
 		}
 	}
 
+	id, err := c.createCostCenter(ctx, name)
+	var delErr *DeletedCostCenterError
+	if !errors.As(err, &delErr) || c.deletedCollisionPolicy != config.DeletedCollisionSuffix {
+		return id, err
+	}
+
+	c.log.Warn("Cost center name collides with a deleted cost center, creating with suffix",
+		"name", name, "deleted_id", delErr.ID)
+	for n := 2; n <= maxNameSuffix; n++ {
+		candidate := suffixedName(name, n)
+		id, err = c.createCostCenter(ctx, candidate)
+		if errors.As(err, &delErr) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		c.log.Info("Using suffixed cost center in place of deleted one", "name", name, "suffixed_name", candidate, "id", id)
+		c.rememberActive(name, id)
+		if c.ccCache != nil {
+			_ = c.ccCache.Set(name, id, candidate)
+		}
+		return id, nil
+	}
+	return "", fmt.Errorf("creating cost center %q: names up to %q all collide with deleted cost centers",
+		name, suffixedName(name, maxNameSuffix))
+}
+
+// createCostCenter performs a single create attempt for the exact name.
+func (c *Client) createCostCenter(ctx context.Context, name string) (string, error) {
 	url := c.enterpriseURL("/settings/billing/cost-centers")
 	body := map[string]string{"name": name}
 
 	var resp costCenterCreateResponse
-	_, err := c.doJSON(http.MethodPost, url, body, &resp)
+	_, err := c.doJSON(ctx, http.MethodPost, url, body, &resp)
 	if err == nil {
 		c.log.Info("Created cost center", "name", name, "id", resp.ID)
+		c.emitAudit(audit.Event{Action: audit.ActionCostCenterCreated, CostCenterID: resp.ID, CostCenter: name}, nil)
+		c.recordCreated(resp.ID)
+		c.rememberActive(name, resp.ID)
 		// Update cache with newly created cost center.
 		if c.ccCache != nil {
 			_ = c.ccCache.Set(name, resp.ID, name)
@@ -188,7 +376,11 @@ func (c *Client) CreateCostCenter(name string) (string, error) {
 		c.log.Info("Cost center already exists, extracting existing ID", "name", name)
 
 		if m := uuidFromConflictRe.FindStringSubmatch(apiErr.Body); len(m) == 2 {
+			if c.isCostCenterDeleted(ctx, m[1]) {
+				return "", &DeletedCostCenterError{Name: name, ID: m[1]}
+			}
 			c.log.Info("Extracted existing cost center ID from API response", "id", m[1])
+			c.rememberActive(name, m[1])
 			// Update cache with extracted ID.
 			if c.ccCache != nil {
 				_ = c.ccCache.Set(name, m[1], name)
@@ -197,19 +389,42 @@ func (c *Client) CreateCostCenter(name string) (string, error) {
 		}
 
 		c.log.Warn("Could not extract UUID from 409 response, falling back to name search", "name", name)
-		return c.findCostCenterByName(name)
+		return c.findCostCenterByName(ctx, name)
 	}
 
+	c.emitAudit(audit.Event{Action: audit.ActionCostCenterCreated, CostCenter: name}, err)
 	return "", fmt.Errorf("creating cost center %q: %w", name, err)
 }
 
+// isCostCenterDeleted reports whether the cost center with the given ID is in
+// the "deleted" state.  Lookup failures are treated as "not deleted" so the
+// previous behaviour (reuse the ID) is preserved when the detail endpoint is
+// unavailable.
+func (c *Client) isCostCenterDeleted(ctx context.Context, id string) bool {
+	detail, err := c.GetCostCenter(ctx, id)
+	if err != nil {
+		c.log.Debug("Could not check cost center state", "id", id, "error", err)
+		return false
+	}
+	if detail.State == "deleted" {
+		c.costCenterGone(id)
+		return true
+	}
+	return false
+}
+
 // CreateCostCenterWithPreload creates a cost center with preload optimization.
-// If the name already exists in the given map, it returns the cached ID.
-// On successful creation (or 409 extraction), it updates the map.
-func (c *Client) CreateCostCenterWithPreload(name string, activeMap map[string]string) (string, error) {
+// If the name already exists in the given map, it returns the cached ID,
+// unless the cost center has since been found missing or deleted.  On
+// successful creation (or 409 extraction), it updates the map.
+func (c *Client) CreateCostCenterWithPreload(ctx context.Context, name string, activeMap map[string]string) (string, error) {
 	if id, ok := activeMap[name]; ok {
-		c.log.Debug("Found cost center in preload map", "name", name, "id", id)
-		return id, nil
+		if !c.run.isGone(id) {
+			c.log.Debug("Found cost center in preload map", "name", name, "id", id)
+			return id, nil
+		}
+		c.log.Debug("Preloaded cost center is gone, recreating", "name", name, "id", id)
+		delete(activeMap, name)
 	}
 
 	// Check file-based cache before making API call.
@@ -221,7 +436,7 @@ func (c *Client) CreateCostCenterWithPreload(name string, activeMap map[string]s
 		}
 	}
 
-	id, err := c.CreateCostCenter(name)
+	id, err := c.CreateCostCenter(ctx, name)
 	if err != nil {
 		return "", err
 	}
@@ -229,31 +444,105 @@ func (c *Client) CreateCostCenterWithPreload(name string, activeMap map[string]s
 	return id, nil
 }
 
+// GetAllCostCenters returns every cost center in the enterprise regardless of
+// state ("active", "deleted", …), following the Link header across pages.
+func (c *Client) GetAllCostCenters(ctx context.Context) ([]CostCenter, error) {
+	pageURL := c.enterpriseURL("/settings/billing/cost-centers") + "?per_page=100"
+
+	var all []CostCenter
+	for page := 1; pageURL != ""; page++ {
+		var resp costCentersListResponse
+		httpResp, err := c.doJSON(ctx, http.MethodGet, pageURL, nil, &resp)
+		if err != nil {
+			return nil, fmt.Errorf("fetching cost centers page %d: %w", page, err)
+		}
+		all = append(all, resp.CostCenters...)
+		c.log.Debug("Fetched cost centers page", "page", page, "count", len(resp.CostCenters))
+		pageURL = nextPageURL(httpResp)
+	}
+	return all, nil
+}
+
+// CostCenterCounts is a cost center with the number of resources of each
+// type assigned to it.
+type CostCenterCounts struct {
+	CostCenter
+	Users        int
+	Repositories int
+	Other        int // organizations and any other resource type
+}
+
+// CountCostCenterResources fetches the resources of each cost center, on up
+// to the client's parallelism at once, and counts them by type.  Cost
+// centers that are not active are not fetched and count as empty.
+func (c *Client) CountCostCenterResources(ctx context.Context, ccs []CostCenter) ([]CostCenterCounts, error) {
+	out := make([]CostCenterCounts, len(ccs))
+	errs := make([]error, len(ccs))
+	c.parallel(ctx, len(ccs), func(i int) {
+		out[i].CostCenter = ccs[i]
+		if ccs[i].State != "active" {
+			return
+		}
+		detail, err := c.GetCostCenter(ctx, ccs[i].ID)
+		if err != nil {
+			errs[i] = fmt.Errorf("counting resources of cost center %q: %w", ccs[i].Name, err)
+			return
+		}
+		for _, r := range detail.Resources {
+			switch r.Type {
+			case "User":
+				out[i].Users++
+			case "Repository":
+				out[i].Repositories++
+			default:
+				out[i].Other++
+			}
+		}
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return out, errors.Join(errs...)
+}
+
 // findCostCenterByName searches the list of all cost centers for an active one
-// with the exact name.
-func (c *Client) findCostCenterByName(name string) (string, error) {
-	active, err := c.GetAllActiveCostCenters()
+// with the exact name.  When only a deleted cost center carries the name a
+// *DeletedCostCenterError is returned.
+func (c *Client) findCostCenterByName(ctx context.Context, name string) (string, error) {
+	all, err := c.GetAllCostCenters(ctx)
 	if err != nil {
 		return "", fmt.Errorf("finding cost center by name %q: %w", name, err)
 	}
-	if id, ok := active[name]; ok {
-		c.log.Info("Found active cost center by name", "name", name, "id", id)
-		return id, nil
+	var deletedID string
+	for _, cc := range all {
+		if cc.Name != name || cc.ID == "" {
+			continue
+		}
+		if cc.State == "active" {
+			c.log.Info("Found active cost center by name", "name", name, "id", cc.ID)
+			return cc.ID, nil
+		}
+		if cc.State == "deleted" {
+			deletedID = cc.ID
+		}
+	}
+	if deletedID != "" {
+		return "", &DeletedCostCenterError{Name: name, ID: deletedID}
 	}
 	return "", fmt.Errorf("no active cost center found with name %q", name)
 }
 
 // EnsureCostCentersExist creates (or retrieves) the two PRU-tier cost centers,
 // returning their IDs.
-func (c *Client) EnsureCostCentersExist(noPRUName, pruAllowedName string) (noPRUID, pruAllowedID string, err error) {
+func (c *Client) EnsureCostCentersExist(ctx context.Context, noPRUName, pruAllowedName string) (noPRUID, pruAllowedID string, err error) {
 	c.log.Info("Ensuring cost center exists", "name", noPRUName)
-	noPRUID, err = c.CreateCostCenter(noPRUName)
+	noPRUID, err = c.CreateCostCenter(ctx, noPRUName)
 	if err != nil {
 		return "", "", fmt.Errorf("ensuring cost center %q: %w", noPRUName, err)
 	}
 
 	c.log.Info("Ensuring cost center exists", "name", pruAllowedName)
-	pruAllowedID, err = c.CreateCostCenter(pruAllowedName)
+	pruAllowedID, err = c.CreateCostCenter(ctx, pruAllowedName)
 	if err != nil {
 		return "", "", fmt.Errorf("ensuring cost center %q: %w", pruAllowedName, err)
 	}
@@ -264,10 +553,10 @@ func (c *Client) EnsureCostCentersExist(noPRUName, pruAllowedName string) (noPRU
 
 // ResolveCostCenters resolves two cost center names to UUIDs without creating
 // them.  Returns an error listing any names that could not be found.
-func (c *Client) ResolveCostCenters(noPRUName, pruAllowedName string) (noPRUID, pruAllowedID string, err error) {
+func (c *Client) ResolveCostCenters(ctx context.Context, noPRUName, pruAllowedName string) (noPRUID, pruAllowedID string, err error) {
 	c.log.Info("Resolving cost center names to IDs (no creation)")
 
-	activeMap, err := c.GetAllActiveCostCenters()
+	activeMap, err := c.GetAllActiveCostCenters(ctx)
 	if err != nil {
 		return "", "", fmt.Errorf("fetching active cost centers for resolution: %w", err)
 	}
@@ -304,7 +593,7 @@ func (c *Client) ResolveCostCenters(noPRUName, pruAllowedName string) (noPRUID,
 // are skipped.  When true, users are added regardless of existing membership.
 //
 // Returns a map of username → success status.
-func (c *Client) AddUsersToCostCenter(costCenterID string, usernames []string, ignoreCurrentCC bool) (map[string]bool, error) {
+func (c *Client) AddUsersToCostCenter(ctx context.Context, costCenterID string, usernames []string, ignoreCurrentCC bool) (map[string]bool, error) {
 	if len(usernames) == 0 {
 		return map[string]bool{}, nil
 	}
@@ -314,9 +603,20 @@ func (c *Client) AddUsersToCostCenter(costCenterID string, usernames []string, i
 	}
 
 	results := make(map[string]bool, len(usernames))
+	done, usernames := c.checkpoint.Filter(checkpoint.KindUsers, costCenterID, usernames)
+	for _, u := range done {
+		results[u] = true
+	}
+	if len(done) > 0 {
+		c.log.Info("Skipping users added before the apply was interrupted",
+			"cost_center_id", costCenterID, "count", len(done))
+	}
+	if len(usernames) == 0 {
+		return results, nil
+	}
 
 	// Check which users are already in the target cost center.
-	currentMembers, err := c.GetCostCenterMembers(costCenterID)
+	currentMembers, err := c.GetCostCenterMembers(ctx, costCenterID)
 	if err != nil {
 		if IsCostCenterNotFound(err) {
 			return nil, fmt.Errorf(
@@ -336,7 +636,7 @@ func (c *Client) AddUsersToCostCenter(costCenterID string, usernames []string, i
 		}
 
 		if !ignoreCurrentCC {
-			mem, _ := c.CheckUserCostCenterMembership(u)
+			mem, _ := c.CheckUserCostCenterMembership(ctx, u)
 			if mem != nil {
 				c.log.Info("Skipping user already in another cost center",
 					"user", u, "current_cost_center", mem.Name)
@@ -358,84 +658,150 @@ func (c *Client) AddUsersToCostCenter(costCenterID string, usernames []string, i
 		"already_assigned", len(usernames)-len(toAdd),
 	)
 
-	// Chunk into batches of 50.
+	// Chunk into batches of 50, submitted up to the configured parallelism
+	// at a time.
 	const batchSize = 50
-	for i := 0; i < len(toAdd); i += batchSize {
-		end := i + batchSize
-		if end > len(toAdd) {
-			end = len(toAdd)
-		}
-		batch := toAdd[i:end]
-
-		url := c.enterpriseURL(fmt.Sprintf("/settings/billing/cost-centers/%s/resource", costCenterID))
-		body := map[string]any{"users": batch}
-
-		_, err := c.doJSON(http.MethodPost, url, body, nil)
+	var batches [][]string
+	for batch := range slices.Chunk(toAdd, batchSize) {
+		batches = append(batches, batch)
+	}
+	var mu sync.Mutex
+	c.parallel(ctx, len(batches), func(i int) {
+		batch := batches[i]
+		err := c.postResources(ctx, costCenterID, "users", batch)
 		if err != nil {
 			c.log.Error("Failed to add users batch", "cost_center_id", costCenterID, "batch_size", len(batch), "error", err)
-			for _, u := range batch {
-				results[u] = false
+			c.recordFailure(journal.OpAddUsers, costCenterID, batch, err, 1)
+			c.emitAudit(audit.Event{Action: audit.ActionUsersAdded, CostCenterID: costCenterID, Resources: batch}, err)
+		} else {
+			c.log.Info("Successfully added users batch", "cost_center_id", costCenterID, "batch_size", len(batch))
+			c.run.usersAdded(costCenterID, batch)
+			if cerr := c.checkpoint.Record(checkpoint.KindUsers, costCenterID, batch); cerr != nil {
+				c.log.Warn("Could not update apply checkpoint", "error", cerr)
 			}
-			continue
+			c.emitAudit(audit.Event{Action: audit.ActionUsersAdded, CostCenterID: costCenterID, Resources: batch}, nil)
 		}
-		c.log.Info("Successfully added users batch", "cost_center_id", costCenterID, "batch_size", len(batch))
+		mu.Lock()
+		defer mu.Unlock()
 		for _, u := range batch {
-			results[u] = true
+			results[u] = err == nil
 		}
-	}
+	})
 
 	return results, nil
 }
 
 // BulkUpdateCostCenterAssignments processes multiple cost center → usernames
-// mappings, chunking and deduplicating as needed.
-func (c *Client) BulkUpdateCostCenterAssignments(assignments map[string][]string, ignoreCurrentCC bool) (map[string]map[string]bool, error) {
+// mappings, chunking and deduplicating as needed.  Cost centers are applied
+// in cost_center.apply_order when configured, otherwise sorted by ID.  With
+// cost_center.apply_parallelism above 1, cost centers outside apply_order's
+// first and last lists are applied concurrently; first and last keep their
+// serial order.  Logs name each cost center alongside its ID.  The results
+// name each cost center, are ordered by name and are also added to
+// ApplyResults.
+func (c *Client) BulkUpdateCostCenterAssignments(ctx context.Context, assignments map[string][]string, ignoreCurrentCC bool) ([]CostCenterResult, error) {
 	results := make(map[string]map[string]bool)
 	totalUsers := 0
 	successUsers := 0
 	failedUsers := 0
 
+	ids := make([]string, 0, len(assignments))
 	for ccID, usernames := range assignments {
-		if len(usernames) == 0 {
-			continue
+		if len(usernames) > 0 {
+			ids = append(ids, ccID)
 		}
-		totalUsers += len(usernames)
+	}
+	var first, last []string
+	if c.applyOrder.configured() {
+		first, last = c.resolveApplyOrderIDs(ctx)
+		ids = sortCostCenterIDs(ids, first, last)
+		c.log.Info("Applying cost centers in configured order", "order", strings.Join(ids, ", "))
+	} else {
+		sort.Strings(ids)
+	}
+	names := c.costCenterNames(ctx)
+	head, middle, tail := splitApplyPhases(ids, first, last)
+	if c.parallelism > 1 && len(middle) > 1 {
+		c.log.Info("Applying cost centers in parallel", "cost_centers", len(middle), "parallelism", c.parallelism)
+	}
 
-		ccResults, err := c.AddUsersToCostCenter(ccID, usernames, ignoreCurrentCC)
+	var mu sync.Mutex
+	record := func(ccID string, ccResults map[string]bool) (ccFailed bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[ccID] = ccResults
+		c.progress.Emit(progress.PhaseApply, len(results), len(ids), ccID)
+		totalUsers += len(assignments[ccID])
+		for _, ok := range ccResults {
+			if ok {
+				successUsers++
+			} else {
+				failedUsers++
+				ccFailed = true
+			}
+		}
+		return ccFailed
+	}
+	failAll := func(usernames []string) map[string]bool {
+		ccResults := make(map[string]bool, len(usernames))
+		for _, u := range usernames {
+			ccResults[u] = false
+		}
+		return ccResults
+	}
+
+	blocked := ""
+	apply := func(ccID string) (ccFailed bool) {
+		if ctx.Err() != nil {
+			return false // interrupted: leave the rest untouched
+		}
+		usernames := assignments[ccID]
+		if blocked != "" {
+			c.log.Error("Skipping cost center: an apply-first cost center did not complete",
+				"cost_center", names[ccID], "cost_center_id", ccID, "blocked_by", blocked, "blocked_by_name", names[blocked])
+			return record(ccID, failAll(usernames))
+		}
+
+		ccResults, err := c.AddUsersToCostCenter(ctx, ccID, usernames, ignoreCurrentCC)
 		if err != nil {
 			if IsCostCenterNotFound(err) {
 				c.log.Error("Cost center not found — this usually means a cost center name was used instead of a UUID",
+					"cost_center", names[ccID],
 					"cost_center_id", ccID,
 					"hint", "enable auto_create_cost_centers or verify the ID in enterprise billing settings",
 					"error", err)
 			} else {
-				c.log.Error("Failed to update cost center assignments", "cost_center_id", ccID, "error", err)
-			}
-			ccResults = make(map[string]bool, len(usernames))
-			for _, u := range usernames {
-				ccResults[u] = false
+				c.log.Error("Failed to update cost center assignments", "cost_center", names[ccID], "cost_center_id", ccID, "error", err)
 			}
+			ccResults = failAll(usernames)
 		}
-		results[ccID] = ccResults
+		return record(ccID, ccResults)
+	}
 
-		for _, ok := range ccResults {
-			if ok {
-				successUsers++
-			} else {
-				failedUsers++
-			}
+	for _, ccID := range head {
+		if apply(ccID) && c.applyOrder.firstMustSucceed && blocked == "" {
+			blocked = ccID
 		}
 	}
+	c.parallel(ctx, len(middle), func(i int) { apply(middle[i]) })
+	for _, ccID := range tail {
+		apply(ccID)
+	}
 
+	named := ResultsByCostCenter(results, names)
+	c.applied.add(named)
 	c.log.Info("Assignment results", "successful", successUsers, "total", totalUsers)
 	if failedUsers > 0 {
 		c.log.Error("Some users failed assignment", "failed", failedUsers)
 	}
-	return results, nil
+	if err := ctx.Err(); err != nil {
+		return named, fmt.Errorf("interrupted after %d of %d cost centers: %w", len(results), len(ids), err)
+	}
+	return named, nil
 }
 
 // RemoveUsersFromCostCenter removes a list of usernames from a cost center.
-func (c *Client) RemoveUsersFromCostCenter(costCenterID string, usernames []string) (map[string]bool, error) {
+func (c *Client) RemoveUsersFromCostCenter(ctx context.Context, costCenterID string, usernames []string) (map[string]bool, error) {
 	if len(usernames) == 0 {
 		return map[string]bool{}, nil
 	}
@@ -444,13 +810,12 @@ func (c *Client) RemoveUsersFromCostCenter(costCenterID string, usernames []stri
 		return nil, err
 	}
 
-	url := c.enterpriseURL(fmt.Sprintf("/settings/billing/cost-centers/%s/resource", costCenterID))
-	body := map[string]any{"users": usernames}
-
-	_, err := c.doJSON(http.MethodDelete, url, body, nil)
+	err := c.deleteResources(ctx, costCenterID, "users", usernames)
 	if err != nil {
 		c.log.Error("Failed to remove users from cost center",
 			"cost_center_id", costCenterID, "error", err)
+		c.recordFailure(journal.OpRemoveUsers, costCenterID, usernames, err, 1)
+		c.emitAudit(audit.Event{Action: audit.ActionUsersRemoved, CostCenterID: costCenterID, Resources: usernames}, err)
 		result := make(map[string]bool, len(usernames))
 		for _, u := range usernames {
 			result[u] = false
@@ -460,6 +825,8 @@ func (c *Client) RemoveUsersFromCostCenter(costCenterID string, usernames []stri
 
 	c.log.Info("Successfully removed users from cost center",
 		"cost_center_id", costCenterID, "count", len(usernames))
+	c.run.usersRemoved(costCenterID, usernames)
+	c.emitAudit(audit.Event{Action: audit.ActionUsersRemoved, CostCenterID: costCenterID, Resources: usernames}, nil)
 	result := make(map[string]bool, len(usernames))
 	for _, u := range usernames {
 		result[u] = true
@@ -467,31 +834,101 @@ func (c *Client) RemoveUsersFromCostCenter(costCenterID string, usernames []stri
 	return result, nil
 }
 
+// Resource types accepted by the cost center memberships endpoint.
+const (
+	ResourceTypeUser = "user"
+	ResourceTypeRepo = "repo"
+	ResourceTypeOrg  = "org"
+)
+
 // CheckUserCostCenterMembership checks whether a user belongs to any cost
 // center.  Returns the cost center reference if found, nil otherwise.
-func (c *Client) CheckUserCostCenterMembership(username string) (*CostCenterRef, error) {
+func (c *Client) CheckUserCostCenterMembership(ctx context.Context, username string) (*CostCenterRef, error) {
+	return c.CheckCostCenterMembership(ctx, ResourceTypeUser, username)
+}
+
+// CheckRepositoryCostCenterMembership checks whether a repository (full
+// "org/repo" name) belongs to any cost center.
+func (c *Client) CheckRepositoryCostCenterMembership(ctx context.Context, fullName string) (*CostCenterRef, error) {
+	return c.CheckCostCenterMembership(ctx, ResourceTypeRepo, fullName)
+}
+
+// CheckOrganizationCostCenterMembership checks whether an organization
+// belongs to any cost center.
+func (c *Client) CheckOrganizationCostCenterMembership(ctx context.Context, org string) (*CostCenterRef, error) {
+	return c.CheckCostCenterMembership(ctx, ResourceTypeOrg, org)
+}
+
+// CheckCostCenterMembership checks whether a resource of the given type
+// ("user", "repo" or "org") belongs to any cost center.  Returns the cost
+// center reference if found, nil otherwise.  Lookup failures are treated as
+// "not in any cost center" so callers can fall through to assignment.
+func (c *Client) CheckCostCenterMembership(ctx context.Context, resourceType, name string) (*CostCenterRef, error) {
+	if err := checkMembershipType(resourceType); err != nil {
+		return nil, err
+	}
+	ref, err := c.LookupCostCenterMembership(ctx, resourceType, name)
+	if err != nil {
+		c.log.Debug("Failed to check cost center membership",
+			"resource_type", resourceType, "name", name, "error", err)
+		return nil, nil // treat lookup failures as "not in any cost center"
+	}
+	return ref, nil
+}
+
+// LookupCostCenterMembership is CheckCostCenterMembership for callers that
+// must tell "in no cost center" (nil, nil) from a failed lookup.
+func (c *Client) LookupCostCenterMembership(ctx context.Context, resourceType, name string) (*CostCenterRef, error) {
+	if err := checkMembershipType(resourceType); err != nil {
+		return nil, err
+	}
+	if ref, ok := c.run.membership(resourceType, name); ok {
+		c.log.Debug("Using cached cost center membership", "resource_type", resourceType, "name", name)
+		return ref, nil
+	}
+
 	url := c.enterpriseURL(fmt.Sprintf(
-		"/settings/billing/cost-centers/memberships?resource_type=user&name=%s", username,
+		"/settings/billing/cost-centers/memberships?resource_type=%s&name=%s",
+		resourceType, neturl.QueryEscape(name),
 	))
 
 	var resp membershipResponse
-	if _, err := c.doJSON(http.MethodGet, url, nil, &resp); err != nil {
-		c.log.Debug("Failed to check cost center membership", "user", username, "error", err)
-		return nil, nil // treat lookup failures as "not in any cost center"
+	if _, err := c.doJSON(ctx, http.MethodGet, url, nil, &resp); err != nil {
+		return nil, fmt.Errorf("looking up cost center of %s %q: %w", resourceType, name, err)
 	}
 
 	if len(resp.Memberships) > 0 {
 		ref := &resp.Memberships[0].CostCenter
-		c.log.Debug("User belongs to cost center", "user", username, "cost_center_id", ref.ID)
+		c.log.Debug("Resource belongs to cost center",
+			"resource_type", resourceType, "name", name, "cost_center_id", ref.ID)
+		c.run.setMembership(resourceType, name, ref)
 		return ref, nil
 	}
-	c.log.Debug("User not in any cost center", "user", username)
+	c.log.Debug("Resource not in any cost center", "resource_type", resourceType, "name", name)
+	c.run.setMembership(resourceType, name, nil)
 	return nil, nil
 }
 
+func checkMembershipType(resourceType string) error {
+	switch resourceType {
+	case ResourceTypeUser, ResourceTypeRepo, ResourceTypeOrg:
+		return nil
+	}
+	return fmt.Errorf("unsupported membership resource type %q: must be user, repo, or org", resourceType)
+}
+
 // AddRepositoriesToCostCenter adds repository full-names (org/repo) to a cost
 // center.
-func (c *Client) AddRepositoriesToCostCenter(costCenterID string, repoNames []string) error {
+func (c *Client) AddRepositoriesToCostCenter(ctx context.Context, costCenterID string, repoNames []string) error {
+	if len(repoNames) == 0 {
+		return nil
+	}
+
+	done, repoNames := c.checkpoint.Filter(checkpoint.KindRepositories, costCenterID, repoNames)
+	if len(done) > 0 {
+		c.log.Info("Skipping repositories added before the apply was interrupted",
+			"cost_center_id", costCenterID, "count", len(done))
+	}
 	if len(repoNames) == 0 {
 		return nil
 	}
@@ -499,16 +936,100 @@ func (c *Client) AddRepositoriesToCostCenter(costCenterID string, repoNames []st
 	c.log.Info("Adding repositories to cost center",
 		"cost_center_id", costCenterID, "count", len(repoNames))
 
-	url := c.enterpriseURL(fmt.Sprintf("/settings/billing/cost-centers/%s/resource", costCenterID))
-	body := map[string]any{"repositories": repoNames}
-
-	_, err := c.doJSON(http.MethodPost, url, body, nil)
-	if err != nil {
+	if err := c.postResources(ctx, costCenterID, "repositories", repoNames); err != nil {
+		c.recordFailure(journal.OpAddRepositories, costCenterID, repoNames, err, 1)
+		c.emitAudit(audit.Event{Action: audit.ActionReposAdded, CostCenterID: costCenterID, Resources: repoNames}, err)
 		return fmt.Errorf("adding repositories to cost center %s: %w", costCenterID, err)
 	}
 
 	c.log.Info("Successfully added repositories to cost center",
 		"cost_center_id", costCenterID, "count", len(repoNames))
+	c.run.reposAdded(repoNames)
+	if err := c.checkpoint.Record(checkpoint.KindRepositories, costCenterID, repoNames); err != nil {
+		c.log.Warn("Could not update apply checkpoint", "error", err)
+	}
+	c.emitAudit(audit.Event{Action: audit.ActionReposAdded, CostCenterID: costCenterID, Resources: repoNames}, nil)
+	return nil
+}
+
+// RemoveRepositoriesFromCostCenter removes repository full-names (org/repo)
+// from a cost center.
+func (c *Client) RemoveRepositoriesFromCostCenter(ctx context.Context, costCenterID string, repoNames []string) error {
+	if len(repoNames) == 0 {
+		return nil
+	}
+
+	if err := ValidateCostCenterID(costCenterID); err != nil {
+		return err
+	}
+
+	if err := c.deleteResources(ctx, costCenterID, "repositories", repoNames); err != nil {
+		c.recordFailure(journal.OpRemoveRepositories, costCenterID, repoNames, err, 1)
+		c.emitAudit(audit.Event{Action: audit.ActionReposRemoved, CostCenterID: costCenterID, Resources: repoNames}, err)
+		return fmt.Errorf("removing repositories from cost center %s: %w", costCenterID, err)
+	}
+
+	c.log.Info("Successfully removed repositories from cost center",
+		"cost_center_id", costCenterID, "count", len(repoNames))
+	c.run.reposRemoved(repoNames)
+	c.emitAudit(audit.Event{Action: audit.ActionReposRemoved, CostCenterID: costCenterID, Resources: repoNames}, nil)
+	return nil
+}
+
+// DeleteCostCenter deletes a cost center.  The API archives it: the cost
+// center moves to the "deleted" state, keeps its name and can be restored
+// in enterprise billing settings.  A cost center that is already gone is
+// not an error.
+func (c *Client) DeleteCostCenter(ctx context.Context, id, name string) error {
+	if err := ValidateCostCenterID(id); err != nil {
+		return err
+	}
+
+	url := c.enterpriseURL(fmt.Sprintf("/settings/billing/cost-centers/%s", id))
+	if _, err := c.doJSON(ctx, http.MethodDelete, url, nil, nil); err != nil {
+		if IsCostCenterNotFound(err) {
+			c.log.Info("Cost center already gone", "name", name, "id", id)
+			c.costCenterGone(id)
+			return nil
+		}
+		c.emitAudit(audit.Event{Action: audit.ActionCostCenterDeleted, CostCenterID: id, CostCenter: name}, err)
+		return fmt.Errorf("deleting cost center %q: %w", name, err)
+	}
+
+	c.log.Info("Deleted cost center", "name", name, "id", id)
+	c.costCenterGone(id)
+	c.emitAudit(audit.Event{Action: audit.ActionCostCenterDeleted, CostCenterID: id, CostCenter: name}, nil)
+	return nil
+}
+
+// RenameCostCenter renames a cost center.  Its ID, members, repositories
+// and budgets are kept; the run cache and the file cache follow the new
+// name.  A name already used by another cost center is a conflict.
+func (c *Client) RenameCostCenter(ctx context.Context, id, oldName, newName string) error {
+	if err := ValidateCostCenterID(id); err != nil {
+		return err
+	}
+
+	url := c.enterpriseURL(fmt.Sprintf("/settings/billing/cost-centers/%s", id))
+	body := map[string]string{"name": newName}
+	ev := audit.Event{Action: audit.ActionCostCenterRenamed, CostCenterID: id, CostCenter: newName, PreviousName: oldName}
+	if _, err := c.doJSON(ctx, http.MethodPatch, url, body, nil); err != nil {
+		if IsCostCenterNotFound(err) {
+			c.costCenterGone(id)
+		}
+		c.emitAudit(ev, err)
+		return fmt.Errorf("renaming cost center %q to %q: %w", oldName, newName, err)
+	}
+
+	c.log.Info("Renamed cost center", "id", id, "from", oldName, "to", newName)
+	c.run.renamed(id, newName)
+	if c.ccCache != nil {
+		if _, err := c.ccCache.DeleteID(id); err != nil {
+			c.log.Warn("Could not save cost center cache", "error", err)
+		}
+		_ = c.ccCache.Set(newName, id, newName)
+	}
+	c.emitAudit(ev, nil)
 	return nil
 }
 
@@ -0,0 +1,1458 @@
+package github_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/audit"
+	"github.com/renan-alm/gh-cost-center/internal/cache"
+	"github.com/renan-alm/gh-cost-center/internal/checkpoint"
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/github/githubtest"
+	"github.com/renan-alm/gh-cost-center/internal/journal"
+	"github.com/renan-alm/gh-cost-center/internal/progress"
+	"github.com/renan-alm/gh-cost-center/internal/quota"
+)
+
+func newFakeClient(t *testing.T, srv *githubtest.Server) *github.Client {
+	t.Helper()
+	cfg := &config.Manager{Enterprise: srv.Enterprise, APIBaseURL: srv.URL, Token: "test-token"}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	c, err := github.NewClient(cfg, logger)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c
+}
+
+// assigned reports whether results record user as added to the cost center
+// id.
+func assigned(results []github.CostCenterResult, id, user string) bool {
+	for _, r := range results {
+		if r.ID == id {
+			return r.Users[user]
+		}
+	}
+	return false
+}
+
+func TestEndToEnd_AssignCopilotUsers(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.AddSeats("alice", "bob", "carol")
+	existing := srv.AddCostCenter("No PRU")
+	c := newFakeClient(t, srv)
+
+	users, err := c.GetCopilotUsers(t.Context())
+	if err != nil {
+		t.Fatalf("GetCopilotUsers: %v", err)
+	}
+	if len(users) != 3 {
+		t.Fatalf("got %d users, want 3", len(users))
+	}
+
+	// Creating an existing name resolves to the existing ID via the 409 body.
+	id, err := c.CreateCostCenter(t.Context(), "No PRU")
+	if err != nil {
+		t.Fatalf("CreateCostCenter: %v", err)
+	}
+	if id != existing {
+		t.Errorf("id = %q, want %q", id, existing)
+	}
+
+	results, err := c.BulkUpdateCostCenterAssignments(t.Context(), map[string][]string{id: {"alice", "bob"}}, true)
+	if err != nil {
+		t.Fatalf("BulkUpdateCostCenterAssignments: %v", err)
+	}
+	if len(results) != 1 || results[0].Name != "No PRU" || !assigned(results, id, "alice") || !assigned(results, id, "bob") {
+		t.Errorf("results = %+v, want No PRU with alice and bob", results)
+	}
+	applied := c.ApplyResults()
+	if len(applied) != 1 || applied[0].ID != id || applied[0].Name != "No PRU" || applied[0].Succeeded != 2 {
+		t.Errorf("ApplyResults = %+v, want No PRU with 2 users", applied)
+	}
+
+	ref, err := c.CheckUserCostCenterMembership(t.Context(), "alice")
+	if err != nil || ref == nil || ref.ID != id {
+		t.Errorf("membership = %+v, %v; want %q", ref, err, id)
+	}
+	if drift := c.SchemaDrift(); len(drift) != 0 {
+		t.Errorf("fake server responses should match the expected schema, got %+v", drift)
+	}
+}
+
+func TestCreateCostCenter_DeletedNameCollision(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	deleted := srv.AddDeletedCostCenter("Legacy")
+	c := newFakeClient(t, srv)
+
+	_, err := c.CreateCostCenter(t.Context(), "Legacy")
+	var delErr *github.DeletedCostCenterError
+	if !errors.As(err, &delErr) {
+		t.Fatalf("err = %v, want *DeletedCostCenterError", err)
+	}
+	if delErr.ID != deleted || delErr.Name != "Legacy" {
+		t.Errorf("delErr = %+v, want ID %q", delErr, deleted)
+	}
+}
+
+func TestCreateCostCenter_DeletedNameCollisionSuffix(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.AddDeletedCostCenter("Legacy")
+	srv.AddDeletedCostCenter("Legacy (2)")
+	cfg := &config.Manager{
+		Enterprise:             srv.Enterprise,
+		APIBaseURL:             srv.URL,
+		Token:                  "test-token",
+		DeletedCollisionPolicy: config.DeletedCollisionSuffix,
+	}
+	c, err := github.NewClient(cfg, slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	id, err := c.CreateCostCenter(t.Context(), "Legacy")
+	if err != nil {
+		t.Fatalf("CreateCostCenter: %v", err)
+	}
+	cc, ok := srv.CostCenterByName("Legacy (3)")
+	if !ok || cc.ID != id {
+		t.Errorf("CostCenterByName(\"Legacy (3)\") = %+v, want ID %q", cc, id)
+	}
+}
+
+func TestPreloadActiveCostCenters_SharedAcrossLookups(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	existing := srv.AddCostCenter("Existing")
+	c := newFakeClient(t, srv)
+
+	if err := c.PreloadActiveCostCenters(t.Context()); err != nil {
+		t.Fatalf("PreloadActiveCostCenters: %v", err)
+	}
+	created, err := c.CreateCostCenter(t.Context(), "New")
+	if err != nil {
+		t.Fatalf("CreateCostCenter: %v", err)
+	}
+
+	active, err := c.GetAllActiveCostCenters(t.Context())
+	if err != nil {
+		t.Fatalf("GetAllActiveCostCenters: %v", err)
+	}
+	if active["Existing"] != existing || active["New"] != created {
+		t.Errorf("active = %v", active)
+	}
+
+	lists := 0
+	for _, r := range srv.Requests() {
+		if r == "GET /enterprises/"+srv.Enterprise+"/settings/billing/cost-centers" {
+			lists++
+		}
+	}
+	if lists != 1 {
+		t.Errorf("cost center list fetched %d times, want 1", lists)
+	}
+}
+
+func TestPermissionReport_ClassicScopes(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.SetOAuthScopes("admin:org", "manage_billing:enterprise", "read:org", "repo")
+	srv.AddSeats("alice")
+	c := newFakeClient(t, srv)
+
+	if _, err := c.GetCopilotUsers(t.Context()); err != nil {
+		t.Fatalf("GetCopilotUsers: %v", err)
+	}
+	if _, err := c.CreateCostCenter(t.Context(), "New"); err != nil {
+		t.Fatalf("CreateCostCenter: %v", err)
+	}
+	if _, err := c.GetOrgTeams(t.Context(), "missing-org"); err == nil {
+		t.Fatal("expected 404 for unknown org")
+	}
+
+	rep := c.PermissionReport()
+	var areas []string
+	for _, u := range rep.Used {
+		areas = append(areas, u.Area+"/"+u.Access)
+	}
+	want := "Copilot seats/read,Enterprise billing/write,Organization members/read"
+	if strings.Join(areas, ",") != want {
+		t.Errorf("used = %v, want %s", areas, want)
+	}
+	if strings.Join(rep.UnusedScopes, ",") != "admin:org,repo" {
+		t.Errorf("unused = %v, want [admin:org repo]", rep.UnusedScopes)
+	}
+	if strings.Join(rep.MinimalScopes, ",") != "manage_billing:copilot,manage_billing:enterprise,read:org" {
+		t.Errorf("minimal = %v", rep.MinimalScopes)
+	}
+}
+
+func TestPermissionReport_FineGrainedToken(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	c := newFakeClient(t, srv)
+	if err := c.VerifyToken(t.Context()); err != nil {
+		t.Fatalf("VerifyToken: %v", err)
+	}
+	if _, err := c.GetAllActiveCostCenters(t.Context()); err != nil {
+		t.Fatalf("GetAllActiveCostCenters: %v", err)
+	}
+
+	rep := c.PermissionReport()
+	if rep.GrantedScopes != nil {
+		t.Errorf("granted = %v, want nil without X-OAuth-Scopes", rep.GrantedScopes)
+	}
+	if len(rep.Used) != 1 || rep.Used[0].Area != "Enterprise billing" || rep.Used[0].Calls != 1 {
+		t.Errorf("used = %+v, want one Enterprise billing read (rate_limit ignored)", rep.Used)
+	}
+}
+
+func TestBulkUpdate_ApplyOrder(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	catchAll := srv.AddCostCenter("Catch-all")
+	finance := srv.AddCostCenter("Finance")
+	other := srv.AddCostCenter("Other")
+	cfg := &config.Manager{
+		Enterprise: srv.Enterprise,
+		APIBaseURL: srv.URL,
+		Token:      "test-token",
+		ApplyFirst: []string{"Finance"},
+		ApplyLast:  []string{catchAll},
+	}
+	c, err := github.NewClient(cfg, slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	_, err = c.BulkUpdateCostCenterAssignments(t.Context(), map[string][]string{
+		catchAll: {"zed"},
+		other:    {"bob"},
+		finance:  {"alice"},
+	}, true)
+	if err != nil {
+		t.Fatalf("BulkUpdateCostCenterAssignments: %v", err)
+	}
+
+	var order []string
+	for _, r := range srv.Requests() {
+		if strings.HasPrefix(r, "POST ") && strings.HasSuffix(r, "/resource") {
+			order = append(order, r)
+		}
+	}
+	prefix := "POST /enterprises/" + srv.Enterprise + "/settings/billing/cost-centers/"
+	want := []string{prefix + finance + "/resource", prefix + other + "/resource", prefix + catchAll + "/resource"}
+	if strings.Join(order, "\n") != strings.Join(want, "\n") {
+		t.Errorf("apply order =\n%s\nwant\n%s", strings.Join(order, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestBulkUpdate_FirstMustSucceed(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	other := srv.AddCostCenter("Other")
+	missing := "00000000-0000-4000-8000-999999999999"
+	cfg := &config.Manager{
+		Enterprise:            srv.Enterprise,
+		APIBaseURL:            srv.URL,
+		Token:                 "test-token",
+		ApplyFirst:            []string{missing},
+		ApplyFirstMustSucceed: true,
+	}
+	c, err := github.NewClient(cfg, slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	results, err := c.BulkUpdateCostCenterAssignments(t.Context(), map[string][]string{
+		missing: {"alice"},
+		other:   {"bob"},
+	}, true)
+	if err != nil {
+		t.Fatalf("BulkUpdateCostCenterAssignments: %v", err)
+	}
+	if assigned(results, other, "bob") {
+		t.Error("bob should be skipped after the apply-first cost center failed")
+	}
+	if cc, _ := srv.CostCenterByName("Other"); len(cc.Users) != 0 {
+		t.Errorf("Other users = %v, want none", cc.Users)
+	}
+}
+
+func TestBulkUpdate_Parallel(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	first := srv.AddCostCenter("First")
+	last := srv.AddCostCenter("Last")
+	assignments := map[string][]string{first: {"alice"}, last: {"zed"}}
+	for i := range 6 {
+		id := srv.AddCostCenter(fmt.Sprintf("Team %d", i))
+		var users []string
+		for j := range 120 { // three batches for each cost center
+			users = append(users, fmt.Sprintf("user-%d-%d", i, j))
+		}
+		assignments[id] = users
+	}
+	cfg := &config.Manager{
+		Enterprise:       srv.Enterprise,
+		APIBaseURL:       srv.URL,
+		Token:            "test-token",
+		ApplyFirst:       []string{first},
+		ApplyLast:        []string{last},
+		ApplyParallelism: 4,
+	}
+	c, err := github.NewClient(cfg, slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	results, err := c.BulkUpdateCostCenterAssignments(t.Context(), assignments, true)
+	if err != nil {
+		t.Fatalf("BulkUpdateCostCenterAssignments: %v", err)
+	}
+	for id, users := range assignments {
+		for _, u := range users {
+			if !assigned(results, id, u) {
+				t.Fatalf("%s not assigned to %s", u, id)
+			}
+		}
+	}
+	if cc, _ := srv.CostCenterByName("Team 5"); len(cc.Users) != 120 {
+		t.Errorf("Team 5 has %d users, want 120", len(cc.Users))
+	}
+
+	var posts []string
+	for _, r := range srv.Requests() {
+		if strings.HasPrefix(r, "POST ") && strings.HasSuffix(r, "/resource") {
+			posts = append(posts, r)
+		}
+	}
+	if len(posts) != 2+6*3 {
+		t.Fatalf("got %d resource POSTs, want %d", len(posts), 2+6*3)
+	}
+	if !strings.Contains(posts[0], first) || !strings.Contains(posts[len(posts)-1], last) {
+		t.Errorf("first/last cost centers not applied serially around the parallel ones:\n%s", strings.Join(posts, "\n"))
+	}
+}
+
+func TestBulkUpdate_Cancelled(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	a := srv.AddCostCenter("A")
+	c := newFakeClient(t, srv)
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := c.BulkUpdateCostCenterAssignments(ctx, map[string][]string{a: {"alice"}}, true)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if cc, _ := srv.CostCenterByName("A"); len(cc.Users) != 0 {
+		t.Errorf("A users = %v, want none after cancellation", cc.Users)
+	}
+}
+
+func TestBulkUpdate_ResumesFromCheckpoint(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	a := srv.AddCostCenter("A")
+	b := srv.AddCostCenter("B")
+	c := newFakeClient(t, srv)
+	dir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	// The interrupted run got alice into A before stopping.
+	cp, err := checkpoint.Start(dir, srv.Enterprise, logger)
+	if err != nil {
+		t.Fatalf("checkpoint.Start: %v", err)
+	}
+	_ = cp.Record(checkpoint.KindUsers, a, []string{"alice"})
+	if cp, err = checkpoint.Resume(dir, srv.Enterprise, logger); err != nil {
+		t.Fatalf("checkpoint.Resume: %v", err)
+	}
+	c.SetCheckpoint(cp)
+
+	results, err := c.BulkUpdateCostCenterAssignments(t.Context(), map[string][]string{a: {"alice"}, b: {"bob"}}, true)
+	if err != nil {
+		t.Fatalf("BulkUpdateCostCenterAssignments: %v", err)
+	}
+	if !assigned(results, a, "alice") || !assigned(results, b, "bob") {
+		t.Errorf("results = %v, want both users reported added", results)
+	}
+	for _, r := range srv.Requests() {
+		if strings.Contains(r, a) {
+			t.Errorf("request %q sent for a cost center the checkpoint had completed", r)
+		}
+	}
+	if _, todo := cp.Filter(checkpoint.KindUsers, b, []string{"bob"}); len(todo) != 0 {
+		t.Error("bob's batch was not recorded in the checkpoint")
+	}
+}
+
+func TestBulkUpdate_ProgressEvents(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	a := srv.AddCostCenter("A")
+	b := srv.AddCostCenter("B")
+	c := newFakeClient(t, srv)
+	var buf bytes.Buffer
+	c.SetProgress(progress.New(&buf))
+
+	if _, err := c.BulkUpdateCostCenterAssignments(t.Context(), map[string][]string{a: {"alice"}, b: {"bob"}}, true); err != nil {
+		t.Fatalf("BulkUpdateCostCenterAssignments: %v", err)
+	}
+
+	var last progress.Event
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d progress lines, want 2:\n%s", len(lines), buf.String())
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &last); err != nil {
+		t.Fatal(err)
+	}
+	if last.Phase != progress.PhaseApply || last.Done != 2 || last.Total != 2 {
+		t.Errorf("last event = %+v, want apply 2/2", last)
+	}
+}
+
+func TestTransferAlerts(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	id := srv.AddCostCenter("Platform")
+	srv.AddBudget(githubtest.Budget{Type: "ProductPricing", ProductSKU: "actions", Scope: "cost_center", Amount: 50, EntityName: id,
+		Alerting: githubtest.Alerting{WillAlert: true, Recipients: []string{"carol", "dave"}}})
+	srv.AddBudget(githubtest.Budget{Type: "ProductPricing", ProductSKU: "actions", Scope: "cost_center", Amount: 50, EntityName: "Other",
+		Alerting: githubtest.Alerting{WillAlert: true, Recipients: []string{"carol"}}})
+	c := newFakeClient(t, srv)
+	j := journal.Open(t.TempDir(), slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+	c.SetJournal(j)
+
+	plan, err := c.PlanAlertTransfer(t.Context(), id, "Platform", []string{"carol"}, []string{"alice"})
+	if err != nil {
+		t.Fatalf("PlanAlertTransfer: %v", err)
+	}
+	if len(plan) != 1 || !plan[0].Changed() {
+		t.Fatalf("plan = %+v, want one changed budget", plan)
+	}
+	if err := c.TransferAlerts(t.Context(), id, "Platform", []string{"carol"}, []string{"alice"}, plan); err != nil {
+		t.Fatalf("TransferAlerts: %v", err)
+	}
+
+	budgets := srv.Budgets()
+	if got := strings.Join(budgets[0].Alerting.Recipients, ","); got != "dave,alice" {
+		t.Errorf("Platform recipients = %q, want dave,alice", got)
+	}
+	if got := strings.Join(budgets[1].Alerting.Recipients, ","); got != "carol" {
+		t.Errorf("other cost center's recipients changed to %q", got)
+	}
+	if h := j.Handoffs(); len(h) != 1 || h[0].CostCenterID != id || h[0].Budgets != 1 || h[0].Error != "" {
+		t.Errorf("journal handoffs = %+v", h)
+	}
+}
+
+func TestReconcileProductBudgets_UpdatesDriftedAmount(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	id := srv.AddCostCenter("Finance")
+	srv.AddBudget(githubtest.Budget{Type: "ProductPricing", ProductSKU: "actions", Scope: "cost_center", Amount: 50, EntityName: "Finance"})
+	srv.AddBudget(githubtest.Budget{Type: "SkuPricing", ProductSKU: "copilot_premium_request", Scope: "cost_center", Amount: 100, EntityName: "Finance"})
+	c := newFakeClient(t, srv)
+
+	updated, err := c.ReconcileProductBudgets(t.Context(), id, "Finance", map[string]config.ProductBudget{
+		"actions":                 {Amount: 200, Enabled: true},
+		"copilot_premium_request": {Amount: 100, Enabled: true},
+		"packages":                {Amount: 10, Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("ReconcileProductBudgets: %v", err)
+	}
+	if strings.Join(updated, ",") != "actions" {
+		t.Errorf("updated = %v, want [actions]", updated)
+	}
+
+	budgets := srv.Budgets()
+	if len(budgets) != 2 {
+		t.Fatalf("got %d budgets, want 2 (missing budgets are not created)", len(budgets))
+	}
+	for _, b := range budgets {
+		want := 100
+		if b.ProductSKU == "actions" {
+			want = 200
+		}
+		if b.Amount != want {
+			t.Errorf("%s amount = %d, want %d", b.ProductSKU, b.Amount, want)
+		}
+	}
+}
+
+func TestReconcileProductBudgets_UpdatesStopUsage(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	id := srv.AddCostCenter("Finance")
+	stop := true
+	srv.AddBudget(githubtest.Budget{Type: "ProductPricing", ProductSKU: "actions", Scope: "cost_center", Amount: 50, EntityName: "Finance", PreventFurtherUsage: &stop})
+	// The API does not report the setting for this one: it is left alone.
+	srv.AddBudget(githubtest.Budget{Type: "ProductPricing", ProductSKU: "packages", Scope: "cost_center", Amount: 10, EntityName: "Finance"})
+	c := newFakeClient(t, srv)
+
+	updated, err := c.ReconcileProductBudgets(t.Context(), id, "Finance", map[string]config.ProductBudget{
+		"actions":  {Amount: 50, Enabled: true, AllowFurtherUsage: true},
+		"packages": {Amount: 10, Enabled: true, AllowFurtherUsage: true},
+	})
+	if err != nil {
+		t.Fatalf("ReconcileProductBudgets: %v", err)
+	}
+	if strings.Join(updated, ",") != "actions" {
+		t.Errorf("updated = %v, want [actions]", updated)
+	}
+	for _, b := range srv.Budgets() {
+		if b.ProductSKU == "actions" && (b.PreventFurtherUsage == nil || *b.PreventFurtherUsage) {
+			t.Errorf("actions prevent_further_usage = %v, want false", b.PreventFurtherUsage)
+		}
+	}
+}
+
+func TestReconcileProductBudgets_UpdatesAlertThreshold(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	id := srv.AddCostCenter("Finance")
+	seventyFive := 75
+	srv.AddBudget(githubtest.Budget{Type: "ProductPricing", ProductSKU: "copilot", Scope: "cost_center", Amount: 200, EntityName: "Finance",
+		Alerting: githubtest.Alerting{WillAlert: true, Recipients: []string{"finance-lead"}, Threshold: &seventyFive}})
+	// Without a configured threshold the budget's own is left alone.
+	srv.AddBudget(githubtest.Budget{Type: "ProductPricing", ProductSKU: "actions", Scope: "cost_center", Amount: 50, EntityName: "Finance",
+		Alerting: githubtest.Alerting{Threshold: &seventyFive}})
+	c := newFakeClient(t, srv)
+
+	updated, err := c.ReconcileProductBudgets(t.Context(), id, "Finance", map[string]config.ProductBudget{
+		"copilot": {Amount: 200, Enabled: true, AlertThreshold: 90},
+		"actions": {Amount: 50, Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("ReconcileProductBudgets: %v", err)
+	}
+	if strings.Join(updated, ",") != "copilot" {
+		t.Errorf("updated = %v, want [copilot]", updated)
+	}
+	for _, b := range srv.Budgets() {
+		want := 75
+		if b.ProductSKU == "copilot" {
+			want = 90
+			if !b.Alerting.WillAlert || strings.Join(b.Alerting.Recipients, ",") != "finance-lead" {
+				t.Errorf("copilot alerting = %+v, want the recipients kept", b.Alerting)
+			}
+		}
+		if b.Alerting.Threshold == nil || *b.Alerting.Threshold != want {
+			t.Errorf("%s alert threshold = %v, want %d", b.ProductSKU, b.Alerting.Threshold, want)
+		}
+	}
+}
+
+func TestEnsureProductBudgets_SeesBudgetsOnLaterPages(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	id := srv.AddCostCenter("Finance")
+	for i := range 150 {
+		srv.AddBudget(githubtest.Budget{Type: "ProductPricing", ProductSKU: "actions", Scope: "cost_center", Amount: 10, EntityName: fmt.Sprintf("other-%d", i)})
+	}
+	srv.AddBudget(githubtest.Budget{Type: "ProductPricing", ProductSKU: "actions", Scope: "cost_center", Amount: 200, EntityName: id})
+	c := newFakeClient(t, srv)
+
+	for range 2 {
+		written, err := c.EnsureProductBudgets(t.Context(), id, "Finance", map[string]config.ProductBudget{
+			"actions": {Amount: 200, Enabled: true},
+		})
+		if err != nil || len(written) != 0 {
+			t.Fatalf("EnsureProductBudgets = %v, %v; want nothing written", written, err)
+		}
+	}
+	if n := len(srv.Budgets()); n != 151 {
+		t.Errorf("got %d budgets, want 151 (no duplicate for Finance)", n)
+	}
+}
+
+func TestEnsureProductBudgets_CreatesMissingOnly(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	finance := srv.AddCostCenter("Finance")
+	legal := srv.AddCostCenter("Legal")
+	srv.AddBudget(githubtest.Budget{Type: "ProductPricing", ProductSKU: "actions", Scope: "cost_center", Amount: 50, EntityName: finance})
+	c := newFakeClient(t, srv)
+	c.SetRunCache(github.NewRunCache())
+	products := map[string]config.ProductBudget{
+		"actions": {Amount: 200, Enabled: true},
+		"copilot": {Amount: 100, Enabled: true},
+		"ghas":    {Amount: 5, Enabled: false},
+	}
+
+	for id, want := range map[string]string{finance: "copilot", legal: "actions,copilot"} {
+		created, err := c.EnsureProductBudgets(t.Context(), id, id, products)
+		if err != nil || strings.Join(created, ",") != want {
+			t.Fatalf("EnsureProductBudgets(%s) = %v, %v; want %s", id, created, err, want)
+		}
+	}
+	// A second pass sees the created budgets in the run cache.
+	if created, err := c.EnsureProductBudgets(t.Context(), legal, legal, products); err != nil || len(created) != 0 {
+		t.Errorf("second pass created %v, %v; want nothing", created, err)
+	}
+
+	lists := 0
+	for _, r := range srv.Requests() {
+		if strings.HasPrefix(r, "GET ") && strings.Contains(r, "/settings/billing/budgets") {
+			lists++
+		}
+	}
+	if lists != 1 {
+		t.Errorf("budgets listed %d times, want once per run", lists)
+	}
+	for _, b := range srv.Budgets() {
+		if b.EntityName == finance && b.ProductSKU == "actions" && b.Amount != 50 {
+			t.Errorf("existing actions budget amount = %d, want 50 left alone", b.Amount)
+		}
+	}
+	if n := len(srv.Budgets()); n != 4 {
+		t.Errorf("got %d budgets, want 4", n)
+	}
+}
+
+func TestDeleteCostCenter(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	id := srv.AddCostCenter("Stale")
+	srv.AddCostCenter("Kept", "alice")
+	c := newFakeClient(t, srv)
+	c.SetRunCache(github.NewRunCache())
+
+	if _, err := c.GetAllActiveCostCenters(t.Context()); err != nil {
+		t.Fatalf("GetAllActiveCostCenters: %v", err)
+	}
+	if err := c.DeleteCostCenter(t.Context(), id, "Stale"); err != nil {
+		t.Fatalf("DeleteCostCenter: %v", err)
+	}
+	if _, ok := srv.CostCenterByName("Stale"); ok {
+		t.Error("Stale is still active on the server")
+	}
+	if got := srv.CostCenters()[0].State; got != "deleted" {
+		t.Errorf("state = %q, want deleted", got)
+	}
+	active, err := c.GetAllActiveCostCenters(t.Context())
+	if err != nil {
+		t.Fatalf("GetAllActiveCostCenters: %v", err)
+	}
+	if _, ok := active["Stale"]; ok || len(active) != 1 {
+		t.Errorf("active = %v, want only Kept", active)
+	}
+
+	// Deleting it again is not an error.
+	if err := c.DeleteCostCenter(t.Context(), id, "Stale"); err != nil {
+		t.Errorf("second DeleteCostCenter: %v", err)
+	}
+}
+
+func TestRenameCostCenter(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	id := srv.AddCostCenter("Platform", "alice")
+	srv.AddCostCenter("Data")
+	c := newFakeClient(t, srv)
+	c.SetRunCache(github.NewRunCache())
+
+	if _, err := c.GetAllActiveCostCenters(t.Context()); err != nil {
+		t.Fatalf("GetAllActiveCostCenters: %v", err)
+	}
+	if err := c.RenameCostCenter(t.Context(), id, "Platform", "Platform Engineering"); err != nil {
+		t.Fatalf("RenameCostCenter: %v", err)
+	}
+	cc, ok := srv.CostCenterByName("Platform Engineering")
+	if !ok || cc.ID != id || strings.Join(cc.Users, ",") != "alice" {
+		t.Errorf("renamed cost center = %+v, want ID %s with alice", cc, id)
+	}
+	active, err := c.GetAllActiveCostCenters(t.Context())
+	if err != nil {
+		t.Fatalf("GetAllActiveCostCenters: %v", err)
+	}
+	if _, ok := active["Platform"]; ok || active["Platform Engineering"] != id {
+		t.Errorf("active = %v, want Platform Engineering -> %s", active, id)
+	}
+
+	if err := c.RenameCostCenter(t.Context(), id, "Platform Engineering", "Data"); err == nil {
+		t.Error("expected a conflict renaming onto another cost center's name")
+	}
+}
+
+func TestCountCostCenterResources(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.AddCostCenter("Platform", "alice", "bob")
+	gone := srv.AddCostCenter("Old")
+	c := newFakeClient(t, srv)
+	if err := c.DeleteCostCenter(t.Context(), gone, "Old"); err != nil {
+		t.Fatalf("DeleteCostCenter: %v", err)
+	}
+
+	all, err := c.GetAllCostCenters(t.Context())
+	if err != nil {
+		t.Fatalf("GetAllCostCenters: %v", err)
+	}
+	counts, err := c.CountCostCenterResources(t.Context(), all)
+	if err != nil {
+		t.Fatalf("CountCostCenterResources: %v", err)
+	}
+	if len(counts) != 2 || counts[0].Name != "Platform" || counts[0].Users != 2 || counts[1].State != "deleted" || counts[1].Users != 0 {
+		t.Errorf("counts = %+v", counts)
+	}
+}
+
+func TestLookupCostCenterMembership(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	id := srv.AddCostCenter("Platform", "alice")
+	c := newFakeClient(t, srv)
+
+	ref, err := c.LookupCostCenterMembership(t.Context(), github.ResourceTypeUser, "alice")
+	if err != nil || ref == nil || ref.ID != id {
+		t.Errorf("alice = %+v, %v; want %s", ref, err, id)
+	}
+	if ref, err := c.LookupCostCenterMembership(t.Context(), github.ResourceTypeUser, "bob"); err != nil || ref != nil {
+		t.Errorf("bob = %+v, %v; want none", ref, err)
+	}
+	if _, err := c.LookupCostCenterMembership(t.Context(), "team", "bob"); err == nil {
+		t.Error("expected error for unsupported resource type")
+	}
+}
+
+func TestRunCache_SharesMembershipReads(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	id := srv.AddCostCenter("Engineering", "alice")
+	c := newFakeClient(t, srv)
+	c.SetRunCache(github.NewRunCache())
+
+	for range 2 {
+		if _, err := c.GetAllActiveCostCenters(t.Context()); err != nil {
+			t.Fatalf("GetAllActiveCostCenters: %v", err)
+		}
+		if _, err := c.CheckUserCostCenterMembership(t.Context(), "bob"); err != nil {
+			t.Fatalf("CheckUserCostCenterMembership: %v", err)
+		}
+	}
+	if _, err := c.AddUsersToCostCenter(t.Context(), id, []string{"bob"}, true); err != nil {
+		t.Fatalf("AddUsersToCostCenter: %v", err)
+	}
+	members, err := c.GetCostCenterMembers(t.Context(), id)
+	if err != nil {
+		t.Fatalf("GetCostCenterMembers: %v", err)
+	}
+	if strings.Join(members, ",") != "alice,bob" {
+		t.Errorf("members = %v, want cached list updated with bob", members)
+	}
+
+	// The add must invalidate bob's cached "no cost center" answer.
+	ref, err := c.CheckUserCostCenterMembership(t.Context(), "bob")
+	if err != nil || ref == nil || ref.ID != id {
+		t.Errorf("membership = %+v, %v; want %q", ref, err, id)
+	}
+
+	counts := make(map[string]int)
+	for _, r := range srv.Requests() {
+		counts[strings.SplitN(r, "?", 2)[0]]++
+	}
+	base := "GET /enterprises/" + srv.Enterprise + "/settings/billing/cost-centers"
+	if counts[base] != 1 || counts[base+"/memberships"] != 2 || counts[base+"/"+id] != 1 {
+		t.Errorf("requests = %v, want one list, one detail and two membership reads", counts)
+	}
+}
+
+func TestRunCache_AddMovesUsersOutOfOtherCostCenters(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	from := srv.AddCostCenter("Engineering", "alice", "bob")
+	to := srv.AddCostCenter("Research")
+	c := newFakeClient(t, srv)
+	c.SetRunCache(github.NewRunCache())
+
+	if _, err := c.GetCostCenterMembers(t.Context(), from); err != nil {
+		t.Fatalf("GetCostCenterMembers: %v", err)
+	}
+	if _, err := c.AddUsersToCostCenter(t.Context(), to, []string{"bob"}, true); err != nil {
+		t.Fatalf("AddUsersToCostCenter: %v", err)
+	}
+	members, err := c.GetCostCenterMembers(t.Context(), from)
+	if err != nil {
+		t.Fatalf("GetCostCenterMembers: %v", err)
+	}
+	if strings.Join(members, ",") != "alice" {
+		t.Errorf("cached members of the old cost center = %v, want bob moved out", members)
+	}
+}
+
+func TestRunCache_ForgetsVanishedCostCenter(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	id := srv.AddCostCenter("Platform")
+	c := newFakeClient(t, srv)
+	c.SetRunCache(github.NewRunCache())
+	cc, err := cache.New(t.TempDir(), slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+	if err != nil {
+		t.Fatalf("cache.New: %v", err)
+	}
+	c.SetCache(cc)
+
+	preload, err := c.GetAllActiveCostCenters(t.Context())
+	if err != nil {
+		t.Fatalf("GetAllActiveCostCenters: %v", err)
+	}
+	srv.RemoveCostCenter(id)
+
+	if _, err := c.AddUsersToCostCenter(t.Context(), id, []string{"alice"}, true); !github.IsCostCenterNotFound(err) {
+		t.Fatalf("AddUsersToCostCenter err = %v, want not found", err)
+	}
+	if _, ok := cc.Get("Platform"); ok {
+		t.Error("file cache still holds the vanished cost center")
+	}
+	active, _ := c.GetAllActiveCostCenters(t.Context())
+	if _, ok := active["Platform"]; ok {
+		t.Errorf("run cache still lists the vanished cost center: %v", active)
+	}
+
+	// A preload map built before the 404 must not hand out the stale ID.
+	newID, err := c.CreateCostCenterWithPreload(t.Context(), "Platform", preload)
+	if err != nil {
+		t.Fatalf("CreateCostCenterWithPreload: %v", err)
+	}
+	if newID == id || preload["Platform"] != newID {
+		t.Errorf("got ID %q (preload %q), want a new cost center instead of %q", newID, preload["Platform"], id)
+	}
+}
+
+func TestGetAllActiveCostCenters_DropsStaleCacheEntries(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	renamed := srv.AddCostCenter("Old Name")
+	kept := srv.AddCostCenter("Kept")
+	c := newFakeClient(t, srv)
+	cc, err := cache.New(t.TempDir(), slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+	if err != nil {
+		t.Fatalf("cache.New: %v", err)
+	}
+	_ = cc.Set("Old Name", renamed, "Old Name")
+	_ = cc.Set("Kept", kept, "Kept")
+	_ = cc.Set("Purged", "00000000-0000-0000-0000-000000000000", "Purged")
+	c.SetCache(cc)
+	srv.RenameCostCenter(renamed, "New Name")
+
+	if _, err := c.GetAllActiveCostCenters(t.Context()); err != nil {
+		t.Fatalf("GetAllActiveCostCenters: %v", err)
+	}
+	for _, key := range []string{"Old Name", "Purged"} {
+		if e, ok := cc.Get(key); ok {
+			t.Errorf("stale entry %q -> %s survived", key, e.ID)
+		}
+	}
+	if e, ok := cc.Get("Kept"); !ok || e.ID != kept {
+		t.Errorf("Kept entry = %+v, %v; want %s", e, ok, kept)
+	}
+	if e, ok := cc.Get("New Name"); !ok || e.ID != renamed {
+		t.Errorf("New Name entry = %+v, %v; want %s", e, ok, renamed)
+	}
+}
+
+func TestGetPremiumRequestsByUser(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.SetPremiumRequests("alice", 42)
+	c := newFakeClient(t, srv)
+
+	usage, err := c.GetPremiumRequestsByUser(t.Context(), []string{"alice", "bob"}, 2026, 9)
+	if err != nil {
+		t.Fatalf("GetPremiumRequestsByUser: %v", err)
+	}
+	if usage["alice"] != 42 || usage["bob"] != 0 || len(usage) != 2 {
+		t.Errorf("usage = %v, want alice 42 and bob 0", usage)
+	}
+}
+
+func TestHTTPCache_ConditionalRequests(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.EnableETags()
+	srv.AddCostCenter("Platform")
+	srv.AddSeats("alice", "bob")
+	store := cache.NewHTTPStore(t.TempDir(), slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+
+	// Each run gets a fresh client, as separate invocations of the tool do.
+	run := func() (*github.Client, map[string]string, []github.CopilotUser) {
+		t.Helper()
+		c := newFakeClient(t, srv)
+		c.SetHTTPCache(store)
+		active, err := c.GetAllActiveCostCenters(t.Context())
+		if err != nil {
+			t.Fatalf("GetAllActiveCostCenters: %v", err)
+		}
+		users, err := c.GetCopilotUsers(t.Context())
+		if err != nil {
+			t.Fatalf("GetCopilotUsers: %v", err)
+		}
+		return c, active, users
+	}
+
+	c, _, _ := run()
+	if hits, misses := c.HTTPCacheStats(); hits != 0 || misses != 2 {
+		t.Errorf("first run: hits=%d misses=%d, want 0 and 2", hits, misses)
+	}
+
+	c, active, users := run()
+	if hits, misses := c.HTTPCacheStats(); hits != 2 || misses != 0 {
+		t.Errorf("second run: hits=%d misses=%d, want 2 and 0", hits, misses)
+	}
+	if _, ok := active["Platform"]; !ok || len(users) != 2 {
+		t.Errorf("cached responses decoded to %v and %d users", active, len(users))
+	}
+
+	// A change on the server invalidates the stored response.
+	srv.AddCostCenter("Data")
+	c, active, _ = run()
+	if hits, misses := c.HTTPCacheStats(); hits != 1 || misses != 1 {
+		t.Errorf("third run: hits=%d misses=%d, want 1 and 1", hits, misses)
+	}
+	if _, ok := active["Data"]; !ok {
+		t.Errorf("active = %v, want the new cost center", active)
+	}
+}
+
+func TestBucketCache_ServesMembersAndSeats(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.AddSeats("alice", "bob")
+	srv.AddOrgTeam("acme", githubtest.Team{ID: 1, Name: "Platform", Slug: "platform", Members: []string{"alice"}})
+	srv.AddEnterpriseTeam(githubtest.Team{ID: 2, Name: "Data", Slug: "data", Members: []string{"bob"}})
+	dir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	// Each run gets a fresh client, as a plan and the apply after it do.
+	run := func() {
+		t.Helper()
+		c := newFakeClient(t, srv)
+		c.SetBucketCache(
+			cache.NewBucket(dir, cache.BucketTeamMembers, time.Hour, logger),
+			cache.NewBucket(dir, cache.BucketSeats, time.Hour, logger))
+		if users, err := c.GetCopilotUsers(t.Context()); err != nil || len(users) != 2 {
+			t.Fatalf("GetCopilotUsers = %d users, %v", len(users), err)
+		}
+		if m, err := c.GetOrgTeamMembers(t.Context(), "acme", "platform"); err != nil || len(m) != 1 || m[0].Login != "alice" {
+			t.Fatalf("GetOrgTeamMembers = %v, %v", m, err)
+		}
+		if m, err := c.GetEnterpriseTeamMembers(t.Context(), "data"); err != nil || len(m) != 1 || m[0].Login != "bob" {
+			t.Fatalf("GetEnterpriseTeamMembers = %v, %v", m, err)
+		}
+		c.FlushBucketCache()
+	}
+
+	run()
+	first := len(srv.Requests())
+	if first != 3 {
+		t.Fatalf("first run made %d requests, want 3", first)
+	}
+	run()
+	if n := len(srv.Requests()) - first; n != 0 {
+		t.Errorf("second run made %d requests, want none: %v", n, srv.Requests()[first:])
+	}
+}
+
+func TestReplayJournal_RetriesTransientOnly(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	id := srv.AddCostCenter("Engineering")
+	c := newFakeClient(t, srv)
+
+	dir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	j := journal.Open(dir, logger)
+	_ = j.Record(journal.Entry{Op: journal.OpAddUsers, CostCenterID: id, Resources: []string{"alice"}, Error: "502"}, true)
+	_ = j.Record(journal.Entry{Op: journal.OpAddUsers, CostCenterID: id, Resources: []string{"mallory"}, Error: "422"}, false)
+	c.SetJournal(j)
+
+	recovered, failed, err := c.ReplayJournal(t.Context(), j.Transient())
+	if err != nil || recovered != 1 || failed != 0 {
+		t.Fatalf("ReplayJournal = %d, %d, %v; want 1 recovered", recovered, failed, err)
+	}
+	cc, _ := srv.CostCenterByName("Engineering")
+	if strings.Join(cc.Users, ",") != "alice" {
+		t.Errorf("users = %v, want only the transient entry replayed", cc.Users)
+	}
+	if len(j.Transient()) != 0 || len(j.Permanent()) != 1 {
+		t.Errorf("journal transient = %d, permanent = %d; want 0 and 1", len(j.Transient()), len(j.Permanent()))
+	}
+}
+
+func TestReplayJournal_Removals(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	id := srv.AddCostCenter("Engineering", "alice", "zed")
+	c := newFakeClient(t, srv)
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	j := journal.Open(t.TempDir(), logger)
+	_ = j.Record(journal.Entry{Op: journal.OpRemoveUsers, CostCenterID: id, Resources: []string{"zed"}, Error: "502"}, true)
+	_ = j.Record(journal.Entry{Op: journal.OpAddUsers, CostCenterID: "00000000-0000-0000-0000-000000000000", Resources: []string{"bob"}, Error: "502"}, true)
+	c.SetJournal(j)
+
+	recovered, failed, err := c.ReplayJournal(t.Context(), j.Transient())
+	if err != nil || recovered != 1 || failed != 1 {
+		t.Fatalf("ReplayJournal = %d, %d, %v; want 1 recovered and 1 failed", recovered, failed, err)
+	}
+	cc, _ := srv.CostCenterByName("Engineering")
+	if strings.Join(cc.Users, ",") != "alice" {
+		t.Errorf("users = %v, want zed removed", cc.Users)
+	}
+	// The failed entry stays in the journal until a replay succeeds.
+	left := append(j.Transient(), j.Permanent()...)
+	if len(left) != 1 || left[0].Op != journal.OpAddUsers || left[0].Attempts != 2 {
+		t.Errorf("journal = %+v; want only the failed add, with 2 attempts", left)
+	}
+}
+
+func TestCheckPermissions(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.SetOAuthScopes("read:org")
+	c := newFakeClient(t, srv)
+
+	err := c.CheckPermissions(t.Context(), github.RequiredPermissions("assign", "teams", github.ModeSettings{TeamsScope: "organization"}, true))
+	var missing *github.MissingPermissionsError
+	if !errors.As(err, &missing) {
+		t.Fatalf("CheckPermissions = %v, want *MissingPermissionsError", err)
+	}
+	if len(missing.Missing) != 2 || missing.Missing[0].Area != "Enterprise billing" || missing.Missing[1].Access != "write" {
+		t.Errorf("missing = %+v, want Enterprise billing read and write", missing.Missing)
+	}
+
+	if err := c.CheckPermissions(t.Context(), github.RequiredPermissions("report", "teams", github.ModeSettings{TeamsScope: "organization"}, false)); err != nil {
+		t.Errorf("report needs only read:org here, got %v", err)
+	}
+
+	err = c.CheckPermissions(t.Context(), github.RequiredPermissions("orphans", "teams", github.ModeSettings{TeamsScope: "organization"}, false))
+	if !errors.As(err, &missing) || len(missing.Missing) != 2 {
+		t.Errorf("orphans = %v, want Copilot seats and billing missing whatever the mode", err)
+	}
+}
+
+func TestRequiredPermissions_Rules(t *testing.T) {
+	areas := func(reqs []github.PermissionRequirement) []string {
+		var out []string
+		for _, r := range reqs {
+			out = append(out, r.Area+" "+r.Access)
+		}
+		return out
+	}
+	tests := []struct {
+		name     string
+		settings github.ModeSettings
+		want     []string
+	}{
+		{"seats only", github.ModeSettings{}, []string{"Copilot seats read", "Enterprise billing read"}},
+		{"every membership, enterprise teams",
+			github.ModeSettings{TeamsScope: "enterprise", RulesOrgs: true, RulesTeams: true, RulesAttributes: true},
+			[]string{"Copilot seats read", "Organization members read", "Enterprise teams read",
+				"Enterprise external identities read", "Enterprise billing read"}},
+		{"organization teams", github.ModeSettings{TeamsScope: "organization", RulesTeams: true},
+			[]string{"Copilot seats read", "Organization members read", "Enterprise billing read"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := areas(github.RequiredPermissions("assign", "rules", tt.settings, false))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RequiredPermissions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPermissions_FineGrainedTokenSkipped(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	c := newFakeClient(t, srv)
+	if err := c.CheckPermissions(t.Context(), github.RequiredPermissions("assign", "users", github.ModeSettings{}, true)); err != nil {
+		t.Errorf("CheckPermissions without X-OAuth-Scopes = %v, want nil", err)
+	}
+}
+
+func TestAuditSink_ReceivesWrites(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []audit.Event
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		var batch []audit.Event
+		_ = json.NewDecoder(r.Body).Decode(&batch)
+		mu.Lock()
+		events = append(events, batch...)
+		mu.Unlock()
+	}))
+	t.Cleanup(collector.Close)
+
+	srv := githubtest.NewServer(t)
+	id := srv.AddCostCenter("Platform")
+	c := newFakeClient(t, srv)
+	sink, err := audit.NewSink(audit.Options{Format: audit.FormatHTTP, URL: collector.URL}, slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+	if err != nil {
+		t.Fatalf("NewSink: %v", err)
+	}
+	c.SetAuditSink(sink)
+
+	if err := c.AddRepositoriesToCostCenter(t.Context(), id, []string{"org/api"}); err != nil {
+		t.Fatalf("AddRepositoriesToCostCenter: %v", err)
+	}
+	if _, err := c.RemoveUsersFromCostCenter(t.Context(), id, []string{"alice"}); err != nil {
+		t.Fatalf("RemoveUsersFromCostCenter: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("events = %+v, want 2", events)
+	}
+	if events[0].Action != audit.ActionReposAdded || events[0].Enterprise != srv.Enterprise || !events[0].Success {
+		t.Errorf("first event = %+v", events[0])
+	}
+	if events[1].Action != audit.ActionUsersRemoved || events[1].CostCenterID != id {
+		t.Errorf("second event = %+v", events[1])
+	}
+}
+
+// createdRecorder collects the IDs passed to RecordCostCenterCreated.
+type createdRecorder struct{ ids []string }
+
+func (r *createdRecorder) RecordCostCenterCreated(id string) error {
+	r.ids = append(r.ids, id)
+	return nil
+}
+
+func TestCreationRecorder_RecordsNewCostCentersOnly(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	existing := srv.AddCostCenter("Existing")
+	c := newFakeClient(t, srv)
+	rec := &createdRecorder{}
+	c.SetCreationRecorder(rec)
+
+	id, err := c.CreateCostCenter(t.Context(), "New")
+	if err != nil {
+		t.Fatalf("CreateCostCenter: %v", err)
+	}
+	if got, err := c.CreateCostCenter(t.Context(), "Existing"); err != nil || got != existing {
+		t.Fatalf("CreateCostCenter(Existing) = %q, %v", got, err)
+	}
+	if len(rec.ids) != 1 || rec.ids[0] != id {
+		t.Errorf("recorded %v, want [%s]", rec.ids, id)
+	}
+}
+
+func TestRemoveRepositoriesFromCostCenter(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	id := srv.AddCostCenter("Platform")
+	c := newFakeClient(t, srv)
+
+	if err := c.AddRepositoriesToCostCenter(t.Context(), id, []string{"org/a", "org/b"}); err != nil {
+		t.Fatalf("AddRepositoriesToCostCenter: %v", err)
+	}
+	if err := c.RemoveRepositoriesFromCostCenter(t.Context(), id, []string{"org/a"}); err != nil {
+		t.Fatalf("RemoveRepositoriesFromCostCenter: %v", err)
+	}
+	cc, _ := srv.CostCenterByName("Platform")
+	if strings.Join(cc.Repositories, ",") != "org/b" {
+		t.Errorf("repositories = %v, want [org/b]", cc.Repositories)
+	}
+}
+
+func TestGetAllActiveCostCenters_FollowsPagination(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	const total = 250
+	for i := range total {
+		srv.AddCostCenter(fmt.Sprintf("CC %03d", i))
+	}
+	c := newFakeClient(t, srv)
+
+	active, err := c.GetAllActiveCostCenters(t.Context())
+	if err != nil {
+		t.Fatalf("GetAllActiveCostCenters: %v", err)
+	}
+	if len(active) != total {
+		t.Fatalf("got %d active cost centers, want %d", len(active), total)
+	}
+	if _, ok := active["CC 249"]; !ok {
+		t.Error("cost center on the last page missing")
+	}
+	pages := 0
+	for _, r := range srv.Requests() {
+		if strings.HasSuffix(r, "/settings/billing/cost-centers") {
+			pages++
+		}
+	}
+	if pages != 3 {
+		t.Errorf("fetched %d pages, want 3 (per_page=100)", pages)
+	}
+
+	// A name on a later page resolves instead of being created again.
+	id, err := c.CreateCostCenter(t.Context(), "CC 200")
+	if err != nil {
+		t.Fatalf("CreateCostCenter: %v", err)
+	}
+	if id != active["CC 200"] {
+		t.Errorf("id = %q, want existing %q", id, active["CC 200"])
+	}
+	if n := len(srv.CostCenters()); n != total {
+		t.Errorf("server has %d cost centers, want %d (no duplicate)", n, total)
+	}
+}
+
+func TestGetCostCenterMembers_FollowsPagination(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	users := make([]string, 1234)
+	for i := range users {
+		users[i] = fmt.Sprintf("user-%04d", i)
+	}
+	id := srv.AddCostCenter("Big", users...)
+	c := newFakeClient(t, srv)
+
+	got, err := c.GetCostCenterMembers(t.Context(), id)
+	if err != nil {
+		t.Fatalf("GetCostCenterMembers: %v", err)
+	}
+	if len(got) != len(users) {
+		t.Fatalf("got %d members, want %d", len(got), len(users))
+	}
+	if got[len(got)-1] != "user-1233" {
+		t.Errorf("last member = %q, want user-1233", got[len(got)-1])
+	}
+	pages := 0
+	for _, r := range srv.Requests() {
+		if strings.HasSuffix(r, "/settings/billing/cost-centers/"+id) {
+			pages++
+		}
+	}
+	if pages != 13 {
+		t.Errorf("fetched %d pages, want 13 (per_page=100)", pages)
+	}
+}
+
+func TestProbeCostCenters(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.AddSeats("alice")
+	c := newFakeClient(t, srv)
+	if err := c.ProbeCostCenters(t.Context()); err != nil {
+		t.Fatalf("ProbeCostCenters on an enabled enterprise: %v", err)
+	}
+
+	srv.DisableCostCenters()
+	err := c.ProbeCostCenters(t.Context())
+	var unavailable *github.CostCentersUnavailableError
+	if !errors.As(err, &unavailable) {
+		t.Fatalf("err = %v, want *CostCentersUnavailableError", err)
+	}
+	if unavailable.StatusCode != http.StatusNotFound || !strings.Contains(err.Error(), "enhanced billing platform") {
+		t.Errorf("err = %v", err)
+	}
+	// Reads outside the cost centers API keep working for the report.
+	if users, err := c.GetCopilotUsers(t.Context()); err != nil || len(users) != 1 {
+		t.Errorf("GetCopilotUsers = %v, %v", users, err)
+	}
+}
+
+func TestSharedRateLimit_RecordsRequests(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.AddSeats("alice")
+	cc := srv.AddCostCenter("Eng")
+	ledger := t.TempDir() + "/ledger.ndjson"
+	cfg := &config.Manager{
+		Enterprise: srv.Enterprise, APIBaseURL: srv.URL, Token: "test-token",
+		SharedRateLimitFile: ledger, SharedRateLimitBudget: 900,
+	}
+	c, err := github.NewClient(cfg, slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	if _, err := c.GetCopilotUsers(t.Context()); err != nil {
+		t.Fatalf("GetCopilotUsers: %v", err)
+	}
+	if _, err := c.BulkUpdateCostCenterAssignments(t.Context(), map[string][]string{cc: {"alice"}}, true); err != nil {
+		t.Fatalf("BulkUpdateCostCenterAssignments: %v", err)
+	}
+
+	data, err := os.ReadFile(ledger)
+	if err != nil {
+		t.Fatalf("reading ledger: %v", err)
+	}
+	points := 0
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var r quota.Record
+		if err := json.Unmarshal([]byte(line), &r); err != nil || r.Tool != "gh-cost-center" {
+			t.Fatalf("bad ledger record %q: %v", line, err)
+		}
+		points += r.Points
+	}
+	reads, writes := 0, 0
+	for _, req := range srv.Requests() {
+		if strings.HasPrefix(req, "GET ") {
+			reads++
+		} else {
+			writes++
+		}
+	}
+	if want := reads*quota.ReadPoints + writes*quota.WritePoints; points != want || writes == 0 {
+		t.Errorf("ledger holds %d points, want %d (%d reads, %d writes)", points, want, reads, writes)
+	}
+}
+
+func TestProbes(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.AddOrgTeam("acme", githubtest.Team{ID: 1, Name: "Platform", Slug: "platform", Members: []string{"alice"}})
+	c := newFakeClient(t, srv)
+
+	if err := c.ProbeEnterpriseTeams(t.Context()); err != nil {
+		t.Errorf("ProbeEnterpriseTeams: %v", err)
+	}
+	if err := c.ProbeOrgTeams(t.Context(), "acme"); err != nil {
+		t.Errorf("ProbeOrgTeams: %v", err)
+	}
+	// The fake server has no custom properties endpoint.
+	err := c.ProbeOrgCustomProperties(t.Context(), "acme")
+	var apiErr *github.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || !strings.Contains(err.Error(), "custom properties of acme") {
+		t.Errorf("ProbeOrgCustomProperties = %v, want a 404 naming the organization", err)
+	}
+}
+
+func TestExternalGroups(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	for i := 1; i <= 120; i++ {
+		srv.AddExternalGroup("acme", githubtest.ExternalGroup{ID: int64(i), Name: fmt.Sprintf("group-%d", i)})
+	}
+	srv.AddExternalGroup("acme", githubtest.ExternalGroup{ID: 500, Name: "Engineering", Members: []string{"alice_acme", "bob_acme"}})
+	var many []string
+	for i := 1; i <= 150; i++ {
+		many = append(many, fmt.Sprintf("user%d_acme", i))
+	}
+	srv.AddExternalGroup("acme", githubtest.ExternalGroup{ID: 501, Name: "Everyone", Members: many})
+	c := newFakeClient(t, srv)
+
+	groups, err := c.GetOrgExternalGroups(t.Context(), "acme")
+	if err != nil {
+		t.Fatalf("GetOrgExternalGroups: %v", err)
+	}
+	if len(groups) != 122 || groups[120].ID != 500 || groups[120].Name != "Engineering" {
+		t.Fatalf("got %d groups, last %+v", len(groups), groups[len(groups)-1])
+	}
+
+	members, err := c.GetExternalGroupMembers(t.Context(), "acme", 500)
+	if err != nil {
+		t.Fatalf("GetExternalGroupMembers: %v", err)
+	}
+	if len(members) != 2 || members[0].Login != "alice_acme" || members[1].Login != "bob_acme" {
+		t.Errorf("members = %+v", members)
+	}
+	members, err = c.GetExternalGroupMembers(t.Context(), "acme", 501)
+	if err != nil {
+		t.Fatalf("GetExternalGroupMembers (paged): %v", err)
+	}
+	if len(members) != 150 || members[149].Login != "user150_acme" {
+		t.Errorf("got %d members of a paged group, want 150", len(members))
+	}
+	if _, err := c.GetExternalGroupMembers(t.Context(), "acme", 999); err == nil {
+		t.Error("expected an error for an unknown group")
+	}
+}
+
+func TestGetEnterpriseIdentities(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.AddIdentity(githubtest.Identity{Login: "alice_acme", NameID: "alice@acme.com", SCIMUsername: "alice@acme.com", Emails: []string{"alice@acme.com", "a.smith@acme.com"}})
+	srv.AddIdentity(githubtest.Identity{Login: "bob_acme", NameID: "CN=Bob,OU=Contractors,DC=acme,DC=com", Attributes: map[string]string{
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/department": "Finance",
+		"employeeId": "E-42",
+	}})
+	srv.AddIdentity(githubtest.Identity{Login: "carol_acme", Emails: []string{"carol@vendor.com"}})
+	c := newFakeClient(t, srv)
+
+	ids, err := c.GetEnterpriseIdentities(t.Context())
+	if err != nil {
+		t.Fatalf("GetEnterpriseIdentities: %v", err)
+	}
+	if len(ids) != 3 {
+		t.Fatalf("got %d identities across pages, want 3", len(ids))
+	}
+	if got := ids[0].Values(); !reflect.DeepEqual(got, []string{"alice@acme.com", "alice@acme.com", "alice@acme.com", "a.smith@acme.com"}) {
+		t.Errorf("alice values = %v", got)
+	}
+	if ids[1].NameID != "CN=Bob,OU=Contractors,DC=acme,DC=com" || ids[2].Emails[0] != "carol@vendor.com" {
+		t.Errorf("identities = %+v", ids)
+	}
+	attrs := ids[1].AttributeMap()
+	for name, want := range map[string]string{"department": "Finance", "employeeid": "E-42", "name_id": "CN=Bob,OU=Contractors,DC=acme,DC=com"} {
+		if got := attrs[name]; len(got) != 1 || got[0] != want {
+			t.Errorf("attribute %s = %v, want %q", name, got, want)
+		}
+	}
+
+	srv.SetIdentityProvider("oidc")
+	ids, err = c.GetEnterpriseIdentities(t.Context())
+	if err != nil {
+		t.Fatalf("GetEnterpriseIdentities (oidc): %v", err)
+	}
+	if len(ids) != 3 || ids[0].NameID != "" || ids[0].SCIMUsername != "alice@acme.com" {
+		t.Errorf("oidc identities = %+v", ids)
+	}
+
+	rep := c.PermissionReport()
+	if len(rep.Used) == 0 || rep.Used[0].Area != "Enterprise external identities" || rep.Used[0].Access != "read" {
+		t.Errorf("permission usage = %+v", rep.Used)
+	}
+}
+
+func TestGetOrgMembers(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	logins := make([]string, 150)
+	for i := range logins {
+		logins[i] = fmt.Sprintf("user-%03d", i)
+	}
+	srv.SetOrgMembers("acme", logins...)
+	c := newFakeClient(t, srv)
+
+	members, err := c.GetOrgMembers(t.Context(), "acme")
+	if err != nil {
+		t.Fatalf("GetOrgMembers: %v", err)
+	}
+	if len(members) != 150 || members[149].Login != "user-149" {
+		t.Fatalf("got %d members, last %+v", len(members), members[len(members)-1])
+	}
+	if _, err := c.GetOrgMembers(t.Context(), "unknown"); err == nil {
+		t.Error("expected an error for an unknown organization")
+	}
+}
+
+func TestGetOrgRepoTopics(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	for i := range 120 {
+		srv.AddRepo("acme", githubtest.Repo{Name: fmt.Sprintf("repo-%03d", i)})
+	}
+	srv.AddRepo("acme", githubtest.Repo{Name: "payments-api", Topics: []string{"payments", "go"}})
+	c := newFakeClient(t, srv)
+
+	topics, err := c.GetOrgRepoTopics(t.Context(), "acme")
+	if err != nil {
+		t.Fatalf("GetOrgRepoTopics: %v", err)
+	}
+	if len(topics) != 121 {
+		t.Errorf("got %d repositories, want 121", len(topics))
+	}
+	if got := topics["acme/payments-api"]; !reflect.DeepEqual(got, []string{"payments", "go"}) {
+		t.Errorf("topics = %v", got)
+	}
+	if got, ok := topics["acme/repo-000"]; !ok || len(got) != 0 {
+		t.Errorf("repo-000 topics = %v, %v", got, ok)
+	}
+}
+
+func TestGetCopilotUsers_AssigningOrganization(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.AddSeat(githubtest.Seat{Login: "alice", Organization: "acme", AssigningTeam: "data"})
+	srv.AddSeat(githubtest.Seat{Login: "bob"})
+	c := newFakeClient(t, srv)
+
+	users, err := c.GetCopilotUsers(t.Context())
+	if err != nil {
+		t.Fatalf("GetCopilotUsers: %v", err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("got %d users, want 2", len(users))
+	}
+	if users[0].AssigningOrg() != "acme" || users[0].AssigningTeamSlug() != "data" {
+		t.Errorf("alice: org %q, team %q", users[0].AssigningOrg(), users[0].AssigningTeamSlug())
+	}
+	if users[1].AssigningOrg() != "" || users[1].AssigningTeamSlug() != "" {
+		t.Errorf("bob: org %q, team %q, want none", users[1].AssigningOrg(), users[1].AssigningTeamSlug())
+	}
+}
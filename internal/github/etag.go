@@ -0,0 +1,161 @@
+package github
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/renan-alm/gh-cost-center/internal/cache"
+)
+
+// etagTransport wraps an http.RoundTripper and makes GET requests
+// conditional: a response carrying an ETag or Last-Modified is kept in the
+// store, later requests for the same URL send If-None-Match or
+// If-Modified-Since, and a 304 Not Modified is answered from the store as a
+// 200.  Conditional requests answered with 304 do not count against the
+// primary rate limit, so repeated runs over unchanged teams, seats and cost
+// centers cost almost none of it.
+type etagTransport struct {
+	base  http.RoundTripper
+	store *cache.HTTPStore
+
+	stats *httpCacheStats
+}
+
+// httpCacheStats counts 304s served from the store (hits) and full
+// responses stored (misses).
+type httpCacheStats struct {
+	hits, misses atomic.Int64
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Requests that are already conditional want to see the 304 themselves.
+	if req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" {
+		return t.base.RoundTrip(req)
+	}
+	url := req.URL.String()
+	entry, cached := t.store.Get(url)
+	if cached {
+		req = req.Clone(req.Context())
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached && resp.StatusCode == http.StatusNotModified {
+		body, err := t.store.Body(url)
+		if err != nil {
+			// The body went missing: ask again without validators.
+			_ = resp.Body.Close()
+			req.Header.Del("If-None-Match")
+			req.Header.Del("If-Modified-Since")
+			return t.base.RoundTrip(req)
+		}
+		_ = resp.Body.Close()
+		resp.StatusCode = http.StatusOK
+		resp.Status = "200 OK (not modified)"
+		resp.Header.Set(cacheStatusHeader, "hit")
+		if entry.Link != "" {
+			resp.Header.Set("Link", entry.Link)
+		}
+		if entry.ContentType != "" {
+			resp.Header.Set("Content-Type", entry.ContentType)
+		}
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Body = body
+		t.stats.hits.Add(1)
+		return resp, nil
+	}
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if resp.StatusCode != http.StatusOK || (etag == "" && lastModified == "") {
+		return resp, nil
+	}
+	t.stats.misses.Add(1)
+	w, err := t.store.Put(cache.HTTPEntry{
+		URL:          url,
+		ETag:         etag,
+		LastModified: lastModified,
+		Link:         resp.Header.Get("Link"),
+		ContentType:  resp.Header.Get("Content-Type"),
+	})
+	if err != nil {
+		return resp, nil // caching is best-effort
+	}
+	resp.Body = &teeBody{body: resp.Body, w: w}
+	return resp, nil
+}
+
+// cacheStatusHeader marks responses served from the HTTP cache.
+const cacheStatusHeader = "X-Cost-Center-Cache"
+
+// teeBody copies a response body into the store as it is read, and commits
+// it once the body has been read to the end.
+type teeBody struct {
+	body io.ReadCloser
+	w    *cache.HTTPWriter
+	eof  bool
+	err  bool
+}
+
+func (b *teeBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if n > 0 && !b.err {
+		if _, werr := b.w.Write(p[:n]); werr != nil {
+			b.err = true
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		b.eof = true
+	}
+	return n, err
+}
+
+// Close commits the stored body when it is complete.  A decoder may stop
+// before the final bytes (trailing whitespace), so the rest is drained
+// first.
+func (b *teeBody) Close() error {
+	if !b.eof && !b.err {
+		if _, err := io.Copy(io.Discard, b); err != nil {
+			b.err = true
+		}
+	}
+	err := b.body.Close()
+	if b.eof && !b.err {
+		_ = b.w.Commit()
+	} else {
+		b.w.Discard()
+	}
+	return err
+}
+
+// SetHTTPCache makes GET requests conditional on the responses kept in
+// store (see etagTransport).  Call it before the first request.
+func (c *Client) SetHTTPCache(store *cache.HTTPStore) {
+	base := c.http.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c.httpCache = &httpCacheStats{}
+	c.http.Transport = &etagTransport{base: base, store: store, stats: c.httpCache}
+}
+
+// HTTPCacheStats returns how many GET responses were served from the HTTP
+// cache (304 Not Modified) and how many were fetched and stored in full.
+func (c *Client) HTTPCacheStats() (hits, misses int64) {
+	if c.httpCache == nil {
+		return 0, 0
+	}
+	return c.httpCache.hits.Load(), c.httpCache.misses.Load()
+}
@@ -1,14 +1,19 @@
 package github
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -24,7 +29,7 @@ type discardW struct{}
 
 func (discardW) Write(p []byte) (int, error) { return len(p), nil }
 
-func newTestClient(t *testing.T, url string) *Client {
+func newTestClient(t testing.TB, url string) *Client {
 	t.Helper()
 	return &Client{
 		http:       &http.Client{Timeout: 5 * time.Second},
@@ -113,18 +118,83 @@ func TestNewClient(t *testing.T) {
 	})
 }
 
+func TestResolveToken(t *testing.T) {
+	// A fake gh that knows a keyring token for one host only, standing in
+	// for `gh auth token`.
+	gh := filepath.Join(t.TempDir(), "gh")
+	script := "#!/bin/sh\nfor a; do host=$a; done\n[ \"$host\" = keyring.example.test ] && echo \"keyring-$host\"\nexit 0\n"
+	if err := os.WriteFile(gh, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	t.Setenv("GH_PATH", gh)
+	tests := []struct {
+		name string
+		host string
+		env  map[string]string
+		want string
+	}{
+		{"GH_TOKEN before GITHUB_TOKEN", "github.com", map[string]string{"GH_TOKEN": "gh", "GITHUB_TOKEN": "github"}, "gh"},
+		{"data residency uses GH_TOKEN", "octo.ghe.com", map[string]string{"GH_TOKEN": "gh", "GH_ENTERPRISE_TOKEN": "ent"}, "gh"},
+		{"GHES uses enterprise token", "ghe.example.test", map[string]string{"GITHUB_TOKEN": "github", "GITHUB_ENTERPRISE_TOKEN": "ent"}, "ent"},
+		{"GHES falls back to GITHUB_TOKEN", "ghe.example.test", map[string]string{"GITHUB_TOKEN": "github"}, "github"},
+		{"gh keyring before GITHUB_TOKEN", "keyring.example.test", map[string]string{"GITHUB_TOKEN": "github"}, "keyring-keyring.example.test"},
+		{"no token", "ghe.example.test", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"GH_TOKEN", "GITHUB_TOKEN", "GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN", "CODESPACES"} {
+				t.Setenv(k, tt.env[k])
+			}
+			if got, _ := resolveToken("", tt.host); got != tt.want {
+				t.Errorf("token = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if got, src := resolveToken("flag", "github.com"); got != "flag" || src != "--token flag" {
+		t.Errorf("flag token = %q (%s), want the --token value", got, src)
+	}
+}
+
+func TestSortCostCenterIDs(t *testing.T) {
+	ids := []string{"d", "catch-all", "b", "a", "finance"}
+	got := sortCostCenterIDs(ids, []string{"finance", "missing"}, []string{"catch-all"})
+	want := "finance,a,b,d,catch-all"
+	if strings.Join(got, ",") != want {
+		t.Errorf("sortCostCenterIDs = %v, want %s", got, want)
+	}
+}
+
+func TestAPIPathPrefix(t *testing.T) {
+	tests := []struct {
+		baseURL, want string
+	}{
+		{"https://api.github.com", ""},
+		{"https://ghes.example.com/api/v3", "/api/v3"},
+		{"https://api.acme.ghe.com", ""},
+	}
+	for _, tt := range tests {
+		c := &Client{baseURL: tt.baseURL}
+		if got := c.apiPathPrefix(); got != tt.want {
+			t.Errorf("apiPathPrefix(%q) = %q, want %q", tt.baseURL, got, tt.want)
+		}
+	}
+}
+
 func TestEnterpriseURL(t *testing.T) {
-	c := &Client{baseURL: "https://api.github.com", enterprise: "my-ent"}
 	tests := []struct {
-		path, want string
+		baseURL, path, want string
 	}{
-		{"/copilot/billing/seats", "https://api.github.com/enterprises/my-ent/copilot/billing/seats"},
-		{"/settings/billing/cost-centers", "https://api.github.com/enterprises/my-ent/settings/billing/cost-centers"},
-		{"/teams", "https://api.github.com/enterprises/my-ent/teams"},
+		{"https://api.github.com", "/copilot/billing/seats", "https://api.github.com/enterprises/my-ent/copilot/billing/seats"},
+		{"https://api.github.com", "/settings/billing/cost-centers", "https://api.github.com/enterprises/my-ent/settings/billing/cost-centers"},
+		{"https://api.github.com", "/teams", "https://api.github.com/enterprises/my-ent/teams"},
+		{"https://ghes.example.com/api/v3", "/teams", "https://ghes.example.com/api/v3/enterprises/my-ent/teams"},
+		{"https://api.acme.ghe.com", "/teams", "https://api.acme.ghe.com/enterprises/my-ent/teams"},
 	}
 	for _, tt := range tests {
+		c := &Client{baseURL: tt.baseURL, enterprise: "my-ent"}
 		if got := c.enterpriseURL(tt.path); got != tt.want {
-			t.Errorf("enterpriseURL(%q) = %q, want %q", tt.path, got, tt.want)
+			t.Errorf("enterpriseURL(%q) on %s = %q, want %q", tt.path, tt.baseURL, got, tt.want)
 		}
 	}
 }
@@ -182,6 +252,49 @@ func TestBackoff(t *testing.T) {
 	}
 }
 
+func TestRetryPolicy(t *testing.T) {
+	var zero retryPolicy
+	if zero.attempts() != maxRetries || zero.backoff(1) != 2*retryBackoffBase {
+		t.Errorf("zero policy = %d attempts, backoff(1) %v", zero.attempts(), zero.backoff(1))
+	}
+
+	p := retryPolicy{maxRetries: 8, base: 500 * time.Millisecond, max: 3 * time.Second}
+	if p.attempts() != 8 {
+		t.Errorf("attempts = %d, want 8", p.attempts())
+	}
+	if got := p.backoff(2); got != 2*time.Second {
+		t.Errorf("backoff(2) = %v, want 2s", got)
+	}
+	if got := p.backoff(6); got != 3*time.Second {
+		t.Errorf("backoff(6) = %v, want capped at 3s", got)
+	}
+
+	p.jitter = 0.5
+	for range 100 {
+		if got := p.backoff(1); got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("backoff(1) with jitter 0.5 = %v, want within 0.5s..1.5s", got)
+		}
+	}
+}
+
+func TestDoJSON_MaxRetriesOverride(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL)
+	c.retry = retryPolicy{maxRetries: 1}
+
+	if _, err := c.doJSON(t.Context(), http.MethodGet, srv.URL+"/test", nil, nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
 func TestRateLimitWait(t *testing.T) {
 	c := &Client{log: testLogger()}
 	t.Run("with valid header", func(t *testing.T) {
@@ -204,6 +317,22 @@ func TestRateLimitWait(t *testing.T) {
 			t.Errorf("rateLimitWait = %v, want %v", wait, rateLimitFallback)
 		}
 	})
+	t.Run("retry-after seconds", func(t *testing.T) {
+		resp := &http.Response{Header: http.Header{
+			"Retry-After":       []string{"7"},
+			"X-Ratelimit-Reset": []string{strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)},
+		}}
+		if wait := c.rateLimitWait(resp); wait != 7*time.Second {
+			t.Errorf("rateLimitWait = %v, want 7s (Retry-After wins)", wait)
+		}
+	})
+	t.Run("retry-after date", func(t *testing.T) {
+		at := time.Now().Add(20 * time.Second).UTC().Format(http.TimeFormat)
+		resp := &http.Response{Header: http.Header{"Retry-After": []string{at}}}
+		if wait := c.rateLimitWait(resp); wait < 18*time.Second || wait > 21*time.Second {
+			t.Errorf("rateLimitWait = %v, expected ~20s", wait)
+		}
+	})
 	t.Run("past reset time", func(t *testing.T) {
 		resetTime := time.Now().Add(-10 * time.Second)
 		resp := &http.Response{Header: http.Header{"X-Ratelimit-Reset": []string{strconv.FormatInt(resetTime.Unix(), 10)}}}
@@ -213,6 +342,230 @@ func TestRateLimitWait(t *testing.T) {
 	})
 }
 
+func TestDo_APIVersionOverride(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-GitHub-Api-Version"); got != "2026-03-10" {
+			t.Errorf("X-GitHub-Api-Version = %q, want the configured version", got)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL)
+	c.apiVersion = "2026-03-10"
+	if _, err := c.doJSON(t.Context(), http.MethodGet, srv.URL+"/rate_limit", nil, nil); err != nil {
+		t.Fatalf("doJSON: %v", err)
+	}
+}
+
+func TestCheckSchema_ReportsDrift(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"costCenters": [
+			{"id": "cc-1", "name": "A", "state": "active", "resources": []},
+			{"uuid": "cc-2", "name": "", "state": "active", "owner": "x"},
+			{"uuid": "cc-3", "name": "C", "state": null, "owner": "y"}
+		]}`))
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL)
+
+	var resp costCentersListResponse
+	if _, err := c.doJSON(t.Context(), http.MethodGet, srv.URL+"/cost-centers", nil, &resp); err != nil {
+		t.Fatalf("doJSON: %v", err)
+	}
+	got := make(map[string]int)
+	for _, f := range c.SchemaDrift() {
+		if f.Response != "cost centers" {
+			t.Errorf("unexpected response %q", f.Response)
+		}
+		got[f.Field+" "+f.Kind] = f.Count
+	}
+	want := map[string]int{
+		"costCenters[].uuid unknown":  2,
+		"costCenters[].owner unknown": 2,
+		"costCenters[].id missing":    2,
+		"costCenters[].name empty":    1,
+		"costCenters[].state empty":   1,
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("drift = %v, want %v", got, want)
+	}
+}
+
+func TestCheckSchema_IgnoresUncheckedTypes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"login": "alice", "extra": 1}]`))
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL)
+
+	var members []TeamMember
+	if _, err := c.doJSON(t.Context(), http.MethodGet, srv.URL+"/members", nil, &members); err != nil {
+		t.Fatalf("doJSON: %v", err)
+	}
+	if drift := c.SchemaDrift(); len(drift) != 0 {
+		t.Errorf("expected no drift for team members, got %+v", drift)
+	}
+}
+
+func TestGetJSON_CoalescesInFlightRequests(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		_ = json.NewEncoder(w).Encode(map[string]string{"path": r.URL.Path})
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL)
+
+	const callers = 5
+	url := srv.URL + "/enterprises/test-ent/settings/billing/cost-centers/cc-1"
+	results := make(chan string, callers)
+	var started sync.WaitGroup
+	started.Add(callers)
+	for range callers {
+		go func() {
+			started.Done()
+			var out struct{ Path string }
+			if _, err := c.doJSON(t.Context(), http.MethodGet, url, nil, &out); err != nil {
+				t.Errorf("doJSON: %v", err)
+			}
+			results <- out.Path
+		}()
+	}
+	// Release the response once the first call reached the API and the
+	// other callers had time to join it.
+	started.Wait()
+	for hits.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	for range callers {
+		if got := <-results; got != "/enterprises/test-ent/settings/billing/cost-centers/cc-1" {
+			t.Errorf("decoded path = %q", got)
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("API calls = %d, want 1", n)
+	}
+
+	// Later requests are not served from the finished call.
+	if _, err := c.doJSON(t.Context(), http.MethodGet, url, nil, nil); err != nil {
+		t.Fatalf("doJSON: %v", err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("API calls = %d, want 2 after a sequential request", n)
+	}
+}
+
+func TestGetJSON_CallerContextDoesNotCancelFlight(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		<-release
+		_ = json.NewEncoder(w).Encode(map[string]string{"id": "cc-1"})
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL)
+	url := srv.URL + "/enterprises/test-ent/settings/billing/cost-centers/cc-1"
+
+	first, cancel := context.WithCancel(t.Context())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := c.doJSON(first, http.MethodGet, url, nil, nil)
+		firstErr <- err
+	}()
+	for hits.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	secondErr := make(chan error, 1)
+	go func() {
+		var out struct{ ID string }
+		_, err := c.doJSON(t.Context(), http.MethodGet, url, nil, &out)
+		if err == nil && out.ID != "cc-1" {
+			err = fmt.Errorf("decoded id = %q", out.ID)
+		}
+		secondErr <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// The first caller gives up; the second still gets the response.
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled caller: err = %v, want context.Canceled", err)
+	}
+	close(release)
+	if err := <-secondErr; err != nil {
+		t.Errorf("live caller: %v", err)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("API calls = %d, want 1", n)
+	}
+}
+
+func TestGetJSON_WriteStartsNewFlight(t *testing.T) {
+	var gets atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			gets.Add(1)
+			<-release
+		}
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL)
+	base := srv.URL + "/enterprises/test-ent/settings/billing/cost-centers/cc-1"
+
+	done := make(chan error, 2)
+	get := func() {
+		_, err := c.doJSON(t.Context(), http.MethodGet, base, nil, nil)
+		done <- err
+	}
+	go get()
+	for gets.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := c.doJSON(t.Context(), http.MethodPost, base+"/resource", map[string]any{"users": []string{"alice"}}, nil); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	// A GET issued after the write reaches the API instead of joining the
+	// flight that started before it.
+	go get()
+	for gets.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	for range 2 {
+		if err := <-done; err != nil {
+			t.Errorf("GET: %v", err)
+		}
+	}
+}
+
+func TestFlightGenerations(t *testing.T) {
+	var g flightGenerations
+	cc1 := "https://api.github.com/enterprises/e/settings/billing/cost-centers/cc-1"
+	cc2 := "https://api.github.com/enterprises/e/settings/billing/cost-centers/cc-2"
+	list := "https://api.github.com/enterprises/e/settings/billing/cost-centers?state=active"
+	k1, k2, kl := g.key(cc1), g.key(cc2), g.key(list)
+
+	g.bump(cc1 + "/resource")
+	if g.key(cc1) == k1 {
+		t.Error("a write to cc-1 kept the flight key of cc-1")
+	}
+	if g.key(cc2) != k2 {
+		t.Error("a write to cc-1 changed the flight key of cc-2")
+	}
+	if g.key(list) == kl {
+		t.Error("a write kept the flight key of the cost center list")
+	}
+}
+
 func TestDoJSON_Success(t *testing.T) {
 	type payload struct {
 		Name string `json:"name"`
@@ -237,7 +590,7 @@ func TestDoJSON_Success(t *testing.T) {
 	defer srv.Close()
 	c := newTestClient(t, srv.URL)
 	var got payload
-	if _, err := c.doJSON(http.MethodGet, srv.URL+"/test", nil, &got); err != nil {
+	if _, err := c.doJSON(t.Context(), http.MethodGet, srv.URL+"/test", nil, &got); err != nil {
 		t.Fatalf("doJSON: %v", err)
 	}
 	if got.Name != "Alice" || got.Age != 30 {
@@ -251,7 +604,7 @@ func TestDoJSON_NoBody(t *testing.T) {
 	}))
 	defer srv.Close()
 	c := newTestClient(t, srv.URL)
-	if _, err := c.doJSON(http.MethodPost, srv.URL+"/test", map[string]string{"a": "b"}, nil); err != nil {
+	if _, err := c.doJSON(t.Context(), http.MethodPost, srv.URL+"/test", map[string]string{"a": "b"}, nil); err != nil {
 		t.Fatalf("doJSON: %v", err)
 	}
 }
@@ -272,7 +625,7 @@ func TestDoJSON_PostWithBody(t *testing.T) {
 	defer srv.Close()
 	c := newTestClient(t, srv.URL)
 	var resp map[string]string
-	if _, err := c.doJSON(http.MethodPost, srv.URL+"/test", map[string]string{"name": "test-cc"}, &resp); err != nil {
+	if _, err := c.doJSON(t.Context(), http.MethodPost, srv.URL+"/test", map[string]string{"name": "test-cc"}, &resp); err != nil {
 		t.Fatalf("doJSON: %v", err)
 	}
 	if resp["id"] != "abc-123" {
@@ -287,7 +640,7 @@ func TestDoJSON_NonRetryableError(t *testing.T) {
 	}))
 	defer srv.Close()
 	c := newTestClient(t, srv.URL)
-	_, err := c.doJSON(http.MethodGet, srv.URL+"/test", nil, nil)
+	_, err := c.doJSON(t.Context(), http.MethodGet, srv.URL+"/test", nil, nil)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -318,7 +671,7 @@ func TestDoJSON_RetryOnServerError(t *testing.T) {
 	defer srv.Close()
 	c := newTestClient(t, srv.URL)
 	var resp map[string]string
-	if _, err := c.doJSON(http.MethodGet, srv.URL+"/test", nil, &resp); err != nil {
+	if _, err := c.doJSON(t.Context(), http.MethodGet, srv.URL+"/test", nil, &resp); err != nil {
 		t.Fatalf("doJSON: %v", err)
 	}
 	if resp["status"] != "ok" {
@@ -329,6 +682,55 @@ func TestDoJSON_RetryOnServerError(t *testing.T) {
 	}
 }
 
+func TestRateLimitKind(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		body   string
+		want   string
+	}{
+		{"429", http.StatusTooManyRequests, http.Header{}, "", "primary"},
+		{"403 retry-after", http.StatusForbidden, http.Header{"Retry-After": []string{"30"}}, "", "secondary"},
+		{"403 secondary message", http.StatusForbidden, http.Header{}, `{"message":"You have exceeded a secondary rate limit."}`, "secondary"},
+		{"403 primary exhausted", http.StatusForbidden, http.Header{"X-Ratelimit-Remaining": []string{"0"}}, "", "primary"},
+		{"403 permission", http.StatusForbidden, http.Header{"X-Ratelimit-Remaining": []string{"4999"}}, `{"message":"Resource not accessible by integration"}`, ""},
+		{"500", http.StatusInternalServerError, http.Header{"Retry-After": []string{"1"}}, "", ""},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: tt.header}
+		if got := rateLimitKind(resp, tt.body); got != tt.want {
+			t.Errorf("%s: rateLimitKind = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDoJSON_SecondaryRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"You have exceeded a secondary rate limit."}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL)
+
+	start := time.Now()
+	if _, err := c.doJSON(t.Context(), http.MethodPost, srv.URL+"/test", map[string]string{"a": "b"}, nil); err != nil {
+		t.Fatalf("doJSON: %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d, want 2", got)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want Retry-After honored", elapsed)
+	}
+}
+
 func TestDoJSON_ExhaustedRetries(t *testing.T) {
 	var calls atomic.Int32
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -338,7 +740,7 @@ func TestDoJSON_ExhaustedRetries(t *testing.T) {
 	}))
 	defer srv.Close()
 	c := newTestClient(t, srv.URL)
-	_, err := c.doJSON(http.MethodGet, srv.URL+"/test", nil, nil)
+	_, err := c.doJSON(t.Context(), http.MethodGet, srv.URL+"/test", nil, nil)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -513,7 +915,7 @@ func TestGetCopilotUsers_Pagination(t *testing.T) {
 	}))
 	defer srv.Close()
 	c := newTestClient(t, srv.URL)
-	users, err := c.GetCopilotUsers()
+	users, err := c.GetCopilotUsers(t.Context())
 	if err != nil {
 		t.Fatalf("GetCopilotUsers: %v", err)
 	}
@@ -522,6 +924,25 @@ func TestGetCopilotUsers_Pagination(t *testing.T) {
 	}
 }
 
+func TestNextPageURL(t *testing.T) {
+	tests := []struct {
+		link, want string
+	}{
+		{"", ""},
+		{`<https://api.github.com/x?page=2>; rel="next", <https://api.github.com/x?page=5>; rel="last"`, "https://api.github.com/x?page=2"},
+		{`<https://api.github.com/x?page=1>; rel="prev", <https://api.github.com/x?page=1>; rel="first"`, ""},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.link != "" {
+			resp.Header.Set("Link", tt.link)
+		}
+		if got := nextPageURL(resp); got != tt.want {
+			t.Errorf("nextPageURL(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
+
 func TestGetAllActiveCostCenters(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -534,7 +955,7 @@ func TestGetAllActiveCostCenters(t *testing.T) {
 	}))
 	defer srv.Close()
 	c := newTestClient(t, srv.URL)
-	active, err := c.GetAllActiveCostCenters()
+	active, err := c.GetAllActiveCostCenters(t.Context())
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -553,7 +974,7 @@ func TestCreateCostCenter_Success(t *testing.T) {
 	}))
 	defer srv.Close()
 	c := newTestClient(t, srv.URL)
-	id, err := c.CreateCostCenter("CC")
+	id, err := c.CreateCostCenter(t.Context(), "CC")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -569,7 +990,7 @@ func TestCreateCostCenter_Conflict(t *testing.T) {
 	}))
 	defer srv.Close()
 	c := newTestClient(t, srv.URL)
-	id, err := c.CreateCostCenter("Existing")
+	id, err := c.CreateCostCenter(t.Context(), "Existing")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -673,7 +1094,7 @@ func TestResolveCostCenters(t *testing.T) {
 		}))
 		defer srv.Close()
 		c := newTestClient(t, srv.URL)
-		noPRU, pruAllowed, err := c.ResolveCostCenters("No PRU", "PRU Allowed")
+		noPRU, pruAllowed, err := c.ResolveCostCenters(t.Context(), "No PRU", "PRU Allowed")
 		if err != nil {
 			t.Fatalf("err: %v", err)
 		}
@@ -694,7 +1115,7 @@ func TestResolveCostCenters(t *testing.T) {
 		}))
 		defer srv.Close()
 		c := newTestClient(t, srv.URL)
-		_, _, err := c.ResolveCostCenters("No PRU", "Missing CC")
+		_, _, err := c.ResolveCostCenters(t.Context(), "No PRU", "Missing CC")
 		if err == nil {
 			t.Fatal("expected error")
 		}
@@ -713,7 +1134,7 @@ func TestResolveCostCenters(t *testing.T) {
 		}))
 		defer srv.Close()
 		c := newTestClient(t, srv.URL)
-		_, _, err := c.ResolveCostCenters("No PRU", "PRU Allowed")
+		_, _, err := c.ResolveCostCenters(t.Context(), "No PRU", "PRU Allowed")
 		if err == nil {
 			t.Fatal("expected error")
 		}
@@ -725,7 +1146,7 @@ func TestResolveCostCenters(t *testing.T) {
 
 func TestAddUsersToCostCenter_InvalidID(t *testing.T) {
 	c := newTestClient(t, "http://unused")
-	_, err := c.AddUsersToCostCenter("not-a-uuid", []string{"alice"}, true)
+	_, err := c.AddUsersToCostCenter(t.Context(), "not-a-uuid", []string{"alice"}, true)
 	if err == nil {
 		t.Fatal("expected error for invalid ID")
 	}
@@ -736,7 +1157,7 @@ func TestAddUsersToCostCenter_InvalidID(t *testing.T) {
 
 func TestGetCostCenter_InvalidID(t *testing.T) {
 	c := newTestClient(t, "http://unused")
-	_, err := c.GetCostCenter("Ölbrück-Straße")
+	_, err := c.GetCostCenter(t.Context(), "Ölbrück-Straße")
 	if err == nil {
 		t.Fatal("expected error for invalid ID with special chars")
 	}
@@ -752,7 +1173,7 @@ func TestListBudgets_NotFound(t *testing.T) {
 	}))
 	defer srv.Close()
 	c := newTestClient(t, srv.URL)
-	_, err := c.ListBudgets()
+	_, err := c.ListBudgets(t.Context())
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -771,7 +1192,7 @@ func TestListBudgets_Success(t *testing.T) {
 	}))
 	defer srv.Close()
 	c := newTestClient(t, srv.URL)
-	budgets, err := c.ListBudgets()
+	budgets, err := c.ListBudgets(t.Context())
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -797,7 +1218,7 @@ func TestGetOrgTeams_Pagination(t *testing.T) {
 	}))
 	defer srv.Close()
 	c := newTestClient(t, srv.URL)
-	teams, err := c.GetOrgTeams("my-org")
+	teams, err := c.GetOrgTeams(t.Context(), "my-org")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -816,7 +1237,7 @@ func TestGetOrgPropertySchema(t *testing.T) {
 	}))
 	defer srv.Close()
 	c := newTestClient(t, srv.URL)
-	defs, err := c.GetOrgPropertySchema("my-org")
+	defs, err := c.GetOrgPropertySchema(t.Context(), "my-org")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -827,3 +1248,269 @@ func TestGetOrgPropertySchema(t *testing.T) {
 		t.Errorf("first = %q", defs[0].PropertyName)
 	}
 }
+
+func TestVerifyToken(t *testing.T) {
+	t.Run("accepted", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/rate_limit" {
+				t.Errorf("path = %q, want /rate_limit", r.URL.Path)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"resources":{}}`))
+		}))
+		defer srv.Close()
+		c := newTestClient(t, srv.URL)
+		if err := c.VerifyToken(t.Context()); err != nil {
+			t.Fatalf("VerifyToken: %v", err)
+		}
+	})
+	t.Run("rejected", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"Bad credentials"}`))
+		}))
+		defer srv.Close()
+		c := newTestClient(t, srv.URL)
+		err := c.VerifyToken(t.Context())
+		if err == nil {
+			t.Fatal("expected error for rejected token")
+		}
+		if !strings.Contains(err.Error(), "401") {
+			t.Errorf("error should mention 401: %v", err)
+		}
+	})
+}
+
+func TestCheckCostCenterMembership(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		if q.Get("resource_type") == "repo" && q.Get("name") == "my-org/my-repo" {
+			_ = json.NewEncoder(w).Encode(membershipResponse{Memberships: []Membership{
+				{CostCenter: CostCenterRef{ID: "cc-repo", Name: "Repo CC"}},
+			}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(membershipResponse{})
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL)
+
+	ref, err := c.CheckRepositoryCostCenterMembership(t.Context(), "my-org/my-repo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if ref == nil || ref.ID != "cc-repo" {
+		t.Fatalf("ref = %+v, want cc-repo", ref)
+	}
+
+	ref, err = c.CheckOrganizationCostCenterMembership(t.Context(), "my-org")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if ref != nil {
+		t.Errorf("expected no membership for org, got %+v", ref)
+	}
+
+	if _, err := c.CheckCostCenterMembership(t.Context(), "team", "x"); err == nil {
+		t.Error("expected error for unsupported resource type")
+	}
+}
+
+func TestChaosTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	t.Run("p=0 passes through", func(t *testing.T) {
+		tr := newChaosTransport(nil, 0, 1)
+		for i := 0; i < 20; i++ {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			resp, err := tr.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("RoundTrip: %v", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+		}
+	})
+
+	t.Run("p=1 always injects retryable errors", func(t *testing.T) {
+		tr := newChaosTransport(nil, 1, 1)
+		seen := map[int]bool{}
+		for i := 0; i < 50; i++ {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			resp, err := tr.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("RoundTrip: %v", err)
+			}
+			_ = resp.Body.Close()
+			if !retryableStatusCodes[resp.StatusCode] {
+				t.Fatalf("status = %d, want retryable", resp.StatusCode)
+			}
+			seen[resp.StatusCode] = true
+		}
+		if !seen[http.StatusTooManyRequests] || !seen[http.StatusInternalServerError] {
+			t.Errorf("expected both 429 and 500 to be injected, saw %v", seen)
+		}
+	})
+}
+
+func TestSetChaosProbability_Clamps(t *testing.T) {
+	defer SetChaosProbability(0)
+	SetChaosProbability(2)
+	if chaosProbability != 1 {
+		t.Errorf("chaosProbability = %v, want 1", chaosProbability)
+	}
+	SetChaosProbability(-1)
+	if chaosProbability != 0 {
+		t.Errorf("chaosProbability = %v, want 0", chaosProbability)
+	}
+}
+
+func TestRateGate(t *testing.T) {
+	header := func(resource string, remaining int, reset time.Time) *http.Response {
+		h := http.Header{}
+		h.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		h.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		if resource != "" {
+			h.Set("X-RateLimit-Resource", resource)
+		}
+		return &http.Response{Header: h}
+	}
+	reset := time.Now().Add(time.Minute)
+
+	g := newRateGate(10)
+	if d := g.pause(); d != 0 {
+		t.Errorf("pause before any response = %v, want 0", d)
+	}
+	g.observe(header("core", 500, reset))
+	if d := g.pause(); d != 0 {
+		t.Errorf("pause with budget left = %v, want 0", d)
+	}
+	g.observe(header("search", 0, reset))
+	if d := g.pause(); d != 0 {
+		t.Errorf("search limit should not gate core requests, pause = %v", d)
+	}
+
+	g.observe(header("", 10, reset))
+	g.observe(header("core", 400, reset)) // stale response from a concurrent request
+	if d := g.pause(); d < 50*time.Second || d > 62*time.Second {
+		t.Errorf("pause at reserve = %v, want about a minute", d)
+	}
+
+	g.observe(header("core", 5000, time.Now().Add(time.Hour))) // new window
+	if d := g.pause(); d != 0 {
+		t.Errorf("pause after reset = %v, want 0", d)
+	}
+
+	g.exhaust(time.Now().Add(-time.Second))
+	if d := g.pause(); d != 0 {
+		t.Errorf("pause after an expired 429 window = %v, want 0", d)
+	}
+
+	var nilGate *rateGate
+	nilGate.observe(header("core", 0, reset))
+	if d := nilGate.pause(); d != 0 {
+		t.Errorf("nil gate pause = %v", d)
+	}
+}
+
+func TestSplitApplyPhases(t *testing.T) {
+	head, middle, tail := splitApplyPhases([]string{"f1", "f2", "a", "b", "l1"}, []string{"f1", "f2"}, []string{"l1"})
+	if strings.Join(head, ",") != "f1,f2" || strings.Join(middle, ",") != "a,b" || strings.Join(tail, ",") != "l1" {
+		t.Errorf("phases = %v / %v / %v", head, middle, tail)
+	}
+	head, middle, tail = splitApplyPhases([]string{"a", "b"}, nil, nil)
+	if len(head) != 0 || len(middle) != 2 || len(tail) != 0 {
+		t.Errorf("unordered phases = %v / %v / %v", head, middle, tail)
+	}
+}
+
+func TestHandoffRecipients(t *testing.T) {
+	tests := []struct {
+		current, from, to []string
+		want              string
+	}{
+		{[]string{"carol", "dave"}, nil, []string{"alice"}, "alice"},
+		{[]string{"carol", "dave"}, []string{"carol"}, []string{"alice"}, "dave,alice"},
+		{[]string{"carol", "alice"}, []string{"carol"}, []string{"alice"}, "alice"},
+		{nil, []string{"carol"}, []string{"alice", "bob"}, "alice,bob"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(handoffRecipients(tt.current, tt.from, tt.to), ","); got != tt.want {
+			t.Errorf("handoffRecipients(%v, %v, %v) = %q, want %q", tt.current, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestResultsByCostCenter(t *testing.T) {
+	got := ResultsByCostCenter(map[string]map[string]bool{
+		"id-b": {"alice": true, "bob": false, "ann": false},
+		"id-a": {"carol": true},
+		"id-z": {"dave": true},
+	}, map[string]string{"id-a": "Zeta", "id-b": "Alpha"})
+
+	want := []CostCenterResult{
+		{ID: "id-z", Succeeded: 1, SucceededUsers: []string{"dave"}, Users: map[string]bool{"dave": true}},
+		{ID: "id-b", Name: "Alpha", Succeeded: 1, Failed: 2, SucceededUsers: []string{"alice"}, FailedUsers: []string{"ann", "bob"},
+			Users: map[string]bool{"alice": true, "bob": false, "ann": false}},
+		{ID: "id-a", Name: "Zeta", Succeeded: 1, SucceededUsers: []string{"carol"}, Users: map[string]bool{"carol": true}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+	if l := got[1].Label(); l != "Alpha (id-b)" {
+		t.Errorf("Label = %q", l)
+	}
+	if l := got[0].Label(); l != "id-z" {
+		t.Errorf("Label without a name = %q", l)
+	}
+}
+
+func TestMergeResults(t *testing.T) {
+	got := MergeResults(
+		ResultsByCostCenter(map[string]map[string]bool{"id-b": {"alice": true, "bob": false}}, map[string]string{"id-b": "Beta"}),
+		ResultsByCostCenter(map[string]map[string]bool{
+			"id-b": {"bob": true, "carol": false},
+			"id-a": {"dave": true},
+		}, map[string]string{"id-a": "Alpha", "id-b": "Beta"}),
+	)
+
+	want := []CostCenterResult{
+		{ID: "id-a", Name: "Alpha", Succeeded: 1, SucceededUsers: []string{"dave"}, Users: map[string]bool{"dave": true}},
+		{ID: "id-b", Name: "Beta", Succeeded: 2, Failed: 1, SucceededUsers: []string{"alice", "bob"}, FailedUsers: []string{"carol"},
+			Users: map[string]bool{"alice": true, "bob": true, "carol": false}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestEachUniqueCopilotUser_SkipsRepeatedLogins(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(seatsResponse{TotalSeats: 3, Seats: []seatEntry{
+			{Assignee: assignee{Login: "alice", ID: 1}, Organization: &SeatOrganization{Login: "acme"}},
+			{Assignee: assignee{Login: "bob", ID: 2}},
+			{Assignee: assignee{Login: "alice", ID: 1}, Organization: &SeatOrganization{Login: "acme-labs"}},
+		}})
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL)
+
+	var got []string
+	err := c.EachUniqueCopilotUser(t.Context(), func(u CopilotUser) error {
+		got = append(got, u.Login+"@"+u.AssigningOrg())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EachUniqueCopilotUser: %v", err)
+	}
+	if strings.Join(got, ",") != "alice@acme,bob@" {
+		t.Errorf("users = %v, want alice@acme,bob@", got)
+	}
+}
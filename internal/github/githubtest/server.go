@@ -0,0 +1,1035 @@
+// Package githubtest provides an in-memory fake of the GitHub billing, Copilot,
+// and teams REST endpoints used by gh-cost-center.  It is intended for
+// end-to-end tests of the managers and for forks that extend the tool: point a
+// github.Client at Server.URL and assert on the resulting state.
+//
+// The fake is deliberately small — it implements only the endpoints and
+// response shapes the client consumes, and keeps all state in memory behind a
+// mutex so it is safe for concurrent requests.
+package githubtest
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// DefaultEnterprise is the enterprise slug served when none is given.
+const DefaultEnterprise = "test-enterprise"
+
+// CostCenter is the fake server's view of a cost center and its resources.
+type CostCenter struct {
+	ID           string
+	Name         string
+	State        string // "active" or "deleted"
+	Users        []string
+	Repositories []string
+}
+
+// Seat is a Copilot seat assignment.  Organization and AssigningTeam (a
+// team slug) name what granted the seat; empty omits them.
+type Seat struct {
+	Login                   string
+	CreatedAt               string
+	PendingCancellationDate string
+	LastActivityAt          string
+	Organization            string
+	AssigningTeam           string
+}
+
+// Team is an organization or enterprise team with its member logins.
+// Maintainers are returned for role=maintainer member queries; they should
+// also appear in Members, as on the real API.
+type Team struct {
+	ID          int64
+	Name        string
+	Slug        string
+	Description string
+	Members     []string
+	Maintainers []string
+}
+
+// ExternalGroup is an identity provider group provisioned into an
+// organization, with its member logins.
+type ExternalGroup struct {
+	ID      int64
+	Name    string
+	Members []string
+}
+
+// Repo is an organization repository with its topics.
+type Repo struct {
+	Name   string
+	Topics []string
+}
+
+// Identity is an external identity of the enterprise's identity provider,
+// linked to a GitHub login.  The first email is the primary one.
+type Identity struct {
+	Login        string
+	NameID       string // SAML NameID; not served for an OIDC provider
+	SCIMUsername string
+	Emails       []string
+	Attributes   map[string]string // SAML attributes; not served for OIDC
+}
+
+// Budget is a budget created through the fake API or added with AddBudget.
+type Budget struct {
+	ID         string   `json:"id"`
+	Type       string   `json:"budget_type"`
+	ProductSKU string   `json:"budget_product_sku"`
+	Scope      string   `json:"budget_scope"`
+	Amount     int      `json:"budget_amount"`
+	EntityName string   `json:"budget_entity_name"`
+	Alerting   Alerting `json:"budget_alerting"`
+	// PreventFurtherUsage is omitted from responses when unset, as for
+	// budgets older than the setting.
+	PreventFurtherUsage *bool `json:"prevent_further_usage,omitempty"`
+}
+
+// Alerting is a budget's budget_alerting object.
+type Alerting struct {
+	WillAlert  bool     `json:"will_alert"`
+	Recipients []string `json:"alert_recipients"`
+	// Threshold is omitted from responses when unset.
+	Threshold *int `json:"alert_threshold,omitempty"`
+}
+
+// Server is a fake GitHub API backed by httptest.Server.
+type Server struct {
+	*httptest.Server
+
+	Enterprise string
+
+	mu          sync.Mutex
+	nextID      int
+	costCenters []*CostCenter
+	seats       []Seat
+	orgTeams    map[string][]*Team // org -> teams
+	entTeams    []*Team
+	extGroups   map[string][]*ExternalGroup // org -> external groups
+	orgMembers  map[string][]string         // org -> member logins
+	orgRepos    map[string][]Repo           // org -> repositories
+	identities  []Identity
+	idp         string // "saml" (default) or "oidc"
+	budgets     []Budget
+	premium     map[string]float64 // login -> premium requests, any month
+	requests    []string
+	scopes      *string // X-OAuth-Scopes value; nil omits the header
+
+	// noCostCenters makes every cost center endpoint answer 404, as for an
+	// enterprise without the cost centers API.
+	noCostCenters bool
+
+	// etags makes GET responses carry an ETag and honour If-None-Match.
+	etags bool
+}
+
+// NewServer starts a fake API for DefaultEnterprise and registers cleanup
+// with t.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{
+		Enterprise: DefaultEnterprise,
+		orgTeams:   make(map[string][]*Team),
+		extGroups:  make(map[string][]*ExternalGroup),
+		orgMembers: make(map[string][]string),
+		orgRepos:   make(map[string][]Repo),
+		premium:    make(map[string]float64),
+		idp:        "saml",
+	}
+	s.Server = httptest.NewServer(s.routes())
+	t.Cleanup(s.Close)
+	return s
+}
+
+// --------------------------------------------------------------------
+// Fixture setup
+// --------------------------------------------------------------------
+
+// AddCostCenter registers an active cost center and returns its ID.
+func (s *Server) AddCostCenter(name string, users ...string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addCostCenterLocked(name, "active", users).ID
+}
+
+// SetOAuthScopes makes every response carry an X-OAuth-Scopes header, as the
+// real API does for classic personal access tokens.
+func (s *Server) SetOAuthScopes(scopes ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v := strings.Join(scopes, ", ")
+	s.scopes = &v
+}
+
+// DisableCostCenters makes every cost center endpoint answer 404, like an
+// enterprise that is not on the enhanced billing platform.
+func (s *Server) DisableCostCenters() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.noCostCenters = true
+}
+
+// EnableETags makes GET responses carry an ETag derived from the body and
+// answers requests whose If-None-Match matches it with 304 Not Modified, as
+// the real API does.
+func (s *Server) EnableETags() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.etags = true
+}
+
+// AddDeletedCostCenter registers a cost center in the "deleted" state.
+func (s *Server) AddDeletedCostCenter(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addCostCenterLocked(name, "deleted", nil).ID
+}
+
+// RemoveCostCenter drops a cost center entirely, as if it had been purged
+// outside the tool; later requests for its ID answer 404.
+func (s *Server) RemoveCostCenter(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.costCenters = slices.DeleteFunc(s.costCenters, func(cc *CostCenter) bool { return cc.ID == id })
+}
+
+// RenameCostCenter renames a cost center, as if it had been renamed outside
+// the tool.
+func (s *Server) RenameCostCenter(id, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cc := s.findLocked(id); cc != nil {
+		cc.Name = name
+	}
+}
+
+// AddSeats registers Copilot seats for the given logins.
+func (s *Server) AddSeats(logins ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, l := range logins {
+		s.seats = append(s.seats, Seat{Login: l, CreatedAt: "2025-01-01T00:00:00Z"})
+	}
+}
+
+// AddSeat registers a fully specified Copilot seat.
+func (s *Server) AddSeat(seat Seat) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seats = append(s.seats, seat)
+}
+
+// AddOrgTeam registers an organization team.
+func (s *Server) AddOrgTeam(org string, team Team) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := team
+	s.orgTeams[org] = append(s.orgTeams[org], &t)
+}
+
+// AddEnterpriseTeam registers an enterprise team.
+func (s *Server) AddEnterpriseTeam(team Team) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := team
+	s.entTeams = append(s.entTeams, &t)
+}
+
+// SetOrgMembers replaces the members of an organization.
+func (s *Server) SetOrgMembers(org string, logins ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orgMembers[org] = append([]string(nil), logins...)
+}
+
+// AddExternalGroup registers an identity provider group of an
+// organization.
+func (s *Server) AddExternalGroup(org string, group ExternalGroup) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g := group
+	s.extGroups[org] = append(s.extGroups[org], &g)
+}
+
+// AddRepo registers a repository of an organization.
+func (s *Server) AddRepo(org string, repo Repo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orgRepos[org] = append(s.orgRepos[org], repo)
+}
+
+// AddIdentity registers an external identity of the enterprise.
+func (s *Server) AddIdentity(id Identity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.identities = append(s.identities, id)
+}
+
+// SetIdentityProvider makes the enterprise's identity provider "saml"
+// (the default) or "oidc", which serves no SAML identities.
+func (s *Server) SetIdentityProvider(kind string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.idp = kind
+}
+
+// SetOrgTeamMembers replaces the members of an organization team.
+func (s *Server) SetOrgTeamMembers(org, slug string, members ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t := findTeam(s.orgTeams[org], slug); t != nil {
+		t.Members = members
+	}
+}
+
+// RemoveOrgTeam deletes an organization team.
+func (s *Server) RemoveOrgTeam(org, slug string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orgTeams[org] = slices.DeleteFunc(s.orgTeams[org], func(t *Team) bool { return t.Slug == slug })
+}
+
+// RenameOrgTeam renames an organization team, which changes its slug like
+// on GitHub; its ID stays.
+func (s *Server) RenameOrgTeam(org, slug, name, newSlug string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t := findTeam(s.orgTeams[org], slug); t != nil {
+		t.Name = name
+		t.Slug = newSlug
+	}
+}
+
+// --------------------------------------------------------------------
+// State inspection
+// --------------------------------------------------------------------
+
+// CostCenterByName returns a copy of the cost center with the given name.
+func (s *Server) CostCenterByName(name string) (CostCenter, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, cc := range s.costCenters {
+		if cc.Name == name && cc.State == "active" {
+			return copyCostCenter(cc), true
+		}
+	}
+	return CostCenter{}, false
+}
+
+// CostCenters returns copies of every cost center, in creation order.
+func (s *Server) CostCenters() []CostCenter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]CostCenter, 0, len(s.costCenters))
+	for _, cc := range s.costCenters {
+		out = append(out, copyCostCenter(cc))
+	}
+	return out
+}
+
+// AddBudget registers an existing budget and returns its ID.
+func (s *Server) AddBudget(b Budget) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addBudgetLocked(b).ID
+}
+
+// Budgets returns every budget created through the API.
+func (s *Server) Budgets() []Budget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Budget(nil), s.budgets...)
+}
+
+// SetPremiumRequests sets the premium requests a user made; the usage
+// report returns them for any month.
+func (s *Server) SetPremiumRequests(login string, n float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.premium[login] = n
+}
+
+// Requests returns the "METHOD /path" log of every request received.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// --------------------------------------------------------------------
+// Routing
+// --------------------------------------------------------------------
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	cc := "/enterprises/{ent}/settings/billing/cost-centers"
+
+	mux.HandleFunc("GET /rate_limit", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"resources": map[string]any{}})
+	})
+	mux.HandleFunc("GET "+cc, s.listCostCenters)
+	mux.HandleFunc("POST "+cc, s.createCostCenter)
+	mux.HandleFunc("GET "+cc+"/memberships", s.memberships)
+	mux.HandleFunc("GET "+cc+"/{id}", s.getCostCenter)
+	mux.HandleFunc("PATCH "+cc+"/{id}", s.updateCostCenter)
+	mux.HandleFunc("DELETE "+cc+"/{id}", s.deleteCostCenter)
+	mux.HandleFunc("POST "+cc+"/{id}/resource", s.addResources)
+	mux.HandleFunc("DELETE "+cc+"/{id}/resource", s.removeResources)
+	mux.HandleFunc("GET /enterprises/{ent}/settings/billing/budgets", s.listBudgets)
+	mux.HandleFunc("POST /enterprises/{ent}/settings/billing/budgets", s.createBudget)
+	mux.HandleFunc("PATCH /enterprises/{ent}/settings/billing/budgets/{id}", s.updateBudget)
+	mux.HandleFunc("GET /enterprises/{ent}/settings/billing/premium_request/usage", s.premiumRequestUsage)
+	mux.HandleFunc("GET /enterprises/{ent}/copilot/billing/seats", s.listSeats)
+	mux.HandleFunc("GET /enterprises/{ent}/teams", s.listEnterpriseTeams)
+	mux.HandleFunc("GET /enterprises/{ent}/teams/{slug}/memberships", s.listEnterpriseTeamMembers)
+	mux.HandleFunc("GET /orgs/{org}/teams", s.listOrgTeams)
+	mux.HandleFunc("GET /orgs/{org}/teams/{slug}/members", s.listOrgTeamMembers)
+	mux.HandleFunc("GET /orgs/{org}/members", s.listOrgMembers)
+	mux.HandleFunc("GET /orgs/{org}/repos", s.listOrgRepos)
+	mux.HandleFunc("GET /orgs/{org}/external-groups", s.listExternalGroups)
+	mux.HandleFunc("GET /orgs/{org}/external-group/{id}", s.getExternalGroup)
+	mux.HandleFunc("POST /graphql", s.graphql)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		if s.scopes != nil {
+			w.Header().Set("X-OAuth-Scopes", *s.scopes)
+		}
+		disabled := s.noCostCenters && strings.Contains(r.URL.Path, "/settings/billing/cost-centers")
+		etags := s.etags && r.Method == http.MethodGet
+		s.mu.Unlock()
+		if disabled {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		if ent := r.PathValue("ent"); ent != "" && ent != s.Enterprise {
+			http.NotFound(w, r)
+			return
+		}
+		if !etags {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, r)
+		maps.Copy(w.Header(), rec.Header())
+		if rec.Code == http.StatusOK {
+			etag := fmt.Sprintf(`"%x"`, sha256.Sum256(rec.Body.Bytes()))
+			w.Header().Set("ETag", etag)
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.WriteHeader(rec.Code)
+		_, _ = w.Write(rec.Body.Bytes())
+	})
+}
+
+// --------------------------------------------------------------------
+// Handlers
+// --------------------------------------------------------------------
+
+func (s *Server) listCostCenters(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	page, perPage := pagination(r)
+	start, end := pageBounds(len(s.costCenters), page, perPage)
+	list := make([]map[string]any, 0, end-start)
+	for _, cc := range s.costCenters[start:end] {
+		list = append(list, map[string]any{"id": cc.ID, "name": cc.Name, "state": cc.State})
+	}
+	setNextLink(w, r, s.URL, page, end < len(s.costCenters))
+	writeJSON(w, http.StatusOK, map[string]any{"costCenters": list})
+}
+
+func (s *Server) createCostCenter(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "name is required"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, cc := range s.costCenters {
+		if cc.Name == body.Name {
+			writeJSON(w, http.StatusConflict, map[string]string{
+				"message": fmt.Sprintf("A cost center with this name already exists. Existing cost center UUID: %s", cc.ID),
+			})
+			return
+		}
+	}
+	created := s.addCostCenterLocked(body.Name, "active", nil)
+	writeJSON(w, http.StatusCreated, map[string]string{"id": created.ID, "name": created.Name})
+}
+
+func (s *Server) premiumRequestUsage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := []map[string]any{}
+	if n, ok := s.premium[r.URL.Query().Get("user")]; ok {
+		items = append(items, map[string]any{
+			"product": "Copilot", "sku": "Copilot Premium Request", "model": "GPT-4.1",
+			"unitType": "requests", "grossQuantity": n, "netQuantity": n,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"usageItems": items})
+}
+
+func (s *Server) getCostCenter(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cc := s.findLocked(r.PathValue("id"))
+	if cc == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	resources := make([]map[string]string, 0, len(cc.Users)+len(cc.Repositories))
+	for _, u := range cc.Users {
+		resources = append(resources, map[string]string{"type": "User", "name": u})
+	}
+	for _, repo := range cc.Repositories {
+		resources = append(resources, map[string]string{"type": "Repository", "name": repo})
+	}
+	page, perPage := pagination(r)
+	start, end := pageBounds(len(resources), page, perPage)
+	setNextLink(w, r, s.URL, page, end < len(resources))
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id": cc.ID, "name": cc.Name, "state": cc.State, "resources": resources[start:end],
+	})
+}
+
+// updateCostCenter renames a cost center.  Another cost center with the
+// name is a conflict.
+func (s *Server) updateCostCenter(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "name is required"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cc := s.findLocked(r.PathValue("id"))
+	if cc == nil || cc.State == "deleted" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	for _, other := range s.costCenters {
+		if other != cc && other.Name == body.Name {
+			writeJSON(w, http.StatusConflict, map[string]string{
+				"message": fmt.Sprintf("A cost center with this name already exists. Existing cost center UUID: %s", other.ID),
+			})
+			return
+		}
+	}
+	cc.Name = body.Name
+	writeJSON(w, http.StatusOK, map[string]string{"id": cc.ID, "name": cc.Name})
+}
+
+// deleteCostCenter archives a cost center, like the API: it moves to the
+// "deleted" state.
+func (s *Server) deleteCostCenter(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cc := s.findLocked(r.PathValue("id"))
+	if cc == nil || cc.State == "deleted" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	cc.State = "deleted"
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Cost center deleted"})
+}
+
+// resourceBody is the payload accepted by the resource add/remove endpoints.
+type resourceBody struct {
+	Users        []string `json:"users"`
+	Repositories []string `json:"repositories"`
+}
+
+func (s *Server) addResources(w http.ResponseWriter, r *http.Request) {
+	var body resourceBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	target := s.findLocked(r.PathValue("id"))
+	if target == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	// A resource belongs to at most one cost center: adding moves it.
+	for _, cc := range s.costCenters {
+		cc.Users = without(cc.Users, body.Users)
+		cc.Repositories = without(cc.Repositories, body.Repositories)
+	}
+	target.Users = append(target.Users, body.Users...)
+	target.Repositories = append(target.Repositories, body.Repositories...)
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Resources successfully added to the cost center."})
+}
+
+func (s *Server) removeResources(w http.ResponseWriter, r *http.Request) {
+	var body resourceBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	target := s.findLocked(r.PathValue("id"))
+	if target == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	target.Users = without(target.Users, body.Users)
+	target.Repositories = without(target.Repositories, body.Repositories)
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Resources successfully removed from the cost center."})
+}
+
+func (s *Server) memberships(w http.ResponseWriter, r *http.Request) {
+	resourceType := r.URL.Query().Get("resource_type")
+	name := r.URL.Query().Get("name")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []map[string]any
+	for _, cc := range s.costCenters {
+		if cc.State != "active" {
+			continue
+		}
+		var pool []string
+		switch resourceType {
+		case "user":
+			pool = cc.Users
+		case "repo":
+			pool = cc.Repositories
+		}
+		for _, v := range pool {
+			if v == name {
+				out = append(out, map[string]any{
+					"cost_center": map[string]string{"id": cc.ID, "name": cc.Name},
+				})
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"memberships": out})
+}
+
+func (s *Server) listBudgets(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	page, perPage := pagination(r)
+	start, end := pageBounds(len(s.budgets), page, perPage)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"budgets":       s.budgets[start:end],
+		"has_next_page": end < len(s.budgets),
+		"total_count":   len(s.budgets),
+	})
+}
+
+func (s *Server) createBudget(w http.ResponseWriter, r *http.Request) {
+	var b Budget
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return
+	}
+	s.mu.Lock()
+	b = s.addBudgetLocked(b)
+	s.mu.Unlock()
+	writeJSON(w, http.StatusCreated, b)
+}
+
+func (s *Server) updateBudget(w http.ResponseWriter, r *http.Request) {
+	var patch struct {
+		Amount              *int      `json:"budget_amount"`
+		Alerting            *Alerting `json:"budget_alerting"`
+		PreventFurtherUsage *bool     `json:"prevent_further_usage"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.budgets {
+		if s.budgets[i].ID == r.PathValue("id") {
+			if patch.Amount != nil {
+				s.budgets[i].Amount = *patch.Amount
+			}
+			if patch.Alerting != nil {
+				// A patch without a threshold keeps the current one.
+				threshold := s.budgets[i].Alerting.Threshold
+				s.budgets[i].Alerting = *patch.Alerting
+				if s.budgets[i].Alerting.Threshold == nil {
+					s.budgets[i].Alerting.Threshold = threshold
+				}
+			}
+			if patch.PreventFurtherUsage != nil {
+				s.budgets[i].PreventFurtherUsage = patch.PreventFurtherUsage
+			}
+			writeJSON(w, http.StatusOK, s.budgets[i])
+			return
+		}
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
+
+func (s *Server) listSeats(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	page, perPage := pagination(r)
+	start, end := pageBounds(len(s.seats), page, perPage)
+	seats := make([]map[string]any, 0, end-start)
+	for i, seat := range s.seats[start:end] {
+		entry := map[string]any{
+			"assignee": map[string]any{
+				"login": seat.Login,
+				"id":    start + i + 1,
+				"type":  "User",
+			},
+			"created_at":                seat.CreatedAt,
+			"pending_cancellation_date": nullable(seat.PendingCancellationDate),
+			"last_activity_at":          nullable(seat.LastActivityAt),
+			"organization":              nil,
+			"assigning_team":            nil,
+		}
+		if seat.Organization != "" {
+			entry["organization"] = map[string]any{"login": seat.Organization, "id": 1}
+		}
+		if seat.AssigningTeam != "" {
+			entry["assigning_team"] = map[string]any{"id": 1, "name": seat.AssigningTeam, "slug": seat.AssigningTeam}
+		}
+		seats = append(seats, entry)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"total_seats": len(s.seats), "seats": seats})
+}
+
+func (s *Server) listOrgTeams(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	teams, ok := s.orgTeams[r.PathValue("org")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	writePagedTeams(w, r, teams)
+}
+
+func (s *Server) listOrgTeamMembers(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := findTeam(s.orgTeams[r.PathValue("org")], r.PathValue("slug"))
+	if t == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	if r.URL.Query().Get("role") == "maintainer" {
+		writePagedMembers(w, r, t.Maintainers)
+		return
+	}
+	writePagedMembers(w, r, t.Members)
+}
+
+func (s *Server) listEnterpriseTeams(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writePagedTeams(w, r, s.entTeams)
+}
+
+func (s *Server) listEnterpriseTeamMembers(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := findTeam(s.entTeams, r.PathValue("slug"))
+	if t == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	writePagedMembers(w, r, t.Members)
+}
+
+func (s *Server) listOrgMembers(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	members, ok := s.orgMembers[r.PathValue("org")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	writePagedMembers(w, r, members)
+}
+
+func (s *Server) listOrgRepos(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	org := r.PathValue("org")
+	repos := s.orgRepos[org]
+	page, perPage := pagination(r)
+	start, end := pageBounds(len(repos), page, perPage)
+	list := make([]map[string]any, 0, end-start)
+	for _, repo := range repos[start:end] {
+		topics := repo.Topics
+		if topics == nil {
+			topics = []string{}
+		}
+		list = append(list, map[string]any{"name": repo.Name, "full_name": org + "/" + repo.Name, "topics": topics})
+	}
+	setNextLink(w, r, s.URL, page, end < len(repos))
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) listExternalGroups(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	groups := s.extGroups[r.PathValue("org")]
+	page, perPage := pagination(r)
+	start, end := pageBounds(len(groups), page, perPage)
+	list := make([]map[string]any, 0, end-start)
+	for _, g := range groups[start:end] {
+		list = append(list, map[string]any{"group_id": g.ID, "group_name": g.Name, "updated_at": "2024-01-01T00:00:00Z"})
+	}
+	setNextLink(w, r, s.URL, page, end < len(groups))
+	writeJSON(w, http.StatusOK, map[string]any{"groups": list})
+}
+
+func (s *Server) getExternalGroup(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, g := range s.extGroups[r.PathValue("org")] {
+		if strconv.FormatInt(g.ID, 10) != r.PathValue("id") {
+			continue
+		}
+		page, perPage := pagination(r)
+		start, end := pageBounds(len(g.Members), page, perPage)
+		members := make([]map[string]any, 0, end-start)
+		for i, l := range g.Members[start:end] {
+			members = append(members, map[string]any{"member_id": start + i + 1, "member_login": l})
+		}
+		setNextLink(w, r, s.URL, page, end < len(g.Members))
+		writeJSON(w, http.StatusOK, map[string]any{"group_id": g.ID, "group_name": g.Name, "members": members})
+		return
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
+
+// identityPageSize is how many identities the fake serves per GraphQL page,
+// small so tests exercise pagination.
+const identityPageSize = 2
+
+// graphql serves the enterprise external identities query, the only
+// GraphQL query the client sends.  The cursor is the index of the next
+// identity.
+func (s *Server) graphql(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !strings.Contains(req.Query, "externalIdentities") {
+		writeJSON(w, http.StatusOK, map[string]any{"errors": []map[string]string{{"message": "unsupported query"}}})
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slug, _ := req.Variables["slug"].(string); slug != s.Enterprise {
+		writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"enterprise": nil}})
+		return
+	}
+	oidc := strings.Contains(req.Query, "oidcProvider")
+	if oidc != (s.idp == "oidc") {
+		writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"enterprise": map[string]any{"ownerInfo": map[string]any{"provider": nil}}}})
+		return
+	}
+	start := 0
+	if cursor, ok := req.Variables["cursor"].(string); ok {
+		start, _ = strconv.Atoi(cursor)
+	}
+	end := min(start+identityPageSize, len(s.identities))
+	nodes := make([]map[string]any, 0, end-start)
+	for _, id := range s.identities[start:end] {
+		emails := make([]map[string]any, 0, len(id.Emails))
+		for i, e := range id.Emails {
+			emails = append(emails, map[string]any{"value": e, "primary": i == 0})
+		}
+		node := map[string]any{
+			"user":         map[string]any{"login": id.Login},
+			"scimIdentity": map[string]any{"username": id.SCIMUsername, "emails": emails},
+		}
+		if !oidc {
+			attrs := make([]map[string]any, 0, len(id.Attributes))
+			for _, name := range slices.Sorted(maps.Keys(id.Attributes)) {
+				attrs = append(attrs, map[string]any{"name": name, "value": id.Attributes[name]})
+			}
+			node["samlIdentity"] = map[string]any{"nameId": id.NameID, "emails": emails, "attributes": attrs}
+		}
+		nodes = append(nodes, node)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"enterprise": map[string]any{"ownerInfo": map[string]any{"provider": map[string]any{
+		"externalIdentities": map[string]any{
+			"pageInfo": map[string]any{"hasNextPage": end < len(s.identities), "endCursor": strconv.Itoa(end)},
+			"nodes":    nodes,
+		},
+	}}}}})
+}
+
+// --------------------------------------------------------------------
+// Helpers
+// --------------------------------------------------------------------
+
+// addCostCenterLocked appends a cost center with a deterministic UUID.
+// Callers must hold s.mu.
+func (s *Server) addCostCenterLocked(name, state string, users []string) *CostCenter {
+	s.nextID++
+	cc := &CostCenter{
+		ID:    fmt.Sprintf("00000000-0000-4000-8000-%012d", s.nextID),
+		Name:  name,
+		State: state,
+		Users: append([]string(nil), users...),
+	}
+	s.costCenters = append(s.costCenters, cc)
+	return cc
+}
+
+// addBudgetLocked stores b with a deterministic ID.  Callers must hold s.mu.
+func (s *Server) addBudgetLocked(b Budget) Budget {
+	s.nextID++
+	b.ID = fmt.Sprintf("budget-%d", s.nextID)
+	s.budgets = append(s.budgets, b)
+	return b
+}
+
+// findLocked returns the cost center with the given ID.  Callers must hold s.mu.
+func (s *Server) findLocked(id string) *CostCenter {
+	for _, cc := range s.costCenters {
+		if cc.ID == id {
+			return cc
+		}
+	}
+	return nil
+}
+
+func copyCostCenter(cc *CostCenter) CostCenter {
+	out := *cc
+	out.Users = append([]string(nil), cc.Users...)
+	out.Repositories = append([]string(nil), cc.Repositories...)
+	sort.Strings(out.Users)
+	sort.Strings(out.Repositories)
+	return out
+}
+
+func findTeam(teams []*Team, slug string) *Team {
+	for _, t := range teams {
+		if t.Slug == slug {
+			return t
+		}
+	}
+	return nil
+}
+
+func writePagedTeams(w http.ResponseWriter, r *http.Request, teams []*Team) {
+	page, perPage := pagination(r)
+	start, end := pageBounds(len(teams), page, perPage)
+	out := make([]map[string]any, 0, end-start)
+	for _, t := range teams[start:end] {
+		out = append(out, map[string]any{"id": t.ID, "name": t.Name, "slug": t.Slug, "description": t.Description})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func writePagedMembers(w http.ResponseWriter, r *http.Request, logins []string) {
+	page, perPage := pagination(r)
+	start, end := pageBounds(len(logins), page, perPage)
+	out := make([]map[string]any, 0, end-start)
+	for i, l := range logins[start:end] {
+		out = append(out, map[string]any{"login": l, "id": start + i + 1, "type": "User"})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// pagination parses page/per_page query parameters (defaults 1 and 30,
+// matching the real API).
+func pagination(r *http.Request) (page, perPage int) {
+	page, perPage = 1, 30
+	if v, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && v > 0 {
+		page = v
+	}
+	if v, err := strconv.Atoi(r.URL.Query().Get("per_page")); err == nil && v > 0 {
+		perPage = v
+	}
+	return page, perPage
+}
+
+// setNextLink sets a Link header pointing at the next page of r when more
+// is true.
+func setNextLink(w http.ResponseWriter, r *http.Request, baseURL string, page int, more bool) {
+	if !more {
+		return
+	}
+	next := *r.URL
+	q := next.Query()
+	q.Set("page", strconv.Itoa(page+1))
+	next.RawQuery = q.Encode()
+	w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="next"`, baseURL, next.RequestURI()))
+}
+
+func pageBounds(total, page, perPage int) (start, end int) {
+	start = (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+	end = start + perPage
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+// without returns items with every value in remove filtered out.
+func without(items, remove []string) []string {
+	if len(remove) == 0 {
+		return items
+	}
+	drop := make(map[string]bool, len(remove))
+	for _, r := range remove {
+		drop[strings.ToLower(r)] = true
+	}
+	kept := items[:0]
+	for _, it := range items {
+		if !drop[strings.ToLower(it)] {
+			kept = append(kept, it)
+		}
+	}
+	return kept
+}
+
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,245 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ExternalIdentity is an enterprise member's identity at the enterprise's
+// identity provider (SAML or, for Enterprise Managed Users, OIDC), as
+// linked to a GitHub login.
+type ExternalIdentity struct {
+	Login string
+	// NameID is the SAML NameID, often a UPN or a distinguished name; empty
+	// for OIDC.
+	NameID string
+	// SCIMUsername is the user name the identity provider provisioned.
+	SCIMUsername string
+	// Emails are the verified addresses from the SAML assertion and the
+	// SCIM identity, primary addresses first.
+	Emails []string
+	// Attributes are the SAML assertion's attributes (department, employee
+	// ID, ...) by name as sent, which is often a claim URI; an attribute
+	// sent several times has several values.
+	Attributes map[string][]string
+}
+
+// Values returns the identity's NameID, SCIM user name and emails, in that
+// order, skipping empty ones.
+func (e ExternalIdentity) Values() []string {
+	var vs []string
+	for _, v := range append([]string{e.NameID, e.SCIMUsername}, e.Emails...) {
+		if v != "" {
+			vs = append(vs, v)
+		}
+	}
+	return vs
+}
+
+// AttributeMap returns the identity's attributes keyed by lower-cased
+// name, for rules to look up.  A URI name is also reachable by its last
+// path segment, so "department" finds
+// "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/department".
+// "name_id", "scim_username" and "emails" hold the identity's other fields
+// unless an attribute already has that name.
+func (e ExternalIdentity) AttributeMap() map[string][]string {
+	m := make(map[string][]string, 2*len(e.Attributes)+3)
+	for name, values := range e.Attributes {
+		m[strings.ToLower(name)] = values
+	}
+	for name, values := range e.Attributes {
+		if i := strings.LastIndex(name, "/"); i >= 0 && i < len(name)-1 {
+			short := strings.ToLower(name[i+1:])
+			if _, taken := m[short]; !taken {
+				m[short] = values
+			}
+		}
+	}
+	for name, values := range map[string][]string{
+		"name_id":       {e.NameID},
+		"scim_username": {e.SCIMUsername},
+		"emails":        e.Emails,
+	} {
+		if _, taken := m[name]; !taken && len(values) > 0 && values[0] != "" {
+			m[name] = values
+		}
+	}
+	return m
+}
+
+// graphqlURL returns the GraphQL endpoint next to baseURL: /graphql on
+// github.com and GHE.com, /api/graphql on GitHub Enterprise Server.
+func (c *Client) graphqlURL() string {
+	return strings.TrimSuffix(c.baseURL, "/v3") + "/graphql"
+}
+
+// graphqlError is one entry of a GraphQL response's errors.
+type graphqlError struct {
+	Message string `json:"message"`
+}
+
+// graphQL runs a GraphQL query and decodes its data into dest.  Errors the
+// API returns alongside a 200 are reported as an error.
+func (c *Client) graphQL(ctx context.Context, query string, vars map[string]any, dest any) error {
+	var resp struct {
+		Data   any            `json:"data"`
+		Errors []graphqlError `json:"errors"`
+	}
+	resp.Data = dest
+	body := map[string]any{"query": query, "variables": vars}
+	if _, err := c.doJSON(ctx, http.MethodPost, c.graphqlURL(), body, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		msgs := make([]string, len(resp.Errors))
+		for i, e := range resp.Errors {
+			msgs[i] = e.Message
+		}
+		return fmt.Errorf("graphql: %s", strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// externalIdentitiesQuery lists the external identities of an enterprise's
+// identity provider; %s is samlIdentityProvider or oidcProvider, aliased
+// to provider so one struct decodes both.
+const externalIdentitiesQuery = `query($slug: String!, $cursor: String) {
+  enterprise(slug: $slug) {
+    ownerInfo {
+      provider: %s {
+        externalIdentities(first: 100, after: $cursor) {
+          pageInfo { hasNextPage endCursor }
+          nodes {
+            user { login }
+            samlIdentity { nameId emails { value primary } attributes { name value } }
+            scimIdentity { username emails { value primary } }
+          }
+        }
+      }
+    }
+  }
+}`
+
+type identityEmail struct {
+	Value   string `json:"value"`
+	Primary bool   `json:"primary"`
+}
+
+type externalIdentityNode struct {
+	User *struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	SAMLIdentity *struct {
+		NameID     string          `json:"nameId"`
+		Emails     []identityEmail `json:"emails"`
+		Attributes []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"attributes"`
+	} `json:"samlIdentity"`
+	SCIMIdentity *struct {
+		Username string          `json:"username"`
+		Emails   []identityEmail `json:"emails"`
+	} `json:"scimIdentity"`
+}
+
+// GetEnterpriseIdentities returns the external identities of the
+// enterprise's SAML identity provider, or of its OIDC provider when SAML
+// is not configured (Enterprise Managed Users on Entra ID).  Identities not
+// linked to a GitHub user are skipped.  Reading them requires an enterprise
+// owner token with admin:enterprise.
+func (c *Client) GetEnterpriseIdentities(ctx context.Context) ([]ExternalIdentity, error) {
+	ids, found, err := c.enterpriseIdentities(ctx, "samlIdentityProvider")
+	if err == nil && !found {
+		ids, found, err = c.enterpriseIdentities(ctx, "oidcProvider")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("enterprise %s has no SAML or OIDC identity provider", c.enterprise)
+	}
+	c.log.Info("Total external identities found", "count", len(ids))
+	return ids, nil
+}
+
+// enterpriseIdentities pages through the identities of one provider kind;
+// found is false when the enterprise has no such provider.
+func (c *Client) enterpriseIdentities(ctx context.Context, provider string) (ids []ExternalIdentity, found bool, err error) {
+	c.log.Info("Fetching enterprise external identities", "provider", provider)
+	query := fmt.Sprintf(externalIdentitiesQuery, provider)
+
+	var cursor any
+	for page := 1; ; page++ {
+		var data struct {
+			Enterprise *struct {
+				OwnerInfo *struct {
+					Provider *struct {
+						ExternalIdentities struct {
+							PageInfo struct {
+								HasNextPage bool   `json:"hasNextPage"`
+								EndCursor   string `json:"endCursor"`
+							} `json:"pageInfo"`
+							Nodes []externalIdentityNode `json:"nodes"`
+						} `json:"externalIdentities"`
+					} `json:"provider"`
+				} `json:"ownerInfo"`
+			} `json:"enterprise"`
+		}
+		vars := map[string]any{"slug": c.enterprise, "cursor": cursor}
+		if err := c.graphQL(ctx, query, vars, &data); err != nil {
+			return nil, false, fmt.Errorf("fetching external identities page %d: %w", page, err)
+		}
+		if data.Enterprise == nil || data.Enterprise.OwnerInfo == nil {
+			return nil, false, fmt.Errorf("enterprise %s not found or token is not an enterprise owner", c.enterprise)
+		}
+		p := data.Enterprise.OwnerInfo.Provider
+		if p == nil {
+			return nil, false, nil
+		}
+		for _, n := range p.ExternalIdentities.Nodes {
+			if n.User == nil || n.User.Login == "" {
+				continue
+			}
+			ids = append(ids, n.identity())
+		}
+		if !p.ExternalIdentities.PageInfo.HasNextPage {
+			return ids, true, nil
+		}
+		cursor = p.ExternalIdentities.PageInfo.EndCursor
+	}
+}
+
+// identity flattens a node into an ExternalIdentity.
+func (n externalIdentityNode) identity() ExternalIdentity {
+	id := ExternalIdentity{Login: n.User.Login}
+	var emails []identityEmail
+	if n.SAMLIdentity != nil {
+		id.NameID = n.SAMLIdentity.NameID
+		emails = append(emails, n.SAMLIdentity.Emails...)
+		for _, a := range n.SAMLIdentity.Attributes {
+			if id.Attributes == nil {
+				id.Attributes = make(map[string][]string)
+			}
+			id.Attributes[a.Name] = append(id.Attributes[a.Name], a.Value)
+		}
+	}
+	if n.SCIMIdentity != nil {
+		id.SCIMUsername = n.SCIMIdentity.Username
+		emails = append(emails, n.SCIMIdentity.Emails...)
+	}
+	seen := make(map[string]bool)
+	for _, primary := range []bool{true, false} {
+		for _, e := range emails {
+			key := strings.ToLower(e.Value)
+			if e.Primary != primary || e.Value == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			id.Emails = append(id.Emails, e.Value)
+		}
+	}
+	return id
+}
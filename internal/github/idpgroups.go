@@ -0,0 +1,67 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// ExternalGroup is an identity provider group (Entra ID, Okta, ...)
+// provisioned into an Enterprise Managed Users organization.
+type ExternalGroup struct {
+	ID        int64  `json:"group_id"`
+	Name      string `json:"group_name"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// ExternalGroupMember is a member of an external group.
+type ExternalGroupMember struct {
+	ID    int64  `json:"member_id"`
+	Login string `json:"member_login"`
+	Name  string `json:"member_name"`
+	Email string `json:"member_email"`
+}
+
+// GetOrgExternalGroups returns the identity provider groups available to
+// the given organization, following pagination.
+func (c *Client) GetOrgExternalGroups(ctx context.Context, org string) ([]ExternalGroup, error) {
+	c.log.Info("Fetching external groups for organization", "org", org)
+	pageURL := fmt.Sprintf("%s/orgs/%s/external-groups?per_page=100", c.baseURL, org)
+
+	var groups []ExternalGroup
+	for page := 1; pageURL != ""; page++ {
+		var resp struct {
+			Groups []ExternalGroup `json:"groups"`
+		}
+		httpResp, err := c.doJSON(ctx, http.MethodGet, pageURL, nil, &resp)
+		if err != nil {
+			return nil, fmt.Errorf("fetching external groups for org %s page %d: %w", org, page, err)
+		}
+		groups = append(groups, resp.Groups...)
+		pageURL = nextPageURL(httpResp)
+	}
+
+	c.log.Info("Total external groups found", "org", org, "count", len(groups))
+	return groups, nil
+}
+
+// GetExternalGroupMembers returns the members of an external group of the
+// given organization, following pagination.
+func (c *Client) GetExternalGroupMembers(ctx context.Context, org string, groupID int64) ([]ExternalGroupMember, error) {
+	c.log.Debug("Fetching members for external group", "org", org, "group_id", groupID)
+	pageURL := fmt.Sprintf("%s/orgs/%s/external-group/%d?per_page=100", c.baseURL, org, groupID)
+
+	var members []ExternalGroupMember
+	for page := 1; pageURL != ""; page++ {
+		var resp struct {
+			Members []ExternalGroupMember `json:"members"`
+		}
+		httpResp, err := c.doJSON(ctx, http.MethodGet, pageURL, nil, &resp)
+		if err != nil {
+			return nil, fmt.Errorf("fetching external group %d members for org %s page %d: %w", groupID, org, page, err)
+		}
+		members = append(members, resp.Members...)
+		pageURL = nextPageURL(httpResp)
+	}
+	return members, nil
+}
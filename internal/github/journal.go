@@ -0,0 +1,134 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/renan-alm/gh-cost-center/internal/audit"
+	"github.com/renan-alm/gh-cost-center/internal/journal"
+)
+
+// SetJournal attaches a retry journal.  Failed cost center writes, additions
+// and removals, are then recorded as transient or permanent, and
+// ReplayJournal re-attempts the transient ones.
+func (c *Client) SetJournal(j *journal.Journal) {
+	c.journal = j
+}
+
+// IsTransientError reports whether err is worth re-attempting later: a
+// retryable status (429, 5xx) that outlasted the retries, a network error,
+// or a write interrupted by cancellation.
+func IsTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return retryableStatusCodes[apiErr.StatusCode]
+	}
+	return isTransient(err)
+}
+
+// recordFailure journals a failed write, if a journal is attached.
+func (c *Client) recordFailure(op, costCenterID string, resources []string, err error, attempts int) {
+	if c.journal == nil {
+		return
+	}
+	e := journal.Entry{
+		Op:           op,
+		CostCenterID: costCenterID,
+		Resources:    resources,
+		Error:        err.Error(),
+		Attempts:     attempts,
+	}
+	if jerr := c.journal.Record(e, IsTransientError(err)); jerr != nil {
+		c.log.Warn("Could not write retry journal", "error", jerr)
+	}
+}
+
+// ReplayJournal re-attempts entries, transient failures recorded by
+// earlier runs in the attached journal.  The caller picks the entries,
+// once the run is confirmed and its own plan applied.  An entry leaves the
+// journal only once its write succeeds; writes that fail again stay with
+// their attempt count increased, or move to the permanent failures.  It
+// returns how many entries succeeded and how many failed.
+func (c *Client) ReplayJournal(ctx context.Context, entries []journal.Entry) (recovered, failed int, err error) {
+	if c.journal == nil {
+		return 0, 0, nil
+	}
+	if len(entries) > 0 {
+		c.log.Info("Re-attempting transient failures from previous run", "entries", len(entries))
+	}
+
+	for _, e := range entries {
+		var opErr error
+		switch e.Op {
+		case journal.OpAddUsers:
+			opErr = c.postResources(ctx, e.CostCenterID, "users", e.Resources)
+		case journal.OpAddRepositories:
+			opErr = c.postResources(ctx, e.CostCenterID, "repositories", e.Resources)
+		case journal.OpRemoveUsers:
+			opErr = c.deleteResources(ctx, e.CostCenterID, "users", e.Resources)
+		case journal.OpRemoveRepositories:
+			opErr = c.deleteResources(ctx, e.CostCenterID, "repositories", e.Resources)
+		default:
+			c.log.Warn("Skipping retry journal entry with unknown operation", "op", e.Op)
+			continue
+		}
+		if opErr != nil {
+			failed++
+			c.log.Error("Journaled write failed again",
+				"op", e.Op, "cost_center_id", e.CostCenterID, "attempts", e.Attempts+1, "error", opErr)
+			if jerr := c.journal.Retried(e, opErr.Error(), IsTransientError(opErr)); jerr != nil {
+				c.log.Warn("Could not write retry journal", "error", jerr)
+			}
+			continue
+		}
+		recovered++
+		if jerr := c.journal.Resolve(e); jerr != nil {
+			c.log.Warn("Could not write retry journal", "error", jerr)
+		}
+		switch e.Op {
+		case journal.OpAddUsers:
+			c.run.usersAdded(e.CostCenterID, e.Resources)
+			c.emitAudit(audit.Event{Action: audit.ActionUsersAdded, CostCenterID: e.CostCenterID, Resources: e.Resources}, nil)
+		case journal.OpAddRepositories:
+			c.run.reposAdded(e.Resources)
+			c.emitAudit(audit.Event{Action: audit.ActionReposAdded, CostCenterID: e.CostCenterID, Resources: e.Resources}, nil)
+		case journal.OpRemoveUsers:
+			c.run.usersRemoved(e.CostCenterID, e.Resources)
+			c.emitAudit(audit.Event{Action: audit.ActionUsersRemoved, CostCenterID: e.CostCenterID, Resources: e.Resources}, nil)
+		case journal.OpRemoveRepositories:
+			c.run.reposRemoved(e.Resources)
+			c.emitAudit(audit.Event{Action: audit.ActionReposRemoved, CostCenterID: e.CostCenterID, Resources: e.Resources}, nil)
+		}
+		c.log.Info("Journaled write succeeded",
+			"op", e.Op, "cost_center_id", e.CostCenterID, "resources", len(e.Resources))
+	}
+	return recovered, failed, nil
+}
+
+// postResources adds users or repositories (key "users" or "repositories")
+// to a cost center in one request.
+func (c *Client) postResources(ctx context.Context, costCenterID, key string, names []string) error {
+	defer c.acquireWrite()()
+	url := c.enterpriseURL(fmt.Sprintf("/settings/billing/cost-centers/%s/resource", costCenterID))
+	_, err := c.doJSON(ctx, http.MethodPost, url, map[string]any{key: names}, nil)
+	if IsCostCenterNotFound(err) {
+		c.costCenterGone(costCenterID)
+	}
+	return err
+}
+
+// deleteResources removes users or repositories (key "users" or
+// "repositories") from a cost center in one request.
+func (c *Client) deleteResources(ctx context.Context, costCenterID, key string, names []string) error {
+	url := c.enterpriseURL(fmt.Sprintf("/settings/billing/cost-centers/%s/resource", costCenterID))
+	_, err := c.doJSON(ctx, http.MethodDelete, url, map[string]any{key: names}, nil)
+	if IsCostCenterNotFound(err) {
+		c.costCenterGone(costCenterID)
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package github
+
+import (
+	"context"
+	"sync"
+)
+
+// parallel calls fn for every index in [0, n) on up to c.parallelism
+// goroutines and returns when all calls have finished.  With parallelism 1
+// (the default) the calls run in order on the calling goroutine.  Once ctx
+// is cancelled no further calls are started; calls already running are
+// waited for.
+func (c *Client) parallel(ctx context.Context, n int, fn func(i int)) {
+	workers := min(c.parallelism, n)
+	if workers <= 1 {
+		for i := range n {
+			if ctx.Err() != nil {
+				return
+			}
+			fn(i)
+		}
+		return
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+dispatch:
+	for i := range n {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(next)
+	wg.Wait()
+}
+
+// acquireWrite blocks until fewer than c.parallelism cost center writes
+// are in flight.  Cost centers and their batches are both fanned out, so
+// this keeps the total number of concurrent writes at the configured
+// parallelism.  The returned func releases the slot.
+func (c *Client) acquireWrite() func() {
+	if c.writeSlots == nil {
+		return func() {}
+	}
+	c.writeSlots <- struct{}{}
+	return func() { <-c.writeSlots }
+}
@@ -0,0 +1,393 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PermissionUsage summarises the calls made against one API area at one
+// access level during a run.
+type PermissionUsage struct {
+	Area   string // e.g. "Enterprise billing"
+	Access string // "read" or "write"
+	Calls  int
+
+	// ClassicScopes lists the classic PAT scopes accepted for the area, the
+	// narrowest first.
+	ClassicScopes []string
+
+	// FineGrained is the X-Accepted-GitHub-Permissions value reported by the
+	// API (e.g. "members=read"), when present.
+	FineGrained string
+}
+
+// PermissionReport compares the permissions a run exercised with the scopes
+// granted to the token.
+type PermissionReport struct {
+	Used []PermissionUsage
+
+	// GrantedScopes comes from the X-OAuth-Scopes header.  It is nil for
+	// fine-grained PATs and GitHub App tokens, which do not report grants.
+	GrantedScopes []string
+
+	// UnusedScopes are granted scopes that no exercised area needed.
+	UnusedScopes []string
+
+	// MinimalScopes is the narrowest set of classic scopes covering Used.
+	MinimalScopes []string
+}
+
+// endpointArea maps an API request to the area and accepted classic scopes.
+type endpointArea struct {
+	area    string
+	classic []string
+}
+
+// API areas the client calls, with the classic scopes accepted for each.
+var (
+	areaBilling         = endpointArea{"Enterprise billing", []string{"manage_billing:enterprise", "admin:enterprise"}}
+	areaCopilotSeats    = endpointArea{"Copilot seats", []string{"manage_billing:copilot", "read:enterprise", "admin:enterprise"}}
+	areaEnterpriseTeams = endpointArea{"Enterprise teams", []string{"read:enterprise", "admin:enterprise"}}
+	areaIdentities      = endpointArea{"Enterprise external identities", []string{"admin:enterprise"}}
+	areaOrgMembers      = endpointArea{"Organization members", []string{"read:org", "write:org", "admin:org"}}
+	areaOrgPropsRead    = endpointArea{"Organization custom properties", []string{"read:org", "write:org", "admin:org"}}
+	areaOrgPropsWrite   = endpointArea{"Organization custom properties", []string{"admin:org"}}
+	areaRepoProps       = endpointArea{"Repository custom properties", []string{"repo"}}
+	areaOrgRepos        = endpointArea{"Organization repositories", []string{"repo"}}
+)
+
+// classifyEndpoint returns the permission area for a request path, or
+// ok=false for endpoints that need no permission (e.g. /rate_limit).
+func classifyEndpoint(method, path string) (endpointArea, bool) {
+	write := method != http.MethodGet && method != http.MethodHead
+	switch {
+	case path == "/rate_limit":
+		return endpointArea{}, false
+	case strings.Contains(path, "/settings/billing/"):
+		return areaBilling, true
+	case strings.HasSuffix(path, "/graphql"):
+		// The only GraphQL query the client sends reads identities.
+		return areaIdentities, true
+	case strings.HasSuffix(path, "/copilot/billing/seats"):
+		return areaCopilotSeats, true
+	case strings.HasPrefix(path, "/enterprises/") && strings.Contains(path, "/teams"):
+		return areaEnterpriseTeams, true
+	case strings.HasPrefix(path, "/orgs/") && (strings.Contains(path, "/teams") || strings.HasSuffix(path, "/members") || strings.Contains(path, "/external-group")):
+		return areaOrgMembers, true
+	case strings.HasPrefix(path, "/orgs/") && strings.HasSuffix(path, "/repos"):
+		return areaOrgRepos, true
+	case strings.HasPrefix(path, "/orgs/") && strings.Contains(path, "/properties/"):
+		if write {
+			return areaOrgPropsWrite, true
+		}
+		return areaOrgPropsRead, true
+	case strings.HasPrefix(path, "/repos/"):
+		return areaRepoProps, true
+	}
+	return endpointArea{"Other", nil}, true
+}
+
+// PermissionRequirement is an API area, at one access level, that a command
+// is going to call.
+type PermissionRequirement struct {
+	Area          string
+	Access        string   // "read" or "write"
+	ClassicScopes []string // any one of these satisfies the requirement
+}
+
+func requirement(a endpointArea, access string) PermissionRequirement {
+	return PermissionRequirement{Area: a.area, Access: access, ClassicScopes: a.classic}
+}
+
+// RequiresBilling reports whether reqs include the enterprise billing
+// (cost centers) API.
+func RequiresBilling(reqs []PermissionRequirement) bool {
+	return slices.ContainsFunc(reqs, func(r PermissionRequirement) bool { return r.Area == areaBilling.area })
+}
+
+// ModeSettings are the configuration settings that decide which API areas
+// a mode reads.
+type ModeSettings struct {
+	TeamsScope string // cost_center.teams.scope
+
+	// The memberships rules mode conditions read.
+	RulesOrgs, RulesTeams, RulesAttributes bool
+}
+
+// RequiredPermissions returns the API areas a command will call for the
+// given cost center mode.  command is "assign", "diff", "report",
+// "orphans" (report --orphans, Copilot seats and billing in every mode),
+// "allocation", "budgets", "cleanup", "list", "lookup", "members",
+// "move", "remove", "rename", "rollback", "snapshot", "stats" or
+// "transfer-alerts" (the last thirteen only touch billing, whatever the
+// mode); settings decide what the mode reads, and apply adds the billing
+// writes of an apply run.
+func RequiredPermissions(command, mode string, settings ModeSettings, apply bool) []PermissionRequirement {
+	switch command {
+	case "stats", "snapshot", "allocation", "list", "lookup", "members":
+		return []PermissionRequirement{requirement(areaBilling, "read")}
+	case "budgets", "cleanup", "move", "remove", "rename", "rollback", "transfer-alerts":
+		reqs := []PermissionRequirement{requirement(areaBilling, "read")}
+		if apply {
+			reqs = append(reqs, requirement(areaBilling, "write"))
+		}
+		return reqs
+	case "orphans":
+		return []PermissionRequirement{requirement(areaCopilotSeats, "read"), requirement(areaBilling, "read")}
+	}
+	var reqs []PermissionRequirement
+	switch mode {
+	case "teams":
+		reqs = append(reqs, teamsRequirement(settings.TeamsScope))
+	case "rules":
+		reqs = append(reqs, requirement(areaCopilotSeats, "read"))
+		if settings.RulesOrgs {
+			reqs = append(reqs, requirement(areaOrgMembers, "read"))
+		}
+		if settings.RulesTeams {
+			reqs = append(reqs, teamsRequirement(settings.TeamsScope))
+		}
+		if settings.RulesAttributes {
+			reqs = append(reqs, requirement(areaIdentities, "read"))
+		}
+	case "repos", "custom-prop":
+		reqs = append(reqs, requirement(areaOrgPropsRead, "read"))
+	case "idp-groups", "orgs":
+		reqs = append(reqs, requirement(areaOrgMembers, "read"))
+	case "email-domain":
+		reqs = append(reqs, requirement(areaCopilotSeats, "read"), requirement(areaIdentities, "read"))
+	default: // users
+		reqs = append(reqs, requirement(areaCopilotSeats, "read"))
+	}
+	if command == "report" {
+		return reqs
+	}
+	reqs = append(reqs, requirement(areaBilling, "read"))
+	if apply {
+		reqs = append(reqs, requirement(areaBilling, "write"))
+	}
+	return reqs
+}
+
+// teamsRequirement returns the area team members are read from in scope.
+// "auto" scope probes enterprise teams but falls back to organization
+// teams, so only the fallback's access is required.
+func teamsRequirement(scope string) PermissionRequirement {
+	if scope == "enterprise" {
+		return requirement(areaEnterpriseTeams, "read")
+	}
+	return requirement(areaOrgMembers, "read")
+}
+
+// MissingPermissionsError lists the requirements the token's classic
+// scopes do not cover.
+type MissingPermissionsError struct {
+	Granted []string
+	Missing []PermissionRequirement
+}
+
+func (e *MissingPermissionsError) Error() string {
+	parts := make([]string, 0, len(e.Missing))
+	for _, m := range e.Missing {
+		parts = append(parts, fmt.Sprintf("%s %s (needs one of: %s)", m.Area, m.Access, strings.Join(m.ClassicScopes, ", ")))
+	}
+	granted := strings.Join(e.Granted, ", ")
+	if granted == "" {
+		granted = "none"
+	}
+	return fmt.Sprintf("token is missing permissions: %s; granted scopes: %s", strings.Join(parts, "; "), granted)
+}
+
+// CheckPermissions verifies the token and, for classic tokens, that its
+// granted scopes cover every requirement, so a run fails before it starts
+// rather than part-way through.  Fine-grained PATs and GitHub App tokens do
+// not report their grants; for those only the token itself is verified.
+func (c *Client) CheckPermissions(ctx context.Context, reqs []PermissionRequirement) error {
+	if err := c.VerifyToken(ctx); err != nil {
+		return err
+	}
+
+	c.perms.mu.Lock()
+	seen, granted := c.perms.seen, append([]string(nil), c.perms.granted...)
+	c.perms.mu.Unlock()
+	if !seen {
+		c.log.Debug("Token does not report its scopes, skipping permission pre-check")
+		return nil
+	}
+
+	var missing []PermissionRequirement
+	for _, r := range reqs {
+		ok := false
+		for _, s := range r.ClassicScopes {
+			if slices.Contains(granted, s) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			missing = append(missing, r)
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingPermissionsError{Granted: granted, Missing: missing}
+	}
+	c.log.Debug("Token scopes cover the required permissions", "requirements", len(reqs))
+	return nil
+}
+
+// permissionRecorder accumulates PermissionUsage from every response.
+type permissionRecorder struct {
+	mu      sync.Mutex
+	used    map[string]*PermissionUsage // area+"|"+access -> usage
+	granted []string
+	seen    bool // X-OAuth-Scopes observed
+}
+
+func newPermissionRecorder() *permissionRecorder {
+	return &permissionRecorder{used: make(map[string]*PermissionUsage)}
+}
+
+// record notes one request and its response headers.  A nil recorder is a
+// no-op.
+func (r *permissionRecorder) record(method, path string, resp *http.Response) {
+	if r == nil {
+		return
+	}
+	area, ok := classifyEndpoint(method, path)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if v, present := resp.Header["X-Oauth-Scopes"]; present && !r.seen {
+		r.seen = true
+		r.granted = parseScopes(strings.Join(v, ","))
+	}
+	if !ok {
+		return
+	}
+
+	access := "read"
+	if method != http.MethodGet && method != http.MethodHead && area.area != areaIdentities.area {
+		access = "write"
+	}
+	key := area.area + "|" + access
+	u, exists := r.used[key]
+	if !exists {
+		u = &PermissionUsage{Area: area.area, Access: access, ClassicScopes: area.classic}
+		r.used[key] = u
+	}
+	u.Calls++
+	if fg := resp.Header.Get("X-Accepted-GitHub-Permissions"); fg != "" {
+		u.FineGrained = fg
+	}
+}
+
+// report builds the PermissionReport from the recorded data.
+func (r *permissionRecorder) report() *PermissionReport {
+	if r == nil {
+		return &PermissionReport{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rep := &PermissionReport{}
+	for _, u := range r.used {
+		rep.Used = append(rep.Used, *u)
+	}
+	sort.Slice(rep.Used, func(i, j int) bool {
+		if rep.Used[i].Area != rep.Used[j].Area {
+			return rep.Used[i].Area < rep.Used[j].Area
+		}
+		return rep.Used[i].Access < rep.Used[j].Access
+	})
+
+	minimal := make(map[string]bool)
+	needed := make(map[string]bool)
+	for _, u := range rep.Used {
+		if len(u.ClassicScopes) == 0 {
+			continue
+		}
+		minimal[u.ClassicScopes[0]] = true
+		// The narrowest granted scope satisfying the area counts as needed;
+		// broader duplicates show up as unused.
+		for _, s := range u.ClassicScopes {
+			if slices.Contains(r.granted, s) {
+				needed[s] = true
+				break
+			}
+		}
+	}
+	for s := range minimal {
+		rep.MinimalScopes = append(rep.MinimalScopes, s)
+	}
+	sort.Strings(rep.MinimalScopes)
+
+	if r.seen {
+		rep.GrantedScopes = append([]string{}, r.granted...)
+		for _, s := range r.granted {
+			if !needed[s] {
+				rep.UnusedScopes = append(rep.UnusedScopes, s)
+			}
+		}
+	}
+	return rep
+}
+
+// parseScopes splits an X-OAuth-Scopes header value.
+func parseScopes(v string) []string {
+	var scopes []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// PermissionReport returns the permissions exercised by this client so far,
+// compared with the scopes granted to its token.
+func (c *Client) PermissionReport() *PermissionReport {
+	return c.perms.report()
+}
+
+// Print displays the report to stdout.
+func (r *PermissionReport) Print() {
+	fmt.Println("\n=== Token Permission Report ===")
+	if len(r.Used) == 0 {
+		fmt.Println("No permission-bearing API calls were made.")
+		return
+	}
+
+	fmt.Println("Exercised:")
+	for _, u := range r.Used {
+		line := fmt.Sprintf("  %-32s %-5s %5d call(s)", u.Area, u.Access, u.Calls)
+		if u.FineGrained != "" {
+			line += "  fine-grained: " + u.FineGrained
+		}
+		if len(u.ClassicScopes) > 0 {
+			line += "  classic: " + strings.Join(u.ClassicScopes, " | ")
+		}
+		fmt.Println(line)
+	}
+
+	if r.GrantedScopes == nil {
+		fmt.Println("\nGranted: not reported by the API (fine-grained PAT or GitHub App token).")
+		fmt.Println("Grant only the fine-grained permissions listed above.")
+	} else {
+		fmt.Printf("\nGranted classic scopes: %s\n", strings.Join(r.GrantedScopes, ", "))
+		if len(r.UnusedScopes) > 0 {
+			fmt.Printf("Unused (candidates to remove): %s\n", strings.Join(r.UnusedScopes, ", "))
+		} else {
+			fmt.Println("Unused: none")
+		}
+	}
+	if len(r.MinimalScopes) > 0 {
+		fmt.Printf("Minimal classic scopes for this run: %s\n", strings.Join(r.MinimalScopes, ", "))
+	}
+}
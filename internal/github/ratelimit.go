@@ -0,0 +1,83 @@
+package github
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateGate tracks the primary rate limit reported on API responses.  Once
+// X-RateLimit-Remaining drops to reserve, every request made through the
+// client waits for the reset, so parallel apply workers back off together
+// instead of each running into 429s.
+type rateGate struct {
+	reserve int
+
+	mu        sync.Mutex
+	known     bool
+	remaining int
+	reset     time.Time
+}
+
+func newRateGate(reserve int) *rateGate {
+	return &rateGate{reserve: reserve}
+}
+
+// observe records the rate-limit headers of resp.  Responses from
+// concurrent requests arrive out of order, so within one window the lowest
+// remaining count wins.
+func (g *rateGate) observe(resp *http.Response) {
+	if g == nil || resp == nil {
+		return
+	}
+	if res := resp.Header.Get("X-RateLimit-Resource"); res != "" && res != "core" {
+		return // search, graphql, ... have their own budgets
+	}
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	resetUnix, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+	reset := time.Unix(resetUnix, 0)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.known && reset.Equal(g.reset) && remaining > g.remaining {
+		return
+	}
+	g.known, g.remaining, g.reset = true, remaining, reset
+}
+
+// exhaust marks the budget as spent until until, after a 429.
+func (g *rateGate) exhaust(until time.Time) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.known, g.remaining, g.reset = true, 0, until
+}
+
+// pause returns how long a request should wait before being sent: zero
+// while the remaining budget is above the reserve, otherwise the time until
+// the window resets (plus a one second margin).
+func (g *rateGate) pause() time.Duration {
+	if g == nil {
+		return 0
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.known || g.remaining > g.reserve {
+		return 0
+	}
+	wait := time.Until(g.reset)
+	if wait <= 0 {
+		g.known = false // window has reset; the next response tells us more
+		return 0
+	}
+	return wait + time.Second
+}
@@ -1,6 +1,8 @@
 package github
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 )
@@ -11,6 +13,9 @@ type RepoProperties struct {
 	RepositoryName     string     `json:"repository_name"`
 	RepositoryFullName string     `json:"repository_full_name"`
 	Properties         []Property `json:"properties"`
+	// Topics are the repository's topics, filled in from GetOrgRepoTopics
+	// by callers that match on them.
+	Topics []string `json:"-"`
 }
 
 // Property is a single custom property name-value pair.
@@ -31,12 +36,12 @@ type PropertyDefinition struct {
 
 // GetOrgPropertySchema returns all custom property definitions for the given
 // organization.
-func (c *Client) GetOrgPropertySchema(org string) ([]PropertyDefinition, error) {
+func (c *Client) GetOrgPropertySchema(ctx context.Context, org string) ([]PropertyDefinition, error) {
 	c.log.Info("Fetching custom property schema", "org", org)
 	url := fmt.Sprintf("%s/orgs/%s/properties/schema", c.baseURL, org)
 
 	var defs []PropertyDefinition
-	if _, err := c.doJSON(http.MethodGet, url, nil, &defs); err != nil {
+	if _, err := c.doJSON(ctx, http.MethodGet, url, nil, &defs); err != nil {
 		return nil, fmt.Errorf("fetching property schema for org %s: %w", org, err)
 	}
 	c.log.Info("Custom properties defined", "org", org, "count", len(defs))
@@ -46,11 +51,27 @@ func (c *Client) GetOrgPropertySchema(org string) ([]PropertyDefinition, error)
 // GetOrgReposWithProperties returns all repositories with their custom
 // property values for the given organization, handling pagination.  An optional
 // query string (GitHub search syntax) narrows the results.
-func (c *Client) GetOrgReposWithProperties(org string, query string) ([]RepoProperties, error) {
+func (c *Client) GetOrgReposWithProperties(ctx context.Context, org string, query string) ([]RepoProperties, error) {
+	var allRepos []RepoProperties
+	err := c.EachOrgRepoWithProperties(ctx, org, query, func(r RepoProperties) error {
+		allRepos = append(allRepos, r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allRepos, nil
+}
+
+// EachOrgRepoWithProperties calls fn for every repository with its custom
+// property values as the repositories are decoded, so memory stays flat in
+// organizations with many repositories.  An error from fn stops the
+// iteration and is returned.
+func (c *Client) EachOrgRepoWithProperties(ctx context.Context, org, query string, fn func(RepoProperties) error) error {
 	c.log.Info("Fetching repositories with custom properties", "org", org)
 	baseURL := fmt.Sprintf("%s/orgs/%s/properties/values", c.baseURL, org)
 
-	var allRepos []RepoProperties
+	total := 0
 	page := 1
 	const perPage = 100
 
@@ -60,32 +81,67 @@ func (c *Client) GetOrgReposWithProperties(org string, query string) ([]RepoProp
 			pageURL += "&repository_query=" + query
 		}
 
-		var repos []RepoProperties
-		if _, err := c.doJSON(http.MethodGet, pageURL, nil, &repos); err != nil {
-			return nil, fmt.Errorf("fetching repos with properties for org %s page %d: %w", org, page, err)
-		}
-		if len(repos) == 0 {
-			break
+		var count int
+		_, err := c.doStream(ctx, http.MethodGet, pageURL, nil, func(dec *json.Decoder) error {
+			var err error
+			count, err = streamArray(dec, func(dec *json.Decoder) error {
+				var r RepoProperties
+				if err := dec.Decode(&r); err != nil {
+					return err
+				}
+				return fn(r)
+			})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("fetching repos with properties for org %s page %d: %w", org, page, err)
 		}
-		allRepos = append(allRepos, repos...)
-		c.log.Debug("Fetched repos with properties page", "org", org, "page", page, "count", len(repos))
-		if len(repos) < perPage {
+		total += count
+		c.log.Debug("Fetched repos with properties page", "org", org, "page", page, "count", count)
+		if count < perPage {
 			break
 		}
 		page++
 	}
 
-	c.log.Info("Total repositories with custom properties", "org", org, "count", len(allRepos))
-	return allRepos, nil
+	c.log.Info("Total repositories with custom properties", "org", org, "count", total)
+	return nil
+}
+
+// GetOrgRepoTopics returns the topics of every repository of the given
+// organization, keyed by full name, following pagination.  Repositories
+// without topics are included with none.
+func (c *Client) GetOrgRepoTopics(ctx context.Context, org string) (map[string][]string, error) {
+	c.log.Info("Fetching repository topics", "org", org)
+	pageURL := fmt.Sprintf("%s/orgs/%s/repos?type=all&per_page=100", c.baseURL, org)
+
+	topics := make(map[string][]string)
+	for page := 1; pageURL != ""; page++ {
+		var repos []struct {
+			FullName string   `json:"full_name"`
+			Topics   []string `json:"topics"`
+		}
+		resp, err := c.doJSON(ctx, http.MethodGet, pageURL, nil, &repos)
+		if err != nil {
+			return nil, fmt.Errorf("fetching repositories for org %s page %d: %w", org, page, err)
+		}
+		for _, r := range repos {
+			topics[r.FullName] = r.Topics
+		}
+		pageURL = nextPageURL(resp)
+	}
+
+	c.log.Info("Total repositories with topics listed", "org", org, "count", len(topics))
+	return topics, nil
 }
 
 // GetRepoProperties returns custom property values for a specific repository.
-func (c *Client) GetRepoProperties(owner, repo string) ([]Property, error) {
+func (c *Client) GetRepoProperties(ctx context.Context, owner, repo string) ([]Property, error) {
 	c.log.Debug("Fetching custom properties for repository", "repo", owner+"/"+repo)
 	url := fmt.Sprintf("%s/repos/%s/%s/properties/values", c.baseURL, owner, repo)
 
 	var props []Property
-	if _, err := c.doJSON(http.MethodGet, url, nil, &props); err != nil {
+	if _, err := c.doJSON(ctx, http.MethodGet, url, nil, &props); err != nil {
 		return nil, fmt.Errorf("fetching properties for %s/%s: %w", owner, repo, err)
 	}
 	return props, nil
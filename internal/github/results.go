@@ -0,0 +1,137 @@
+package github
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// CostCenterResult is how the users of one cost center fared in an apply.
+// Name is empty when the cost center could not be named (e.g. it is no
+// longer active).
+type CostCenterResult struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name,omitempty"`
+	Succeeded      int      `json:"succeeded"`
+	Failed         int      `json:"failed"`
+	SucceededUsers []string `json:"succeeded_users,omitempty"`
+	FailedUsers    []string `json:"failed_users,omitempty"`
+
+	// Users is the outcome of each user: username -> success.
+	Users map[string]bool `json:"-"`
+}
+
+// Label is the name and ID of the cost center for display, or the ID alone
+// when it has no name.
+func (r CostCenterResult) Label() string {
+	if r.Name == "" || r.Name == r.ID {
+		return r.ID
+	}
+	return r.Name + " (" + r.ID + ")"
+}
+
+// ResultsByCostCenter summarises per-user results (cost center ID →
+// username → success) for each cost center, naming them from names (ID →
+// name).  Results are ordered by name, then ID.
+func ResultsByCostCenter(results map[string]map[string]bool, names map[string]string) []CostCenterResult {
+	out := make([]CostCenterResult, 0, len(results))
+	for id, users := range results {
+		out = append(out, tally(CostCenterResult{ID: id, Name: names[id]}, users))
+	}
+	sortResults(out)
+	return out
+}
+
+// MergeResults adds the user outcomes of more to results, merging entries
+// of the same cost center; a user's outcome in more replaces the earlier
+// one.  The merged results are ordered by name, then ID.
+func MergeResults(results, more []CostCenterResult) []CostCenterResult {
+	byID := make(map[string]int, len(results))
+	for i, r := range results {
+		byID[r.ID] = i
+	}
+	for _, r := range more {
+		i, ok := byID[r.ID]
+		if !ok {
+			byID[r.ID] = len(results)
+			results = append(results, tally(CostCenterResult{ID: r.ID, Name: r.Name}, r.Users))
+			continue
+		}
+		users := maps.Clone(results[i].Users)
+		if users == nil {
+			users = make(map[string]bool, len(r.Users))
+		}
+		maps.Copy(users, r.Users)
+		results[i] = tally(CostCenterResult{ID: r.ID, Name: cmp.Or(results[i].Name, r.Name)}, users)
+	}
+	sortResults(results)
+	return results
+}
+
+// tally fills in the user outcomes of r and their counts.
+func tally(r CostCenterResult, users map[string]bool) CostCenterResult {
+	r.Users = users
+	for user, ok := range users {
+		if ok {
+			r.Succeeded++
+			r.SucceededUsers = append(r.SucceededUsers, user)
+		} else {
+			r.Failed++
+			r.FailedUsers = append(r.FailedUsers, user)
+		}
+	}
+	sort.Strings(r.SucceededUsers)
+	sort.Strings(r.FailedUsers)
+	return r
+}
+
+func sortResults(results []CostCenterResult) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Name != results[j].Name {
+			return results[i].Name < results[j].Name
+		}
+		return results[i].ID < results[j].ID
+	})
+}
+
+// costCenterNames maps the IDs of the active cost centers to their names,
+// for logs and summaries.  With a RunCache attached the active list is
+// usually loaded already; otherwise it is fetched.  A failed lookup is
+// logged and yields an empty map, so results fall back to IDs.
+func (c *Client) costCenterNames(ctx context.Context) map[string]string {
+	active, err := c.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		c.log.Warn("Could not look up cost center names, reporting IDs only", "error", err)
+		return map[string]string{}
+	}
+	names := make(map[string]string, len(active))
+	for name, id := range active {
+		names[id] = name
+	}
+	return names
+}
+
+// applyResults accumulates the results of every
+// BulkUpdateCostCenterAssignments call of a client.
+type applyResults struct {
+	mu      sync.Mutex
+	results []CostCenterResult
+}
+
+func (a *applyResults) add(results []CostCenterResult) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.results = append(a.results, results...)
+}
+
+// ApplyResults returns the per-cost-center results of every
+// BulkUpdateCostCenterAssignments call so far, in call order.  Callers that
+// run several modes take the results each mode added.
+func (c *Client) ApplyResults() []CostCenterResult {
+	c.applied.mu.Lock()
+	defer c.applied.mu.Unlock()
+	return slices.Clone(c.applied.results)
+}
@@ -0,0 +1,42 @@
+package github
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// retryPolicy is github.retry.  Zero fields fall back to the defaults, so a
+// Client built without a config retries as it always has.
+type retryPolicy struct {
+	maxRetries int           // attempts per request, including the first
+	base       time.Duration // wait before the first retry
+	max        time.Duration // cap on any single wait
+	jitter     float64       // 0..1: each wait varies by up to ±jitter
+}
+
+// attempts returns how many times a request is tried.
+func (p retryPolicy) attempts() int {
+	if p.maxRetries <= 0 {
+		return maxRetries
+	}
+	return p.maxRetries
+}
+
+// backoff returns the wait before retry number attempt+1: base * 2^attempt,
+// capped at max, then spread by ±jitter so parallel workers that failed
+// together do not retry in lockstep.
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	base, ceiling := p.base, p.max
+	if base <= 0 {
+		base = retryBackoffBase
+	}
+	if ceiling <= 0 {
+		ceiling = retryBackoffMax
+	}
+	wait := time.Duration(math.Min(float64(base)*math.Pow(2, float64(attempt)), float64(ceiling)))
+	if p.jitter > 0 {
+		wait = time.Duration(float64(wait) * (1 + p.jitter*(2*rand.Float64()-1)))
+	}
+	return wait
+}
@@ -0,0 +1,311 @@
+package github
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+)
+
+// RunCache holds cost center reads shared by every manager that uses the
+// client during one run, so combined runs (assign --modes) do not repeat
+// identical requests.  Unlike the file-based cache.Cache it is never
+// persisted; writes made through the client keep it up to date.
+type RunCache struct {
+	mu sync.Mutex
+
+	// activeCCs maps name → ID for active cost centers; nil until loaded.
+	activeCCs map[string]string
+
+	// members maps cost center ID → assigned user logins.
+	members map[string][]string
+
+	// memberships maps resource type + "/" + name → cost center.  A nil
+	// value records that the resource is in no cost center.
+	memberships map[string]*CostCenterRef
+
+	// gone holds the IDs of cost centers found missing or deleted during
+	// the run; preload maps built before that must not reuse them.
+	gone map[string]bool
+
+	// budgets holds the enterprise budgets once budgetsLoaded is set.
+	budgets       []Budget
+	budgetsLoaded bool
+}
+
+// NewRunCache returns an empty RunCache.
+func NewRunCache() *RunCache {
+	return &RunCache{
+		members:     make(map[string][]string),
+		memberships: make(map[string]*CostCenterRef),
+		gone:        make(map[string]bool),
+	}
+}
+
+// SetRunCache attaches a per-run cache to the client.  Cost center lists,
+// members, membership lookups and budgets are then fetched at most once and
+// served from memory afterwards.
+func (c *Client) SetRunCache(rc *RunCache) {
+	c.run = rc
+}
+
+// active returns a copy of the active cost center map, or ok=false when it
+// has not been loaded.
+func (rc *RunCache) active() (map[string]string, bool) {
+	if rc == nil {
+		return nil, false
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.activeCCs == nil {
+		return nil, false
+	}
+	return maps.Clone(rc.activeCCs), true
+}
+
+// setActive stores the active cost center map.
+func (rc *RunCache) setActive(active map[string]string) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.activeCCs = maps.Clone(active)
+}
+
+// resetActive forgets the active cost center map.
+func (rc *RunCache) resetActive() {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.activeCCs = nil
+}
+
+// rememberActive records a created or resolved cost center, if the active
+// map has been loaded.
+func (rc *RunCache) rememberActive(name, id string) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.activeCCs != nil {
+		rc.activeCCs[name] = id
+	}
+}
+
+// renamed records the new name of a cost center in the active map and in
+// the cached memberships.
+func (rc *RunCache) renamed(id, name string) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.activeCCs != nil {
+		maps.DeleteFunc(rc.activeCCs, func(_, v string) bool { return v == id })
+		rc.activeCCs[name] = id
+	}
+	for key, ref := range rc.memberships {
+		if ref != nil && ref.ID == id {
+			rc.memberships[key] = &CostCenterRef{ID: id, Name: name}
+		}
+	}
+}
+
+// costCenterMembers returns a copy of the cached users of a cost center.
+func (rc *RunCache) costCenterMembers(id string) ([]string, bool) {
+	if rc == nil {
+		return nil, false
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	users, ok := rc.members[id]
+	return slices.Clone(users), ok
+}
+
+// setCostCenterMembers stores the users of a cost center.
+func (rc *RunCache) setCostCenterMembers(id string, users []string) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.members[id] = slices.Clone(users)
+}
+
+// membership returns the cached cost center of a resource.
+func (rc *RunCache) membership(resourceType, name string) (*CostCenterRef, bool) {
+	if rc == nil {
+		return nil, false
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	ref, ok := rc.memberships[resourceType+"/"+name]
+	return ref, ok
+}
+
+// setMembership stores the cost center of a resource (nil for none).
+func (rc *RunCache) setMembership(resourceType, name string, ref *CostCenterRef) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.memberships[resourceType+"/"+name] = ref
+}
+
+// usersAdded records users added to a cost center.  Adding a user moves it
+// out of any other cost center, so it is dropped from their cached members.
+// Their memberships are forgotten rather than guessed, since the API
+// reports the cost center name.
+func (rc *RunCache) usersAdded(id string, users []string) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	moved := make(map[string]bool, len(users))
+	for _, u := range users {
+		moved[u] = true
+	}
+	for other, cur := range rc.members {
+		if other != id {
+			rc.members[other] = slices.DeleteFunc(cur, func(u string) bool { return moved[u] })
+		}
+	}
+	if cur, ok := rc.members[id]; ok {
+		for _, u := range users {
+			if !slices.Contains(cur, u) {
+				cur = append(cur, u)
+			}
+		}
+		rc.members[id] = cur
+	}
+	for _, u := range users {
+		delete(rc.memberships, ResourceTypeUser+"/"+u)
+	}
+}
+
+// usersRemoved records users removed from a cost center.
+func (rc *RunCache) usersRemoved(id string, users []string) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if cur, ok := rc.members[id]; ok {
+		rc.members[id] = slices.DeleteFunc(cur, func(u string) bool { return slices.Contains(users, u) })
+	}
+	for _, u := range users {
+		delete(rc.memberships, ResourceTypeUser+"/"+u)
+	}
+}
+
+// reposAdded forgets the cached memberships of repositories added to a cost
+// center.
+func (rc *RunCache) reposAdded(repos []string) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for _, r := range repos {
+		delete(rc.memberships, ResourceTypeRepo+"/"+r)
+	}
+}
+
+// reposRemoved forgets the cached memberships of repositories removed from
+// a cost center.
+func (rc *RunCache) reposRemoved(repos []string) {
+	rc.reposAdded(repos)
+}
+
+// forgetCostCenter drops everything cached about a cost center that no
+// longer exists and remembers its ID as gone.
+func (rc *RunCache) forgetCostCenter(id string) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	maps.DeleteFunc(rc.activeCCs, func(_, v string) bool { return v == id })
+	delete(rc.members, id)
+	maps.DeleteFunc(rc.memberships, func(_ string, ref *CostCenterRef) bool { return ref != nil && ref.ID == id })
+	rc.gone[id] = true
+}
+
+// isGone reports whether the cost center was found missing or deleted
+// during the run.
+func (rc *RunCache) isGone(id string) bool {
+	if rc == nil {
+		return false
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.gone[id]
+}
+
+// budgetList returns a copy of the cached budgets, or ok=false when they
+// have not been listed.
+func (rc *RunCache) budgetList() ([]Budget, bool) {
+	if rc == nil {
+		return nil, false
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if !rc.budgetsLoaded {
+		return nil, false
+	}
+	return slices.Clone(rc.budgets), true
+}
+
+// setBudgets stores the enterprise budgets.
+func (rc *RunCache) setBudgets(budgets []Budget) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.budgets, rc.budgetsLoaded = slices.Clone(budgets), true
+}
+
+// budgetCreated records a created budget, if the budgets have been listed.
+func (rc *RunCache) budgetCreated(b Budget) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.budgetsLoaded {
+		rc.budgets = append(rc.budgets, b)
+	}
+}
+
+// budgetUpdated records the settings an update from pc made to a budget.
+func (rc *RunCache) budgetUpdated(id string, pc config.ProductBudget) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for i := range rc.budgets {
+		if rc.budgets[i].ID == id {
+			applyProductBudget(&rc.budgets[i], pc)
+		}
+	}
+}
+
+// forgetBudgets drops the cached budgets, after a write whose effect is
+// unknown, so the next read lists them again.
+func (rc *RunCache) forgetBudgets() {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.budgets, rc.budgetsLoaded = nil, false
+}
@@ -0,0 +1,237 @@
+package github
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// schemaSpec describes what the client expects of one decoded type of the
+// billing API beyond its struct fields.
+type schemaSpec struct {
+	// required fields must be present; nonEmpty fields must also hold a
+	// value other than null, "", [] or {}.
+	required []string
+	nonEmpty []string
+	// known fields are documented by the API but not decoded, so their
+	// presence is not reported.
+	known []string
+}
+
+// schemaSpecs lists the billing API types whose responses are checked.
+// Nested struct types are checked for unknown fields whether listed or not.
+var schemaSpecs = map[reflect.Type]schemaSpec{
+	reflect.TypeOf(costCentersListResponse{}): {required: []string{"costCenters"}},
+	reflect.TypeOf(CostCenter{}):              {nonEmpty: []string{"id", "name", "state"}, known: []string{"resources"}},
+	reflect.TypeOf(costCenterDetailResponse{}): {
+		required: []string{"resources"},
+		nonEmpty: []string{"id", "name", "state"},
+	},
+	reflect.TypeOf(Resource{}):           {nonEmpty: []string{"type", "name"}},
+	reflect.TypeOf(membershipResponse{}): {required: []string{"memberships"}},
+	reflect.TypeOf(CostCenterRef{}):      {nonEmpty: []string{"id"}},
+	reflect.TypeOf(budgetsListResponse{}): {
+		required: []string{"budgets"},
+		known:    []string{"total_count"},
+	},
+	reflect.TypeOf(Budget{}): {
+		nonEmpty: []string{"id", "budget_type", "budget_scope"},
+		known:    []string{"budget_product_skus"},
+	},
+	reflect.TypeOf(premiumRequestUsageResponse{}): {
+		required: []string{"usageItems"},
+		known:    []string{"timePeriod", "enterprise", "organization", "user", "product", "model", "costCenter"},
+	},
+	reflect.TypeOf(premiumRequestUsageItem{}): {
+		required: []string{"grossQuantity"},
+		nonEmpty: []string{"model"},
+		known: []string{"product", "sku", "unitType", "pricePerUnit", "grossAmount",
+			"discountQuantity", "discountAmount", "netQuantity", "netAmount"},
+	},
+}
+
+// Kinds of SchemaFinding.
+const (
+	SchemaUnknown = "unknown" // a field the client does not know
+	SchemaMissing = "missing" // a required field is absent (renamed?)
+	SchemaEmpty   = "empty"   // a required field is null or empty
+)
+
+// SchemaFinding is one way billing API responses differed from what the
+// client expects, with the number of times it was seen.
+type SchemaFinding struct {
+	Response string // the decoded response, e.g. "cost centers"
+	Field    string // JSON path, e.g. "costCenters[].state"
+	Kind     string // SchemaUnknown, SchemaMissing or SchemaEmpty
+	Count    int
+}
+
+// schemaResponses names the checked response types in findings.
+var schemaResponses = map[reflect.Type]string{
+	reflect.TypeOf(costCentersListResponse{}):     "cost centers",
+	reflect.TypeOf(costCenterDetailResponse{}):    "cost center",
+	reflect.TypeOf(membershipResponse{}):          "cost center memberships",
+	reflect.TypeOf(budgetsListResponse{}):         "budgets",
+	reflect.TypeOf(premiumRequestUsageResponse{}): "premium request usage",
+}
+
+// schemaDrift collects SchemaFindings over a run.
+type schemaDrift struct {
+	mu       sync.Mutex
+	findings map[SchemaFinding]int // Count is zero in the keys
+}
+
+// checkSchema compares the raw response decoded into dest with dest's
+// type, when it is one of schemaResponses, and records the differences.
+// The first occurrence of each finding is logged as a warning so a
+// breaking API change shows up as a diagnostic rather than as empty
+// results.
+func (c *Client) checkSchema(url string, raw json.RawMessage, dest any) {
+	t := reflect.TypeOf(dest)
+	if t == nil || t.Kind() != reflect.Pointer {
+		return
+	}
+	name, ok := schemaResponses[t.Elem()]
+	if !ok {
+		return
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return
+	}
+	var found []SchemaFinding
+	walkSchema(t.Elem(), v, "", func(field, kind string) {
+		found = append(found, SchemaFinding{Response: name, Field: field, Kind: kind})
+	})
+	if len(found) == 0 {
+		return
+	}
+
+	c.schema.mu.Lock()
+	defer c.schema.mu.Unlock()
+	if c.schema.findings == nil {
+		c.schema.findings = make(map[SchemaFinding]int)
+	}
+	for _, f := range found {
+		if c.schema.findings[f] == 0 {
+			c.log.Warn("API response differs from the expected schema",
+				"response", f.Response, "field", f.Field, "kind", f.Kind, "url", url)
+		}
+		c.schema.findings[f]++
+	}
+}
+
+// walkSchema checks v against t, reporting findings under the JSON path
+// prefix.  Only objects decoded into structs are checked.
+func walkSchema(t reflect.Type, v any, prefix string, report func(field, kind string)) {
+	switch t.Kind() {
+	case reflect.Pointer:
+		walkSchema(t.Elem(), v, prefix, report)
+	case reflect.Slice:
+		items, ok := v.([]any)
+		if !ok {
+			return
+		}
+		for _, item := range items {
+			walkSchema(t.Elem(), item, prefix+"[]", report)
+		}
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return
+		}
+		spec := schemaSpecs[t]
+		path := func(key string) string {
+			if prefix == "" {
+				return key
+			}
+			return prefix + "." + key
+		}
+
+		fields := make(map[string]reflect.Type)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			key, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if key == "" || key == "-" {
+				continue
+			}
+			fields[key] = f.Type
+		}
+		for _, key := range spec.known {
+			if _, ok := fields[key]; !ok {
+				fields[key] = nil
+			}
+		}
+
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			ft, ok := fields[key]
+			if !ok {
+				report(path(key), SchemaUnknown)
+				continue
+			}
+			if ft != nil {
+				walkSchema(ft, obj[key], path(key), report)
+			}
+		}
+
+		for _, key := range spec.required {
+			if _, ok := obj[key]; !ok {
+				report(path(key), SchemaMissing)
+			}
+		}
+		for _, key := range spec.nonEmpty {
+			val, ok := obj[key]
+			switch {
+			case !ok:
+				report(path(key), SchemaMissing)
+			case isEmptyJSON(val):
+				report(path(key), SchemaEmpty)
+			}
+		}
+	}
+}
+
+// isEmptyJSON reports whether a decoded JSON value is null, "", [] or {}.
+func isEmptyJSON(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// SchemaDrift returns how billing API responses of this client differed
+// from the fields it expects, sorted by response and field.  It is empty
+// when every response matched.
+func (c *Client) SchemaDrift() []SchemaFinding {
+	c.schema.mu.Lock()
+	defer c.schema.mu.Unlock()
+	out := make([]SchemaFinding, 0, len(c.schema.findings))
+	for f, n := range c.schema.findings {
+		f.Count = n
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Response != out[j].Response {
+			return out[i].Response < out[j].Response
+		}
+		if out[i].Field != out[j].Field {
+			return out[i].Field < out[j].Field
+		}
+		return out[i].Kind < out[j].Kind
+	})
+	return out
+}
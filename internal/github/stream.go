@@ -0,0 +1,68 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// streamArray reads a JSON array from dec one element at a time, calling
+// item for each so it can decode the element with dec.Decode.  Only one
+// element is held in memory at a time.  It returns the number of elements.
+func streamArray(dec *json.Decoder, item func(*json.Decoder) error) (int, error) {
+	if err := expectDelim(dec, '['); err != nil {
+		return 0, err
+	}
+	n := 0
+	for dec.More() {
+		if err := item(dec); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := expectDelim(dec, ']'); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// streamObjectArray reads a JSON object from dec, streaming the array under
+// field through streamArray and skipping every other value.  A missing
+// field yields zero elements.
+func streamObjectArray(dec *json.Decoder, field string, item func(*json.Decoder) error) (int, error) {
+	if err := expectDelim(dec, '{'); err != nil {
+		return 0, err
+	}
+	n := 0
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return n, err
+		}
+		if key, _ := tok.(string); key == field {
+			if n, err = streamArray(dec, item); err != nil {
+				return n, err
+			}
+			continue
+		}
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return n, err
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// expectDelim reads the next token and checks that it is want.
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("expected %q in JSON, got %v", want, tok)
+	}
+	return nil
+}
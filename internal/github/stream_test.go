@@ -0,0 +1,315 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestStreamArray(t *testing.T) {
+	dec := json.NewDecoder(strings.NewReader(`[{"login":"a"},{"login":"b"}]`))
+	var got []string
+	n, err := streamArray(dec, func(dec *json.Decoder) error {
+		var u assignee
+		if err := dec.Decode(&u); err != nil {
+			return err
+		}
+		got = append(got, u.Login)
+		return nil
+	})
+	if err != nil || n != 2 || strings.Join(got, ",") != "a,b" {
+		t.Errorf("streamArray = %d, %v, %v", n, got, err)
+	}
+
+	if _, err := streamArray(json.NewDecoder(strings.NewReader(`{}`)), nil); err == nil {
+		t.Error("expected an error for an object")
+	}
+}
+
+func TestStreamArray_StopsOnItemError(t *testing.T) {
+	dec := json.NewDecoder(strings.NewReader(`[1,2,3]`))
+	stop := fmt.Errorf("stop")
+	n, err := streamArray(dec, func(dec *json.Decoder) error {
+		var v int
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		if v == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || n != 1 {
+		t.Errorf("streamArray = %d, %v; want 1, stop", n, err)
+	}
+}
+
+func TestStreamObjectArray(t *testing.T) {
+	body := `{"total_seats":2,"extra":{"nested":[1,2]},"seats":[{"assignee":{"login":"a"}},{"assignee":{"login":"b"}}],"after":"x"}`
+	var got []string
+	n, err := streamObjectArray(json.NewDecoder(strings.NewReader(body)), "seats", func(dec *json.Decoder) error {
+		var s seatEntry
+		if err := dec.Decode(&s); err != nil {
+			return err
+		}
+		got = append(got, s.Assignee.Login)
+		return nil
+	})
+	if err != nil || n != 2 || strings.Join(got, ",") != "a,b" {
+		t.Errorf("streamObjectArray = %d, %v, %v", n, got, err)
+	}
+
+	n, err = streamObjectArray(json.NewDecoder(strings.NewReader(`{"total_seats":0}`)), "seats", nil)
+	if err != nil || n != 0 {
+		t.Errorf("missing field = %d, %v; want 0, nil", n, err)
+	}
+	if _, err := streamObjectArray(json.NewDecoder(strings.NewReader(`[]`)), "seats", nil); err == nil {
+		t.Error("expected an error for an array")
+	}
+	if _, err := streamObjectArray(json.NewDecoder(strings.NewReader(`{"seats":{}}`)), "seats", nil); err == nil {
+		t.Error("expected an error for a non-array field")
+	}
+}
+
+// largeItems is the response size used by the memory tests and benchmarks.
+const largeItems = 50000
+
+// genReader produces a JSON body of n items on the fly so the body itself
+// is never held in memory.
+type genReader struct {
+	prefix, suffix string
+	item           func(i int) string
+	n, i           int
+	buf            []byte
+	done           bool
+}
+
+func (g *genReader) Read(p []byte) (int, error) {
+	for len(g.buf) == 0 {
+		switch {
+		case g.done:
+			return 0, io.EOF
+		case g.i == 0 && g.prefix != "":
+			g.buf = []byte(g.prefix)
+			g.prefix = ""
+		case g.i < g.n:
+			s := g.item(g.i)
+			if g.i > 0 {
+				s = "," + s
+			}
+			g.buf = []byte(s)
+			g.i++
+		default:
+			g.buf = []byte(g.suffix)
+			g.done = true
+		}
+	}
+	n := copy(p, g.buf)
+	g.buf = g.buf[n:]
+	return n, nil
+}
+
+func seatsBody(n int) io.Reader {
+	return seatsPage(n, 0, n)
+}
+
+// seatsPage produces the n seats starting at seat start of total.
+func seatsPage(total, start, n int) io.Reader {
+	return &genReader{
+		prefix: fmt.Sprintf(`{"total_seats":%d,"seats":[`, total),
+		suffix: `]}`,
+		n:      n,
+		item: func(i int) string {
+			i += start
+			return fmt.Sprintf(`{"assignee":{"login":"user-%d","id":%d,"type":"User"},"created_at":"2026-01-01T00:00:00Z","plan":"business","last_activity_editor":"vscode"}`, i, i)
+		},
+	}
+}
+
+// seatsServer serves largeItems seats in pages of per_page.
+func seatsServer(tb testing.TB) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		start := (page - 1) * perPage
+		n := max(0, min(perPage, largeItems-start))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.Copy(w, seatsPage(largeItems, start, n))
+	}))
+	tb.Cleanup(srv.Close)
+	return srv
+}
+
+func repoPropertiesBody(n int) io.Reader {
+	return &genReader{
+		prefix: `[`,
+		suffix: `]`,
+		n:      n,
+		item: func(i int) string {
+			return fmt.Sprintf(`{"repository_id":%d,"repository_name":"repo-%d","repository_full_name":"org/repo-%d","properties":[{"property_name":"team","value":"team-%d"},{"property_name":"tier","value":["gold","silver"]}]}`, i, i, i, i%50)
+		},
+	}
+}
+
+// heapInUse returns the live heap after a collection.
+func heapInUse() uint64 {
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.HeapAlloc
+}
+
+// streamSeats streams the seats of body, sampling the heap every sample
+// items, and returns the largest growth over the starting heap.
+func streamSeats(tb testing.TB, body io.Reader, sample int) uint64 {
+	base := heapInUse()
+	var peak uint64
+	n, err := streamObjectArray(json.NewDecoder(body), "seats", func(dec *json.Decoder) error {
+		var s seatEntry
+		if err := dec.Decode(&s); err != nil {
+			return err
+		}
+		if s.Assignee.ID%int64(sample) == 0 {
+			if h := heapInUse(); h > base && h-base > peak {
+				peak = h - base
+			}
+		}
+		return nil
+	})
+	if err != nil || n != largeItems {
+		tb.Fatalf("streamed %d seats: %v", n, err)
+	}
+	return peak
+}
+
+func TestStreamObjectArray_FlatMemory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("memory test")
+	}
+	// 50k seats decode to well over 10 MiB as a slice; the stream should
+	// only ever hold a decoder buffer and one item.
+	const limit = 2 << 20
+	if peak := streamSeats(t, seatsBody(largeItems), 5000); peak > limit {
+		t.Errorf("heap grew by %d bytes while streaming, want at most %d", peak, limit)
+	}
+}
+
+func BenchmarkSeats_Stream(b *testing.B) {
+	b.ReportAllocs()
+	var peak uint64
+	for i := 0; i < b.N; i++ {
+		if p := streamSeats(b, seatsBody(largeItems), 10000); p > peak {
+			peak = p
+		}
+	}
+	b.ReportMetric(float64(peak), "peak-heap-B")
+}
+
+func BenchmarkSeats_DecodeAll(b *testing.B) {
+	b.ReportAllocs()
+	var peak uint64
+	for i := 0; i < b.N; i++ {
+		base := heapInUse()
+		var resp seatsResponse
+		if err := json.NewDecoder(seatsBody(largeItems)).Decode(&resp); err != nil {
+			b.Fatal(err)
+		}
+		if h := heapInUse(); h > base && h-base > peak {
+			peak = h - base
+		}
+		runtime.KeepAlive(resp)
+	}
+	b.ReportMetric(float64(peak), "peak-heap-B")
+}
+
+// BenchmarkSeats_Paginated walks every page of the seat listing as the
+// users mode of assign does, compared with collecting the seats first.
+func BenchmarkSeats_Paginated(b *testing.B) {
+	c := newTestClient(b, seatsServer(b).URL)
+	for _, bc := range []struct {
+		name string
+		walk func(fn func(CopilotUser)) error
+	}{
+		{"EachUniqueCopilotUser", func(fn func(CopilotUser)) error {
+			return c.EachUniqueCopilotUser(b.Context(), func(u CopilotUser) error {
+				fn(u)
+				return nil
+			})
+		}},
+		{"GetCopilotUsers", func(fn func(CopilotUser)) error {
+			users, err := c.GetCopilotUsers(b.Context())
+			for _, u := range users {
+				fn(u)
+			}
+			return err
+		}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var peak uint64
+			for i := 0; i < b.N; i++ {
+				base := heapInUse()
+				seen := 0
+				err := bc.walk(func(u CopilotUser) {
+					if seen++; seen%10000 == 0 {
+						if h := heapInUse(); h > base && h-base > peak {
+							peak = h - base
+						}
+					}
+				})
+				if err != nil || seen != largeItems {
+					b.Fatalf("walked %d seats: %v", seen, err)
+				}
+			}
+			b.ReportMetric(float64(peak), "peak-heap-B")
+		})
+	}
+}
+
+func BenchmarkRepoProperties_Stream(b *testing.B) {
+	b.ReportAllocs()
+	var peak uint64
+	for i := 0; i < b.N; i++ {
+		base := heapInUse()
+		seen := 0
+		_, err := streamArray(json.NewDecoder(repoPropertiesBody(largeItems)), func(dec *json.Decoder) error {
+			var r RepoProperties
+			if err := dec.Decode(&r); err != nil {
+				return err
+			}
+			if seen++; seen%10000 == 0 {
+				if h := heapInUse(); h > base && h-base > peak {
+					peak = h - base
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(peak), "peak-heap-B")
+}
+
+func BenchmarkRepoProperties_DecodeAll(b *testing.B) {
+	b.ReportAllocs()
+	var peak uint64
+	for i := 0; i < b.N; i++ {
+		base := heapInUse()
+		var repos []RepoProperties
+		if err := json.NewDecoder(repoPropertiesBody(largeItems)).Decode(&repos); err != nil {
+			b.Fatal(err)
+		}
+		if h := heapInUse(); h > base && h-base > peak {
+			peak = h - base
+		}
+		runtime.KeepAlive(repos)
+	}
+	b.ReportMetric(float64(peak), "peak-heap-B")
+}
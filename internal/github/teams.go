@@ -1,8 +1,10 @@
 package github
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"slices"
 )
 
 // Team represents a GitHub team (organization or enterprise level).
@@ -24,7 +26,7 @@ type TeamMember struct {
 
 // GetOrgTeams returns all teams for the given organization, handling
 // pagination automatically.
-func (c *Client) GetOrgTeams(org string) ([]Team, error) {
+func (c *Client) GetOrgTeams(ctx context.Context, org string) ([]Team, error) {
 	c.log.Info("Fetching teams for organization", "org", org)
 	baseURL := fmt.Sprintf("%s/orgs/%s/teams", c.baseURL, org)
 
@@ -35,7 +37,7 @@ func (c *Client) GetOrgTeams(org string) ([]Team, error) {
 	for {
 		pageURL := fmt.Sprintf("%s?page=%d&per_page=%d", baseURL, page, perPage)
 		var teams []Team
-		if _, err := c.doJSON(http.MethodGet, pageURL, nil, &teams); err != nil {
+		if _, err := c.doJSON(ctx, http.MethodGet, pageURL, nil, &teams); err != nil {
 			return nil, fmt.Errorf("fetching teams for org %s page %d: %w", org, page, err)
 		}
 		if len(teams) == 0 {
@@ -55,18 +57,37 @@ func (c *Client) GetOrgTeams(org string) ([]Team, error) {
 
 // GetOrgTeamMembers returns all members of the specified organization team,
 // handling pagination automatically.
-func (c *Client) GetOrgTeamMembers(org, teamSlug string) ([]TeamMember, error) {
-	c.log.Debug("Fetching members for team", "org", org, "team", teamSlug)
-	baseURL := fmt.Sprintf("%s/orgs/%s/teams/%s/members", c.baseURL, org, teamSlug)
+func (c *Client) GetOrgTeamMembers(ctx context.Context, org, teamSlug string) ([]TeamMember, error) {
+	return c.getOrgTeamMembers(ctx, org, teamSlug, "")
+}
+
+// GetOrgTeamMaintainers returns the maintainers of the specified organization
+// team, handling pagination automatically.
+func (c *Client) GetOrgTeamMaintainers(ctx context.Context, org, teamSlug string) ([]TeamMember, error) {
+	return c.getOrgTeamMembers(ctx, org, teamSlug, "maintainer")
+}
 
+// getOrgTeamMembers lists team members, optionally filtered by role
+// ("member" or "maintainer"; empty means all).
+func (c *Client) getOrgTeamMembers(ctx context.Context, org, teamSlug, role string) ([]TeamMember, error) {
+	key := "org/" + org + "/" + teamSlug + "?role=" + role
 	var allMembers []TeamMember
+	if c.memberCache != nil && c.memberCache.Get(key, &allMembers) {
+		return allMembers, nil
+	}
+	c.log.Debug("Fetching members for team", "org", org, "team", teamSlug, "role", role)
+	baseURL := fmt.Sprintf("%s/orgs/%s/teams/%s/members", c.baseURL, org, teamSlug)
+
 	page := 1
 	const perPage = 100
 
 	for {
 		pageURL := fmt.Sprintf("%s?page=%d&per_page=%d", baseURL, page, perPage)
+		if role != "" {
+			pageURL += "&role=" + role
+		}
 		var members []TeamMember
-		if _, err := c.doJSON(http.MethodGet, pageURL, nil, &members); err != nil {
+		if _, err := c.doJSON(ctx, http.MethodGet, pageURL, nil, &members); err != nil {
 			return nil, fmt.Errorf("fetching members for team %s/%s page %d: %w", org, teamSlug, page, err)
 		}
 		if len(members) == 0 {
@@ -81,13 +102,45 @@ func (c *Client) GetOrgTeamMembers(org, teamSlug string) ([]TeamMember, error) {
 		page++
 	}
 
-	c.log.Info("Total members found", "team", org+"/"+teamSlug, "count", len(allMembers))
+	c.log.Info("Total members found", "team", org+"/"+teamSlug, "role", role, "count", len(allMembers))
+	c.cacheTeamMembers(key, allMembers)
+	return allMembers, nil
+}
+
+// GetOrgMembers returns all members of the specified organization, handling
+// pagination automatically.
+func (c *Client) GetOrgMembers(ctx context.Context, org string) ([]TeamMember, error) {
+	c.log.Info("Fetching members for organization", "org", org)
+	baseURL := fmt.Sprintf("%s/orgs/%s/members", c.baseURL, org)
+
+	var allMembers []TeamMember
+	page := 1
+	const perPage = 100
+
+	for {
+		pageURL := fmt.Sprintf("%s?page=%d&per_page=%d", baseURL, page, perPage)
+		var members []TeamMember
+		if _, err := c.doJSON(ctx, http.MethodGet, pageURL, nil, &members); err != nil {
+			return nil, fmt.Errorf("fetching members for org %s page %d: %w", org, page, err)
+		}
+		if len(members) == 0 {
+			break
+		}
+		allMembers = append(allMembers, members...)
+		c.log.Debug("Fetched organization members page", "org", org, "page", page, "count", len(members))
+		if len(members) < perPage {
+			break
+		}
+		page++
+	}
+
+	c.log.Info("Total members found for organization", "org", org, "count", len(allMembers))
 	return allMembers, nil
 }
 
 // GetEnterpriseTeams returns all teams in the enterprise, handling pagination
 // automatically.
-func (c *Client) GetEnterpriseTeams() ([]Team, error) {
+func (c *Client) GetEnterpriseTeams(ctx context.Context) ([]Team, error) {
 	c.log.Info("Fetching enterprise teams", "enterprise", c.enterprise)
 	baseURL := c.enterpriseURL("/teams")
 
@@ -98,7 +151,7 @@ func (c *Client) GetEnterpriseTeams() ([]Team, error) {
 	for {
 		pageURL := fmt.Sprintf("%s?page=%d&per_page=%d", baseURL, page, perPage)
 		var teams []Team
-		if _, err := c.doJSON(http.MethodGet, pageURL, nil, &teams); err != nil {
+		if _, err := c.doJSON(ctx, http.MethodGet, pageURL, nil, &teams); err != nil {
 			return nil, fmt.Errorf("fetching enterprise teams page %d: %w", page, err)
 		}
 		if len(teams) == 0 {
@@ -118,18 +171,22 @@ func (c *Client) GetEnterpriseTeams() ([]Team, error) {
 
 // GetEnterpriseTeamMembers returns all members of the specified enterprise
 // team, handling pagination automatically.
-func (c *Client) GetEnterpriseTeamMembers(teamSlug string) ([]TeamMember, error) {
+func (c *Client) GetEnterpriseTeamMembers(ctx context.Context, teamSlug string) ([]TeamMember, error) {
+	key := "enterprise/" + c.enterprise + "/" + teamSlug
+	var allMembers []TeamMember
+	if c.memberCache != nil && c.memberCache.Get(key, &allMembers) {
+		return allMembers, nil
+	}
 	c.log.Debug("Fetching members for enterprise team", "team", teamSlug)
 	baseURL := c.enterpriseURL(fmt.Sprintf("/teams/%s/memberships", teamSlug))
 
-	var allMembers []TeamMember
 	page := 1
 	const perPage = 100
 
 	for {
 		pageURL := fmt.Sprintf("%s?page=%d&per_page=%d", baseURL, page, perPage)
 		var members []TeamMember
-		if _, err := c.doJSON(http.MethodGet, pageURL, nil, &members); err != nil {
+		if _, err := c.doJSON(ctx, http.MethodGet, pageURL, nil, &members); err != nil {
 			return nil, fmt.Errorf("fetching enterprise team %s members page %d: %w", teamSlug, page, err)
 		}
 		if len(members) == 0 {
@@ -145,5 +202,99 @@ func (c *Client) GetEnterpriseTeamMembers(teamSlug string) ([]TeamMember, error)
 	}
 
 	c.log.Info("Total members found for enterprise team", "team", teamSlug, "count", len(allMembers))
+	c.cacheTeamMembers(key, allMembers)
 	return allMembers, nil
 }
+
+// OrgTeamMembersIfChanged lists the members of an organization team
+// unless the listing is unchanged since it returned etags (see
+// teamMembersIfChanged).
+func (c *Client) OrgTeamMembersIfChanged(ctx context.Context, org, teamSlug string, etags []string, recorded int) ([]TeamMember, []string, bool, error) {
+	baseURL := fmt.Sprintf("%s/orgs/%s/teams/%s/members", c.baseURL, org, teamSlug)
+	return c.teamMembersIfChanged(ctx, baseURL, org+"/"+teamSlug, etags, recorded)
+}
+
+// EnterpriseTeamMembersIfChanged is OrgTeamMembersIfChanged for an
+// enterprise team.
+func (c *Client) EnterpriseTeamMembersIfChanged(ctx context.Context, teamSlug string, etags []string, recorded int) ([]TeamMember, []string, bool, error) {
+	baseURL := c.enterpriseURL(fmt.Sprintf("/teams/%s/memberships", teamSlug))
+	return c.teamMembersIfChanged(ctx, baseURL, teamSlug, etags, recorded)
+}
+
+// teamMembersIfChanged revalidates a member listing whose pages last
+// returned etags and held recorded members in total.  When every page
+// answers 304 Not Modified (which does not count against the rate limit)
+// it reports changed false and returns no members; otherwise it fetches
+// the listing and returns its members with the ETag of each page.  The
+// returned ETags are nil when a page carried none.
+func (c *Client) teamMembersIfChanged(ctx context.Context, baseURL, team string, etags []string, recorded int) ([]TeamMember, []string, bool, error) {
+	const perPage = 100
+	if len(etags) > 0 {
+		unchanged, err := c.pagesUnchanged(ctx, baseURL, etags, perPage)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("revalidating members of team %s: %w", team, err)
+		}
+		// A full last page may since have been followed by another one.
+		if unchanged && recorded > 0 && recorded == len(etags)*perPage {
+			var next []TeamMember
+			pageURL := fmt.Sprintf("%s?page=%d&per_page=%d", baseURL, len(etags)+1, perPage)
+			if _, err := c.doJSON(ctx, http.MethodGet, pageURL, nil, &next); err != nil {
+				return nil, nil, false, fmt.Errorf("revalidating members of team %s: %w", team, err)
+			}
+			unchanged = len(next) == 0
+		}
+		if unchanged {
+			c.log.Debug("Team members not modified", "team", team, "pages", len(etags))
+			return nil, etags, false, nil
+		}
+	}
+
+	var allMembers []TeamMember
+	var pageETags []string
+	for page := 1; ; page++ {
+		pageURL := fmt.Sprintf("%s?page=%d&per_page=%d", baseURL, page, perPage)
+		var members []TeamMember
+		resp, err := c.doJSON(ctx, http.MethodGet, pageURL, nil, &members)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("fetching members for team %s page %d: %w", team, page, err)
+		}
+		pageETags = append(pageETags, resp.Header.Get("ETag"))
+		allMembers = append(allMembers, members...)
+		if len(members) < perPage {
+			break
+		}
+	}
+	if slices.Contains(pageETags, "") {
+		pageETags = nil
+	}
+	c.log.Info("Total members found", "team", team, "count", len(allMembers))
+	return allMembers, pageETags, true, nil
+}
+
+// pagesUnchanged sends a conditional GET for each page of a listing and
+// reports whether every one answered 304 Not Modified.  It stops at the
+// first page that changed.
+func (c *Client) pagesUnchanged(ctx context.Context, baseURL string, etags []string, perPage int) (bool, error) {
+	for i, etag := range etags {
+		pageURL := fmt.Sprintf("%s?page=%d&per_page=%d", baseURL, i+1, perPage)
+		resp, err := c.doStreamHeader(ctx, http.MethodGet, pageURL, nil, http.Header{"If-None-Match": {etag}}, nil)
+		if err != nil {
+			return false, err
+		}
+		if resp.StatusCode != http.StatusNotModified {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// cacheTeamMembers stores a team member list in the member bucket, if one
+// is attached.  Failing to store it only costs a refetch next run.
+func (c *Client) cacheTeamMembers(key string, members []TeamMember) {
+	if c.memberCache == nil {
+		return
+	}
+	if err := c.memberCache.Set(key, members); err != nil {
+		c.log.Warn("Could not cache team members", "key", key, "error", err)
+	}
+}
@@ -0,0 +1,72 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"sync"
+)
+
+// premiumRequestUsageResponse is the premium request usage report of the
+// enhanced billing platform.
+type premiumRequestUsageResponse struct {
+	UsageItems []premiumRequestUsageItem `json:"usageItems"`
+}
+
+type premiumRequestUsageItem struct {
+	Model         string  `json:"model"`
+	GrossQuantity float64 `json:"grossQuantity"`
+}
+
+// GetUserPremiumRequests returns how many Copilot premium requests a user
+// made in the given month, before the included allowance is deducted.
+func (c *Client) GetUserPremiumRequests(ctx context.Context, login string, year, month int) (float64, error) {
+	url := c.enterpriseURL(fmt.Sprintf("/settings/billing/premium_request/usage?year=%d&month=%d&user=%s",
+		year, month, neturl.QueryEscape(login)))
+
+	var resp premiumRequestUsageResponse
+	if _, err := c.getJSON(ctx, url, &resp); err != nil {
+		return 0, fmt.Errorf("fetching premium request usage of %s: %w", login, err)
+	}
+	var total float64
+	for _, item := range resp.UsageItems {
+		total += item.GrossQuantity
+	}
+	return total, nil
+}
+
+// GetPremiumRequestsByUser returns the premium requests of every login in
+// the given month (see GetUserPremiumRequests), fetched with the configured
+// parallelism.  Users without usage map to 0; a user the report does not
+// know (404) is counted as 0 too.  Other failures are returned together.
+func (c *Client) GetPremiumRequestsByUser(ctx context.Context, logins []string, year, month int) (map[string]float64, error) {
+	usage := make(map[string]float64, len(logins))
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	c.parallel(ctx, len(logins), func(i int) {
+		n, err := c.GetUserPremiumRequests(ctx, logins[i], year, month)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			n, err = 0, nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		usage[logins[i]] = n
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%d of %d usage lookups failed: %w", len(errs), len(logins), errors.Join(errs...))
+	}
+	c.log.Debug("Fetched premium request usage", "users", len(usage), "year", year, "month", month)
+	return usage, nil
+}
@@ -0,0 +1,310 @@
+package i18n
+
+// catalogs maps language -> message key -> message.  Messages are
+// fmt.Sprintf formats; every translation must use the same verbs in the
+// same order as English.
+var catalogs = map[string]map[string]string{
+	English: {
+		"answer.yes":        "yes",
+		"unit.users":        "users",
+		"unit.repositories": "repositories",
+
+		"confirm.proceed":           "Proceed? (yes/no): ",
+		"confirm.proceed_apply":     "Proceed with APPLY? (yes/no): ",
+		"confirm.apply.intro":       "You are about to APPLY cost center assignments to GitHub Enterprise.",
+		"confirm.apply.no_diff":     "This will push assignments for ALL processed users (no diff).",
+		"confirm.apply.check":       "Current cost center membership will be checked — users in other cost centers will be SKIPPED.",
+		"confirm.apply.fast":        "Fast mode: Users will be assigned WITHOUT checking current cost center membership.",
+		"confirm.apply.summary":     "Summary:",
+		"confirm.apply.group":       "  - %s: %d users",
+		"confirm.planfile.intro":    "You are about to APPLY the plan in %s (created %s) to GitHub Enterprise.",
+		"confirm.planfile.create":   "  + create cost center %s",
+		"confirm.planfile.change":   "  - %s (%s): add %d %s",
+		"confirm.planfile.remove":   "  - %s (%s): remove %d %s",
+		"confirm.rollback.intro":    "You are about to ROLL BACK run %s, moving %d resources in GitHub Enterprise.",
+		"confirm.budgets.intro":     "You are about to WRITE %d budgets of %d cost centers in GitHub Enterprise.",
+		"confirm.cleanup.intro":     "You are about to DELETE %d empty cost centers in GitHub Enterprise.  They can be restored in enterprise billing settings.",
+		"confirm.deleted.delete":    "You are about to DELETE %d cost centers of deleted teams in GitHub Enterprise.  They can be restored in enterprise billing settings:",
+		"confirm.deleted.empty":     "You are about to REMOVE all users from %d cost centers of deleted teams in GitHub Enterprise:",
+		"confirm.move.intro":        "You are about to MOVE %d users from cost center %s to %s in GitHub Enterprise.",
+		"confirm.remove.intro":      "You are about to REMOVE %d users from cost center %s in GitHub Enterprise.  They will be in no cost center.",
+		"confirm.rename.intro":      "You are about to RENAME cost center %s to %s in GitHub Enterprise.  Its ID, members and budgets are kept.",
+		"confirm.replay.intro":      "You are about to RE-SEND %d writes that failed in a previous run to GitHub Enterprise:",
+		"confirm.replay.entry":      "  - %s to cost center %s: %s",
+		"confirm.transfer.intro":    "You are about to TRANSFER the budget alerts of cost center %s to %s, updating %d budgets in GitHub Enterprise.",
+		"consent.title":             "FIRST APPLY AGAINST ENTERPRISE %q",
+		"consent.no_history":        "No previous apply against this enterprise is recorded in the export directory.",
+		"consent.review":            "First runs move the most users and repositories; review the changes below.",
+		"consent.affected":          "Cost centers affected: %d",
+		"consent.total":             "Total %s to assign: %d",
+		"consent.to_create":         "Cost centers to be created: %d",
+		"consent.deleted.delete":    "Cost centers of deleted teams to be deleted: %d",
+		"consent.deleted.empty":     "Cost centers of deleted teams to be emptied: %d",
+		"consent.largest":           "Largest changes (%d of %d):",
+		"consent.type_slug":         "Type the enterprise slug (%s) to proceed: ",
+		"conflict.title":            "%s is in %d mapped teams with different cost centers:",
+		"conflict.option":           "  %d) %s (team %s)",
+		"conflict.current":          "  %d) %s (team %s) [current: conflict strategy]",
+		"conflict.choose":           "Choose 1-%d, Enter to keep the current one, or s to stop asking: ",
+		"conflict.remember":         "Remember this choice in %s? (yes/no): ",
+		"conflict.no_file":          "Set cost_center.overrides_file to remember choices; this one applies to this run only.",
+		"summary.assign.title":      "=== Assignment Summary ===",
+		"summary.assign.pru":        "PRUs Allowed (%s): %d users",
+		"summary.assign.no_pru":     "No PRUs (%s): %d users",
+		"summary.assign.server":     "Server-connected (%s): %d users",
+		"summary.assign.wind_down":  "Pending cancellation, wind-down (%s): %d users",
+		"summary.assign.skipped":    "Pending cancellation, skipped: %d users",
+		"summary.assign.total":      "Total: %d users",
+		"summary.budget.title":      "=== Budget Impact (Copilot, est. %s per seat/month) ===",
+		"summary.budget.row":        "  %s: %d -> %d seats, est. %s -> %s of %s budget (headroom %s)",
+		"summary.budget.configured": "[configured budget, not created yet]",
+		"summary.budget.exceeds":    "EXCEEDS BUDGET",
+		"summary.budgets.title":     "=== Budget Changes (--create-budgets) ===",
+		"summary.budgets.create":    "  + %s: create %s budget of %s",
+		"summary.budgets.update":    "  ~ %s: update %s budget %s -> %s",
+		"summary.budgets.none":      "  No budget changes.",
+		"summary.budgets.allows":    "(usage continues past the amount)",
+		"summary.budgets.stops":     "(usage stops at the amount)",
+		"summary.budgets.alerts":    "(alerts at %d%%)",
+		"summary.combined.title":    "=== Combined Assignment Summary ===",
+		"summary.success.title":     "SUCCESS SUMMARY",
+		"summary.success.ccs":       "COST CENTERS (%s):",
+		"summary.success.no_pru":    "  No PRU Overages: %s",
+		"summary.success.pru":       "  PRU Overages Allowed: %s",
+		"summary.success.users":     "USER STATISTICS:",
+		"summary.success.processed": "  Total users processed: %d",
+		"summary.success.incr":      "  Incremental processing: %d of %d total users",
+		"summary.success.pending":   "  Seats pending cancellation: %d users (handling: %s)",
+		"summary.success.rate":      "  Assignment success rate: %d/%d users",
+		"summary.success.failed":    "  Failed assignments: %d users",
+		"summary.success.by_cc":     "RESULTS BY COST CENTER:",
+		"summary.success.cc_result": "  %s: %d assigned, %d failed",
+		"summary.repos.title":       "REPOSITORY ASSIGNMENT SUMMARY",
+		"summary.customprop.title":  "CUSTOM-PROPERTY ASSIGNMENT SUMMARY",
+		"summary.repos.total":       "Total repositories in organization: %d",
+		"summary.repos.mappings":    "Mappings processed: %d / %d",
+		"summary.repos.ccs":         "Cost centers processed: %d / %d",
+		"summary.repos.cc":          "Cost Center: %s",
+		"summary.repos.property":    "  Property:  %s",
+		"summary.repos.values":      "  Values:    %s",
+		"summary.repos.topics":      "  Topics:    %s",
+		"summary.repos.names":       "  Names:     %s",
+		"summary.repos.filters":     "  Filters (AND):",
+		"summary.repos.matched":     "  Matched:   %d repositories",
+		"summary.repos.assigned":    "  Assigned:  %d repositories",
+		"summary.repos.current":     "  Already in cost center: %d repositories",
+		"summary.repos.elsewhere":   "  Skipped (in other cost centers): %d repositories (%s)",
+		"summary.repos.unchecked":   "  Skipped (cost center lookup failed): %d repositories (%s)",
+		"summary.repos.unmatched":   "  No longer matching: %d repositories (%s)",
+		"summary.repos.removed":     "  Removed:   %d repositories no longer matching",
+		"summary.repos.ok":          "  Status:    Success",
+		"summary.repos.failed":      "  Status:    Failed — %s",
+		"rollback.title_snapshot":   "Rollback of run %s (changes up to snapshot %s)",
+		"rollback.title_now":        "Rollback of run %s (changes up to now)",
+		"rollback.nothing":          "Nothing to roll back.",
+		"rollback.skipped":          "Skipped (%d):",
+		"rollback.total":            "Total: %d to move back, %d skipped",
+	},
+	Spanish: {
+		"answer.yes":        "sí",
+		"unit.users":        "usuarios",
+		"unit.repositories": "repositorios",
+
+		"confirm.proceed":           "¿Continuar? (sí/no): ",
+		"confirm.proceed_apply":     "¿Continuar con APPLY? (sí/no): ",
+		"confirm.apply.intro":       "Está a punto de APLICAR asignaciones de centros de costo en GitHub Enterprise.",
+		"confirm.apply.no_diff":     "Se enviarán las asignaciones de TODOS los usuarios procesados (sin diff).",
+		"confirm.apply.check":       "Se verificará la pertenencia actual a centros de costo — los usuarios en otros centros de costo se OMITIRÁN.",
+		"confirm.apply.fast":        "Modo rápido: los usuarios se asignarán SIN verificar su pertenencia actual a centros de costo.",
+		"confirm.apply.summary":     "Resumen:",
+		"confirm.apply.group":       "  - %s: %d usuarios",
+		"confirm.planfile.intro":    "Está a punto de APLICAR el plan de %s (creado %s) en GitHub Enterprise.",
+		"confirm.planfile.create":   "  + crear centro de costo %s",
+		"confirm.planfile.change":   "  - %s (%s): agregar %d %s",
+		"confirm.planfile.remove":   "  - %s (%s): quitar %d %s",
+		"confirm.rollback.intro":    "Está a punto de REVERTIR la ejecución %s, moviendo %d recursos en GitHub Enterprise.",
+		"confirm.budgets.intro":     "Está a punto de ESCRIBIR %d presupuestos de %d centros de costo en GitHub Enterprise.",
+		"confirm.cleanup.intro":     "Está a punto de ELIMINAR %d centros de costo vacíos en GitHub Enterprise.  Se pueden restaurar en la configuración de facturación de la empresa.",
+		"confirm.deleted.delete":    "Está a punto de ELIMINAR %d centros de costo de equipos eliminados en GitHub Enterprise.  Se pueden restaurar en la configuración de facturación de la empresa:",
+		"confirm.deleted.empty":     "Está a punto de QUITAR todos los usuarios de %d centros de costo de equipos eliminados en GitHub Enterprise:",
+		"confirm.move.intro":        "Está a punto de MOVER %d usuarios del centro de costo %s a %s en GitHub Enterprise.",
+		"confirm.remove.intro":      "Está a punto de QUITAR %d usuarios del centro de costo %s en GitHub Enterprise.  No quedarán en ningún centro de costo.",
+		"confirm.rename.intro":      "Está a punto de RENOMBRAR el centro de costo %s a %s en GitHub Enterprise.  Se conservan su ID, miembros y presupuestos.",
+		"confirm.replay.intro":      "Está a punto de REENVIAR a GitHub Enterprise %d escrituras que fallaron en una ejecución anterior:",
+		"confirm.replay.entry":      "  - %s en el centro de costo %s: %s",
+		"confirm.transfer.intro":    "Está a punto de TRANSFERIR las alertas de presupuesto del centro de costo %s a %s, actualizando %d presupuestos en GitHub Enterprise.",
+		"consent.title":             "PRIMER APPLY EN LA EMPRESA %q",
+		"consent.no_history":        "No hay ningún apply previo en esta empresa registrado en el directorio de exportación.",
+		"consent.review":            "Las primeras ejecuciones mueven la mayor cantidad de usuarios y repositorios; revise los cambios a continuación.",
+		"consent.affected":          "Centros de costo afectados: %d",
+		"consent.total":             "Total de %s a asignar: %d",
+		"consent.to_create":         "Centros de costo a crear: %d",
+		"consent.deleted.delete":    "Centros de costo de equipos eliminados a eliminar: %d",
+		"consent.deleted.empty":     "Centros de costo de equipos eliminados a vaciar: %d",
+		"consent.largest":           "Cambios más grandes (%d de %d):",
+		"consent.type_slug":         "Escriba el identificador de la empresa (%s) para continuar: ",
+		"conflict.title":            "%s está en %d equipos mapeados con distintos centros de costo:",
+		"conflict.option":           "  %d) %s (equipo %s)",
+		"conflict.current":          "  %d) %s (equipo %s) [actual: estrategia de conflicto]",
+		"conflict.choose":           "Elija 1-%d, Enter para mantener el actual, o s para dejar de preguntar: ",
+		"conflict.remember":         "¿Recordar esta elección en %s? (sí/no): ",
+		"conflict.no_file":          "Configure cost_center.overrides_file para recordar las elecciones; esta solo aplica a esta ejecución.",
+		"summary.assign.title":      "=== Resumen de asignación ===",
+		"summary.assign.pru":        "PRUs permitidos (%s): %d usuarios",
+		"summary.assign.no_pru":     "Sin PRUs (%s): %d usuarios",
+		"summary.assign.server":     "Conectados a servidor (%s): %d usuarios",
+		"summary.assign.wind_down":  "Cancelación pendiente, retiro gradual (%s): %d usuarios",
+		"summary.assign.skipped":    "Cancelación pendiente, omitidos: %d usuarios",
+		"summary.assign.total":      "Total: %d usuarios",
+		"summary.budget.title":      "=== Impacto en el presupuesto (Copilot, aprox. %s por licencia/mes) ===",
+		"summary.budget.row":        "  %s: %d -> %d licencias, aprox. %s -> %s de un presupuesto de %s (margen %s)",
+		"summary.budget.configured": "[presupuesto configurado, aún no creado]",
+		"summary.budget.exceeds":    "SUPERA EL PRESUPUESTO",
+		"summary.budgets.title":     "=== Cambios de presupuestos (--create-budgets) ===",
+		"summary.budgets.create":    "  + %s: crear presupuesto de %s de %s",
+		"summary.budgets.update":    "  ~ %s: actualizar presupuesto de %s %s -> %s",
+		"summary.budgets.none":      "  Sin cambios de presupuestos.",
+		"summary.budgets.allows":    "(el uso continúa después del monto)",
+		"summary.budgets.stops":     "(el uso se detiene en el monto)",
+		"summary.budgets.alerts":    "(alertas al %d%%)",
+		"summary.combined.title":    "=== Resumen combinado de asignación ===",
+		"summary.success.title":     "RESUMEN DE RESULTADOS",
+		"summary.success.ccs":       "CENTROS DE COSTO (%s):",
+		"summary.success.no_pru":    "  Sin excedentes de PRU: %s",
+		"summary.success.pru":       "  Excedentes de PRU permitidos: %s",
+		"summary.success.users":     "ESTADÍSTICAS DE USUARIOS:",
+		"summary.success.processed": "  Total de usuarios procesados: %d",
+		"summary.success.incr":      "  Procesamiento incremental: %d de %d usuarios en total",
+		"summary.success.pending":   "  Licencias con cancelación pendiente: %d usuarios (tratamiento: %s)",
+		"summary.success.rate":      "  Tasa de asignaciones exitosas: %d/%d usuarios",
+		"summary.success.failed":    "  Asignaciones fallidas: %d usuarios",
+		"summary.success.by_cc":     "RESULTADOS POR CENTRO DE COSTO:",
+		"summary.success.cc_result": "  %s: %d asignados, %d fallidos",
+		"summary.repos.title":       "RESUMEN DE ASIGNACIÓN DE REPOSITORIOS",
+		"summary.customprop.title":  "RESUMEN DE ASIGNACIÓN POR PROPIEDADES PERSONALIZADAS",
+		"summary.repos.total":       "Total de repositorios en la organización: %d",
+		"summary.repos.mappings":    "Mapeos procesados: %d / %d",
+		"summary.repos.ccs":         "Centros de costo procesados: %d / %d",
+		"summary.repos.cc":          "Centro de costo: %s",
+		"summary.repos.property":    "  Propiedad: %s",
+		"summary.repos.values":      "  Valores:   %s",
+		"summary.repos.topics":      "  Temas:     %s",
+		"summary.repos.names":       "  Nombres:   %s",
+		"summary.repos.filters":     "  Filtros (Y):",
+		"summary.repos.matched":     "  Coinciden: %d repositorios",
+		"summary.repos.assigned":    "  Asignados: %d repositorios",
+		"summary.repos.current":     "  Ya en el centro de costo: %d repositorios",
+		"summary.repos.elsewhere":   "  Omitidos (en otros centros de costo): %d repositorios (%s)",
+		"summary.repos.unchecked":   "  Omitidos (falló la consulta del centro de costo): %d repositorios (%s)",
+		"summary.repos.unmatched":   "  Ya no coinciden: %d repositorios (%s)",
+		"summary.repos.removed":     "  Eliminados: %d repositorios que ya no coinciden",
+		"summary.repos.ok":          "  Estado:    Correcto",
+		"summary.repos.failed":      "  Estado:    Falló — %s",
+		"rollback.title_snapshot":   "Reversión de la ejecución %s (cambios hasta la instantánea %s)",
+		"rollback.title_now":        "Reversión de la ejecución %s (cambios hasta ahora)",
+		"rollback.nothing":          "No hay nada que revertir.",
+		"rollback.skipped":          "Omitidos (%d):",
+		"rollback.total":            "Total: %d a revertir, %d omitidos",
+	},
+	Portuguese: {
+		"answer.yes":        "sim",
+		"unit.users":        "usuários",
+		"unit.repositories": "repositórios",
+
+		"confirm.proceed":           "Continuar? (sim/não): ",
+		"confirm.proceed_apply":     "Continuar com APPLY? (sim/não): ",
+		"confirm.apply.intro":       "Você está prestes a APLICAR atribuições de centros de custo no GitHub Enterprise.",
+		"confirm.apply.no_diff":     "Serão enviadas as atribuições de TODOS os usuários processados (sem diff).",
+		"confirm.apply.check":       "A associação atual a centros de custo será verificada — usuários em outros centros de custo serão IGNORADOS.",
+		"confirm.apply.fast":        "Modo rápido: os usuários serão atribuídos SEM verificar a associação atual a centros de custo.",
+		"confirm.apply.summary":     "Resumo:",
+		"confirm.apply.group":       "  - %s: %d usuários",
+		"confirm.planfile.intro":    "Você está prestes a APLICAR o plano de %s (criado em %s) no GitHub Enterprise.",
+		"confirm.planfile.create":   "  + criar centro de custo %s",
+		"confirm.planfile.change":   "  - %s (%s): adicionar %d %s",
+		"confirm.planfile.remove":   "  - %s (%s): remover %d %s",
+		"confirm.rollback.intro":    "Você está prestes a REVERTER a execução %s, movendo %d recursos no GitHub Enterprise.",
+		"confirm.budgets.intro":     "Você está prestes a GRAVAR %d orçamentos de %d centros de custo no GitHub Enterprise.",
+		"confirm.cleanup.intro":     "Você está prestes a EXCLUIR %d centros de custo vazios no GitHub Enterprise.  Eles podem ser restaurados nas configurações de cobrança da empresa.",
+		"confirm.deleted.delete":    "Você está prestes a EXCLUIR %d centros de custo de equipes excluídas no GitHub Enterprise.  Eles podem ser restaurados nas configurações de cobrança da empresa:",
+		"confirm.deleted.empty":     "Você está prestes a REMOVER todos os usuários de %d centros de custo de equipes excluídas no GitHub Enterprise:",
+		"confirm.move.intro":        "Você está prestes a MOVER %d usuários do centro de custo %s para %s no GitHub Enterprise.",
+		"confirm.remove.intro":      "Você está prestes a REMOVER %d usuários do centro de custo %s no GitHub Enterprise.  Eles ficarão sem centro de custo.",
+		"confirm.rename.intro":      "Você está prestes a RENOMEAR o centro de custo %s para %s no GitHub Enterprise.  Seu ID, membros e orçamentos são mantidos.",
+		"confirm.replay.intro":      "Você está prestes a REENVIAR ao GitHub Enterprise %d gravações que falharam em uma execução anterior:",
+		"confirm.replay.entry":      "  - %s no centro de custo %s: %s",
+		"confirm.transfer.intro":    "Você está prestes a TRANSFERIR os alertas de orçamento do centro de custo %s para %s, atualizando %d orçamentos no GitHub Enterprise.",
+		"consent.title":             "PRIMEIRO APPLY NA EMPRESA %q",
+		"consent.no_history":        "Nenhum apply anterior nesta empresa está registrado no diretório de exportação.",
+		"consent.review":            "As primeiras execuções movem a maior quantidade de usuários e repositórios; revise as alterações abaixo.",
+		"consent.affected":          "Centros de custo afetados: %d",
+		"consent.total":             "Total de %s a atribuir: %d",
+		"consent.to_create":         "Centros de custo a criar: %d",
+		"consent.deleted.delete":    "Centros de custo de equipes excluídas a excluir: %d",
+		"consent.deleted.empty":     "Centros de custo de equipes excluídas a esvaziar: %d",
+		"consent.largest":           "Maiores alterações (%d de %d):",
+		"consent.type_slug":         "Digite o identificador da empresa (%s) para continuar: ",
+		"conflict.title":            "%s está em %d equipes mapeadas com centros de custo diferentes:",
+		"conflict.option":           "  %d) %s (equipe %s)",
+		"conflict.current":          "  %d) %s (equipe %s) [atual: estratégia de conflito]",
+		"conflict.choose":           "Escolha 1-%d, Enter para manter o atual, ou s para parar de perguntar: ",
+		"conflict.remember":         "Lembrar esta escolha em %s? (sim/não): ",
+		"conflict.no_file":          "Defina cost_center.overrides_file para lembrar as escolhas; esta vale apenas para esta execução.",
+		"summary.assign.title":      "=== Resumo da atribuição ===",
+		"summary.assign.pru":        "PRUs permitidos (%s): %d usuários",
+		"summary.assign.no_pru":     "Sem PRUs (%s): %d usuários",
+		"summary.assign.server":     "Conectados a servidor (%s): %d usuários",
+		"summary.assign.wind_down":  "Cancelamento pendente, desativação gradual (%s): %d usuários",
+		"summary.assign.skipped":    "Cancelamento pendente, ignorados: %d usuários",
+		"summary.assign.total":      "Total: %d usuários",
+		"summary.budget.title":      "=== Impacto no orçamento (Copilot, aprox. %s por licença/mês) ===",
+		"summary.budget.row":        "  %s: %d -> %d licenças, aprox. %s -> %s de um orçamento de %s (margem %s)",
+		"summary.budget.configured": "[orçamento configurado, ainda não criado]",
+		"summary.budget.exceeds":    "EXCEDE O ORÇAMENTO",
+		"summary.budgets.title":     "=== Alterações de orçamentos (--create-budgets) ===",
+		"summary.budgets.create":    "  + %s: criar orçamento de %s de %s",
+		"summary.budgets.update":    "  ~ %s: atualizar orçamento de %s %s -> %s",
+		"summary.budgets.none":      "  Nenhuma alteração de orçamentos.",
+		"summary.budgets.allows":    "(o uso continua após o valor)",
+		"summary.budgets.stops":     "(o uso para no valor)",
+		"summary.budgets.alerts":    "(alertas em %d%%)",
+		"summary.combined.title":    "=== Resumo combinado da atribuição ===",
+		"summary.success.title":     "RESUMO DOS RESULTADOS",
+		"summary.success.ccs":       "CENTROS DE CUSTO (%s):",
+		"summary.success.no_pru":    "  Sem excedentes de PRU: %s",
+		"summary.success.pru":       "  Excedentes de PRU permitidos: %s",
+		"summary.success.users":     "ESTATÍSTICAS DE USUÁRIOS:",
+		"summary.success.processed": "  Total de usuários processados: %d",
+		"summary.success.incr":      "  Processamento incremental: %d de %d usuários no total",
+		"summary.success.pending":   "  Licenças com cancelamento pendente: %d usuários (tratamento: %s)",
+		"summary.success.rate":      "  Taxa de atribuições bem-sucedidas: %d/%d usuários",
+		"summary.success.failed":    "  Atribuições com falha: %d usuários",
+		"summary.success.by_cc":     "RESULTADOS POR CENTRO DE CUSTO:",
+		"summary.success.cc_result": "  %s: %d atribuídos, %d com falha",
+		"summary.repos.title":       "RESUMO DA ATRIBUIÇÃO DE REPOSITÓRIOS",
+		"summary.customprop.title":  "RESUMO DA ATRIBUIÇÃO POR PROPRIEDADES PERSONALIZADAS",
+		"summary.repos.total":       "Total de repositórios na organização: %d",
+		"summary.repos.mappings":    "Mapeamentos processados: %d / %d",
+		"summary.repos.ccs":         "Centros de custo processados: %d / %d",
+		"summary.repos.cc":          "Centro de custo: %s",
+		"summary.repos.property":    "  Propriedade: %s",
+		"summary.repos.values":      "  Valores:     %s",
+		"summary.repos.topics":      "  Tópicos:     %s",
+		"summary.repos.names":       "  Nomes:       %s",
+		"summary.repos.filters":     "  Filtros (E):",
+		"summary.repos.matched":     "  Correspondentes: %d repositórios",
+		"summary.repos.assigned":    "  Atribuídos:      %d repositórios",
+		"summary.repos.current":     "  Já no centro de custo: %d repositórios",
+		"summary.repos.elsewhere":   "  Ignorados (em outros centros de custo): %d repositórios (%s)",
+		"summary.repos.unchecked":   "  Ignorados (falha ao consultar o centro de custo): %d repositórios (%s)",
+		"summary.repos.unmatched":   "  Não correspondem mais: %d repositórios (%s)",
+		"summary.repos.removed":     "  Removidos:   %d repositórios que não correspondem mais",
+		"summary.repos.ok":          "  Status:      Sucesso",
+		"summary.repos.failed":      "  Status:      Falhou — %s",
+		"rollback.title_snapshot":   "Reversão da execução %s (alterações até o snapshot %s)",
+		"rollback.title_now":        "Reversão da execução %s (alterações até agora)",
+		"rollback.nothing":          "Nada a reverter.",
+		"rollback.skipped":          "Ignorados (%d):",
+		"rollback.total":            "Total: %d a reverter, %d ignorados",
+	},
+}
@@ -0,0 +1,98 @@
+// Package i18n is the message catalog for the confirmation prompts and run
+// summaries operators read and keep for audits.  English is the source
+// language; Spanish and Portuguese are bundled.  Log messages are not
+// translated so they stay searchable.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Supported languages.
+const (
+	English    = "en"
+	Spanish    = "es"
+	Portuguese = "pt"
+)
+
+// current is the language T translates into.
+var current = English
+
+// Languages returns the supported language codes, sorted.
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		langs = append(langs, l)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Supported reports whether lang is a supported language code.
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// SetLanguage selects the language of later T calls.
+func SetLanguage(lang string) error {
+	if !Supported(lang) {
+		return fmt.Errorf("unsupported language %q: must be one of %s", lang, strings.Join(Languages(), ", "))
+	}
+	current = lang
+	return nil
+}
+
+// Language returns the selected language code.
+func Language() string {
+	return current
+}
+
+// FromLocale returns the supported language of the POSIX locale (LC_ALL,
+// then LC_MESSAGES, then LANG, e.g. "es_MX.UTF-8"), or English.
+func FromLocale() string {
+	for _, k := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		v := os.Getenv(k)
+		if v == "" {
+			continue
+		}
+		lang := strings.ToLower(v)
+		if i := strings.IndexAny(lang, "_.@-"); i >= 0 {
+			lang = lang[:i]
+		}
+		if Supported(lang) {
+			return lang
+		}
+		return English
+	}
+	return English
+}
+
+// T returns the message key in the selected language, formatted with args
+// like fmt.Sprintf.  Messages missing from a catalog fall back to English,
+// and unknown keys are returned as is.
+func T(key string, args ...any) string {
+	msg, ok := catalogs[current][key]
+	if !ok {
+		if msg, ok = catalogs[English][key]; !ok {
+			msg = key
+		}
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// unaccent lets operators answer prompts without typing accents ("si").
+var unaccent = strings.NewReplacer("í", "i", "ã", "a")
+
+// IsYes reports whether answer confirms a prompt: "yes" in any language, or
+// the selected language's word for it, with or without accents.
+func IsYes(answer string) bool {
+	a := unaccent.Replace(strings.TrimSpace(strings.ToLower(answer)))
+	return a == "yes" || a == unaccent.Replace(T("answer.yes"))
+}
@@ -0,0 +1,92 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+// verbPattern matches fmt verbs, ignoring escaped percent signs.
+var verbPattern = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z]`)
+
+func TestCatalogs_MatchEnglish(t *testing.T) {
+	for lang, catalog := range catalogs {
+		if lang == English {
+			continue
+		}
+		for key, en := range catalogs[English] {
+			msg, ok := catalog[key]
+			if !ok {
+				t.Errorf("%s: missing %q", lang, key)
+				continue
+			}
+			if got, want := verbPattern.FindAllString(msg, -1), verbPattern.FindAllString(en, -1); !slices.Equal(got, want) {
+				t.Errorf("%s %q: verbs %v, want %v", lang, key, got, want)
+			}
+		}
+		for key := range catalog {
+			if _, ok := catalogs[English][key]; !ok {
+				t.Errorf("%s: %q is not in the English catalog", lang, key)
+			}
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	t.Cleanup(func() { current = English })
+
+	if got := T("summary.assign.total", 3); got != "Total: 3 users" {
+		t.Errorf("en = %q", got)
+	}
+	if err := SetLanguage(Spanish); err != nil {
+		t.Fatal(err)
+	}
+	if got := T("summary.assign.total", 3); got != "Total: 3 usuarios" {
+		t.Errorf("es = %q", got)
+	}
+	if got := T("no.such.key"); got != "no.such.key" {
+		t.Errorf("unknown key = %q", got)
+	}
+	if err := SetLanguage("fr"); err == nil {
+		t.Error("expected an error for an unsupported language")
+	}
+}
+
+func TestIsYes(t *testing.T) {
+	t.Cleanup(func() { current = English })
+
+	current = Portuguese
+	for _, a := range []string{"yes", " SIM ", "sim"} {
+		if !IsYes(a) {
+			t.Errorf("pt IsYes(%q) = false", a)
+		}
+	}
+	current = Spanish
+	for _, a := range []string{"sí", "si", "Yes"} {
+		if !IsYes(a) {
+			t.Errorf("es IsYes(%q) = false", a)
+		}
+	}
+	for _, a := range []string{"", "no", "y", "sim"} {
+		if IsYes(a) {
+			t.Errorf("es IsYes(%q) = true", a)
+		}
+	}
+}
+
+func TestFromLocale(t *testing.T) {
+	for _, tc := range []struct{ lcAll, lang, want string }{
+		{"", "es_MX.UTF-8", Spanish},
+		{"pt_BR.UTF-8", "es_MX.UTF-8", Portuguese},
+		{"", "fr_FR.UTF-8", English},
+		{"", "", English},
+		{"", "C", English},
+	} {
+		t.Setenv("LC_ALL", tc.lcAll)
+		t.Setenv("LC_MESSAGES", "")
+		t.Setenv("LANG", tc.lang)
+		if got := FromLocale(); got != tc.want {
+			t.Errorf("LC_ALL=%q LANG=%q: FromLocale = %q, want %q", tc.lcAll, tc.lang, got, tc.want)
+		}
+	}
+}
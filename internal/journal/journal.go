@@ -0,0 +1,268 @@
+// Package journal persists cost center writes that failed so the next run
+// can act on them.  Transient failures (5xx or rate limits that outlasted
+// the client's retries, network errors) are kept apart from permanent ones
+// (4xx) because only the former are worth re-attempting automatically.
+// The journal also keeps the history of budget alert handoffs.
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultFile is the journal filename inside the export directory.
+	DefaultFile = "retry_journal.json"
+	// currentVersion is the journal format version.
+	currentVersion = 1
+)
+
+// Operations recorded in the journal.
+const (
+	OpAddUsers           = "add_users"
+	OpAddRepositories    = "add_repositories"
+	OpRemoveUsers        = "remove_users"
+	OpRemoveRepositories = "remove_repositories"
+)
+
+// Entry is one failed write.
+type Entry struct {
+	Op           string    `json:"op"` // one of the Op constants
+	CostCenterID string    `json:"cost_center_id"`
+	Resources    []string  `json:"resources"`
+	Error        string    `json:"error"`
+	FailedAt     time.Time `json:"failed_at"`
+	// Attempts counts how many runs tried the write, including the first.
+	Attempts int `json:"attempts"`
+}
+
+// Handoff is one transfer of a cost center's budget alerts.
+type Handoff struct {
+	CostCenterID string    `json:"cost_center_id"`
+	CostCenter   string    `json:"cost_center"`
+	From         []string  `json:"from,omitempty"` // empty: every previous owner
+	To           []string  `json:"to"`
+	Budgets      int       `json:"budgets_updated"`
+	Error        string    `json:"error,omitempty"`
+	At           time.Time `json:"at"`
+}
+
+// journalData is the on-disk JSON structure.
+type journalData struct {
+	Version   int       `json:"version"`
+	Transient []Entry   `json:"transient"`
+	Permanent []Entry   `json:"permanent"`
+	Handoffs  []Handoff `json:"handoffs,omitempty"`
+}
+
+// Journal is a file-backed record of failed writes.
+type Journal struct {
+	mu       sync.Mutex
+	filePath string
+	data     journalData
+	log      *slog.Logger
+}
+
+// Open loads the journal from dir, or starts an empty one when the file is
+// missing or unreadable.
+func Open(dir string, logger *slog.Logger) *Journal {
+	j := &Journal{
+		filePath: filepath.Join(dir, DefaultFile),
+		log:      logger,
+		data:     journalData{Version: currentVersion},
+	}
+	if err := j.load(); err != nil && !os.IsNotExist(err) {
+		j.log.Warn("Could not read retry journal, starting fresh", "path", j.filePath, "error", err)
+	}
+	return j
+}
+
+// Record adds a failed write to the transient or permanent list and flushes
+// the journal to disk.
+func (j *Journal) Record(e Entry, transient bool) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if e.FailedAt.IsZero() {
+		e.FailedAt = time.Now().UTC()
+	}
+	if e.Attempts == 0 {
+		e.Attempts = 1
+	}
+	if transient {
+		j.data.Transient = append(j.data.Transient, e)
+	} else {
+		j.data.Permanent = append(j.data.Permanent, e)
+	}
+	j.log.Debug("Recorded failed write", "op", e.Op, "cost_center_id", e.CostCenterID,
+		"resources", len(e.Resources), "transient", transient)
+	return j.save()
+}
+
+// Resolve removes the transient entry e once its replay has succeeded.
+// Entries stay in the journal until then, so a replay that is interrupted
+// leaves them for the next run.
+func (j *Journal) Resolve(e Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	i := indexOf(j.data.Transient, e)
+	if i < 0 {
+		return nil
+	}
+	j.data.Transient = slices.Delete(j.data.Transient, i, i+1)
+	return j.save()
+}
+
+// Drop removes resources from the transient entry e without replaying
+// them, and the entry itself once none remain.
+func (j *Journal) Drop(e Entry, resources []string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	i := indexOf(j.data.Transient, e)
+	if i < 0 {
+		return nil
+	}
+	var kept []string
+	for _, r := range j.data.Transient[i].Resources {
+		if !slices.Contains(resources, r) {
+			kept = append(kept, r)
+		}
+	}
+	if len(kept) == 0 {
+		j.data.Transient = slices.Delete(j.data.Transient, i, i+1)
+	} else {
+		j.data.Transient[i].Resources = kept
+	}
+	return j.save()
+}
+
+// Retried records that the replay of the transient entry e failed again
+// with errMsg: its attempt count goes up, and it moves to the permanent
+// entries when the new failure is not transient.
+func (j *Journal) Retried(e Entry, errMsg string, transient bool) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	i := indexOf(j.data.Transient, e)
+	if i < 0 {
+		return nil
+	}
+	e = j.data.Transient[i]
+	e.Error = errMsg
+	e.Attempts++
+	if transient {
+		j.data.Transient[i] = e
+	} else {
+		j.data.Transient = slices.Delete(j.data.Transient, i, i+1)
+		j.data.Permanent = append(j.data.Permanent, e)
+	}
+	return j.save()
+}
+
+// ClearPermanent forgets the permanent failures in reported, once the
+// caller has reported them.  Permanent failures recorded since are kept.
+func (j *Journal) ClearPermanent(reported []Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	kept := j.data.Permanent[:0]
+	for _, e := range j.data.Permanent {
+		if indexOf(reported, e) < 0 {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) == len(j.data.Permanent) {
+		return nil
+	}
+	j.data.Permanent = kept
+	return j.save()
+}
+
+// indexOf returns the index of the entry of entries for the same write as
+// e, or -1.
+func indexOf(entries []Entry, e Entry) int {
+	return slices.IndexFunc(entries, func(x Entry) bool {
+		return x.Op == e.Op && x.CostCenterID == e.CostCenterID &&
+			x.FailedAt.Equal(e.FailedAt) && slices.Equal(x.Resources, e.Resources)
+	})
+}
+
+// RecordHandoff appends an alert handoff and flushes the journal to
+// disk.  Handoffs are kept across runs; they are never replayed or cleared.
+func (j *Journal) RecordHandoff(h Handoff) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if h.At.IsZero() {
+		h.At = time.Now().UTC()
+	}
+	j.data.Handoffs = append(j.data.Handoffs, h)
+	return j.save()
+}
+
+// Handoffs returns a copy of the recorded alert handoffs.
+func (j *Journal) Handoffs() []Handoff {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]Handoff(nil), j.data.Handoffs...)
+}
+
+// Transient returns a copy of the transient entries.
+func (j *Journal) Transient() []Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]Entry(nil), j.data.Transient...)
+}
+
+// Permanent returns a copy of the permanent entries.
+func (j *Journal) Permanent() []Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]Entry(nil), j.data.Permanent...)
+}
+
+// FilePath returns the path to the journal file.
+func (j *Journal) FilePath() string {
+	return j.filePath
+}
+
+// load reads the journal file from disk.
+func (j *Journal) load() error {
+	data, err := os.ReadFile(j.filePath)
+	if err != nil {
+		return err
+	}
+	var d journalData
+	if err := json.Unmarshal(data, &d); err != nil {
+		return fmt.Errorf("decoding journal file: %w", err)
+	}
+	if d.Version != currentVersion {
+		return fmt.Errorf("journal version %d, expected %d", d.Version, currentVersion)
+	}
+	j.data = d
+	j.log.Debug("Retry journal loaded", "transient", len(d.Transient), "permanent", len(d.Permanent))
+	return nil
+}
+
+// save writes the journal to disk, creating the directory if needed.
+func (j *Journal) save() error {
+	if err := os.MkdirAll(filepath.Dir(j.filePath), 0o755); err != nil {
+		return fmt.Errorf("creating journal directory: %w", err)
+	}
+	data, err := json.MarshalIndent(j.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding journal: %w", err)
+	}
+	if err := os.WriteFile(j.filePath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing journal file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package journal
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+}
+
+func TestJournal_RecordAndReload(t *testing.T) {
+	dir := t.TempDir()
+	j := Open(dir, testLogger())
+
+	if err := j.Record(Entry{Op: OpAddUsers, CostCenterID: "cc-1", Resources: []string{"alice"}, Error: "502"}, true); err != nil {
+		t.Fatalf("Record transient: %v", err)
+	}
+	if err := j.Record(Entry{Op: OpAddRepositories, CostCenterID: "cc-2", Resources: []string{"o/r"}, Error: "422"}, false); err != nil {
+		t.Fatalf("Record permanent: %v", err)
+	}
+
+	reloaded := Open(dir, testLogger())
+	tr := reloaded.Transient()
+	if len(tr) != 1 || tr[0].CostCenterID != "cc-1" || tr[0].Attempts != 1 || tr[0].FailedAt.IsZero() {
+		t.Errorf("transient = %+v", tr)
+	}
+	if p := reloaded.Permanent(); len(p) != 1 || p[0].Op != OpAddRepositories {
+		t.Errorf("permanent = %+v", p)
+	}
+}
+
+func TestJournal_ResolveAndRetried(t *testing.T) {
+	dir := t.TempDir()
+	j := Open(dir, testLogger())
+	_ = j.Record(Entry{Op: OpAddUsers, CostCenterID: "cc-1", Resources: []string{"alice"}}, true)
+	_ = j.Record(Entry{Op: OpRemoveUsers, CostCenterID: "cc-2", Resources: []string{"bob"}}, true)
+	_ = j.Record(Entry{Op: OpAddRepositories, CostCenterID: "cc-3", Resources: []string{"o/r"}}, true)
+
+	// Entries read back from disk, as a replay in a later run sees them.
+	entries := Open(dir, testLogger()).Transient()
+	if len(entries) != 3 {
+		t.Fatalf("transient = %d entries, want 3", len(entries))
+	}
+	if err := j.Resolve(entries[0]); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if err := j.Retried(entries[1], "502", true); err != nil {
+		t.Fatalf("Retried transient: %v", err)
+	}
+	if err := j.Retried(entries[2], "422", false); err != nil {
+		t.Fatalf("Retried permanent: %v", err)
+	}
+
+	reloaded := Open(dir, testLogger())
+	tr := reloaded.Transient()
+	if len(tr) != 1 || tr[0].Op != OpRemoveUsers || tr[0].Attempts != 2 || tr[0].Error != "502" {
+		t.Errorf("transient = %+v; want the removal with 2 attempts", tr)
+	}
+	if p := reloaded.Permanent(); len(p) != 1 || p[0].CostCenterID != "cc-3" || p[0].Attempts != 2 {
+		t.Errorf("permanent = %+v; want cc-3 with 2 attempts", p)
+	}
+}
+
+func TestJournal_ClearPermanent(t *testing.T) {
+	dir := t.TempDir()
+	j := Open(dir, testLogger())
+	_ = j.Record(Entry{Op: OpAddUsers, CostCenterID: "cc-1"}, false)
+	_ = j.Record(Entry{Op: OpAddUsers, CostCenterID: "cc-2"}, true)
+	reported := j.Permanent()
+	_ = j.Record(Entry{Op: OpAddUsers, CostCenterID: "cc-3"}, false)
+
+	if err := j.ClearPermanent(reported); err != nil {
+		t.Fatalf("ClearPermanent: %v", err)
+	}
+	reloaded := Open(dir, testLogger())
+	if p := reloaded.Permanent(); len(p) != 1 || p[0].CostCenterID != "cc-3" || len(reloaded.Transient()) != 1 {
+		t.Errorf("permanent = %+v, transient = %d; want only the unreported cc-3 and 1", p, len(reloaded.Transient()))
+	}
+}
+
+func TestOpen_CorruptFileStartsFresh(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/"+DefaultFile, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(Open(dir, testLogger()).Transient()); n != 0 {
+		t.Errorf("transient = %d, want 0", n)
+	}
+}
+
+func TestJournal_RecordHandoff(t *testing.T) {
+	dir := t.TempDir()
+	j := Open(dir, testLogger())
+	if err := j.Record(Entry{Op: OpAddUsers, CostCenterID: "cc-1", Resources: []string{"alice"}}, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.RecordHandoff(Handoff{CostCenterID: "cc-1", CostCenter: "Platform", From: []string{"carol"}, To: []string{"alice"}, Budgets: 2}); err != nil {
+		t.Fatalf("RecordHandoff: %v", err)
+	}
+	if err := j.Resolve(j.Transient()[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	h := Open(dir, testLogger()).Handoffs()
+	if len(h) != 1 || h[0].CostCenter != "Platform" || h[0].Budgets != 2 || h[0].At.IsZero() {
+		t.Errorf("handoffs = %+v", h)
+	}
+}
+
+func TestJournal_Drop(t *testing.T) {
+	dir := t.TempDir()
+	j := Open(dir, testLogger())
+	_ = j.Record(Entry{Op: OpAddUsers, CostCenterID: "cc-1", Resources: []string{"alice", "bob"}}, true)
+	_ = j.Record(Entry{Op: OpRemoveUsers, CostCenterID: "cc-2", Resources: []string{"carol"}}, true)
+
+	entries := j.Transient()
+	if err := j.Drop(entries[0], []string{"alice"}); err != nil {
+		t.Fatalf("Drop: %v", err)
+	}
+	if err := j.Drop(entries[1], []string{"carol"}); err != nil {
+		t.Fatalf("Drop: %v", err)
+	}
+
+	tr := Open(dir, testLogger()).Transient()
+	if len(tr) != 1 || tr[0].CostCenterID != "cc-1" || len(tr[0].Resources) != 1 || tr[0].Resources[0] != "bob" {
+		t.Errorf("transient = %+v; want only bob left for cc-1", tr)
+	}
+}
@@ -0,0 +1,125 @@
+// Package progress writes machine-readable progress events, one JSON object
+// per line (NDJSON), for tools that wrap the CLI and render their own
+// progress bars.  Logs and summaries are unaffected.
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Phases emitted during an assign run.
+const (
+	PhaseStart    = "start"    // total is the number of modes
+	PhaseSnapshot = "snapshot" // pre-apply membership snapshot
+	PhaseMode     = "mode"     // done/total modes, message is the mode
+	PhaseApply    = "apply"    // done/total cost centers, message is the ID
+	PhaseReplay   = "replay"   // retry journal replay, after the modes
+	PhaseDone     = "done"     // message is "ok" or the error
+)
+
+// Event is one progress line.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Phase   string    `json:"phase"`
+	Done    int       `json:"done"`
+	Total   int       `json:"total"`
+	Message string    `json:"message,omitempty"`
+}
+
+// Reporter writes events to a file or file descriptor.  A nil Reporter
+// discards them, so callers need not check whether progress is enabled.
+type Reporter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer // nil for inherited file descriptors
+	err    error     // first write error; later events are dropped
+}
+
+// New returns a Reporter writing to w.
+func New(w io.Writer) *Reporter {
+	return &Reporter{w: w}
+}
+
+// Open returns a Reporter for target: "fd:N" writes to an inherited file
+// descriptor (e.g. one a wrapping process opened as a pipe), anything else
+// is a file path, created or truncated.  An empty target returns nil.
+func Open(target string) (*Reporter, error) {
+	if target == "" {
+		return nil, nil
+	}
+	if n, ok := strings.CutPrefix(target, "fd:"); ok {
+		fd, err := strconv.Atoi(n)
+		if err != nil || fd < 1 {
+			return nil, fmt.Errorf("invalid progress target %q: want fd:N with N >= 1", target)
+		}
+		f := os.NewFile(uintptr(fd), target)
+		if f == nil {
+			return nil, fmt.Errorf("progress file descriptor %d is not open", fd)
+		}
+		if _, err := f.Stat(); err != nil {
+			return nil, fmt.Errorf("progress file descriptor %d is not open: %w", fd, err)
+		}
+		return New(f), nil
+	}
+	f, err := os.Create(target)
+	if err != nil {
+		return nil, fmt.Errorf("opening progress file: %w", err)
+	}
+	r := New(f)
+	r.closer = f
+	return r, nil
+}
+
+// Emit writes one event.  Each event is a single write so readers of a pipe
+// never see partial lines.  Write errors are remembered (see Close) rather
+// than returned, since progress must never fail a run.
+func (r *Reporter) Emit(phase string, done, total int, message string) {
+	if r == nil {
+		return
+	}
+	line, err := json.Marshal(Event{
+		Time:    time.Now().UTC(),
+		Phase:   phase,
+		Done:    done,
+		Total:   total,
+		Message: message,
+	})
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if _, err := r.w.Write(append(line, '\n')); err != nil {
+		r.err = err
+	}
+}
+
+// Close closes the progress file (inherited descriptors are left open) and
+// returns the first write error, if any.
+func (r *Reporter) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closer != nil {
+		if err := r.closer.Close(); err != nil && r.err == nil {
+			r.err = err
+		}
+		r.closer = nil
+	}
+	if r.err != nil {
+		return fmt.Errorf("writing progress events: %w", r.err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package progress
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEmit_WritesNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	r := New(&buf)
+	r.Emit(PhaseStart, 0, 2, "apply")
+	r.Emit(PhaseApply, 1, 3, "cc-1")
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var events []Event
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var e Event
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("line %q is not JSON: %v", sc.Text(), err)
+		}
+		events = append(events, e)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if e := events[1]; e.Phase != PhaseApply || e.Done != 1 || e.Total != 3 || e.Message != "cc-1" || e.Time.IsZero() {
+		t.Errorf("event = %+v", e)
+	}
+}
+
+func TestNilReporter(t *testing.T) {
+	var r *Reporter
+	r.Emit(PhaseDone, 1, 1, "ok")
+	if err := r.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if r, err := Open(""); r != nil || err != nil {
+		t.Errorf("Open(\"\") = %v, %v; want nil, nil", r, err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.ndjson")
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	r.Emit(PhaseDone, 1, 1, "ok")
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(data, []byte("\n")) || !bytes.Contains(data, []byte(`"phase":"done"`)) {
+		t.Errorf("file = %q", data)
+	}
+
+	for _, target := range []string{"fd:x", "fd:0", "fd:987654"} {
+		if _, err := Open(target); err == nil {
+			t.Errorf("Open(%q) succeeded, want error", target)
+		}
+	}
+}
+
+type failingWriter struct{ writes int }
+
+func (w *failingWriter) Write([]byte) (int, error) {
+	w.writes++
+	return 0, errors.New("broken pipe")
+}
+
+func TestEmit_StopsAfterWriteError(t *testing.T) {
+	w := &failingWriter{}
+	r := New(w)
+	r.Emit(PhaseStart, 0, 1, "")
+	r.Emit(PhaseDone, 1, 1, "ok")
+	if w.writes != 1 {
+		t.Errorf("writes = %d, want 1", w.writes)
+	}
+	if err := r.Close(); err == nil {
+		t.Error("Close should report the write error")
+	}
+}
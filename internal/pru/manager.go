@@ -12,34 +12,75 @@ import (
 
 	"github.com/renan-alm/gh-cost-center/internal/config"
 	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
 )
 
 // Manager handles PRU-based cost center assignment.
 type Manager struct {
 	noPRUCCID      string
 	pruAllowedCCID string
-	exceptions     map[string]bool // set of exception logins (lower-cased)
+	exceptions     map[string]bool       // set of exception logins (lower-cased)
+	patterns       []config.LoginPattern // glob and regex exception entries
 	log            *slog.Logger
+
+	// Server-connected (GHES via GitHub Connect) users.
+	serverSuffixes  []string // lower-cased login suffixes
+	segregateServer bool
+	serverCCID      string
+
+	// Users whose Copilot seat is pending cancellation.
+	pendingHandling string
+	windDownCCID    string
 }
 
 // NewManager creates a PRU manager from the loaded configuration.
 func NewManager(cfg *config.Manager, logger *slog.Logger) *Manager {
 	exceptions := make(map[string]bool, len(cfg.PRUsExceptionUsers))
+	var patterns []config.LoginPattern
 	for _, u := range cfg.PRUsExceptionUsers {
-		exceptions[strings.ToLower(u)] = true
+		p, err := config.ParseLoginPattern(u)
+		if err != nil {
+			logger.Warn("Ignoring invalid PRU exception entry", "entry", u, "error", err)
+			continue
+		}
+		if login, ok := p.Exact(); ok {
+			exceptions[login] = true
+		} else {
+			patterns = append(patterns, p)
+		}
 	}
 
 	logger.Info("Initialized PRU manager",
 		"exception_users", len(exceptions),
+		"exception_patterns", len(patterns),
 		"no_pru_cc", cfg.NoPRUsCostCenterID,
 		"pru_allowed_cc", cfg.PRUsAllowedCostCenterID,
 	)
 
+	suffixes := make([]string, 0, len(cfg.ServerLoginSuffixes))
+	for _, s := range cfg.ServerLoginSuffixes {
+		suffixes = append(suffixes, strings.ToLower(s))
+	}
+	serverCCID := cfg.ServerCostCenterID
+	if serverCCID == "" {
+		serverCCID = cfg.ServerCostCenterName // placeholder until resolved
+	}
+	windDownCCID := cfg.WindDownCostCenterID
+	if windDownCCID == "" {
+		windDownCCID = cfg.WindDownCostCenterName // placeholder until resolved
+	}
+
 	return &Manager{
-		noPRUCCID:      cfg.NoPRUsCostCenterID,
-		pruAllowedCCID: cfg.PRUsAllowedCostCenterID,
-		exceptions:     exceptions,
-		log:            logger,
+		noPRUCCID:       cfg.NoPRUsCostCenterID,
+		pruAllowedCCID:  cfg.PRUsAllowedCostCenterID,
+		exceptions:      exceptions,
+		patterns:        patterns,
+		log:             logger,
+		serverSuffixes:  suffixes,
+		segregateServer: cfg.ServerUsersHandling == config.ServerUsersSegregate,
+		serverCCID:      serverCCID,
+		pendingHandling: cfg.PendingCancellationHandling,
+		windDownCCID:    windDownCCID,
 	}
 }
 
@@ -57,16 +98,126 @@ func (m *Manager) NoPRUCCID() string { return m.noPRUCCID }
 // PRUAllowedCCID returns the current PRU-allowed cost center ID.
 func (m *Manager) PRUAllowedCCID() string { return m.pruAllowedCCID }
 
-// IsException returns true if the login is in the PRU exception list.
+// SetServerCostCenterID updates the cost center ID for segregated
+// server-connected users once it has been resolved or created.
+func (m *Manager) SetServerCostCenterID(id string) {
+	m.serverCCID = id
+}
+
+// ServerCCID returns the cost center ID for segregated server-connected
+// users.
+func (m *Manager) ServerCCID() string { return m.serverCCID }
+
+// SegregatesServerUsers reports whether server-connected users go to their
+// own cost center.
+func (m *Manager) SegregatesServerUsers() bool { return m.segregateServer }
+
+// SetWindDownCostCenterID updates the cost center ID for users with a
+// pending seat cancellation once it has been resolved or created.
+func (m *Manager) SetWindDownCostCenterID(id string) {
+	m.windDownCCID = id
+}
+
+// WindDownCCID returns the cost center ID for users with a pending seat
+// cancellation.
+func (m *Manager) WindDownCCID() string { return m.windDownCCID }
+
+// WindsDownPendingCancellations reports whether users with a pending seat
+// cancellation go to the wind-down cost center.
+func (m *Manager) WindsDownPendingCancellations() bool {
+	return m.pendingHandling == config.PendingCancellationWindDown
+}
+
+// SkipsPendingCancellations reports whether users with a pending seat
+// cancellation are left out of assignment.
+func (m *Manager) SkipsPendingCancellations() bool {
+	return m.pendingHandling == config.PendingCancellationSkip
+}
+
+// IsPendingCancellation reports whether the user's Copilot seat is due to
+// be cancelled.
+func IsPendingCancellation(user github.CopilotUser) bool {
+	return user.PendingCancellationDate != ""
+}
+
+// Skips reports whether the user is left out of assignment: their seat is
+// pending cancellation and the policy is to skip such users.
+func (m *Manager) Skips(user github.CopilotUser) bool {
+	return m.SkipsPendingCancellations() && IsPendingCancellation(user)
+}
+
+// PendingCancellations returns the users whose seat is pending
+// cancellation.
+func PendingCancellations(users []github.CopilotUser) []github.CopilotUser {
+	var pending []github.CopilotUser
+	for _, u := range users {
+		if IsPendingCancellation(u) {
+			pending = append(pending, u)
+		}
+	}
+	return pending
+}
+
+// IsServerConnected reports whether the login belongs to a user whose
+// identity comes from a connected GHES instance.
+func (m *Manager) IsServerConnected(login string) bool {
+	_, ok := m.serverSuffix(login)
+	return ok
+}
+
+// NormalizeLogin lower-cases the login and strips a server-connected
+// suffix, so "Alice_GHES" and "alice" refer to the same person.
+func (m *Manager) NormalizeLogin(login string) string {
+	lower := strings.ToLower(login)
+	if suffix, ok := m.serverSuffix(login); ok {
+		return strings.TrimSuffix(lower, suffix)
+	}
+	return lower
+}
+
+// serverSuffix returns the configured suffix the login ends with.  A login
+// that is only the suffix does not count.
+func (m *Manager) serverSuffix(login string) (string, bool) {
+	lower := strings.ToLower(login)
+	for _, s := range m.serverSuffixes {
+		if len(lower) > len(s) && strings.HasSuffix(lower, s) {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// IsException returns true if the login, normalized, is in the PRU
+// exception list or matches one of its glob or regex patterns.
 func (m *Manager) IsException(login string) bool {
-	return m.exceptions[strings.ToLower(login)]
+	normalized := m.NormalizeLogin(login)
+	if m.exceptions[normalized] {
+		return true
+	}
+	for _, p := range m.patterns {
+		if p.Match(normalized) {
+			return true
+		}
+	}
+	return false
 }
 
-// AssignCostCenter returns the cost center ID for a given user.
+// AssignCostCenter returns the cost center ID for a given user.  Users the
+// manager Skips are not assigned by callers.
 //
-//	exception user → pru_allowed_cost_center_id
-//	everyone else  → no_prus_cost_center_id
+//	pending cancellation (wind_down)  → pending_cancellation.cost_center_id
+//	server-connected user (segregate) → server_connected.cost_center_id
+//	exception user                    → pru_allowed_cost_center_id
+//	everyone else                     → no_prus_cost_center_id
 func (m *Manager) AssignCostCenter(user github.CopilotUser) string {
+	if m.WindsDownPendingCancellations() && IsPendingCancellation(user) {
+		m.log.Debug("User seat is pending cancellation", "user", user.Login, "cc", m.windDownCCID)
+		return m.windDownCCID
+	}
+	if m.segregateServer && m.IsServerConnected(user.Login) {
+		m.log.Debug("User is server-connected", "user", user.Login, "cc", m.serverCCID)
+		return m.serverCCID
+	}
 	if m.IsException(user.Login) {
 		m.log.Debug("User is PRU exception", "user", user.Login, "cc", m.pruAllowedCCID)
 		return m.pruAllowedCCID
@@ -76,23 +227,37 @@ func (m *Manager) AssignCostCenter(user github.CopilotUser) string {
 }
 
 // AssignmentGroups builds the desired {cost_center_id: [usernames]} map for a
-// list of users.
+// list of users, leaving out skipped users.
 func (m *Manager) AssignmentGroups(users []github.CopilotUser) map[string][]string {
 	groups := map[string][]string{
 		m.pruAllowedCCID: {},
 		m.noPRUCCID:      {},
 	}
+	if m.segregateServer {
+		groups[m.serverCCID] = []string{}
+	}
+	if m.WindsDownPendingCancellations() {
+		groups[m.windDownCCID] = []string{}
+	}
 	for _, u := range users {
+		if m.Skips(u) {
+			m.log.Debug("Skipping user with pending seat cancellation", "user", u.Login, "date", u.PendingCancellationDate)
+			continue
+		}
 		cc := m.AssignCostCenter(u)
 		groups[cc] = append(groups[cc], u.Login)
 	}
 	return groups
 }
 
-// GenerateSummary returns a cost-center → user-count map for display.
+// GenerateSummary returns a cost-center → user-count map for display,
+// leaving out skipped users.
 func (m *Manager) GenerateSummary(users []github.CopilotUser) map[string]int {
 	summary := make(map[string]int)
 	for _, u := range users {
+		if m.Skips(u) {
+			continue
+		}
 		cc := m.AssignCostCenter(u)
 		summary[cc]++
 	}
@@ -126,10 +291,10 @@ func (m *Manager) PrintConfigSummary(cfg *config.Manager, autoCreate bool) {
 		fmt.Printf("PRUs Allowed Cost Center: New cost center %q to be created\n", cfg.PRUsAllowedCostCenterName)
 	} else {
 		fmt.Printf("No PRUs Cost Center: %s\n", m.noPRUCCID)
-		printCCURL(cfg.Enterprise, m.noPRUCCID)
+		printCCURL(cfg, m.noPRUCCID)
 
 		fmt.Printf("PRUs Allowed Cost Center: %s\n", m.pruAllowedCCID)
-		printCCURL(cfg.Enterprise, m.pruAllowedCCID)
+		printCCURL(cfg, m.pruAllowedCCID)
 	}
 
 	fmt.Printf("PRUs Exception Users (%d):\n", len(cfg.PRUsExceptionUsers))
@@ -141,64 +306,69 @@ func (m *Manager) PrintConfigSummary(cfg *config.Manager, autoCreate bool) {
 }
 
 // ShowSuccessSummary prints a comprehensive success summary at the end of a
-// run, including cost center URLs, user statistics, and assignment results.
-func ShowSuccessSummary(cfg *config.Manager, users []github.CopilotUser, originalCount *int, results map[string]map[string]bool, applied bool) {
+// run, including cost center URLs, user statistics, and assignment results
+// per cost center, by name and ID.
+func ShowSuccessSummary(cfg *config.Manager, users []github.CopilotUser, originalCount *int, results []github.CostCenterResult, applied bool) {
 	fmt.Println()
 	fmt.Println(strings.Repeat("=", 60))
-	fmt.Println("SUCCESS SUMMARY")
+	fmt.Println(i18n.T("summary.success.title"))
 	fmt.Println(strings.Repeat("=", 60))
 
 	// Cost center links.
 	if cfg.Enterprise != "" && !strings.HasPrefix(cfg.Enterprise, "REPLACE_WITH_") {
-		fmt.Printf("\nCOST CENTERS (%s):\n", cfg.Enterprise)
+		fmt.Println("\n" + i18n.T("summary.success.ccs", cfg.Enterprise))
 		if !strings.HasPrefix(cfg.NoPRUsCostCenterID, "REPLACE_WITH_") {
-			fmt.Printf("  No PRU Overages: %s\n", cfg.NoPRUsCostCenterID)
-			fmt.Printf("     -> https://github.com/enterprises/%s/billing/cost_centers/%s\n",
-				cfg.Enterprise, cfg.NoPRUsCostCenterID)
+			fmt.Println(i18n.T("summary.success.no_pru", cfg.NoPRUsCostCenterID))
+			fmt.Printf("     -> %s\n", cfg.CostCenterURL(cfg.NoPRUsCostCenterID))
 		}
 		if !strings.HasPrefix(cfg.PRUsAllowedCostCenterID, "REPLACE_WITH_") {
-			fmt.Printf("  PRU Overages Allowed: %s\n", cfg.PRUsAllowedCostCenterID)
-			fmt.Printf("     -> https://github.com/enterprises/%s/billing/cost_centers/%s\n",
-				cfg.Enterprise, cfg.PRUsAllowedCostCenterID)
+			fmt.Println(i18n.T("summary.success.pru", cfg.PRUsAllowedCostCenterID))
+			fmt.Printf("     -> %s\n", cfg.CostCenterURL(cfg.PRUsAllowedCostCenterID))
 		}
 	}
 
 	// User statistics.
 	if len(users) > 0 {
-		fmt.Printf("\nUSER STATISTICS:\n")
-		fmt.Printf("  Total users processed: %d\n", len(users))
+		fmt.Println("\n" + i18n.T("summary.success.users"))
+		fmt.Println(i18n.T("summary.success.processed", len(users)))
 		if originalCount != nil {
-			fmt.Printf("  Incremental processing: %d of %d total users\n", len(users), *originalCount)
+			fmt.Println(i18n.T("summary.success.incr", len(users), *originalCount))
+		}
+		if pending := len(PendingCancellations(users)); pending > 0 {
+			fmt.Println(i18n.T("summary.success.pending", pending, cfg.PendingCancellationHandling))
 		}
 
 		if results != nil && applied {
 			totalAttempted := 0
 			totalSuccessful := 0
-			for _, ccResults := range results {
-				for _, ok := range ccResults {
-					totalAttempted++
-					if ok {
-						totalSuccessful++
-					}
-				}
+			for _, r := range results {
+				totalAttempted += r.Succeeded + r.Failed
+				totalSuccessful += r.Succeeded
 			}
-			fmt.Printf("  Assignment success rate: %d/%d users\n", totalSuccessful, totalAttempted)
+			fmt.Println(i18n.T("summary.success.rate", totalSuccessful, totalAttempted))
 			if totalSuccessful < totalAttempted {
-				fmt.Printf("  Failed assignments: %d users\n", totalAttempted-totalSuccessful)
+				fmt.Println(i18n.T("summary.success.failed", totalAttempted-totalSuccessful))
 			}
 		}
 	}
 
+	if len(results) > 0 && applied {
+		fmt.Println("\n" + i18n.T("summary.success.by_cc"))
+		for _, r := range results {
+			fmt.Println(i18n.T("summary.success.cc_result", r.Label(), r.Succeeded, r.Failed))
+		}
+	}
+
 	fmt.Println(strings.Repeat("=", 60))
 }
 
 // printCCURL prints the cost center URL if the IDs are not placeholders.
-func printCCURL(enterprise, ccID string) {
-	if enterprise == "" || strings.HasPrefix(enterprise, "REPLACE_WITH_") {
+func printCCURL(cfg *config.Manager, ccID string) {
+	if cfg.Enterprise == "" || strings.HasPrefix(cfg.Enterprise, "REPLACE_WITH_") {
 		return
 	}
 	if strings.HasPrefix(ccID, "REPLACE_WITH_") {
 		return
 	}
-	fmt.Printf("  -> https://github.com/enterprises/%s/billing/cost_centers/%s\n", enterprise, ccID)
+	fmt.Printf("  -> %s\n", cfg.CostCenterURL(ccID))
 }
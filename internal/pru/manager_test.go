@@ -91,6 +91,29 @@ func TestIsException(t *testing.T) {
 	}
 }
 
+func TestIsException_Patterns(t *testing.T) {
+	cfg := testConfig("cc1", "cc2", []string{"alice", "svc-*", "/^bot-[0-9]+$/"})
+	mgr := NewManager(cfg, testLogger())
+
+	for login, want := range map[string]bool{
+		"alice":      true,
+		"svc-build":  true,
+		"SVC-Deploy": true,
+		"my-svc-x":   false,
+		"bot-42":     true,
+		"BOT-7":      true,
+		"bot-x":      false,
+		"robot-1":    false,
+	} {
+		if got := mgr.IsException(login); got != want {
+			t.Errorf("IsException(%s) = %v; want %v", login, got, want)
+		}
+	}
+	if got := mgr.AssignCostCenter(github.CopilotUser{Login: "svc-ci"}); got != "cc2" {
+		t.Errorf("AssignCostCenter(svc-ci) = %q; want cc2", got)
+	}
+}
+
 func TestAssignmentGroups(t *testing.T) {
 	cfg := testConfig("cc-no-pru", "cc-pru-allowed", []string{"alice"})
 	mgr := NewManager(cfg, testLogger())
@@ -239,3 +262,90 @@ func TestNewManager_NilExceptions(t *testing.T) {
 		t.Error("IsException should return false when exception list is nil")
 	}
 }
+
+func TestServerConnected_Assign(t *testing.T) {
+	cfg := testConfig("cc-no-pru", "cc-pru-allowed", []string{"alice"})
+	cfg.ServerLoginSuffixes = []string{"_GHES"}
+	cfg.ServerUsersHandling = config.ServerUsersAssign
+	mgr := NewManager(cfg, testLogger())
+
+	if !mgr.IsServerConnected("Alice_ghes") || mgr.IsServerConnected("alice") || mgr.IsServerConnected("_ghes") {
+		t.Error("IsServerConnected should match the suffix case-insensitively, and not a bare suffix")
+	}
+	if got := mgr.NormalizeLogin("Alice_GHES"); got != "alice" {
+		t.Errorf("NormalizeLogin = %q; want alice", got)
+	}
+	// Exception matching uses the normalized login.
+	if got := mgr.AssignCostCenter(github.CopilotUser{Login: "alice_ghes"}); got != "cc-pru-allowed" {
+		t.Errorf("AssignCostCenter(alice_ghes) = %q; want cc-pru-allowed", got)
+	}
+	if got := mgr.AssignCostCenter(github.CopilotUser{Login: "bob_ghes"}); got != "cc-no-pru" {
+		t.Errorf("AssignCostCenter(bob_ghes) = %q; want cc-no-pru", got)
+	}
+}
+
+func TestServerConnected_Segregate(t *testing.T) {
+	cfg := testConfig("cc-no-pru", "cc-pru-allowed", []string{"alice"})
+	cfg.ServerLoginSuffixes = []string{"_ghes"}
+	cfg.ServerUsersHandling = config.ServerUsersSegregate
+	cfg.ServerCostCenterName = "GHES users"
+	mgr := NewManager(cfg, testLogger())
+
+	if mgr.ServerCCID() != "GHES users" {
+		t.Errorf("ServerCCID() = %q; want the name as a placeholder", mgr.ServerCCID())
+	}
+	mgr.SetServerCostCenterID("cc-ghes")
+
+	groups := mgr.AssignmentGroups([]github.CopilotUser{
+		{Login: "alice"}, {Login: "alice_ghes"}, {Login: "carol"},
+	})
+	if len(groups["cc-ghes"]) != 1 || groups["cc-ghes"][0] != "alice_ghes" {
+		t.Errorf("server group = %v; want [alice_ghes]", groups["cc-ghes"])
+	}
+	if len(groups["cc-pru-allowed"]) != 1 || len(groups["cc-no-pru"]) != 1 {
+		t.Errorf("groups = %v", groups)
+	}
+}
+
+func TestPendingCancellationHandling(t *testing.T) {
+	users := []github.CopilotUser{
+		{Login: "alice"},
+		{Login: "bob", PendingCancellationDate: "2026-10-31"},
+		{Login: "carol", PendingCancellationDate: "2026-10-31"},
+	}
+	tests := []struct {
+		handling string
+		want     map[string]int
+		grouped  int
+	}{
+		{config.PendingCancellationAssign, map[string]int{"cc-no-pru": 2, "cc-pru-allowed": 1}, 3},
+		{config.PendingCancellationSkip, map[string]int{"cc-no-pru": 1}, 1},
+		{config.PendingCancellationWindDown, map[string]int{"cc-no-pru": 1, "cc-wind-down": 2}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.handling, func(t *testing.T) {
+			cfg := testConfig("cc-no-pru", "cc-pru-allowed", []string{"carol"})
+			cfg.PendingCancellationHandling = tt.handling
+			cfg.WindDownCostCenterID = "cc-wind-down"
+			mgr := NewManager(cfg, testLogger())
+
+			got := mgr.GenerateSummary(users)
+			if len(got) != len(tt.want) {
+				t.Fatalf("summary = %v, want %v", got, tt.want)
+			}
+			for cc, n := range tt.want {
+				if got[cc] != n {
+					t.Errorf("summary[%s] = %d, want %d", cc, got[cc], n)
+				}
+			}
+			groups := mgr.AssignmentGroups(users)
+			total := 0
+			for _, logins := range groups {
+				total += len(logins)
+			}
+			if total != tt.grouped {
+				t.Errorf("grouped %d users, want %d", total, tt.grouped)
+			}
+		})
+	}
+}
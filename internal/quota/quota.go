@@ -0,0 +1,337 @@
+// Package quota paces API requests against a usage ledger shared by
+// several processes.  Every process appends one line per request to the
+// same file; before sending, each sums what all of them spent in the last
+// minute and waits while the total would exceed the budget.  A fleet of
+// automations that shares one token or one enterprise thus stays under
+// GitHub's secondary rate limits together, rather than each staying under
+// them alone.
+//
+// The ledger is newline-delimited JSON, one record per request:
+//
+//	{"time_ms":1767225600000,"tool":"gh-cost-center","points":1}
+//
+// time_ms is the Unix time in milliseconds and points the request's cost
+// (GitHub counts 1 point for a GET and 5 for a write).  Other tools join
+// the ledger by appending records in this format, holding an exclusive
+// lock (flock, or LockFileEx on Windows) on the ledger path with ".lock"
+// appended; lines that do not parse are ignored.  Each process keeps the
+// records of the current window in memory and only reads what was
+// appended since.
+package quota
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// Window is the span over which usage is summed.
+	Window = time.Minute
+	// DefaultBudget is the points per Window shared by all processes: the
+	// REST API's secondary limit of 900 points per minute.
+	DefaultBudget = 900
+
+	// Points charged for a read and for a write request.
+	ReadPoints  = 1
+	WritePoints = 5
+
+	// compactSize is the ledger size beyond which records older than the
+	// window are dropped.
+	compactSize = 256 << 10
+)
+
+// Record is one request in the ledger.
+type Record struct {
+	TimeMS int64  `json:"time_ms"`
+	Tool   string `json:"tool"`
+	Points int    `json:"points"`
+}
+
+// Ledger is a usage file shared with other processes.
+type Ledger struct {
+	path   string
+	tool   string
+	budget int
+	log    *slog.Logger
+
+	mu sync.Mutex // serialises Reserve within this process
+
+	// records are the ledger's records within the window, as of the last
+	// read; the first offset bytes of the ledger file, whose identity is
+	// file, have been read into them.
+	records []Record
+	offset  int64
+	file    os.FileInfo
+
+	// now and sleep are replaced in tests.
+	now   func() time.Time
+	sleep func(context.Context, time.Duration) error
+}
+
+// Open returns the ledger at path, recording requests as tool and pacing
+// them to budget points per Window (DefaultBudget when zero).  The file and
+// its directory are created on the first request.
+func Open(path, tool string, budget int, logger *slog.Logger) *Ledger {
+	if budget <= 0 {
+		budget = DefaultBudget
+	}
+	return &Ledger{
+		path:   path,
+		tool:   tool,
+		budget: budget,
+		log:    logger,
+		now:    time.Now,
+		sleep:  sleep,
+	}
+}
+
+// Path returns the ledger file.
+func (l *Ledger) Path() string {
+	return l.path
+}
+
+// Budget returns the points allowed per Window.
+func (l *Ledger) Budget() int {
+	return l.budget
+}
+
+// Reserve waits until the ledger has room for a request of points within
+// the budget, then records it.  Reading the ledger and recording the
+// request happen under an exclusive lock on the lock file (the ledger path
+// with ".lock" appended), so processes that take it never both claim the
+// last room; tools that append without it can still overshoot the budget
+// by a few requests.  A ledger that cannot be locked, read or written is
+// logged and does not hold the request back.
+func (l *Ledger) Reserve(ctx context.Context, points int) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for {
+		wait, err := l.tryReserve(points)
+		if err != nil {
+			l.log.Warn("Could not read the shared rate limit ledger, not pacing", "path", l.path, "error", err)
+			return nil
+		}
+		if wait <= 0 {
+			return nil
+		}
+		l.log.Info("Shared rate limit budget reached, pausing",
+			"wait", wait.Round(time.Millisecond), "budget", l.budget, "ledger", l.path)
+		if err := l.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// tryReserve records a request of points when the ledger has room for it,
+// or returns how long until it has.
+func (l *Ledger) tryReserve(points int) (time.Duration, error) {
+	unlock, err := l.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	now := l.now()
+	size, err := l.refresh(now)
+	if err != nil {
+		return 0, err
+	}
+	if wait := l.waitFor(l.records, points, now); wait > 0 {
+		return wait, nil
+	}
+	if size > compactSize {
+		l.compact()
+	}
+	// The record is read back with the next refresh, like those of other
+	// processes.
+	if err := l.append(Record{TimeMS: now.UnixMilli(), Tool: l.tool, Points: points}); err != nil {
+		l.log.Warn("Could not write the shared rate limit ledger", "path", l.path, "error", err)
+	}
+	return 0, nil
+}
+
+// lock takes the exclusive lock on the ledger's lock file and returns its
+// release.
+func (l *Ledger) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating ledger directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("locking %s: %w", f.Name(), err)
+	}
+	return func() {
+		_ = unlockFile(f)
+		_ = f.Close()
+	}, nil
+}
+
+// waitFor returns how long until records (within the window, any order)
+// leave room for points more, or zero when there is room now.  A request
+// larger than the whole budget waits for an empty window.
+func (l *Ledger) waitFor(records []Record, points int, now time.Time) time.Duration {
+	used := 0
+	for _, r := range records {
+		used += r.Points
+	}
+	if used+points <= l.budget || used == 0 {
+		return 0
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].TimeMS < records[j].TimeMS })
+	for _, r := range records {
+		used -= r.Points
+		if used+points <= l.budget {
+			return time.UnixMilli(r.TimeMS).Add(Window).Sub(now) + time.Millisecond
+		}
+	}
+	return time.UnixMilli(records[len(records)-1].TimeMS).Add(Window).Sub(now) + time.Millisecond
+}
+
+// refresh reads the records appended to the ledger since the last read,
+// drops those that have left the window before now, and returns the size
+// of the ledger.  A ledger replaced since, as by another process's
+// compaction, is read again from the start; a missing ledger is empty.
+func (l *Ledger) refresh(now time.Time) (int64, error) {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		l.records, l.offset, l.file = nil, 0, nil
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if l.file == nil || !os.SameFile(l.file, info) || info.Size() < l.offset {
+		l.records, l.offset, l.file = nil, 0, info
+	}
+
+	since := now.Add(-Window).UnixMilli()
+	kept := l.records[:0]
+	for _, r := range l.records {
+		if r.TimeMS > since {
+			kept = append(kept, r)
+		}
+	}
+	l.records = kept
+
+	if _, err := f.Seek(l.offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return 0, err
+	}
+	// A line still being written is read with the next refresh.
+	data = data[:bytes.LastIndexByte(data, '\n')+1]
+	l.offset += int64(len(data))
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		var r Record
+		if json.Unmarshal(sc.Bytes(), &r) != nil || r.Points <= 0 {
+			continue
+		}
+		if r.TimeMS > since {
+			l.records = append(l.records, r)
+		}
+	}
+	return info.Size(), nil
+}
+
+// append adds r to the ledger.  Each record is one small O_APPEND write,
+// so records of concurrent processes do not interleave.
+func (l *Ledger) append(r Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return fmt.Errorf("creating ledger directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// compact replaces the ledger, under the lock, with only the records still
+// within the window.  The new ledger is written to a temporary file of its
+// own and renamed over the old one.
+func (l *Ledger) compact() {
+	var buf bytes.Buffer
+	for _, r := range l.records {
+		line, _ := json.Marshal(r)
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if err := l.replace(buf.Bytes()); err != nil {
+		l.log.Debug("Could not compact the shared rate limit ledger", "error", err)
+	}
+}
+
+// replace atomically replaces the ledger with data and records it as read.
+func (l *Ledger) replace(data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		return err
+	}
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return err
+	}
+	l.offset, l.file = int64(len(data)), info
+	return nil
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,170 @@
+package quota
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// fakeLedger returns a ledger on a fake clock that advances when it sleeps.
+func fakeLedger(t *testing.T, budget int) (*Ledger, *time.Time, *[]time.Duration) {
+	t.Helper()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+	l := Open(filepath.Join(t.TempDir(), "sub", "ledger.ndjson"), "test", budget, testLogger())
+	l.now = func() time.Time { return now }
+	l.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		now = now.Add(d)
+		return nil
+	}
+	return l, &now, &slept
+}
+
+func TestReserve_RecordsRequests(t *testing.T) {
+	l, _, slept := fakeLedger(t, 10)
+	for _, p := range []int{ReadPoints, WritePoints} {
+		if err := l.Reserve(t.Context(), p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(*slept) != 0 {
+		t.Errorf("slept %v within budget", *slept)
+	}
+	data, err := os.ReadFile(l.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"time_ms":1767225600000,"tool":"test","points":1}` + "\n" +
+		`{"time_ms":1767225600000,"tool":"test","points":5}` + "\n"
+	if string(data) != want {
+		t.Errorf("ledger = %q, want %q", data, want)
+	}
+}
+
+func TestReserve_PacesAcrossProcesses(t *testing.T) {
+	l, now, slept := fakeLedger(t, 10)
+	// Another tool spent 8 points 30s ago and 2 points 10s ago.
+	other := []Record{
+		{TimeMS: now.Add(-30 * time.Second).UnixMilli(), Tool: "other", Points: 8},
+		{TimeMS: now.Add(-10 * time.Second).UnixMilli(), Tool: "other", Points: 2},
+		{TimeMS: now.Add(-2 * time.Minute).UnixMilli(), Tool: "other", Points: 100}, // outside the window
+	}
+	for _, r := range other {
+		if err := l.append(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, _ := os.OpenFile(l.Path(), os.O_APPEND|os.O_WRONLY, 0o644)
+	_, _ = f.WriteString("not json\n")
+	_ = f.Close()
+
+	if err := l.Reserve(t.Context(), WritePoints); err != nil {
+		t.Fatal(err)
+	}
+	// The 8 points must leave the window first: 30s plus a millisecond.
+	if len(*slept) != 1 || (*slept)[0] != 30*time.Second+time.Millisecond {
+		t.Errorf("slept %v, want one wait of 30.001s", *slept)
+	}
+}
+
+func TestReserve_CancelledWhileWaiting(t *testing.T) {
+	l, now, _ := fakeLedger(t, 1)
+	_ = l.append(Record{TimeMS: now.UnixMilli(), Tool: "other", Points: 1})
+	l.sleep = sleep
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if err := l.Reserve(ctx, ReadPoints); err == nil {
+		t.Error("expected the cancelled context to end the wait")
+	}
+}
+
+func TestReserve_Compacts(t *testing.T) {
+	l, now, _ := fakeLedger(t, DefaultBudget)
+	old := strings.Repeat(`{"time_ms":1,"tool":"other","points":1}`+"\n", compactSize/40+1)
+	if err := os.MkdirAll(filepath.Dir(l.Path()), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(l.Path(), []byte(old), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_ = l.append(Record{TimeMS: now.UnixMilli(), Tool: "other", Points: 3})
+
+	if err := l.Reserve(t.Context(), ReadPoints); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(l.Path())
+	if n := strings.Count(string(data), "\n"); n != 2 {
+		t.Errorf("compacted ledger has %d records, want the 2 within the window:\n%s", n, data)
+	}
+}
+
+func TestReserve_NilLedger(t *testing.T) {
+	var l *Ledger
+	if err := l.Reserve(t.Context(), ReadPoints); err != nil {
+		t.Errorf("nil ledger: %v", err)
+	}
+}
+
+func TestReserve_ReadsOnlyNewRecords(t *testing.T) {
+	l, now, slept := fakeLedger(t, 10)
+	other := Open(l.Path(), "other", 10, testLogger())
+	other.now = l.now
+
+	if err := l.Reserve(t.Context(), WritePoints); err != nil {
+		t.Fatal(err)
+	}
+	offset := l.offset
+	if err := other.Reserve(t.Context(), WritePoints); err != nil {
+		t.Fatal(err)
+	}
+	// The first ledger sees the other process's record without rereading
+	// its own.
+	if _, err := l.refresh(*now); err != nil {
+		t.Fatal(err)
+	}
+	if len(l.records) != 2 || l.offset <= offset {
+		t.Errorf("records = %v, offset %d -> %d; want both records, read incrementally", l.records, offset, l.offset)
+	}
+	if err := l.Reserve(t.Context(), ReadPoints); err != nil {
+		t.Fatal(err)
+	}
+	if len(*slept) != 1 {
+		t.Errorf("slept %v, want one wait for the shared budget", *slept)
+	}
+}
+
+func TestReserve_RereadsReplacedLedger(t *testing.T) {
+	l, now, _ := fakeLedger(t, 10)
+	if err := l.Reserve(t.Context(), WritePoints); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.refresh(*now); err != nil {
+		t.Fatal(err)
+	}
+	// Another process compacts the ledger into a new file.
+	tmp := l.Path() + ".new"
+	rec := `{"time_ms":` + strconv.FormatInt(now.UnixMilli(), 10) + `,"tool":"other","points":2}` + "\n"
+	if err := os.WriteFile(tmp, []byte(rec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, l.Path()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.refresh(*now); err != nil {
+		t.Fatal(err)
+	}
+	if len(l.records) != 1 || l.records[0].Tool != "other" {
+		t.Errorf("records = %v, want the replaced ledger's only record", l.records)
+	}
+}
@@ -3,12 +3,17 @@
 package repository
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 
+	"github.com/renan-alm/gh-cost-center/internal/budgets"
 	"github.com/renan-alm/gh-cost-center/internal/config"
 	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/i18n"
 )
 
 // MappingResult records the outcome of processing a single explicit mapping.
@@ -17,8 +22,24 @@ type MappingResult struct {
 	CostCenterID   string
 	PropertyName   string
 	PropertyValues []string
+	Topics         []string
+	Repositories   []string // name globs
 	ReposMatched   int
+	Repos          []string // matched repository full names (plan mode)
 	ReposAssigned  int
+	// With check-current, ReposCurrent counts matched repositories already
+	// in the cost center and ReposElsewhere lists those left in another one
+	// as "org/repo (cost center)".
+	ReposCurrent   int
+	ReposElsewhere []string
+	// ReposUnchecked are matched repositories whose current cost center
+	// could not be looked up; they are left unassigned.
+	ReposUnchecked []string
+	// ReposUnmatched are repositories the cost center holds that no
+	// mapping to it matches any more; recorded on the cost center's first
+	// mapping when remove_repos_no_longer_matching is set.
+	ReposUnmatched []string
+	ReposRemoved   int
 	Success        bool
 	Message        string
 }
@@ -35,22 +56,45 @@ type Summary struct {
 func (s *Summary) Print() {
 	fmt.Println()
 	fmt.Println(strings.Repeat("=", 80))
-	fmt.Println("REPOSITORY ASSIGNMENT SUMMARY")
+	fmt.Println(i18n.T("summary.repos.title"))
 	fmt.Println(strings.Repeat("=", 80))
-	fmt.Printf("Total repositories in organization: %d\n", s.TotalRepos)
-	fmt.Printf("Mappings processed: %d / %d\n", s.MappingsApplied, s.MappingsTotal)
+	fmt.Println(i18n.T("summary.repos.total", s.TotalRepos))
+	fmt.Println(i18n.T("summary.repos.mappings", s.MappingsApplied, s.MappingsTotal))
 
 	for _, r := range s.MappingResults {
 		fmt.Println()
-		fmt.Printf("Cost Center: %s\n", r.CostCenter)
-		fmt.Printf("  Property:  %s\n", r.PropertyName)
-		fmt.Printf("  Values:    %s\n", strings.Join(r.PropertyValues, ", "))
-		fmt.Printf("  Matched:   %d repositories\n", r.ReposMatched)
-		fmt.Printf("  Assigned:  %d repositories\n", r.ReposAssigned)
+		fmt.Println(i18n.T("summary.repos.cc", r.CostCenter))
+		if r.PropertyName != "" {
+			fmt.Println(i18n.T("summary.repos.property", r.PropertyName))
+			fmt.Println(i18n.T("summary.repos.values", strings.Join(r.PropertyValues, ", ")))
+		}
+		if len(r.Topics) > 0 {
+			fmt.Println(i18n.T("summary.repos.topics", strings.Join(r.Topics, ", ")))
+		}
+		if len(r.Repositories) > 0 {
+			fmt.Println(i18n.T("summary.repos.names", strings.Join(r.Repositories, ", ")))
+		}
+		fmt.Println(i18n.T("summary.repos.matched", r.ReposMatched))
+		fmt.Println(i18n.T("summary.repos.assigned", r.ReposAssigned))
+		if r.ReposCurrent > 0 {
+			fmt.Println(i18n.T("summary.repos.current", r.ReposCurrent))
+		}
+		if len(r.ReposElsewhere) > 0 {
+			fmt.Println(i18n.T("summary.repos.elsewhere", len(r.ReposElsewhere), strings.Join(r.ReposElsewhere, ", ")))
+		}
+		if len(r.ReposUnchecked) > 0 {
+			fmt.Println(i18n.T("summary.repos.unchecked", len(r.ReposUnchecked), strings.Join(r.ReposUnchecked, ", ")))
+		}
+		if len(r.ReposUnmatched) > 0 {
+			fmt.Println(i18n.T("summary.repos.unmatched", len(r.ReposUnmatched), strings.Join(r.ReposUnmatched, ", ")))
+		}
+		if r.ReposRemoved > 0 {
+			fmt.Println(i18n.T("summary.repos.removed", r.ReposRemoved))
+		}
 		if r.Success {
-			fmt.Println("  Status:    Success")
+			fmt.Println(i18n.T("summary.repos.ok"))
 		} else {
-			fmt.Printf("  Status:    Failed \u2014 %s\n", r.Message)
+			fmt.Println(i18n.T("summary.repos.failed", r.Message))
 		}
 	}
 	fmt.Println(strings.Repeat("=", 80))
@@ -62,6 +106,17 @@ type Manager struct {
 	client   *github.Client
 	log      *slog.Logger
 	mappings []config.ExplicitMapping
+	matchers []mappingMatcher // one per mapping
+
+	reconciler   *budgets.Reconciler
+	checkCurrent bool
+}
+
+// mappingMatcher holds the compiled selectors of a mapping; topic and
+// repo are nil when the mapping does not set them.
+type mappingMatcher struct {
+	value, topic, repo func(string) bool
+	all                bool // every selector must match (combine: all)
 }
 
 // NewManager creates a new repository manager from configuration.
@@ -69,14 +124,40 @@ func NewManager(cfg *config.Manager, client *github.Client, logger *slog.Logger)
 	if len(cfg.ReposMappings) == 0 {
 		return nil, fmt.Errorf("repos mode requires at least one mapping in cost_center.repos.mappings")
 	}
+	matchers := make([]mappingMatcher, len(cfg.ReposMappings))
+	for i, mp := range cfg.ReposMappings {
+		value, err := mp.ValueMatcher()
+		if err != nil {
+			return nil, fmt.Errorf("cost_center.repos.mappings[%d]: %w", i, err)
+		}
+		topic, err := mp.TopicMatcher()
+		if err != nil {
+			return nil, fmt.Errorf("cost_center.repos.mappings[%d] topics: %w", i, err)
+		}
+		repo, err := mp.RepositoryMatcher()
+		if err != nil {
+			return nil, fmt.Errorf("cost_center.repos.mappings[%d] repositories: %w", i, err)
+		}
+		matchers[i] = mappingMatcher{value: value, topic: topic, repo: repo, all: mp.Combine == "all"}
+	}
 	return &Manager{
-		cfg:      cfg,
-		client:   client,
-		log:      logger,
-		mappings: cfg.ReposMappings,
+		cfg:        cfg,
+		client:     client,
+		log:        logger,
+		mappings:   cfg.ReposMappings,
+		matchers:   matchers,
+		reconciler: budgets.NewReconciler(client, logger),
 	}, nil
 }
 
+// SetCheckCurrent makes Run look up each matched repository's current cost
+// center first: repositories already in the mapped cost center are not
+// re-added, and those in another cost center are skipped and reported
+// rather than moved.
+func (m *Manager) SetCheckCurrent(enabled bool) {
+	m.checkCurrent = enabled
+}
+
 // ValidateConfiguration checks mapping definitions and returns any issues.
 func (m *Manager) ValidateConfiguration() []string {
 	var issues []string
@@ -84,6 +165,9 @@ func (m *Manager) ValidateConfiguration() []string {
 		if mp.CostCenter == "" {
 			issues = append(issues, fmt.Sprintf("mapping %d: missing cost_center", i+1))
 		}
+		if len(mp.Topics)+len(mp.Repositories) > 0 && mp.PropertyName == "" && len(mp.PropertyValues) == 0 {
+			continue
+		}
 		if mp.PropertyName == "" {
 			issues = append(issues, fmt.Sprintf("mapping %d: missing property_name", i+1))
 		}
@@ -105,39 +189,78 @@ func (m *Manager) PrintConfigSummary(org string) {
 	for i, mp := range m.mappings {
 		fmt.Printf("\n  Mapping %d:\n", i+1)
 		fmt.Printf("    Cost Center:    %s\n", mp.CostCenter)
-		fmt.Printf("    Property:       %s\n", mp.PropertyName)
-		fmt.Printf("    Values:         %s\n", strings.Join(mp.PropertyValues, ", "))
+		if mp.PropertyName != "" {
+			fmt.Printf("    Property:       %s\n", mp.PropertyName)
+			fmt.Printf("    Values:         %s\n", strings.Join(mp.PropertyValues, ", "))
+		}
+		if len(mp.Topics) > 0 {
+			fmt.Printf("    Topics:         %s\n", strings.Join(mp.Topics, ", "))
+		}
+		if len(mp.Repositories) > 0 {
+			fmt.Printf("    Repositories:   %s\n", strings.Join(mp.Repositories, ", "))
+		}
+		if mp.Combine == "all" {
+			fmt.Printf("    Combine:        all\n")
+		}
+		if mp.Match != "" && mp.Match != "exact" {
+			fmt.Printf("    Match:          %s\n", mp.Match)
+		}
+	}
+	fmt.Printf("\nCheck current cost center: %v\n", m.checkCurrent)
+	if m.cfg.ReposRemoveNoLongerMatching {
+		fmt.Printf("Remove repos no longer matching: true\n")
 	}
 	fmt.Println(strings.Repeat("=", 80))
 }
 
 // Run executes the full repository-based assignment flow.
 // mode is "plan" or "apply".  createBudgets enables budget creation for new CCs.
-func (m *Manager) Run(org, mode string, createBudgets bool) (*Summary, error) {
+func (m *Manager) Run(ctx context.Context, org, mode string, createBudgets bool) (*Summary, error) {
 	m.log.Info("Starting repository-based cost center assignment",
 		"org", org, "mode", mode, "mappings", len(m.mappings))
 
+	// Topics are not part of the custom properties listing; fetch them
+	// only when a mapping needs them.
+	var topics map[string][]string
+	if m.usesTopics() {
+		var err error
+		if topics, err = m.client.GetOrgRepoTopics(ctx, org); err != nil {
+			return nil, fmt.Errorf("fetching repository topics: %w", err)
+		}
+	}
+
 	// Fetch all repos with custom properties.
 	m.log.Info("Fetching repositories with custom properties...", "org", org)
-	allRepos, err := m.client.GetOrgReposWithProperties(org, "")
+	// Repos are streamed and only those matching a mapping are kept, so
+	// memory does not grow with the size of the organization.
+	total := 0
+	var allRepos []github.RepoProperties
+	err := m.client.EachOrgRepoWithProperties(ctx, org, "", func(r github.RepoProperties) error {
+		total++
+		r.Topics = topics[r.RepositoryFullName]
+		if m.matchesAnyMapping(r) {
+			allRepos = append(allRepos, r)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("fetching repos with properties: %w", err)
 	}
-	if len(allRepos) == 0 {
+	if total == 0 {
 		m.log.Warn("No repositories found", "org", org)
 		return &Summary{TotalRepos: 0, MappingsTotal: len(m.mappings)}, nil
 	}
-	m.log.Info("Repositories found", "org", org, "count", len(allRepos))
+	m.log.Info("Repositories found", "org", org, "count", total, "matching", len(allRepos))
 
 	// Preload existing cost centers for efficient lookups.
-	activeCCs, err := m.client.GetAllActiveCostCenters()
+	activeCCs, err := m.client.GetAllActiveCostCenters(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("fetching active cost centers: %w", err)
 	}
 	m.log.Info("Existing cost centers loaded", "count", len(activeCCs))
 
 	summary := &Summary{
-		TotalRepos:    len(allRepos),
+		TotalRepos:    total,
 		MappingsTotal: len(m.mappings),
 	}
 
@@ -147,22 +270,113 @@ func (m *Manager) Run(org, mode string, createBudgets bool) (*Summary, error) {
 			"index", i+1, "total", len(m.mappings),
 			"cost_center", mp.CostCenter,
 			"property", mp.PropertyName,
-			"values", strings.Join(mp.PropertyValues, ","))
+			"values", strings.Join(mp.PropertyValues, ","),
+			"topics", strings.Join(mp.Topics, ","))
 
-		result := m.processMapping(mp, allRepos, activeCCs, mode, createBudgets)
+		result := m.processMapping(ctx, mp, m.matchers[i], allRepos, activeCCs, mode, createBudgets)
 		if result.Success {
 			summary.MappingsApplied++
 		}
 		summary.MappingResults = append(summary.MappingResults, result)
 	}
 
+	if m.cfg.ReposRemoveNoLongerMatching {
+		if err := m.removeNoLongerMatching(ctx, org, mode, allRepos, activeCCs, summary); err != nil {
+			return nil, err
+		}
+	}
+
 	return summary, nil
 }
 
+// removeNoLongerMatching finds the repositories of org that each mapped
+// cost center holds but none of its mappings matches any more, and removes
+// them in apply mode.  A cost center one of whose mappings failed to assign
+// is left alone, since its desired repositories are not known for sure.
+func (m *Manager) removeNoLongerMatching(
+	ctx context.Context,
+	org, mode string,
+	allRepos []github.RepoProperties,
+	activeCCs map[string]string,
+	summary *Summary,
+) error {
+	var order []string
+	first := make(map[string]int)               // cost center -> index of its first result
+	desired := make(map[string]map[string]bool) // cost center -> lower-cased full names
+	skip := make(map[string]bool)
+	for i, mp := range m.mappings {
+		cc := mp.CostCenter
+		if cc == "" {
+			continue
+		}
+		if _, seen := first[cc]; !seen {
+			order = append(order, cc)
+			first[cc] = i
+			desired[cc] = make(map[string]bool)
+		}
+		r := summary.MappingResults[i]
+		if !r.Success && r.ReposMatched > 0 {
+			skip[cc] = true
+		}
+		for _, repo := range allRepos {
+			if m.matchers[i].matches(mp, repo) {
+				desired[cc][strings.ToLower(repo.RepositoryFullName)] = true
+			}
+		}
+	}
+
+	prefix := strings.ToLower(org) + "/"
+	for _, cc := range order {
+		id, ok := activeCCs[cc]
+		if !ok || skip[cc] {
+			continue
+		}
+		current, err := m.client.GetCostCenterRepositories(ctx, id)
+		if err != nil {
+			return fmt.Errorf("fetching repositories of cost center %s: %w", cc, err)
+		}
+		var unmatched []string
+		for _, name := range current {
+			key := strings.ToLower(name)
+			if strings.HasPrefix(key, prefix) && !desired[cc][key] {
+				unmatched = append(unmatched, name)
+			}
+		}
+		if len(unmatched) == 0 {
+			continue
+		}
+		slices.Sort(unmatched)
+		r := &summary.MappingResults[first[cc]]
+		r.ReposUnmatched = unmatched
+
+		if mode == "plan" {
+			m.log.Info("mode=plan: would remove repos no longer matching",
+				"cost_center", cc, "count", len(unmatched))
+			continue
+		}
+		if err := m.client.RemoveRepositoriesFromCostCenter(ctx, id, unmatched); err != nil {
+			if r.Success {
+				summary.MappingsApplied--
+			}
+			r.Success = false
+			r.Message = fmt.Sprintf("failed to remove repos no longer matching: %v", err)
+			m.log.Error("Failed to remove repos no longer matching",
+				"cost_center", cc, "error", err)
+			continue
+		}
+		r.ReposRemoved = len(unmatched)
+		m.log.Info("Removed repos no longer matching",
+			"cost_center", cc, "count", len(unmatched))
+	}
+	return nil
+}
+
 // processMapping handles a single explicit mapping -- find matching repos,
 // ensure CC exists, and assign.
 func (m *Manager) processMapping(
+	ctx context.Context,
 	mp config.ExplicitMapping,
+	match mappingMatcher,
 	allRepos []github.RepoProperties,
 	activeCCs map[string]string,
 	mode string,
@@ -172,17 +386,24 @@ func (m *Manager) processMapping(
 		CostCenter:     mp.CostCenter,
 		PropertyName:   mp.PropertyName,
 		PropertyValues: mp.PropertyValues,
+		Topics:         mp.Topics,
+		Repositories:   mp.Repositories,
 	}
 
 	// Validate mapping fields.
-	if mp.CostCenter == "" || mp.PropertyName == "" || len(mp.PropertyValues) == 0 {
-		result.Message = "invalid mapping: missing cost_center, property_name, or property_values"
+	if mp.CostCenter == "" || (len(mp.Topics) == 0 && len(mp.Repositories) == 0 && (mp.PropertyName == "" || len(mp.PropertyValues) == 0)) {
+		result.Message = "invalid mapping: missing cost_center, property_name, property_values, topics, or repositories"
 		m.log.Error("Invalid mapping configuration", "cost_center", mp.CostCenter)
 		return result
 	}
 
 	// Find matching repos.
-	matching := findMatchingRepos(allRepos, mp.PropertyName, mp.PropertyValues)
+	var matching []github.RepoProperties
+	for _, r := range allRepos {
+		if match.matches(mp, r) {
+			matching = append(matching, r)
+		}
+	}
 	result.ReposMatched = len(matching)
 
 	if len(matching) == 0 {
@@ -190,13 +411,30 @@ func (m *Manager) processMapping(
 		m.log.Warn("No repos matched",
 			"cost_center", mp.CostCenter,
 			"property", mp.PropertyName,
-			"values", strings.Join(mp.PropertyValues, ","))
+			"values", strings.Join(mp.PropertyValues, ","),
+			"topics", strings.Join(mp.Topics, ","))
 		return result
 	}
 
 	m.log.Info("Repositories matched",
 		"cost_center", mp.CostCenter, "count", len(matching))
 
+	if m.checkCurrent {
+		matching = m.filterCurrent(ctx, mp.CostCenter, activeCCs[mp.CostCenter], matching, &result)
+		if len(matching) == 0 {
+			result.Success = len(result.ReposUnchecked) == 0
+			result.Message = "no repositories to assign: all are in this or another cost center"
+			if !result.Success {
+				result.Message = fmt.Sprintf("no repositories to assign: could not check the cost center of %d", len(result.ReposUnchecked))
+			}
+			m.log.Info("No repos left to assign after checking current cost centers",
+				"cost_center", mp.CostCenter,
+				"already_assigned", result.ReposCurrent,
+				"in_other_cost_centers", len(result.ReposElsewhere))
+			return result
+		}
+	}
+
 	// Plan mode -- just report what would happen.
 	if mode == "plan" {
 		result.ReposAssigned = len(matching)
@@ -207,6 +445,7 @@ func (m *Manager) processMapping(
 			"cost_center", mp.CostCenter, "count", len(matching))
 		for _, r := range matching {
 			m.log.Debug("Would assign", "repo", r.RepositoryFullName, "cost_center", mp.CostCenter)
+			result.Repos = append(result.Repos, r.RepositoryFullName)
 		}
 		return result
 	}
@@ -216,7 +455,7 @@ func (m *Manager) processMapping(
 	if !ok {
 		m.log.Info("Cost center does not exist, creating...", "name", mp.CostCenter)
 		var err error
-		ccID, err = m.client.CreateCostCenterWithPreload(mp.CostCenter, activeCCs)
+		ccID, err = m.client.CreateCostCenterWithPreload(ctx, mp.CostCenter, activeCCs)
 		if err != nil {
 			result.Message = fmt.Sprintf("failed to create cost center: %v", err)
 			m.log.Error("Failed to create cost center",
@@ -228,7 +467,7 @@ func (m *Manager) processMapping(
 
 		// Create budgets if enabled.
 		if createBudgets && m.cfg.BudgetsEnabled {
-			if err := m.createBudgets(ccID, mp.CostCenter); err != nil {
+			if err := m.createBudgets(ctx, ccID, mp.CostCenter); err != nil {
 				result.Message = fmt.Sprintf("budget creation failed: %v", err)
 				m.log.Error("Budget creation failed for cost center", "name", mp.CostCenter, "error", err)
 				return result
@@ -236,6 +475,15 @@ func (m *Manager) processMapping(
 		}
 	} else {
 		m.log.Info("Cost center already exists", "name", mp.CostCenter, "id", ccID)
+
+		// Update budgets whose amount drifted from configuration.
+		if createBudgets && m.cfg.BudgetsEnabled {
+			if err := m.reconciler.Reconcile(ctx, ccID, mp.CostCenter, m.cfg.BudgetProductsFor(mp.CostCenter)); err != nil {
+				result.Message = fmt.Sprintf("budget update failed: %v", err)
+				m.log.Error("Budget update failed for cost center", "name", mp.CostCenter, "error", err)
+				return result
+			}
+		}
 	}
 
 	result.CostCenterID = ccID
@@ -267,7 +515,7 @@ func (m *Manager) processMapping(
 	}
 
 	// Call API to assign repos.
-	if err := m.client.AddRepositoriesToCostCenter(ccID, repoNames); err != nil {
+	if err := m.client.AddRepositoriesToCostCenter(ctx, ccID, repoNames); err != nil {
 		result.Message = fmt.Sprintf("failed to assign repos: %v", err)
 		m.log.Error("Failed to assign repos",
 			"cost_center", mp.CostCenter, "error", err)
@@ -275,62 +523,114 @@ func (m *Manager) processMapping(
 	}
 
 	result.ReposAssigned = len(repoNames)
-	result.Success = true
+	result.Success = len(result.ReposUnchecked) == 0
 	result.Message = fmt.Sprintf("successfully assigned %d/%d repositories",
 		len(repoNames), len(matching))
+	if !result.Success {
+		result.Message += fmt.Sprintf("; could not check the cost center of %d", len(result.ReposUnchecked))
+	}
 	m.log.Info("Successfully assigned repos",
 		"cost_center", mp.CostCenter, "assigned", len(repoNames))
 
 	return result
 }
 
+// filterCurrent drops the repositories that already belong to a cost
+// center, counting those in ccName (ID ccID, empty when it does not exist
+// yet) and listing the others on result.  Repositories whose membership
+// cannot be looked up are dropped too, and listed as unchecked, so a failed
+// lookup never moves a repository out of another cost center.
+func (m *Manager) filterCurrent(ctx context.Context, ccName, ccID string, repos []github.RepoProperties, result *MappingResult) []github.RepoProperties {
+	var keep []github.RepoProperties
+	for _, r := range repos {
+		ref, err := m.client.LookupCostCenterMembership(ctx, github.ResourceTypeRepo, r.RepositoryFullName)
+		switch {
+		case err != nil:
+			m.log.Warn("Skipping repository: cannot check its current cost center",
+				"repo", r.RepositoryFullName, "cost_center", ccName, "error", err)
+			result.ReposUnchecked = append(result.ReposUnchecked, r.RepositoryFullName)
+		case ref == nil:
+			keep = append(keep, r)
+		case (ccID != "" && ref.ID == ccID) || ref.Name == ccName:
+			result.ReposCurrent++
+		default:
+			m.log.Info("Skipping repository already in another cost center",
+				"repo", r.RepositoryFullName, "current_cost_center", ref.Name, "cost_center", ccName)
+			result.ReposElsewhere = append(result.ReposElsewhere, fmt.Sprintf("%s (%s)", r.RepositoryFullName, ref.Name))
+		}
+	}
+	return keep
+}
+
 // createBudgets creates configured budgets for a single cost center.
-func (m *Manager) createBudgets(ccID, ccName string) error {
+func (m *Manager) createBudgets(ctx context.Context, ccID, ccName string) error {
 	m.log.Info("Creating budgets for cost center", "name", ccName)
 
-	var failures []string
-	for product, pc := range m.cfg.BudgetProducts {
-		if !pc.Enabled {
-			m.log.Debug("Skipping disabled product budget", "product", product)
-			continue
+	products := m.cfg.BudgetProductsFor(ccName)
+	written, err := m.client.EnsureProductBudgets(ctx, ccID, ccName, products)
+	for _, product := range written {
+		m.log.Info("Budget created",
+			"product", product, "cost_center", ccName, "amount", products[product].Amount)
+	}
+	if err != nil {
+		// If budgets API is unavailable, log and stop trying.
+		var unavailable *github.BudgetsAPIUnavailableError
+		if errors.As(err, &unavailable) {
+			m.log.Warn("Budgets API unavailable, skipping remaining budgets", "error", err)
+			return nil
 		}
+		m.log.Error("Failed to create budgets", "cost_center", ccName, "error", err)
+		return fmt.Errorf("budget creation failed for cost center %s: %w", ccName, err)
+	}
+	return nil
+}
 
-		ok, err := m.client.CreateProductBudget(ccID, ccName, product, pc.Amount)
-		if err != nil {
-			// If budgets API is unavailable, log and stop trying.
-			if _, unavailable := err.(*github.BudgetsAPIUnavailableError); unavailable {
-				m.log.Warn("Budgets API unavailable, skipping remaining budgets",
-					"error", err)
-				return nil
-			}
-			m.log.Error("Failed to create budget",
-				"product", product, "cost_center", ccName, "error", err)
-			failures = append(failures, fmt.Sprintf("%s: %v", product, err))
-			continue
+// matchesAnyMapping reports whether repo matches at least one mapping.
+func (m *Manager) matchesAnyMapping(repo github.RepoProperties) bool {
+	for i, mp := range m.mappings {
+		if m.matchers[i].matches(mp, repo) {
+			return true
 		}
-		if ok {
-			m.log.Info("Budget created",
-				"product", product, "cost_center", ccName, "amount", pc.Amount)
+	}
+	return false
+}
+
+// usesTopics reports whether any mapping selects repositories by topic.
+func (m *Manager) usesTopics() bool {
+	for _, mp := range m.mappings {
+		if len(mp.Topics) > 0 {
+			return true
 		}
 	}
+	return false
+}
 
-	if len(failures) > 0 {
-		return fmt.Errorf("budget creation failed for cost center %s: %s", ccName, strings.Join(failures, "; "))
+// matches reports whether repo meets any, or with combine: all every, of
+// the selectors the mapping sets.
+func (mm mappingMatcher) matches(mp config.ExplicitMapping, repo github.RepoProperties) bool {
+	var results []bool
+	if mp.PropertyName != "" {
+		results = append(results, len(findMatchingRepos([]github.RepoProperties{repo}, mp.PropertyName, mm.value)) > 0)
 	}
-	return nil
+	if mm.topic != nil {
+		results = append(results, slices.ContainsFunc(repo.Topics, mm.topic))
+	}
+	if mm.repo != nil {
+		results = append(results, mm.repo(repo.RepositoryFullName))
+	}
+	if mm.all {
+		return len(results) > 0 && !slices.Contains(results, false)
+	}
+	return slices.Contains(results, true)
 }
 
-// findMatchingRepos returns repos whose custom properties match the mapping criteria.
+// findMatchingRepos returns repos whose propertyName property has a value
+// that match accepts.
 func findMatchingRepos(
 	repos []github.RepoProperties,
 	propertyName string,
-	propertyValues []string,
+	match func(string) bool,
 ) []github.RepoProperties {
-	valueSet := make(map[string]bool, len(propertyValues))
-	for _, v := range propertyValues {
-		valueSet[v] = true
-	}
-
 	var matched []github.RepoProperties
 	for _, repo := range repos {
 		for _, prop := range repo.Properties {
@@ -338,7 +638,7 @@ func findMatchingRepos(
 				continue
 			}
 			// Property value can be a string or []string.
-			if matchesValue(prop.Value, valueSet) {
+			if matchesValue(prop.Value, match) {
 				matched = append(matched, repo)
 				break
 			}
@@ -347,15 +647,15 @@ func findMatchingRepos(
 	return matched
 }
 
-// matchesValue checks if a property value (string or []any) matches any value
-// in the allowed set.
-func matchesValue(val any, allowed map[string]bool) bool {
+// matchesValue checks if a property value (string or []any) has a value
+// match accepts.
+func matchesValue(val any, match func(string) bool) bool {
 	switch v := val.(type) {
 	case string:
-		return allowed[v]
+		return match(v)
 	case []any:
 		for _, item := range v {
-			if s, ok := item.(string); ok && allowed[s] {
+			if s, ok := item.(string); ok && match(s) {
 				return true
 			}
 		}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/renan-alm/gh-cost-center/internal/config"
 	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/github/githubtest"
 )
 
 // newTestManager builds a Manager with test defaults.
@@ -153,7 +154,7 @@ func TestFindMatchingRepos_StringValue(t *testing.T) {
 		},
 	}
 
-	matched := findMatchingRepos(repos, "team", []string{"engineering"})
+	matched := findMatchingRepos(repos, "team", exact("engineering"))
 	if len(matched) != 2 {
 		t.Errorf("expected 2 matches, got %d", len(matched))
 	}
@@ -177,7 +178,7 @@ func TestFindMatchingRepos_ArrayValue(t *testing.T) {
 		},
 	}
 
-	matched := findMatchingRepos(repos, "tags", []string{"go"})
+	matched := findMatchingRepos(repos, "tags", exact("go"))
 	if len(matched) != 1 {
 		t.Errorf("expected 1 match, got %d", len(matched))
 	}
@@ -211,7 +212,7 @@ func TestFindMatchingRepos_MultipleValues(t *testing.T) {
 		},
 	}
 
-	matched := findMatchingRepos(repos, "team", []string{"engineering", "devops"})
+	matched := findMatchingRepos(repos, "team", exact("engineering", "devops"))
 	if len(matched) != 2 {
 		t.Errorf("expected 2 matches, got %d", len(matched))
 	}
@@ -228,7 +229,7 @@ func TestFindMatchingRepos_NoMatch(t *testing.T) {
 		},
 	}
 
-	matched := findMatchingRepos(repos, "team", []string{"engineering"})
+	matched := findMatchingRepos(repos, "team", exact("engineering"))
 	if len(matched) != 0 {
 		t.Errorf("expected 0 matches, got %d", len(matched))
 	}
@@ -245,7 +246,7 @@ func TestFindMatchingRepos_DifferentPropertyName(t *testing.T) {
 		},
 	}
 
-	matched := findMatchingRepos(repos, "team", []string{"engineering"})
+	matched := findMatchingRepos(repos, "team", exact("engineering"))
 	if len(matched) != 0 {
 		t.Errorf("should not match different property name, got %d", len(matched))
 	}
@@ -260,24 +261,120 @@ func TestFindMatchingRepos_NoProperties(t *testing.T) {
 		},
 	}
 
-	matched := findMatchingRepos(repos, "team", []string{"engineering"})
+	matched := findMatchingRepos(repos, "team", exact("engineering"))
 	if len(matched) != 0 {
 		t.Errorf("expected 0 matches for repo with no properties, got %d", len(matched))
 	}
 }
 
+// exact matches the given property values exactly.
+func exact(values ...string) func(string) bool {
+	match, _ := config.ExplicitMapping{PropertyValues: values}.ValueMatcher()
+	return match
+}
+
+// inSet matches the values set in allowed.
+func inSet(allowed map[string]bool) func(string) bool {
+	return func(s string) bool { return allowed[s] }
+}
+
+func TestFindMatchingRepos_GlobAndRegex(t *testing.T) {
+	repos := []github.RepoProperties{
+		{RepositoryFullName: "org/pay-api", Properties: []github.Property{{PropertyName: "team", Value: "team-payments-api"}}},
+		{RepositoryFullName: "org/pay-web", Properties: []github.Property{{PropertyName: "team", Value: []any{"shared", "team-payments-web"}}}},
+		{RepositoryFullName: "org/other", Properties: []github.Property{{PropertyName: "team", Value: "team-search"}}},
+		{RepositoryFullName: "org/prefixed", Properties: []github.Property{{PropertyName: "team", Value: "old-team-payments-x"}}},
+	}
+	for _, tc := range []struct {
+		match  string
+		values []string
+		want   int
+	}{
+		{"glob", []string{"team-payments-*"}, 2},
+		{"glob", []string{"team-????????-api"}, 1},
+		{"regex", []string{"^team-payments-(api|web)$"}, 2},
+		{"regex", []string{"payments"}, 3},
+		{"exact", []string{"team-payments-*"}, 0},
+	} {
+		match, err := config.ExplicitMapping{PropertyValues: tc.values, Match: tc.match}.ValueMatcher()
+		if err != nil {
+			t.Fatalf("ValueMatcher(%s %v): %v", tc.match, tc.values, err)
+		}
+		if got := findMatchingRepos(repos, "team", match); len(got) != tc.want {
+			t.Errorf("%s %v matched %d repos, want %d", tc.match, tc.values, len(got), tc.want)
+		}
+	}
+}
+
+func TestMatchesAnyMapping_Topics(t *testing.T) {
+	cfg := &config.Manager{ReposMappings: []config.ExplicitMapping{
+		{CostCenter: "Payments", Topics: []string{"payments-*"}, Match: "glob"},
+		{CostCenter: "Platform", PropertyName: "team", PropertyValues: []string{"platform"}, Topics: []string{"infra"}},
+	}}
+	m, err := NewManager(cfg, nil, testLogger())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if !m.usesTopics() {
+		t.Error("usesTopics = false")
+	}
+	if issues := m.ValidateConfiguration(); len(issues) != 0 {
+		t.Errorf("topics-only mapping reported issues: %v", issues)
+	}
+	for _, tc := range []struct {
+		repo github.RepoProperties
+		want bool
+	}{
+		{github.RepoProperties{Topics: []string{"go", "payments-api"}}, true},
+		{github.RepoProperties{Topics: []string{"infra"}}, true},
+		{github.RepoProperties{Properties: []github.Property{{PropertyName: "team", Value: "platform"}}}, true},
+		{github.RepoProperties{Topics: []string{"search"}, Properties: []github.Property{{PropertyName: "team", Value: "search"}}}, false},
+	} {
+		if got := m.matchesAnyMapping(tc.repo); got != tc.want {
+			t.Errorf("matchesAnyMapping(%+v) = %v, want %v", tc.repo, got, tc.want)
+		}
+	}
+}
+
+func TestMatchesAnyMapping_RepositoryNames(t *testing.T) {
+	cfg := &config.Manager{ReposMappings: []config.ExplicitMapping{
+		{CostCenter: "Payments", Repositories: []string{"Payments-*"}},
+		{CostCenter: "Infra", Repositories: []string{"acme/infra-*"}, PropertyName: "env", PropertyValues: []string{"prod"}, Combine: "all"},
+	}}
+	m, err := NewManager(cfg, nil, testLogger())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	prod := []github.Property{{PropertyName: "env", Value: "prod"}}
+	for _, tc := range []struct {
+		repo github.RepoProperties
+		want []bool // per mapping
+	}{
+		{github.RepoProperties{RepositoryFullName: "acme/payments-api"}, []bool{true, false}},
+		{github.RepoProperties{RepositoryFullName: "acme/infra-dns", Properties: prod}, []bool{false, true}},
+		{github.RepoProperties{RepositoryFullName: "acme/infra-dns"}, []bool{false, false}},
+		{github.RepoProperties{RepositoryFullName: "labs/infra-dns", Properties: prod}, []bool{false, false}},
+	} {
+		for i, mp := range m.mappings {
+			if got := m.matchers[i].matches(mp, tc.repo); got != tc.want[i] {
+				t.Errorf("%s on %s = %v, want %v", mp.CostCenter, tc.repo.RepositoryFullName, got, tc.want[i])
+			}
+		}
+	}
+}
+
 // --- matchesValue tests ---
 
 func TestMatchesValue_StringMatch(t *testing.T) {
 	allowed := map[string]bool{"eng": true, "devops": true}
-	if !matchesValue("eng", allowed) {
+	if !matchesValue("eng", inSet(allowed)) {
 		t.Error("expected true for matching string")
 	}
 }
 
 func TestMatchesValue_StringNoMatch(t *testing.T) {
 	allowed := map[string]bool{"eng": true}
-	if matchesValue("sales", allowed) {
+	if matchesValue("sales", inSet(allowed)) {
 		t.Error("expected false for non-matching string")
 	}
 }
@@ -285,7 +382,7 @@ func TestMatchesValue_StringNoMatch(t *testing.T) {
 func TestMatchesValue_ArrayMatch(t *testing.T) {
 	allowed := map[string]bool{"go": true}
 	val := []any{"python", "go", "javascript"}
-	if !matchesValue(val, allowed) {
+	if !matchesValue(val, inSet(allowed)) {
 		t.Error("expected true for array containing matching value")
 	}
 }
@@ -293,21 +390,21 @@ func TestMatchesValue_ArrayMatch(t *testing.T) {
 func TestMatchesValue_ArrayNoMatch(t *testing.T) {
 	allowed := map[string]bool{"rust": true}
 	val := []any{"python", "go"}
-	if matchesValue(val, allowed) {
+	if matchesValue(val, inSet(allowed)) {
 		t.Error("expected false for array not containing matching value")
 	}
 }
 
 func TestMatchesValue_NilValue(t *testing.T) {
 	allowed := map[string]bool{"eng": true}
-	if matchesValue(nil, allowed) {
+	if matchesValue(nil, inSet(allowed)) {
 		t.Error("expected false for nil value")
 	}
 }
 
 func TestMatchesValue_IntValue(t *testing.T) {
 	allowed := map[string]bool{"eng": true}
-	if matchesValue(42, allowed) {
+	if matchesValue(42, inSet(allowed)) {
 		t.Error("expected false for int value")
 	}
 }
@@ -315,7 +412,7 @@ func TestMatchesValue_IntValue(t *testing.T) {
 func TestMatchesValue_EmptyArray(t *testing.T) {
 	allowed := map[string]bool{"eng": true}
 	val := []any{}
-	if matchesValue(val, allowed) {
+	if matchesValue(val, inSet(allowed)) {
 		t.Error("expected false for empty array")
 	}
 }
@@ -415,7 +512,7 @@ func TestCreateBudgets_AllSucceed(t *testing.T) {
 	}
 	mgr := newTestManagerWithClient(t, client, products)
 
-	err := mgr.createBudgets("cc-id-1", "Test CC")
+	err := mgr.createBudgets(t.Context(), "cc-id-1", "Test CC")
 	if err != nil {
 		t.Errorf("expected nil error, got %v", err)
 	}
@@ -439,7 +536,7 @@ func TestCreateBudgets_PartialFailure(t *testing.T) {
 	}
 	mgr := newTestManagerWithClient(t, client, products)
 
-	err := mgr.createBudgets("cc-id-1", "Fail CC")
+	err := mgr.createBudgets(t.Context(), "cc-id-1", "Fail CC")
 	if err == nil {
 		t.Fatal("expected error for budget creation failure")
 	}
@@ -462,7 +559,7 @@ func TestCreateBudgets_APIUnavailable(t *testing.T) {
 	mgr := newTestManagerWithClient(t, client, products)
 
 	// 404 triggers BudgetsAPIUnavailableError — graceful degradation, returns nil.
-	err := mgr.createBudgets("cc-id-1", "Test CC")
+	err := mgr.createBudgets(t.Context(), "cc-id-1", "Test CC")
 	if err != nil {
 		t.Errorf("expected nil error for API unavailable, got %v", err)
 	}
@@ -477,8 +574,114 @@ func TestCreateBudgets_DisabledProducts(t *testing.T) {
 		log: testLogger(),
 	}
 
-	err := mgr.createBudgets("cc-id-1", "Test CC")
+	err := mgr.createBudgets(t.Context(), "cc-id-1", "Test CC")
 	if err != nil {
 		t.Errorf("expected nil error when all products disabled, got %v", err)
 	}
 }
+
+func TestRemoveNoLongerMatching(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	platform := srv.AddCostCenter("Platform")
+	client, err := github.NewClient(&config.Manager{Enterprise: srv.Enterprise, APIBaseURL: srv.URL, Token: "test-token"}, testLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if err := client.AddRepositoriesToCostCenter(t.Context(), platform,
+		[]string{"my-org/api", "my-org/legacy", "other-org/tool"}); err != nil {
+		t.Fatalf("seeding repositories: %v", err)
+	}
+
+	mappings := []config.ExplicitMapping{
+		{CostCenter: "Platform", PropertyName: "team", PropertyValues: []string{"platform"}},
+	}
+	m := newTestManager(mappings)
+	m.client = client
+	m.matchers = []mappingMatcher{{value: exact("platform")}}
+	allRepos := []github.RepoProperties{{
+		RepositoryFullName: "my-org/api",
+		Properties:         []github.Property{{PropertyName: "team", Value: "platform"}},
+	}}
+	activeCCs := map[string]string{"Platform": platform}
+
+	for _, mode := range []string{"plan", "apply"} {
+		summary := &Summary{MappingResults: []MappingResult{{CostCenter: "Platform", ReposMatched: 1, Success: true}}}
+		if err := m.removeNoLongerMatching(t.Context(), "my-org", mode, allRepos, activeCCs, summary); err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		r := summary.MappingResults[0]
+		if got := strings.Join(r.ReposUnmatched, ","); got != "my-org/legacy" {
+			t.Errorf("%s: unmatched = %q, want my-org/legacy", mode, got)
+		}
+		want := map[string]int{"plan": 0, "apply": 1}[mode]
+		if r.ReposRemoved != want {
+			t.Errorf("%s: removed = %d, want %d", mode, r.ReposRemoved, want)
+		}
+	}
+
+	cc, _ := srv.CostCenterByName("Platform")
+	if got := strings.Join(cc.Repositories, ","); got != "my-org/api,other-org/tool" {
+		t.Errorf("cost center repositories = %q, want my-org/api,other-org/tool", got)
+	}
+}
+
+func TestFilterCurrent(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	platform := srv.AddCostCenter("Platform")
+	data := srv.AddCostCenter("Data")
+	client, err := github.NewClient(&config.Manager{Enterprise: srv.Enterprise, APIBaseURL: srv.URL, Token: "test-token"}, testLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if err := client.AddRepositoriesToCostCenter(t.Context(), platform, []string{"my-org/api"}); err != nil {
+		t.Fatalf("seeding repositories: %v", err)
+	}
+	if err := client.AddRepositoriesToCostCenter(t.Context(), data, []string{"my-org/etl"}); err != nil {
+		t.Fatalf("seeding repositories: %v", err)
+	}
+
+	m := newTestManager([]config.ExplicitMapping{{CostCenter: "Platform", Topics: []string{"platform"}}})
+	m.client = client
+	m.SetCheckCurrent(true)
+	repos := []github.RepoProperties{
+		{RepositoryFullName: "my-org/api"},
+		{RepositoryFullName: "my-org/etl"},
+		{RepositoryFullName: "my-org/new"},
+	}
+	var result MappingResult
+	keep := m.filterCurrent(t.Context(), "Platform", platform, repos, &result)
+
+	if len(keep) != 1 || keep[0].RepositoryFullName != "my-org/new" {
+		t.Errorf("keep = %v, want only my-org/new", keep)
+	}
+	if result.ReposCurrent != 1 {
+		t.Errorf("ReposCurrent = %d, want 1", result.ReposCurrent)
+	}
+	if got := strings.Join(result.ReposElsewhere, ","); got != "my-org/etl (Data)" {
+		t.Errorf("ReposElsewhere = %q", got)
+	}
+}
+
+func TestFilterCurrent_SkipsReposThatCannotBeChecked(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	platform := srv.AddCostCenter("Platform")
+	client, err := github.NewClient(&config.Manager{Enterprise: srv.Enterprise, APIBaseURL: srv.URL, Token: "test-token"}, testLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	srv.DisableCostCenters()
+
+	m := newTestManager([]config.ExplicitMapping{{CostCenter: "Platform", Topics: []string{"platform"}}})
+	m.client = client
+	m.SetCheckCurrent(true)
+	repos := []github.RepoProperties{{RepositoryFullName: "my-org/api"}}
+	var result MappingResult
+	keep := m.filterCurrent(t.Context(), "Platform", platform, repos, &result)
+
+	if len(keep) != 0 {
+		t.Errorf("keep = %v, want none", keep)
+	}
+	if got := strings.Join(result.ReposUnchecked, ","); got != "my-org/api" {
+		t.Errorf("ReposUnchecked = %q, want my-org/api", got)
+	}
+}
@@ -0,0 +1,261 @@
+// Package rules evaluates the ordered assignment rules of rules mode.  Each
+// rule inspects what is known about a user (login, organizations, teams,
+// Copilot seat plan, last activity and identity provider attributes), by
+// list conditions or a CEL when expression, and names a cost center; the
+// first rule that matches decides, so mixed policies (teams for
+// engineering, the organization for sales, the PRU split elsewhere) live
+// in one list.
+package rules
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+)
+
+// Subject is what the rules see of a user.
+type Subject struct {
+	Login string
+	Orgs  []string // organizations the user belongs to
+	Teams []string // team keys ("org/team-slug", or slugs in enterprise scope)
+	Plan  string   // Copilot seat plan
+	// LastActivity is the last Copilot activity; zero when never active.
+	LastActivity time.Time
+	// Attributes are the identity attributes by lower-cased name (see
+	// github.ExternalIdentity.AttributeMap).
+	Attributes map[string][]string
+}
+
+// rule is a compiled config.AssignmentRule.
+type rule struct {
+	name         string
+	logins       []config.LoginPattern
+	teams        []string // lower-cased globs
+	orgs         []string // lower-cased globs
+	plans        []string
+	attributes   map[string][]string // lower-cased name -> lower-cased globs
+	inactiveDays int
+	when         *config.RuleCondition // nil without a when expression
+	costCenter   string
+}
+
+// Engine evaluates rules in order.
+type Engine struct {
+	rules []rule
+	now   time.Time
+}
+
+// New compiles rules, as validated by config.  now is the reference for
+// inactive_days.
+func New(rules []config.AssignmentRule, now time.Time) (*Engine, error) {
+	e := &Engine{now: now}
+	for i, r := range rules {
+		c := rule{
+			name:         r.Name,
+			plans:        r.Plans,
+			inactiveDays: r.InactiveDays,
+			costCenter:   attrPlaceholder.ReplaceAllStringFunc(r.CostCenter, strings.ToLower),
+		}
+		if c.name == "" {
+			c.name = fmt.Sprintf("#%d", i+1)
+		}
+		if r.When != "" {
+			when, err := config.ParseRuleCondition(r.When)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: when: %w", c.name, err)
+			}
+			c.when = when
+		}
+		for _, l := range r.Logins {
+			p, err := config.ParseLoginPattern(l)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", c.name, err)
+			}
+			c.logins = append(c.logins, p)
+		}
+		for _, t := range r.Teams {
+			c.teams = append(c.teams, strings.ToLower(t))
+		}
+		for _, o := range r.Orgs {
+			c.orgs = append(c.orgs, strings.ToLower(o))
+		}
+		for name, globs := range r.Attributes {
+			if c.attributes == nil {
+				c.attributes = make(map[string][]string)
+			}
+			key := strings.ToLower(name)
+			for _, g := range globs {
+				c.attributes[key] = append(c.attributes[key], strings.ToLower(g))
+			}
+		}
+		e.rules = append(e.rules, c)
+	}
+	return e, nil
+}
+
+// UsesOrgs reports whether any rule has an orgs condition or reads orgs,
+// so organization memberships are worth fetching.
+func (e *Engine) UsesOrgs() bool {
+	for _, r := range e.rules {
+		if len(r.orgs) > 0 || r.when.Reads(config.RuleVarOrgs) {
+			return true
+		}
+	}
+	return false
+}
+
+// UsesAttributes reports whether any rule has an attributes condition or
+// reads attributes, so identity provider identities are worth fetching.
+func (e *Engine) UsesAttributes() bool {
+	for _, r := range e.rules {
+		if len(r.attributes) > 0 || r.when.Reads(config.RuleVarAttributes) {
+			return true
+		}
+	}
+	return false
+}
+
+// UsesTeams reports whether any rule has a teams condition or reads teams.
+func (e *Engine) UsesTeams() bool {
+	for _, r := range e.rules {
+		if len(r.teams) > 0 || r.when.Reads(config.RuleVarTeams) {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate returns the cost center of the first rule s matches, with its
+// placeholders filled in, and the rule's name.  ok is false when no rule
+// matches.
+func (e *Engine) Evaluate(s Subject) (costCenter, ruleName string, ok bool) {
+	for _, r := range e.rules {
+		vars, matched := r.match(s, e.now)
+		if !matched {
+			continue
+		}
+		cc := r.costCenter
+		for k, v := range vars {
+			cc = strings.ReplaceAll(cc, "{"+k+"}", v)
+		}
+		return cc, r.name, true
+	}
+	return "", "", false
+}
+
+// match reports whether s meets every condition of r, and returns the
+// placeholder values of the entries that matched.  A when expression that
+// fails for s, e.g. on an attribute s lacks, does not match.
+func (r rule) match(s Subject, now time.Time) (map[string]string, bool) {
+	vars := map[string]string{"login": s.Login}
+
+	if len(r.logins) > 0 {
+		hit := false
+		for _, p := range r.logins {
+			if p.Match(s.Login) {
+				hit = true
+				break
+			}
+		}
+		if !hit {
+			return nil, false
+		}
+	}
+	if len(r.orgs) > 0 {
+		org, hit := firstGlobMatch(r.orgs, s.Orgs)
+		if !hit {
+			return nil, false
+		}
+		vars["org"] = org
+	}
+	if len(r.teams) > 0 {
+		team, hit := firstGlobMatch(r.teams, s.Teams)
+		if !hit {
+			return nil, false
+		}
+		org, slug, _ := strings.Cut(team, "/")
+		if _, set := vars["org"]; !set {
+			vars["org"] = org
+		}
+		vars["team"] = slug
+	}
+	for name, globs := range r.attributes {
+		value, hit := firstGlobMatch(globs, s.Attributes[name])
+		if !hit {
+			return nil, false
+		}
+		vars["attr."+name] = value
+	}
+	if len(r.plans) > 0 {
+		hit := false
+		for _, p := range r.plans {
+			if strings.EqualFold(p, s.Plan) {
+				hit = true
+				break
+			}
+		}
+		if !hit {
+			return nil, false
+		}
+	}
+	if r.inactiveDays > 0 && !s.LastActivity.IsZero() &&
+		now.Sub(s.LastActivity) < time.Duration(r.inactiveDays)*24*time.Hour {
+		return nil, false
+	}
+	if r.when != nil {
+		if ok, err := r.when.Match(s.whenVars(now)); err != nil || !ok {
+			return nil, false
+		}
+	}
+	return vars, true
+}
+
+// whenVars returns the when expression variables of s.
+func (s Subject) whenVars(now time.Time) map[string]any {
+	vars := map[string]any{
+		config.RuleVarLogin:        s.Login,
+		config.RuleVarOrgs:         nonNil(s.Orgs),
+		config.RuleVarTeams:        nonNil(s.Teams),
+		config.RuleVarPlan:         s.Plan,
+		config.RuleVarAttributes:   s.Attributes,
+		config.RuleVarInactiveDays: 0,
+		config.RuleVarNeverActive:  s.LastActivity.IsZero(),
+	}
+	if s.Attributes == nil {
+		vars[config.RuleVarAttributes] = map[string][]string{}
+	}
+	if !s.LastActivity.IsZero() {
+		vars[config.RuleVarInactiveDays] = int(now.Sub(s.LastActivity) / (24 * time.Hour))
+	}
+	return vars
+}
+
+// nonNil returns s, or an empty slice for nil.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// attrPlaceholder matches {attr.NAME} placeholders, whose names are
+// lower-cased like attribute keys.
+var attrPlaceholder = regexp.MustCompile(`\{attr\.[^{}]*\}`)
+
+// firstGlobMatch returns the first of values, in order, that matches any
+// of globs (lower-cased), ignoring case.
+func firstGlobMatch(globs, values []string) (string, bool) {
+	for _, v := range values {
+		lower := strings.ToLower(v)
+		for _, g := range globs {
+			if ok, _ := path.Match(g, lower); ok {
+				return v, true
+			}
+		}
+	}
+	return "", false
+}
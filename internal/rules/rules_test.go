@@ -0,0 +1,131 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+)
+
+func TestEvaluate_Order(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	e, err := New([]config.AssignmentRule{
+		{Name: "services", Logins: []string{"svc-*"}, CostCenter: "Automation"},
+		{Name: "engineering", Teams: []string{"acme/eng-*"}, CostCenter: "Eng {team}"},
+		{Name: "sales", Orgs: []string{"acme-sales", "*-field"}, CostCenter: "[org] {org}"},
+		{Name: "dormant", InactiveDays: 90, Plans: []string{"business"}, CostCenter: "Dormant"},
+	}, now)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if !e.UsesOrgs() || !e.UsesTeams() {
+		t.Errorf("UsesOrgs = %v, UsesTeams = %v", e.UsesOrgs(), e.UsesTeams())
+	}
+
+	for _, tc := range []struct {
+		name    string
+		subject Subject
+		cc      string
+		rule    string
+	}{
+		{"login glob wins first", Subject{Login: "SVC-ci", Teams: []string{"acme/eng-web"}}, "Automation", "services"},
+		{"team placeholder", Subject{Login: "alice", Teams: []string{"acme/ops", "ACME/eng-Web"}}, "Eng eng-Web", "engineering"},
+		{"org placeholder", Subject{Login: "bob", Orgs: []string{"acme", "emea-field"}}, "[org] emea-field", "sales"},
+		{"never active", Subject{Login: "carol", Plan: "Business"}, "Dormant", "dormant"},
+		{"inactive", Subject{Login: "dave", Plan: "business", LastActivity: now.AddDate(0, 0, -120)}, "Dormant", "dormant"},
+		{"recently active", Subject{Login: "erin", Plan: "business", LastActivity: now.AddDate(0, 0, -3)}, "", ""},
+		{"other plan", Subject{Login: "frank", Plan: "enterprise"}, "", ""},
+	} {
+		cc, rule, ok := e.Evaluate(tc.subject)
+		if cc != tc.cc || rule != tc.rule || ok != (tc.cc != "") {
+			t.Errorf("%s: Evaluate = %q, %q, %v; want %q, %q", tc.name, cc, rule, ok, tc.cc, tc.rule)
+		}
+	}
+}
+
+func TestEvaluate_Attributes(t *testing.T) {
+	e, err := New([]config.AssignmentRule{
+		{Name: "contractors", Attributes: map[string][]string{"EmployeeType": {"contractor"}}, CostCenter: "Contractors"},
+		{Name: "department", Attributes: map[string][]string{"department": {"*"}, "employeeid": {"e*"}}, CostCenter: "Dept {attr.Department}"},
+	}, time.Now())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if !e.UsesAttributes() {
+		t.Error("UsesAttributes = false")
+	}
+	for _, tc := range []struct {
+		attrs map[string][]string
+		cc    string
+	}{
+		{map[string][]string{"employeetype": {"Contractor"}, "department": {"R&D"}}, "Contractors"},
+		{map[string][]string{"department": {"R&D"}, "employeeid": {"E1234"}}, "Dept R&D"},
+		{map[string][]string{"department": {"R&D"}, "employeeid": {"C99"}}, ""},
+		{nil, ""},
+	} {
+		if cc, _, _ := e.Evaluate(Subject{Login: "alice", Attributes: tc.attrs}); cc != tc.cc {
+			t.Errorf("Evaluate(%v) = %q, want %q", tc.attrs, cc, tc.cc)
+		}
+	}
+}
+
+func TestEvaluate_CatchAll(t *testing.T) {
+	e, err := New([]config.AssignmentRule{{CostCenter: "Everyone ({login})"}}, time.Now())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if e.UsesOrgs() || e.UsesTeams() {
+		t.Error("a rule without conditions needs no memberships")
+	}
+	cc, rule, ok := e.Evaluate(Subject{Login: "alice"})
+	if !ok || cc != "Everyone (alice)" || rule != "#1" {
+		t.Errorf("Evaluate = %q, %q, %v", cc, rule, ok)
+	}
+}
+
+func TestEvaluate_When(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	e, err := New([]config.AssignmentRule{
+		{Name: "eng-not-contractors", Orgs: []string{"acme"},
+			When:       `teams.exists(t, t.startsWith("acme/eng-")) && !(attributes[?"employeetype"].orValue([]).exists(v, v.lowerAscii() == "contractor"))`,
+			CostCenter: "Eng {org}"},
+		{Name: "sales-or-field", When: `"acme-sales" in orgs || login.endsWith("-field")`, CostCenter: "Sales"},
+		{Name: "stale", When: `never_active || inactive_days >= 60`, CostCenter: "Stale"},
+		{Name: "finance", When: `attributes.department.exists(d, d == "Finance")`, CostCenter: "Finance"},
+	}, now)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if !e.UsesOrgs() || !e.UsesTeams() || !e.UsesAttributes() {
+		t.Errorf("UsesOrgs = %v, UsesTeams = %v, UsesAttributes = %v", e.UsesOrgs(), e.UsesTeams(), e.UsesAttributes())
+	}
+
+	recent := now.AddDate(0, 0, -3)
+	for _, tc := range []struct {
+		name    string
+		subject Subject
+		cc      string
+	}{
+		{"team, employee", Subject{Login: "alice", Orgs: []string{"acme"}, Teams: []string{"acme/eng-web"}, LastActivity: recent}, "Eng acme"},
+		{"team, contractor", Subject{Login: "bob", Orgs: []string{"acme"}, Teams: []string{"acme/eng-web"}, LastActivity: recent,
+			Attributes: map[string][]string{"employeetype": {"Contractor"}}}, ""},
+		{"org alternative", Subject{Login: "carol", Orgs: []string{"acme-sales"}, LastActivity: recent}, "Sales"},
+		{"login alternative", Subject{Login: "dave-field", LastActivity: recent}, "Sales"},
+		{"never active", Subject{Login: "erin"}, "Stale"},
+		{"inactive", Subject{Login: "frank", LastActivity: now.AddDate(0, 0, -61)}, "Stale"},
+		{"missing attribute does not match", Subject{Login: "gina", LastActivity: recent}, ""},
+		{"attribute", Subject{Login: "hal", LastActivity: recent, Attributes: map[string][]string{"department": {"Finance"}}}, "Finance"},
+	} {
+		if cc, _, _ := e.Evaluate(tc.subject); cc != tc.cc {
+			t.Errorf("%s: Evaluate = %q, want %q", tc.name, cc, tc.cc)
+		}
+	}
+}
+
+func TestNew_InvalidWhen(t *testing.T) {
+	for _, when := range []string{`login ==`, `login`, `unknown == "x"`} {
+		if _, err := New([]config.AssignmentRule{{When: when, CostCenter: "X"}}, time.Now()); err == nil {
+			t.Errorf("New(when %q) succeeded, want an error", when)
+		}
+	}
+}
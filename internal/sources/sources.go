@@ -0,0 +1,89 @@
+// Package sources merges the user assignments proposed by several
+// assignment sources (an overrides file, team membership, the PRU rules)
+// into one placement per user.  Sources are consulted in priority order and
+// the first source that places a user wins, so modes compose predictably
+// instead of overwriting each other.
+package sources
+
+import (
+	"sort"
+	"strings"
+)
+
+// Source names, as listed in cost_center.sources.
+const (
+	Overrides = "overrides"  // login -> cost center file
+	Teams     = "teams"      // team membership (teams mode)
+	IdPGroups = "idp_groups" // identity provider groups (idp-groups mode)
+	Users     = "users"      // PRU rules (users mode), the catch-all default
+)
+
+// Proposal is the placement a single source wants: cost center (name or
+// UUID) -> logins.
+type Proposal map[string][]string
+
+// Result is the merged placement of every user.
+type Result struct {
+	// Assignments maps cost center -> logins, sorted.
+	Assignments map[string][]string
+	// Winner maps lower-cased login -> the source that placed the user.
+	Winner map[string]string
+	// Contributors maps cost center -> the sources that placed users in
+	// it, in priority order.
+	Contributors map[string][]string
+	// Shadowed counts, per source, the proposed placements that were
+	// dropped because the user was already placed.
+	Shadowed map[string]int
+}
+
+// Resolve merges proposals (source -> proposal) in order.  Logins are
+// matched case-insensitively and keep the spelling of the winning source.
+// A source that proposes one user for several cost centers places the user
+// in the first of them by name.  Sources in order without a proposal are
+// skipped.
+func Resolve(order []string, proposals map[string]Proposal) Result {
+	r := Result{
+		Assignments:  make(map[string][]string),
+		Winner:       make(map[string]string),
+		Contributors: make(map[string][]string),
+		Shadowed:     make(map[string]int),
+	}
+	for _, src := range order {
+		p := proposals[src]
+		names := make([]string, 0, len(p))
+		for name := range p {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			placed := false
+			for _, login := range p[name] {
+				key := strings.ToLower(login)
+				if _, taken := r.Winner[key]; taken {
+					r.Shadowed[src]++
+					continue
+				}
+				r.Winner[key] = src
+				r.Assignments[name] = append(r.Assignments[name], login)
+				placed = true
+			}
+			if placed {
+				r.Contributors[name] = append(r.Contributors[name], src)
+			}
+		}
+	}
+	for _, logins := range r.Assignments {
+		sort.Strings(logins)
+	}
+	return r
+}
+
+// Placed returns how many users each source placed.
+func (r Result) Placed() map[string]int {
+	n := make(map[string]int)
+	for _, src := range r.Winner {
+		n[src]++
+	}
+	return n
+}
@@ -0,0 +1,50 @@
+package sources
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolve_Priority(t *testing.T) {
+	proposals := map[string]Proposal{
+		Overrides: {"CC Platform": {"Alice"}},
+		Teams:     {"CC Data": {"alice", "bob"}, "CC Web": {"bob", "carol"}},
+		Users:     {"00 - No PRU overages": {"alice", "bob", "carol", "dave"}},
+	}
+	r := Resolve([]string{Overrides, Teams, IdPGroups, Users}, proposals)
+
+	want := map[string][]string{
+		"CC Platform":          {"Alice"},
+		"CC Data":              {"bob"},
+		"CC Web":               {"carol"},
+		"00 - No PRU overages": {"dave"},
+	}
+	if !reflect.DeepEqual(r.Assignments, want) {
+		t.Errorf("Assignments = %v, want %v", r.Assignments, want)
+	}
+	if r.Winner["alice"] != Overrides || r.Winner["dave"] != Users {
+		t.Errorf("Winner = %v", r.Winner)
+	}
+	// alice is shadowed in teams; bob is in two teams and lands in the first
+	// cost center by name.
+	if r.Shadowed[Teams] != 2 || r.Shadowed[Users] != 3 {
+		t.Errorf("Shadowed = %v", r.Shadowed)
+	}
+	if got := r.Placed(); got[Overrides] != 1 || got[Teams] != 2 || got[Users] != 1 {
+		t.Errorf("Placed = %v", got)
+	}
+}
+
+func TestResolve_OrderDecides(t *testing.T) {
+	proposals := map[string]Proposal{
+		Teams: {"CC Data": {"alice"}},
+		Users: {"00 - No PRU overages": {"alice"}},
+	}
+	r := Resolve([]string{Users, Teams}, proposals)
+	if !reflect.DeepEqual(r.Assignments, map[string][]string{"00 - No PRU overages": {"alice"}}) {
+		t.Errorf("Assignments = %v", r.Assignments)
+	}
+	if !reflect.DeepEqual(r.Contributors, map[string][]string{"00 - No PRU overages": {Users}}) {
+		t.Errorf("Contributors = %v", r.Contributors)
+	}
+}
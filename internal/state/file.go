@@ -0,0 +1,40 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// LoadFile reads the JSON state file at path into v.  A missing file leaves
+// v unchanged.  what names the file in errors, e.g. "team names file".
+func LoadFile(path, what string, v any) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", what, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parsing %s: %w", what, err)
+	}
+	return nil
+}
+
+// SaveFile writes v to the JSON state file at path, creating its
+// directory.  what names the file in errors, as in LoadFile.
+func SaveFile(path, what string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating export directory: %w", err)
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling %s: %w", what, err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", what, err)
+	}
+	return nil
+}
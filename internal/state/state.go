@@ -0,0 +1,234 @@
+// Package state captures the cost center membership graph of an enterprise
+// (cost centers, their users and repositories) into versioned JSON
+// snapshots.  A snapshot is taken before every apply so later commands can
+// compare against or restore an earlier layout.  The package also reads
+// and writes the small JSON state files kept in the export directory.
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultDir is the snapshot directory inside the export directory.
+	DefaultDir = "snapshots"
+	// Latest refers to the most recent snapshot of an enterprise in Load.
+	Latest = "latest"
+	// currentVersion is the snapshot format version.
+	currentVersion = 1
+	// idLayout formats snapshot IDs from the capture time.
+	idLayout = "20060102T150405Z"
+)
+
+// Reasons recorded in snapshots.
+const (
+	ReasonPreApply    = "pre-apply"
+	ReasonPreRollback = "pre-rollback"
+	ReasonManual      = "manual"
+)
+
+// ErrNotFound is returned by Load when no snapshot matches.
+var ErrNotFound = errors.New("snapshot not found")
+
+// CostCenter is one cost center and its members at capture time.
+type CostCenter struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Users        []string `json:"users"`
+	Repositories []string `json:"repositories"`
+}
+
+// Snapshot is the membership graph of one enterprise at one point in time.
+type Snapshot struct {
+	Version     int          `json:"version"`
+	ID          string       `json:"id"`
+	Enterprise  string       `json:"enterprise"`
+	TakenAt     time.Time    `json:"taken_at"`
+	Reason      string       `json:"reason"` // ReasonPreApply, ReasonPreRollback or ReasonManual
+	CostCenters []CostCenter `json:"cost_centers"`
+}
+
+// Counts returns the total users and repositories in the snapshot.
+func (s *Snapshot) Counts() (users, repos int) {
+	for _, cc := range s.CostCenters {
+		users += len(cc.Users)
+		repos += len(cc.Repositories)
+	}
+	return users, repos
+}
+
+// Source reads the current membership graph; *github.Client implements it.
+type Source interface {
+	GetAllActiveCostCenters(ctx context.Context) (map[string]string, error)
+	GetCostCenterMembers(ctx context.Context, id string) ([]string, error)
+	GetCostCenterRepositories(ctx context.Context, id string) ([]string, error)
+}
+
+// Capture reads every active cost center and its members from src.  Cost
+// centers and members are sorted so snapshots of an unchanged enterprise
+// are identical apart from their ID and time.
+func Capture(ctx context.Context, src Source, enterprise, reason string) (*Snapshot, error) {
+	active, err := src.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching active cost centers: %w", err)
+	}
+	snap := &Snapshot{
+		Version:     currentVersion,
+		Enterprise:  enterprise,
+		TakenAt:     time.Now().UTC(),
+		Reason:      reason,
+		CostCenters: make([]CostCenter, 0, len(active)),
+	}
+	for name, id := range active {
+		users, err := src.GetCostCenterMembers(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("fetching members of cost center %q: %w", name, err)
+		}
+		repos, err := src.GetCostCenterRepositories(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("fetching repositories of cost center %q: %w", name, err)
+		}
+		cc := CostCenter{
+			ID:           id,
+			Name:         name,
+			Users:        append([]string{}, users...),
+			Repositories: append([]string{}, repos...),
+		}
+		sort.Strings(cc.Users)
+		sort.Strings(cc.Repositories)
+		snap.CostCenters = append(snap.CostCenters, cc)
+	}
+	sort.Slice(snap.CostCenters, func(i, j int) bool { return snap.CostCenters[i].Name < snap.CostCenters[j].Name })
+	return snap, nil
+}
+
+// Store keeps snapshots as one JSON file each in a directory.
+type Store struct {
+	dir string
+}
+
+// NewStore returns a store for the snapshots directory inside exportDir.
+func NewStore(exportDir string) *Store {
+	return &Store{dir: filepath.Join(exportDir, DefaultDir)}
+}
+
+// Dir returns the snapshot directory.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Save writes snap, assigning its ID from the capture time (with a numeric
+// suffix if that ID is taken), and returns the file path.
+func (s *Store) Save(snap *Snapshot) (string, error) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", fmt.Errorf("creating snapshot directory: %w", err)
+	}
+	base := snap.TakenAt.UTC().Format(idLayout)
+	snap.ID = base
+	for n := 2; ; n++ {
+		if _, err := os.Stat(s.path(snap.ID)); os.IsNotExist(err) {
+			break
+		}
+		snap.ID = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encoding snapshot: %w", err)
+	}
+	path := s.path(snap.ID)
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return "", fmt.Errorf("writing snapshot %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// List returns the snapshots of enterprise, oldest first.  A missing
+// directory yields an empty list.
+func (s *Store) List(enterprise string) ([]*Snapshot, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot directory: %w", err)
+	}
+	var snaps []*Snapshot
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		snap, err := s.read(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if snap.Enterprise == enterprise {
+			snaps = append(snaps, snap)
+		}
+	}
+	sort.Slice(snaps, func(i, j int) bool {
+		if !snaps[i].TakenAt.Equal(snaps[j].TakenAt) {
+			return snaps[i].TakenAt.Before(snaps[j].TakenAt)
+		}
+		return snaps[i].ID < snaps[j].ID
+	})
+	return snaps, nil
+}
+
+// Load returns the snapshot with the given ID, or the most recent one of
+// enterprise when id is Latest.  A snapshot of another enterprise is
+// rejected.
+func (s *Store) Load(enterprise, id string) (*Snapshot, error) {
+	if id == Latest {
+		snaps, err := s.List(enterprise)
+		if err != nil {
+			return nil, err
+		}
+		if len(snaps) == 0 {
+			return nil, fmt.Errorf("%w: no snapshots of %q in %s", ErrNotFound, enterprise, s.dir)
+		}
+		return snaps[len(snaps)-1], nil
+	}
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("invalid snapshot ID %q", id)
+	}
+	snap, err := s.read(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if snap.Enterprise != enterprise {
+		return nil, fmt.Errorf("snapshot %s is for enterprise %q, not %q", id, snap.Enterprise, enterprise)
+	}
+	return snap, nil
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// read loads and version-checks one snapshot file.
+func (s *Store) read(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot: %w", err)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("parsing snapshot %s: %w", path, err)
+	}
+	if snap.Version != currentVersion {
+		return nil, fmt.Errorf("snapshot %s has version %d, expected %d", path, snap.Version, currentVersion)
+	}
+	return &snap, nil
+}
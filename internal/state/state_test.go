@@ -0,0 +1,145 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSource serves a fixed membership graph.
+type fakeSource struct {
+	active map[string]string
+	users  map[string][]string
+	repos  map[string][]string
+}
+
+func (f fakeSource) GetAllActiveCostCenters(context.Context) (map[string]string, error) {
+	return f.active, nil
+}
+func (f fakeSource) GetCostCenterMembers(_ context.Context, id string) ([]string, error) {
+	return f.users[id], nil
+}
+func (f fakeSource) GetCostCenterRepositories(_ context.Context, id string) ([]string, error) {
+	return f.repos[id], nil
+}
+
+func TestCapture_SortsCostCentersAndMembers(t *testing.T) {
+	src := fakeSource{
+		active: map[string]string{"Platform": "cc-2", "Eng": "cc-1"},
+		users:  map[string][]string{"cc-1": {"bob", "alice"}},
+		repos:  map[string][]string{"cc-2": {"org/b", "org/a"}},
+	}
+	snap, err := Capture(t.Context(), src, "ent", ReasonManual)
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	want := []CostCenter{
+		{ID: "cc-1", Name: "Eng", Users: []string{"alice", "bob"}, Repositories: []string{}},
+		{ID: "cc-2", Name: "Platform", Users: []string{}, Repositories: []string{"org/a", "org/b"}},
+	}
+	if !reflect.DeepEqual(snap.CostCenters, want) {
+		t.Errorf("cost centers = %+v, want %+v", snap.CostCenters, want)
+	}
+	if u, r := snap.Counts(); u != 2 || r != 2 {
+		t.Errorf("Counts = %d, %d", u, r)
+	}
+}
+
+func TestStore_SaveListLoad(t *testing.T) {
+	store := NewStore(t.TempDir())
+	at := time.Date(2026, 4, 1, 10, 0, 0, 0, time.UTC)
+
+	first := &Snapshot{Version: currentVersion, Enterprise: "ent", TakenAt: at, Reason: ReasonPreApply}
+	second := &Snapshot{Version: currentVersion, Enterprise: "ent", TakenAt: at, Reason: ReasonManual}
+	other := &Snapshot{Version: currentVersion, Enterprise: "other", TakenAt: at.Add(time.Hour)}
+	for _, s := range []*Snapshot{first, second, other} {
+		if _, err := store.Save(s); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	if first.ID != "20260401T100000Z" || second.ID != "20260401T100000Z-2" {
+		t.Errorf("IDs = %q, %q", first.ID, second.ID)
+	}
+
+	snaps, err := store.List("ent")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(snaps) != 2 || snaps[0].ID != first.ID || snaps[1].ID != second.ID {
+		t.Errorf("List = %+v", snaps)
+	}
+
+	latest, err := store.Load("ent", Latest)
+	if err != nil || latest.ID != second.ID {
+		t.Errorf("Load(latest) = %+v, %v", latest, err)
+	}
+	if _, err := store.Load("ent", other.ID); err == nil {
+		t.Error("expected an error loading another enterprise's snapshot")
+	}
+	if _, err := store.Load("ent", "20990101T000000Z"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load(missing) error = %v, want ErrNotFound", err)
+	}
+	if _, err := store.Load("ent", "../x"); err == nil {
+		t.Error("expected an error for a path in the ID")
+	}
+}
+
+func TestStore_ListMissingDir(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "nope"))
+	snaps, err := store.List("ent")
+	if err != nil || len(snaps) != 0 {
+		t.Errorf("List = %v, %v; want empty", snaps, err)
+	}
+	if _, err := store.Load("ent", Latest); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load(latest) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStore_RejectsOtherVersion(t *testing.T) {
+	store := NewStore(t.TempDir())
+	if err := os.MkdirAll(store.Dir(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(store.Dir(), "x.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "enterprise": "ent"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load("ent", "x"); err == nil {
+		t.Error("expected a version error")
+	}
+}
+
+func TestSaveFile_LoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export", "names.json")
+	type record struct {
+		Names map[string]string `json:"names"`
+	}
+
+	var missing record
+	if err := LoadFile(path, "names file", &missing); err != nil || missing.Names != nil {
+		t.Fatalf("LoadFile of a missing file = %v, %+v; want nil, empty", err, missing)
+	}
+	want := record{Names: map[string]string{"acme/web": "CC Web"}}
+	if err := SaveFile(path, "names file", want); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	var got record
+	if err := LoadFile(path, "names file", &got); err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadFile = %+v, want %+v", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadFile(path, "names file", &got); err == nil || !strings.Contains(err.Error(), "parsing names file") {
+		t.Errorf("LoadFile of invalid JSON = %v, want a parsing error", err)
+	}
+}
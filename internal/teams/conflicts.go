@@ -0,0 +1,150 @@
+package teams
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+// Conflict is a user who is a member of several mapped teams that map to
+// different cost centers.
+type Conflict struct {
+	User        string
+	Teams       []string // team keys, ranked by the conflict strategy
+	CostCenters []string // the cost center of each team in Teams
+	Current     string   // the conflict strategy's cost center
+}
+
+// ConflictResolver chooses the cost center of a user in a Conflict.  An
+// empty costCenter keeps Current; remember persists the choice as an
+// override (cost_center.overrides_file).
+type ConflictResolver func(c Conflict) (costCenter string, remember bool, err error)
+
+// SetConflictResolver makes BuildTeamAssignments ask r about every user in
+// several teams with different cost centers, instead of applying the
+// conflict strategy on its own.  Users with an override are settled without asking.
+func (m *Manager) SetConflictResolver(r ConflictResolver) {
+	m.resolver = r
+}
+
+// settleConflicts applies overrides and, with a resolver, the operator's
+// choices to users in several teams.  teamsOf and ccsOf hold each user's
+// team keys and the cost center of each.  Choices are kept for the rest of
+// the run, so later builds do not ask again.
+func (m *Manager) settleConflicts(teamsOf, ccsOf map[string][]string, final map[string]UserAssignment) error {
+	users := make([]string, 0, len(teamsOf))
+	for user, teams := range teamsOf {
+		if len(teams) > 1 && distinct(ccsOf[user]) > 1 {
+			users = append(users, user)
+		}
+	}
+	sort.Strings(users)
+
+	settled := 0
+	var unsettled []string
+	for _, user := range users {
+		cc, ok := m.resolved[user]
+		if !ok {
+			cc, ok = m.cfg.Overrides[strings.ToLower(user)]
+		}
+		if !ok && m.resolver != nil {
+			choice, remember, err := m.resolver(Conflict{
+				User:        user,
+				Teams:       teamsOf[user],
+				CostCenters: ccsOf[user],
+				Current:     final[user].CostCenter,
+			})
+			if err != nil {
+				return fmt.Errorf("resolving team conflict for %s: %w", user, err)
+			}
+			if choice != "" {
+				cc, ok = choice, true
+				if remember {
+					if err := m.cfg.RecordOverride(user, choice); err != nil {
+						m.log.Warn("Could not record override, the choice applies to this run only", "user", user, "error", err)
+					}
+				}
+			}
+		}
+		if !ok {
+			if m.conflictStrategy == config.ConflictFail {
+				unsettled = append(unsettled, fmt.Sprintf("%s (%s)", user, strings.Join(teamsOf[user], ", ")))
+			}
+			continue
+		}
+		if m.resolved == nil {
+			m.resolved = make(map[string]string)
+		}
+		m.resolved[user] = cc
+		ua := final[user]
+		ua.CostCenter = cc
+		final[user] = ua
+		settled++
+	}
+	if settled > 0 {
+		m.log.Info("Multi-team users settled by override or choice", "count", settled)
+	}
+	if len(unsettled) > 0 {
+		return fmt.Errorf("%d users are in teams mapped to different cost centers (conflict_strategy %q); add overrides or change the strategy: %s",
+			len(unsettled), config.ConflictFail, strings.Join(unsettled, "; "))
+	}
+	return nil
+}
+
+// priorityRanks indexes team_priority by lower-cased team key.
+func priorityRanks(teams []string) map[string]int {
+	ranks := make(map[string]int, len(teams))
+	for i, team := range teams {
+		key := strings.ToLower(team)
+		if _, dup := ranks[key]; !dup {
+			ranks[key] = i
+		}
+	}
+	return ranks
+}
+
+// rankTeams orders a user's team assignments by the conflict strategy, the
+// winner first.  size holds each team key's member count.  Ties, and the
+// "fail" strategy, fall back to team key order so every run agrees.
+func (m *Manager) rankTeams(cands []UserAssignment, size map[string]int) []UserAssignment {
+	ranked := slices.Clone(cands)
+	key := func(ua UserAssignment) string {
+		return m.teamKey(ua.Org, github.Team{Slug: ua.TeamSlug})
+	}
+	rank := func(ua UserAssignment) int {
+		if r, ok := m.priority[strings.ToLower(key(ua))]; ok {
+			return r
+		}
+		return len(m.priority)
+	}
+	slices.SortStableFunc(ranked, func(a, b UserAssignment) int {
+		var c int
+		switch m.conflictStrategy {
+		case config.ConflictPriority:
+			c = cmp.Compare(rank(a), rank(b))
+		case config.ConflictSmallest:
+			c = cmp.Compare(size[key(a)], size[key(b)])
+		case config.ConflictLargest:
+			c = cmp.Compare(size[key(b)], size[key(a)])
+		}
+		if c != 0 {
+			return c
+		}
+		return cmp.Compare(strings.ToLower(key(a)), strings.ToLower(key(b)))
+	})
+	return ranked
+}
+
+// distinct counts the different values in s.
+func distinct(s []string) int {
+	seen := make(map[string]bool, len(s))
+	for _, v := range s {
+		seen[v] = true
+	}
+	return len(seen)
+}
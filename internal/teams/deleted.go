@@ -0,0 +1,206 @@
+package teams
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+// deletedTeamCostCenter is the active cost center of an auto strategy team
+// that no longer exists.
+type deletedTeamCostCenter struct {
+	name, id string
+	// idKey is the recorded team ID key, or "" when the cost center was
+	// found by its name.
+	idKey string
+}
+
+// deletedTeamAction returns what an apply does with d: the deleted_teams
+// setting for cost centers recorded by team ID, and warn for those only
+// named like a team, which may have been made by hand or belong to a team
+// renamed before its ID was recorded.
+func (m *Manager) deletedTeamAction(d deletedTeamCostCenter) string {
+	if d.idKey == "" {
+		return config.DeletedTeamsWarn
+	}
+	return m.cfg.TeamsDeletedTeams
+}
+
+// noteFetchedTeams remembers every listed team, including those
+// include/exclude skip, whose cost centers are not deleted teams'.
+func (m *Manager) noteFetchedTeams(allTeams map[string][]github.Team) {
+	m.fetchedIDs = make(map[string]bool)
+	m.fetchedNames = make(map[string]bool)
+	for source, teams := range allTeams {
+		for _, team := range teams {
+			if team.ID != 0 {
+				m.fetchedIDs[m.teamIDKey(team)] = true
+			}
+			m.fetchedNames[normalizeCCName(m.defaultCostCenterName(source, team))] = true
+		}
+	}
+}
+
+// defaultNameBase returns the unsuffixed name of a cost center named like
+// the auto strategy's default names, or ok=false for other names.
+func (m *Manager) defaultNameBase(name string) (string, bool) {
+	var matched bool
+	if m.scope == "enterprise" {
+		matched = strings.HasPrefix(name, "[enterprise team] ")
+	} else {
+		for _, org := range m.orgs {
+			if strings.HasPrefix(name, "[org team] "+org+"/") {
+				matched = true
+				break
+			}
+		}
+	}
+	if !matched {
+		return "", false
+	}
+	if i := strings.LastIndex(name, " ("); i > 0 {
+		if _, ok := nameSuffix(name, name[:i]); ok {
+			return name[:i], true
+		}
+	}
+	return name, true
+}
+
+// deletedTeamCostCenters returns the active cost centers (name → ID) of
+// auto strategy teams that no longer exist: those recorded for a team ID
+// the listing no longer returns and, without a name_template, those named
+// like a team no listed team generates.  Cost centers a team of this run
+// uses or the configuration targets are never included.
+func (m *Manager) deletedTeamCostCenters(active map[string]string) []deletedTeamCostCenter {
+	if m.mode != "auto" || m.fetchedIDs == nil {
+		return nil
+	}
+	configured, err := m.cfg.ConfiguredCostCenters()
+	if err != nil {
+		m.log.Warn("Could not check for cost centers of deleted teams", "error", err)
+		return nil
+	}
+	inUse := make(map[string]bool, len(m.ccNameCache)+len(configured))
+	for _, name := range m.ccNameCache {
+		inUse[name] = true
+	}
+	for _, cc := range configured {
+		inUse[cc] = true
+	}
+
+	var out []deletedTeamCostCenter
+	found := make(map[string]bool)
+	recorded, err := m.cfg.TeamIDCostCenterNames()
+	if err != nil {
+		m.log.Warn("Could not read recorded team cost centers", "error", err)
+	}
+	for idKey, name := range recorded {
+		id, ok := active[name]
+		if !ok || m.fetchedIDs[idKey] || inUse[name] || inUse[id] || found[name] {
+			continue
+		}
+		found[name] = true
+		out = append(out, deletedTeamCostCenter{name: name, id: id, idKey: idKey})
+	}
+	if m.nameTemplate == nil {
+		for name, id := range active {
+			if inUse[name] || inUse[id] || found[name] {
+				continue
+			}
+			base, ok := m.defaultNameBase(name)
+			if !ok || m.fetchedNames[normalizeCCName(name)] || m.fetchedNames[normalizeCCName(base)] {
+				continue
+			}
+			found[name] = true
+			out = append(out, deletedTeamCostCenter{name: name, id: id})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return out
+}
+
+// findDeletedTeamCostCenters lists the cost centers of deleted teams
+// against the active cost centers.  Lookup failures are logged and yield
+// none.  Once PreviewDeletedTeams has run, its list is returned, so an
+// apply acts on exactly the cost centers that were confirmed.
+func (m *Manager) findDeletedTeamCostCenters(ctx context.Context) []deletedTeamCostCenter {
+	if m.mode != "auto" {
+		return nil
+	}
+	if m.deletedPreviewed {
+		return m.deletedPreview
+	}
+	active, err := m.client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		m.log.Warn("Could not check for cost centers of deleted teams", "error", err)
+		return nil
+	}
+	return m.deletedTeamCostCenters(active)
+}
+
+// PreviewDeletedTeams returns the names of the cost centers of deleted
+// teams that an apply would empty or delete, for listing them before the
+// operator confirms.  It must follow BuildTeamAssignments; the apply of
+// this manager then acts on these cost centers only.
+func (m *Manager) PreviewDeletedTeams(ctx context.Context) []string {
+	m.deletedPreview = m.findDeletedTeamCostCenters(ctx)
+	m.deletedPreviewed = true
+	var names []string
+	for _, d := range m.deletedPreview {
+		if m.deletedTeamAction(d) != config.DeletedTeamsWarn {
+			names = append(names, d.name)
+		}
+	}
+	return names
+}
+
+// logDeletedTeams reports what an apply would do with the cost centers of
+// deleted teams.
+func (m *Manager) logDeletedTeams(deleted []deletedTeamCostCenter) {
+	for _, d := range deleted {
+		m.log.Info("Plan mode: team of cost center no longer exists",
+			"cost_center", d.name, "id", d.id, "action", m.deletedTeamAction(d))
+	}
+}
+
+// handleDeletedTeams applies cost_center.teams.deleted_teams to the cost
+// centers of deleted teams recorded by team ID; those found by name are
+// only logged.  Failures are logged; the next apply finds the cost centers
+// again.
+func (m *Manager) handleDeletedTeams(ctx context.Context, deleted []deletedTeamCostCenter) {
+	for _, d := range deleted {
+		switch m.deletedTeamAction(d) {
+		case config.DeletedTeamsEmpty:
+			users, err := m.client.GetCostCenterMembers(ctx, d.id)
+			if err != nil {
+				m.log.Error("Could not list users of deleted team's cost center", "cost_center", d.name, "error", err)
+				continue
+			}
+			if len(users) == 0 {
+				continue
+			}
+			if _, err := m.client.RemoveUsersFromCostCenter(ctx, d.id, users); err != nil {
+				m.log.Error("Could not empty cost center of deleted team", "cost_center", d.name, "error", err)
+				continue
+			}
+			m.log.Info("Emptied cost center of deleted team", "cost_center", d.name, "users", len(users))
+		case config.DeletedTeamsDelete:
+			if err := m.client.DeleteCostCenter(ctx, d.id, d.name); err != nil {
+				m.log.Error("Could not delete cost center of deleted team", "cost_center", d.name, "error", err)
+			}
+		default:
+			if d.idKey == "" {
+				m.log.Warn("Cost center is named like a team that no longer exists; it is left alone",
+					"cost_center", d.name, "id", d.id,
+					"hint", "remove it by hand or with cleanup once it is no longer used")
+				continue
+			}
+			m.log.Warn("Team of cost center no longer exists; its users stay assigned",
+				"cost_center", d.name, "id", d.id,
+				"hint", "set cost_center.teams.deleted_teams to empty or delete, or run cleanup")
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package teams
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+// deltaStats counts how the member lists of a run were obtained.
+type deltaStats struct {
+	reused    int // listing not modified, recorded members used
+	unchanged int // fetched, same members as recorded
+	changed   int // fetched, members differ from the record (or none)
+}
+
+// SetChangedTeamsOnly makes the manager revalidate the member listing of
+// each team against the ETags recorded by an earlier run instead of
+// fetching it.  Teams whose listing answers 304 Not Modified, which does
+// not count against the rate limit, reuse the recorded members; the rest
+// are fetched.
+func (m *Manager) SetChangedTeamsOnly(enabled bool) {
+	m.changedOnly = enabled
+}
+
+// membershipHash hashes a member list independent of its order.
+func membershipHash(members []string) string {
+	sorted := append([]string(nil), members...)
+	sort.Strings(sorted)
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\n")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// recordedMembership returns the membership of a team recorded by an
+// earlier run, or the zero value when there is none.
+func (m *Manager) recordedMembership(key string) config.TeamMembership {
+	if m.recorded == nil {
+		rec, err := m.cfg.TeamMemberships()
+		if err != nil {
+			m.log.Warn("Could not read recorded team memberships, fetching all teams", "error", err)
+			rec = map[string]config.TeamMembership{}
+		}
+		m.recorded = rec
+	}
+	return m.recorded[key]
+}
+
+// fetchChangedMembers returns the members of a team, revalidating the
+// recorded listing first (see SetChangedTeamsOnly).
+func (m *Manager) fetchChangedMembers(ctx context.Context, orgOrEnterprise, key string, team github.Team) ([]string, error) {
+	r := m.recordedMembership(key)
+	var members []github.TeamMember
+	var etags []string
+	var changed bool
+	var err error
+	if m.scope == "enterprise" {
+		members, etags, changed, err = m.client.EnterpriseTeamMembersIfChanged(ctx, team.Slug, r.ETags, len(r.Members))
+	} else {
+		members, etags, changed, err = m.client.OrgTeamMembersIfChanged(ctx, orgOrEnterprise, team.Slug, r.ETags, len(r.Members))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		m.log.Debug("Team unchanged since last run, reusing recorded members", "team", key, "members", len(r.Members))
+		m.delta.reused++
+		m.observe(key, r)
+		return r.Members, nil
+	}
+
+	usernames := memberLogins(members)
+	hash := membershipHash(usernames)
+	if r.Hash == hash {
+		m.delta.unchanged++
+	} else {
+		m.delta.changed++
+	}
+	m.observe(key, config.TeamMembership{
+		ETags:     etags,
+		Hash:      hash,
+		Members:   usernames,
+		FetchedAt: time.Now().UTC(),
+	})
+	return usernames, nil
+}
+
+// observe keeps the membership of a team seen in this run.
+func (m *Manager) observe(key string, r config.TeamMembership) {
+	if m.observed == nil {
+		m.observed = make(map[string]config.TeamMembership)
+	}
+	m.observed[key] = r
+}
+
+// recordMemberships persists the member lists of the teams seen in this
+// run for the next run with SetChangedTeamsOnly, and logs the delta.
+func (m *Manager) recordMemberships() {
+	if !m.changedOnly || len(m.observed) == 0 {
+		return
+	}
+	m.log.Info("Team membership delta",
+		"reused", m.delta.reused,
+		"fetched_unchanged", m.delta.unchanged,
+		"fetched_changed", m.delta.changed)
+	if err := m.cfg.RecordTeamMemberships(m.observed); err != nil {
+		m.log.Warn("Could not record team memberships", "error", err)
+	}
+}
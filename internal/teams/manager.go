@@ -4,17 +4,25 @@
 package teams
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
+	"text/template"
 
+	"github.com/renan-alm/gh-cost-center/internal/budgets"
 	"github.com/renan-alm/gh-cost-center/internal/config"
 	"github.com/renan-alm/gh-cost-center/internal/github"
 )
 
 // UserAssignment records the cost center assignment for a user found via a
-// team.  Only the final (last-team-wins) assignment is kept per user.
+// team.  Only one assignment is kept per user, chosen by the conflict
+// strategy when the user is in several teams.
 type UserAssignment struct {
 	Username   string
 	CostCenter string
@@ -36,6 +44,23 @@ type Manager struct {
 	mappings    map[string]string // team key -> CC name (manual mode)
 	removeUsers bool
 
+	// nameTemplate, when set, names auto strategy cost centers.
+	nameTemplate *template.Template
+	// marker finds cost_center.teams.description_key in team
+	// descriptions; nil when it is not set.
+	marker *regexp.Regexp
+	// includeTeam reports whether a team key passes teams.include and
+	// teams.exclude; nil processes every team.
+	includeTeam func(teamKey string) bool
+
+	// Multi-team users: see config.ConflictPriority and friends.
+	conflictStrategy string
+	priority         map[string]int // lower-cased team key -> rank
+
+	// Per-member split support.
+	splits      map[string]map[string]string // team key -> attribute -> CC name
+	memberAttrs map[string]string            // lower-cased login -> attribute
+
 	// Budget creation support.
 	createBudgets  bool
 	budgetProducts map[string]config.ProductBudget
@@ -44,23 +69,75 @@ type Manager struct {
 	teamsCache   map[string][]github.Team // org/enterprise -> teams
 	membersCache map[string][]string      // team-key -> usernames
 	ccNameCache  map[string]string        // team-key -> CC name
+
+	// Delta detection (see SetChangedTeamsOnly): the memberships recorded
+	// by the last run, those seen in this run, and how they were obtained.
+	changedOnly bool
+	recorded    map[string]config.TeamMembership
+	observed    map[string]config.TeamMembership
+	delta       deltaStats
+
+	// resolver, when set, chooses the cost center of users in several
+	// teams; resolved keeps the choices made in this run.
+	resolver ConflictResolver
+	resolved map[string]string
+
+	// collisionNames holds the auto-strategy names of teams whose generated
+	// cost center name collided, recorded on apply.
+	collisionNames map[string]string
+
+	// autoTeams maps the ID key (see teamIDKey) of each auto strategy team
+	// to its team key, for following team renames.
+	autoTeams map[string]string
+
+	// fetchedIDs and fetchedNames hold the ID keys and normalized default
+	// cost center names of every listed team, before include/exclude, for
+	// telling deleted teams from filtered ones.
+	fetchedIDs   map[string]bool
+	fetchedNames map[string]bool
+
+	// deletedPreview holds the cost centers of deleted teams listed by
+	// PreviewDeletedTeams, once deletedPreviewed is set.
+	deletedPreview   []deletedTeamCostCenter
+	deletedPreviewed bool
 }
 
 // NewManager creates a new teams manager from the resolved configuration.
 func NewManager(cfg *config.Manager, client *github.Client, logger *slog.Logger) *Manager {
+	var nameTemplate *template.Template
+	if cfg.TeamsNameTemplate != "" {
+		var err error
+		if nameTemplate, err = config.ParseTeamNameTemplate(cfg.TeamsNameTemplate); err != nil {
+			// Validated at load; a bad template here falls back to the
+			// default names.
+			logger.Error("Invalid cost_center.teams.name_template, using default names", "error", err)
+		}
+	}
+	includeTeam, err := cfg.TeamFilter()
+	if err != nil {
+		// Validated at load.
+		logger.Error("Invalid team filter, processing every team", "error", err)
+	}
 	return &Manager{
-		cfg:          cfg,
-		client:       client,
-		log:          logger,
-		scope:        cfg.TeamsScope,
-		mode:         cfg.TeamsStrategy,
-		orgs:         cfg.Organizations,
-		autoCreate:   cfg.TeamsAutoCreate,
-		mappings:     cfg.TeamsMappings,
-		removeUsers:  cfg.TeamsRemoveUnmatchedUsers,
-		teamsCache:   make(map[string][]github.Team),
-		membersCache: make(map[string][]string),
-		ccNameCache:  make(map[string]string),
+		cfg:              cfg,
+		client:           client,
+		log:              logger,
+		scope:            cfg.TeamsScope,
+		mode:             cfg.TeamsStrategy,
+		orgs:             cfg.Organizations,
+		autoCreate:       cfg.TeamsAutoCreate,
+		mappings:         cfg.TeamsMappings,
+		conflictStrategy: cfg.TeamsConflictStrategy,
+		removeUsers:      cfg.TeamsRemoveUnmatchedUsers,
+		nameTemplate:     nameTemplate,
+		includeTeam:      includeTeam,
+		marker:           markerPattern(cfg.TeamsDescriptionKey),
+		splits:           cfg.TeamsSplits,
+		memberAttrs:      cfg.TeamsMemberAttributes,
+		priority:         priorityRanks(cfg.TeamsPriority),
+		teamsCache:       make(map[string][]github.Team),
+		membersCache:     make(map[string][]string),
+		ccNameCache:      make(map[string]string),
 	}
 }
 
@@ -86,30 +163,45 @@ func (m *Manager) PrintConfigSummary(checkCurrent, createBudgets bool) {
 	fmt.Printf("Full sync (remove users who left teams): %v\n", m.removeUsers)
 	fmt.Printf("Check current cost center: %v\n", checkCurrent)
 	fmt.Printf("Create budgets: %v\n", createBudgets)
+	if m.conflictStrategy != "" {
+		fmt.Printf("Multi-team conflict strategy: %s\n", m.conflictStrategy)
+	}
 
 	switch m.mode {
 	case "auto":
-		if m.scope == "enterprise" {
+		if m.nameTemplate != nil {
+			fmt.Printf("Cost center naming: %s\n", m.cfg.TeamsNameTemplate)
+		} else if m.scope == "enterprise" {
 			fmt.Println("Cost center naming: [enterprise team] {team-name}")
 		} else {
 			fmt.Println("Cost center naming: [org team] {org-name}/{team-name}")
 		}
+		fmt.Printf("Cost centers of deleted teams: %s\n", m.cfg.TeamsDeletedTeams)
 	case "manual":
 		fmt.Printf("Manual mappings configured: %d\n", len(m.mappings))
 		for teamKey, cc := range m.mappings {
 			fmt.Printf("  - %s -> %s\n", teamKey, cc)
 		}
 	}
+	if len(m.splits) > 0 {
+		fmt.Printf("Team splits by member attribute: %d\n", len(m.splits))
+		for _, teamKey := range slices.Sorted(maps.Keys(m.splits)) {
+			split := m.splits[teamKey]
+			for _, attr := range slices.Sorted(maps.Keys(split)) {
+				fmt.Printf("  - %s [%s] -> %s\n", teamKey, attr, split[attr])
+			}
+		}
+	}
 	fmt.Println("===== End of Configuration =====")
 }
 
 // fetchAllTeams fetches teams from all configured sources (orgs or enterprise).
-func (m *Manager) fetchAllTeams() (map[string][]github.Team, error) {
+func (m *Manager) fetchAllTeams(ctx context.Context) (map[string][]github.Team, error) {
 	allTeams := make(map[string][]github.Team)
 
 	if m.scope == "enterprise" {
 		m.log.Info("Fetching enterprise teams", "enterprise", m.cfg.Enterprise)
-		teams, err := m.client.GetEnterpriseTeams()
+		teams, err := m.client.GetEnterpriseTeams(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("fetching enterprise teams: %w", err)
 		}
@@ -123,7 +215,7 @@ func (m *Manager) fetchAllTeams() (map[string][]github.Team, error) {
 		}
 		for _, org := range m.orgs {
 			m.log.Info("Fetching teams from organization", "org", org)
-			teams, err := m.client.GetOrgTeams(org)
+			teams, err := m.client.GetOrgTeams(ctx, org)
 			if err != nil {
 				return nil, fmt.Errorf("fetching teams for org %s: %w", org, err)
 			}
@@ -133,47 +225,75 @@ func (m *Manager) fetchAllTeams() (map[string][]github.Team, error) {
 		}
 	}
 
-	total := 0
-	for _, t := range allTeams {
-		total += len(t)
+	m.noteFetchedTeams(allTeams)
+
+	total, skipped := 0, 0
+	for source, teams := range allTeams {
+		total += len(teams)
+		if m.includeTeam == nil {
+			continue
+		}
+		kept := teams[:0:0]
+		for _, team := range teams {
+			if m.includeTeam(m.teamKey(source, team)) {
+				kept = append(kept, team)
+			} else {
+				m.log.Debug("Skipping team excluded by teams.include/exclude", "team", m.teamKey(source, team))
+				skipped++
+			}
+		}
+		allTeams[source] = kept
+		m.teamsCache[source] = kept
 	}
 	m.log.Info("Total teams fetched", "count", total)
+	if skipped > 0 {
+		m.log.Info("Teams skipped by include/exclude filters", "count", skipped, "processed", total-skipped)
+	}
 	return allTeams, nil
 }
 
-// fetchTeamMembers fetches the members of a team, using an in-memory cache.
-func (m *Manager) fetchTeamMembers(orgOrEnterprise, teamSlug string) ([]string, error) {
-	var cacheKey string
-	if m.scope == "enterprise" {
-		cacheKey = teamSlug
-	} else {
-		cacheKey = orgOrEnterprise + "/" + teamSlug
-	}
+// fetchTeamMembers fetches the members of a team, using an in-memory cache
+// and, with SetChangedTeamsOnly, the memberships recorded by earlier runs.
+func (m *Manager) fetchTeamMembers(ctx context.Context, orgOrEnterprise string, team github.Team) ([]string, error) {
+	cacheKey := m.teamKey(orgOrEnterprise, team)
 
 	if cached, ok := m.membersCache[cacheKey]; ok {
 		return cached, nil
 	}
 
-	var members []github.TeamMember
-	var err error
-	if m.scope == "enterprise" {
-		members, err = m.client.GetEnterpriseTeamMembers(teamSlug)
+	var usernames []string
+	if m.changedOnly {
+		var err error
+		if usernames, err = m.fetchChangedMembers(ctx, orgOrEnterprise, cacheKey, team); err != nil {
+			return nil, fmt.Errorf("fetching members for team %s: %w", cacheKey, err)
+		}
 	} else {
-		members, err = m.client.GetOrgTeamMembers(orgOrEnterprise, teamSlug)
-	}
-	if err != nil {
-		return nil, fmt.Errorf("fetching members for team %s: %w", cacheKey, err)
+		var members []github.TeamMember
+		var err error
+		if m.scope == "enterprise" {
+			members, err = m.client.GetEnterpriseTeamMembers(ctx, team.Slug)
+		} else {
+			members, err = m.client.GetOrgTeamMembers(ctx, orgOrEnterprise, team.Slug)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fetching members for team %s: %w", cacheKey, err)
+		}
+		usernames = memberLogins(members)
 	}
 
+	m.membersCache[cacheKey] = usernames
+	return usernames, nil
+}
+
+// memberLogins returns the non-empty logins of members.
+func memberLogins(members []github.TeamMember) []string {
 	usernames := make([]string, 0, len(members))
 	for _, member := range members {
 		if member.Login != "" {
 			usernames = append(usernames, member.Login)
 		}
 	}
-
-	m.membersCache[cacheKey] = usernames
-	return usernames, nil
+	return usernames
 }
 
 // costCenterForTeam determines the cost center name for a given team.
@@ -192,6 +312,16 @@ func (m *Manager) costCenterForTeam(orgOrEnterprise string, team github.Team) (s
 
 	var ccName string
 
+	// A mapping in the config wins over the team's own marker, which wins
+	// over the strategy.
+	if _, mapped := m.mappings[teamKey]; !mapped {
+		if cc, ok := m.markedCostCenter(team); ok {
+			m.log.Debug("Using cost center from team description", "team", teamKey, "cost_center", cc)
+			m.ccNameCache[teamKey] = cc
+			return cc, true
+		}
+	}
+
 	switch m.mode {
 	case "manual":
 		cc, ok := m.mappings[teamKey]
@@ -204,10 +334,21 @@ func (m *Manager) costCenterForTeam(orgOrEnterprise string, team github.Team) (s
 		ccName = cc
 
 	case "auto":
-		if m.scope == "enterprise" {
-			ccName = fmt.Sprintf("[enterprise team] %s", team.Name)
-		} else {
-			ccName = fmt.Sprintf("[org team] %s/%s", orgOrEnterprise, team.Name)
+		switch {
+		case m.nameTemplate != nil:
+			data := config.TeamNameData{Enterprise: m.cfg.Enterprise, TeamName: team.Name, TeamSlug: team.Slug}
+			if m.scope != "enterprise" {
+				data.Org = orgOrEnterprise
+			}
+			name, err := config.ExecuteTeamNameTemplate(m.nameTemplate, data)
+			if err != nil {
+				m.log.Error("Could not name team cost center from name_template, skipping team",
+					"team", teamKey, "error", err)
+				return "", false
+			}
+			ccName = name
+		default:
+			ccName = m.defaultCostCenterName(orgOrEnterprise, team)
 		}
 
 	default:
@@ -219,15 +360,76 @@ func (m *Manager) costCenterForTeam(orgOrEnterprise string, team github.Team) (s
 	return ccName, true
 }
 
+// defaultCostCenterName returns the auto strategy name of a team's cost
+// center when no name_template is set.
+func (m *Manager) defaultCostCenterName(orgOrEnterprise string, team github.Team) string {
+	if m.scope == "enterprise" {
+		return fmt.Sprintf("[enterprise team] %s", team.Name)
+	}
+	return fmt.Sprintf("[org team] %s/%s", orgOrEnterprise, team.Name)
+}
+
+// costCenterForMember returns the cost center for a single team member.  When
+// the team has a split configured and the member's attribute matches one of
+// its entries, that cost center wins; otherwise the team's cost center is
+// used.
+func (m *Manager) costCenterForMember(teamKey, teamCC, username string) string {
+	split, ok := m.splits[teamKey]
+	if !ok {
+		return teamCC
+	}
+	attr, ok := m.memberAttrs[strings.ToLower(username)]
+	if !ok {
+		m.log.Debug("No attribute for split team member, using team cost center",
+			"team", teamKey, "user", username)
+		return teamCC
+	}
+	if cc, ok := split[attr]; ok {
+		return cc
+	}
+	m.log.Debug("Attribute not mapped in team split, using team cost center",
+		"team", teamKey, "user", username, "attribute", attr)
+	return teamCC
+}
+
+// MemberTeams returns the keys (see teamKey) of the teams each user belongs
+// to, sorted, by lower-cased login.  Teams are listed in the configured
+// scope, after teams.include and teams.exclude, through the manager's
+// caches.
+func (m *Manager) MemberTeams(ctx context.Context) (map[string][]string, error) {
+	allTeams, err := m.fetchAllTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	teamsOf := make(map[string][]string)
+	for source, teams := range allTeams {
+		for _, team := range teams {
+			members, err := m.fetchTeamMembers(ctx, source, team)
+			if err != nil {
+				return nil, err
+			}
+			key := m.teamKey(source, team)
+			for _, login := range members {
+				lower := strings.ToLower(login)
+				teamsOf[lower] = append(teamsOf[lower], key)
+			}
+		}
+	}
+	for _, keys := range teamsOf {
+		sort.Strings(keys)
+	}
+	return teamsOf, nil
+}
+
 // BuildTeamAssignments builds the complete team->members mapping with cost
 // centers.  Users can only belong to ONE cost center; if a user appears in
-// multiple teams the last-team-wins.
+// multiple teams, cost_center.teams.conflict_strategy picks the team.
 //
 // Returns a map of costCenterName -> []UserAssignment.
-func (m *Manager) BuildTeamAssignments() (map[string][]UserAssignment, error) {
+func (m *Manager) BuildTeamAssignments(ctx context.Context) (map[string][]UserAssignment, error) {
 	m.log.Info("Building team-based cost center assignments...")
 
-	allTeams, err := m.fetchAllTeams()
+	allTeams, err := m.fetchAllTeams(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -237,13 +439,20 @@ func (m *Manager) BuildTeamAssignments() (map[string][]UserAssignment, error) {
 		return nil, nil
 	}
 
-	// Track final assignment per user (last-team-wins).
-	userFinal := make(map[string]UserAssignment) // username -> assignment
+	m.disambiguateAutoNames(allTeams)
 
-	// Track multi-team users for conflict reporting.
-	userTeamMap := make(map[string][]string) // username -> list of team keys
+	// Every team assignment of each user; the conflict strategy picks one.
+	candidates := make(map[string][]UserAssignment) // username -> per team
+	teamSize := make(map[string]int)                // team key -> members
 
-	for orgOrEnterprise, teams := range allTeams {
+	sources := make([]string, 0, len(allTeams))
+	for orgOrEnterprise := range allTeams {
+		sources = append(sources, orgOrEnterprise)
+	}
+	sort.Strings(sources)
+
+	for _, orgOrEnterprise := range sources {
+		teams := allTeams[orgOrEnterprise]
 		sourceLabel := "organization"
 		if m.scope == "enterprise" {
 			sourceLabel = "enterprise"
@@ -260,7 +469,7 @@ func (m *Manager) BuildTeamAssignments() (map[string][]UserAssignment, error) {
 				continue
 			}
 
-			members, err := m.fetchTeamMembers(orgOrEnterprise, team.Slug)
+			members, err := m.fetchTeamMembers(ctx, orgOrEnterprise, team)
 			if err != nil {
 				return nil, err
 			}
@@ -277,15 +486,14 @@ func (m *Manager) BuildTeamAssignments() (map[string][]UserAssignment, error) {
 				teamKey = orgOrEnterprise + "/" + team.Slug
 			}
 
+			teamSize[teamKey] = len(members)
 			for _, username := range members {
-				userTeamMap[username] = append(userTeamMap[username], teamKey)
-				// Last-team-wins: overwrite any previous assignment.
-				userFinal[username] = UserAssignment{
+				candidates[username] = append(candidates[username], UserAssignment{
 					Username:   username,
-					CostCenter: ccName,
+					CostCenter: m.costCenterForMember(teamKey, ccName, username),
 					Org:        orgOrEnterprise,
 					TeamSlug:   team.Slug,
-				}
+				})
 			}
 
 			m.log.Info("Team assignment",
@@ -296,6 +504,22 @@ func (m *Manager) BuildTeamAssignments() (map[string][]UserAssignment, error) {
 		}
 	}
 
+	userFinal := make(map[string]UserAssignment) // username -> assignment
+	userTeamMap := make(map[string][]string)     // username -> list of team keys
+	userTeamCCs := make(map[string][]string)     // username -> cost center per team
+	for username, cands := range candidates {
+		ranked := m.rankTeams(cands, teamSize)
+		userFinal[username] = ranked[0]
+		for _, ua := range ranked {
+			userTeamMap[username] = append(userTeamMap[username], m.teamKey(ua.Org, github.Team{Slug: ua.TeamSlug}))
+			userTeamCCs[username] = append(userTeamCCs[username], ua.CostCenter)
+		}
+	}
+
+	if err := m.settleConflicts(userTeamMap, userTeamCCs, userFinal); err != nil {
+		return nil, err
+	}
+
 	// Report multi-team users.
 	var multiTeamUsers []string
 	for user, teams := range userTeamMap {
@@ -305,8 +529,8 @@ func (m *Manager) BuildTeamAssignments() (map[string][]UserAssignment, error) {
 	}
 	if len(multiTeamUsers) > 0 {
 		sort.Strings(multiTeamUsers)
-		m.log.Warn("Users in multiple teams (last-team-wins)",
-			"count", len(multiTeamUsers))
+		m.log.Warn("Users in multiple teams",
+			"count", len(multiTeamUsers), "conflict_strategy", m.conflictStrategy)
 		limit := 10
 		if len(multiTeamUsers) < limit {
 			limit = len(multiTeamUsers)
@@ -323,6 +547,8 @@ func (m *Manager) BuildTeamAssignments() (map[string][]UserAssignment, error) {
 		}
 	}
 
+	m.recordMemberships()
+
 	// Convert to costCenter -> []UserAssignment.
 	assignments := make(map[string][]UserAssignment)
 	for _, ua := range userFinal {
@@ -342,20 +568,21 @@ func (m *Manager) BuildTeamAssignments() (map[string][]UserAssignment, error) {
 // is aborted if any name cannot be resolved.
 //
 // Returns a map of ccName -> ccID and a set of newly-created cost center IDs.
-func (m *Manager) EnsureCostCentersExist(ccNames []string) (map[string]string, map[string]bool, error) {
+func (m *Manager) EnsureCostCentersExist(ctx context.Context, ccNames []string) (map[string]string, map[string]bool, error) {
 	if !m.autoCreate {
-		return m.resolveCostCenters(ccNames)
+		return m.resolveCostCenters(ctx, ccNames)
 	}
 
 	m.log.Info("Ensuring cost centers exist", "count", len(ccNames))
 
 	// Preload active cost centers for performance.
-	activeMap, err := m.client.GetAllActiveCostCenters()
+	activeMap, err := m.client.GetAllActiveCostCenters(ctx)
 	if err != nil {
 		m.log.Warn("Failed to preload cost centers, falling back to individual creation", "error", err)
 		activeMap = make(map[string]string)
 	} else {
 		m.log.Info("Preloaded active cost centers", "count", len(activeMap))
+		m.renameTeamCostCenters(ctx, activeMap)
 	}
 
 	ccMap := make(map[string]string, len(ccNames))
@@ -381,7 +608,7 @@ func (m *Manager) EnsureCostCentersExist(ccNames []string) (map[string]string, m
 
 		// Need to create.
 		apiCalls++
-		id, err := m.client.CreateCostCenterWithPreload(name, activeMap)
+		id, err := m.client.CreateCostCenterWithPreload(ctx, name, activeMap)
 		if err != nil {
 			m.log.Error("Failed to create/find cost center", "name", name, "error", err)
 			m.log.Warn("Falling back to cost center name as ID — this may cause downstream failures", "name", name)
@@ -414,10 +641,10 @@ func (m *Manager) EnsureCostCentersExist(ccNames []string) (map[string]string, m
 // resolveCostCenters resolves cost center names to UUIDs without creating
 // any new cost centers.  This is used when auto-create is disabled.
 // All names must resolve or the method returns an error listing the failures.
-func (m *Manager) resolveCostCenters(ccNames []string) (map[string]string, map[string]bool, error) {
+func (m *Manager) resolveCostCenters(ctx context.Context, ccNames []string) (map[string]string, map[string]bool, error) {
 	m.log.Info("Auto-creation disabled, resolving cost center names to IDs", "count", len(ccNames))
 
-	activeMap, err := m.client.GetAllActiveCostCenters()
+	activeMap, err := m.client.GetAllActiveCostCenters(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("fetching active cost centers for resolution: %w", err)
 	}
@@ -459,9 +686,10 @@ func (m *Manager) resolveCostCenters(ccNames []string) (map[string]string, map[s
 
 // SyncTeamAssignments is the main orchestration function.  In plan mode it
 // previews changes; in apply mode it pushes assignments to GitHub Enterprise
-// and optionally removes users who left teams.
-func (m *Manager) SyncTeamAssignments(mode string, ignoreCurrentCC bool) (map[string]map[string]bool, error) {
-	assignments, err := m.BuildTeamAssignments()
+// and optionally removes users who left teams.  The apply results, removals
+// included, name each cost center.
+func (m *Manager) SyncTeamAssignments(ctx context.Context, mode string, ignoreCurrentCC bool) ([]github.CostCenterResult, error) {
+	assignments, err := m.BuildTeamAssignments(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -480,10 +708,11 @@ func (m *Manager) SyncTeamAssignments(mode string, ignoreCurrentCC bool) (map[st
 	// Ensure cost centers exist.
 	var ccMap map[string]string
 	var newlyCreated map[string]bool
+	var deletedTeams []deletedTeamCostCenter
 
 	if mode == "plan" {
 		// In plan mode, still resolve names to verify they exist.
-		ccMap, _, err = m.resolveCostCenters(ccNames)
+		ccMap, _, err = m.resolveCostCenters(ctx, ccNames)
 		if err != nil {
 			// In plan mode, log warning instead of failing — names may not
 			// exist yet if auto-create would be used in apply mode.
@@ -500,18 +729,32 @@ func (m *Manager) SyncTeamAssignments(mode string, ignoreCurrentCC bool) (map[st
 		}
 		newlyCreated = make(map[string]bool)
 		m.log.Info("Plan mode: verified cost centers", "count", len(ccNames))
+		if m.autoCreate {
+			m.logTeamRenames(ctx)
+		}
+		m.logDeletedTeams(m.findDeletedTeamCostCenters(ctx))
 	} else {
-		ccMap, newlyCreated, err = m.EnsureCostCentersExist(ccNames)
+		ccMap, newlyCreated, err = m.EnsureCostCentersExist(ctx, ccNames)
 		if err != nil {
 			return nil, fmt.Errorf("ensuring cost centers exist: %w", err)
 		}
+		m.recordCostCenterNames()
+		deletedTeams = m.findDeletedTeamCostCenters(ctx)
+		m.recordTeamCostCenters(ccMap, deletedTeams)
 
 		// Create budgets for newly-created cost centers.
 		if m.createBudgets && len(newlyCreated) > 0 {
-			if err := m.createBudgetsForNewCCs(ccMap, newlyCreated); err != nil {
+			if err := m.createBudgetsForNewCCs(ctx, ccMap, newlyCreated); err != nil {
 				return nil, fmt.Errorf("creating budgets: %w", err)
 			}
 		}
+
+		// Bring drifted budget amounts on existing cost centers back in line.
+		if m.createBudgets {
+			if err := m.reconcileExistingBudgets(ctx, ccMap, newlyCreated); err != nil {
+				return nil, fmt.Errorf("updating budgets: %w", err)
+			}
+		}
 	}
 
 	// Convert assignments to use actual cost center IDs and deduplicate.
@@ -549,25 +792,23 @@ func (m *Manager) SyncTeamAssignments(mode string, ignoreCurrentCC bool) (map[st
 
 	// Apply mode: sync assignments.
 	m.log.Info("Syncing team-based assignments to GitHub Enterprise...")
-	results, err := m.client.BulkUpdateCostCenterAssignments(idBased, ignoreCurrentCC)
+	results, err := m.client.BulkUpdateCostCenterAssignments(ctx, idBased, ignoreCurrentCC)
 	if err != nil {
 		return nil, fmt.Errorf("applying team assignments: %w", err)
 	}
 
 	// Handle user removal.
 	m.log.Info("Checking for users no longer in teams...")
-	removedResults := m.handleUserRemoval(idBased, ccMap, newlyCreated)
+	removedResults := m.handleUserRemoval(ctx, idBased, ccMap, newlyCreated)
+	m.handleDeletedTeams(ctx, deletedTeams)
 
 	// Merge removal results.
-	if m.removeUsers {
-		for ccID, userResults := range removedResults {
-			if _, ok := results[ccID]; !ok {
-				results[ccID] = make(map[string]bool)
-			}
-			for user, ok := range userResults {
-				results[ccID][user] = ok
-			}
+	if m.removeUsers && len(removedResults) > 0 {
+		idToName := make(map[string]string, len(ccMap))
+		for name, id := range ccMap {
+			idToName[id] = name
 		}
+		results = github.MergeResults(results, github.ResultsByCostCenter(removedResults, idToName))
 	}
 
 	return results, nil
@@ -577,6 +818,7 @@ func (m *Manager) SyncTeamAssignments(mode string, ignoreCurrentCC bool) (map[st
 // center but no longer in the corresponding team.  Newly-created cost centers
 // are skipped as an optimisation -- they cannot have stale members.
 func (m *Manager) handleUserRemoval(
+	ctx context.Context,
 	expectedAssignments map[string][]string,
 	ccNameToID map[string]string,
 	newlyCreated map[string]bool,
@@ -611,7 +853,7 @@ func (m *Manager) handleUserRemoval(
 	totalRemoved := 0
 
 	for ccID, expectedUsers := range toCheck {
-		currentMembers, err := m.client.GetCostCenterMembers(ccID)
+		currentMembers, err := m.client.GetCostCenterMembers(ctx, ccID)
 		if err != nil {
 			displayName := idToName[ccID]
 			if displayName == "" {
@@ -661,7 +903,7 @@ func (m *Manager) handleUserRemoval(
 			m.log.Info("Removing users no longer in team",
 				"cost_center", displayName,
 				"count", len(stale))
-			removalStatus, err := m.client.RemoveUsersFromCostCenter(ccID, stale)
+			removalStatus, err := m.client.RemoveUsersFromCostCenter(ctx, ccID, stale)
 			if err != nil {
 				m.log.Error("Failed to remove users", "cost_center", displayName, "error", err)
 			}
@@ -696,8 +938,8 @@ func (m *Manager) handleUserRemoval(
 }
 
 // GenerateSummary builds and returns a teams-aware summary report.
-func (m *Manager) GenerateSummary() (*Summary, error) {
-	assignments, err := m.BuildTeamAssignments()
+func (m *Manager) GenerateSummary(ctx context.Context) (*Summary, error) {
+	assignments, err := m.BuildTeamAssignments(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -770,9 +1012,64 @@ func (s *Summary) Print(enterprise string) {
 	}
 }
 
+// BudgetProductsFor returns the product budgets of a cost center, with the
+// budgets.overrides entry for its name or, failing that, for one of the
+// teams mapped to it applied.
+func (m *Manager) BudgetProductsFor(ccName string) map[string]config.ProductBudget {
+	if m.cfg == nil || len(m.cfg.BudgetOverrides) == 0 {
+		return m.budgetProducts
+	}
+	keys := []string{ccName}
+	var teamKeys []string
+	for key, name := range m.ccNameCache {
+		if name == ccName {
+			teamKeys = append(teamKeys, key)
+		}
+	}
+	sort.Strings(teamKeys)
+	o, ok := m.cfg.BudgetOverrideFor(append(keys, teamKeys...)...)
+	if !ok {
+		return m.budgetProducts
+	}
+	return config.WithBudgetOverride(m.budgetProducts, o)
+}
+
+// reconcileExistingBudgets updates budgets on cost centers that already
+// existed before this run whose amount differs from the configured one.
+// Missing budgets on existing cost centers are left alone.
+func (m *Manager) reconcileExistingBudgets(ctx context.Context, ccMap map[string]string, newlyCreated map[string]bool) error {
+	if len(m.budgetProducts) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(ccMap))
+	for name := range ccMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	reconciler := budgets.NewReconciler(m.client, m.log)
+	var failures []string
+	for _, name := range names {
+		ccID := ccMap[name]
+		if newlyCreated[ccID] {
+			continue
+		}
+		if err := reconciler.Reconcile(ctx, ccID, name, m.BudgetProductsFor(name)); err != nil {
+			m.log.Error("Failed to update budgets", "cost_center", name, "error", err)
+			failures = append(failures, err.Error())
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("budget update failures: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
 // createBudgetsForNewCCs creates configured budgets for each newly-created
 // cost center.  Stops attempting if the budgets API is unavailable (404).
-func (m *Manager) createBudgetsForNewCCs(ccMap map[string]string, newlyCreated map[string]bool) error {
+func (m *Manager) createBudgetsForNewCCs(ctx context.Context, ccMap map[string]string, newlyCreated map[string]bool) error {
 	if len(m.budgetProducts) == 0 {
 		m.log.Debug("No budget products configured, skipping budget creation")
 		return nil
@@ -799,27 +1096,22 @@ func (m *Manager) createBudgetsForNewCCs(ccMap map[string]string, newlyCreated m
 		}
 
 		m.log.Info("Creating budgets for cost center", "name", ccName)
-		for product, pc := range m.budgetProducts {
-			if !pc.Enabled {
-				continue
-			}
-			ok, err := m.client.CreateProductBudget(ccID, ccName, product, pc.Amount)
-			if err != nil {
-				if _, is404 := err.(*github.BudgetsAPIUnavailableError); is404 {
-					m.log.Warn("Budgets API unavailable, disabling further attempts",
-						"error", err)
-					budgetsDisabled = true
-					break
-				}
-				m.log.Error("Failed to create budget",
-					"product", product, "cost_center", ccName, "error", err)
-				failures = append(failures, fmt.Sprintf("%s/%s: %v", ccName, product, err))
-				continue
-			}
-			if ok {
-				m.log.Info("Budget created",
-					"product", product, "cost_center", ccName, "amount", pc.Amount)
+		products := m.BudgetProductsFor(ccName)
+		written, err := m.client.EnsureProductBudgets(ctx, ccID, ccName, products)
+		for _, product := range written {
+			m.log.Info("Budget created",
+				"product", product, "cost_center", ccName, "amount", products[product].Amount)
+		}
+		if err != nil {
+			var unavailable *github.BudgetsAPIUnavailableError
+			if errors.As(err, &unavailable) {
+				m.log.Warn("Budgets API unavailable, disabling further attempts",
+					"error", err)
+				budgetsDisabled = true
+				break
 			}
+			m.log.Error("Failed to create budgets", "cost_center", ccName, "error", err)
+			failures = append(failures, err.Error())
 		}
 	}
 
@@ -6,11 +6,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/renan-alm/gh-cost-center/internal/config"
 	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/github/githubtest"
 )
 
 // newTestManager builds a Manager with the given overrides and a discarding logger.
@@ -68,6 +72,26 @@ func TestCostCenterForTeam_AutoEnterprise(t *testing.T) {
 	}
 }
 
+func TestCostCenterForTeam_NameTemplate(t *testing.T) {
+	for _, tc := range []struct {
+		scope, source, tmpl, want string
+	}{
+		{"organization", "my-org", "CC-{{.Org}}-{{.TeamSlug | upper}}", "CC-my-org-BACKEND-TEAM"},
+		{"enterprise", "test-enterprise", "{{.Enterprise}} {{.TeamName | replace \" \" \"_\"}}", "test-enterprise Backend_Team"},
+	} {
+		mgr := newTestManager(tc.scope, "auto", []string{"my-org"}, nil, false, false)
+		tmpl, err := config.ParseTeamNameTemplate(tc.tmpl)
+		if err != nil {
+			t.Fatalf("ParseTeamNameTemplate(%q): %v", tc.tmpl, err)
+		}
+		mgr.nameTemplate = tmpl
+		cc, ok := mgr.costCenterForTeam(tc.source, github.Team{Name: "Backend Team", Slug: "backend-team"})
+		if !ok || cc != tc.want {
+			t.Errorf("%s: got %q, %v; want %q", tc.scope, cc, ok, tc.want)
+		}
+	}
+}
+
 func TestCostCenterForTeam_ManualHit(t *testing.T) {
 	mappings := map[string]string{
 		"my-org/devs": "Engineering CC",
@@ -126,64 +150,112 @@ func TestBuildTeamAssignments_NoTeams(t *testing.T) {
 	// member cache interaction work correctly with unit-level tests.
 }
 
-func TestBuildTeamAssignments_LastTeamWins(t *testing.T) {
-	mgr := newTestManager("organization", "auto", []string{"org1"}, nil, false, false)
+func TestRankTeams(t *testing.T) {
+	cands := []UserAssignment{
+		{Username: "bob", CostCenter: "CC B", Org: "org1", TeamSlug: "team-b"},
+		{Username: "bob", CostCenter: "CC A", Org: "org1", TeamSlug: "team-a"},
+		{Username: "bob", CostCenter: "CC C", Org: "org1", TeamSlug: "team-c"},
+	}
+	size := map[string]int{"org1/team-a": 5, "org1/team-b": 2, "org1/team-c": 9}
+
+	for _, tc := range []struct {
+		strategy string
+		priority []string
+		want     string
+	}{
+		{config.ConflictAlphabetical, nil, "team-a"},
+		{config.ConflictFail, nil, "team-a"},
+		{config.ConflictPriority, []string{"ORG1/team-c", "org1/team-b"}, "team-c"},
+		{config.ConflictPriority, []string{"org1/team-x"}, "team-a"},
+		{config.ConflictSmallest, nil, "team-b"},
+		{config.ConflictLargest, nil, "team-c"},
+	} {
+		mgr := newTestManager("organization", "auto", []string{"org1"}, nil, false, false)
+		mgr.conflictStrategy = tc.strategy
+		mgr.priority = priorityRanks(tc.priority)
+		// Every order of the candidates gives the same winner.
+		for i := range cands {
+			rotated := append(slices.Clone(cands[i:]), cands[:i]...)
+			if got := mgr.rankTeams(rotated, size)[0].TeamSlug; got != tc.want {
+				t.Errorf("%s %v (rotation %d): winner = %s, want %s", tc.strategy, tc.priority, i, got, tc.want)
+			}
+		}
+	}
+}
 
-	// Pre-populate caches to simulate fetched data.
-	mgr.teamsCache["org1"] = []github.Team{
-		{Name: "team-a", Slug: "team-a"},
-		{Name: "team-b", Slug: "team-b"},
+func TestBuildTeamAssignments_IncludeExclude(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.AddOrgTeam("my-org", githubtest.Team{Name: "dept-eng", Slug: "dept-eng", Members: []string{"alice"}})
+	srv.AddOrgTeam("my-org", githubtest.Team{Name: "dept-chess-interest-group", Slug: "dept-chess-interest-group", Members: []string{"bob"}})
+	srv.AddOrgTeam("my-org", githubtest.Team{Name: "hobby", Slug: "hobby", Members: []string{"carol"}})
+	client := newTestClientFromURL(t, srv.URL)
+	cfg := &config.Manager{
+		Enterprise:    githubtest.DefaultEnterprise,
+		Organizations: []string{"my-org"},
+		TeamsScope:    "organization",
+		TeamsStrategy: "auto",
+		TeamsInclude:  []string{"DEPT-*"},
+		TeamsExclude:  []string{"my-org/*-interest-group"},
 	}
-	mgr.membersCache["org1/team-a"] = []string{"alice", "bob"}
-	mgr.membersCache["org1/team-b"] = []string{"bob", "carol"}
 
-	// Simulate BuildTeamAssignments logic manually since it calls fetchAllTeams.
-	userFinal := make(map[string]UserAssignment)
-	userTeamMap := make(map[string][]string)
+	assignments, err := NewManager(cfg, client, testLogger()).BuildTeamAssignments(t.Context())
+	if err != nil {
+		t.Fatalf("BuildTeamAssignments: %v", err)
+	}
+	if len(assignments) != 1 || len(assignments["[org team] my-org/dept-eng"]) != 1 {
+		t.Errorf("assignments = %v, want only dept-eng", assignments)
+	}
+}
 
-	for _, team := range mgr.teamsCache["org1"] {
-		ccName, ok := mgr.costCenterForTeam("org1", team)
-		if !ok {
-			continue
-		}
-		cacheKey := "org1/" + team.Slug
-		members := mgr.membersCache[cacheKey]
-		for _, username := range members {
-			userTeamMap[username] = append(userTeamMap[username], cacheKey)
-			userFinal[username] = UserAssignment{
-				Username:   username,
-				CostCenter: ccName,
-				Org:        "org1",
-				TeamSlug:   team.Slug,
-			}
-		}
+func TestMemberTeams(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.AddOrgTeam("my-org", githubtest.Team{Name: "web", Slug: "web", Members: []string{"Alice", "bob"}})
+	srv.AddOrgTeam("my-org", githubtest.Team{Name: "api", Slug: "api", Members: []string{"alice"}})
+	srv.AddOrgTeam("my-org", githubtest.Team{Name: "hobby", Slug: "hobby", Members: []string{"carol"}})
+	client := newTestClientFromURL(t, srv.URL)
+	cfg := &config.Manager{
+		Enterprise:    githubtest.DefaultEnterprise,
+		Organizations: []string{"my-org"},
+		TeamsScope:    "organization",
+		TeamsExclude:  []string{"my-org/hobby"},
 	}
 
-	// bob was in both teams, last-team-wins.
-	bobAssign := userFinal["bob"]
-	if bobAssign.CostCenter == "" {
-		t.Fatal("bob should have an assignment")
+	got, err := NewManager(cfg, client, testLogger()).MemberTeams(t.Context())
+	if err != nil {
+		t.Fatalf("MemberTeams: %v", err)
 	}
-	// bob should be in team-b (last iterated).
-	if bobAssign.TeamSlug != "team-b" {
-		t.Logf("bob assigned to %s (last-team-wins is non-deterministic with maps)", bobAssign.TeamSlug)
+	want := map[string][]string{"alice": {"my-org/api", "my-org/web"}, "bob": {"my-org/web"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MemberTeams = %v, want %v", got, want)
 	}
+}
 
-	// alice should be in team-a.
-	aliceAssign := userFinal["alice"]
-	if aliceAssign.CostCenter != "[org team] org1/team-a" {
-		t.Errorf("alice: got %q, want %q", aliceAssign.CostCenter, "[org team] org1/team-a")
+func TestBuildTeamAssignments_ConflictFail(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.AddOrgTeam("my-org", githubtest.Team{Name: "backend", Slug: "backend", Members: []string{"alice", "bob"}})
+	srv.AddOrgTeam("my-org", githubtest.Team{Name: "frontend", Slug: "frontend", Members: []string{"alice"}})
+	client := newTestClientFromURL(t, srv.URL)
+	cfg := &config.Manager{
+		Enterprise:            githubtest.DefaultEnterprise,
+		Organizations:         []string{"my-org"},
+		TeamsScope:            "organization",
+		TeamsStrategy:         "auto",
+		TeamsConflictStrategy: config.ConflictFail,
 	}
 
-	// carol should be in team-b.
-	carolAssign := userFinal["carol"]
-	if carolAssign.CostCenter != "[org team] org1/team-b" {
-		t.Errorf("carol: got %q, want %q", carolAssign.CostCenter, "[org team] org1/team-b")
+	_, err := NewManager(cfg, client, testLogger()).BuildTeamAssignments(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "alice (my-org/backend, my-org/frontend)") {
+		t.Fatalf("err = %v, want alice's conflict reported", err)
 	}
 
-	// bob is a multi-team user.
-	if len(userTeamMap["bob"]) != 2 {
-		t.Errorf("bob should be in 2 teams, got %d", len(userTeamMap["bob"]))
+	// An override settles the conflict.
+	cfg.Overrides = map[string]string{"alice": "[org team] my-org/frontend"}
+	assignments, err := NewManager(cfg, client, testLogger()).BuildTeamAssignments(t.Context())
+	if err != nil {
+		t.Fatalf("BuildTeamAssignments with override: %v", err)
+	}
+	if n := len(assignments["[org team] my-org/frontend"]); n != 1 {
+		t.Errorf("frontend has %d users, want alice", n)
 	}
 }
 
@@ -206,7 +278,7 @@ func TestEnsureCostCentersExist_AutoCreateDisabled(t *testing.T) {
 	mgr := newTestManager("organization", "auto", nil, nil, false, false)
 	mgr.client = client
 
-	ccMap, newlyCreated, err := mgr.EnsureCostCentersExist([]string{"cc-a", "cc-b"})
+	ccMap, newlyCreated, err := mgr.EnsureCostCentersExist(t.Context(), []string{"cc-a", "cc-b"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -285,7 +357,7 @@ func TestFetchTeamMembers_Cache(t *testing.T) {
 	mgr.membersCache["org1/devs"] = []string{"alice", "bob"}
 
 	// Should return cached values without calling client.
-	members, err := mgr.fetchTeamMembers("org1", "devs")
+	members, err := mgr.fetchTeamMembers(t.Context(), "org1", github.Team{Slug: "devs"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -303,7 +375,7 @@ func TestFetchTeamMembers_EnterpriseCacheKey(t *testing.T) {
 	// For enterprise scope, cache key is just the slug.
 	mgr.membersCache["devs"] = []string{"carol"}
 
-	members, err := mgr.fetchTeamMembers("test-enterprise", "devs")
+	members, err := mgr.fetchTeamMembers(t.Context(), "test-enterprise", github.Team{Slug: "devs"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -359,6 +431,7 @@ func TestCreateBudgetsForNewCCs_NoProducts(t *testing.T) {
 	mgr := newTestManagerWithClient(nil, nil)
 	// Empty products should return nil immediately.
 	err := mgr.createBudgetsForNewCCs(
+		t.Context(),
 		map[string]string{"CC A": "cc-id-a"},
 		map[string]bool{"cc-id-a": true},
 	)
@@ -385,6 +458,7 @@ func TestCreateBudgetsForNewCCs_AllSucceed(t *testing.T) {
 	mgr := newTestManagerWithClient(client, products)
 
 	err := mgr.createBudgetsForNewCCs(
+		t.Context(),
 		map[string]string{"CC A": "cc-id-a"},
 		map[string]bool{"cc-id-a": true},
 	)
@@ -412,6 +486,7 @@ func TestCreateBudgetsForNewCCs_PartialFailure(t *testing.T) {
 	mgr := newTestManagerWithClient(client, products)
 
 	err := mgr.createBudgetsForNewCCs(
+		t.Context(),
 		map[string]string{"CC A": "cc-id-a"},
 		map[string]bool{"cc-id-a": true},
 	)
@@ -438,6 +513,7 @@ func TestCreateBudgetsForNewCCs_APIUnavailable(t *testing.T) {
 
 	// 404 triggers BudgetsAPIUnavailableError — should return nil (graceful degradation).
 	err := mgr.createBudgetsForNewCCs(
+		t.Context(),
 		map[string]string{"CC A": "cc-id-a"},
 		map[string]bool{"cc-id-a": true},
 	)
@@ -468,7 +544,7 @@ func TestEnsureCostCentersExist_ResolvesNames(t *testing.T) {
 		map[string]string{"org1/team-a": "CC Alpha"}, false, false)
 	mgr.client = client
 
-	ccMap, newlyCreated, err := mgr.EnsureCostCentersExist([]string{"CC Alpha", "CC Beta"})
+	ccMap, newlyCreated, err := mgr.EnsureCostCentersExist(t.Context(), []string{"CC Alpha", "CC Beta"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -499,7 +575,7 @@ func TestEnsureCostCentersExist_UnresolvedNamesFail(t *testing.T) {
 	mgr := newTestManager("organization", "manual", []string{"org1"}, nil, false, false)
 	mgr.client = client
 
-	_, _, err := mgr.EnsureCostCentersExist([]string{"CC Alpha", "CC Missing", "CC Also Missing"})
+	_, _, err := mgr.EnsureCostCentersExist(t.Context(), []string{"CC Alpha", "CC Missing", "CC Also Missing"})
 	if err == nil {
 		t.Fatal("expected error for unresolved cost centers")
 	}
@@ -531,7 +607,7 @@ func TestEnsureCostCentersExist_SpecialCharsInName(t *testing.T) {
 		map[string]string{"org1/users": "42_Ölbrück-Straße"}, false, false)
 	mgr.client = client
 
-	ccMap, _, err := mgr.EnsureCostCentersExist([]string{"42_Ölbrück-Straße"})
+	ccMap, _, err := mgr.EnsureCostCentersExist(t.Context(), []string{"42_Ölbrück-Straße"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -569,7 +645,7 @@ func TestResolveCostCenters_UUIDPassthrough(t *testing.T) {
 	mgr := newTestManager("organization", "manual", []string{"org1"}, nil, false, false)
 	mgr.client = client
 
-	ccMap, _, err := mgr.EnsureCostCentersExist([]string{knownUUID})
+	ccMap, _, err := mgr.EnsureCostCentersExist(t.Context(), []string{knownUUID})
 	if err != nil {
 		t.Fatalf("UUID mapping value should pass through as cost center ID, got error: %v", err)
 	}
@@ -598,7 +674,7 @@ func TestResolveCostCenters_MixedUUIDAndName(t *testing.T) {
 	mgr := newTestManager("organization", "manual", []string{"org1"}, nil, false, false)
 	mgr.client = client
 
-	ccMap, _, err := mgr.EnsureCostCentersExist([]string{knownUUID, "CC-Named"})
+	ccMap, _, err := mgr.EnsureCostCentersExist(t.Context(), []string{knownUUID, "CC-Named"})
 	if err != nil {
 		t.Fatalf("mixed UUID+name should succeed, got error: %v", err)
 	}
@@ -625,7 +701,7 @@ func TestResolveCostCenters_NameNotFound(t *testing.T) {
 	mgr := newTestManager("organization", "manual", []string{"org1"}, nil, false, false)
 	mgr.client = client
 
-	_, _, err := mgr.EnsureCostCentersExist([]string{"CC-Does-Not-Exist"})
+	_, _, err := mgr.EnsureCostCentersExist(t.Context(), []string{"CC-Does-Not-Exist"})
 	if err == nil {
 		t.Fatal("expected error for unresolvable cost center name")
 	}
@@ -663,7 +739,7 @@ func TestEnsureCostCentersExist_UUIDPassthrough(t *testing.T) {
 	mgr := newTestManager("organization", "manual", []string{"org1"}, nil, true, false)
 	mgr.client = client
 
-	ccMap, _, err := mgr.EnsureCostCentersExist([]string{knownUUID})
+	ccMap, _, err := mgr.EnsureCostCentersExist(t.Context(), []string{knownUUID})
 	if err != nil {
 		t.Fatalf("UUID value should pass through directly, got error: %v", err)
 	}
@@ -674,3 +750,504 @@ func TestEnsureCostCentersExist_UUIDPassthrough(t *testing.T) {
 		t.Errorf("ccMap[uuid]: got %q, want %q (the UUID itself)", ccMap[knownUUID], knownUUID)
 	}
 }
+
+func TestCostCenterForMember_Split(t *testing.T) {
+	mgr := newTestManager("organization", "manual", []string{"org"}, nil, false, false)
+	mgr.splits = map[string]map[string]string{
+		"org/platform": {"contractor": "CC Contractors", "employee": "CC Employees"},
+	}
+	mgr.memberAttrs = map[string]string{
+		"alice": "employee",
+		"bob":   "contractor",
+		"carol": "intern",
+	}
+
+	tests := []struct {
+		teamKey, user, want string
+	}{
+		{"org/platform", "Alice", "CC Employees"},
+		{"org/platform", "bob", "CC Contractors"},
+		{"org/platform", "carol", "CC Platform"}, // attribute not mapped
+		{"org/platform", "dave", "CC Platform"},  // no attribute
+		{"org/other", "bob", "CC Platform"},      // team has no split
+	}
+	for _, tt := range tests {
+		if got := mgr.costCenterForMember(tt.teamKey, "CC Platform", tt.user); got != tt.want {
+			t.Errorf("costCenterForMember(%q, %q) = %q, want %q", tt.teamKey, tt.user, got, tt.want)
+		}
+	}
+}
+
+func TestSyncTeamAssignments_ApplyEndToEnd(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.AddOrgTeam("my-org", githubtest.Team{Name: "backend", Slug: "backend", Members: []string{"alice", "bob"}})
+	srv.AddOrgTeam("my-org", githubtest.Team{Name: "frontend", Slug: "frontend", Members: []string{"carol"}})
+	staleCC := srv.AddCostCenter("[org team] my-org/frontend", "dave")
+
+	client := newTestClientFromURL(t, srv.URL)
+	cfg := &config.Manager{
+		Enterprise:                githubtest.DefaultEnterprise,
+		Organizations:             []string{"my-org"},
+		TeamsScope:                "organization",
+		TeamsStrategy:             "auto",
+		TeamsAutoCreate:           true,
+		TeamsRemoveUnmatchedUsers: true,
+	}
+	mgr := NewManager(cfg, client, testLogger())
+
+	results, err := mgr.SyncTeamAssignments(t.Context(), "apply", true)
+	if err != nil {
+		t.Fatalf("SyncTeamAssignments: %v", err)
+	}
+	if len(results) == 0 {
+		t.Fatal("expected apply results")
+	}
+
+	backend, ok := srv.CostCenterByName("[org team] my-org/backend")
+	if !ok {
+		t.Fatal("expected backend cost center to be created")
+	}
+	if strings.Join(backend.Users, ",") != "alice,bob" {
+		t.Errorf("backend users = %v, want [alice bob]", backend.Users)
+	}
+
+	frontend, _ := srv.CostCenterByName("[org team] my-org/frontend")
+	if frontend.ID != staleCC {
+		t.Errorf("frontend cost center should be reused, got %q want %q", frontend.ID, staleCC)
+	}
+	if strings.Join(frontend.Users, ",") != "carol" {
+		t.Errorf("frontend users = %v, want [carol] (dave removed by full sync)", frontend.Users)
+	}
+}
+
+func TestSyncTeamAssignments_RenamesCostCenterOfRenamedTeam(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.AddOrgTeam("my-org", githubtest.Team{ID: 7, Name: "backend", Slug: "backend", Members: []string{"alice"}})
+	client := newTestClientFromURL(t, srv.URL)
+	cfg := &config.Manager{
+		Enterprise:      githubtest.DefaultEnterprise,
+		Organizations:   []string{"my-org"},
+		TeamsScope:      "organization",
+		TeamsStrategy:   "auto",
+		TeamsAutoCreate: true,
+		ExportDir:       t.TempDir(),
+	}
+	if _, err := NewManager(cfg, client, testLogger()).SyncTeamAssignments(t.Context(), "apply", true); err != nil {
+		t.Fatalf("first apply: %v", err)
+	}
+	before, ok := srv.CostCenterByName("[org team] my-org/backend")
+	if !ok {
+		t.Fatal("expected backend cost center to be created")
+	}
+
+	srv.RenameOrgTeam("my-org", "backend", "platform", "platform")
+	if _, err := NewManager(cfg, client, testLogger()).SyncTeamAssignments(t.Context(), "apply", true); err != nil {
+		t.Fatalf("second apply: %v", err)
+	}
+	after, ok := srv.CostCenterByName("[org team] my-org/platform")
+	if !ok {
+		t.Fatal("expected the cost center to carry the new team name")
+	}
+	if after.ID != before.ID {
+		t.Errorf("cost center ID = %q, want the renamed %q", after.ID, before.ID)
+	}
+	if strings.Join(after.Users, ",") != "alice" {
+		t.Errorf("users = %v, want [alice]", after.Users)
+	}
+	if n := len(srv.CostCenters()); n != 1 {
+		t.Errorf("server has %d cost centers, want 1", n)
+	}
+}
+
+func TestSyncTeamAssignments_DeletedTeams(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.AddOrgTeam("my-org", githubtest.Team{ID: 1, Name: "backend", Slug: "backend", Members: []string{"alice"}})
+	srv.AddOrgTeam("my-org", githubtest.Team{ID: 2, Name: "frontend", Slug: "frontend", Members: []string{"carol"}})
+	srv.AddOrgTeam("my-org", githubtest.Team{ID: 3, Name: "interest", Slug: "interest", Members: []string{"erin"}})
+	srv.AddOrgTeam("my-org", githubtest.Team{ID: 4, Name: "ops", Slug: "ops", Members: []string{"gus"}})
+	legacy := srv.AddCostCenter("[org team] my-org/legacy", "dave")
+	srv.AddCostCenter("[org team] my-org/interest", "erin")
+	srv.AddCostCenter("Finance", "frank")
+	client := newTestClientFromURL(t, srv.URL)
+	cfg := &config.Manager{
+		Enterprise:        githubtest.DefaultEnterprise,
+		Organizations:     []string{"my-org"},
+		TeamsScope:        "organization",
+		TeamsStrategy:     "auto",
+		TeamsAutoCreate:   true,
+		TeamsExclude:      []string{"interest"},
+		TeamsDeletedTeams: config.DeletedTeamsWarn,
+		ExportDir:         t.TempDir(),
+	}
+	if _, err := NewManager(cfg, client, testLogger()).SyncTeamAssignments(t.Context(), "apply", true); err != nil {
+		t.Fatalf("first apply: %v", err)
+	}
+	if _, ok := srv.CostCenterByName("[org team] my-org/legacy"); !ok {
+		t.Fatal("warn must leave the cost center of a deleted team alone")
+	}
+	frontend, _ := srv.CostCenterByName("[org team] my-org/frontend")
+
+	srv.RemoveOrgTeam("my-org", "frontend")
+	srv.RemoveOrgTeam("my-org", "ops")
+	cfg.TeamsDeletedTeams = config.DeletedTeamsDelete
+	// Another mode targets the cost center of ops.
+	cfg.Overrides = map[string]string{"gus": "[org team] my-org/ops"}
+	mgr := NewManager(cfg, client, testLogger())
+	if _, err := mgr.BuildTeamAssignments(t.Context()); err != nil {
+		t.Fatalf("BuildTeamAssignments: %v", err)
+	}
+	if got := mgr.PreviewDeletedTeams(t.Context()); strings.Join(got, ",") != "[org team] my-org/frontend" {
+		t.Errorf("PreviewDeletedTeams = %v, want only the frontend cost center", got)
+	}
+	if _, err := mgr.SyncTeamAssignments(t.Context(), "apply", true); err != nil {
+		t.Fatalf("second apply: %v", err)
+	}
+	states := make(map[string]string)
+	for _, cc := range srv.CostCenters() {
+		states[cc.ID] = cc.State
+	}
+	if states[frontend.ID] != "deleted" {
+		t.Errorf("frontend = %q, want deleted", states[frontend.ID])
+	}
+	// legacy is only named like a team, so it is warned about.
+	if states[legacy] == "deleted" {
+		t.Error("legacy was deleted, want a cost center found by name kept")
+	}
+	for _, name := range []string{"[org team] my-org/backend", "[org team] my-org/interest", "[org team] my-org/ops", "Finance"} {
+		if _, ok := srv.CostCenterByName(name); !ok {
+			t.Errorf("%s was deleted, want it kept", name)
+		}
+	}
+}
+
+func TestDeletedTeamCostCenters_Convention(t *testing.T) {
+	mgr := newTestManager("organization", "auto", []string{"org1"}, nil, false, false)
+	mgr.cfg.ExportDir = t.TempDir()
+	mgr.noteFetchedTeams(map[string][]github.Team{"org1": {{Name: "Data", Slug: "data"}}})
+	mgr.ccNameCache["org1/data"] = "[org team] org1/Data"
+
+	got := mgr.deletedTeamCostCenters(map[string]string{
+		"[org team] org1/Data":     "id-1",
+		"[org team] org1/Data (2)": "id-2", // suffixed name of a listed team
+		"[org team] org1/Gone":     "id-3",
+		"[org team] org1/Gone (2)": "id-4",
+		"[org team] org2/Gone":     "id-5", // not a configured organization
+		"Platform":                 "id-6",
+	})
+	var names []string
+	for _, d := range got {
+		names = append(names, d.name)
+	}
+	if want := "[org team] org1/Gone,[org team] org1/Gone (2)"; strings.Join(names, ",") != want {
+		t.Errorf("deleted team cost centers = %v, want %s", names, want)
+	}
+}
+
+func TestBuildTeamAssignments_ChangedTeamsOnly(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.EnableETags()
+	srv.AddOrgTeam("my-org", githubtest.Team{Name: "backend", Slug: "backend", Members: []string{"alice", "bob"}})
+	srv.AddOrgTeam("my-org", githubtest.Team{Name: "frontend", Slug: "frontend", Members: []string{"carol"}})
+	client := newTestClientFromURL(t, srv.URL)
+	dir := t.TempDir()
+
+	// Each run gets a fresh manager, as separate invocations do, and
+	// returns how the member lists were obtained.
+	run := func() (map[string][]UserAssignment, deltaStats) {
+		t.Helper()
+		cfg := &config.Manager{
+			Enterprise:    githubtest.DefaultEnterprise,
+			Organizations: []string{"my-org"},
+			TeamsScope:    "organization",
+			TeamsStrategy: "auto",
+			ExportDir:     dir,
+		}
+		mgr := NewManager(cfg, client, testLogger())
+		mgr.SetChangedTeamsOnly(true)
+		assignments, err := mgr.BuildTeamAssignments(t.Context())
+		if err != nil {
+			t.Fatalf("BuildTeamAssignments: %v", err)
+		}
+		return assignments, mgr.delta
+	}
+
+	if _, delta := run(); delta.changed != 2 {
+		t.Fatalf("first run delta = %+v, want both teams fetched", delta)
+	}
+
+	// Both listings answer 304 and the recorded members are reused.
+	assignments, delta := run()
+	if delta != (deltaStats{reused: 2}) {
+		t.Errorf("second run delta = %+v, want both teams reused", delta)
+	}
+	if n := len(assignments["[org team] my-org/backend"]); n != 2 {
+		t.Errorf("backend assignments = %d, want 2 from the recorded members", n)
+	}
+
+	srv.SetOrgTeamMembers("my-org", "backend", "alice", "bob", "dave")
+	assignments, delta = run()
+	if delta != (deltaStats{reused: 1, changed: 1}) {
+		t.Errorf("third run delta = %+v, want backend fetched and frontend reused", delta)
+	}
+	if n := len(assignments["[org team] my-org/backend"]); n != 3 {
+		t.Errorf("backend assignments = %d, want 3 after the change", n)
+	}
+}
+
+func TestBuildTeamAssignments_ConflictResolver(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.AddOrgTeam("my-org", githubtest.Team{Name: "backend", Slug: "backend", Members: []string{"alice", "bob"}})
+	srv.AddOrgTeam("my-org", githubtest.Team{Name: "frontend", Slug: "frontend", Members: []string{"alice"}})
+	client := newTestClientFromURL(t, srv.URL)
+	cfg := &config.Manager{
+		Enterprise:    githubtest.DefaultEnterprise,
+		Organizations: []string{"my-org"},
+		TeamsScope:    "organization",
+		TeamsStrategy: "auto",
+		OverridesFile: filepath.Join(t.TempDir(), "overrides.yaml"),
+	}
+
+	var asked []Conflict
+	mgr := NewManager(cfg, client, testLogger())
+	mgr.SetConflictResolver(func(c Conflict) (string, bool, error) {
+		asked = append(asked, c)
+		return c.CostCenters[0], true, nil
+	})
+	for range 2 {
+		assignments, err := mgr.BuildTeamAssignments(t.Context())
+		if err != nil {
+			t.Fatalf("BuildTeamAssignments: %v", err)
+		}
+		if n := len(assignments["[org team] my-org/backend"]); n != 2 {
+			t.Errorf("backend has %d users, want alice and bob", n)
+		}
+	}
+	if len(asked) != 1 || asked[0].User != "alice" || asked[0].Current != "[org team] my-org/backend" {
+		t.Fatalf("asked %+v, want alice once", asked)
+	}
+
+	// The remembered choice settles the next run without asking.
+	next := NewManager(cfg, client, testLogger())
+	next.SetConflictResolver(func(c Conflict) (string, bool, error) {
+		t.Errorf("asked about %s again", c.User)
+		return "", false, nil
+	})
+	assignments, err := next.BuildTeamAssignments(t.Context())
+	if err != nil {
+		t.Fatalf("BuildTeamAssignments: %v", err)
+	}
+	if n := len(assignments["[org team] my-org/backend"]); n != 2 {
+		t.Errorf("backend has %d users on the next run, want 2", n)
+	}
+	data, err := os.ReadFile(cfg.OverridesFile)
+	if err != nil || !strings.Contains(string(data), "alice: '[org team] my-org/backend'") {
+		t.Errorf("overrides file = %q, %v", data, err)
+	}
+}
+
+func TestUnmappedTeams_ManualOrg(t *testing.T) {
+	srv := githubtest.NewServer(t)
+	srv.AddOrgTeam("my-org", githubtest.Team{Name: "Backend", Slug: "backend", Members: []string{"alice", "bob"}})
+	srv.AddOrgTeam("my-org", githubtest.Team{
+		Name: "Data", Slug: "data", Description: "Data platform",
+		Members: []string{"carol", "dave", "erin"}, Maintainers: []string{"dave", "carol"},
+	})
+
+	cfg := &config.Manager{
+		Enterprise:    githubtest.DefaultEnterprise,
+		Organizations: []string{"my-org"},
+		TeamsScope:    "organization",
+		TeamsStrategy: "manual",
+		TeamsMappings: map[string]string{"my-org/backend": "CC Backend"},
+	}
+	mgr := NewManager(cfg, newTestClientFromURL(t, srv.URL), testLogger())
+
+	got, err := mgr.UnmappedTeams(t.Context())
+	if err != nil {
+		t.Fatalf("UnmappedTeams: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d unmapped teams, want 1: %+v", len(got), got)
+	}
+	u := got[0]
+	if u.Key != "my-org/data" || u.Org != "my-org" || u.MemberCount != 3 || u.Description != "Data platform" {
+		t.Errorf("unexpected team: %+v", u)
+	}
+	if strings.Join(u.Maintainers, ",") != "carol,dave" {
+		t.Errorf("maintainers = %v, want [carol dave]", u.Maintainers)
+	}
+
+	var buf strings.Builder
+	if err := WriteUnmappedTeams(&buf, got, "csv"); err != nil {
+		t.Fatalf("WriteUnmappedTeams csv: %v", err)
+	}
+	want := "key,org,slug,name,description,maintainers,member_count\nmy-org/data,my-org,data,Data,Data platform,carol;dave,3\n"
+	if buf.String() != want {
+		t.Errorf("csv =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestUnmappedTeams_RequiresManual(t *testing.T) {
+	mgr := newTestManager("organization", "auto", []string{"my-org"}, nil, false, false)
+	if _, err := mgr.UnmappedTeams(t.Context()); err == nil {
+		t.Fatal("expected error for auto strategy")
+	}
+}
+
+func TestWriteUnmappedTeams_JSONEmpty(t *testing.T) {
+	var buf strings.Builder
+	if err := WriteUnmappedTeams(&buf, nil, "json"); err != nil {
+		t.Fatalf("WriteUnmappedTeams: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("json = %q, want []", buf.String())
+	}
+	if err := WriteUnmappedTeams(&buf, nil, "xml"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestDisambiguateAutoNames_SuffixesCollisions(t *testing.T) {
+	mgr := newTestManager("organization", "auto", []string{"org1"}, nil, false, false)
+	mgr.cfg.ExportDir = t.TempDir()
+
+	allTeams := map[string][]github.Team{
+		"org1": {
+			{Name: "Platform", Slug: "platform"},
+			{Name: "platform", Slug: "platform-legacy"},
+			{Name: "Backend", Slug: "backend"},
+		},
+	}
+	mgr.disambiguateAutoNames(allTeams)
+
+	want := map[string]string{
+		"org1/platform":        "[org team] org1/Platform",
+		"org1/platform-legacy": "[org team] org1/platform (2)",
+		"org1/backend":         "[org team] org1/Backend",
+	}
+	for key, name := range want {
+		if got := mgr.ccNameCache[key]; got != name {
+			t.Errorf("ccNameCache[%q] = %q, want %q", key, got, name)
+		}
+	}
+	if _, ok := mgr.collisionNames["org1/backend"]; ok {
+		t.Error("non-colliding team should not be recorded")
+	}
+}
+
+func TestDisambiguateAutoNames_RecordedSuffixIsStable(t *testing.T) {
+	dir := t.TempDir()
+	first := newTestManager("organization", "auto", []string{"org1"}, nil, false, false)
+	first.cfg.ExportDir = dir
+	first.disambiguateAutoNames(map[string][]github.Team{
+		"org1": {{Name: "Data", Slug: "data-b"}, {Name: "data", Slug: "data-c"}},
+	})
+	first.recordCostCenterNames()
+
+	// A new team that sorts first must not take over an existing cost center.
+	second := newTestManager("organization", "auto", []string{"org1"}, nil, false, false)
+	second.cfg.ExportDir = dir
+	second.disambiguateAutoNames(map[string][]github.Team{
+		"org1": {{Name: "DATA", Slug: "data-a"}, {Name: "Data", Slug: "data-b"}, {Name: "data", Slug: "data-c"}},
+	})
+
+	want := map[string]string{
+		"org1/data-b": "[org team] org1/Data",
+		"org1/data-c": "[org team] org1/data (2)",
+		"org1/data-a": "[org team] org1/DATA (3)",
+	}
+	for key, name := range want {
+		if got := second.ccNameCache[key]; got != name {
+			t.Errorf("ccNameCache[%q] = %q, want %q", key, got, name)
+		}
+	}
+}
+
+func TestResolveScope(t *testing.T) {
+	tests := []struct {
+		name  string
+		teams []githubtest.Team
+		want  string
+	}{
+		{"populated enterprise team", []githubtest.Team{{Name: "empty", Slug: "empty"}, {Name: "eng", Slug: "eng", Members: []string{"alice"}}}, "enterprise"},
+		{"empty enterprise teams", []githubtest.Team{{Name: "empty", Slug: "empty"}}, "organization"},
+		{"no enterprise teams", nil, "organization"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := githubtest.NewServer(t)
+			for _, team := range tt.teams {
+				srv.AddEnterpriseTeam(team)
+			}
+			cfg := &config.Manager{TeamsScope: "auto"}
+			if err := ResolveScope(t.Context(), cfg, newTestClientFromURL(t, srv.URL), testLogger()); err != nil {
+				t.Fatalf("ResolveScope: %v", err)
+			}
+			if cfg.TeamsScope != tt.want {
+				t.Errorf("scope = %q, want %q", cfg.TeamsScope, tt.want)
+			}
+		})
+	}
+
+	t.Run("enterprise teams forbidden", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, `{"message":"Resource not accessible"}`, http.StatusForbidden)
+		}))
+		defer srv.Close()
+		cfg := &config.Manager{TeamsScope: "auto"}
+		if err := ResolveScope(t.Context(), cfg, newTestClientFromURL(t, srv.URL), testLogger()); err != nil {
+			t.Fatalf("ResolveScope: %v", err)
+		}
+		if cfg.TeamsScope != "organization" {
+			t.Errorf("scope = %q, want organization", cfg.TeamsScope)
+		}
+	})
+
+	t.Run("other errors returned", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, `{"message":"Validation Failed"}`, http.StatusUnprocessableEntity)
+		}))
+		defer srv.Close()
+		cfg := &config.Manager{TeamsScope: "auto"}
+		if err := ResolveScope(t.Context(), cfg, newTestClientFromURL(t, srv.URL), testLogger()); err == nil {
+			t.Fatal("expected an error")
+		}
+		if cfg.TeamsScope != "auto" {
+			t.Errorf("scope = %q, want auto left unresolved", cfg.TeamsScope)
+		}
+	})
+
+	t.Run("explicit scope kept", func(t *testing.T) {
+		cfg := &config.Manager{TeamsScope: "enterprise"}
+		if err := ResolveScope(t.Context(), cfg, nil, testLogger()); err != nil {
+			t.Fatalf("ResolveScope: %v", err)
+		}
+		if cfg.TeamsScope != "enterprise" {
+			t.Errorf("scope = %q, want enterprise", cfg.TeamsScope)
+		}
+	})
+}
+
+func TestBudgetProductsFor_Overrides(t *testing.T) {
+	mgr := newTestManager("organization", "manual", []string{"acme"}, nil, false, false)
+	mgr.cfg.BudgetOverrides = map[string]map[string]config.BudgetOverride{
+		"acme/platform": {"copilot": {Amount: 5000}},
+		"cc legal":      {"actions": {Amount: 0}},
+	}
+	mgr.SetBudgetConfig(true, map[string]config.ProductBudget{
+		"copilot": {Amount: 200, Enabled: true},
+		"actions": {Amount: 125, Enabled: true},
+	})
+	mgr.ccNameCache["acme/platform"] = "CC Platform"
+	mgr.ccNameCache["acme/web"] = "CC Web"
+
+	if got := mgr.BudgetProductsFor("CC Platform")["copilot"]; got.Amount != 5000 || !got.Enabled {
+		t.Errorf("CC Platform copilot = %+v, want 5000 from the team override", got)
+	}
+	if got := mgr.BudgetProductsFor("CC Legal")["actions"]; got.Enabled {
+		t.Errorf("CC Legal actions = %+v, want it turned off", got)
+	}
+	if got := mgr.BudgetProductsFor("CC Web")["copilot"]; got.Amount != 200 {
+		t.Errorf("CC Web copilot = %+v, want the global 200", got)
+	}
+}
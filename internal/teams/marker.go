@@ -0,0 +1,46 @@
+package teams
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+// markerPattern compiles the regular expression finding "key: value" in a
+// team description.  The key is matched ignoring case and must start the
+// description or follow a space or separator; the value is either quoted
+// or runs up to the next space, comma, semicolon or closing bracket.
+func markerPattern(key string) *regexp.Regexp {
+	if key == "" {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)(?:^|[\s,;(\[])` + regexp.QuoteMeta(key) +
+		`\s*[:=]\s*(?:"([^"]*)"|'([^']*)'|([^\s,;)\]]+))`)
+}
+
+// markedCostCenter returns the cost center (name or ID, "id:" prefixed or
+// not) a team's owners set in its description with
+// cost_center.teams.description_key.
+func (m *Manager) markedCostCenter(team github.Team) (string, bool) {
+	if m.marker == nil {
+		return "", false
+	}
+	match := m.marker.FindStringSubmatch(team.Description)
+	if match == nil {
+		return "", false
+	}
+	for _, v := range match[1:] {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		ref, err := config.CostCenterRef(v)
+		if err != nil {
+			m.log.Warn("Ignoring invalid cost center ID in team description", "team", team.Slug, "error", err)
+			return "", false
+		}
+		return ref, true
+	}
+	return "", false
+}
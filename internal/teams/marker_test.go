@@ -0,0 +1,66 @@
+package teams
+
+import (
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+func TestMarkedCostCenter(t *testing.T) {
+	mgr := newTestManager("organization", "auto", []string{"org1"}, nil, false, false)
+	mgr.marker = markerPattern("cost-center")
+
+	for desc, want := range map[string]string{
+		"cost-center: FIN-1234":                                "FIN-1234",
+		"Payments squad. Cost-Center=FIN-1234; on-call: x":     "FIN-1234",
+		`Billing (cost-center: "Finance Platform")`:            "Finance Platform",
+		"cost-center:3b7c0d1e-0000-4000-8000-000000000000":     "3b7c0d1e-0000-4000-8000-000000000000",
+		"cost-center: id:3B7C0D1E-0000-4000-8000-000000000000": "3b7c0d1e-0000-4000-8000-000000000000",
+		"cost-center: id:FIN-1234":                             "",
+		"no-cost-center: FIN-1":                                "",
+		"Payments squad":                                       "",
+		`cost-center: ""`:                                      "",
+	} {
+		got, ok := mgr.markedCostCenter(github.Team{Description: desc})
+		if got != want || ok != (want != "") {
+			t.Errorf("%q: got %q, %v; want %q", desc, got, ok, want)
+		}
+	}
+
+	mgr.marker = nil
+	if _, ok := mgr.markedCostCenter(github.Team{Description: "cost-center: FIN-1234"}); ok {
+		t.Error("no description_key should read no marker")
+	}
+}
+
+func TestCostCenterForTeam_Marker(t *testing.T) {
+	mappings := map[string]string{"org1/mapped": "CC Mapped"}
+	mgr := newTestManager("organization", "manual", []string{"org1"}, mappings, false, false)
+	mgr.marker = markerPattern("cost-center")
+
+	marked := github.Team{Name: "payments", Slug: "payments", Description: "cost-center: FIN-1234"}
+	if cc, ok := mgr.costCenterForTeam("org1", marked); !ok || cc != "FIN-1234" {
+		t.Errorf("marked team: got %q, %v; want FIN-1234", cc, ok)
+	}
+	mapped := github.Team{Name: "mapped", Slug: "mapped", Description: "cost-center: FIN-9999"}
+	if cc, ok := mgr.costCenterForTeam("org1", mapped); !ok || cc != "CC Mapped" {
+		t.Errorf("mapped team: got %q, %v; want the config mapping to win", cc, ok)
+	}
+	if _, ok := mgr.costCenterForTeam("org1", github.Team{Name: "other", Slug: "other"}); ok {
+		t.Error("unmarked, unmapped team in manual strategy should be skipped")
+	}
+}
+
+func TestDisambiguateAutoNames_MarkedTeamsShare(t *testing.T) {
+	mgr := newTestManager("organization", "auto", []string{"org1"}, nil, false, false)
+	mgr.marker = markerPattern("cost-center")
+	mgr.disambiguateAutoNames(map[string][]github.Team{"org1": {
+		{Name: "a", Slug: "a", Description: "cost-center: FIN-1"},
+		{Name: "b", Slug: "b", Description: "cost-center: FIN-1"},
+	}})
+	for _, slug := range []string{"a", "b"} {
+		if cc, _ := mgr.costCenterForTeam("org1", github.Team{Name: slug, Slug: slug, Description: "cost-center: FIN-1"}); cc != "FIN-1" {
+			t.Errorf("team %s: got %q, want the shared FIN-1", slug, cc)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package teams
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+// teamKey returns the key a team is tracked under: the slug for enterprise
+// teams, "org/slug" for organization teams.
+func (m *Manager) teamKey(orgOrEnterprise string, team github.Team) string {
+	if m.scope == "enterprise" {
+		return team.Slug
+	}
+	return orgOrEnterprise + "/" + team.Slug
+}
+
+// normalizeCCName folds a cost center name for collision checks.  Names that
+// differ only in case or surrounding whitespace are treated as the same cost
+// center.
+func normalizeCCName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// nameSuffix returns n when name is "base (n)" with n >= 2.
+func nameSuffix(name, base string) (int, bool) {
+	rest, ok := strings.CutPrefix(name, base+" (")
+	if !ok {
+		return 0, false
+	}
+	rest, ok = strings.CutSuffix(rest, ")")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(rest)
+	if err != nil || n < 2 {
+		return 0, false
+	}
+	return n, true
+}
+
+// disambiguateAutoNames gives every team in auto strategy its own cost
+// center.  When several teams resolve to the same auto-generated name, the
+// first team (by key) keeps the name and the others get "name (2)",
+// "name (3)", … so their members are not merged silently.  Assignments
+// recorded by earlier runs are honoured first, so a team keeps its suffix
+// even when teams are added or removed.  The resulting collision
+// assignments are kept in m.collisionNames for recordCostCenterNames.
+func (m *Manager) disambiguateAutoNames(allTeams map[string][]github.Team) {
+	if m.mode != "auto" {
+		return
+	}
+
+	recorded, err := m.cfg.TeamCostCenterNames()
+	if err != nil {
+		m.log.Warn("Could not read recorded team cost center names", "error", err)
+		recorded = nil
+	}
+
+	type member struct{ key, base string }
+	groups := make(map[string][]member) // normalized base name -> teams
+	m.autoTeams = make(map[string]string)
+	for source, teams := range allTeams {
+		for _, team := range teams {
+			delete(m.ccNameCache, m.teamKey(source, team)) // recompute the unsuffixed name
+			if _, marked := m.markedCostCenter(team); marked {
+				continue // teams naming the same cost center share it
+			}
+			base, ok := m.costCenterForTeam(source, team)
+			if !ok {
+				continue
+			}
+			norm := normalizeCCName(base)
+			groups[norm] = append(groups[norm], member{key: m.teamKey(source, team), base: base})
+			if team.ID != 0 {
+				m.autoTeams[m.teamIDKey(team)] = m.teamKey(source, team)
+			}
+		}
+	}
+
+	norms := make([]string, 0, len(groups))
+	for norm, members := range groups {
+		sort.Slice(members, func(i, j int) bool { return members[i].key < members[j].key })
+		norms = append(norms, norm)
+	}
+	sort.Strings(norms)
+
+	// Every base name is reserved so a suffixed name never lands on another
+	// team's cost center.
+	taken := make(map[string]bool, len(groups))
+	for _, norm := range norms {
+		taken[norm] = true
+	}
+	claimed := make(map[string]bool)
+	names := make(map[string]string)
+
+	for _, norm := range norms {
+		for _, mem := range groups[norm] {
+			rec, ok := recorded[mem.key]
+			if !ok {
+				continue
+			}
+			if _, suffixed := nameSuffix(rec, mem.base); rec != mem.base && !suffixed {
+				continue // team renamed since the name was recorded
+			}
+			if claimed[normalizeCCName(rec)] {
+				continue
+			}
+			names[mem.key] = rec
+			claimed[normalizeCCName(rec)] = true
+			taken[normalizeCCName(rec)] = true
+		}
+	}
+
+	m.collisionNames = make(map[string]string)
+	for _, norm := range norms {
+		members := groups[norm]
+		for _, mem := range members {
+			name, ok := names[mem.key]
+			if !ok {
+				if !claimed[norm] {
+					name = mem.base
+				} else {
+					for n := 2; ; n++ {
+						name = fmt.Sprintf("%s (%d)", mem.base, n)
+						if !taken[normalizeCCName(name)] {
+							break
+						}
+					}
+					m.log.Warn("Teams resolve to the same cost center name, using a suffixed name",
+						"team", mem.key, "name", mem.base, "suffixed_name", name)
+				}
+				claimed[normalizeCCName(name)] = true
+				taken[normalizeCCName(name)] = true
+			}
+			m.ccNameCache[mem.key] = name
+			if len(members) > 1 || name != mem.base {
+				m.collisionNames[mem.key] = name
+			}
+		}
+	}
+}
+
+// recordCostCenterNames persists the collision assignments made by
+// disambiguateAutoNames so later runs keep the same suffixes.
+func (m *Manager) recordCostCenterNames() {
+	if m.collisionNames == nil {
+		return
+	}
+	if err := m.cfg.RecordTeamCostCenterNames(m.collisionNames); err != nil {
+		m.log.Warn("Could not record team cost center names", "error", err)
+		return
+	}
+	if len(m.collisionNames) > 0 {
+		m.log.Debug("Recorded team cost center names", "count", len(m.collisionNames))
+	}
+}
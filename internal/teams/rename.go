@@ -0,0 +1,118 @@
+package teams
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+// teamIDKey returns the key the cost center of a team is recorded under
+// across renames: the team's ID, which a rename keeps, prefixed by the scope
+// as enterprise and organization teams are numbered separately.
+func (m *Manager) teamIDKey(team github.Team) string {
+	return m.scope + "/" + strconv.FormatInt(team.ID, 10)
+}
+
+// costCenterRename is the cost center of a team renamed since the last
+// apply.
+type costCenterRename struct {
+	team     string // team key
+	from, to string
+}
+
+// teamRenames returns the cost centers of auto strategy teams renamed since
+// the last apply.  A cost center is renamed, rather than a new one created,
+// when the team's recorded cost center is active, its new name is not, and
+// no team of this run uses the old name.
+func (m *Manager) teamRenames(active map[string]string) []costCenterRename {
+	if len(m.autoTeams) == 0 {
+		return nil
+	}
+	recorded, err := m.cfg.TeamIDCostCenterNames()
+	if err != nil {
+		m.log.Warn("Could not read recorded team cost centers, not renaming any", "error", err)
+		return nil
+	}
+	inUse := make(map[string]bool, len(m.ccNameCache))
+	for _, name := range m.ccNameCache {
+		inUse[name] = true
+	}
+
+	var out []costCenterRename
+	for idKey, key := range m.autoTeams {
+		from, ok := recorded[idKey]
+		to := m.ccNameCache[key]
+		if !ok || from == to || inUse[from] {
+			continue
+		}
+		if _, ok := active[from]; !ok {
+			continue
+		}
+		if _, ok := active[to]; ok {
+			continue
+		}
+		out = append(out, costCenterRename{team: key, from: from, to: to})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].team < out[j].team })
+	return out
+}
+
+// renameTeamCostCenters renames the cost centers of renamed teams and
+// updates active to match.  A failed rename is logged and the team gets a
+// new cost center instead.
+func (m *Manager) renameTeamCostCenters(ctx context.Context, active map[string]string) {
+	for _, r := range m.teamRenames(active) {
+		id := active[r.from]
+		if err := m.client.RenameCostCenter(ctx, id, r.from, r.to); err != nil {
+			m.log.Error("Could not rename cost center of renamed team, creating a new one",
+				"team", r.team, "from", r.from, "to", r.to, "error", err)
+			continue
+		}
+		delete(active, r.from)
+		active[r.to] = id
+	}
+}
+
+// logTeamRenames reports the cost centers an apply would rename.
+func (m *Manager) logTeamRenames(ctx context.Context) {
+	if len(m.autoTeams) == 0 {
+		return
+	}
+	active, err := m.client.GetAllActiveCostCenters(ctx)
+	if err != nil {
+		m.log.Warn("Plan mode: could not check for renamed teams", "error", err)
+		return
+	}
+	for _, r := range m.teamRenames(active) {
+		m.log.Info("Plan mode: team renamed, would rename its cost center",
+			"team", r.team, "from", r.from, "to", r.to)
+	}
+}
+
+// recordTeamCostCenters persists the cost center of each auto strategy team
+// by team ID so later runs can follow team renames.  ccMap holds the cost
+// centers resolved by this run (name → ID); teams whose cost center could
+// not be resolved are not recorded.  The records of deleted teams whose
+// cost center is still active are kept, so later runs find it again.
+func (m *Manager) recordTeamCostCenters(ccMap map[string]string, deleted []deletedTeamCostCenter) {
+	if m.mode != "auto" {
+		return
+	}
+	names := make(map[string]string, len(m.autoTeams)+len(deleted))
+	for _, d := range deleted {
+		if d.idKey != "" {
+			names[d.idKey] = d.name
+		}
+	}
+	for idKey, key := range m.autoTeams {
+		name := m.ccNameCache[key]
+		if id, ok := ccMap[name]; ok && id != name {
+			names[idKey] = name
+		}
+	}
+	if err := m.cfg.RecordTeamIDCostCenterNames(names); err != nil {
+		m.log.Warn("Could not record team cost centers", "error", err)
+	}
+}
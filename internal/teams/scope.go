@@ -0,0 +1,66 @@
+package teams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+// ResolveScope replaces the "auto" teams scope in cfg with the scope to
+// use for this run: "enterprise" when the enterprise has teams and at
+// least one of them has members, "organization" otherwise.  The decision
+// and its reason are logged.  Other scopes are left as configured.
+func ResolveScope(ctx context.Context, cfg *config.Manager, client *github.Client, logger *slog.Logger) error {
+	if cfg.TeamsScope != "auto" {
+		return nil
+	}
+	scope, reason, err := detectScope(ctx, client)
+	if err != nil {
+		return fmt.Errorf("resolving automatic teams scope: %w", err)
+	}
+	logger.Info("Resolved automatic teams scope", "scope", scope, "reason", reason)
+	cfg.TeamsScope = scope
+	return nil
+}
+
+// detectScope probes the enterprise teams API.  A token without enterprise
+// team access (403 or 404) falls back to organization scope; other
+// failures, such as a server error or a cancelled run, are returned rather
+// than silently switching scope.
+func detectScope(ctx context.Context, client *github.Client) (scope, reason string, err error) {
+	teams, err := client.GetEnterpriseTeams(ctx)
+	if err != nil {
+		if !noAccess(err) {
+			return "", "", err
+		}
+		return "organization", fmt.Sprintf("enterprise teams unavailable: %v", err), nil
+	}
+	if len(teams) == 0 {
+		return "organization", "enterprise has no teams", nil
+	}
+	for _, t := range teams {
+		members, err := client.GetEnterpriseTeamMembers(ctx, t.Slug)
+		if err != nil {
+			if !noAccess(err) {
+				return "", "", err
+			}
+			return "organization", fmt.Sprintf("enterprise team %s members unavailable: %v", t.Slug, err), nil
+		}
+		if len(members) > 0 {
+			return "enterprise", fmt.Sprintf("enterprise team %s has %d members", t.Slug, len(members)), nil
+		}
+	}
+	return "organization", fmt.Sprintf("none of the %d enterprise teams has members", len(teams)), nil
+}
+
+// noAccess reports whether err is the API refusing or hiding a resource.
+func noAccess(err error) bool {
+	var apiErr *github.APIError
+	return errors.As(err, &apiErr) &&
+		(apiErr.StatusCode == http.StatusForbidden || apiErr.StatusCode == http.StatusNotFound)
+}
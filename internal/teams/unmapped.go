@@ -0,0 +1,117 @@
+package teams
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// UnmappedTeam describes a team that has no entry in team_mappings (manual
+// mode) nor a description marker, with enough metadata for a ticket to be
+// routed to its owners.
+type UnmappedTeam struct {
+	Key         string   `json:"key"` // "org/slug" or enterprise team slug
+	Org         string   `json:"org,omitempty"`
+	Slug        string   `json:"slug"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Maintainers []string `json:"maintainers"`
+	MemberCount int      `json:"member_count"`
+}
+
+// UnmappedTeams returns every team without a manual mapping, sorted by key.
+// Maintainers are only available for organization teams; enterprise teams
+// report an empty list.
+func (m *Manager) UnmappedTeams(ctx context.Context) ([]UnmappedTeam, error) {
+	if m.mode != "manual" {
+		return nil, fmt.Errorf("unmapped teams are only reported in manual strategy (current: %s)", m.mode)
+	}
+
+	allTeams, err := m.fetchAllTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []UnmappedTeam
+	for source, teams := range allTeams {
+		for _, team := range teams {
+			key := team.Slug
+			org := ""
+			if m.scope != "enterprise" {
+				key = source + "/" + team.Slug
+				org = source
+			}
+			if _, ok := m.mappings[key]; ok {
+				continue
+			}
+			if _, ok := m.markedCostCenter(team); ok {
+				continue
+			}
+
+			members, err := m.fetchTeamMembers(ctx, source, team)
+			if err != nil {
+				return nil, err
+			}
+
+			maintainers := []string{}
+			if org != "" {
+				ms, err := m.client.GetOrgTeamMaintainers(ctx, org, team.Slug)
+				if err != nil {
+					m.log.Warn("Could not fetch team maintainers", "team", key, "error", err)
+				}
+				for _, mt := range ms {
+					maintainers = append(maintainers, mt.Login)
+				}
+				sort.Strings(maintainers)
+			}
+
+			out = append(out, UnmappedTeam{
+				Key:         key,
+				Org:         org,
+				Slug:        team.Slug,
+				Name:        team.Name,
+				Description: team.Description,
+				Maintainers: maintainers,
+				MemberCount: len(members),
+			})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+// WriteUnmappedTeams writes teams to w as "json" (an array) or "csv" (one
+// row per team, maintainers joined with ';').
+func WriteUnmappedTeams(w io.Writer, teams []UnmappedTeam, format string) error {
+	switch format {
+	case "json":
+		if teams == nil {
+			teams = []UnmappedTeam{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(teams)
+
+	case "csv":
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"key", "org", "slug", "name", "description", "maintainers", "member_count"})
+		for _, t := range teams {
+			_ = cw.Write([]string{
+				t.Key, t.Org, t.Slug, t.Name, t.Description,
+				strings.Join(t.Maintainers, ";"),
+				strconv.Itoa(t.MemberCount),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+
+	default:
+		return fmt.Errorf("unsupported unmapped-teams format %q: must be 'json' or 'csv'", format)
+	}
+}
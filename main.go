@@ -12,6 +12,7 @@
 package main
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"syscall"
@@ -29,14 +30,15 @@ func main() {
 		os.Exit(0)
 	}()
 
-	// SIGINT (Ctrl-C) — let the Go runtime handle cleanup, but ensure
-	// we exit with a non-zero code so callers can detect interruption.
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt)
+	// SIGINT (Ctrl-C) cancels the context: in-flight requests are aborted,
+	// worker pools stop dispatching, and interrupted writes are journaled
+	// for replay.  A second Ctrl-C falls back to the default handler and
+	// kills the process.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	go func() {
-		<-interrupt
-		os.Exit(130) // 128 + SIGINT(2) — standard Unix convention
+		<-ctx.Done()
+		stop()
 	}()
 
-	cmd.Execute()
+	cmd.Execute(ctx)
 }